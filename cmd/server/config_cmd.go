@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"mcp-tools-server/internal/config"
+)
+
+const configUsage = `Usage:
+  server config genkey
+  server config encrypt
+
+genkey prints a new key for CONFIG_ENCRYPTION_KEY. encrypt reads a value from stdin and
+prints it encrypted with the key from CONFIG_ENCRYPTION_KEY or CONFIG_ENCRYPTION_KEY_FILE,
+ready to use in place of the plaintext setting.
+`
+
+// runConfigCommand implements the "config" subcommand and returns the process exit code.
+func runConfigCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, configUsage)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "genkey":
+		key, err := config.GenerateEncryptionKey()
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to generate key: %v\n", err)
+			return exitToolError
+		}
+		fmt.Fprintln(stdout, key)
+		return exitOK
+	case "encrypt":
+		return encryptConfigValue(stdin, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "Unknown config command: %s\n\n%s", args[0], configUsage)
+		return exitUsage
+	}
+}
+
+// encryptConfigValue encrypts the value read from stdin. A single trailing newline, as
+// added by echo or a here-string, is not part of the value.
+func encryptConfigValue(stdin io.Reader, stdout, stderr io.Writer) int {
+	key, err := config.LoadEncryptionKey()
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid encryption key: %v\n", err)
+		return exitUsage
+	}
+	if key == nil {
+		fmt.Fprintln(stderr, "Set CONFIG_ENCRYPTION_KEY or CONFIG_ENCRYPTION_KEY_FILE")
+		return exitUsage
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read value: %v\n", err)
+		return exitToolError
+	}
+	value := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	encrypted, err := config.EncryptValue(key, value)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to encrypt value: %v\n", err)
+		return exitToolError
+	}
+	fmt.Fprintln(stdout, encrypted)
+	return exitOK
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/internal/config"
+)
+
+func TestRunConfigCommand(t *testing.T) {
+	run := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runConfigCommand(args, strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	code, key, _ := run("", "genkey")
+	if code != exitOK {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	t.Setenv("CONFIG_ENCRYPTION_KEY", strings.TrimSpace(key))
+
+	t.Run("encrypt produces a value the config can decrypt", func(t *testing.T) {
+		code, stdout, stderr := run("redis://:hunter2@redis:6379\n", "encrypt")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		encrypted := strings.TrimSpace(stdout)
+		if !strings.HasPrefix(encrypted, config.EncryptedPrefix) {
+			t.Fatalf("Expected an encrypted value, got %q", encrypted)
+		}
+
+		t.Setenv("REDIS_URL", encrypted)
+		cfg := config.NewServerConfig()
+		if err := cfg.Err(); err != nil || cfg.RedisURL != "redis://:hunter2@redis:6379" {
+			t.Errorf("Expected the decrypted URL, got %q (%v)", cfg.RedisURL, err)
+		}
+	})
+
+	t.Run("unknown command", func(t *testing.T) {
+		if code, _, _ := run("", "decrypt"); code != exitUsage {
+			t.Errorf("Expected exit code 2, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"mcp-tools-server/internal/server"
+	"mcp-tools-server/pkg/conformance"
+)
+
+const conformanceUsage = `Usage:
+  server conformance [-tool NAME] [-args JSON] [-timeout DURATION] [URL...]
+
+Runs the MCP conformance checks against each URL: http:// and https:// URLs are
+Streamable HTTP endpoints, ws:// and wss:// URLs WebSocket endpoints. Without URLs,
+the checks run against an in-process server on stdio, Streamable HTTP, and WebSocket.
+Exits 1 if any check fails.
+`
+
+// runConformanceCommand implements the "conformance" subcommand and returns the process
+// exit code. Results are written to stdout; logs and errors go to stderr.
+func runConformanceCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("conformance", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { fmt.Fprint(stderr, conformanceUsage) }
+	tool := flags.String("tool", "generate_uuid", "Tool called by the tools/call check")
+	rawArgs := flags.String("args", "", "Arguments of that call as a JSON object")
+	timeout := flags.Duration("timeout", 10*time.Second, "Limit on each check")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	opts := conformance.Options{Tool: *tool, Timeout: *timeout}
+	if *rawArgs != "" {
+		if err := json.Unmarshal([]byte(*rawArgs), &opts.Args); err != nil {
+			fmt.Fprintf(stderr, "Arguments must be a JSON object: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	ctx := context.Background()
+	var transports []conformance.Transport
+	if flags.NArg() == 0 {
+		local, stop, code := localTransports(ctx, stderr)
+		if local == nil {
+			return code
+		}
+		defer stop()
+		transports = local
+	}
+	for _, url := range flags.Args() {
+		switch {
+		case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+			transports = append(transports, conformance.NewStreamableTransport(url, nil))
+		case strings.HasPrefix(url, "ws://"), strings.HasPrefix(url, "wss://"):
+			transport, err := conformance.NewWebSocketTransport(ctx, url)
+			if err != nil {
+				fmt.Fprintf(stderr, "Failed to connect: %v\n", err)
+				return exitToolError
+			}
+			defer transport.Close()
+			transports = append(transports, transport)
+		default:
+			fmt.Fprintf(stderr, "Unsupported URL: %s\n\n%s", url, conformanceUsage)
+			return exitUsage
+		}
+	}
+
+	var passed, failed, skipped int
+	for _, transport := range transports {
+		for _, result := range conformance.Run(ctx, transport, opts) {
+			line := fmt.Sprintf("%s\t%s\t%s", result.Status, result.Transport, result.Check)
+			switch result.Status {
+			case conformance.Passed:
+				passed++
+			case conformance.Failed:
+				failed++
+				line += "\t" + result.Error
+			case conformance.Skipped:
+				skipped++
+				line += "\t" + result.Error
+			}
+			fmt.Fprintln(stdout, line)
+		}
+	}
+	fmt.Fprintf(stdout, "%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	if failed > 0 {
+		return exitToolError
+	}
+	return exitOK
+}
+
+// localTransports starts an in-process server with the configured tools on stdio,
+// Streamable HTTP, and WebSocket, and returns a transport to each and a function that
+// stops them. On failure it returns nil and the exit code.
+func localTransports(ctx context.Context, stderr io.Writer) ([]conformance.Transport, func(), int) {
+	toolService, code := newLocalToolService(stderr)
+	if toolService == nil {
+		return nil, nil, code
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var listeners []net.Listener
+	stop := func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}
+	serve := func(handler http.Handler) (string, error) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return "", err
+		}
+		listeners = append(listeners, listener)
+		go http.Serve(listener, handler)
+		return listener.Addr().String(), nil
+	}
+	streamableAddr, err := serve(server.NewStreamableHTTPServer(toolService, server.WithLogger(logger)).Handler())
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to start Streamable HTTP server: %v\n", err)
+		return nil, nil, exitToolError
+	}
+	webSocketAddr, err := serve(server.NewWebSocketServer(toolService, server.WithLogger(logger)).Handler())
+	if err != nil {
+		stop()
+		fmt.Fprintf(stderr, "Failed to start WebSocket server: %v\n", err)
+		return nil, nil, exitToolError
+	}
+
+	webSocket, err := conformance.NewWebSocketTransport(ctx, "ws://"+webSocketAddr+"/ws")
+	if err != nil {
+		stop()
+		fmt.Fprintf(stderr, "Failed to connect: %v\n", err)
+		return nil, nil, exitToolError
+	}
+	stdio, err := conformance.NewStdioTransport(ctx, func(ctx context.Context) (io.WriteCloser, io.Reader, error) {
+		inReader, inWriter := io.Pipe()
+		outReader, outWriter := io.Pipe()
+		mcpServer := server.NewMCPServer(toolService, logger)
+		mcpServer.SetHeartbeat(0)
+		mcpServer.SetIO(inReader, outWriter)
+		go func() {
+			_ = mcpServer.Start(context.Background())
+			outWriter.Close()
+		}()
+		return inWriter, outReader, nil
+	})
+	if err != nil {
+		stop()
+		fmt.Fprintf(stderr, "Failed to start stdio server: %v\n", err)
+		return nil, nil, exitToolError
+	}
+
+	transports := []conformance.Transport{
+		stdio,
+		conformance.NewStreamableTransport("http://"+streamableAddr+"/mcp", nil),
+		webSocket,
+	}
+	return transports, func() {
+		for _, transport := range transports {
+			transport.Close()
+		}
+		stop()
+	}, exitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunConformanceCommand(t *testing.T) {
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := runConformanceCommand(args, &stdout, &stderr)
+		return code, stdout.String()
+	}
+
+	t.Run("in-process server passes", func(t *testing.T) {
+		code, stdout := run()
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d:\n%s", code, stdout)
+		}
+		for _, transport := range []string{"stdio", "streamable", "websocket"} {
+			if !strings.Contains(stdout, "pass\t"+transport+"\ttools/call") {
+				t.Errorf("Expected tools/call to pass over %s, got:\n%s", transport, stdout)
+			}
+		}
+	})
+
+	t.Run("failed checks exit 1", func(t *testing.T) {
+		code, stdout := run("-tool", "no_such_tool")
+		if code != exitToolError {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if !strings.Contains(stdout, "fail\tstdio\ttools/list") {
+			t.Errorf("Expected tools/list to fail, got:\n%s", stdout)
+		}
+	})
+
+	t.Run("usage errors exit 2", func(t *testing.T) {
+		cases := [][]string{
+			{"-args", "not json"},
+			{"ftp://localhost/mcp"},
+		}
+		for _, args := range cases {
+			if code, _ := run(args...); code != exitUsage {
+				t.Errorf("Expected exit code 2 for %v, got %d", args, code)
+			}
+		}
+	})
+}
@@ -8,14 +8,38 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"time"
 
 	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/listener"
+	"mcp-tools-server/internal/logging"
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/internal/policy"
+	"mcp-tools-server/internal/redact"
 	"mcp-tools-server/internal/server"
+	"mcp-tools-server/internal/store"
 	"mcp-tools-server/internal/version"
+	"mcp-tools-server/internal/webhook"
+	"mcp-tools-server/pkg/egress"
+	"mcp-tools-server/pkg/httpclient"
 	"mcp-tools-server/pkg/tools"
 )
 
 func main() {
+	// --- Subcommands ---
+	if len(os.Args) > 1 && os.Args[1] == "tools" {
+		os.Exit(runToolsCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:], os.Stdin, os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplayCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "conformance" {
+		os.Exit(runConformanceCommand(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	// --- Flag Definition ---
 	var (
 		showVersion       = flag.Bool("version", false, "Show version and exit")
@@ -29,6 +53,8 @@ func main() {
 		webSocketPort     = flag.Int("websocket-port", 0, "Port for WebSocket server (overrides env)")
 		enableOriginCheck = flag.Bool("enable-origin-check", false, "Enable origin check for streamable server")
 		allowedOriginsRaw = flag.String("allowed-origins", "", "Comma-separated list of allowed origins (overrides env)")
+		bindAddress       = flag.String("bind-address", "", "Interface all listeners bind to, e.g. 127.0.0.1 (overrides env)")
+		oneShot           = flag.Bool("oneshot", false, "Handle a single JSON-RPC request from stdin, write the response, and exit")
 	)
 	flag.Parse()
 
@@ -51,19 +77,22 @@ func main() {
 	}
 
 	// --- Configuration Loading ---
-	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	slog.SetDefault(logger)
-
 	cfg := config.NewServerConfig()
+	if err := cfg.Err(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	// Override config with flags if they were provided
 	if *httpPort != 0 {
 		cfg.HTTPPort = *httpPort
+		cfg.SetSource("HTTP_PORT", config.SourceFlag)
 	}
 	if *streamablePort != 0 {
 		cfg.StreamableHTTPPort = *streamablePort
+		cfg.SetSource("STREAMABLE_HTTP_PORT", config.SourceFlag)
 	}
 	if *webSocketPort != 0 {
 		cfg.WebSocketPort = *webSocketPort
+		cfg.SetSource("WEBSOCKET_PORT", config.SourceFlag)
 	}
 	// For bool flags, we need to check if the flag was actually set on the command line
 	// to differentiate it from the default `false` value.
@@ -75,18 +104,313 @@ func main() {
 	})
 	if isOriginCheckSet {
 		cfg.EnableOriginCheck = *enableOriginCheck
+		cfg.SetSource("ENABLE_ORIGIN_CHECK", config.SourceFlag)
 	}
 	if *allowedOriginsRaw != "" {
 		cfg.AllowedOrigins = strings.Split(*allowedOriginsRaw, ",")
+		cfg.SetSource("ALLOWED_ORIGINS", config.SourceFlag)
+	}
+	if *bindAddress != "" {
+		cfg.BindAddress = *bindAddress
+		cfg.HTTPBindAddress = *bindAddress
+		cfg.StreamableHTTPBindAddress = *bindAddress
+		cfg.WebSocketBindAddress = *bindAddress
+		for _, name := range []string{"BIND_ADDRESS", "HTTP_BIND_ADDRESS", "STREAMABLE_HTTP_BIND_ADDRESS", "WEBSOCKET_BIND_ADDRESS"} {
+			cfg.SetSource(name, config.SourceFlag)
+		}
+	}
+
+	// --- Redaction ---
+	redactPatterns, err := cfg.RedactPatternMap()
+	if err != nil {
+		log.Fatalf("Invalid redaction configuration: %v", err)
+	}
+	redactRules, err := redact.Rules(cfg.RedactRules, redactPatterns)
+	if err != nil {
+		log.Fatalf("Invalid redaction configuration: %v", err)
+	}
+	redactor := redact.New(redactRules)
+
+	// --- Logging ---
+	// Sessions that ask for logs with logging/setLevel get the records about them
+	sessionLogs := server.NewSessionLogHandler()
+	logger, logLevel, logCloser, err := logging.New(logging.Config{
+		Level:      cfg.LogLevel,
+		Format:     cfg.LogFormat,
+		File:       cfg.LogFile,
+		MaxSizeMB:  cfg.LogMaxSizeMB,
+		MaxBackups: cfg.LogMaxBackups,
+		MaxAgeDays: cfg.LogMaxAgeDays,
+		Stdio:      runMCP || *oneShot,
+		Redactor:   redactor,
+		Tee:        sessionLogs,
+	})
+	if err != nil {
+		log.Fatalf("Invalid logging configuration: %v", err)
 	}
+	defer logCloser.Close()
+	slog.SetDefault(logger)
 
 	// --- Service and Server Initialization ---
+	// Tools build their HTTP clients from these settings, so they are applied first
+	egressPolicy, err := egress.New(egress.Config{
+		Allow:         cfg.EgressAllow,
+		Deny:          cfg.EgressDeny,
+		NoDefaultDeny: !cfg.EgressDefaultDeny,
+		AllowLocal:    cfg.EgressAllowLocal,
+	})
+	if err != nil {
+		log.Fatalf("Invalid egress policy: %v", err)
+	}
+	if err := httpclient.Configure(httpclient.Config{
+		Timeout:   time.Duration(cfg.HTTPClientTimeout) * time.Second,
+		ProxyURL:  cfg.HTTPClientProxy,
+		CAFile:    cfg.HTTPClientCAFile,
+		UserAgent: "mcp-tools-server/" + version.GetVersion(),
+		Policy:    egressPolicy,
+	}); err != nil {
+		log.Fatalf("Invalid HTTP client configuration: %v", err)
+	}
 	registry := tools.NewToolRegistry()
+	if eager := registry.SetLazy(cfg.LazyTools); len(eager) > 0 {
+		logger.Warn("Tools cannot be built lazily; building them at startup", "tools", eager)
+	}
 	toolService, err := server.NewToolService(registry, logger)
 	if err != nil {
 		logger.Error("Failed to create tool service", "error", err)
 		os.Exit(1)
 	}
+	sessionLogs.Attach(toolService.Sessions())
+	if err := addHTTPTools(toolService, cfg, logger); err != nil {
+		logger.Error("Invalid HTTP tool configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := addPipelines(toolService, cfg, logger); err != nil {
+		logger.Error("Invalid pipeline configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := setToolAliases(toolService, cfg); err != nil {
+		logger.Error("Invalid tool alias configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := addSchedules(toolService, cfg); err != nil {
+		logger.Error("Invalid schedule configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := setToolThrottles(toolService, cfg); err != nil {
+		logger.Error("Invalid tool throttle configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := setToolQuotas(toolService, cfg); err != nil {
+		logger.Error("Invalid tool quota configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := setPolicy(toolService, cfg, logger); err != nil {
+		logger.Error("Invalid policy configuration", "error", err)
+		os.Exit(1)
+	}
+
+	sharedStore, err := store.New(cfg.StoreBackend, cfg.RedisURL)
+	if err != nil {
+		logger.Error("Failed to create shared store", "error", err)
+		os.Exit(1)
+	}
+	defer sharedStore.Close()
+	toolService.SetCoordinator(store.NewCoordinator(sharedStore, cfg.InstanceID))
+	if cfg.StoreBackend == "redis" {
+		toolService.Throttle().SetCoordinator(toolService.Coordinator())
+	}
+	toolService.Sessions().SetShared(cfg.SharedSessions)
+	logger.Info("Shared store configured", "backend", cfg.StoreBackend, "instance", cfg.InstanceID, "sharedSessions", cfg.SharedSessions)
+	toolService.SetToolDefaults(cfg.ToolDefaults)
+	toolService.SetStrictOutput(cfg.OutputSchemaStrict)
+	if cfg.ToolSearch {
+		toolService.EnableToolSearch()
+	}
+	if cfg.ServerInfo {
+		toolService.EnableServerInfo()
+	}
+	toolService.SetListPageSize(cfg.ToolsListPageSize)
+	defaultVersions, err := cfg.ToolDefaultVersionMap()
+	if err != nil {
+		logger.Error("Invalid tool version configuration", "error", err)
+		os.Exit(1)
+	}
+	toolService.SetDefaultVersions(defaultVersions)
+	toolService.SetRedactor(redactor)
+	toolService.SetLogSafeMode(cfg.LogSafeMode)
+	if cfg.SessionRecordDir != "" {
+		recorder, err := server.NewSessionRecorder(cfg.SessionRecordDir, redactor, logger)
+		if err != nil {
+			logger.Error("Invalid session recording configuration", "error", err)
+			os.Exit(1)
+		}
+		toolService.SetRecorder(recorder)
+		logger.Warn("Recording MCP sessions; recordings hold tool arguments and results", "dir", cfg.SessionRecordDir)
+	}
+	toolService.Quarantine().SetPolicy(server.QuarantinePolicy{
+		FailureRate: cfg.QuarantineFailureRate,
+		MinCalls:    cfg.QuarantineMinCalls,
+		Window:      time.Duration(cfg.QuarantineWindow) * time.Second,
+		Cooldown:    time.Duration(cfg.QuarantineCooldown) * time.Second,
+	})
+	toolService.Breaker().SetPolicy(server.BreakerPolicy{
+		Failures: cfg.BreakerFailures,
+		Cooldown: time.Duration(cfg.BreakerCooldown) * time.Second,
+	})
+	toolService.Overload().SetPolicy(server.OverloadPolicy{
+		MaxInFlight:    cfg.OverloadMaxInFlight,
+		MaxGoroutines:  cfg.OverloadMaxGoroutines,
+		MaxMemoryBytes: uint64(cfg.OverloadMaxMemoryMB) << 20,
+		RetryAfter:     time.Duration(cfg.OverloadRetryAfter) * time.Second,
+	})
+	toolService.Health().SetInterval(time.Duration(cfg.HealthCheckInterval)*time.Second, time.Duration(cfg.HealthCheckTimeout)*time.Second)
+	toolService.History().SetLimits(cfg.ExecutionHistorySize, cfg.ExecutionHistoryMaxBytes)
+	toolService.Jobs().SetLimits(cfg.JobsMaxRunning, time.Duration(cfg.JobsRetention)*time.Second)
+	toolService.Approvals().SetTools(cfg.ApprovalTools)
+	toolService.Approvals().SetTimeout(time.Duration(cfg.ApprovalTimeout) * time.Second)
+	toolService.SetResultLimit(cfg.MaxResultBytes, time.Duration(cfg.ResultCursorTTL)*time.Second)
+
+	// Webhooks receive the selected server events until shutdown has been announced
+	var notifier *webhook.Notifier
+	var unsubscribeWebhooks func()
+	if len(cfg.WebhookURLs) > 0 {
+		notifier = webhook.New(webhook.Config{
+			URLs:       cfg.WebhookURLs,
+			Secret:     cfg.WebhookSecret,
+			MaxRetries: cfg.WebhookMaxRetries,
+			Timeout:    time.Duration(cfg.WebhookTimeout) * time.Second,
+		}, logger)
+		eventTypes := make([]server.EventType, 0, len(cfg.WebhookEvents))
+		for _, eventType := range cfg.WebhookEvents {
+			eventTypes = append(eventTypes, server.EventType(strings.TrimSpace(eventType)))
+		}
+		unsubscribeWebhooks = toolService.Events().Subscribe(func(event server.Event) {
+			notifier.Send(string(event.Type), event)
+		}, eventTypes...)
+		logger.Info("Webhooks enabled", "urls", len(cfg.WebhookURLs), "events", cfg.WebhookEvents)
+	}
+
+	// --- One-Shot Mode ---
+	if *oneShot {
+		response, err := server.NewMCPServer(toolService, logger).ServeOnce(context.Background())
+		if err != nil {
+			logger.Error("One-shot request failed", "error", err)
+			os.Exit(1)
+		}
+		if response != nil && response.Error != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Options shared by every HTTP-based transport
+	network, err := listener.Network(cfg.IPFamily)
+	if err != nil {
+		logger.Error("Invalid listener configuration", "error", err)
+		os.Exit(1)
+	}
+	trustedProxies, err := server.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		logger.Error("Invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	tenantConfigs, err := cfg.TenantConfigs()
+	if err != nil {
+		logger.Error("Invalid tenant configuration", "error", err)
+		os.Exit(1)
+	}
+	limits := server.Limits{
+		ReadHeaderTimeout: time.Duration(cfg.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(cfg.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(cfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(cfg.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		MaxBodyBytes:      cfg.MaxBodyBytes,
+	}
+	serverOptions := []server.ServerOption{
+		server.WithLogger(logger),
+		server.WithNetwork(network),
+		server.WithTrustedProxies(trustedProxies),
+		server.WithAdminToken(cfg.AdminToken),
+		server.WithLimits(limits),
+		server.WithReusePort(cfg.ReusePort),
+		server.WithKeepAlive(server.KeepAlive{
+			SSEInterval:          time.Duration(cfg.SSEKeepAliveInterval) * time.Second,
+			SSERetry:             time.Duration(cfg.SSERetryMS) * time.Millisecond,
+			WebSocketPing:        time.Duration(cfg.WebSocketPingInterval) * time.Second,
+			WebSocketPongTimeout: time.Duration(cfg.WebSocketPongTimeout) * time.Second,
+		}),
+		server.WithWebSocketResume(time.Duration(cfg.WebSocketResumeTTL) * time.Second),
+	}
+
+	if len(tenantConfigs) > 0 {
+		tenantList := make([]server.Tenant, 0, len(tenantConfigs))
+		for name, tenant := range tenantConfigs {
+			tenantList = append(tenantList, server.Tenant{
+				Name:      name,
+				APIKey:    tenant.APIKey,
+				Tools:     tenant.Tools,
+				RateLimit: tenant.RateLimit,
+				Burst:     tenant.Burst,
+			})
+		}
+		tenants, err := server.NewTenants(tenantList, logger)
+		if err != nil {
+			logger.Error("Invalid tenant configuration", "error", err)
+			os.Exit(1)
+		}
+		if cfg.StoreBackend == "redis" {
+			tenants.SetCoordinator(toolService.Coordinator())
+		}
+		serverOptions = append(serverOptions, server.WithTenants(tenants))
+		toolService.Use(tenants.ToolMiddleware())
+		logger.Info("Tenants enabled", "count", len(tenantList))
+	}
+
+	batchOptions := server.BatchOptions{MaxSize: cfg.BatchMaxSize, Parallel: cfg.BatchParallel}
+	serverOptions = append(serverOptions, server.WithBatch(batchOptions))
+	serverOptions = append(serverOptions, server.WithSSEResume(server.SSEResumeOptions{
+		History: cfg.SSEEventHistory,
+		TTL:     time.Duration(cfg.SSEEventTTL) * time.Second,
+	}))
+
+	if cfg.ChaosEnabled {
+		chaos := server.NewChaos(server.ChaosConfig{
+			LatencyRate: cfg.ChaosLatencyRate,
+			MaxLatency:  time.Duration(cfg.ChaosLatencyMaxMS) * time.Millisecond,
+			ErrorRate:   cfg.ChaosErrorRate,
+			DropRate:    cfg.ChaosSSEDropRate,
+		}, logger)
+		serverOptions = append(serverOptions, server.WithChaos(chaos))
+		toolService.Use(chaos.Middleware())
+		logger.Warn("Chaos mode enabled; tool calls and SSE events will fail on purpose",
+			"latencyRate", cfg.ChaosLatencyRate, "errorRate", cfg.ChaosErrorRate, "sseDropRate", cfg.ChaosSSEDropRate)
+	}
+
+	// Listeners passed by systemd socket activation replace binding the configured ports
+	inherited, err := listener.Inherited()
+	if err != nil {
+		logger.Error("Failed to inherit listeners", "error", err)
+		os.Exit(1)
+	}
+	withInherited := func(name string, opts []server.ServerOption) []server.ServerOption {
+		if l, ok := inherited[name]; ok {
+			logger.Info("Using inherited listener", "name", name, "address", l.Addr().String())
+			delete(inherited, name)
+			return append(opts, server.WithListener(l))
+		}
+		return opts
+	}
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		serverOptions = append(serverOptions, server.WithTLS(cfg.TLSCertFile, cfg.TLSKeyFile))
+	}
+
+	metrics.SetBuildInfo(version.GetVersion(), version.GetGitCommit(), version.GetBuildTime())
+	metrics.SetTransportEnabled("stdio", runMCP)
+	metrics.SetTransportEnabled("http", runHTTP)
+	metrics.SetTransportEnabled("streamable", runStreamable)
+	metrics.SetTransportEnabled("websocket", runWebSocket)
 
 	var mcpServer *server.MCPServer
 	var httpServer *server.HTTPServer
@@ -95,26 +419,223 @@ func main() {
 
 	if runMCP {
 		mcpServer = server.NewMCPServer(toolService, logger)
+		mcpServer.SetHeartbeat(time.Duration(cfg.StdioHeartbeatInterval) * time.Second)
+		mcpServer.SetBatchOptions(batchOptions)
 		logger.Info("Stdio MCP server enabled")
 	}
 	if runHTTP {
-		httpServer = server.NewHTTPServer(toolService, cfg.HTTPPort, logger)
+		httpServer = server.NewHTTPServer(toolService, withInherited("http", append(serverOptions,
+			server.WithBindAddress(cfg.HTTPBindAddress),
+			server.WithPort(cfg.HTTPPort),
+		))...)
+		httpServer.SetLogLevel(logLevel)
+		httpServer.SetConfig(cfg)
+		httpServer.SetToolRegistration(cfg.ToolRegistration)
+		httpServer.SetApprovalCallbackSecret(cfg.WebhookSecret)
 		logger.Info("HTTP REST server enabled", "port", cfg.HTTPPort)
 	}
 	if runStreamable {
-		streamableHTTPServer = server.NewStreamableHTTPServer(cfg, toolService, logger)
-		logger.Info("Streamable HTTP MCP server enabled", "port", cfg.StreamableHTTPPort, "origin-check", cfg.EnableOriginCheck)
+		streamableLimits := limits
+		streamableLimits.ReadTimeout = time.Duration(cfg.StreamableReadTimeout) * time.Second
+		streamableLimits.WriteTimeout = time.Duration(cfg.StreamableWriteTimeout) * time.Second
+		streamableLimits.IdleTimeout = time.Duration(cfg.StreamableIdleTimeout) * time.Second
+		streamableHTTPServer = server.NewStreamableHTTPServer(toolService, withInherited("streamable", append(serverOptions,
+			server.WithBindAddress(cfg.StreamableHTTPBindAddress),
+			server.WithPort(cfg.StreamableHTTPPort),
+			server.WithOriginCheck(cfg.EnableOriginCheck, cfg.AllowedOrigins),
+			server.WithStateless(cfg.StreamableStateless),
+			server.WithLimits(streamableLimits),
+		))...)
+		logger.Info("Streamable HTTP MCP server enabled", "port", cfg.StreamableHTTPPort, "origin-check", cfg.EnableOriginCheck, "stateless", cfg.StreamableStateless)
 	}
 	if runWebSocket {
-		jsonRPCProcessor := server.NewJSONRPCProcessor(toolService, logger)
-		webSocketServer = server.NewWebSocketServer(cfg, jsonRPCProcessor)
+		webSocketServer = server.NewWebSocketServer(toolService, withInherited("websocket", append(serverOptions,
+			server.WithBindAddress(cfg.WebSocketBindAddress),
+			server.WithPort(cfg.WebSocketPort),
+		))...)
 		logger.Info("WebSocket server enabled", "port", cfg.WebSocketPort)
 	}
 
+	for name, l := range inherited {
+		logger.Warn("Closing unused inherited listener", "name", name, "address", l.Addr().String())
+		_ = l.Close()
+	}
+
 	// --- Server Start ---
 	// The combined server handles the lifecycle of all non-nil servers.
 	srv := server.NewServer(cfg, mcpServer, httpServer, streamableHTTPServer, webSocketServer)
-	if err := srv.Start(context.Background()); err != nil {
+	srv.SetEvents(toolService.Events())
+	toolService.Scheduler().Start()
+	toolService.Health().Start()
+	if err := toolService.Sessions().Start(); err != nil {
+		logger.Error("Failed to start the shared session registry", "error", err)
+		os.Exit(1)
+	}
+	if cfg.ToolWarmUp {
+		go toolService.WarmUp()
+	}
+	err = srv.Start(context.Background())
+	toolService.Sessions().Stop()
+	toolService.Health().Stop()
+	toolService.Scheduler().Stop()
+	if notifier != nil {
+		// Hand the shutdown event to the notifier, then give deliveries one timeout to finish
+		unsubscribeWebhooks()
+		flushCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.WebhookTimeout)*time.Second)
+		if flushErr := notifier.Close(flushCtx); flushErr != nil {
+			logger.Warn("Undelivered webhooks at shutdown", "error", flushErr)
+		}
+		cancel()
+	}
+	if err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
+
+// addHTTPTools registers the HTTP tools defined in the configuration.
+func addHTTPTools(toolService *server.ToolService, cfg *config.ServerConfig, logger *slog.Logger) error {
+	definitions, err := cfg.HTTPToolDefinitions()
+	if err != nil {
+		return err
+	}
+	for _, definition := range definitions {
+		tool, err := tools.NewDeclarativeTool(definition, logger)
+		if err != nil {
+			return fmt.Errorf("HTTP tool %s: %w", definition.Name, err)
+		}
+		if err := toolService.AddTool(tool); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addPipelines registers the pipelines defined in the configuration. A pipeline may only
+// call tools registered before it, including earlier pipelines, so pipelines cannot form
+// cycles. A step held for approval waits until it is decided.
+func addPipelines(toolService *server.ToolService, cfg *config.ServerConfig, logger *slog.Logger) error {
+	definitions, err := cfg.PipelineDefinitions()
+	if err != nil {
+		return err
+	}
+	for _, definition := range definitions {
+		for _, step := range definition.Steps {
+			if _, ok := toolService.Resolve(step.Tool); !ok {
+				return fmt.Errorf("pipeline %s: unknown tool %s", definition.Name, step.Tool)
+			}
+		}
+		pipeline, err := tools.NewPipeline(definition, toolService.ExecuteToolAwaitingApproval, logger)
+		if err != nil {
+			return fmt.Errorf("pipeline %s: %w", definition.Name, err)
+		}
+		if err := toolService.AddTool(pipeline); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addSchedules registers the scheduled tool runs defined in the configuration.
+func addSchedules(toolService *server.ToolService, cfg *config.ServerConfig) error {
+	schedules, err := cfg.ScheduleConfigs()
+	if err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		if err := toolService.Scheduler().Add(server.Schedule{
+			Name:      schedule.Name,
+			Cron:      schedule.Cron,
+			Tool:      schedule.Tool,
+			Arguments: schedule.Arguments,
+			Enabled:   !schedule.Disabled,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setToolAliases applies TOOL_ALIASES, which may stand for HTTP tools and pipelines too.
+func setToolAliases(toolService *server.ToolService, cfg *config.ServerConfig) error {
+	aliases, err := cfg.ToolAliasMap()
+	if err != nil {
+		return err
+	}
+	return toolService.SetAliases(aliases)
+}
+
+// setToolThrottles applies TOOL_THROTTLES, rejecting entries for tools that do not exist.
+func setToolThrottles(toolService *server.ToolService, cfg *config.ServerConfig) error {
+	configs, err := cfg.ToolThrottleConfigs()
+	if err != nil {
+		return err
+	}
+	policies := make(map[string]server.ThrottlePolicy, len(configs))
+	for name, throttle := range configs {
+		if _, ok := toolService.GetTools()[name]; !ok {
+			return fmt.Errorf("throttle for unknown tool %s", name)
+		}
+		policies[name] = server.ThrottlePolicy{
+			Rate:        throttle.Rate,
+			Burst:       throttle.Burst,
+			Concurrency: throttle.Concurrency,
+			Queue:       throttle.Mode == "queue",
+			MaxWait:     time.Duration(throttle.MaxWaitMS) * time.Millisecond,
+		}
+	}
+	toolService.Throttle().SetPolicies(policies)
+	return nil
+}
+
+// setPolicy evaluates tool calls against the configured rules and policy agent, in
+// that order. Neither being set leaves calls unevaluated.
+func setPolicy(toolService *server.ToolService, cfg *config.ServerConfig, logger *slog.Logger) error {
+	rules, err := cfg.PolicyRuleList()
+	if err != nil {
+		return err
+	}
+	var engines policy.Chain
+	if rules != nil {
+		ruleSet, err := policy.NewRuleSet(rules, cfg.PolicyDefault == policy.EffectAllow)
+		if err != nil {
+			return err
+		}
+		engines = append(engines, ruleSet)
+	}
+	if cfg.PolicyOPAURL != "" {
+		engines = append(engines, policy.NewOPA(cfg.PolicyOPAURL, time.Duration(cfg.PolicyOPATimeout)*time.Second))
+	}
+	if len(engines) == 0 {
+		return nil
+	}
+	toolService.SetPolicy(engines)
+	logger.Info("Tool call policy enabled", "rules", len(rules), "opa", cfg.PolicyOPAURL != "")
+	return nil
+}
+
+// setToolQuotas applies the default and per-tool resource quotas from the configuration.
+func setToolQuotas(toolService *server.ToolService, cfg *config.ServerConfig) error {
+	configs, err := cfg.ToolQuotaConfigs()
+	if err != nil {
+		return err
+	}
+	quotas := make(map[string]tools.Quota, len(configs))
+	for name, quota := range configs {
+		if _, ok := toolService.GetTools()[name]; !ok {
+			return fmt.Errorf("quota for unknown tool %s", name)
+		}
+		quotas[name] = tools.Quota{
+			TimeLimit:      time.Duration(quota.TimeLimitMS) * time.Millisecond,
+			CPUTime:        time.Duration(quota.CPUTimeMS) * time.Millisecond,
+			MaxMemoryBytes: quota.MaxMemoryBytes,
+			MaxOutputBytes: quota.MaxOutputBytes,
+		}
+	}
+	toolService.Governor().SetQuotas(tools.Quota{
+		TimeLimit:      time.Duration(cfg.ToolTimeLimit) * time.Millisecond,
+		CPUTime:        time.Duration(cfg.ToolCPUTime) * time.Millisecond,
+		MaxMemoryBytes: cfg.ToolMaxMemoryBytes,
+		MaxOutputBytes: cfg.ToolMaxOutputBytes,
+	}, quotas)
+	return nil
+}
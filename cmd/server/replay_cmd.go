@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"mcp-tools-server/internal/server"
+)
+
+const replayUsage = `Usage:
+  server replay <recording.jsonl>
+
+Replays the client messages of a session recording against the current build and
+compares each response with the recorded one. Exits 1 if any response differs.
+`
+
+// replayReport is the output of the replay subcommand.
+type replayReport struct {
+	Requests   int                   `json:"requests"`
+	Mismatches int                   `json:"mismatches"`
+	Results    []server.ReplayResult `json:"results"`
+}
+
+// runReplayCommand implements the "replay" subcommand and returns the process exit code.
+// The report is written to stdout; logs and errors go to stderr.
+func runReplayCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprint(stderr, replayUsage)
+		return exitUsage
+	}
+
+	file, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to open recording: %v\n", err)
+		return exitUsage
+	}
+	defer file.Close()
+	messages, err := server.ReadRecording(file)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid recording: %v\n", err)
+		return exitUsage
+	}
+
+	toolService, code := newLocalToolService(stderr)
+	if toolService == nil {
+		return code
+	}
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	results, err := server.NewJSONRPCProcessor(toolService, logger).Replay(context.Background(), messages)
+	if err != nil {
+		fmt.Fprintf(stderr, "Replay failed: %v\n", err)
+		return exitUsage
+	}
+
+	report := replayReport{Requests: len(results), Results: results}
+	for _, result := range results {
+		if !result.Match {
+			report.Mismatches++
+		}
+	}
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(stderr, "Failed to encode report: %v\n", err)
+		return exitToolError
+	}
+	if report.Mismatches > 0 {
+		return exitToolError
+	}
+	return exitOK
+}
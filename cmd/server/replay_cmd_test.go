@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/internal/server"
+)
+
+func TestRunReplayCommand(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	dir := t.TempDir()
+	recorder, err := server.NewSessionRecorder(dir, nil, logger)
+	if err != nil {
+		t.Fatalf("NewSessionRecorder failed: %v", err)
+	}
+	toolService, code := newLocalToolService(io.Discard)
+	if toolService == nil {
+		t.Fatalf("Failed to create tool service: exit code %d", code)
+	}
+	toolService.SetRecorder(recorder)
+	processor := server.NewJSONRPCProcessor(toolService, logger)
+	ctx := server.WithSessionID(context.Background(), "session")
+	processor.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	processor.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]interface{}{"name": "generate_uuid", "arguments": map[string]interface{}{}}})
+
+	run := func(args ...string) (int, replayReport, string) {
+		var stdout, stderr bytes.Buffer
+		code := runReplayCommand(args, &stdout, &stderr)
+		var report replayReport
+		_ = json.Unmarshal(stdout.Bytes(), &report)
+		return code, report, stderr.String()
+	}
+
+	t.Run("mismatches exit 1", func(t *testing.T) {
+		code, report, stderr := run(recorder.Path("session"))
+		if code != exitToolError {
+			t.Fatalf("Expected exit code 1, got %d: %s", code, stderr)
+		}
+		// generate_uuid returns a new UUID each time, tools/list is unchanged
+		if report.Requests != 2 || report.Mismatches != 1 || !report.Results[0].Match {
+			t.Errorf("Expected only the tool call to differ, got %+v", report)
+		}
+	})
+
+	t.Run("matching recording exits 0", func(t *testing.T) {
+		data, err := os.ReadFile(recorder.Path("session"))
+		if err != nil {
+			t.Fatalf("Failed to read recording: %v", err)
+		}
+		lines := strings.SplitAfter(string(data), "\n")
+		path := filepath.Join(dir, "list.jsonl")
+		if err := os.WriteFile(path, []byte(lines[0]+lines[1]), 0o600); err != nil {
+			t.Fatalf("Failed to write recording: %v", err)
+		}
+		if code, report, stderr := run(path); code != exitOK || report.Mismatches != 0 {
+			t.Errorf("Expected exit code 0 and no mismatches, got %d (%+v): %s", code, report, stderr)
+		}
+	})
+
+	t.Run("usage errors exit 2", func(t *testing.T) {
+		cases := [][]string{
+			{},
+			{filepath.Join(dir, "missing.jsonl")},
+		}
+		for _, args := range cases {
+			if code, _, _ := run(args...); code != exitUsage {
+				t.Errorf("Expected exit code 2 for %v, got %d", args, code)
+			}
+		}
+	})
+}
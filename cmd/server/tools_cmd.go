@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+
+	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/server"
+	"mcp-tools-server/pkg/tools"
+)
+
+// Exit codes for the tools subcommand.
+const (
+	exitOK        = 0
+	exitToolError = 1
+	exitUsage     = 2
+)
+
+const toolsUsage = `Usage:
+  server tools list
+  server tools run [-args JSON] <tool>
+  server tools new [-dir DIR] [-description TEXT] <name>
+
+Runs tools locally without starting a transport. Tool arguments are read from -args,
+or from stdin when -args is omitted and stdin is not a terminal. new scaffolds a tool
+in the tools package (-dir, default pkg/tools) with a test and its registration.
+`
+
+// runToolsCommand implements the "tools" subcommand and returns the process exit code.
+// Only the result is written to stdout; logs and errors go to stderr.
+func runToolsCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, toolsUsage)
+		return exitUsage
+	}
+	if args[0] == "new" {
+		return newTool(args[1:], stdout, stderr)
+	}
+
+	toolService, code := newLocalToolService(stderr)
+	if toolService == nil {
+		return code
+	}
+
+	switch args[0] {
+	case "list":
+		return listTools(toolService, stdout)
+	case "run":
+		return runTool(toolService, args[1:], stdin, stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "Unknown tools command: %s\n\n%s", args[0], toolsUsage)
+		return exitUsage
+	}
+}
+
+// newLocalToolService creates a tool service with the configured HTTP tools, pipelines,
+// aliases, and tool defaults, logging to stderr. On failure it returns nil and the exit code.
+func newLocalToolService(stderr io.Writer) (*server.ToolService, int) {
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService, err := server.NewToolService(tools.NewToolRegistry(), logger)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to create tool service: %v\n", err)
+		return nil, exitToolError
+	}
+	cfg := config.NewServerConfig()
+	if err := cfg.Err(); err != nil {
+		fmt.Fprintf(stderr, "Invalid configuration: %v\n", err)
+		return nil, exitUsage
+	}
+	if err := addHTTPTools(toolService, cfg, logger); err != nil {
+		fmt.Fprintf(stderr, "Invalid HTTP tool configuration: %v\n", err)
+		return nil, exitUsage
+	}
+	if err := addPipelines(toolService, cfg, logger); err != nil {
+		fmt.Fprintf(stderr, "Invalid pipeline configuration: %v\n", err)
+		return nil, exitUsage
+	}
+	if err := setToolAliases(toolService, cfg); err != nil {
+		fmt.Fprintf(stderr, "Invalid tool alias configuration: %v\n", err)
+		return nil, exitUsage
+	}
+	toolService.SetToolDefaults(cfg.ToolDefaults)
+	toolService.SetStrictOutput(cfg.OutputSchemaStrict)
+	if cfg.ToolSearch {
+		toolService.EnableToolSearch()
+	}
+	if cfg.ServerInfo {
+		toolService.EnableServerInfo()
+	}
+	defaultVersions, err := cfg.ToolDefaultVersionMap()
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid tool version configuration: %v\n", err)
+		return nil, exitUsage
+	}
+	toolService.SetDefaultVersions(defaultVersions)
+	return toolService, exitOK
+}
+
+// listTools prints the available tools and their descriptions, one per line.
+func listTools(toolService *server.ToolService, stdout io.Writer) int {
+	descriptions := toolService.ListTools()
+	names := make([]string, 0, len(descriptions))
+	for name := range descriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(stdout, "%s\t%s\n", name, descriptions[name])
+	}
+	return exitOK
+}
+
+// runTool executes a single tool and prints its result as indented JSON.
+func runTool(toolService *server.ToolService, args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tools run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	rawArgs := flags.String("args", "", "Tool arguments as a JSON object")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, toolsUsage)
+		return exitUsage
+	}
+	name := flags.Arg(0)
+
+	if *rawArgs == "" && !isTerminal(stdin) {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read arguments from stdin: %v\n", err)
+			return exitUsage
+		}
+		*rawArgs = string(data)
+	}
+
+	var toolArgs map[string]interface{}
+	if strings.TrimSpace(*rawArgs) != "" {
+		if err := json.Unmarshal([]byte(*rawArgs), &toolArgs); err != nil {
+			fmt.Fprintf(stderr, "Arguments must be a JSON object: %v\n", err)
+			return exitUsage
+		}
+	}
+
+	result, err := toolService.ExecuteTool(name, toolArgs)
+	if err != nil {
+		fmt.Fprintf(stderr, "Tool %s failed: %v\n", name, err)
+		return exitToolError
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		fmt.Fprintf(stderr, "Failed to encode result: %v\n", err)
+		return exitToolError
+	}
+	return exitOK
+}
+
+// isTerminal reports whether r is an interactive terminal, in which case it is not read
+// for arguments.
+func isTerminal(r io.Reader) bool {
+	file, ok := r.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRunToolsCommand(t *testing.T) {
+	run := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runToolsCommand(args, strings.NewReader(stdin), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("list prints available tools", func(t *testing.T) {
+		code, stdout, _ := run("", "list")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d", code)
+		}
+		if !strings.Contains(stdout, "generate_uuid\t") {
+			t.Errorf("Expected generate_uuid in output, got %q", stdout)
+		}
+	})
+
+	t.Run("list includes configured HTTP tools", func(t *testing.T) {
+		t.Setenv("HTTP_TOOLS", `[{"name": "service_status", "description": "Service status", "url": "https://status.example.com"}]`)
+		code, stdout, stderr := run("", "list")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, "service_status\t") {
+			t.Errorf("Expected service_status in output, got %q", stdout)
+		}
+	})
+
+	t.Run("run executes configured pipelines", func(t *testing.T) {
+		t.Setenv("PIPELINES", `[{"name": "labelled_id", "description": "Labelled ID", "steps": [{"tool": "generate_uuid", "args": {"version": "$.input.version"}}], "output": {"id": "{{$.input.label}}-{{$.steps.generate_uuid.uuid}}"}}]`)
+		code, stdout, stderr := run("", "run", "-args", `{"label":"job","version":"v4"}`, "labelled_id")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		var result map[string]string
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil || !strings.HasPrefix(result["id"], "job-") || len(result["id"]) != 40 {
+			t.Errorf("Expected a labelled UUID, got %q", stdout)
+		}
+
+		t.Setenv("PIPELINES", `[{"name": "broken", "description": "Unknown tool", "steps": [{"tool": "no_such_tool"}]}]`)
+		if code, _, _ := run("", "list"); code != exitUsage {
+			t.Errorf("Expected a pipeline calling an unknown tool to be a usage error, got %d", code)
+		}
+	})
+
+	t.Run("run accepts aliases", func(t *testing.T) {
+		t.Setenv("TOOL_ALIASES", `{"uuid":"generate_uuid"}`)
+		code, stdout, stderr := run("", "run", "uuid")
+		if code != exitOK || !strings.Contains(stdout, `"uuid"`) {
+			t.Errorf("Expected the alias to run generate_uuid, got %d: %s%s", code, stdout, stderr)
+		}
+
+		t.Setenv("TOOL_ALIASES", `{"uuid":"no_such_tool"}`)
+		if code, _, _ := run("", "list"); code != exitUsage {
+			t.Errorf("Expected an alias of an unknown tool to be a usage error, got %d", code)
+		}
+	})
+
+	t.Run("run with args flag", func(t *testing.T) {
+		code, stdout, stderr := run("", "run", "-args", `{"version":"v7"}`, "generate_uuid")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		var result map[string]string
+		if err := json.Unmarshal([]byte(stdout), &result); err != nil {
+			t.Fatalf("Expected JSON result, got %q", stdout)
+		}
+		if id := result["uuid"]; len(id) != 36 || id[14] != '7' {
+			t.Errorf("Expected a v7 UUID, got %q", id)
+		}
+	})
+
+	t.Run("run reads args from stdin", func(t *testing.T) {
+		code, stdout, stderr := run(`{"version":"v4"}`, "run", "generate_uuid")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, `"uuid"`) {
+			t.Errorf("Expected a uuid result, got %q", stdout)
+		}
+	})
+
+	t.Run("tool errors exit 1", func(t *testing.T) {
+		code, stdout, stderr := run("", "run", "-args", `{"version":"v1"}`, "generate_uuid")
+		if code != exitToolError {
+			t.Errorf("Expected exit code 1, got %d", code)
+		}
+		if stdout != "" || !strings.Contains(stderr, "unsupported UUID version") {
+			t.Errorf("Expected error on stderr only, got stdout %q stderr %q", stdout, stderr)
+		}
+	})
+
+	t.Run("usage errors exit 2", func(t *testing.T) {
+		cases := [][]string{
+			{},
+			{"bogus"},
+			{"run"},
+			{"run", "-args", "not json", "generate_uuid"},
+		}
+		for _, args := range cases {
+			if code, _, _ := run("", args...); code != exitUsage {
+				t.Errorf("Expected exit code 2 for %v, got %d", args, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// toolNamePattern matches the snake_case names of built-in tools.
+var toolNamePattern = regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`)
+
+// registryFile and registryFunc are where new tools are registered.
+const (
+	registryFile = "tool.go"
+	registryFunc = "registerBuiltinTools"
+)
+
+// newTool scaffolds a tool in the tools package: a source file with a schema stub, a
+// test file, and its registration in registerBuiltinTools. Nothing is written if any of
+// it already exists.
+func newTool(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("tools new", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dir := flags.String("dir", filepath.Join("pkg", "tools"), "Directory of the tools package")
+	description := flags.String("description", "", "Tool description")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprint(stderr, toolsUsage)
+		return exitUsage
+	}
+	name := flags.Arg(0)
+	if !toolNamePattern.MatchString(name) {
+		fmt.Fprintf(stderr, "Tool name must be snake_case, such as word_count: %s\n", name)
+		return exitUsage
+	}
+
+	scaffold := toolScaffold{
+		Name:        name,
+		Type:        camelCase(name),
+		Description: *description,
+	}
+	if scaffold.Description == "" {
+		scaffold.Description = "TODO: describe what " + name + " does"
+	}
+	files, err := scaffold.render()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to generate %s: %v\n", name, err)
+		return exitToolError
+	}
+
+	registryPath := filepath.Join(*dir, registryFile)
+	registry, err := os.ReadFile(registryPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to read the tool registry: %v\n", err)
+		return exitUsage
+	}
+	registered, err := registerTool(registry, scaffold)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to register %s: %v\n", name, err)
+		return exitUsage
+	}
+	for file := range files {
+		if _, err := os.Stat(filepath.Join(*dir, file)); err == nil {
+			fmt.Fprintf(stderr, "%s already exists\n", filepath.Join(*dir, file))
+			return exitUsage
+		}
+	}
+
+	for _, file := range []string{name + ".go", name + "_test.go"} {
+		path := filepath.Join(*dir, file)
+		if err := os.WriteFile(path, files[file], 0o644); err != nil {
+			fmt.Fprintf(stderr, "Failed to write %s: %v\n", path, err)
+			return exitToolError
+		}
+		fmt.Fprintf(stdout, "Created %s\n", path)
+	}
+	if err := os.WriteFile(registryPath, registered, 0o644); err != nil {
+		fmt.Fprintf(stderr, "Failed to write %s: %v\n", registryPath, err)
+		return exitToolError
+	}
+	fmt.Fprintf(stdout, "Registered %s in %s\n", name, registryPath)
+	return exitOK
+}
+
+// toolScaffold holds what the templates of a new tool need.
+type toolScaffold struct {
+	Name        string // Tool and registry name, such as word_count
+	Type        string // Go type, such as WordCount
+	Description string
+}
+
+// render returns the formatted source and test files of the tool, by file name.
+func (s toolScaffold) render() (map[string][]byte, error) {
+	files := make(map[string][]byte, 2)
+	for file, tmpl := range map[string]*template.Template{
+		s.Name + ".go":      toolTemplate,
+		s.Name + "_test.go": toolTestTemplate,
+	} {
+		var source bytes.Buffer
+		if err := tmpl.Execute(&source, s); err != nil {
+			return nil, err
+		}
+		formatted, err := format.Source(source.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		files[file] = formatted
+	}
+	return files, nil
+}
+
+// registerTool adds the tool's builder to the end of registerBuiltinTools in the
+// registry source, failing if a tool of that name is already registered.
+func registerTool(source []byte, s toolScaffold) ([]byte, error) {
+	for _, method := range []string{"Register", "RegisterDeferrable"} {
+		if bytes.Contains(source, []byte(fmt.Sprintf("tr.%s(%q", method, s.Name))) {
+			return nil, fmt.Errorf("%s is already registered", s.Name)
+		}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, registryFile, source, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var end token.Pos
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Name.Name == registryFunc && fn.Body != nil {
+			end = fn.Body.Rbrace
+		}
+	}
+	if end == token.NoPos {
+		return nil, errors.New("no " + registryFunc + " function in " + registryFile)
+	}
+
+	offset := fset.Position(end).Offset
+	registration := fmt.Sprintf(`
+	// Register %s (no config needed)
+	tr.Register(%q, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return New%s(logger), nil
+	})
+`, s.Name, s.Name, s.Type)
+	updated := append([]byte{}, source[:offset]...)
+	updated = append(updated, registration...)
+	updated = append(updated, source[offset:]...)
+	return format.Source(updated)
+}
+
+// camelCase converts a snake_case name to an exported Go identifier.
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+var toolTemplate = template.Must(template.New("tool").Parse(`package tools
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// {{.Type}} implements the {{.Name}} tool.
+type {{.Type}} struct {
+	logger *slog.Logger
+}
+
+// New{{.Type}} creates the {{.Name}} tool.
+func New{{.Type}}(logger *slog.Logger) *{{.Type}} {
+	return &{{.Type}}{logger: logger}
+}
+
+// Name returns the tool's name
+func (t *{{.Type}}) Name() string {
+	return "{{.Name}}"
+}
+
+// Description returns the tool's description
+func (t *{{.Type}}) Description() string {
+	return {{printf "%q" .Description}}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *{{.Type}}) InputSchema() map[string]interface{} {
+	// TODO: describe the tool's arguments
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"input": map[string]interface{}{
+				"type":        "string",
+				"description": "Input to process",
+			},
+		},
+		"required": []string{"input"},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test
+func (t *{{.Type}}) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"input": "example"}
+}
+
+// Execute runs the tool with the given arguments
+func (t *{{.Type}}) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	input, ok := args["input"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing required argument: input")
+	}
+
+	// TODO: implement the tool
+	return map[string]interface{}{"input": input}, nil
+}
+`))
+
+var toolTestTemplate = template.Must(template.New("test").Parse(`package tools
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func Test{{.Type}}(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tool := New{{.Type}}(logger)
+
+	t.Run("is registered", func(t *testing.T) {
+		if _, err := NewToolRegistry().CreateSpecific(logger, []string{"{{.Name}}"}); err != nil {
+			t.Fatalf("Expected {{.Name}} to be registered: %v", err)
+		}
+	})
+
+	t.Run("executes", func(t *testing.T) {
+		result, err := tool.Execute(tool.SelfTestArgs())
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		// TODO: check the result
+		if result["input"] != "example" {
+			t.Errorf("Unexpected result: %v", result)
+		}
+	})
+
+	t.Run("requires input", func(t *testing.T) {
+		if _, err := tool.Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected an error for missing input")
+		}
+	})
+}
+`))
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewTool(t *testing.T) {
+	registry := `package tools
+
+import "log/slog"
+
+func (tr *ToolRegistry) registerBuiltinTools() {
+	// Register UUID generator (no config needed)
+	tr.Register("uuid_gen", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewUUIDGen(logger), nil
+	})
+}
+`
+	setup := func(t *testing.T) string {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "tool.go"), []byte(registry), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+	run := func(args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+		code := runToolsCommand(append([]string{"new"}, args...), strings.NewReader(""), &stdout, &stderr)
+		return code, stdout.String(), stderr.String()
+	}
+
+	t.Run("scaffolds and registers a tool", func(t *testing.T) {
+		dir := setup(t)
+		code, stdout, stderr := run("-dir", dir, "-description", "Counts words in a text", "word_count")
+		if code != exitOK {
+			t.Fatalf("Expected exit code 0, got %d: %s", code, stderr)
+		}
+		if !strings.Contains(stdout, "Registered word_count") {
+			t.Errorf("Expected the registration to be reported, got %q", stdout)
+		}
+
+		source, err := os.ReadFile(filepath.Join(dir, "word_count.go"))
+		if err != nil {
+			t.Fatalf("Expected the tool file: %v", err)
+		}
+		for _, want := range []string{"type WordCount struct", "func NewWordCount(logger *slog.Logger) *WordCount", `return "word_count"`, `"Counts words in a text"`, "func (t *WordCount) InputSchema()"} {
+			if !bytes.Contains(source, []byte(want)) {
+				t.Errorf("Expected %q in the tool file", want)
+			}
+		}
+		test, err := os.ReadFile(filepath.Join(dir, "word_count_test.go"))
+		if err != nil || !bytes.Contains(test, []byte("func TestWordCount(t *testing.T)")) {
+			t.Errorf("Expected the test file, got %v", err)
+		}
+
+		updated, _ := os.ReadFile(filepath.Join(dir, "tool.go"))
+		uuid := strings.Index(string(updated), `tr.Register("uuid_gen"`)
+		added := strings.Index(string(updated), `tr.Register("word_count"`)
+		if uuid < 0 || added < uuid || !strings.Contains(string(updated), "return NewWordCount(logger), nil") {
+			t.Errorf("Expected word_count registered after uuid_gen, got:\n%s", updated)
+		}
+	})
+
+	t.Run("refuses tools that exist", func(t *testing.T) {
+		dir := setup(t)
+		if code, _, stderr := run("-dir", dir, "uuid_gen"); code != exitUsage || !strings.Contains(stderr, "already registered") {
+			t.Errorf("Expected a registered name to be refused, got %d: %s", code, stderr)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "word_count.go"), []byte("package tools\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if code, _, stderr := run("-dir", dir, "word_count"); code != exitUsage || !strings.Contains(stderr, "already exists") {
+			t.Errorf("Expected an existing file to be refused, got %d: %s", code, stderr)
+		}
+		if updated, _ := os.ReadFile(filepath.Join(dir, "tool.go")); string(updated) != registry {
+			t.Error("Expected the registry to be left alone")
+		}
+	})
+
+	t.Run("rejects invalid names", func(t *testing.T) {
+		dir := setup(t)
+		for _, name := range []string{"WordCount", "word-count", "_word", "word__count"} {
+			if code, _, _ := run("-dir", dir, name); code != exitUsage {
+				t.Errorf("Expected %q to be rejected, got %d", name, code)
+			}
+		}
+		if code, _, _ := run("-dir", dir); code != exitUsage {
+			t.Errorf("Expected a missing name to be a usage error, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegint = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+// cborIndefinite is the additional information of an indefinite-length item, and
+// cborBreak the byte that ends one.
+const (
+	cborIndefinite = 31
+	cborBreak      = 0xff
+)
+
+// cborCodec is the CBOR codec. Floats are always encoded in 64 bits.
+type cborCodec struct{}
+
+// Name returns "cbor"
+func (cborCodec) Name() string { return "cbor" }
+
+// ContentType returns "application/cbor"
+func (cborCodec) ContentType() string { return "application/cbor" }
+
+// Marshal encodes value as CBOR
+func (c cborCodec) Marshal(value interface{}) ([]byte, error) {
+	return encode(c, nil, value)
+}
+
+// Unmarshal decodes a CBOR value. Tags are skipped, leaving the values they tag, and
+// undefined decodes to nil.
+func (cborCodec) Unmarshal(data []byte) (interface{}, error) {
+	return decodeAll(data, decodeCBOR)
+}
+
+// appendCBORHead appends an item's initial byte and argument in the shortest form.
+func appendCBORHead(dst []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(dst, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(dst, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, major|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, major|27), n)
+	}
+}
+
+func (cborCodec) appendNil(dst []byte) []byte { return append(dst, 0xf6) }
+
+func (cborCodec) appendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 0xf5)
+	}
+	return append(dst, 0xf4)
+}
+
+func (cborCodec) appendInt(dst []byte, v int64) []byte {
+	if v < 0 {
+		return appendCBORHead(dst, cborNegint, uint64(^v))
+	}
+	return appendCBORHead(dst, cborUint, uint64(v))
+}
+
+func (cborCodec) appendUint(dst []byte, v uint64) []byte {
+	return appendCBORHead(dst, cborUint, v)
+}
+
+func (cborCodec) appendFloat(dst []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, 0xfb), math.Float64bits(v))
+}
+
+func (cborCodec) appendString(dst []byte, v string) []byte {
+	return append(appendCBORHead(dst, cborText, uint64(len(v))), v...)
+}
+
+func (cborCodec) appendBytes(dst []byte, v []byte) []byte {
+	return append(appendCBORHead(dst, cborBytes, uint64(len(v))), v...)
+}
+
+func (cborCodec) appendArrayHeader(dst []byte, n int) []byte {
+	return appendCBORHead(dst, cborArray, uint64(n))
+}
+
+func (cborCodec) appendMapHeader(dst []byte, n int) []byte {
+	return appendCBORHead(dst, cborMap, uint64(n))
+}
+
+// decodeCBOR decodes the next CBOR item.
+func decodeCBOR(r *reader, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("codec: value nested deeper than %d", maxDepth)
+	}
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+
+	if major == cborSimple {
+		return decodeCBORSimple(r, info)
+	}
+	if info == cborIndefinite {
+		return decodeCBORIndefinite(r, major, depth)
+	}
+	n, err := cborArgument(r, info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return intValue(n), nil
+	case cborNegint:
+		if n <= math.MaxInt64 {
+			return ^int64(n), nil
+		}
+		return -1 - float64(n), nil
+	case cborBytes:
+		data, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, data...), nil
+	case cborText:
+		data, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("codec: text string is not valid UTF-8")
+		}
+		return string(data), nil
+	case cborArray:
+		if err := r.checkCount(n); err != nil {
+			return nil, err
+		}
+		array := make([]interface{}, n)
+		for i := range array {
+			if array[i], err = decodeCBOR(r, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	case cborMap:
+		if err := r.checkCount(n); err != nil {
+			return nil, err
+		}
+		object := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			if err := decodeCBOREntry(r, object, depth); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	default: // cborTag
+		return decodeCBOR(r, depth+1)
+	}
+}
+
+// cborArgument reads the argument that follows an initial byte with info.
+func cborArgument(r *reader, info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return r.uint(1 << (info - 24))
+	default:
+		return 0, fmt.Errorf("codec: invalid CBOR additional information %d", info)
+	}
+}
+
+// decodeCBORSimple decodes a simple value or float.
+func decodeCBORSimple(r *reader, info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		h, err := r.uint(2)
+		return halfToFloat(uint16(h)), err
+	case 26:
+		f, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(f))), err
+	case 27:
+		f, err := r.uint(8)
+		return math.Float64frombits(f), err
+	case cborIndefinite:
+		return nil, fmt.Errorf("codec: unexpected CBOR break")
+	default:
+		return nil, fmt.Errorf("codec: unsupported CBOR simple value %d", info)
+	}
+}
+
+// decodeCBORIndefinite decodes an indefinite-length string, array, or map, whose items
+// run until a break.
+func decodeCBORIndefinite(r *reader, major byte, depth int) (interface{}, error) {
+	done := func() bool {
+		if len(r.data) > 0 && r.data[0] == cborBreak {
+			r.data = r.data[1:]
+			return true
+		}
+		return false
+	}
+	switch major {
+	case cborBytes, cborText:
+		var chunks []byte
+		for !done() {
+			chunk, err := decodeCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			switch c := chunk.(type) {
+			case []byte:
+				if major != cborBytes {
+					return nil, fmt.Errorf("codec: invalid chunk in CBOR text string")
+				}
+				chunks = append(chunks, c...)
+			case string:
+				if major != cborText {
+					return nil, fmt.Errorf("codec: invalid chunk in CBOR byte string")
+				}
+				chunks = append(chunks, c...)
+			default:
+				return nil, fmt.Errorf("codec: invalid chunk in CBOR string")
+			}
+		}
+		if major == cborText {
+			return string(chunks), nil
+		}
+		if chunks == nil {
+			chunks = []byte{}
+		}
+		return chunks, nil
+	case cborArray:
+		array := []interface{}{}
+		for !done() {
+			item, err := decodeCBOR(r, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		return array, nil
+	case cborMap:
+		object := make(map[string]interface{})
+		for !done() {
+			if err := decodeCBOREntry(r, object, depth); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	default:
+		return nil, fmt.Errorf("codec: CBOR major type %d cannot have an indefinite length", major)
+	}
+}
+
+// decodeCBOREntry decodes a map key and its value into object.
+func decodeCBOREntry(r *reader, object map[string]interface{}, depth int) error {
+	key, err := decodeCBOR(r, depth+1)
+	if err != nil {
+		return err
+	}
+	name, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+	if object[name], err = decodeCBOR(r, depth+1); err != nil {
+		return err
+	}
+	return nil
+}
+
+// halfToFloat converts an IEEE 754 half-precision float.
+func halfToFloat(h uint16) float64 {
+	exponent, mantissa := int(h>>10&0x1f), float64(h&0x3ff)
+	var v float64
+	switch exponent {
+	case 0:
+		v = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			v = math.Inf(1)
+		} else {
+			v = math.NaN()
+		}
+	default:
+		v = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if h&0x8000 != 0 {
+		v = -v
+	}
+	return v
+}
@@ -0,0 +1,274 @@
+// Package codec encodes JSON-compatible values as CBOR (RFC 8949) or MessagePack, for
+// clients that would rather exchange compact binary messages than JSON text. Only what
+// JSON can express is supported: maps with string keys, arrays, strings, numbers,
+// booleans, and null. Byte strings decode to []byte, which encodes to JSON as base64.
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxDepth bounds the nesting of decoded values, as encoding/json bounds it.
+const maxDepth = 10000
+
+// ErrTruncated is returned when data ends in the middle of a value.
+var ErrTruncated = errors.New("codec: unexpected end of data")
+
+// Codec encodes and decodes JSON-compatible values in a binary format.
+type Codec interface {
+	// Name is the codec's short name, as used in WebSocket subprotocols
+	Name() string
+	// ContentType is the codec's media type, as used in Accept and Content-Type headers
+	ContentType() string
+	// Marshal encodes value, which is anything encoding/json can encode
+	Marshal(value interface{}) ([]byte, error)
+	// Unmarshal decodes a single value that takes up all of data. Integers decode to
+	// int64, or uint64 past its range, and floats to float64.
+	Unmarshal(data []byte) (interface{}, error)
+}
+
+// The supported codecs.
+var (
+	CBOR        Codec = cborCodec{}
+	MessagePack Codec = msgpackCodec{}
+)
+
+// codecs lists the supported codecs, for lookups.
+var codecs = []Codec{CBOR, MessagePack}
+
+// ByName returns the codec called name, or nil if there is none.
+func ByName(name string) Codec {
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// ByContentType returns the codec for a media type, ignoring its parameters, or nil if
+// there is none. MessagePack is also known by its older application/x-msgpack and
+// application/vnd.msgpack types.
+func ByContentType(contentType string) Codec {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case CBOR.ContentType():
+		return CBOR
+	case MessagePack.ContentType(), "application/x-msgpack", "application/vnd.msgpack":
+		return MessagePack
+	}
+	return nil
+}
+
+// FromJSON converts a JSON document to c's encoding. Integers stay integers.
+func FromJSON(c Codec, data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return c.Marshal(value)
+}
+
+// ToJSON converts a document in c's encoding to JSON.
+func ToJSON(c Codec, data []byte) ([]byte, error) {
+	value, err := c.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
+
+// format appends the parts of a binary encoding; encode walks values onto it.
+type format interface {
+	appendNil(dst []byte) []byte
+	appendBool(dst []byte, v bool) []byte
+	appendInt(dst []byte, v int64) []byte
+	appendUint(dst []byte, v uint64) []byte
+	appendFloat(dst []byte, v float64) []byte
+	appendString(dst []byte, v string) []byte
+	appendBytes(dst []byte, v []byte) []byte
+	appendArrayHeader(dst []byte, n int) []byte
+	appendMapHeader(dst []byte, n int) []byte
+}
+
+// encode appends the encoding of value in f. Maps are written with their keys sorted, so
+// equal values encode identically. Types other than the ones JSON decodes into take a
+// round trip through encoding/json first.
+func encode(f format, dst []byte, value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return f.appendNil(dst), nil
+	case bool:
+		return f.appendBool(dst, v), nil
+	case string:
+		return f.appendString(dst, v), nil
+	case []byte:
+		if v == nil {
+			return f.appendNil(dst), nil
+		}
+		return f.appendBytes(dst, v), nil
+	case int:
+		return f.appendInt(dst, int64(v)), nil
+	case int8:
+		return f.appendInt(dst, int64(v)), nil
+	case int16:
+		return f.appendInt(dst, int64(v)), nil
+	case int32:
+		return f.appendInt(dst, int64(v)), nil
+	case int64:
+		return f.appendInt(dst, v), nil
+	case uint:
+		return f.appendUint(dst, uint64(v)), nil
+	case uint8:
+		return f.appendUint(dst, uint64(v)), nil
+	case uint16:
+		return f.appendUint(dst, uint64(v)), nil
+	case uint32:
+		return f.appendUint(dst, uint64(v)), nil
+	case uint64:
+		return f.appendUint(dst, v), nil
+	case float32:
+		return encodeFloat(f, dst, float64(v))
+	case float64:
+		return encodeFloat(f, dst, v)
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+			return f.appendInt(dst, i), nil
+		}
+		if u, err := strconv.ParseUint(string(v), 10, 64); err == nil {
+			return f.appendUint(dst, u), nil
+		}
+		n, err := strconv.ParseFloat(string(v), 64)
+		if err != nil {
+			return nil, fmt.Errorf("codec: invalid number %q", v)
+		}
+		return encodeFloat(f, dst, n)
+	case map[string]interface{}:
+		if v == nil {
+			return f.appendNil(dst), nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		dst = f.appendMapHeader(dst, len(keys))
+		for _, key := range keys {
+			dst = f.appendString(dst, key)
+			var err error
+			if dst, err = encode(f, dst, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case []interface{}:
+		if v == nil {
+			return f.appendNil(dst), nil
+		}
+		dst = f.appendArrayHeader(dst, len(v))
+		for _, item := range v {
+			var err error
+			if dst, err = encode(f, dst, item); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		decoder := json.NewDecoder(bytes.NewReader(encoded))
+		decoder.UseNumber()
+		var generic interface{}
+		if err := decoder.Decode(&generic); err != nil {
+			return nil, err
+		}
+		return encode(f, dst, generic)
+	}
+}
+
+// encodeFloat appends a float, refusing the values JSON cannot carry.
+func encodeFloat(f format, dst []byte, v float64) ([]byte, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil, fmt.Errorf("codec: unsupported value %v", v)
+	}
+	return f.appendFloat(dst, v), nil
+}
+
+// reader consumes encoded data from the front.
+type reader struct {
+	data []byte
+}
+
+// next returns the next n bytes.
+func (r *reader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)) {
+		return nil, ErrTruncated
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (r *reader) uint(size int) (uint64, error) {
+	b, err := r.next(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v, nil
+}
+
+// checkCount rejects a collection that claims more items than the data left could hold,
+// at one byte each, so a few bytes cannot make the decoder allocate gigabytes.
+func (r *reader) checkCount(n uint64) error {
+	if n > uint64(len(r.data)) {
+		return ErrTruncated
+	}
+	return nil
+}
+
+// decodeAll decodes a single value that takes up all of data.
+func decodeAll(data []byte, decode func(r *reader, depth int) (interface{}, error)) (interface{}, error) {
+	r := &reader{data: data}
+	value, err := decode(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.data) > 0 {
+		return nil, fmt.Errorf("codec: %d bytes of trailing data", len(r.data))
+	}
+	return value, nil
+}
+
+// intValue returns an unsigned integer as int64 when it fits, as encoding/json users
+// expect, and as uint64 otherwise.
+func intValue(v uint64) interface{} {
+	if v <= math.MaxInt64 {
+		return int64(v)
+	}
+	return v
+}
+
+// mapKey returns a decoded map key, which must be a string to be valid JSON.
+func mapKey(key interface{}) (string, error) {
+	s, ok := key.(string)
+	if !ok {
+		return "", fmt.Errorf("codec: map key of type %T is not a string", key)
+	}
+	return s, nil
+}
@@ -0,0 +1,223 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	value := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      int64(7),
+		"result": map[string]interface{}{
+			"content": []interface{}{
+				map[string]interface{}{"type": "text", "text": "héllo"},
+			},
+			"count":    int64(-40000),
+			"big":      uint64(math.MaxUint64),
+			"ratio":    0.25,
+			"ok":       true,
+			"missing":  nil,
+			"blob":     []byte{0, 1, 2},
+			"long":     string(bytes.Repeat([]byte("x"), 70000)),
+			"negative": int64(math.MinInt64),
+		},
+	}
+	for _, c := range []Codec{CBOR, MessagePack} {
+		t.Run(c.Name(), func(t *testing.T) {
+			encoded, err := c.Marshal(value)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			decoded, err := c.Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, value) {
+				t.Errorf("Expected %v after a round trip, got %v", value, decoded)
+			}
+		})
+	}
+}
+
+func TestCodecs_Encoding(t *testing.T) {
+	// Expected encodings from RFC 8949 Appendix A and the MessagePack specification
+	tests := []struct {
+		codec Codec
+		value interface{}
+		hex   string
+	}{
+		{CBOR, int64(0), "00"},
+		{CBOR, int64(24), "1818"},
+		{CBOR, int64(1000000), "1a000f4240"},
+		{CBOR, int64(-1000), "3903e7"},
+		{CBOR, 1.1, "fb3ff199999999999a"},
+		{CBOR, "IETF", "6449455446"},
+		{CBOR, []interface{}{int64(1), []interface{}{int64(2), int64(3)}}, "8201820203"},
+		{CBOR, map[string]interface{}{"b": int64(2), "a": int64(1)}, "a2616101616202"},
+		{CBOR, nil, "f6"},
+		{MessagePack, int64(127), "7f"},
+		{MessagePack, int64(-32), "e0"},
+		{MessagePack, int64(-33), "d0df"},
+		{MessagePack, int64(256), "cd0100"},
+		{MessagePack, "IETF", "a449455446"},
+		{MessagePack, []interface{}{true, false}, "92c3c2"},
+		{MessagePack, map[string]interface{}{"b": int64(2), "a": int64(1)}, "82a16101a16202"},
+		{MessagePack, nil, "c0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec.Name()+" "+tt.hex, func(t *testing.T) {
+			encoded, err := tt.codec.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			if got := hex.EncodeToString(encoded); got != tt.hex {
+				t.Errorf("Expected %s, got %s", tt.hex, got)
+			}
+		})
+	}
+}
+
+func TestCBOR_Unmarshal(t *testing.T) {
+	tests := []struct {
+		name  string
+		hex   string
+		value interface{}
+	}{
+		{"half float", "f93e00", 1.5},
+		{"single float", "fa47c35000", 100000.0},
+		{"undefined", "f7", nil},
+		{"tagged value", "c11a514b67b0", int64(1363896240)},
+		{"indefinite text", "7f657374726561646d696e67ff", "streaming"},
+		{"indefinite array", "9f018202039f0405ffff", []interface{}{int64(1), []interface{}{int64(2), int64(3)}, []interface{}{int64(4), int64(5)}}},
+		{"indefinite map", "bf61610161629f0203ffff", map[string]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tt.hex)
+			value, err := CBOR.Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(value, tt.value) {
+				t.Errorf("Expected %#v, got %#v", tt.value, value)
+			}
+		})
+	}
+}
+
+func TestCodecs_UnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec Codec
+		hex   string
+	}{
+		{"truncated", CBOR, "6449"},
+		{"trailing data", CBOR, "0000"},
+		{"non-string key", CBOR, "a10102"},
+		{"invalid UTF-8", CBOR, "62c328"},
+		{"oversized array", CBOR, "9b00000000ffffffff"},
+		{"stray break", CBOR, "ff"},
+		{"truncated", MessagePack, "a449"},
+		{"non-string key", MessagePack, "810102"},
+		{"extension type", MessagePack, "d40102"},
+		{"oversized map", MessagePack, "dfffffffff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.codec.Name()+" "+tt.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tt.hex)
+			if _, err := tt.codec.Unmarshal(data); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	t.Run("deep nesting", func(t *testing.T) {
+		data := bytes.Repeat([]byte{0x81}, maxDepth+2)
+		if _, err := CBOR.Unmarshal(data); err == nil {
+			t.Error("Expected an error for deeply nested data")
+		}
+	})
+
+	t.Run("truncation is reported", func(t *testing.T) {
+		if _, err := MessagePack.Unmarshal([]byte{0xa4}); !errors.Is(err, ErrTruncated) {
+			t.Errorf("Expected ErrTruncated, got %v", err)
+		}
+	})
+}
+
+func TestJSONConversion(t *testing.T) {
+	document := `{"id":1,"method":"tools/call","params":{"arguments":{"count":3,"scale":0.5,"tags":["a","b"]},"name":"echo"}}`
+	for _, c := range []Codec{CBOR, MessagePack} {
+		t.Run(c.Name(), func(t *testing.T) {
+			encoded, err := FromJSON(c, []byte(document))
+			if err != nil {
+				t.Fatalf("FromJSON failed: %v", err)
+			}
+			decoded, err := c.Unmarshal(encoded)
+			if err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if id := decoded.(map[string]interface{})["id"]; id != int64(1) {
+				t.Errorf("Expected the integer ID to stay an integer, got %#v", id)
+			}
+			back, err := ToJSON(c, encoded)
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			if string(back) != document {
+				t.Errorf("Expected %s, got %s", document, back)
+			}
+		})
+	}
+
+	t.Run("structs", func(t *testing.T) {
+		type result struct {
+			Name  string `json:"name"`
+			Count int    `json:"count,omitempty"`
+		}
+		encoded, err := MessagePack.Marshal(result{Name: "echo"})
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		back, _ := ToJSON(MessagePack, encoded)
+		if want, _ := json.Marshal(result{Name: "echo"}); !bytes.Equal(back, want) {
+			t.Errorf("Expected %s, got %s", want, back)
+		}
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		if _, err := FromJSON(CBOR, []byte("{")); err == nil {
+			t.Error("Expected an error for invalid JSON")
+		}
+	})
+
+	t.Run("NaN is refused", func(t *testing.T) {
+		if _, err := CBOR.Marshal(math.NaN()); err == nil {
+			t.Error("Expected an error for NaN")
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+	if ByName("cbor") != CBOR || ByName("msgpack") != MessagePack || ByName("json") != nil {
+		t.Error("ByName returned the wrong codec")
+	}
+	tests := map[string]Codec{
+		"application/cbor":                 CBOR,
+		"application/msgpack":              MessagePack,
+		"Application/X-MsgPack; charset=x": MessagePack,
+		"application/vnd.msgpack":          MessagePack,
+		"application/json":                 nil,
+	}
+	for contentType, want := range tests {
+		if got := ByContentType(contentType); got != want {
+			t.Errorf("ByContentType(%q) = %v, want %v", contentType, got, want)
+		}
+	}
+}
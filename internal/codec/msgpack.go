@@ -0,0 +1,233 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// msgpackCodec is the MessagePack codec. Floats are always encoded in 64 bits.
+type msgpackCodec struct{}
+
+// Name returns "msgpack"
+func (msgpackCodec) Name() string { return "msgpack" }
+
+// ContentType returns "application/msgpack"
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+// Marshal encodes value as MessagePack
+func (c msgpackCodec) Marshal(value interface{}) ([]byte, error) {
+	return encode(c, nil, value)
+}
+
+// Unmarshal decodes a MessagePack value. Extension types are not supported.
+func (msgpackCodec) Unmarshal(data []byte) (interface{}, error) {
+	return decodeAll(data, decodeMsgpack)
+}
+
+// appendMsgpackHead appends a length in the shortest of the formats given for 8, 16, and
+// 32 bits, or in fix, the format that carries lengths below fixLimit in its first byte. A
+// zero format is skipped.
+func appendMsgpackHead(dst []byte, n int, fix byte, fixLimit int, f8, f16, f32 byte) []byte {
+	switch {
+	case n < fixLimit:
+		return append(dst, fix|byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		return append(dst, f8, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, f16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, f32), uint32(n))
+	}
+}
+
+func (msgpackCodec) appendNil(dst []byte) []byte { return append(dst, 0xc0) }
+
+func (msgpackCodec) appendBool(dst []byte, v bool) []byte {
+	if v {
+		return append(dst, 0xc3)
+	}
+	return append(dst, 0xc2)
+}
+
+func (c msgpackCodec) appendInt(dst []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return c.appendUint(dst, uint64(v))
+	case v >= -32:
+		return append(dst, byte(v))
+	case v >= math.MinInt8:
+		return append(dst, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(v))
+	}
+}
+
+func (msgpackCodec) appendUint(dst []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(dst, byte(v))
+	case v <= math.MaxUint8:
+		return append(dst, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xcf), v)
+	}
+}
+
+func (msgpackCodec) appendFloat(dst []byte, v float64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, 0xcb), math.Float64bits(v))
+}
+
+func (msgpackCodec) appendString(dst []byte, v string) []byte {
+	return append(appendMsgpackHead(dst, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb), v...)
+}
+
+func (msgpackCodec) appendBytes(dst []byte, v []byte) []byte {
+	return append(appendMsgpackHead(dst, len(v), 0, 0, 0xc4, 0xc5, 0xc6), v...)
+}
+
+func (msgpackCodec) appendArrayHeader(dst []byte, n int) []byte {
+	return appendMsgpackHead(dst, n, 0x90, 16, 0, 0xdc, 0xdd)
+}
+
+func (msgpackCodec) appendMapHeader(dst []byte, n int) []byte {
+	return appendMsgpackHead(dst, n, 0x80, 16, 0, 0xde, 0xdf)
+}
+
+// decodeMsgpack decodes the next MessagePack value.
+func decodeMsgpack(r *reader, depth int) (interface{}, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("codec: value nested deeper than %d", maxDepth)
+	}
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+	switch t := b[0]; {
+	case t <= 0x7f:
+		return int64(t), nil
+	case t >= 0xe0:
+		return int64(int8(t)), nil
+	case t >= 0x80 && t <= 0x8f:
+		return decodeMsgpackMap(r, uint64(t&0x0f), depth)
+	case t >= 0x90 && t <= 0x9f:
+		return decodeMsgpackArray(r, uint64(t&0x0f), depth)
+	case t >= 0xa0 && t <= 0xbf:
+		return decodeMsgpackString(r, uint64(t&0x1f))
+	}
+
+	switch t := b[0]; t {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.uint(1 << (t - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, data...), nil
+	case 0xca:
+		f, err := r.uint(4)
+		return float64(math.Float32frombits(uint32(f))), err
+	case 0xcb:
+		f, err := r.uint(8)
+		return math.Float64frombits(f), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := r.uint(1 << (t - 0xcc))
+		return intValue(n), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (t - 0xd0)
+		n, err := r.uint(size)
+		if err != nil {
+			return nil, err
+		}
+		// Sign-extend from the value's size
+		shift := 64 - 8*size
+		return int64(n<<shift) >> shift, nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.uint(1 << (t - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackString(r, n)
+	case 0xdc, 0xdd:
+		n, err := r.uint(2 << (t - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackArray(r, n, depth)
+	case 0xde, 0xdf:
+		n, err := r.uint(2 << (t - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMsgpackMap(r, n, depth)
+	default:
+		return nil, fmt.Errorf("codec: unsupported MessagePack type 0x%02x", t)
+	}
+}
+
+// decodeMsgpackString decodes a string of n bytes.
+func decodeMsgpackString(r *reader, n uint64) (interface{}, error) {
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("codec: string is not valid UTF-8")
+	}
+	return string(data), nil
+}
+
+// decodeMsgpackArray decodes an array of n items.
+func decodeMsgpackArray(r *reader, n uint64, depth int) (interface{}, error) {
+	if err := r.checkCount(n); err != nil {
+		return nil, err
+	}
+	array := make([]interface{}, n)
+	for i := range array {
+		var err error
+		if array[i], err = decodeMsgpack(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+// decodeMsgpackMap decodes a map of n entries.
+func decodeMsgpackMap(r *reader, n uint64, depth int) (interface{}, error) {
+	if err := r.checkCount(n); err != nil {
+		return nil, err
+	}
+	object := make(map[string]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		key, err := decodeMsgpack(r, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		name, err := mapKey(key)
+		if err != nil {
+			return nil, err
+		}
+		if object[name], err = decodeMsgpack(r, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	return object, nil
+}
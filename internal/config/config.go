@@ -1,10 +1,17 @@
 package config
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+
+	"mcp-tools-server/internal/policy"
+	"mcp-tools-server/pkg/tools"
 )
 
 // ServerConfig holds the configuration for the MCP tools server
@@ -13,8 +20,163 @@ type ServerConfig struct {
 	StreamableHTTPPort int      // Port for Streamable HTTP MCP server
 	WebSocketPort      int      // Port for WebSocket server
 	ShutdownTimeout    int      // Timeout for graceful shutdown (seconds)
+	StartupGracePeriod int      // Time after start before readiness passes (seconds)
+	ShutdownDrainDelay int      // Time readiness fails before listeners stop on shutdown (seconds)
+	StartupSummary     string   // Where the JSON startup summary goes: stderr, stdout, off, or a file path
 	EnableOriginCheck  bool     // Whether to enforce origin check for streamable server
 	AllowedOrigins     []string // Comma-separated list of allowed origins
+	TLSCertFile        string   // TLS certificate file; enables HTTPS on all HTTP listeners
+	TLSKeyFile         string   // TLS private key file
+	ReadHeaderTimeout  int      // Time allowed to read request headers (seconds)
+	ReadTimeout        int      // Time allowed to read an entire request (seconds)
+	WriteTimeout       int      // Time allowed to write a response (seconds)
+	IdleTimeout        int      // Time a keep-alive connection may sit idle (seconds)
+	MaxHeaderBytes     int      // Maximum size of request headers
+	MaxBodyBytes       int64    // Maximum size of a request body or WebSocket message
+	ReusePort          bool     // Bind listeners with SO_REUSEPORT for overlapping restarts
+	InstanceID         string   // Identifies this replica in the shared store (defaults to hostname)
+	StoreBackend       string   // Shared state backend: "memory" or "redis"
+	RedisURL           string   // Redis connection URL for the redis store backend
+	SharedSessions     bool     // List sessions and share their state and broadcasts across replicas through the store
+
+	BindAddress               string   // Interface all listeners bind to; empty means all interfaces
+	HTTPBindAddress           string   // Interface for the HTTP API server (defaults to BindAddress)
+	StreamableHTTPBindAddress string   // Interface for the Streamable HTTP server (defaults to BindAddress)
+	WebSocketBindAddress      string   // Interface for the WebSocket server (defaults to BindAddress)
+	IPFamily                  string   // Listener address family: dual, ipv4, or ipv6
+	TrustedProxies            []string // CIDRs or addresses whose forwarding headers are trusted
+
+	// ToolDefaults holds per-tool default arguments keyed by tool name
+	ToolDefaults map[string]map[string]interface{}
+	// ToolDefaultVersions is a JSON object of the version each versioned tool's bare name runs; see ToolDefaultVersionMap
+	ToolDefaultVersions string
+	// ToolAliases is a JSON object of additional tool names and the tools they stand for; see ToolAliasMap
+	ToolAliases string
+	// OutputSchemaStrict fails calls whose results do not match their tool's output schema
+	OutputSchemaStrict bool
+
+	QuarantineFailureRate float64 // Failure rate (0-1) that disables a tool; 0 turns quarantine off
+	QuarantineMinCalls    int     // Calls in the window before the failure rate is evaluated
+	QuarantineWindow      int     // Window over which tool failures are counted (seconds)
+	QuarantineCooldown    int     // Time a quarantined tool stays disabled (seconds)
+
+	BreakerFailures int // Consecutive failures that open a tool's circuit breaker; 0 turns it off
+	BreakerCooldown int // Time an open circuit rejects calls before a trial call (seconds)
+
+	// Past any of these limits the server fails readiness and rejects new sessions; 0 turns a limit off
+	OverloadMaxInFlight   int // Tool calls running or queued at once
+	OverloadMaxGoroutines int // Goroutines in the process
+	OverloadMaxMemoryMB   int // Live heap after garbage collection (MiB)
+	OverloadRetryAfter    int // Retry-After sent while overloaded (seconds)
+
+	HealthCheckInterval int // Time between tool health checks (seconds); 0 runs them only on demand
+	HealthCheckTimeout  int // Time each tool health check may take (seconds)
+
+	LazyTools  []string // Tools built on first use instead of at startup, such as geoip or k8s_get_pods
+	ToolWarmUp bool     // Whether lazy tools are built in the background once the server has started
+
+	HTTPClientTimeout int    // Default time limit for tools' outbound HTTP requests (seconds)
+	HTTPClientProxy   string // Proxy URL for tools' outbound requests; empty uses HTTP_PROXY and HTTPS_PROXY
+	HTTPClientCAFile  string // PEM bundle tools trust in addition to the system roots
+
+	EgressAllow       []string // CIDRs and host names tools may reach; empty allows any that are not denied
+	EgressDeny        []string // CIDRs and host names tools may not reach
+	EgressDefaultDeny bool     // Whether loopback, link-local, and cloud metadata destinations are denied as well
+	EgressAllowLocal  bool     // Whether loopback and unspecified addresses are left out of the default denies
+
+	ExecutionHistorySize     int // Tool executions kept for /admin/executions; 0 turns history off
+	ExecutionHistoryMaxBytes int // Cap on the recorded arguments and result of each execution
+
+	JobsMaxRunning int // Background jobs started through /api/jobs that may run at once
+	JobsRetention  int // Time a finished job's result is kept for polling (seconds)
+
+	ApprovalTools   []string // Tools whose calls wait for operator approval, besides those marked requiresApproval
+	ApprovalTimeout int      // Time a call waits for approval before it is denied (seconds); 0 waits indefinitely
+
+	ToolTimeLimit      int    // Elapsed time each tool execution may take (milliseconds); 0 is unlimited
+	ToolCPUTime        int    // CPU time each exec tool's process may use (milliseconds); 0 is unlimited
+	ToolMaxMemoryBytes int64  // Address space each exec tool's process may map; 0 is unlimited
+	ToolMaxOutputBytes int64  // Largest encoded result a tool may produce; 0 is unlimited
+	ToolQuotas         string // JSON object of per-tool quotas keyed by tool name; see ToolQuotaConfigs
+	ToolThrottles      string // JSON object of per-tool rate and concurrency limits; see ToolThrottleConfigs
+
+	MaxResultBytes  int // Largest encoded tool result returned to clients; 0 turns the limit off
+	ResultCursorTTL int // Time the rest of a paged result stays available to next_page (seconds)
+
+	Tenants string // JSON object of tenants keyed by name; see TenantConfigs
+
+	AdminToken string // Credential every /admin/ request must send; empty admits only local connections
+
+	ToolRegistration []string // Declarative tool types /admin/tools/register accepts; empty disables it
+	HTTPTools        string   // JSON array of HTTP tool definitions; see HTTPToolDefinitions
+	HTTPToolsFile    string   // File holding HTTP tool definitions, used when HTTPTools is unset
+	Pipelines        string   // JSON array of pipeline definitions; see PipelineDefinitions
+	PipelinesFile    string   // File holding pipeline definitions, used when Pipelines is unset
+	Schedules        string   // JSON array of scheduled tool runs; see ScheduleConfigs
+	SchedulesFile    string   // File holding schedules, used when Schedules is unset
+
+	PolicyRules      string // JSON array of policy rules; see PolicyRuleList
+	PolicyRulesFile  string // File holding policy rules, used when PolicyRules is unset
+	PolicyDefault    string // Verdict on calls no policy rule decides: allow or deny
+	PolicyOPAURL     string // Open Policy Agent rule queried for every tool call, e.g. http://opa:8181/v1/data/mcp/authz
+	PolicyOPATimeout int    // Timeout for each policy agent query (seconds)
+
+	WebhookURLs       []string // Endpoints that receive server events as JSON
+	WebhookEvents     []string // Event types sent to the webhooks
+	WebhookSecret     string   // Key for the HMAC-SHA256 signature of webhook bodies
+	WebhookMaxRetries int      // Retries after a failed webhook delivery
+	WebhookTimeout    int      // Timeout for each webhook delivery attempt (seconds)
+
+	LogLevel      string // Minimum log level: debug, info, warn, or error
+	LogFormat     string // Log format: text or json
+	LogFile       string // Log destination: stdout, stderr, or a file path
+	LogMaxSizeMB  int    // Size at which the log file is rotated (megabytes)
+	LogMaxBackups int    // Rotated log files to keep
+	LogMaxAgeDays int    // Days to keep rotated log files
+	LogSafeMode   bool   // Log tool arguments and results as hashes and sizes instead of values
+
+	StdioHeartbeatInterval int    // Time between stdio server heartbeat logs (seconds); 0 turns them off
+	SessionRecordDir       string // Directory receiving a JSON-RPC recording per session; empty turns recording off
+
+	BatchMaxSize  int  // Most messages in a JSON-RPC batch; 0 means no limit
+	BatchParallel bool // Run the requests of a JSON-RPC batch concurrently
+
+	ToolsListPageSize int  // Tools per tools/list and /api/list page; 0 lists every tool at once
+	ToolSearch        bool // Register the search_tools tool
+	ServerInfo        bool // Register the server_info tool
+
+	// Streamable HTTP SSE events are kept in the shared store (STORE_BACKEND) so clients
+	// can resume a stream with Last-Event-ID. With the redis backend any replica can
+	// resume any stream, so load balancers need no sticky sessions for SSE.
+	SSEEventHistory int // Events kept per SSE stream for resuming; 0 turns event IDs and resuming off
+	SSEEventTTL     int // Time a stream's events are kept after its latest event (seconds)
+
+	// Per-transport timeouts and keepalives. The Streamable HTTP timeouts default to the
+	// shared READ_TIMEOUT, WRITE_TIMEOUT, and IDLE_TIMEOUT and do not apply to SSE streams.
+	StreamableReadTimeout  int // Time allowed to read a Streamable HTTP request (seconds)
+	StreamableWriteTimeout int // Time allowed to write a Streamable HTTP response (seconds)
+	StreamableIdleTimeout  int // Time an idle Streamable HTTP keep-alive connection stays open (seconds)
+	SSEKeepAliveInterval   int // Time between keepalive comments on idle SSE streams (seconds)
+	SSERetryMS             int // Reconnection delay suggested to SSE clients (milliseconds); 0 sends none
+	WebSocketPingInterval  int // Time between WebSocket pings (seconds); 0 turns them off
+	WebSocketPongTimeout   int // Time a WebSocket client has to answer a ping (seconds)
+	WebSocketResumeTTL     int // Time a dropped WebSocket session can be resumed (seconds); 0 turns resuming off
+
+	// A stateless Streamable HTTP server keeps no sessions, so any replica can handle any
+	// request, but clients cannot open the SSE stream for server-initiated messages.
+	StreamableStateless bool
+
+	ChaosEnabled      bool    // Inject faults for client testing; never enable in production
+	ChaosLatencyRate  float64 // Fraction (0-1) of tool calls delayed
+	ChaosLatencyMaxMS int     // Longest injected delay (milliseconds)
+	ChaosErrorRate    float64 // Fraction (0-1) of tool calls failed
+	ChaosSSEDropRate  float64 // Fraction (0-1) of Streamable HTTP SSE events dropped
+
+	RedactRules    []string // Built-in redaction rules applied to tool results and logs
+	RedactPatterns string   // JSON object of custom redaction regexes keyed by rule name; see RedactPatternMap
+
+	errs    []error           // Problems found while loading, reported by Err
+	sources map[string]Source // Settings overridden after loading; see SetSource
 }
 
 // getEnvInt reads an int from the environment or returns the default
@@ -27,6 +189,24 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// getEnvFloat reads a float from the environment or returns the default
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if val, ok := os.LookupEnv(key); ok {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	}
+	return defaultVal
+}
+
+// getEnvString reads a string from the environment or returns the default
+func getEnvString(key, defaultVal string) string {
+	if val, ok := os.LookupEnv(key); ok && val != "" {
+		return val
+	}
+	return defaultVal
+}
+
 // getEnvBool reads a bool from the environment or returns the default
 func getEnvBool(key string, defaultVal bool) bool {
 	if val, ok := os.LookupEnv(key); ok {
@@ -47,19 +227,445 @@ func getEnvStringSlice(key string, defaultVal []string) []string {
 	return defaultVal
 }
 
+// getEnvToolDefaults reads per-tool default arguments from a JSON object of the form
+// {"tool_name": {"arg": value}}. It returns nil when unset, and an error when invalid
+func getEnvToolDefaults(key string) (map[string]map[string]interface{}, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return nil, nil
+	}
+	var defaults map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(val), &defaults); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return defaults, nil
+}
+
+// TenantConfig describes one tenant in the TENANTS JSON object.
+type TenantConfig struct {
+	APIKey    string   `json:"apiKey"`
+	Tools     []string `json:"tools,omitempty"`
+	RateLimit float64  `json:"rateLimit,omitempty"`
+	Burst     int      `json:"burst,omitempty"`
+}
+
+// TenantConfigs parses Tenants, a JSON object of the form
+// {"team": {"apiKey": "...", "tools": ["generate_uuid"], "rateLimit": 5, "burst": 10}}.
+// It returns nil when no tenants are configured. Unlike other settings, invalid JSON is
+// an error rather than falling back to a default, since the fallback would disable
+// authentication.
+func (c *ServerConfig) TenantConfigs() (map[string]TenantConfig, error) {
+	if c.Tenants == "" {
+		return nil, nil
+	}
+	var tenants map[string]TenantConfig
+	if err := json.Unmarshal([]byte(c.Tenants), &tenants); err != nil {
+		return nil, fmt.Errorf("invalid TENANTS: %w", err)
+	}
+	return tenants, nil
+}
+
+// HTTPToolDefinitions parses the HTTP tools defined in HTTPTools or, if it is unset, the
+// file named by HTTPToolsFile: a JSON array of tools.Definition with the type defaulting
+// to http. It returns nil when no tools are defined. Like TENANTS, invalid definitions
+// are an error so a typo does not silently drop a tool.
+func (c *ServerConfig) HTTPToolDefinitions() ([]tools.Definition, error) {
+	data := []byte(c.HTTPTools)
+	if c.HTTPTools == "" {
+		if c.HTTPToolsFile == "" {
+			return nil, nil
+		}
+		var err error
+		if data, err = os.ReadFile(c.HTTPToolsFile); err != nil {
+			return nil, fmt.Errorf("failed to read HTTP_TOOLS_FILE: %w", err)
+		}
+	}
+	var definitions []tools.Definition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid HTTP tools: %w", err)
+	}
+	for i := range definitions {
+		if definitions[i].Type == "" {
+			definitions[i].Type = tools.DefinitionHTTP
+		}
+		if definitions[i].Type != tools.DefinitionHTTP {
+			return nil, fmt.Errorf("HTTP tool %s has type %q; only http tools can be configured", definitions[i].Name, definitions[i].Type)
+		}
+		if err := definitions[i].Validate(); err != nil {
+			return nil, fmt.Errorf("HTTP tool %d: %w", i, err)
+		}
+	}
+	return definitions, nil
+}
+
+// PipelineDefinitions parses the pipelines defined in Pipelines or, if it is unset, the
+// file named by PipelinesFile: a JSON array of tools.PipelineDefinition. It returns nil
+// when no pipelines are defined. Invalid definitions are an error, as for HTTP tools.
+func (c *ServerConfig) PipelineDefinitions() ([]tools.PipelineDefinition, error) {
+	data := []byte(c.Pipelines)
+	if c.Pipelines == "" {
+		if c.PipelinesFile == "" {
+			return nil, nil
+		}
+		var err error
+		if data, err = os.ReadFile(c.PipelinesFile); err != nil {
+			return nil, fmt.Errorf("failed to read PIPELINES_FILE: %w", err)
+		}
+	}
+	var definitions []tools.PipelineDefinition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid pipelines: %w", err)
+	}
+	for i, definition := range definitions {
+		if err := definition.Validate(); err != nil {
+			return nil, fmt.Errorf("pipeline %d: %w", i, err)
+		}
+	}
+	return definitions, nil
+}
+
+// PolicyRuleList parses the policy rules defined in PolicyRules or, if it is unset, the
+// file named by PolicyRulesFile: a JSON array of policy.Rule. It returns nil when neither
+// is set. The rules' expressions are compiled by policy.NewRuleSet.
+func (c *ServerConfig) PolicyRuleList() ([]policy.Rule, error) {
+	if c.PolicyDefault != policy.EffectAllow && c.PolicyDefault != policy.EffectDeny {
+		return nil, fmt.Errorf("POLICY_DEFAULT must be %s or %s", policy.EffectAllow, policy.EffectDeny)
+	}
+	data := []byte(c.PolicyRules)
+	if c.PolicyRules == "" {
+		if c.PolicyRulesFile == "" {
+			return nil, nil
+		}
+		var err error
+		if data, err = os.ReadFile(c.PolicyRulesFile); err != nil {
+			return nil, fmt.Errorf("failed to read POLICY_RULES_FILE: %w", err)
+		}
+	}
+	var rules []policy.Rule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid policy rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ToolQuotaConfig describes one tool's entry in the TOOL_QUOTAS JSON object. Zero fields
+// are unlimited.
+type ToolQuotaConfig struct {
+	TimeLimitMS    int   `json:"timeLimitMs"`
+	CPUTimeMS      int   `json:"cpuTimeMs"`
+	MaxMemoryBytes int64 `json:"maxMemoryBytes"`
+	MaxOutputBytes int64 `json:"maxOutputBytes"`
+}
+
+// ToolQuotaConfigs parses ToolQuotas, a JSON object of the form
+// {"image_resize": {"timeLimitMs": 2000, "maxOutputBytes": 5242880}}. It returns nil when no
+// quotas are configured. Invalid JSON is an error, since the fallback would leave tools
+// unlimited.
+func (c *ServerConfig) ToolQuotaConfigs() (map[string]ToolQuotaConfig, error) {
+	if c.ToolQuotas == "" {
+		return nil, nil
+	}
+	var quotas map[string]ToolQuotaConfig
+	if err := json.Unmarshal([]byte(c.ToolQuotas), &quotas); err != nil {
+		return nil, fmt.Errorf("invalid TOOL_QUOTAS: %w", err)
+	}
+	return quotas, nil
+}
+
+// ToolThrottleConfig describes one tool's entry in the TOOL_THROTTLES JSON object. Zero
+// fields are unlimited, and the string "unlimited" may stand for the whole entry.
+type ToolThrottleConfig struct {
+	Rate        float64 `json:"rate"`        // Calls per second
+	Burst       int     `json:"burst"`       // Calls allowed at once above the rate
+	Concurrency int     `json:"concurrency"` // Calls running at once
+	Mode        string  `json:"mode"`        // "reject" (default) or "queue"
+	MaxWaitMS   int     `json:"maxWaitMs"`   // Longest a queued call waits; 0 waits for the request's deadline
+}
+
+// UnmarshalJSON accepts "unlimited" as well as an object.
+func (t *ToolThrottleConfig) UnmarshalJSON(data []byte) error {
+	var keyword string
+	if err := json.Unmarshal(data, &keyword); err == nil {
+		if keyword != "unlimited" {
+			return fmt.Errorf("unknown throttle %q; use an object or \"unlimited\"", keyword)
+		}
+		*t = ToolThrottleConfig{}
+		return nil
+	}
+	type plain ToolThrottleConfig
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// ToolThrottleConfigs parses ToolThrottles, a JSON object of the form
+// {"http_fetch": {"rate": 5, "concurrency": 2, "mode": "queue"}, "hash": "unlimited"}. It
+// returns nil when no throttles are configured. Invalid JSON or an unknown mode is an
+// error, since the fallback would leave expensive tools unlimited.
+func (c *ServerConfig) ToolThrottleConfigs() (map[string]ToolThrottleConfig, error) {
+	if c.ToolThrottles == "" {
+		return nil, nil
+	}
+	var throttles map[string]ToolThrottleConfig
+	if err := json.Unmarshal([]byte(c.ToolThrottles), &throttles); err != nil {
+		return nil, fmt.Errorf("invalid TOOL_THROTTLES: %w", err)
+	}
+	for name, throttle := range throttles {
+		if throttle.Mode != "" && throttle.Mode != "reject" && throttle.Mode != "queue" {
+			return nil, fmt.Errorf("invalid TOOL_THROTTLES: tool %s: unknown mode %q; use reject or queue", name, throttle.Mode)
+		}
+	}
+	return throttles, nil
+}
+
+// ScheduleConfig describes one entry in the SCHEDULES JSON array.
+type ScheduleConfig struct {
+	Name      string                 `json:"name"`
+	Cron      string                 `json:"cron"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Disabled  bool                   `json:"disabled,omitempty"`
+}
+
+// ScheduleConfigs parses the schedules defined in Schedules or, if it is unset, the file
+// named by SchedulesFile: a JSON array of the form
+// [{"name": "nightly", "cron": "0 2 * * *", "tool": "dir_hash", "arguments": {...}}].
+// It returns nil when no schedules are defined. Invalid JSON is an error, as for HTTP
+// tools; cron expressions and tools are checked when the schedules are added.
+func (c *ServerConfig) ScheduleConfigs() ([]ScheduleConfig, error) {
+	data := []byte(c.Schedules)
+	if c.Schedules == "" {
+		if c.SchedulesFile == "" {
+			return nil, nil
+		}
+		var err error
+		if data, err = os.ReadFile(c.SchedulesFile); err != nil {
+			return nil, fmt.Errorf("failed to read SCHEDULES_FILE: %w", err)
+		}
+	}
+	var schedules []ScheduleConfig
+	if err := json.Unmarshal(data, &schedules); err != nil {
+		return nil, fmt.Errorf("invalid schedules: %w", err)
+	}
+	return schedules, nil
+}
+
+// RedactPatternMap parses RedactPatterns, a JSON object of the form
+// {"employee_id": "EMP-[0-9]{6}"}. It returns nil when no patterns are configured. Invalid
+// JSON is an error, since the fallback would let the values through unredacted.
+func (c *ServerConfig) RedactPatternMap() (map[string]string, error) {
+	if c.RedactPatterns == "" {
+		return nil, nil
+	}
+	var patterns map[string]string
+	if err := json.Unmarshal([]byte(c.RedactPatterns), &patterns); err != nil {
+		return nil, fmt.Errorf("invalid REDACT_PATTERNS: %w", err)
+	}
+	return patterns, nil
+}
+
+// ToolDefaultVersionMap parses ToolDefaultVersions, a JSON object of the form
+// {"convert": "v1"}. It returns nil when no versions are configured. Invalid JSON is an
+// error, like TENANTS, so a typo does not silently switch callers to another version.
+func (c *ServerConfig) ToolDefaultVersionMap() (map[string]string, error) {
+	if c.ToolDefaultVersions == "" {
+		return nil, nil
+	}
+	var versions map[string]string
+	if err := json.Unmarshal([]byte(c.ToolDefaultVersions), &versions); err != nil {
+		return nil, fmt.Errorf("invalid TOOL_DEFAULT_VERSIONS: %w", err)
+	}
+	return versions, nil
+}
+
+// ToolAliasMap parses ToolAliases, a JSON object of the form {"uuid": "generate_uuid"}.
+// It returns nil when no aliases are configured. Invalid JSON is an error, like
+// TOOL_DEFAULT_VERSIONS.
+func (c *ServerConfig) ToolAliasMap() (map[string]string, error) {
+	if c.ToolAliases == "" {
+		return nil, nil
+	}
+	var aliases map[string]string
+	if err := json.Unmarshal([]byte(c.ToolAliases), &aliases); err != nil {
+		return nil, fmt.Errorf("invalid TOOL_ALIASES: %w", err)
+	}
+	return aliases, nil
+}
+
 // NewServerConfig creates a new server configuration using environment variables or defaults
 func NewServerConfig() *ServerConfig {
-	return &ServerConfig{
+	bindAddress := getEnvString("BIND_ADDRESS", "")
+	c := &ServerConfig{
 		HTTPPort:           getEnvInt("HTTP_PORT", 8080),
 		StreamableHTTPPort: getEnvInt("STREAMABLE_HTTP_PORT", 8081),
 		WebSocketPort:      getEnvInt("WEBSOCKET_PORT", 8082),
 		ShutdownTimeout:    getEnvInt("SHUTDOWN_TIMEOUT", 30),
+		StartupGracePeriod: getEnvInt("STARTUP_GRACE_PERIOD", 0),
+		ShutdownDrainDelay: getEnvInt("SHUTDOWN_DRAIN_DELAY", 0),
+		StartupSummary:     getEnvString("STARTUP_SUMMARY", "stderr"),
 		EnableOriginCheck:  getEnvBool("ENABLE_ORIGIN_CHECK", false),
 		AllowedOrigins:     getEnvStringSlice("ALLOWED_ORIGINS", []string{"*"}),
+		TLSCertFile:        getEnvString("TLS_CERT_FILE", ""),
+		TLSKeyFile:         getEnvString("TLS_KEY_FILE", ""),
+		ReadHeaderTimeout:  getEnvInt("READ_HEADER_TIMEOUT", 10),
+		ReadTimeout:        getEnvInt("READ_TIMEOUT", 30),
+		WriteTimeout:       getEnvInt("WRITE_TIMEOUT", 30),
+		IdleTimeout:        getEnvInt("IDLE_TIMEOUT", 120),
+		MaxHeaderBytes:     getEnvInt("MAX_HEADER_BYTES", 1<<20),
+		MaxBodyBytes:       int64(getEnvInt("MAX_BODY_BYTES", 1<<20)),
+		ReusePort:          getEnvBool("REUSE_PORT", false),
+		InstanceID:         getEnvString("INSTANCE_ID", defaultInstanceID()),
+		StoreBackend:       getEnvString("STORE_BACKEND", "memory"),
+		RedisURL:           getEnvString("REDIS_URL", ""),
+		SharedSessions:     getEnvBool("SHARED_SESSIONS", false),
+
+		ToolDefaultVersions: getEnvString("TOOL_DEFAULT_VERSIONS", ""),
+		ToolAliases:         getEnvString("TOOL_ALIASES", ""),
+		OutputSchemaStrict:  getEnvBool("OUTPUT_SCHEMA_STRICT", false),
+
+		BindAddress:               bindAddress,
+		HTTPBindAddress:           getEnvString("HTTP_BIND_ADDRESS", bindAddress),
+		StreamableHTTPBindAddress: getEnvString("STREAMABLE_HTTP_BIND_ADDRESS", bindAddress),
+		WebSocketBindAddress:      getEnvString("WEBSOCKET_BIND_ADDRESS", bindAddress),
+		IPFamily:                  getEnvString("IP_FAMILY", "dual"),
+		TrustedProxies:            getEnvStringSlice("TRUSTED_PROXIES", nil),
+
+		QuarantineFailureRate: getEnvFloat("QUARANTINE_FAILURE_RATE", 0),
+		QuarantineMinCalls:    getEnvInt("QUARANTINE_MIN_CALLS", 5),
+		QuarantineWindow:      getEnvInt("QUARANTINE_WINDOW", 60),
+		QuarantineCooldown:    getEnvInt("QUARANTINE_COOLDOWN", 30),
+
+		BreakerFailures: getEnvInt("BREAKER_FAILURES", 0),
+		BreakerCooldown: getEnvInt("BREAKER_COOLDOWN", 30),
+
+		OverloadMaxInFlight:   getEnvInt("OVERLOAD_MAX_IN_FLIGHT", 0),
+		OverloadMaxGoroutines: getEnvInt("OVERLOAD_MAX_GOROUTINES", 0),
+		OverloadMaxMemoryMB:   getEnvInt("OVERLOAD_MAX_MEMORY_MB", 0),
+		OverloadRetryAfter:    getEnvInt("OVERLOAD_RETRY_AFTER", 5),
+
+		HealthCheckInterval: getEnvInt("HEALTH_CHECK_INTERVAL", 30),
+		HealthCheckTimeout:  getEnvInt("HEALTH_CHECK_TIMEOUT", 5),
+
+		LazyTools:  getEnvStringSlice("LAZY_TOOLS", nil),
+		ToolWarmUp: getEnvBool("TOOL_WARMUP", true),
+
+		HTTPClientTimeout: getEnvInt("HTTP_CLIENT_TIMEOUT", 30),
+		HTTPClientProxy:   getEnvString("HTTP_CLIENT_PROXY", ""),
+		HTTPClientCAFile:  getEnvString("HTTP_CLIENT_CA_FILE", ""),
+
+		EgressAllow:       getEnvStringSlice("EGRESS_ALLOW", nil),
+		EgressDeny:        getEnvStringSlice("EGRESS_DENY", nil),
+		EgressDefaultDeny: getEnvBool("EGRESS_DEFAULT_DENY", true),
+		EgressAllowLocal:  getEnvBool("EGRESS_ALLOW_LOCAL", false),
+
+		ExecutionHistorySize:     getEnvInt("EXECUTION_HISTORY_SIZE", 0),
+		ExecutionHistoryMaxBytes: getEnvInt("EXECUTION_HISTORY_MAX_BYTES", 64*1024),
+
+		JobsMaxRunning: getEnvInt("JOBS_MAX_RUNNING", 16),
+		JobsRetention:  getEnvInt("JOBS_RETENTION", 3600),
+
+		ApprovalTools:   getEnvStringSlice("APPROVAL_TOOLS", nil),
+		ApprovalTimeout: getEnvInt("APPROVAL_TIMEOUT", 3600),
+
+		ToolTimeLimit:      getEnvInt("TOOL_TIME_LIMIT", 0),
+		ToolCPUTime:        getEnvInt("TOOL_CPU_TIME", 0),
+		ToolMaxMemoryBytes: int64(getEnvInt("TOOL_MAX_MEMORY_BYTES", 0)),
+		ToolMaxOutputBytes: int64(getEnvInt("TOOL_MAX_OUTPUT_BYTES", 0)),
+		ToolQuotas:         getEnvString("TOOL_QUOTAS", ""),
+		ToolThrottles:      getEnvString("TOOL_THROTTLES", ""),
+
+		MaxResultBytes:  getEnvInt("MAX_RESULT_BYTES", 0),
+		ResultCursorTTL: getEnvInt("RESULT_CURSOR_TTL", 600),
+
+		Tenants: getEnvString("TENANTS", ""),
+
+		AdminToken: getEnvString("ADMIN_TOKEN", ""),
+
+		ToolRegistration: getEnvStringSlice("TOOL_REGISTRATION", nil),
+		HTTPTools:        getEnvString("HTTP_TOOLS", ""),
+		HTTPToolsFile:    getEnvString("HTTP_TOOLS_FILE", ""),
+		Pipelines:        getEnvString("PIPELINES", ""),
+		PipelinesFile:    getEnvString("PIPELINES_FILE", ""),
+		Schedules:        getEnvString("SCHEDULES", ""),
+		SchedulesFile:    getEnvString("SCHEDULES_FILE", ""),
+
+		PolicyRules:      getEnvString("POLICY_RULES", ""),
+		PolicyRulesFile:  getEnvString("POLICY_RULES_FILE", ""),
+		PolicyDefault:    getEnvString("POLICY_DEFAULT", "allow"),
+		PolicyOPAURL:     getEnvString("POLICY_OPA_URL", ""),
+		PolicyOPATimeout: getEnvInt("POLICY_OPA_TIMEOUT", 5),
+
+		WebhookURLs:       getEnvStringSlice("WEBHOOK_URLS", nil),
+		WebhookEvents:     getEnvStringSlice("WEBHOOK_EVENTS", []string{"tool.quarantined", "server.shutdown"}),
+		WebhookSecret:     getEnvString("WEBHOOK_SECRET", ""),
+		WebhookMaxRetries: getEnvInt("WEBHOOK_MAX_RETRIES", 3),
+		WebhookTimeout:    getEnvInt("WEBHOOK_TIMEOUT", 5),
+
+		LogLevel:      getEnvString("LOG_LEVEL", "info"),
+		LogFormat:     getEnvString("LOG_FORMAT", "text"),
+		LogFile:       getEnvString("LOG_FILE", "stdout"),
+		LogMaxSizeMB:  getEnvInt("LOG_MAX_SIZE_MB", 100),
+		LogMaxBackups: getEnvInt("LOG_MAX_BACKUPS", 3),
+		LogMaxAgeDays: getEnvInt("LOG_MAX_AGE_DAYS", 28),
+		LogSafeMode:   getEnvBool("LOG_SAFE_MODE", false),
+
+		StdioHeartbeatInterval: getEnvInt("STDIO_HEARTBEAT_INTERVAL", 60),
+		SessionRecordDir:       getEnvString("SESSION_RECORD_DIR", ""),
+
+		BatchMaxSize:  getEnvInt("JSONRPC_BATCH_MAX_SIZE", 50),
+		BatchParallel: getEnvBool("JSONRPC_BATCH_PARALLEL", false),
+
+		ToolsListPageSize: getEnvInt("TOOLS_LIST_PAGE_SIZE", 0),
+		ToolSearch:        getEnvBool("TOOL_SEARCH", true),
+		ServerInfo:        getEnvBool("SERVER_INFO", true),
+
+		SSEEventHistory: getEnvInt("SSE_EVENT_HISTORY", 100),
+		SSEEventTTL:     getEnvInt("SSE_EVENT_TTL", 300),
+
+		SSEKeepAliveInterval:  getEnvInt("SSE_KEEPALIVE_INTERVAL", 15),
+		SSERetryMS:            getEnvInt("SSE_RETRY_MS", 0),
+		WebSocketPingInterval: getEnvInt("WEBSOCKET_PING_INTERVAL", 30),
+		WebSocketPongTimeout:  getEnvInt("WEBSOCKET_PONG_TIMEOUT", 10),
+		WebSocketResumeTTL:    getEnvInt("WEBSOCKET_RESUME_TTL", 60),
+
+		StreamableStateless: getEnvBool("STREAMABLE_STATELESS", false),
+
+		ChaosEnabled:      getEnvBool("CHAOS_ENABLED", false),
+		ChaosLatencyRate:  getEnvFloat("CHAOS_LATENCY_RATE", 0),
+		ChaosLatencyMaxMS: getEnvInt("CHAOS_LATENCY_MAX_MS", 1000),
+		ChaosErrorRate:    getEnvFloat("CHAOS_ERROR_RATE", 0),
+		ChaosSSEDropRate:  getEnvFloat("CHAOS_SSE_DROP_RATE", 0),
+
+		RedactRules:    getEnvStringSlice("REDACT_RULES", nil),
+		RedactPatterns: getEnvString("REDACT_PATTERNS", ""),
+	}
+	c.StreamableReadTimeout = getEnvInt("STREAMABLE_READ_TIMEOUT", c.ReadTimeout)
+	c.StreamableWriteTimeout = getEnvInt("STREAMABLE_WRITE_TIMEOUT", c.WriteTimeout)
+	c.StreamableIdleTimeout = getEnvInt("STREAMABLE_IDLE_TIMEOUT", c.IdleTimeout)
+	c.decryptSecrets()
+	var err error
+	if c.ToolDefaults, err = getEnvToolDefaults("TOOL_DEFAULTS"); err != nil {
+		c.errs = append(c.errs, err)
+	}
+	if c.AdminToken == "" && slices.ContainsFunc(c.ToolRegistration, func(toolType string) bool { return strings.TrimSpace(toolType) == "exec" }) {
+		c.errs = append(c.errs, errors.New("TOOL_REGISTRATION: registering exec tools requires ADMIN_TOKEN"))
+	}
+	return c
+}
+
+// Err reports settings that could not be loaded, such as encrypted values that failed to
+// decrypt. The server must not start with such a configuration.
+func (c *ServerConfig) Err() error {
+	return errors.Join(c.errs...)
+}
+
+// defaultInstanceID returns the hostname, which is unique per pod in most deployments
+func defaultInstanceID() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
 	}
+	return "local"
 }
 
 // WebSocketAddr returns the address for the WebSocket server
 func (c *ServerConfig) WebSocketAddr() string {
-	return fmt.Sprintf(":%d", c.WebSocketPort)
+	return net.JoinHostPort(c.WebSocketBindAddress, strconv.Itoa(c.WebSocketPort))
 }
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -93,3 +95,502 @@ func TestGetEnvInt(t *testing.T) {
 		}
 	})
 }
+
+func TestNewServerConfig_SharedStore(t *testing.T) {
+	t.Run("defaults to memory store and hostname instance", func(t *testing.T) {
+		_ = os.Unsetenv("STORE_BACKEND")
+		_ = os.Unsetenv("INSTANCE_ID")
+
+		config := NewServerConfig()
+
+		if config.StoreBackend != "memory" {
+			t.Errorf("Expected StoreBackend memory, got %s", config.StoreBackend)
+		}
+		if config.InstanceID == "" {
+			t.Error("Expected a default InstanceID")
+		}
+	})
+
+	t.Run("reads redis settings from environment", func(t *testing.T) {
+		_ = os.Setenv("STORE_BACKEND", "redis")
+		_ = os.Setenv("REDIS_URL", "redis://localhost:6379/0")
+		_ = os.Setenv("INSTANCE_ID", "pod-a")
+		defer func() {
+			_ = os.Unsetenv("STORE_BACKEND")
+			_ = os.Unsetenv("REDIS_URL")
+			_ = os.Unsetenv("INSTANCE_ID")
+		}()
+
+		config := NewServerConfig()
+
+		if config.StoreBackend != "redis" || config.RedisURL != "redis://localhost:6379/0" || config.InstanceID != "pod-a" {
+			t.Errorf("Unexpected shared store config: %+v", config)
+		}
+	})
+}
+
+func TestNewServerConfig_ToolDefaults(t *testing.T) {
+	t.Run("parses per-tool defaults", func(t *testing.T) {
+		_ = os.Setenv("TOOL_DEFAULTS", `{"generate_uuid":{"version":"v7"}}`)
+		defer func() { _ = os.Unsetenv("TOOL_DEFAULTS") }()
+
+		config := NewServerConfig()
+
+		if got := config.ToolDefaults["generate_uuid"]["version"]; got != "v7" {
+			t.Errorf("Expected generate_uuid version default v7, got %v", got)
+		}
+	})
+
+	t.Run("invalid JSON is reported", func(t *testing.T) {
+		_ = os.Setenv("TOOL_DEFAULTS", `{not json`)
+		defer func() { _ = os.Unsetenv("TOOL_DEFAULTS") }()
+
+		config := NewServerConfig()
+
+		if config.ToolDefaults != nil {
+			t.Errorf("Expected nil ToolDefaults, got %v", config.ToolDefaults)
+		}
+		if err := config.Err(); err == nil || !strings.Contains(err.Error(), "TOOL_DEFAULTS") {
+			t.Errorf("Expected an error naming TOOL_DEFAULTS, got %v", err)
+		}
+	})
+}
+
+func TestNewServerConfig_Quarantine(t *testing.T) {
+	t.Run("quarantine is off by default", func(t *testing.T) {
+		config := NewServerConfig()
+		if config.QuarantineFailureRate != 0 {
+			t.Errorf("Expected QuarantineFailureRate 0, got %v", config.QuarantineFailureRate)
+		}
+		if config.QuarantineMinCalls != 5 || config.QuarantineWindow != 60 || config.QuarantineCooldown != 30 {
+			t.Errorf("Unexpected quarantine defaults: %+v", config)
+		}
+	})
+
+	t.Run("reads failure rate from environment", func(t *testing.T) {
+		_ = os.Setenv("QUARANTINE_FAILURE_RATE", "0.5")
+		defer func() { _ = os.Unsetenv("QUARANTINE_FAILURE_RATE") }()
+
+		if config := NewServerConfig(); config.QuarantineFailureRate != 0.5 {
+			t.Errorf("Expected QuarantineFailureRate 0.5, got %v", config.QuarantineFailureRate)
+		}
+	})
+}
+
+func TestNewServerConfig_ExecutionHistory(t *testing.T) {
+	t.Run("history is off by default", func(t *testing.T) {
+		config := NewServerConfig()
+		if config.ExecutionHistorySize != 0 || config.ExecutionHistoryMaxBytes != 64*1024 {
+			t.Errorf("Unexpected execution history defaults: %d %d", config.ExecutionHistorySize, config.ExecutionHistoryMaxBytes)
+		}
+	})
+
+	t.Run("reads size from environment", func(t *testing.T) {
+		_ = os.Setenv("EXECUTION_HISTORY_SIZE", "50")
+		defer func() { _ = os.Unsetenv("EXECUTION_HISTORY_SIZE") }()
+
+		if config := NewServerConfig(); config.ExecutionHistorySize != 50 {
+			t.Errorf("Expected ExecutionHistorySize 50, got %d", config.ExecutionHistorySize)
+		}
+	})
+}
+
+func TestNewServerConfig_Webhooks(t *testing.T) {
+	t.Run("webhooks are off by default", func(t *testing.T) {
+		config := NewServerConfig()
+		if len(config.WebhookURLs) != 0 || config.WebhookMaxRetries != 3 || config.WebhookTimeout != 5 {
+			t.Errorf("Unexpected webhook defaults: %v %d %d", config.WebhookURLs, config.WebhookMaxRetries, config.WebhookTimeout)
+		}
+		if len(config.WebhookEvents) != 2 || config.WebhookEvents[0] != "tool.quarantined" || config.WebhookEvents[1] != "server.shutdown" {
+			t.Errorf("Unexpected default webhook events: %v", config.WebhookEvents)
+		}
+	})
+
+	t.Run("reads URLs and events from environment", func(t *testing.T) {
+		_ = os.Setenv("WEBHOOK_URLS", "https://a.example/hook,https://b.example/hook")
+		_ = os.Setenv("WEBHOOK_EVENTS", "session.started")
+		defer func() {
+			_ = os.Unsetenv("WEBHOOK_URLS")
+			_ = os.Unsetenv("WEBHOOK_EVENTS")
+		}()
+
+		config := NewServerConfig()
+		if len(config.WebhookURLs) != 2 || config.WebhookURLs[1] != "https://b.example/hook" {
+			t.Errorf("Unexpected webhook URLs: %v", config.WebhookURLs)
+		}
+		if len(config.WebhookEvents) != 1 || config.WebhookEvents[0] != "session.started" {
+			t.Errorf("Unexpected webhook events: %v", config.WebhookEvents)
+		}
+	})
+}
+
+func TestServerConfig_TenantConfigs(t *testing.T) {
+	t.Run("no tenants by default", func(t *testing.T) {
+		tenants, err := NewServerConfig().TenantConfigs()
+		if err != nil || tenants != nil {
+			t.Errorf("Expected no tenants, got %v (%v)", tenants, err)
+		}
+	})
+
+	t.Run("parses tenants from environment", func(t *testing.T) {
+		_ = os.Setenv("TENANTS", `{"team-a": {"apiKey": "key-a", "tools": ["generate_uuid"], "rateLimit": 2}}`)
+		defer func() { _ = os.Unsetenv("TENANTS") }()
+
+		tenants, err := NewServerConfig().TenantConfigs()
+		if err != nil {
+			t.Fatalf("TenantConfigs failed: %v", err)
+		}
+		if tenant := tenants["team-a"]; tenant.APIKey != "key-a" || len(tenant.Tools) != 1 || tenant.RateLimit != 2 {
+			t.Errorf("Unexpected tenant: %+v", tenant)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		_ = os.Setenv("TENANTS", `{"team-a":`)
+		defer func() { _ = os.Unsetenv("TENANTS") }()
+
+		if _, err := NewServerConfig().TenantConfigs(); err == nil {
+			t.Error("Expected an error for invalid TENANTS")
+		}
+	})
+}
+
+func TestServerConfig_ExecRegistration(t *testing.T) {
+	t.Setenv("TOOL_REGISTRATION", "template,exec")
+
+	t.Run("requires an admin token", func(t *testing.T) {
+		if err := NewServerConfig().Err(); err == nil || !strings.Contains(err.Error(), "ADMIN_TOKEN") {
+			t.Errorf("Expected an error naming ADMIN_TOKEN, got %v", err)
+		}
+	})
+
+	t.Run("is allowed with an admin token", func(t *testing.T) {
+		t.Setenv("ADMIN_TOKEN", "operator")
+		if err := NewServerConfig().Err(); err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+}
+
+func TestServerConfig_HTTPToolDefinitions(t *testing.T) {
+	t.Run("no tools by default", func(t *testing.T) {
+		definitions, err := NewServerConfig().HTTPToolDefinitions()
+		if err != nil || definitions != nil {
+			t.Errorf("Expected no tools, got %v (%v)", definitions, err)
+		}
+	})
+
+	t.Run("parses tools from environment", func(t *testing.T) {
+		t.Setenv("HTTP_TOOLS", `[{"name": "status", "description": "Service status", "url": "https://status.example.com/api", "extract": "$.status"}]`)
+
+		definitions, err := NewServerConfig().HTTPToolDefinitions()
+		if err != nil {
+			t.Fatalf("HTTPToolDefinitions failed: %v", err)
+		}
+		if len(definitions) != 1 || definitions[0].Type != "http" || definitions[0].Extract != "$.status" {
+			t.Errorf("Unexpected definitions: %+v", definitions)
+		}
+	})
+
+	t.Run("reads tools from a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "tools.json")
+		if err := os.WriteFile(path, []byte(`[{"name": "status", "description": "Service status", "url": "https://status.example.com"}]`), 0o600); err != nil {
+			t.Fatalf("Failed to write tools file: %v", err)
+		}
+		t.Setenv("HTTP_TOOLS_FILE", path)
+
+		if definitions, err := NewServerConfig().HTTPToolDefinitions(); err != nil || len(definitions) != 1 {
+			t.Errorf("Expected one tool, got %v (%v)", definitions, err)
+		}
+	})
+
+	t.Run("invalid definitions are an error", func(t *testing.T) {
+		for _, value := range []string{
+			`[{"name":`,
+			`[{"name": "status", "description": "Service status"}]`,
+			`[{"type": "exec", "name": "ls", "description": "Lists", "command": ["ls"]}]`,
+		} {
+			t.Setenv("HTTP_TOOLS", value)
+			if _, err := NewServerConfig().HTTPToolDefinitions(); err == nil {
+				t.Errorf("Expected an error for %s", value)
+			}
+		}
+	})
+}
+
+func TestServerConfig_PipelineDefinitions(t *testing.T) {
+	t.Run("no pipelines by default", func(t *testing.T) {
+		definitions, err := NewServerConfig().PipelineDefinitions()
+		if err != nil || definitions != nil {
+			t.Errorf("Expected no pipelines, got %v (%v)", definitions, err)
+		}
+	})
+
+	t.Run("parses pipelines from environment", func(t *testing.T) {
+		t.Setenv("PIPELINES", `[{"name": "new_id", "description": "Labelled ID", "steps": [{"tool": "generate_uuid"}], "output": {"id": "{{$.input.prefix}}-{{$.steps.generate_uuid.uuid}}"}}]`)
+
+		definitions, err := NewServerConfig().PipelineDefinitions()
+		if err != nil {
+			t.Fatalf("PipelineDefinitions failed: %v", err)
+		}
+		if len(definitions) != 1 || definitions[0].Steps[0].Tool != "generate_uuid" {
+			t.Errorf("Unexpected definitions: %+v", definitions)
+		}
+	})
+
+	t.Run("invalid definitions are an error", func(t *testing.T) {
+		for _, value := range []string{
+			`[{"name":`,
+			`[{"name": "empty", "description": "No steps", "steps": []}]`,
+			`[{"name": "bad", "description": "Forward reference", "steps": [{"tool": "a", "args": {"x": "$.steps.b.y"}}, {"tool": "b"}]}]`,
+		} {
+			t.Setenv("PIPELINES", value)
+			if _, err := NewServerConfig().PipelineDefinitions(); err == nil {
+				t.Errorf("Expected an error for %s", value)
+			}
+		}
+	})
+}
+
+func TestServerConfig_ScheduleConfigs(t *testing.T) {
+	t.Run("no schedules by default", func(t *testing.T) {
+		schedules, err := NewServerConfig().ScheduleConfigs()
+		if err != nil || schedules != nil {
+			t.Errorf("Expected no schedules, got %v (%v)", schedules, err)
+		}
+	})
+
+	t.Run("parses schedules from a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "schedules.json")
+		if err := os.WriteFile(path, []byte(`[{"name": "ids", "cron": "@every 5m", "tool": "generate_uuid", "disabled": true}]`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		t.Setenv("SCHEDULES_FILE", path)
+
+		schedules, err := NewServerConfig().ScheduleConfigs()
+		if err != nil {
+			t.Fatalf("ScheduleConfigs failed: %v", err)
+		}
+		if len(schedules) != 1 || schedules[0].Cron != "@every 5m" || !schedules[0].Disabled {
+			t.Errorf("Unexpected schedules: %+v", schedules)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		t.Setenv("SCHEDULES", `[{"name":`)
+		if _, err := NewServerConfig().ScheduleConfigs(); err == nil {
+			t.Error("Expected an error")
+		}
+	})
+}
+
+func TestNewServerConfig_Limits(t *testing.T) {
+	t.Run("uses hardened defaults", func(t *testing.T) {
+		config := NewServerConfig()
+		if config.ReadHeaderTimeout != 10 || config.ReadTimeout != 30 || config.WriteTimeout != 30 || config.IdleTimeout != 120 {
+			t.Errorf("Unexpected timeout defaults: %+v", config)
+		}
+		if config.MaxHeaderBytes != 1<<20 || config.MaxBodyBytes != 1<<20 {
+			t.Errorf("Unexpected size limit defaults: %d %d", config.MaxHeaderBytes, config.MaxBodyBytes)
+		}
+	})
+
+	t.Run("reads limits from environment", func(t *testing.T) {
+		_ = os.Setenv("READ_HEADER_TIMEOUT", "5")
+		_ = os.Setenv("MAX_BODY_BYTES", "2048")
+		defer func() {
+			_ = os.Unsetenv("READ_HEADER_TIMEOUT")
+			_ = os.Unsetenv("MAX_BODY_BYTES")
+		}()
+
+		config := NewServerConfig()
+		if config.ReadHeaderTimeout != 5 || config.MaxBodyBytes != 2048 {
+			t.Errorf("Expected ReadHeaderTimeout 5 and MaxBodyBytes 2048, got %d and %d", config.ReadHeaderTimeout, config.MaxBodyBytes)
+		}
+	})
+
+	t.Run("Streamable HTTP timeouts default to the shared ones", func(t *testing.T) {
+		_ = os.Setenv("READ_TIMEOUT", "60")
+		_ = os.Setenv("STREAMABLE_WRITE_TIMEOUT", "300")
+		defer func() {
+			_ = os.Unsetenv("READ_TIMEOUT")
+			_ = os.Unsetenv("STREAMABLE_WRITE_TIMEOUT")
+		}()
+
+		config := NewServerConfig()
+		if config.StreamableReadTimeout != 60 || config.StreamableWriteTimeout != 300 || config.StreamableIdleTimeout != 120 {
+			t.Errorf("Unexpected Streamable HTTP timeouts: read %d, write %d, idle %d",
+				config.StreamableReadTimeout, config.StreamableWriteTimeout, config.StreamableIdleTimeout)
+		}
+		if config.WriteTimeout != 30 {
+			t.Errorf("Expected the shared WriteTimeout to stay 30, got %d", config.WriteTimeout)
+		}
+	})
+}
+
+func TestNewServerConfig_BindAddress(t *testing.T) {
+	t.Run("binds all interfaces by default", func(t *testing.T) {
+		config := NewServerConfig()
+		if config.HTTPBindAddress != "" || config.StreamableHTTPBindAddress != "" || config.WebSocketBindAddress != "" {
+			t.Errorf("Expected empty bind addresses, got %+v", config)
+		}
+	})
+
+	t.Run("per-transport addresses override BIND_ADDRESS", func(t *testing.T) {
+		_ = os.Setenv("BIND_ADDRESS", "10.0.0.5")
+		_ = os.Setenv("HTTP_BIND_ADDRESS", "127.0.0.1")
+		defer func() {
+			_ = os.Unsetenv("BIND_ADDRESS")
+			_ = os.Unsetenv("HTTP_BIND_ADDRESS")
+		}()
+
+		config := NewServerConfig()
+		if config.HTTPBindAddress != "127.0.0.1" {
+			t.Errorf("Expected HTTPBindAddress 127.0.0.1, got %s", config.HTTPBindAddress)
+		}
+		if config.StreamableHTTPBindAddress != "10.0.0.5" || config.WebSocketBindAddress != "10.0.0.5" {
+			t.Errorf("Expected other transports to use BIND_ADDRESS, got %s and %s", config.StreamableHTTPBindAddress, config.WebSocketBindAddress)
+		}
+	})
+}
+
+func TestServerConfig_WebSocketAddr(t *testing.T) {
+	config := &ServerConfig{WebSocketBindAddress: "::1", WebSocketPort: 8082}
+	if addr := config.WebSocketAddr(); addr != "[::1]:8082" {
+		t.Errorf("Expected [::1]:8082, got %s", addr)
+	}
+}
+
+func TestNewServerConfig_TrustedProxies(t *testing.T) {
+	if config := NewServerConfig(); config.TrustedProxies != nil {
+		t.Errorf("Expected no trusted proxies by default, got %v", config.TrustedProxies)
+	}
+
+	_ = os.Setenv("TRUSTED_PROXIES", "10.0.0.0/8,192.168.1.10")
+	defer func() { _ = os.Unsetenv("TRUSTED_PROXIES") }()
+
+	if config := NewServerConfig(); len(config.TrustedProxies) != 2 {
+		t.Errorf("Expected 2 trusted proxies, got %v", config.TrustedProxies)
+	}
+}
+
+func TestServerConfig_RedactPatternMap(t *testing.T) {
+	t.Run("parses patterns by rule name", func(t *testing.T) {
+		_ = os.Setenv("REDACT_RULES", "email,secrets")
+		_ = os.Setenv("REDACT_PATTERNS", `{"employee_id":"EMP-[0-9]{6}"}`)
+		defer func() {
+			_ = os.Unsetenv("REDACT_RULES")
+			_ = os.Unsetenv("REDACT_PATTERNS")
+		}()
+
+		config := NewServerConfig()
+		patterns, err := config.RedactPatternMap()
+		if err != nil {
+			t.Fatalf("RedactPatternMap failed: %v", err)
+		}
+		if patterns["employee_id"] != "EMP-[0-9]{6}" {
+			t.Errorf("Expected employee_id pattern, got %v", patterns)
+		}
+		if len(config.RedactRules) != 2 || config.RedactRules[1] != "secrets" {
+			t.Errorf("Expected rules [email secrets], got %v", config.RedactRules)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		config := &ServerConfig{RedactPatterns: `{not json`}
+		if _, err := config.RedactPatternMap(); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+}
+
+func TestServerConfig_ToolDefaultVersionMap(t *testing.T) {
+	t.Run("parses versions by tool name", func(t *testing.T) {
+		t.Setenv("TOOL_DEFAULT_VERSIONS", `{"convert":"v1"}`)
+		versions, err := NewServerConfig().ToolDefaultVersionMap()
+		if err != nil || versions["convert"] != "v1" {
+			t.Errorf("Expected convert v1, got %v, %v", versions, err)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		config := &ServerConfig{ToolDefaultVersions: `{not json`}
+		if _, err := config.ToolDefaultVersionMap(); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+}
+
+func TestServerConfig_ToolAliasMap(t *testing.T) {
+	t.Run("parses aliases", func(t *testing.T) {
+		t.Setenv("TOOL_ALIASES", `{"uuid":"generate_uuid"}`)
+		aliases, err := NewServerConfig().ToolAliasMap()
+		if err != nil || aliases["uuid"] != "generate_uuid" {
+			t.Errorf("Expected uuid alias, got %v, %v", aliases, err)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		config := &ServerConfig{ToolAliases: `["uuid"]`}
+		if _, err := config.ToolAliasMap(); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+}
+
+func TestServerConfig_ToolQuotaConfigs(t *testing.T) {
+	t.Run("parses quotas by tool name", func(t *testing.T) {
+		_ = os.Setenv("TOOL_QUOTAS", `{"image_resize":{"timeLimitMs":2000,"cpuTimeMs":500,"maxMemoryBytes":4096,"maxOutputBytes":1024}}`)
+		defer func() { _ = os.Unsetenv("TOOL_QUOTAS") }()
+
+		quotas, err := NewServerConfig().ToolQuotaConfigs()
+		if err != nil {
+			t.Fatalf("ToolQuotaConfigs failed: %v", err)
+		}
+		if quota := quotas["image_resize"]; quota.TimeLimitMS != 2000 || quota.CPUTimeMS != 500 || quota.MaxMemoryBytes != 4096 || quota.MaxOutputBytes != 1024 {
+			t.Errorf("Unexpected quota: %+v", quota)
+		}
+	})
+
+	t.Run("no quotas by default", func(t *testing.T) {
+		config := NewServerConfig()
+		if quotas, err := config.ToolQuotaConfigs(); quotas != nil || err != nil {
+			t.Errorf("Expected no quotas, got %v, %v", quotas, err)
+		}
+		if config.ToolTimeLimit != 0 || config.ToolCPUTime != 0 || config.ToolMaxMemoryBytes != 0 || config.ToolMaxOutputBytes != 0 {
+			t.Errorf("Expected unlimited defaults, got %+v", config)
+		}
+	})
+
+	t.Run("invalid JSON is an error", func(t *testing.T) {
+		config := &ServerConfig{ToolQuotas: `{not json`}
+		if _, err := config.ToolQuotaConfigs(); err == nil {
+			t.Error("Expected error for invalid JSON")
+		}
+	})
+}
+
+func TestServerConfig_ToolThrottleConfigs(t *testing.T) {
+	t.Run("parses throttles and the unlimited keyword", func(t *testing.T) {
+		config := &ServerConfig{ToolThrottles: `{"http_fetch":{"rate":5,"concurrency":2,"mode":"queue","maxWaitMs":500},"hash":"unlimited"}`}
+		throttles, err := config.ToolThrottleConfigs()
+		if err != nil {
+			t.Fatalf("ToolThrottleConfigs failed: %v", err)
+		}
+		want := ToolThrottleConfig{Rate: 5, Concurrency: 2, Mode: "queue", MaxWaitMS: 500}
+		if throttles["http_fetch"] != want {
+			t.Errorf("Expected %+v, got %+v", want, throttles["http_fetch"])
+		}
+		if hash, ok := throttles["hash"]; !ok || hash != (ToolThrottleConfig{}) {
+			t.Errorf("Expected an unlimited entry for hash, got %+v", hash)
+		}
+	})
+
+	t.Run("rejects unknown modes and keywords", func(t *testing.T) {
+		for _, raw := range []string{`{"hash":{"mode":"drop"}}`, `{"hash":"sometimes"}`, `{not json`} {
+			if _, err := (&ServerConfig{ToolThrottles: raw}).ToolThrottleConfigs(); err == nil {
+				t.Errorf("Expected an error for %s", raw)
+			}
+		}
+	})
+}
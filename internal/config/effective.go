@@ -0,0 +1,228 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// Source says where the effective value of a setting came from.
+type Source string
+
+// Setting sources, from lowest to highest precedence.
+const (
+	SourceDefault Source = "default" // Built-in default
+	SourceEnv     Source = "env"     // Environment variable
+	SourceFlag    Source = "flag"    // Command-line flag
+)
+
+// redacted replaces the value of secrets that are set.
+const redacted = "[REDACTED]"
+
+// Setting is the effective value of one setting, named by its environment variable.
+type Setting struct {
+	Name    string      `json:"name"`
+	Value   interface{} `json:"value"`
+	Source  Source      `json:"source"`
+	Ignored string      `json:"ignored,omitempty"` // An environment value that could not be parsed
+}
+
+// fields returns a pointer to every setting, keyed by environment variable.
+func (c *ServerConfig) fields() map[string]interface{} {
+	return map[string]interface{}{
+		"HTTP_PORT":                    &c.HTTPPort,
+		"STREAMABLE_HTTP_PORT":         &c.StreamableHTTPPort,
+		"WEBSOCKET_PORT":               &c.WebSocketPort,
+		"SHUTDOWN_TIMEOUT":             &c.ShutdownTimeout,
+		"STARTUP_GRACE_PERIOD":         &c.StartupGracePeriod,
+		"SHUTDOWN_DRAIN_DELAY":         &c.ShutdownDrainDelay,
+		"STARTUP_SUMMARY":              &c.StartupSummary,
+		"ENABLE_ORIGIN_CHECK":          &c.EnableOriginCheck,
+		"ALLOWED_ORIGINS":              &c.AllowedOrigins,
+		"TLS_CERT_FILE":                &c.TLSCertFile,
+		"TLS_KEY_FILE":                 &c.TLSKeyFile,
+		"READ_HEADER_TIMEOUT":          &c.ReadHeaderTimeout,
+		"READ_TIMEOUT":                 &c.ReadTimeout,
+		"WRITE_TIMEOUT":                &c.WriteTimeout,
+		"IDLE_TIMEOUT":                 &c.IdleTimeout,
+		"MAX_HEADER_BYTES":             &c.MaxHeaderBytes,
+		"MAX_BODY_BYTES":               &c.MaxBodyBytes,
+		"REUSE_PORT":                   &c.ReusePort,
+		"INSTANCE_ID":                  &c.InstanceID,
+		"STORE_BACKEND":                &c.StoreBackend,
+		"REDIS_URL":                    &c.RedisURL,
+		"SHARED_SESSIONS":              &c.SharedSessions,
+		"BIND_ADDRESS":                 &c.BindAddress,
+		"HTTP_BIND_ADDRESS":            &c.HTTPBindAddress,
+		"STREAMABLE_HTTP_BIND_ADDRESS": &c.StreamableHTTPBindAddress,
+		"WEBSOCKET_BIND_ADDRESS":       &c.WebSocketBindAddress,
+		"IP_FAMILY":                    &c.IPFamily,
+		"TRUSTED_PROXIES":              &c.TrustedProxies,
+		"TOOL_DEFAULTS":                &c.ToolDefaults,
+		"TOOL_DEFAULT_VERSIONS":        &c.ToolDefaultVersions,
+		"TOOL_ALIASES":                 &c.ToolAliases,
+		"OUTPUT_SCHEMA_STRICT":         &c.OutputSchemaStrict,
+		"QUARANTINE_FAILURE_RATE":      &c.QuarantineFailureRate,
+		"QUARANTINE_MIN_CALLS":         &c.QuarantineMinCalls,
+		"QUARANTINE_WINDOW":            &c.QuarantineWindow,
+		"QUARANTINE_COOLDOWN":          &c.QuarantineCooldown,
+		"BREAKER_FAILURES":             &c.BreakerFailures,
+		"BREAKER_COOLDOWN":             &c.BreakerCooldown,
+		"OVERLOAD_MAX_IN_FLIGHT":       &c.OverloadMaxInFlight,
+		"OVERLOAD_MAX_GOROUTINES":      &c.OverloadMaxGoroutines,
+		"OVERLOAD_MAX_MEMORY_MB":       &c.OverloadMaxMemoryMB,
+		"OVERLOAD_RETRY_AFTER":         &c.OverloadRetryAfter,
+		"HEALTH_CHECK_INTERVAL":        &c.HealthCheckInterval,
+		"HEALTH_CHECK_TIMEOUT":         &c.HealthCheckTimeout,
+		"LAZY_TOOLS":                   &c.LazyTools,
+		"TOOL_WARMUP":                  &c.ToolWarmUp,
+		"HTTP_CLIENT_TIMEOUT":          &c.HTTPClientTimeout,
+		"HTTP_CLIENT_PROXY":            &c.HTTPClientProxy,
+		"HTTP_CLIENT_CA_FILE":          &c.HTTPClientCAFile,
+		"EGRESS_ALLOW":                 &c.EgressAllow,
+		"EGRESS_DENY":                  &c.EgressDeny,
+		"EGRESS_DEFAULT_DENY":          &c.EgressDefaultDeny,
+		"EGRESS_ALLOW_LOCAL":           &c.EgressAllowLocal,
+		"EXECUTION_HISTORY_SIZE":       &c.ExecutionHistorySize,
+		"EXECUTION_HISTORY_MAX_BYTES":  &c.ExecutionHistoryMaxBytes,
+		"JOBS_MAX_RUNNING":             &c.JobsMaxRunning,
+		"JOBS_RETENTION":               &c.JobsRetention,
+		"APPROVAL_TOOLS":               &c.ApprovalTools,
+		"APPROVAL_TIMEOUT":             &c.ApprovalTimeout,
+		"TOOL_TIME_LIMIT":              &c.ToolTimeLimit,
+		"TOOL_CPU_TIME":                &c.ToolCPUTime,
+		"TOOL_MAX_MEMORY_BYTES":        &c.ToolMaxMemoryBytes,
+		"TOOL_MAX_OUTPUT_BYTES":        &c.ToolMaxOutputBytes,
+		"TOOL_QUOTAS":                  &c.ToolQuotas,
+		"TOOL_THROTTLES":               &c.ToolThrottles,
+		"MAX_RESULT_BYTES":             &c.MaxResultBytes,
+		"RESULT_CURSOR_TTL":            &c.ResultCursorTTL,
+		"TENANTS":                      &c.Tenants,
+		"ADMIN_TOKEN":                  &c.AdminToken,
+		"TOOL_REGISTRATION":            &c.ToolRegistration,
+		"HTTP_TOOLS":                   &c.HTTPTools,
+		"HTTP_TOOLS_FILE":              &c.HTTPToolsFile,
+		"PIPELINES":                    &c.Pipelines,
+		"PIPELINES_FILE":               &c.PipelinesFile,
+		"SCHEDULES":                    &c.Schedules,
+		"SCHEDULES_FILE":               &c.SchedulesFile,
+		"POLICY_RULES":                 &c.PolicyRules,
+		"POLICY_RULES_FILE":            &c.PolicyRulesFile,
+		"POLICY_DEFAULT":               &c.PolicyDefault,
+		"POLICY_OPA_URL":               &c.PolicyOPAURL,
+		"POLICY_OPA_TIMEOUT":           &c.PolicyOPATimeout,
+		"WEBHOOK_URLS":                 &c.WebhookURLs,
+		"WEBHOOK_EVENTS":               &c.WebhookEvents,
+		"WEBHOOK_SECRET":               &c.WebhookSecret,
+		"WEBHOOK_MAX_RETRIES":          &c.WebhookMaxRetries,
+		"WEBHOOK_TIMEOUT":              &c.WebhookTimeout,
+		"LOG_LEVEL":                    &c.LogLevel,
+		"LOG_FORMAT":                   &c.LogFormat,
+		"LOG_FILE":                     &c.LogFile,
+		"LOG_MAX_SIZE_MB":              &c.LogMaxSizeMB,
+		"LOG_MAX_BACKUPS":              &c.LogMaxBackups,
+		"LOG_MAX_AGE_DAYS":             &c.LogMaxAgeDays,
+		"LOG_SAFE_MODE":                &c.LogSafeMode,
+		"STDIO_HEARTBEAT_INTERVAL":     &c.StdioHeartbeatInterval,
+		"SESSION_RECORD_DIR":           &c.SessionRecordDir,
+		"JSONRPC_BATCH_MAX_SIZE":       &c.BatchMaxSize,
+		"TOOLS_LIST_PAGE_SIZE":         &c.ToolsListPageSize,
+		"TOOL_SEARCH":                  &c.ToolSearch,
+		"SERVER_INFO":                  &c.ServerInfo,
+		"JSONRPC_BATCH_PARALLEL":       &c.BatchParallel,
+		"SSE_EVENT_HISTORY":            &c.SSEEventHistory,
+		"SSE_EVENT_TTL":                &c.SSEEventTTL,
+		"STREAMABLE_STATELESS":         &c.StreamableStateless,
+		"STREAMABLE_READ_TIMEOUT":      &c.StreamableReadTimeout,
+		"STREAMABLE_WRITE_TIMEOUT":     &c.StreamableWriteTimeout,
+		"STREAMABLE_IDLE_TIMEOUT":      &c.StreamableIdleTimeout,
+		"SSE_KEEPALIVE_INTERVAL":       &c.SSEKeepAliveInterval,
+		"SSE_RETRY_MS":                 &c.SSERetryMS,
+		"WEBSOCKET_PING_INTERVAL":      &c.WebSocketPingInterval,
+		"WEBSOCKET_PONG_TIMEOUT":       &c.WebSocketPongTimeout,
+		"WEBSOCKET_RESUME_TTL":         &c.WebSocketResumeTTL,
+		"CHAOS_ENABLED":                &c.ChaosEnabled,
+		"CHAOS_LATENCY_RATE":           &c.ChaosLatencyRate,
+		"CHAOS_LATENCY_MAX_MS":         &c.ChaosLatencyMaxMS,
+		"CHAOS_ERROR_RATE":             &c.ChaosErrorRate,
+		"CHAOS_SSE_DROP_RATE":          &c.ChaosSSEDropRate,
+		"REDACT_RULES":                 &c.RedactRules,
+		"REDACT_PATTERNS":              &c.RedactPatterns,
+	}
+}
+
+// inherited names the settings whose default is another setting's value.
+var inherited = map[string]string{
+	"HTTP_BIND_ADDRESS":            "BIND_ADDRESS",
+	"STREAMABLE_HTTP_BIND_ADDRESS": "BIND_ADDRESS",
+	"WEBSOCKET_BIND_ADDRESS":       "BIND_ADDRESS",
+}
+
+// SetSource records that a setting was overridden after loading, such as by a flag.
+func (c *ServerConfig) SetSource(name string, source Source) {
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	c.sources[name] = source
+}
+
+// Effective returns every setting with its current value and source, sorted by name.
+// Secrets are redacted.
+func (c *ServerConfig) Effective() []Setting {
+	secrets := c.secrets()
+	lists := c.secretLists()
+	fields := c.fields()
+	settings := make([]Setting, 0, len(fields))
+	for name, field := range fields {
+		setting := Setting{Name: name, Value: field, Source: c.source(name)}
+		if value, ok := os.LookupEnv(name); ok && value != "" && !validEnv(field, value) {
+			setting.Ignored = value
+		}
+		if secret, ok := secrets[name]; ok && *secret != "" {
+			setting.Value = redacted
+			setting.Ignored = ""
+		}
+		if list, ok := lists[name]; ok && len(*list) > 0 {
+			setting.Value = redacted
+		}
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Name < settings[j].Name })
+	return settings
+}
+
+// source returns where a setting's value came from.
+func (c *ServerConfig) source(name string) Source {
+	if source, ok := c.sources[name]; ok {
+		return source
+	}
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		if validEnv(c.fields()[name], value) {
+			return SourceEnv
+		}
+		return SourceDefault
+	}
+	if parent, ok := inherited[name]; ok {
+		return c.source(parent)
+	}
+	return SourceDefault
+}
+
+// validEnv reports whether value parses as field's type, mirroring the getEnv helpers,
+// which fall back to the default for values that do not.
+func validEnv(field interface{}, value string) bool {
+	var err error
+	switch field.(type) {
+	case *int, *int64:
+		_, err = strconv.Atoi(value)
+	case *float64:
+		_, err = strconv.ParseFloat(value, 64)
+	case *bool:
+		_, err = strconv.ParseBool(value)
+	case *map[string]map[string]interface{}:
+		var defaults map[string]map[string]interface{}
+		err = json.Unmarshal([]byte(value), &defaults)
+	}
+	return err == nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import "testing"
+
+func TestServerConfig_Effective(t *testing.T) {
+	find := func(settings []Setting, name string) Setting {
+		for _, setting := range settings {
+			if setting.Name == name {
+				return setting
+			}
+		}
+		t.Fatalf("Setting %s not found", name)
+		return Setting{}
+	}
+
+	t.Run("reports sources", func(t *testing.T) {
+		t.Setenv("STREAMABLE_HTTP_PORT", "9091")
+		t.Setenv("BIND_ADDRESS", "127.0.0.1")
+		config := NewServerConfig()
+		config.HTTPPort = 9000
+		config.SetSource("HTTP_PORT", SourceFlag)
+		settings := config.Effective()
+
+		for name, want := range map[string]Source{
+			"HTTP_PORT":            SourceFlag,
+			"STREAMABLE_HTTP_PORT": SourceEnv,
+			"WEBSOCKET_PORT":       SourceDefault,
+			"HTTP_BIND_ADDRESS":    SourceEnv,
+		} {
+			if got := find(settings, name).Source; got != want {
+				t.Errorf("Expected %s from %s, got %s", name, want, got)
+			}
+		}
+		if got := *find(settings, "HTTP_PORT").Value.(*int); got != 9000 {
+			t.Errorf("Expected HTTP_PORT 9000, got %d", got)
+		}
+	})
+
+	t.Run("reports ignored environment values", func(t *testing.T) {
+		t.Setenv("HTTP_PORT", "80a")
+		setting := find(NewServerConfig().Effective(), "HTTP_PORT")
+		if setting.Source != SourceDefault || setting.Ignored != "80a" {
+			t.Errorf("Unexpected setting: %+v", setting)
+		}
+	})
+
+	t.Run("redacts secrets", func(t *testing.T) {
+		t.Setenv("REDIS_URL", "redis://:hunter2@redis:6379")
+		setting := find(NewServerConfig().Effective(), "REDIS_URL")
+		if setting.Value != redacted || setting.Source != SourceEnv {
+			t.Errorf("Unexpected setting: %+v", setting)
+		}
+	})
+
+	t.Run("redacts webhook URLs", func(t *testing.T) {
+		t.Setenv("WEBHOOK_URLS", "https://hooks.example/services/T0/B0/s3cret")
+		setting := find(NewServerConfig().Effective(), "WEBHOOK_URLS")
+		if setting.Value != redacted || setting.Source != SourceEnv {
+			t.Errorf("Unexpected setting: %+v", setting)
+		}
+	})
+}
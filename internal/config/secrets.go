@@ -0,0 +1,168 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// EncryptedPrefix marks a configuration value as AES-256-GCM ciphertext. The rest of the
+// value is the base64 encoding of the nonce followed by the sealed plaintext.
+const EncryptedPrefix = "enc:v1:"
+
+// Environment variables that supply the key for encrypted values. The key is 32 bytes,
+// base64 encoded.
+const (
+	encryptionKeyEnv     = "CONFIG_ENCRYPTION_KEY"
+	encryptionKeyFileEnv = "CONFIG_ENCRYPTION_KEY_FILE"
+)
+
+// encryptionKeySize is the AES-256 key length in bytes.
+const encryptionKeySize = 32
+
+// GenerateEncryptionKey returns a new random key, base64 encoded for
+// CONFIG_ENCRYPTION_KEY or a key file.
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// LoadEncryptionKey reads the key from CONFIG_ENCRYPTION_KEY or, if unset, from the file
+// named by CONFIG_ENCRYPTION_KEY_FILE. It returns nil when neither is set.
+func LoadEncryptionKey() ([]byte, error) {
+	encoded := os.Getenv(encryptionKeyEnv)
+	if encoded == "" {
+		path := os.Getenv(encryptionKeyFileEnv)
+		if path == "" {
+			return nil, nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", encryptionKeyFileEnv, err)
+		}
+		encoded = strings.TrimSpace(string(data))
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != encryptionKeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, base64 encoded", encryptionKeySize)
+	}
+	return key, nil
+}
+
+// EncryptValue seals plaintext with key and returns it as an encrypted config value.
+func EncryptValue(key []byte, plaintext string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return EncryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// DecryptValue opens an encrypted config value. Values without EncryptedPrefix are
+// returned unchanged.
+func DecryptValue(key []byte, value string) (string, error) {
+	encoded, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+	if key == nil {
+		return "", errors.New("value is encrypted but no encryption key is configured")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed encrypted value")
+	}
+	plaintext, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt value; wrong key or corrupted ciphertext")
+	}
+	return string(plaintext), nil
+}
+
+// newGCM creates an AES-GCM cipher for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// secrets returns the settings that may hold credentials, keyed by environment variable.
+// These are the values that may be encrypted.
+func (c *ServerConfig) secrets() map[string]*string {
+	return map[string]*string{
+		"ADMIN_TOKEN":       &c.AdminToken,
+		"HTTP_CLIENT_PROXY": &c.HTTPClientProxy,
+		"HTTP_TOOLS":        &c.HTTPTools,
+		"PIPELINES":         &c.Pipelines,
+		"REDIS_URL":         &c.RedisURL,
+		"SCHEDULES":         &c.Schedules,
+		"TENANTS":           &c.Tenants,
+		"WEBHOOK_SECRET":    &c.WebhookSecret,
+	}
+}
+
+// secretLists returns the comma-separated settings that may hold credentials, such as
+// webhook URLs carrying a token in their path or query. Each entry may be encrypted.
+func (c *ServerConfig) secretLists() map[string]*[]string {
+	return map[string]*[]string{
+		"WEBHOOK_URLS": &c.WebhookURLs,
+	}
+}
+
+// decryptSecrets decrypts the encrypted secrets in place, recording any failure for Err.
+func (c *ServerConfig) decryptSecrets() {
+	var key []byte
+	var keyErr error
+	loaded := false
+	secrets := c.secrets()
+	for name, list := range c.secretLists() {
+		for i := range *list {
+			secrets[fmt.Sprintf("%s[%d]", name, i)] = &(*list)[i]
+		}
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := secrets[name]
+		if !strings.HasPrefix(*value, EncryptedPrefix) {
+			continue
+		}
+		if !loaded {
+			key, keyErr = LoadEncryptionKey()
+			loaded = true
+		}
+		if keyErr != nil {
+			c.errs = append(c.errs, fmt.Errorf("%s: %w", name, keyErr))
+			continue
+		}
+		plaintext, err := DecryptValue(key, *value)
+		if err != nil {
+			c.errs = append(c.errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		*value = plaintext
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptedValues(t *testing.T) {
+	encoded, err := GenerateEncryptionKey()
+	if err != nil {
+		t.Fatalf("GenerateEncryptionKey failed: %v", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		t.Setenv(encryptionKeyEnv, encoded)
+		key, err := LoadEncryptionKey()
+		if err != nil {
+			t.Fatalf("LoadEncryptionKey failed: %v", err)
+		}
+		encrypted, err := EncryptValue(key, "s3cret")
+		if err != nil {
+			t.Fatalf("EncryptValue failed: %v", err)
+		}
+		if plaintext, err := DecryptValue(key, encrypted); err != nil || plaintext != "s3cret" {
+			t.Errorf("Expected s3cret, got %q (%v)", plaintext, err)
+		}
+		if plaintext, err := DecryptValue(key, "plain"); err != nil || plaintext != "plain" {
+			t.Errorf("Expected plaintext values to pass through, got %q (%v)", plaintext, err)
+		}
+	})
+
+	t.Run("key from file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key")
+		if err := os.WriteFile(path, []byte(encoded+"\n"), 0o600); err != nil {
+			t.Fatalf("Failed to write key file: %v", err)
+		}
+		t.Setenv(encryptionKeyFileEnv, path)
+		if key, err := LoadEncryptionKey(); err != nil || len(key) != encryptionKeySize {
+			t.Errorf("Expected a %d-byte key, got %d (%v)", encryptionKeySize, len(key), err)
+		}
+	})
+
+	t.Run("NewServerConfig decrypts secrets", func(t *testing.T) {
+		t.Setenv(encryptionKeyEnv, encoded)
+		key, _ := LoadEncryptionKey()
+		encrypted, _ := EncryptValue(key, "webhook-key")
+		t.Setenv("WEBHOOK_SECRET", encrypted)
+
+		config := NewServerConfig()
+		if err := config.Err(); err != nil || config.WebhookSecret != "webhook-key" {
+			t.Errorf("Expected the decrypted secret, got %q (%v)", config.WebhookSecret, err)
+		}
+	})
+
+	t.Run("NewServerConfig decrypts webhook URLs one by one", func(t *testing.T) {
+		t.Setenv(encryptionKeyEnv, encoded)
+		key, _ := LoadEncryptionKey()
+		encrypted, _ := EncryptValue(key, "https://hooks.example/services/s3cret")
+		t.Setenv("WEBHOOK_URLS", "https://a.example/hook,"+encrypted)
+
+		config := NewServerConfig()
+		if err := config.Err(); err != nil || len(config.WebhookURLs) != 2 || config.WebhookURLs[1] != "https://hooks.example/services/s3cret" {
+			t.Errorf("Expected the decrypted URL, got %v (%v)", config.WebhookURLs, err)
+		}
+	})
+
+	t.Run("wrong key is reported", func(t *testing.T) {
+		t.Setenv(encryptionKeyEnv, encoded)
+		key, _ := LoadEncryptionKey()
+		encrypted, _ := EncryptValue(key, "webhook-key")
+		t.Setenv("WEBHOOK_SECRET", encrypted)
+		other, _ := GenerateEncryptionKey()
+		t.Setenv(encryptionKeyEnv, other)
+
+		if err := NewServerConfig().Err(); err == nil {
+			t.Error("Expected a decryption error")
+		}
+	})
+
+	t.Run("missing key is reported", func(t *testing.T) {
+		t.Setenv("WEBHOOK_SECRET", EncryptedPrefix+"AAAA")
+		if err := NewServerConfig().Err(); err == nil {
+			t.Error("Expected an error without a key")
+		}
+	})
+}
@@ -0,0 +1,104 @@
+// Package listener creates the TCP listeners used by the HTTP-based transports, either by
+// inheriting them from a supervisor such as systemd or by binding with optional
+// SO_REUSEPORT, so the server can be restarted without refusing connections.
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed under the systemd socket activation
+// protocol (SD_LISTEN_FDS_START).
+const listenFDsStart = 3
+
+// Network returns the Go network name for an IP family. "dual" (or empty) listens on IPv4
+// and IPv6, "ipv4" on IPv4 only, and "ipv6" on IPv6 only.
+func Network(family string) (string, error) {
+	switch strings.ToLower(family) {
+	case "", "dual":
+		return "tcp", nil
+	case "ipv4":
+		return "tcp4", nil
+	case "ipv6":
+		return "tcp6", nil
+	default:
+		return "", fmt.Errorf("invalid IP family %q: must be dual, ipv4, or ipv6", family)
+	}
+}
+
+// Listen binds a listener on addr for network ("tcp", "tcp4", or "tcp6"). A "tcp6"
+// listener on the unspecified address accepts IPv6 only; "tcp" accepts both families
+// where the platform supports dual-stack sockets. With reusePort, SO_REUSEPORT is set so
+// a new process can bind the same port while the old one is still draining.
+func Listen(network, addr string, reusePort bool) (net.Listener, error) {
+	var lc net.ListenConfig
+	if reusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(context.Background(), network, addr)
+}
+
+// Inherited returns the listeners passed by systemd socket activation, keyed by the name
+// set with FileDescriptorName= in the socket unit. It returns nil when the process was not
+// socket activated. The activation variables are unset so child processes do not inherit
+// them.
+func Inherited() (map[string]net.Listener, error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	return fromEnv(os.Getenv, os.Getpid(), listenFDsStart)
+}
+
+// fromEnv implements Inherited, reading the activation variables through getenv and
+// treating descriptors from firstFD onward as the passed listeners.
+func fromEnv(getenv func(string) string, pid, firstFD int) (map[string]net.Listener, error) {
+	if getenv("LISTEN_PID") == "" {
+		return nil, nil
+	}
+	listenPID, err := strconv.Atoi(getenv("LISTEN_PID"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LISTEN_PID: %w", err)
+	}
+	if listenPID != pid {
+		// The descriptors were meant for another process, such as our parent.
+		return nil, nil
+	}
+	count, err := strconv.Atoi(getenv("LISTEN_FDS"))
+	if err != nil || count < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS: %q", getenv("LISTEN_FDS"))
+	}
+
+	var names []string
+	if fdNames := getenv("LISTEN_FDNAMES"); fdNames != "" {
+		names = strings.Split(fdNames, ":")
+	}
+
+	listeners := make(map[string]net.Listener, count)
+	for i := 0; i < count; i++ {
+		fd := firstFD + i
+		name := fmt.Sprintf("fd%d", fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(fd), name)
+		l, err := net.FileListener(file)
+		// FileListener duplicates the descriptor, so the original is always closed.
+		_ = file.Close()
+		if err != nil {
+			for _, opened := range listeners {
+				_ = opened.Close()
+			}
+			return nil, fmt.Errorf("inherited descriptor %d (%s) is not a listener: %w", fd, name, err)
+		}
+		listeners[name] = l
+	}
+	return listeners, nil
+}
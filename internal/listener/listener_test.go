@@ -0,0 +1,166 @@
+package listener
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFromEnv(t *testing.T) {
+	env := func(vars map[string]string) func(string) string {
+		return func(key string) string { return vars[key] }
+	}
+
+	t.Run("returns nil when not socket activated", func(t *testing.T) {
+		listeners, err := fromEnv(env(nil), os.Getpid(), listenFDsStart)
+		if err != nil || listeners != nil {
+			t.Errorf("Expected no listeners, got %v (%v)", listeners, err)
+		}
+	})
+
+	t.Run("ignores descriptors meant for another process", func(t *testing.T) {
+		vars := map[string]string{"LISTEN_PID": "1", "LISTEN_FDS": "1"}
+		listeners, err := fromEnv(env(vars), os.Getpid(), listenFDsStart)
+		if err != nil || listeners != nil {
+			t.Errorf("Expected no listeners, got %v (%v)", listeners, err)
+		}
+	})
+
+	t.Run("rejects invalid LISTEN_FDS", func(t *testing.T) {
+		vars := map[string]string{"LISTEN_PID": strconv.Itoa(os.Getpid()), "LISTEN_FDS": "many"}
+		if _, err := fromEnv(env(vars), os.Getpid(), listenFDsStart); err == nil {
+			t.Error("Expected an error for invalid LISTEN_FDS")
+		}
+	})
+
+	t.Run("wraps passed descriptors by name", func(t *testing.T) {
+		tcp, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		defer tcp.Close()
+		file, err := tcp.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatalf("Failed to get listener file: %v", err)
+		}
+
+		vars := map[string]string{
+			"LISTEN_PID":     strconv.Itoa(os.Getpid()),
+			"LISTEN_FDS":     "1",
+			"LISTEN_FDNAMES": "http",
+		}
+		listeners, err := fromEnv(env(vars), os.Getpid(), int(file.Fd()))
+		if err != nil {
+			t.Fatalf("fromEnv failed: %v", err)
+		}
+		l, ok := listeners["http"]
+		if !ok {
+			t.Fatalf("Expected a listener named http, got %v", listeners)
+		}
+		defer l.Close()
+		if l.Addr().String() != tcp.Addr().String() {
+			t.Errorf("Expected address %s, got %s", tcp.Addr(), l.Addr())
+		}
+	})
+}
+
+func TestListen(t *testing.T) {
+	t.Run("binds without reuse", func(t *testing.T) {
+		l, err := Listen("tcp", "127.0.0.1:0", false)
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		l.Close()
+	})
+
+	t.Run("reuse port allows a second listener on the same port", func(t *testing.T) {
+		first, err := Listen("tcp", "127.0.0.1:0", true)
+		if err != nil {
+			t.Skipf("SO_REUSEPORT unavailable: %v", err)
+		}
+		defer first.Close()
+
+		second, err := Listen("tcp", first.Addr().String(), true)
+		if err != nil {
+			t.Fatalf("Expected second listener to bind %s: %v", first.Addr(), err)
+		}
+		second.Close()
+	})
+
+	t.Run("dual-stack accepts IPv4 and IPv6 clients", func(t *testing.T) {
+		if !ipv6Available() {
+			t.Skip("IPv6 loopback unavailable")
+		}
+		l, err := Listen("tcp", ":0", false)
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		defer l.Close()
+		port := strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+
+		for _, host := range []string{"127.0.0.1", "::1"} {
+			if !canDial(l, net.JoinHostPort(host, port)) {
+				t.Errorf("Expected dual-stack listener to accept %s", host)
+			}
+		}
+	})
+
+	t.Run("ipv6 only rejects IPv4 clients", func(t *testing.T) {
+		if !ipv6Available() {
+			t.Skip("IPv6 loopback unavailable")
+		}
+		l, err := Listen("tcp6", "[::]:0", false)
+		if err != nil {
+			t.Fatalf("Listen failed: %v", err)
+		}
+		defer l.Close()
+		port := strconv.Itoa(l.Addr().(*net.TCPAddr).Port)
+
+		if !canDial(l, net.JoinHostPort("::1", port)) {
+			t.Error("Expected IPv6 client to connect")
+		}
+		if conn, err := net.DialTimeout("tcp4", net.JoinHostPort("127.0.0.1", port), time.Second); err == nil {
+			conn.Close()
+			t.Error("Expected IPv4 client to be refused")
+		}
+	})
+}
+
+func TestNetwork(t *testing.T) {
+	testCases := map[string]string{"": "tcp", "dual": "tcp", "ipv4": "tcp4", "IPv6": "tcp6"}
+	for family, expected := range testCases {
+		if network, err := Network(family); err != nil || network != expected {
+			t.Errorf("Network(%q) = %q, %v; expected %q", family, network, err, expected)
+		}
+	}
+	if _, err := Network("ipx"); err == nil {
+		t.Error("Expected an error for an unknown family")
+	}
+}
+
+// ipv6Available reports whether the IPv6 loopback address can be bound.
+func ipv6Available() bool {
+	l, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// canDial connects to addr and reports whether l accepted the connection.
+func canDial(l net.Listener, addr string) bool {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return false
+	}
+	defer conn.Close()
+	accepted, err := l.Accept()
+	if err != nil {
+		return false
+	}
+	accepted.Close()
+	return true
+}
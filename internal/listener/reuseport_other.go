@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd || dragonfly)
+
+package listener
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePortControl reports that SO_REUSEPORT is unavailable on this platform.
+func reusePortControl(network, address string, c syscall.RawConn) error {
+	return errors.New("SO_REUSEPORT is not supported on this platform")
+}
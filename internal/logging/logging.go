@@ -0,0 +1,140 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"mcp-tools-server/internal/redact"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Config controls the level, format, and destination of log records.
+type Config struct {
+	Level      string // debug, info, warn, or error
+	Format     string // text or json
+	File       string // stdout, stderr, or a file path; empty means stdout
+	MaxSizeMB  int    // Size at which a log file is rotated
+	MaxBackups int    // Rotated files to keep (0 keeps all)
+	MaxAgeDays int    // Days to keep rotated files (0 keeps them forever)
+
+	// Stdio reports that stdout carries MCP protocol frames. Logs bound for stdout are
+	// routed to stderr instead so they cannot corrupt the JSON-RPC stream.
+	Stdio bool
+
+	// Redactor, when set, removes sensitive values from every record before it is written.
+	Redactor *redact.Redactor
+
+	// Tee, when set, also receives every record it is enabled for, after redaction and
+	// regardless of Level.
+	Tee slog.Handler
+}
+
+// nopCloser is returned for the standard streams, which must not be closed.
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
+
+// New builds a logger from cfg. The returned LevelVar changes the level at runtime, and
+// the Closer releases the log file, if any.
+func New(cfg Config) (*slog.Logger, *slog.LevelVar, io.Closer, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
+
+	var out io.Writer
+	var closer io.Closer = nopCloser{}
+	switch cfg.File {
+	case "", "stdout":
+		out = os.Stdout
+		if cfg.Stdio {
+			out = os.Stderr
+		}
+	case "stderr":
+		out = os.Stderr
+	default:
+		file := &lumberjack.Logger{
+			Filename:   cfg.File,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+		}
+		out, closer = file, file
+	}
+
+	options := &slog.HandlerOptions{Level: levelVar}
+	var handler slog.Handler
+	switch strings.ToLower(cfg.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(out, options)
+	case "json":
+		handler = slog.NewJSONHandler(out, options)
+	default:
+		return nil, nil, nil, fmt.Errorf("unsupported log format: %s", cfg.Format)
+	}
+
+	if cfg.Tee != nil {
+		handler = &teeHandler{handlers: []slog.Handler{handler, cfg.Tee}}
+	}
+	return slog.New(cfg.Redactor.Handler(handler)), levelVar, closer, nil
+}
+
+// teeHandler passes records to every handler enabled for them.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+func (h *teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &teeHandler{handlers: handlers}
+}
+
+// ParseLevel parses a level name such as "debug" or "WARN". An empty name means info.
+func ParseLevel(name string) (slog.Level, error) {
+	if name == "" {
+		return slog.LevelInfo, nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unsupported log level: %s", name)
+	}
+	return level, nil
+}
@@ -0,0 +1,133 @@
+package logging
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected slog.Level
+	}{
+		{"", slog.LevelInfo},
+		{"debug", slog.LevelDebug},
+		{"WARN", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := ParseLevel(tt.name)
+			if err != nil {
+				t.Fatalf("ParseLevel failed: %v", err)
+			}
+			if level != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, level)
+			}
+		})
+	}
+
+	t.Run("unknown level fails", func(t *testing.T) {
+		if _, err := ParseLevel("verbose"); err == nil {
+			t.Error("Expected error for unknown level")
+		}
+	})
+}
+
+func TestNew(t *testing.T) {
+	t.Run("writes JSON to a file at the configured level", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "server.log")
+		logger, levelVar, closer, err := New(Config{Level: "warn", Format: "json", File: path, MaxSizeMB: 1})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+
+		logger.Info("hidden")
+		logger.Warn("shown", "key", "value")
+		levelVar.Set(slog.LevelDebug)
+		logger.Debug("shown after level change")
+		if err := closer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Failed to read log file: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), data)
+		}
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &record); err != nil {
+			t.Fatalf("Expected JSON log line: %v", err)
+		}
+		if record["msg"] != "shown" || record["key"] != "value" {
+			t.Errorf("Unexpected log record: %v", record)
+		}
+	})
+
+	t.Run("tees records regardless of level", func(t *testing.T) {
+		var tee strings.Builder
+		path := filepath.Join(t.TempDir(), "server.log")
+		logger, _, closer, err := New(Config{
+			Level: "warn",
+			File:  path,
+			Tee:   slog.NewTextHandler(&tee, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		logger.With("key", "value").Debug("teed")
+		closer.Close()
+
+		if !strings.Contains(tee.String(), "msg=teed key=value") {
+			t.Errorf("Expected the debug record in the tee, got %q", tee.String())
+		}
+		if data, _ := os.ReadFile(path); strings.Contains(string(data), "teed") {
+			t.Errorf("Expected the debug record to stay out of the log, got %q", data)
+		}
+	})
+
+	t.Run("rejects unknown format", func(t *testing.T) {
+		if _, _, _, err := New(Config{Format: "xml"}); err == nil {
+			t.Error("Expected error for unknown format")
+		}
+	})
+
+	t.Run("rejects unknown level", func(t *testing.T) {
+		if _, _, _, err := New(Config{Level: "loud"}); err == nil {
+			t.Error("Expected error for unknown level")
+		}
+	})
+}
+
+func TestNew_Stdio(t *testing.T) {
+	stdoutReader, stdoutWriter, _ := os.Pipe()
+	stderrReader, stderrWriter, _ := os.Pipe()
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdoutWriter, stderrWriter
+	defer func() { os.Stdout, os.Stderr = originalStdout, originalStderr }()
+
+	for _, file := range []string{"", "stdout"} {
+		logger, _, _, err := New(Config{File: file, Stdio: true})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		logger.Info("routed away from stdout")
+	}
+	stdoutWriter.Close()
+	stderrWriter.Close()
+
+	if data, _ := io.ReadAll(stdoutReader); len(data) != 0 {
+		t.Errorf("Expected nothing on stdout, got %q", data)
+	}
+	if data, _ := io.ReadAll(stderrReader); strings.Count(string(data), "routed away from stdout") != 2 {
+		t.Errorf("Expected both records on stderr, got %q", data)
+	}
+}
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TraceParentHeader is the W3C Trace Context header that carries a request's trace ID.
+const TraceParentHeader = "traceparent"
+
+// ExemplarLabel is the exemplar label holding the trace ID, which Grafana uses to link a
+// sample to its trace.
+const ExemplarLabel = "trace_id"
+
+type traceIDKey struct{}
+
+// WithTraceID returns a copy of ctx carrying the given trace ID.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID stored in ctx, or "" if there is none.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// ParseTraceParent returns the trace ID of a traceparent header value, or "" if the value
+// is not a valid version 00 header or its trace ID is all zeros.
+func ParseTraceParent(value string) string {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) != 4 || parts[0] != "00" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return ""
+	}
+	traceID := parts[1]
+	if !isLowerHex(traceID) || !isLowerHex(parts[2]) || !isLowerHex(parts[3]) || strings.Trim(traceID, "0") == "" {
+		return ""
+	}
+	return traceID
+}
+
+// Exemplar returns the exemplar labels for the trace ID in ctx, or nil if there is none.
+// It suits promhttp.WithExemplarFromContext.
+func Exemplar(ctx context.Context) prometheus.Labels {
+	return exemplarFor(TraceIDFromContext(ctx))
+}
+
+// Add adds value to counter, attaching traceID as an exemplar when it is set.
+func Add(counter prometheus.Counter, value float64, traceID string) {
+	if adder, ok := counter.(prometheus.ExemplarAdder); ok && traceID != "" {
+		adder.AddWithExemplar(value, exemplarFor(traceID))
+		return
+	}
+	counter.Add(value)
+}
+
+// Observe records value in observer, attaching traceID as an exemplar when it is set.
+func Observe(observer prometheus.Observer, value float64, traceID string) {
+	if exemplarObserver, ok := observer.(prometheus.ExemplarObserver); ok && traceID != "" {
+		exemplarObserver.ObserveWithExemplar(value, exemplarFor(traceID))
+		return
+	}
+	observer.Observe(value)
+}
+
+// exemplarFor returns the exemplar labels for traceID, or nil when it is empty.
+func exemplarFor(traceID string) prometheus.Labels {
+	if traceID == "" {
+		return nil
+	}
+	return prometheus.Labels{ExemplarLabel: traceID}
+}
+
+// isLowerHex reports whether s holds only lowercase hexadecimal digits.
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"runtime"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Build and runtime information. Goroutine, GC, memory, and open file descriptor stats come
+// from the Go and process collectors of the default registry, as go_* and process_*.
+var (
+	buildInfo = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "build_info",
+			Help: "Always 1; labeled with the version, commit, build time, and Go version of the running server",
+		},
+		[]string{"version", "commit", "build_time", "go_version"},
+	)
+	transportEnabled = NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "transport",
+			Name:      "enabled",
+			Help:      "Whether a transport is enabled (1) or not (0)",
+		},
+		[]string{"transport"},
+	)
+)
+
+// SetBuildInfo records the running build, replacing any build recorded before.
+func SetBuildInfo(version, commit, buildTime string) {
+	buildInfo.Reset()
+	buildInfo.WithLabelValues(version, commit, buildTime, runtime.Version()).Set(1)
+}
+
+// SetTransportEnabled records whether the named transport is enabled.
+func SetTransportEnabled(transport string, enabled bool) {
+	value := 0.0
+	if enabled {
+		value = 1
+	}
+	transportEnabled.WithLabelValues(transport).Set(value)
+}
@@ -0,0 +1,109 @@
+// Package metrics holds the server's shared Prometheus metrics and the helpers every
+// component uses to define its own, so all metrics share the mcp_tools namespace, are
+// registered the same way, and can carry trace ID exemplars.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the name of every metric the server exports.
+const Namespace = "mcp_tools"
+
+// Metrics shared by the transports and the tool service.
+var (
+	HTTPRequestsTotal = NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Total number of HTTP requests",
+		},
+		[]string{"code", "method", "endpoint"},
+	)
+	HTTPRequestDuration = NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request duration in seconds",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"code", "method", "endpoint"},
+	)
+	ToolExecutionsTotal = NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "tool",
+			Name:      "executions_total",
+			Help:      "Total number of tool executions across all transports",
+		},
+		[]string{"tool", "status", "tenant"},
+	)
+)
+
+// NewCounter creates a counter in the server's namespace and registers it.
+func NewCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	opts.Namespace = Namespace
+	return register(prometheus.NewCounter(opts))
+}
+
+// NewCounterVec creates a counter vector in the server's namespace and registers it.
+func NewCounterVec(opts prometheus.CounterOpts, labels []string) *prometheus.CounterVec {
+	opts.Namespace = Namespace
+	return register(prometheus.NewCounterVec(opts, labels))
+}
+
+// NewGauge creates a gauge in the server's namespace and registers it.
+func NewGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	opts.Namespace = Namespace
+	return register(prometheus.NewGauge(opts))
+}
+
+// NewGaugeVec creates a gauge vector in the server's namespace and registers it.
+func NewGaugeVec(opts prometheus.GaugeOpts, labels []string) *prometheus.GaugeVec {
+	opts.Namespace = Namespace
+	return register(prometheus.NewGaugeVec(opts, labels))
+}
+
+// NewHistogram creates a histogram in the server's namespace and registers it.
+func NewHistogram(opts prometheus.HistogramOpts) prometheus.Histogram {
+	opts.Namespace = Namespace
+	return register(prometheus.NewHistogram(opts))
+}
+
+// NewHistogramVec creates a histogram vector in the server's namespace and registers it.
+func NewHistogramVec(opts prometheus.HistogramOpts, labels []string) *prometheus.HistogramVec {
+	opts.Namespace = Namespace
+	return register(prometheus.NewHistogramVec(opts, labels))
+}
+
+// Register adds collectors to the default registry. A collector that is already
+// registered is skipped; any other conflict is a programming error and panics.
+func Register(collectors ...prometheus.Collector) {
+	for _, collector := range collectors {
+		register(collector)
+	}
+}
+
+// register adds collector to the default registry and returns it, or the equal collector
+// registered earlier so that callers always update the one being exported.
+func register[C prometheus.Collector](collector C) C {
+	if err := prometheus.Register(collector); err != nil {
+		registered, ok := err.(prometheus.AlreadyRegisteredError)
+		if !ok {
+			panic(err)
+		}
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing
+		}
+	}
+	return collector
+}
+
+// Handler serves the default registry. Clients that accept the OpenMetrics format get
+// exemplars along with the samples.
+func Handler() http.Handler {
+	return promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+}
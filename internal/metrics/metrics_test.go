@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewCounterVec(t *testing.T) {
+	t.Run("applies the namespace", func(t *testing.T) {
+		counter := NewCounterVec(prometheus.CounterOpts{Subsystem: "test", Name: "namespaced_total", Help: "test"}, []string{"label"})
+		counter.WithLabelValues("a").Inc()
+		if count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, "mcp_tools_test_namespaced_total"); err != nil || count != 1 {
+			t.Errorf("Expected mcp_tools_test_namespaced_total to be registered, got %d series (%v)", count, err)
+		}
+	})
+
+	t.Run("returns the registered collector for a duplicate", func(t *testing.T) {
+		opts := prometheus.CounterOpts{Subsystem: "test", Name: "duplicate_total", Help: "test"}
+		first := NewCounterVec(opts, []string{"label"})
+		second := NewCounterVec(opts, []string{"label"})
+		second.WithLabelValues("a").Inc()
+		if got := testutil.ToFloat64(first.WithLabelValues("a")); got != 1 {
+			t.Errorf("Expected the duplicate to update the registered counter, got %v", got)
+		}
+	})
+}
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"valid", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"empty", "", ""},
+		{"unknown version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", ""},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", ""},
+		{"zero trace ID", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", ""},
+		{"short trace ID", "00-4bf92f35-00f067aa0ba902b7-01", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseTraceParent(tt.value); got != tt.want {
+				t.Errorf("ParseTraceParent(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExemplars(t *testing.T) {
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	t.Run("context carries the trace ID", func(t *testing.T) {
+		if labels := Exemplar(context.Background()); labels != nil {
+			t.Errorf("Expected no exemplar without a trace ID, got %v", labels)
+		}
+		labels := Exemplar(WithTraceID(context.Background(), traceID))
+		if labels[ExemplarLabel] != traceID {
+			t.Errorf("Expected exemplar with trace ID, got %v", labels)
+		}
+	})
+
+	t.Run("samples are exported with exemplars", func(t *testing.T) {
+		counter := NewCounter(prometheus.CounterOpts{Subsystem: "test", Name: "exemplar_total", Help: "test"})
+		histogram := NewHistogram(prometheus.HistogramOpts{Subsystem: "test", Name: "exemplar_seconds", Help: "test"})
+		Add(counter, 1, traceID)
+		Observe(histogram, 0.2, traceID)
+		Add(counter, 1, "")
+
+		if got := testutil.ToFloat64(counter); got != 2 {
+			t.Errorf("Expected counter at 2, got %v", got)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, req)
+		body, _ := io.ReadAll(rec.Body)
+		for _, name := range []string{"mcp_tools_test_exemplar_total", "mcp_tools_test_exemplar_seconds_bucket"} {
+			if !strings.Contains(string(body), name) || !strings.Contains(string(body), `# {trace_id="`+traceID+`"}`) {
+				t.Errorf("Expected %s with a trace ID exemplar, got:\n%s", name, body)
+			}
+		}
+	})
+}
+
+func TestInfo(t *testing.T) {
+	t.Run("build info replaces the previous build", func(t *testing.T) {
+		SetBuildInfo("1.0.0", "abc123", "2024-01-01")
+		SetBuildInfo("1.1.0", "def456", "2024-02-01")
+		if count := testutil.CollectAndCount(buildInfo); count != 1 {
+			t.Errorf("Expected one build_info series, got %d", count)
+		}
+		if got := testutil.ToFloat64(buildInfo.WithLabelValues("1.1.0", "def456", "2024-02-01", runtime.Version())); got != 1 {
+			t.Errorf("Expected build_info for the latest build, got %v", got)
+		}
+	})
+
+	t.Run("transport enablement", func(t *testing.T) {
+		SetTransportEnabled("http", true)
+		SetTransportEnabled("stdio", false)
+		if got := testutil.ToFloat64(transportEnabled.WithLabelValues("http")); got != 1 {
+			t.Errorf("Expected http enabled, got %v", got)
+		}
+		if got := testutil.ToFloat64(transportEnabled.WithLabelValues("stdio")); got != 0 {
+			t.Errorf("Expected stdio disabled, got %v", got)
+		}
+	})
+
+	t.Run("runtime stats are exported", func(t *testing.T) {
+		for _, name := range []string{"go_goroutines", "go_gc_duration_seconds"} {
+			if count, err := testutil.GatherAndCount(prometheus.DefaultGatherer, name); err != nil || count == 0 {
+				t.Errorf("Expected %s to be exported, got %d series (%v)", name, count, err)
+			}
+		}
+	})
+}
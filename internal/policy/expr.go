@@ -0,0 +1,994 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Expression is a compiled rule expression. Rule expressions are a small language of
+// this package's own. Their syntax resembles CEL's, but they are not CEL: there are no
+// macros besides has, no types beyond those JSON decodes to, and ints and doubles mix
+// freely, so arguments decoded from JSON, which are doubles, compare with int literals
+// as expected. The grammar, from the loosest binding to the tightest, is:
+//
+//	expr    = or [ "?" expr ":" expr ]
+//	or      = and { "||" and }
+//	and     = rel { "&&" rel }
+//	rel     = add { ( "==" | "!=" | "<" | "<=" | ">" | ">=" | "in" ) add }
+//	add     = mul { ( "+" | "-" ) mul }
+//	mul     = unary { ( "*" | "/" | "%" ) unary }
+//	unary   = ( "!" | "-" ) unary | member
+//	member  = primary { "." ident [ "(" [ exprs ] ")" ] | "[" expr "]" }
+//	primary = int | double | string | "true" | "false" | "null" | ident
+//	        | ident "(" [ exprs ] ")" | "has" "(" member "." ident ")"
+//	        | "(" expr ")" | "[" [ exprs ] "]" | "{" [ expr ":" expr { "," expr ":" expr } ] "}"
+//	exprs   = expr { "," expr }
+//
+// Binary operators are left-associative, and && and || short-circuit. Strings are
+// 'single' or "double" quoted, with the escapes \n, \t, \r, \\, \', and \". An ident
+// alone is a variable, and a.b selects key b of map a. The functions are has(a.b), which
+// reports whether map a has the key b; size(x) or x.size() of strings, lists, and maps;
+// s.startsWith(p), s.endsWith(p), s.contains(p), and s.matches(re) on strings; and
+// int(x), double(x), and string(x).
+type Expression struct {
+	source string
+	root   node
+}
+
+// Compile parses source into an Expression.
+func Compile(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.expression()
+	if err == nil && p.peek().kind != tokenEOF {
+		err = fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression's source.
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Eval evaluates the expression with the given variables, which may hold the values
+// encoding/json decodes to.
+func (e *Expression) Eval(vars map[string]interface{}) (interface{}, error) {
+	return e.root.eval(vars)
+}
+
+// EvalBool evaluates an expression that must yield a bool.
+func (e *Expression) EvalBool(vars map[string]interface{}) (bool, error) {
+	value, err := e.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q yielded %s, not a bool", e.source, typeName(value))
+	}
+	return b, nil
+}
+
+// Tokens
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenDouble
+	tokenString
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{} // Literal value of int, double, and string tokens
+}
+
+// operators lists the operator tokens, longest first so they match greedily.
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", "[", "]", "{", "}", ",", ".", "?", ":"}
+
+// lex splits source into tokens.
+func lex(source string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(source) && (source[i] == '_' || isLetter(source[i]) || isDigit(source[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i]})
+		case isDigit(c):
+			start := i
+			double := false
+			for i < len(source) && (isDigit(source[i]) || source[i] == '.' || source[i] == 'e' || source[i] == 'E' ||
+				((source[i] == '+' || source[i] == '-') && (source[i-1] == 'e' || source[i-1] == 'E'))) {
+				if source[i] == '.' && (i+1 >= len(source) || !isDigit(source[i+1])) {
+					break // A method call on a number, not a fraction
+				}
+				double = double || !isDigit(source[i])
+				i++
+			}
+			text := source[start:i]
+			if double {
+				value, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad number %q", text)
+				}
+				tokens = append(tokens, token{kind: tokenDouble, text: text, value: value})
+			} else {
+				value, err := strconv.ParseInt(text, 10, 64)
+				if err != nil {
+					return nil, fmt.Errorf("bad number %q", text)
+				}
+				tokens = append(tokens, token{kind: tokenInt, text: text, value: value})
+			}
+		case c == '\'' || c == '"':
+			value, n, err := lexString(source[i:])
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: source[i : i+n], value: value})
+			i += n
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of expression"}), nil
+}
+
+// lexString reads the quoted string at the start of s and returns its value and length.
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(s) {
+				break
+			}
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '\'', '"':
+				b.WriteByte(s[i])
+			default:
+				return "", 0, fmt.Errorf("unknown escape \\%c", s[i])
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", 0, errors.New("unterminated string")
+}
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// Parsing
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the operator or keyword op.
+func (p *parser) accept(op string) bool {
+	t := p.peek()
+	if (t.kind == tokenOperator || t.kind == tokenIdent) && t.text == op {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(op string) error {
+	if !p.accept(op) {
+		return fmt.Errorf("expected %q, found %q", op, p.peek().text)
+	}
+	return nil
+}
+
+// expression parses a conditional, the lowest precedence level.
+func (p *parser) expression() (node, error) {
+	cond, err := p.binary(0)
+	if err != nil || !p.accept("?") {
+		return cond, err
+	}
+	then, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &conditional{cond: cond, then: then, otherwise: otherwise}, nil
+}
+
+// precedence lists the binary operators from the loosest binding to the tightest.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// binary parses left-associative binary operators at the given precedence level.
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, candidate := range precedence[level] {
+			if p.accept(candidate) {
+				op = candidate
+				break
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	for _, op := range []string{"!", "-"} {
+		if p.accept(op) {
+			operand, err := p.unary()
+			if err != nil {
+				return nil, err
+			}
+			return &unary{op: op, operand: operand}, nil
+		}
+	}
+	return p.member()
+}
+
+// member parses a primary expression followed by field selections, method calls, and
+// indexes.
+func (p *parser) member() (node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			name := p.next()
+			if name.kind != tokenIdent {
+				return nil, fmt.Errorf("expected a field name, found %q", name.text)
+			}
+			if p.accept("(") {
+				args, err := p.arguments(")")
+				if err != nil {
+					return nil, err
+				}
+				if n, err = newCall(name.text, n, args); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			n = &selection{operand: n, field: name.text}
+		case p.accept("["):
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			n = &indexing{operand: n, index: index}
+		default:
+			return n, nil
+		}
+	}
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt, tokenDouble, tokenString:
+		return &literal{value: t.value}, nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true}, nil
+		case "false":
+			return &literal{value: false}, nil
+		case "null":
+			return &literal{value: nil}, nil
+		}
+		if !p.accept("(") {
+			return &variable{name: t.text}, nil
+		}
+		if t.text == "has" {
+			arg, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			sel, ok := arg.(*selection)
+			if !ok {
+				return nil, errors.New("has() requires a field selection such as has(args.name)")
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return &presence{selection: sel}, nil
+		}
+		args, err := p.arguments(")")
+		if err != nil {
+			return nil, err
+		}
+		return newCall(t.text, nil, args)
+	case tokenOperator:
+		switch t.text {
+		case "(":
+			n, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return n, p.expect(")")
+		case "[":
+			items, err := p.arguments("]")
+			if err != nil {
+				return nil, err
+			}
+			return &list{items: items}, nil
+		case "{":
+			return p.mapLiteral()
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q", t.text)
+}
+
+// arguments parses a comma-separated list of expressions up to the closing token.
+func (p *parser) arguments(closing string) ([]node, error) {
+	var args []node
+	if p.accept(closing) {
+		return args, nil
+	}
+	for {
+		arg, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(closing) {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) mapLiteral() (node, error) {
+	m := &mapping{}
+	if p.accept("}") {
+		return m, nil
+	}
+	for {
+		key, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		m.keys, m.values = append(m.keys, key), append(m.values, value)
+		if p.accept("}") {
+			return m, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Evaluation
+
+type node interface {
+	eval(vars map[string]interface{}) (interface{}, error)
+}
+
+type literal struct{ value interface{} }
+
+func (n *literal) eval(map[string]interface{}) (interface{}, error) { return n.value, nil }
+
+type variable struct{ name string }
+
+func (n *variable) eval(vars map[string]interface{}) (interface{}, error) {
+	value, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared variable %q", n.name)
+	}
+	return normalize(value), nil
+}
+
+type selection struct {
+	operand node
+	field   string
+}
+
+func (n *selection) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select %q from %s", n.field, typeName(operand))
+	}
+	value, ok := m[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key %q", n.field)
+	}
+	return normalize(value), nil
+}
+
+// presence is the has() macro.
+type presence struct{ selection *selection }
+
+func (n *presence) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.selection.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := operand.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("has() cannot test a field of %s", typeName(operand))
+	}
+	_, found := m[n.selection.field]
+	return found, nil
+}
+
+type indexing struct{ operand, index node }
+
+func (n *indexing) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	index, err := n.index.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch o := operand.(type) {
+	case []interface{}:
+		i, ok := toInt(index)
+		if !ok {
+			return nil, fmt.Errorf("cannot index a list with %s", typeName(index))
+		}
+		if i < 0 || i >= int64(len(o)) {
+			return nil, fmt.Errorf("index %d out of range", i)
+		}
+		return normalize(o[i]), nil
+	case map[string]interface{}:
+		key, ok := index.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot index a map with %s", typeName(index))
+		}
+		value, found := o[key]
+		if !found {
+			return nil, fmt.Errorf("no such key %q", key)
+		}
+		return normalize(value), nil
+	}
+	return nil, fmt.Errorf("cannot index %s", typeName(operand))
+}
+
+type list struct{ items []node }
+
+func (n *list) eval(vars map[string]interface{}) (interface{}, error) {
+	values := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		value, err := item.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+type mapping struct{ keys, values []node }
+
+func (n *mapping) eval(vars map[string]interface{}) (interface{}, error) {
+	m := make(map[string]interface{}, len(n.keys))
+	for i := range n.keys {
+		key, err := n.keys[i].eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("map keys must be strings, not %s", typeName(key))
+		}
+		if m[s], err = n.values[i].eval(vars); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+type conditional struct{ cond, then, otherwise node }
+
+func (n *conditional) eval(vars map[string]interface{}) (interface{}, error) {
+	cond, err := n.cond.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := cond.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition is %s, not a bool", typeName(cond))
+	}
+	if b {
+		return n.then.eval(vars)
+	}
+	return n.otherwise.eval(vars)
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (n *unary) eval(vars map[string]interface{}) (interface{}, error) {
+	operand, err := n.operand.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch v := operand.(type) {
+	case bool:
+		if n.op == "!" {
+			return !v, nil
+		}
+	case int64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	case float64:
+		if n.op == "-" {
+			return -v, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot apply %s to %s", n.op, typeName(operand))
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (n *binary) eval(vars map[string]interface{}) (interface{}, error) {
+	left, err := n.left.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot apply %s to %s", n.op, typeName(left))
+		}
+		if l == (n.op == "||") {
+			return l, nil
+		}
+		right, err := n.right.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot apply %s to %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+	right, err := n.right.eval(vars)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "in":
+		switch r := right.(type) {
+		case []interface{}:
+			for _, item := range r {
+				if equal(left, normalize(item)) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]interface{}:
+			key, ok := left.(string)
+			if !ok {
+				return false, nil
+			}
+			_, found := r[key]
+			return found, nil
+		}
+		return nil, fmt.Errorf("cannot apply in to %s", typeName(right))
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	}
+	return arithmetic(n.op, left, right)
+}
+
+// arithmetic applies + - * / % to two values.
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if op == "+" {
+		switch l := left.(type) {
+		case string:
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		case []interface{}:
+			if r, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+		}
+	}
+	if l, ok := left.(int64); ok {
+		if r, ok := right.(int64); ok {
+			switch op {
+			case "+":
+				return l + r, nil
+			case "-":
+				return l - r, nil
+			case "*":
+				return l * r, nil
+			case "/", "%":
+				if r == 0 {
+					return nil, errors.New("division by zero")
+				}
+				if op == "/" {
+					return l / r, nil
+				}
+				return l % r, nil
+			}
+		}
+	}
+	l, lok := toDouble(left)
+	r, rok := toDouble(right)
+	if lok && rok {
+		switch op {
+		case "+":
+			return l + r, nil
+		case "-":
+			return l - r, nil
+		case "*":
+			return l * r, nil
+		case "/":
+			return l / r, nil
+		case "%":
+			return math.Mod(l, r), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot apply %s to %s and %s", op, typeName(left), typeName(right))
+}
+
+// equal reports whether two values are equal, comparing ints and doubles by value.
+func equal(left, right interface{}) bool {
+	if l, ok := toDouble(left); ok {
+		r, ok := toDouble(right)
+		return ok && l == r
+	}
+	return reflect.DeepEqual(left, right)
+}
+
+// compare orders two numbers or two strings.
+func compare(left, right interface{}) (int, error) {
+	if l, ok := left.(string); ok {
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), nil
+		}
+	}
+	l, lok := toDouble(left)
+	r, rok := toDouble(right)
+	if !lok || !rok {
+		return 0, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+	switch {
+	case l < r:
+		return -1, nil
+	case l > r:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+type call struct {
+	function string
+	target   node // Receiver of a method call; nil for a function call
+	args     []node
+	pattern  *regexp.Regexp // Compiled pattern of matches() with a literal argument
+}
+
+// functionArity is the number of arguments, including the receiver, of each function.
+var functionArity = map[string]int{
+	"size": 1, "int": 1, "double": 1, "string": 1,
+	"startsWith": 2, "endsWith": 2, "contains": 2, "matches": 2,
+}
+
+// newCall checks a function or method call and compiles a literal matches() pattern.
+func newCall(function string, target node, args []node) (node, error) {
+	all := args
+	if target != nil {
+		all = append([]node{target}, args...)
+	}
+	arity, ok := functionArity[function]
+	if !ok {
+		return nil, fmt.Errorf("unknown function %s", function)
+	}
+	if len(all) != arity {
+		return nil, fmt.Errorf("%s takes %d arguments", function, arity)
+	}
+	c := &call{function: function, target: target, args: all}
+	if lit, ok := all[len(all)-1].(*literal); ok && function == "matches" {
+		pattern, ok := lit.value.(string)
+		if !ok {
+			return nil, errors.New("matches() requires a string pattern")
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bad pattern: %w", err)
+		}
+		c.pattern = compiled
+	}
+	return c, nil
+}
+
+func (n *call) eval(vars map[string]interface{}) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(vars)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	switch n.function {
+	case "size":
+		switch v := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(v)), nil
+		case []interface{}:
+			return int64(len(v)), nil
+		case map[string]interface{}:
+			return int64(len(v)), nil
+		}
+	case "int":
+		switch v := args[0].(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if math.IsNaN(v) || v >= math.MaxInt64 || v < math.MinInt64 {
+				return nil, errors.New("int() out of range")
+			}
+			return int64(v), nil
+		case string:
+			i, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("int() cannot parse %q", v)
+			}
+			return i, nil
+		}
+	case "double":
+		switch v := args[0].(type) {
+		case int64, float64:
+			d, _ := toDouble(v)
+			return d, nil
+		case string:
+			d, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("double() cannot parse %q", v)
+			}
+			return d, nil
+		}
+	case "string":
+		switch v := args[0].(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			return strconv.FormatFloat(v, 'g', -1, 64), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+	default:
+		s, sok := args[0].(string)
+		p, pok := args[1].(string)
+		if !sok || !pok {
+			break
+		}
+		switch n.function {
+		case "startsWith":
+			return strings.HasPrefix(s, p), nil
+		case "endsWith":
+			return strings.HasSuffix(s, p), nil
+		case "contains":
+			return strings.Contains(s, p), nil
+		case "matches":
+			pattern := n.pattern
+			if pattern == nil {
+				var err error
+				if pattern, err = regexp.Compile(p); err != nil {
+					return nil, fmt.Errorf("matches() bad pattern: %w", err)
+				}
+			}
+			return pattern.MatchString(s), nil
+		}
+	}
+	types := make([]string, len(args))
+	for i, arg := range args {
+		types[i] = typeName(arg)
+	}
+	return nil, fmt.Errorf("no overload of %s for (%s)", n.function, strings.Join(types, ", "))
+}
+
+// Values
+
+// normalize converts numbers to int64 or float64, the numeric types expressions work
+// with. Other values are returned unchanged.
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case uint32:
+		return int64(v)
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v)
+		}
+		return float64(v)
+	case float32:
+		return float64(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	}
+	return value
+}
+
+// toInt returns an integral number as an int64.
+func toInt(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return v, true
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+// toDouble returns a number as a float64.
+func toDouble(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// typeName names a value's type in error messages.
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,271 @@
+package policy
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpression_Eval(t *testing.T) {
+	vars := map[string]interface{}{
+		"tool": "k8s_pod_logs",
+		"args": map[string]interface{}{
+			"namespace": "prod",
+			"lines":     float64(500),
+			"tags":      []interface{}{"a", "b"},
+			"nested":    map[string]interface{}{"depth": json.Number("2")},
+		},
+		"identity": map[string]interface{}{"tenant": "ops"},
+	}
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{`tool.startsWith('k8s_') && identity.tenant != "dev"`, true},
+		{`args.lines > 100 ? 100 : args.lines`, int64(100)},
+		{`args.lines == 500`, true},
+		{`args.namespace in ["prod", "staging"]`, true},
+		{`"b" in args.tags && !("c" in args.tags)`, true},
+		{`"namespace" in args`, true},
+		{`has(args.container) || args.tags[1] == "b"`, true},
+		{`size(args.tags) + args.tags.size() + size("héllo")`, int64(9)},
+		{`args.nested["depth"] * 2 + 1`, int64(5)},
+		{`7 / 2 + 7 % 2 - -1`, int64(5)},
+		{`1.5 * 2.0`, 3.0},
+		{`"a" + 'b' + "\n"`, "ab\n"},
+		{`[1, 2] + [3]`, []interface{}{int64(1), int64(2), int64(3)}},
+		{`{"k": 1}["k"]`, int64(1)},
+		{`args.namespace.matches("^pr[a-z]+$")`, true},
+		{`args.namespace.endsWith("od") && args.namespace.contains("ro")`, true},
+		{`int("42") + int(2.9) + int(double("1.5"))`, int64(45)},
+		{`string(3) + string(true)`, "3true"},
+		{`null == null && 1 < 2 && "a" <= "b" && 2 >= 2.0`, true},
+		{`false && args.missing`, false},
+		{`true || args.missing`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			got, err := expression.Eval(vars)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpression_Errors(t *testing.T) {
+	t.Run("compile", func(t *testing.T) {
+		for _, source := range []string{``, `1 +`, `(1`, `"open`, `a.`, `unknown(1)`, `size(1, 2)`, `has(a)`, `"x".matches("(")`, `1 # 2`, `a ? b`} {
+			if _, err := Compile(source); err == nil {
+				t.Errorf("Expected a compile error for %q", source)
+			}
+		}
+	})
+
+	t.Run("eval", func(t *testing.T) {
+		vars := map[string]interface{}{"args": map[string]interface{}{"n": float64(1), "list": []interface{}{}}}
+		for _, source := range []string{`missing`, `args.missing`, `args.n.field`, `args.list[0]`, `1 / 0`, `"a" - 1`, `"a" < 1`, `!1`, `size(1)`, `args.n && true`, `int("x")`} {
+			expression, err := Compile(source)
+			if err != nil {
+				t.Fatalf("Compile(%q) failed: %v", source, err)
+			}
+			if _, err := expression.Eval(vars); err == nil {
+				t.Errorf("Expected an evaluation error for %q", source)
+			}
+		}
+	})
+
+	t.Run("EvalBool requires a bool", func(t *testing.T) {
+		expression, _ := Compile(`1 + 1`)
+		if _, err := expression.EvalBool(nil); err == nil {
+			t.Error("Expected an error for a non-bool result")
+		}
+	})
+}
+
+func TestExpression_ParseErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string // Part of the error
+	}{
+		{``, `unexpected "end of expression"`},
+		{`1 +`, `unexpected "end of expression"`},
+		{`(1`, `expected ")"`},
+		{`[1, 2`, `expected ","`},
+		{`{"a" 1}`, `expected ":"`},
+		{`"open`, `unterminated string`},
+		{`"\q"`, `unknown escape`},
+		{`1 # 2`, `unexpected character '#'`},
+		{`1 2`, `unexpected "2"`},
+		{`a.`, `expected a field name`},
+		{`a.1`, `expected a field name`},
+		{`a ? b`, `expected ":"`},
+		{`a = b`, `unexpected character '='`},
+		{`a & b`, `unexpected character '&'`},
+		{`unknown(1)`, `unknown function unknown`},
+		{`"x".unknown()`, `unknown function unknown`},
+		{`size(1, 2)`, `size takes 1 arguments`},
+		{`"x".startsWith()`, `startsWith takes 2 arguments`},
+		{`has(a)`, `has() requires a field selection`},
+		{`"x".matches("(")`, `bad pattern`},
+		{`"x".matches(1)`, `requires a string pattern`},
+		{`99999999999999999999`, `bad number`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			_, err := Compile(tt.source)
+			if err == nil {
+				t.Fatal("Expected a compile error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExpression_Precedence(t *testing.T) {
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{`1 + 2 * 3`, int64(7)},
+		{`(1 + 2) * 3`, int64(9)},
+		{`10 - 4 - 3`, int64(3)},
+		{`24 / 4 / 2`, int64(3)},
+		{`7 - 5 % 3`, int64(5)},
+		{`-2 * 3`, int64(-6)},
+		{`- -2`, int64(2)},
+		{`!true == false`, true},
+		{`!(true == false)`, true},
+		{`true || false && false`, true},
+		{`(true || false) && false`, false},
+		{`false && true || true`, true},
+		{`1 + 1 == 2 && 2 * 2 == 4`, true},
+		{`1 < 2 == true`, true},
+		{`1 + 1 in [2, 3]`, true},
+		{`true ? 1 : 2 + 10`, int64(1)},
+		{`false ? 1 : true ? 2 : 3`, int64(2)},
+		{`1 < 2 ? "lt" : "ge"`, "lt"},
+		{`"ab".size() * 2`, int64(4)},
+		{`[1, 2][0] + 1`, int64(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			got, err := expression.Eval(nil)
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestExpression_TypeMismatches(t *testing.T) {
+	vars := map[string]interface{}{
+		"args": map[string]interface{}{"n": float64(1), "s": "text", "list": []interface{}{"a"}, "flag": true},
+	}
+	tests := []struct {
+		source string
+		want   string // Part of the error
+	}{
+		{`"a" - 1`, `cannot apply - to string and int`},
+		{`args.s * 2`, `cannot apply * to string and int`},
+		{`args.list + "x"`, `cannot apply + to list and string`},
+		{`args.flag + 1`, `cannot apply + to bool and int`},
+		{`"a" < 1`, `cannot compare string and int`},
+		{`args.flag > false`, `cannot compare bool and bool`},
+		{`!1`, `cannot apply ! to int`},
+		{`-"a"`, `cannot apply - to string`},
+		{`args.n && true`, `cannot apply && to double`},
+		{`true || false || "x" || true`, ``},
+		{`false || "x"`, `cannot apply || to string`},
+		{`args.n ? 1 : 2`, `condition is double, not a bool`},
+		{`1 in "abc"`, `cannot apply in to string`},
+		{`args.list["0"]`, `cannot index a list with string`},
+		{`args.s[0]`, `cannot index string`},
+		{`{1: "a"}`, `map keys must be strings, not int`},
+		{`size(1)`, `no overload of size for (int)`},
+		{`args.n.startsWith("1")`, `no overload of startsWith for (double, string)`},
+		{`int(true)`, `no overload of int for (bool)`},
+		{`int("x")`, `int() cannot parse "x"`},
+		{`args.s.field`, `cannot select "field" from string`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			_, err = expression.Eval(vars)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("Expected short-circuiting to skip the mismatch, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestExpression_MissingFields(t *testing.T) {
+	vars := map[string]interface{}{
+		"args": map[string]interface{}{"present": "x", "nested": map[string]interface{}{}},
+	}
+	tests := []struct {
+		source string
+		want   interface{} // The value, or the error as a string when err is set
+		err    bool
+	}{
+		{`missing`, `undeclared variable "missing"`, true},
+		{`args.absent`, `no such key "absent"`, true},
+		{`args["absent"]`, `no such key "absent"`, true},
+		{`args.nested.deeper`, `no such key "deeper"`, true},
+		{`args.absent == null`, `no such key "absent"`, true},
+		{`has(args.absent)`, false, false},
+		{`has(args.present)`, true, false},
+		{`has(args.nested.deeper)`, false, false},
+		{`has(args.absent.deeper)`, `no such key "absent"`, true},
+		{`"absent" in args`, false, false},
+		{`has(args.absent) && args.absent > 1`, false, false},
+		{`has(args.absent) ? args.absent : "fallback"`, "fallback", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expression, err := Compile(tt.source)
+			if err != nil {
+				t.Fatalf("Compile failed: %v", err)
+			}
+			got, err := expression.Eval(vars)
+			if tt.err {
+				if err == nil || !strings.Contains(err.Error(), tt.want.(string)) {
+					t.Errorf("Expected an error containing %q, got %v, %v", tt.want, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Eval failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
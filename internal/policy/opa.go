@@ -0,0 +1,74 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxOPAResponseBytes caps how much of an OPA response is read.
+const maxOPAResponseBytes = 1 << 20
+
+// OPA is an Engine that queries an Open Policy Agent through its Data API. Each call is
+// POSTed as {"input": {"identity": ..., "tool": ..., "args": ...}} to the URL of a rule,
+// such as http://opa:8181/v1/data/mcp/authz. The rule may yield a bool, or an object
+// with "allow", an optional "reason" for denials, and optional "args" replacing the
+// call's arguments. An undefined rule denies the call.
+type OPA struct {
+	url    string
+	client *http.Client
+}
+
+// NewOPA creates an OPA engine querying url, waiting up to timeout for each decision.
+func NewOPA(url string, timeout time.Duration) *OPA {
+	return &OPA{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Evaluate asks the agent for a decision.
+func (o *OPA) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	input.Args = nonNil(input.Args)
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to encode policy input: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("policy agent unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("policy agent returned status %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOPAResponseBytes)).Decode(&response); err != nil {
+		return Decision{}, fmt.Errorf("invalid policy agent response: %w", err)
+	}
+	if len(response.Result) == 0 {
+		return Decision{Reason: "policy is undefined"}, nil
+	}
+	var allow bool
+	if err := json.Unmarshal(response.Result, &allow); err == nil {
+		return Decision{Allow: allow}, nil
+	}
+	var result struct {
+		Allow  bool                   `json:"allow"`
+		Reason string                 `json:"reason"`
+		Args   map[string]interface{} `json:"args"`
+	}
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return Decision{}, fmt.Errorf("policy result must be a bool or an object with allow: %w", err)
+	}
+	return Decision{Allow: result.Allow, Reason: result.Reason, Args: result.Args}, nil
+}
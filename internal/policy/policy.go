@@ -0,0 +1,194 @@
+// Package policy decides whether tool calls may run. Each call is evaluated with the
+// caller's identity, the tool, and its arguments by rules written as expressions (see
+// Expression), by an external Open Policy Agent, or both, which allow it, deny it, or rewrite its
+// arguments.
+package policy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// ErrDenied is returned for a call the policy denies.
+var ErrDenied = errors.New("denied by policy")
+
+// Identity describes who is making a call. Fields are empty when they are not known.
+type Identity struct {
+	Tenant    string `json:"tenant"`
+	SessionID string `json:"sessionId"`
+	ClientIP  string `json:"clientIp"`
+	RequestID string `json:"requestId"`
+}
+
+// Input is what a policy decides on.
+type Input struct {
+	Identity Identity               `json:"identity"`
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args"`
+}
+
+// Decision is a policy's verdict on a call.
+type Decision struct {
+	Allow  bool
+	Reason string                 // Why the call was denied
+	Args   map[string]interface{} // Arguments to call the tool with instead; nil keeps them
+}
+
+// Engine evaluates calls against a policy. An error means the policy could not decide,
+// and callers should deny the call.
+type Engine interface {
+	Evaluate(ctx context.Context, input Input) (Decision, error)
+}
+
+// Rule is one rule of a RuleSet.
+type Rule struct {
+	Name   string            `json:"name,omitempty"`   // Identifies the rule in denials
+	When   string            `json:"when"`             // Expression; the rule applies when it is true
+	Effect string            `json:"effect,omitempty"` // "allow" or "deny"; a rule without one only sets arguments
+	Reason string            `json:"reason,omitempty"` // Reported to the caller when the rule denies
+	Set    map[string]string `json:"set,omitempty"`    // Arguments replaced with the values of expressions
+}
+
+// Rule effects.
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// compiledRule is a Rule with its expressions compiled.
+type compiledRule struct {
+	Rule
+	when *Expression
+	set  map[string]*Expression
+}
+
+// RuleSet is an Engine of rules written as expressions over the variables identity,
+// tool, and args. Rules are tried in order. A rule whose when expression is true first
+// sets its arguments, which later rules see, and then decides the call if it has an
+// effect. When no rule decides, the default applies.
+type RuleSet struct {
+	rules        []compiledRule
+	defaultAllow bool
+}
+
+// NewRuleSet compiles rules into a RuleSet that allows calls no rule decides when
+// defaultAllow is set, and denies them otherwise.
+func NewRuleSet(rules []Rule, defaultAllow bool) (*RuleSet, error) {
+	set := &RuleSet{defaultAllow: defaultAllow}
+	for i, rule := range rules {
+		name := rule.Name
+		if name == "" {
+			name = fmt.Sprintf("rule %d", i)
+		}
+		if rule.Effect != "" && rule.Effect != EffectAllow && rule.Effect != EffectDeny {
+			return nil, fmt.Errorf("%s: effect must be %q or %q", name, EffectAllow, EffectDeny)
+		}
+		if rule.Effect == "" && len(rule.Set) == 0 {
+			return nil, fmt.Errorf("%s: needs an effect or arguments to set", name)
+		}
+		compiled := compiledRule{Rule: rule, set: make(map[string]*Expression, len(rule.Set))}
+		compiled.Name = name
+		var err error
+		if compiled.when, err = Compile(rule.When); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		for arg, source := range rule.Set {
+			if compiled.set[arg], err = Compile(source); err != nil {
+				return nil, fmt.Errorf("%s: argument %s: %w", name, arg, err)
+			}
+		}
+		set.rules = append(set.rules, compiled)
+	}
+	return set, nil
+}
+
+// Evaluate applies the rules to a call.
+func (s *RuleSet) Evaluate(_ context.Context, input Input) (Decision, error) {
+	args := input.Args
+	modified := false
+	vars := map[string]interface{}{
+		"identity": map[string]interface{}{
+			"tenant":    input.Identity.Tenant,
+			"sessionId": input.Identity.SessionID,
+			"clientIp":  input.Identity.ClientIP,
+			"requestId": input.Identity.RequestID,
+		},
+		"tool": input.Tool,
+		"args": nonNil(args),
+	}
+	decided := func(allow bool, reason string) Decision {
+		decision := Decision{Allow: allow, Reason: reason}
+		if modified {
+			decision.Args = args
+		}
+		return decision
+	}
+
+	for _, rule := range s.rules {
+		applies, err := rule.when.EvalBool(vars)
+		if err != nil {
+			return Decision{}, fmt.Errorf("%s: %w", rule.Name, err)
+		}
+		if !applies {
+			continue
+		}
+		if len(rule.set) > 0 {
+			updated := maps.Clone(nonNil(args))
+			for arg, expression := range rule.set {
+				value, err := expression.Eval(vars)
+				if err != nil {
+					return Decision{}, fmt.Errorf("%s: argument %s: %w", rule.Name, arg, err)
+				}
+				updated[arg] = value
+			}
+			args, modified = updated, true
+			vars["args"] = args
+		}
+		switch rule.Effect {
+		case EffectAllow:
+			return decided(true, ""), nil
+		case EffectDeny:
+			reason := rule.Reason
+			if reason == "" {
+				reason = "denied by " + rule.Name
+			}
+			return decided(false, reason), nil
+		}
+	}
+	if s.defaultAllow {
+		return decided(true, ""), nil
+	}
+	return decided(false, "no policy rule allows the call"), nil
+}
+
+// Chain is an Engine that allows a call only if every engine in it does. Each engine
+// sees the arguments the previous ones set.
+type Chain []Engine
+
+// Evaluate asks each engine in turn, stopping at the first denial.
+func (c Chain) Evaluate(ctx context.Context, input Input) (Decision, error) {
+	var args map[string]interface{}
+	for _, engine := range c {
+		decision, err := engine.Evaluate(ctx, input)
+		if err != nil {
+			return Decision{}, err
+		}
+		if decision.Args != nil {
+			input.Args, args = decision.Args, decision.Args
+		}
+		if !decision.Allow {
+			return Decision{Reason: decision.Reason}, nil
+		}
+	}
+	return Decision{Allow: true, Args: args}, nil
+}
+
+// nonNil returns args, or an empty map if it is nil, so expressions can index it.
+func nonNil(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return map[string]interface{}{}
+	}
+	return args
+}
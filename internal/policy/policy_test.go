@@ -0,0 +1,228 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRuleSet(t *testing.T) {
+	rules := []Rule{
+		{Name: "cap lines", When: `tool == "logs" && has(args.lines) && args.lines > 100`, Set: map[string]string{"lines": "100"}},
+		{Name: "ops only", When: `tool.startsWith("k8s_") && identity.tenant != "ops"`, Effect: EffectDeny, Reason: "Kubernetes tools are for ops"},
+		{When: `tool == "logs"`, Effect: EffectAllow},
+	}
+
+	t.Run("denies, allows, and sets arguments", func(t *testing.T) {
+		set, err := NewRuleSet(rules, true)
+		if err != nil {
+			t.Fatalf("NewRuleSet failed: %v", err)
+		}
+		decision, err := set.Evaluate(context.Background(), Input{Tool: "k8s_events", Identity: Identity{Tenant: "dev"}})
+		if err != nil || decision.Allow || decision.Reason != "Kubernetes tools are for ops" {
+			t.Errorf("Expected a denial with the rule's reason, got %+v, %v", decision, err)
+		}
+		decision, _ = set.Evaluate(context.Background(), Input{Tool: "k8s_events", Identity: Identity{Tenant: "ops"}})
+		if !decision.Allow || decision.Args != nil {
+			t.Errorf("Expected ops to be allowed with unchanged arguments, got %+v", decision)
+		}
+		args := map[string]interface{}{"lines": float64(5000), "pod": "api"}
+		decision, _ = set.Evaluate(context.Background(), Input{Tool: "logs", Args: args})
+		if !decision.Allow || decision.Args["lines"] != int64(100) || decision.Args["pod"] != "api" {
+			t.Errorf("Expected lines to be capped, got %+v", decision)
+		}
+		if args["lines"] != float64(5000) {
+			t.Error("Expected the caller's arguments to be left unchanged")
+		}
+	})
+
+	t.Run("applies the default", func(t *testing.T) {
+		set, _ := NewRuleSet(rules, false)
+		if decision, _ := set.Evaluate(context.Background(), Input{Tool: "uuid"}); decision.Allow {
+			t.Error("Expected calls no rule decides to be denied")
+		}
+	})
+
+	t.Run("reports evaluation errors", func(t *testing.T) {
+		set, _ := NewRuleSet([]Rule{{When: `args.path.startsWith("/etc")`, Effect: EffectDeny}}, true)
+		if _, err := set.Evaluate(context.Background(), Input{Tool: "read"}); err == nil {
+			t.Error("Expected an error for a missing argument")
+		}
+	})
+
+	t.Run("validates rules", func(t *testing.T) {
+		for _, rule := range []Rule{
+			{When: `true`, Effect: "maybe"},
+			{When: `true`},
+			{When: `(`, Effect: EffectDeny},
+			{When: `true`, Set: map[string]string{"x": "1 +"}},
+		} {
+			if _, err := NewRuleSet([]Rule{rule}, true); err == nil {
+				t.Errorf("Expected an error for %+v", rule)
+			}
+		}
+	})
+}
+
+func TestRuleSet_DenyByDefault(t *testing.T) {
+	tests := []struct {
+		name         string
+		rules        []Rule
+		defaultAllow bool
+		input        Input
+		allow        bool
+		err          bool // The rules cannot decide, which callers treat as a denial
+	}{
+		{name: "no rules", allow: false},
+		{name: "no rules with default allow", defaultAllow: true, allow: true},
+		{
+			name:  "no rule applies",
+			rules: []Rule{{When: `tool == "echo"`, Effect: EffectAllow}},
+			input: Input{Tool: "deploy"},
+			allow: false,
+		},
+		{
+			name:  "an allow rule applies",
+			rules: []Rule{{When: `tool == "echo"`, Effect: EffectAllow}},
+			input: Input{Tool: "echo"},
+			allow: true,
+		},
+		{
+			name:  "setting arguments does not allow",
+			rules: []Rule{{When: `true`, Set: map[string]string{"n": "1"}}},
+			input: Input{Tool: "echo"},
+			allow: false,
+		},
+		{
+			name:         "a deny rule overrides the default",
+			rules:        []Rule{{When: `tool == "deploy"`, Effect: EffectDeny}},
+			defaultAllow: true,
+			input:        Input{Tool: "deploy"},
+			allow:        false,
+		},
+		{
+			name:  "the first deciding rule wins",
+			rules: []Rule{{When: `true`, Effect: EffectDeny}, {When: `true`, Effect: EffectAllow}},
+			allow: false,
+		},
+		{
+			name:         "a missing argument is an error, not a skipped rule",
+			rules:        []Rule{{When: `args.path.startsWith("/etc")`, Effect: EffectDeny}},
+			defaultAllow: true,
+			input:        Input{Tool: "read"},
+			err:          true,
+		},
+		{
+			name:         "a type mismatch is an error",
+			rules:        []Rule{{When: `args.n > "1"`, Effect: EffectDeny}},
+			defaultAllow: true,
+			input:        Input{Tool: "read", Args: map[string]interface{}{"n": float64(2)}},
+			err:          true,
+		},
+		{
+			name:         "a non-bool condition is an error",
+			rules:        []Rule{{When: `args.n`, Effect: EffectDeny}},
+			defaultAllow: true,
+			input:        Input{Tool: "read", Args: map[string]interface{}{"n": float64(2)}},
+			err:          true,
+		},
+		{
+			name:  "an unknown identity matches no tenant rule",
+			rules: []Rule{{When: `identity.tenant == "ops"`, Effect: EffectAllow}},
+			input: Input{Tool: "echo"},
+			allow: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := NewRuleSet(tt.rules, tt.defaultAllow)
+			if err != nil {
+				t.Fatalf("NewRuleSet failed: %v", err)
+			}
+			decision, err := set.Evaluate(context.Background(), tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Expected an error, got %+v", decision)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Evaluate failed: %v", err)
+			}
+			if decision.Allow != tt.allow {
+				t.Errorf("Expected allow=%v, got %+v", tt.allow, decision)
+			}
+			if !decision.Allow && decision.Reason == "" {
+				t.Error("Expected a denial to give a reason")
+			}
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	capper, _ := NewRuleSet([]Rule{{When: `true`, Set: map[string]string{"n": "1"}}}, true)
+	checker, _ := NewRuleSet([]Rule{{When: `args.n != 1`, Effect: EffectDeny}}, true)
+	decision, err := Chain{capper, checker}.Evaluate(context.Background(), Input{Tool: "t", Args: map[string]interface{}{"n": float64(9)}})
+	if err != nil || !decision.Allow || decision.Args["n"] != int64(1) {
+		t.Errorf("Expected later engines to see earlier arguments, got %+v, %v", decision, err)
+	}
+	denier, _ := NewRuleSet(nil, false)
+	if decision, _ := (Chain{capper, denier}).Evaluate(context.Background(), Input{Tool: "t"}); decision.Allow {
+		t.Error("Expected any engine's denial to deny the call")
+	}
+}
+
+func TestOPA(t *testing.T) {
+	var received map[string]map[string]interface{}
+	result := `{"result": true}`
+	status := http.StatusOK
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(result))
+	}))
+	defer agent.Close()
+	opa := NewOPA(agent.URL+"/v1/data/mcp/authz", time.Second)
+	input := Input{Identity: Identity{Tenant: "ops"}, Tool: "notify", Args: map[string]interface{}{"channel": "ops"}}
+
+	t.Run("sends the input", func(t *testing.T) {
+		decision, err := opa.Evaluate(context.Background(), input)
+		if err != nil || !decision.Allow {
+			t.Fatalf("Expected the call to be allowed, got %+v, %v", decision, err)
+		}
+		in := received["input"]
+		if in["tool"] != "notify" || in["identity"].(map[string]interface{})["tenant"] != "ops" || in["args"].(map[string]interface{})["channel"] != "ops" {
+			t.Errorf("Unexpected input: %v", in)
+		}
+	})
+
+	t.Run("reads object results", func(t *testing.T) {
+		result = `{"result": {"allow": true, "args": {"channel": "audit"}}}`
+		decision, err := opa.Evaluate(context.Background(), input)
+		if err != nil || !decision.Allow || decision.Args["channel"] != "audit" {
+			t.Errorf("Expected rewritten arguments, got %+v, %v", decision, err)
+		}
+		result = `{"result": {"allow": false, "reason": "quiet hours"}}`
+		if decision, _ := opa.Evaluate(context.Background(), input); decision.Allow || decision.Reason != "quiet hours" {
+			t.Errorf("Expected a denial with the reason, got %+v", decision)
+		}
+	})
+
+	t.Run("denies undefined results", func(t *testing.T) {
+		result = `{}`
+		if decision, err := opa.Evaluate(context.Background(), input); err != nil || decision.Allow {
+			t.Errorf("Expected a denial, got %+v, %v", decision, err)
+		}
+	})
+
+	t.Run("reports agent failures", func(t *testing.T) {
+		status = http.StatusInternalServerError
+		defer func() { status = http.StatusOK }()
+		if _, err := opa.Evaluate(context.Background(), input); err == nil {
+			t.Error("Expected an error for a failing agent")
+		}
+	})
+}
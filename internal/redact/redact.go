@@ -0,0 +1,294 @@
+// Package redact removes sensitive values, such as credentials, card numbers, and email
+// addresses, from tool results and log records before they leave the server.
+package redact
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/pkg/tools"
+)
+
+// redactionsTotal counts replaced matches by rule and by where they were found.
+var redactionsTotal = metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "redactions_total",
+		Help: "Total number of values redacted from tool results and logs",
+	},
+	[]string{"rule", "target"},
+)
+
+// Targets of redaction, used as the metric's target label.
+const (
+	TargetResult = "result"
+	TargetLog    = "log"
+)
+
+// Rule replaces the matches of a pattern.
+type Rule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string            // Expanded with the match's groups, e.g. "${1}***"; defaults to [REDACTED:<name>]
+	Valid       func(string) bool // Optional check that a match is a real hit, such as a Luhn checksum
+}
+
+// builtinRules are the rules that can be enabled by name.
+var builtinRules = map[string]Rule{
+	"email": {
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	},
+	"credit_card": {
+		Pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Valid:   luhnValid,
+	},
+	"secrets": {
+		// key=value and "key": "value" pairs whose key names a credential; only the value is replaced
+		Pattern:     regexp.MustCompile(`(?i)\b((?:api[_-]?key|secret|token|password|passwd|pwd|access[_-]?key|client[_-]?secret)"?\s*[:=]\s*"?)([^\s"',;&}]+)`),
+		Replacement: "${1}[REDACTED:secrets]",
+	},
+	"bearer_token": {
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`),
+		Replacement: "${1}[REDACTED:bearer_token]",
+	},
+	"aws_access_key": {
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	},
+	"jwt": {
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	},
+	"private_key": {
+		Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	},
+}
+
+// BuiltinRules returns the names of the rules that can be enabled by name.
+func BuiltinRules() []string {
+	names := make([]string, 0, len(builtinRules))
+	for name := range builtinRules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rules returns the built-in rules with the given names followed by a rule for each
+// custom pattern, keyed by rule name.
+func Rules(names []string, patterns map[string]string) ([]Rule, error) {
+	var rules []Rule
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		rule, ok := builtinRules[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction rule %q; built-in rules are %s", name, strings.Join(BuiltinRules(), ", "))
+		}
+		rule.Name = name
+		rules = append(rules, rule)
+	}
+	customNames := make([]string, 0, len(patterns))
+	for name := range patterns {
+		customNames = append(customNames, name)
+	}
+	sort.Strings(customNames)
+	for _, name := range customNames {
+		pattern, err := regexp.Compile(patterns[name])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %s: %w", name, err)
+		}
+		rules = append(rules, Rule{Name: name, Pattern: pattern})
+	}
+	return rules, nil
+}
+
+// Redactor applies rules to strings, tool results, and log records. A nil Redactor
+// returns everything unchanged.
+type Redactor struct {
+	rules []Rule
+}
+
+// New creates a Redactor applying rules in order. It returns nil when there are no rules.
+func New(rules []Rule) *Redactor {
+	if len(rules) == 0 {
+		return nil
+	}
+	return &Redactor{rules: rules}
+}
+
+// String returns s with every match redacted, counting the hits against target.
+func (r *Redactor) String(s, target string) string {
+	if r == nil || s == "" {
+		return s
+	}
+	for _, rule := range r.rules {
+		hits := 0
+		s = rule.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.Valid != nil && !rule.Valid(match) {
+				return match
+			}
+			hits++
+			if rule.Replacement == "" {
+				return "[REDACTED:" + rule.Name + "]"
+			}
+			submatches := rule.Pattern.FindStringSubmatchIndex(match)
+			return string(rule.Pattern.ExpandString(nil, rule.Replacement, match, submatches))
+		})
+		if hits > 0 {
+			redactionsTotal.WithLabelValues(rule.Name, target).Add(float64(hits))
+		}
+	}
+	return s
+}
+
+// Value returns value with every string inside it redacted, including the text and JSON
+// of content blocks. Maps and slices are copied rather than modified; values of other
+// types, such as binary attachments, are kept.
+func (r *Redactor) Value(value interface{}) interface{} {
+	if r == nil {
+		return value
+	}
+	switch v := value.(type) {
+	case string:
+		return r.String(v, TargetResult)
+	case map[string]interface{}:
+		return r.Map(v)
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.Value(item)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, item := range v {
+			redacted[i] = r.String(item, TargetResult)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, item := range v {
+			redacted[key] = r.String(item, TargetResult)
+		}
+		return redacted
+	case []map[string]interface{}:
+		redacted := make([]map[string]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = r.Map(item)
+		}
+		return redacted
+	case []tools.Content:
+		redacted := make([]tools.Content, len(v))
+		for i, block := range v {
+			block.Text = r.String(block.Text, TargetResult)
+			block.Value = r.Value(block.Value)
+			redacted[i] = block
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// Map returns a copy of a tool result with every string inside it redacted.
+func (r *Redactor) Map(result map[string]interface{}) map[string]interface{} {
+	if r == nil || result == nil {
+		return result
+	}
+	redacted := make(map[string]interface{}, len(result))
+	for key, value := range result {
+		redacted[key] = r.Value(value)
+	}
+	return redacted
+}
+
+// Handler wraps a log handler so that messages and attribute values are redacted before
+// they are written. Non-string values are redacted in their printed form and logged as
+// strings only when something was redacted.
+func (r *Redactor) Handler(next slog.Handler) slog.Handler {
+	if r == nil {
+		return next
+	}
+	return &handler{next: next, redactor: r}
+}
+
+// handler is the slog.Handler returned by Redactor.Handler.
+type handler struct {
+	next     slog.Handler
+	redactor *Redactor
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	redacted := slog.NewRecord(record.Time, record.Level, h.redactor.String(record.Message, TargetLog), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		redacted.AddAttrs(h.attr(attr))
+		return true
+	})
+	return h.next.Handle(ctx, redacted)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i] = h.attr(attr)
+	}
+	return &handler{next: h.next.WithAttrs(redacted), redactor: h.redactor}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+// attr redacts an attribute's value, recursing into groups.
+func (h *handler) attr(attr slog.Attr) slog.Attr {
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.String(value.String(), TargetLog))
+	case slog.KindGroup:
+		group := value.Group()
+		redacted := make([]any, len(group))
+		for i, member := range group {
+			redacted[i] = h.attr(member)
+		}
+		return slog.Group(attr.Key, redacted...)
+	case slog.KindAny:
+		printed := fmt.Sprintf("%+v", value.Any())
+		if redacted := h.redactor.String(printed, TargetLog); redacted != printed {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+	return attr
+}
+
+// luhnValid reports whether the digits in s pass the Luhn checksum used by card numbers.
+func luhnValid(s string) bool {
+	sum, count, double := 0, 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		digit := int(c - '0')
+		if double {
+			if digit *= 2; digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+		count++
+	}
+	return count >= 13 && sum%10 == 0
+}
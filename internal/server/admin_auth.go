@@ -0,0 +1,78 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// adminTokenHeader names the header that carries the operator credential. An
+// "Authorization: Bearer <token>" header is accepted as well.
+const adminTokenHeader = "X-Admin-Token"
+
+// WithAdminToken sets the operator credential every /admin/ request must send. Without
+// one, the /admin/ endpoints only answer direct connections from the loopback interface
+// or a Unix socket.
+func WithAdminToken(token string) ServerOption {
+	return func(o *serverOptions) {
+		o.adminToken = token
+	}
+}
+
+// AdminAuthenticated reports whether /admin/ endpoints require a credential rather than
+// a local connection.
+func (s *HTTPServer) AdminAuthenticated() bool {
+	return s.options.adminToken != ""
+}
+
+// requireAdmin only lets operators through to handler. With an admin token configured,
+// requests must send it and are otherwise rejected with 401. Without one, requests must
+// come straight from the loopback interface or a Unix socket, and are otherwise rejected
+// with 403; a request relayed by a proxy carries forwarding headers and is refused even
+// when the proxy runs on the same host.
+func (s *HTTPServer) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := s.options.adminToken; token != "" {
+			if subtle.ConstantTimeCompare([]byte(adminCredential(r)), []byte(token)) != 1 {
+				loggerFor(r.Context(), s.logger).Warn("Rejected admin request without a valid token", "path", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-tools-server admin"`)
+				s.writeError(w, r, http.StatusUnauthorized, errCodeUnauthorized, "A valid admin token is required")
+				return
+			}
+			handler(w, r)
+			return
+		}
+		if !s.localRequest(r) {
+			loggerFor(r.Context(), s.logger).Warn("Rejected remote admin request", "path", r.URL.Path, "remote", r.RemoteAddr)
+			s.writeError(w, r, http.StatusForbidden, errCodeForbidden, "Admin endpoints only accept local connections unless ADMIN_TOKEN is set")
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// adminCredential returns the admin token sent with r, or "" if there is none.
+func adminCredential(r *http.Request) string {
+	if token := r.Header.Get(adminTokenHeader); token != "" {
+		return token
+	}
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return ""
+}
+
+// localRequest reports whether r came straight from this host: over a Unix socket, or
+// from a loopback address without forwarding headers.
+func (s *HTTPServer) localRequest(r *http.Request) bool {
+	if s.options.network == "unix" {
+		return true
+	}
+	for _, header := range []string{"Forwarded", "X-Forwarded-For", "X-Real-IP"} {
+		if r.Header.Get(header) != "" {
+			return false
+		}
+	}
+	remote, ok := parseHostAddr(r.RemoteAddr)
+	return ok && remote.Unmap().IsLoopback()
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+
+	serve := func(httpServer *HTTPServer, remote string, header http.Header) int {
+		req := httptest.NewRequest("GET", "/admin/quarantine", nil)
+		req.RemoteAddr = remote
+		for key, values := range header {
+			req.Header.Set(key, values[0])
+		}
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("without a token only local connections are served", func(t *testing.T) {
+		httpServer := NewHTTPServer(toolService, WithLogger(logger))
+		for _, remote := range []string{"127.0.0.1:5000", "[::1]:5000"} {
+			if code := serve(httpServer, remote, nil); code != http.StatusOK {
+				t.Errorf("Expected %s to be served, got %d", remote, code)
+			}
+		}
+		if code := serve(httpServer, "192.0.2.1:5000", nil); code != http.StatusForbidden {
+			t.Errorf("Expected a remote request to get 403, got %d", code)
+		}
+		if code := serve(httpServer, "127.0.0.1:5000", http.Header{"X-Forwarded-For": {"192.0.2.1"}}); code != http.StatusForbidden {
+			t.Errorf("Expected a proxied request to get 403, got %d", code)
+		}
+	})
+
+	t.Run("with a token every request must send it", func(t *testing.T) {
+		httpServer := NewHTTPServer(toolService, WithLogger(logger), WithAdminToken("operator"))
+		for _, header := range []http.Header{nil, {adminTokenHeader: {"wrong"}}} {
+			if code := serve(httpServer, "127.0.0.1:5000", header); code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401, got %d", code)
+			}
+		}
+		for _, header := range []http.Header{{adminTokenHeader: {"operator"}}, {"Authorization": {"Bearer operator"}}} {
+			if code := serve(httpServer, "192.0.2.1:5000", header); code != http.StatusOK {
+				t.Errorf("Expected the token to be accepted, got %d", code)
+			}
+		}
+	})
+
+	t.Run("tenant keys do not open admin endpoints", func(t *testing.T) {
+		tenants, err := NewTenants([]Tenant{{Name: "team", APIKey: "key"}}, logger)
+		if err != nil {
+			t.Fatalf("NewTenants failed: %v", err)
+		}
+		httpServer := NewHTTPServer(toolService, WithLogger(logger), WithTenants(tenants), WithAdminToken("operator"))
+		if code := serve(httpServer, "192.0.2.1:5000", http.Header{apiKeyHeader: {"key"}}); code != http.StatusUnauthorized {
+			t.Errorf("Expected a tenant key to get 401, got %d", code)
+		}
+		if code := serve(httpServer, "192.0.2.1:5000", http.Header{"Authorization": {"Bearer operator"}}); code != http.StatusOK {
+			t.Errorf("Expected the admin token to be accepted alongside tenants, got %d", code)
+		}
+	})
+}
@@ -0,0 +1,611 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"mcp-tools-server/internal/logging"
+	"mcp-tools-server/internal/policy"
+	"mcp-tools-server/pkg/tools"
+)
+
+// registerAdminRoutes mounts the operator endpoints under /admin/, which only answer
+// operators; see requireAdmin.
+func (s *HTTPServer) registerAdminRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/readiness", s.requireAdmin(s.negotiateJSON(s.handleAdminReadiness)))
+	mux.HandleFunc("POST /admin/readiness", s.requireAdmin(s.negotiateJSON(s.handleAdminSetReadiness)))
+	mux.HandleFunc("GET /admin/resources", s.requireAdmin(s.negotiateJSON(s.handleAdminListResources)))
+	mux.HandleFunc("POST /admin/resources", s.requireAdmin(s.negotiateJSON(s.handleAdminAddResource)))
+	mux.HandleFunc("DELETE /admin/resources", s.requireAdmin(s.negotiateJSON(s.handleAdminRemoveResource)))
+	mux.HandleFunc("GET /admin/prompts", s.requireAdmin(s.negotiateJSON(s.handleAdminListPrompts)))
+	mux.HandleFunc("POST /admin/prompts", s.requireAdmin(s.negotiateJSON(s.handleAdminAddPrompt)))
+	mux.HandleFunc("DELETE /admin/prompts/{name}", s.requireAdmin(s.negotiateJSON(s.handleAdminRemovePrompt)))
+	mux.HandleFunc("GET /admin/quarantine", s.requireAdmin(s.negotiateJSON(s.handleAdminQuarantine)))
+	mux.HandleFunc("DELETE /admin/quarantine/{name}", s.requireAdmin(s.negotiateJSON(s.handleAdminQuarantineRelease)))
+	mux.HandleFunc("GET /admin/breakers", s.requireAdmin(s.negotiateJSON(s.handleAdminBreakers)))
+	mux.HandleFunc("DELETE /admin/breakers/{name}", s.requireAdmin(s.negotiateJSON(s.handleAdminResetBreaker)))
+	mux.HandleFunc("GET /admin/executions", s.requireAdmin(s.negotiateJSON(s.handleAdminExecutions)))
+	mux.HandleFunc("POST /admin/executions/{id}/replay", s.requireAdmin(s.negotiateJSON(s.handleAdminReplayExecution)))
+	mux.HandleFunc("POST /admin/selftest", s.requireAdmin(s.negotiateJSON(s.handleAdminSelfTest)))
+	mux.HandleFunc("GET /admin/schedules", s.requireAdmin(s.negotiateJSON(s.handleAdminSchedules)))
+	mux.HandleFunc("POST /admin/schedules/{name}/enable", s.requireAdmin(s.negotiateJSON(s.handleAdminSetScheduleEnabled(true))))
+	mux.HandleFunc("POST /admin/schedules/{name}/disable", s.requireAdmin(s.negotiateJSON(s.handleAdminSetScheduleEnabled(false))))
+	mux.HandleFunc("GET /admin/events", s.requireAdmin(s.handleAdminEvents))
+	mux.HandleFunc("GET /admin/sessions", s.requireAdmin(s.negotiateJSON(s.handleAdminSessions)))
+	mux.HandleFunc("POST /admin/sessions/{id}/messages", s.requireAdmin(s.negotiateJSON(s.handleAdminSessionMessage)))
+	mux.HandleFunc("GET /admin/approvals", s.requireAdmin(s.negotiateJSON(s.handleAdminApprovals)))
+	mux.HandleFunc("POST /admin/approvals/{id}", s.requireAdmin(s.negotiateJSON(s.handleAdminDecideApproval)))
+	mux.HandleFunc("POST /admin/notifications", s.requireAdmin(s.negotiateJSON(s.handleAdminNotify)))
+	mux.HandleFunc("GET /admin/tools", s.requireAdmin(s.negotiateJSON(s.handleAdminTools)))
+	mux.HandleFunc("POST /admin/tools/register", s.requireAdmin(s.negotiateJSON(s.handleAdminRegisterTool)))
+	mux.HandleFunc("DELETE /admin/tools/{name}", s.requireAdmin(s.negotiateJSON(s.handleAdminRemoveTool)))
+	mux.HandleFunc("GET /admin/config", s.requireAdmin(s.negotiateJSON(s.handleAdminConfig)))
+	mux.HandleFunc("GET /admin/loglevel", s.requireAdmin(s.negotiateJSON(s.handleAdminLogLevel)))
+	mux.HandleFunc("PUT /admin/loglevel", s.requireAdmin(s.negotiateJSON(s.handleAdminSetLogLevel)))
+}
+
+// writeJSON writes a JSON response with the given status code.
+func (s *HTTPServer) writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		s.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// handleAdminReadiness handles GET /admin/readiness requests.
+func (s *HTTPServer) handleAdminReadiness(w http.ResponseWriter, r *http.Request) {
+	ready, reason := s.health.Ready()
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ready":          ready,
+		"reason":         reason,
+		"unhealthyTools": s.toolService.Health().Unhealthy(),
+	})
+}
+
+// handleAdminSetReadiness handles POST /admin/readiness requests. The body
+// {"ready": false} manually fails readiness and {"ready": true} clears the override.
+func (s *HTTPServer) handleAdminSetReadiness(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Ready *bool `json:"ready"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Ready == nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, `Body must be {"ready": true|false}`)
+		return
+	}
+	s.health.SetManualFail(!*body.Ready)
+	s.logger.Info("Readiness override updated", "ready", *body.Ready)
+	s.handleAdminReadiness(w, r)
+}
+
+// handleAdminListResources handles GET /admin/resources requests.
+func (s *HTTPServer) handleAdminListResources(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"resources": s.toolService.Catalog().Resources()})
+}
+
+// handleAdminAddResource handles POST /admin/resources requests, registering (or
+// replacing) a resource. Live sessions are sent notifications/resources/list_changed.
+func (s *HTTPServer) handleAdminAddResource(w http.ResponseWriter, r *http.Request) {
+	var resource Resource
+	if err := json.NewDecoder(r.Body).Decode(&resource); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if err := s.toolService.Catalog().AddResource(resource); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	s.logger.Info("Resource registered", "uri", resource.URI)
+	s.writeJSON(w, http.StatusCreated, resource)
+}
+
+// handleAdminRemoveResource handles DELETE /admin/resources?uri=... requests.
+func (s *HTTPServer) handleAdminRemoveResource(w http.ResponseWriter, r *http.Request) {
+	uri := r.URL.Query().Get("uri")
+	if !s.toolService.Catalog().RemoveResource(uri) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Resource not found")
+		return
+	}
+	s.logger.Info("Resource unregistered", "uri", uri)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminListPrompts handles GET /admin/prompts requests.
+func (s *HTTPServer) handleAdminListPrompts(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"prompts": s.toolService.Catalog().Prompts()})
+}
+
+// handleAdminAddPrompt handles POST /admin/prompts requests, registering (or replacing)
+// a prompt.
+func (s *HTTPServer) handleAdminAddPrompt(w http.ResponseWriter, r *http.Request) {
+	var prompt Prompt
+	if err := json.NewDecoder(r.Body).Decode(&prompt); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if err := s.toolService.Catalog().AddPrompt(prompt); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	s.logger.Info("Prompt registered", "name", prompt.Name)
+	s.writeJSON(w, http.StatusCreated, prompt)
+}
+
+// handleAdminRemovePrompt handles DELETE /admin/prompts/{name} requests.
+func (s *HTTPServer) handleAdminRemovePrompt(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if !s.toolService.Catalog().RemovePrompt(name) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Prompt not found")
+		return
+	}
+	s.logger.Info("Prompt unregistered", "name", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminQuarantine handles GET /admin/quarantine requests, listing disabled tools.
+func (s *HTTPServer) handleAdminQuarantine(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"tools": s.toolService.Quarantine().Quarantined()})
+}
+
+// handleAdminQuarantineRelease handles DELETE /admin/quarantine/{name} requests, which
+// re-enable a quarantined tool without waiting for its cooldown.
+func (s *HTTPServer) handleAdminQuarantineRelease(w http.ResponseWriter, r *http.Request) {
+	if !s.toolService.Quarantine().Release(r.PathValue("name")) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Tool is not quarantined")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminBreakers handles GET /admin/breakers requests, listing tools whose circuit
+// breaker is open or has counted failures.
+func (s *HTTPServer) handleAdminBreakers(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"tools": s.toolService.Breaker().Breakers()})
+}
+
+// handleAdminResetBreaker handles DELETE /admin/breakers/{name} requests, which close a
+// tool's circuit without waiting for its cooldown.
+func (s *HTTPServer) handleAdminResetBreaker(w http.ResponseWriter, r *http.Request) {
+	if !s.toolService.Breaker().Reset(r.PathValue("name")) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Tool has no recorded failures")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminSelfTest handles POST /admin/selftest requests, which run every tool's
+// self-test, or those of the tools named by the "tool" query parameter. It responds 200
+// when no test failed and 503 otherwise, so deploy pipelines can use it as a smoke test.
+func (s *HTTPServer) handleAdminSelfTest(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	for _, value := range r.URL.Query()["tool"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	results := s.toolService.SelfTest(r.Context(), names)
+	counts := map[SelfTestStatus]int{SelfTestPassed: 0, SelfTestFailed: 0, SelfTestSkipped: 0}
+	for _, result := range results {
+		counts[result.Status]++
+	}
+	status := http.StatusOK
+	if counts[SelfTestFailed] > 0 {
+		status = http.StatusServiceUnavailable
+		loggerFor(r.Context(), s.logger).Warn("Self-test failed", "failed", counts[SelfTestFailed])
+	}
+	s.writeJSON(w, status, map[string]interface{}{
+		"passed":  counts[SelfTestPassed],
+		"failed":  counts[SelfTestFailed],
+		"skipped": counts[SelfTestSkipped],
+		"results": results,
+	})
+}
+
+// handleAdminSchedules handles GET /admin/schedules requests, listing the configured
+// schedules with their next and last runs.
+func (s *HTTPServer) handleAdminSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := s.toolService.Scheduler().List(r.Context())
+	if err != nil {
+		loggerFor(r.Context(), s.logger).Error("Failed to list schedules", "error", err)
+		s.writeError(w, r, http.StatusServiceUnavailable, errCodeStoreUnavailable, "Failed to load the schedules' state")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"schedules": schedules})
+}
+
+// handleAdminSetScheduleEnabled returns the handler for POST
+// /admin/schedules/{name}/enable or /disable requests.
+func (s *HTTPServer) handleAdminSetScheduleEnabled(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status, err := s.toolService.Scheduler().SetEnabled(r.Context(), r.PathValue("name"), enabled)
+		if errors.Is(err, ErrScheduleNotFound) {
+			s.writeError(w, r, http.StatusNotFound, errCodeNotFound, err.Error())
+			return
+		}
+		if err != nil {
+			loggerFor(r.Context(), s.logger).Error("Failed to update schedule", "error", err)
+			s.writeError(w, r, http.StatusServiceUnavailable, errCodeStoreUnavailable, "Failed to store the schedule's state")
+			return
+		}
+		s.writeJSON(w, http.StatusOK, status)
+	}
+}
+
+// handleAdminExecutions handles GET /admin/executions requests, listing recorded tool
+// executions newest first.
+func (s *HTTPServer) handleAdminExecutions(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"executions": s.toolService.History().List()})
+}
+
+// handleAdminReplayExecution handles POST /admin/executions/{id}/replay requests, which
+// call the tool again with the recorded arguments. The replay is itself recorded.
+func (s *HTTPServer) handleAdminReplayExecution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	execution, ok := s.toolService.History().Get(id)
+	if !ok {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Execution not found")
+		return
+	}
+	if execution.ArgumentsTruncated {
+		s.writeError(w, r, http.StatusConflict, errCodeNotReplayable, "Execution arguments exceeded the history size cap and were not recorded")
+		return
+	}
+
+	var args map[string]interface{}
+	if len(execution.Arguments) > 0 {
+		if err := json.Unmarshal(execution.Arguments, &args); err != nil {
+			s.writeError(w, r, http.StatusConflict, errCodeNotReplayable, "Recorded arguments could not be decoded")
+			return
+		}
+	}
+
+	loggerFor(r.Context(), s.logger).Info("Replaying tool execution", "id", id, "tool", execution.Tool)
+	result, err := s.toolService.ExecuteToolContext(withReplayOf(r.Context(), id), execution.Tool, args)
+	switch {
+	case errors.Is(err, ErrToolNotFound):
+		s.writeError(w, r, http.StatusNotFound, errCodeToolNotFound, err.Error())
+	case errors.Is(err, ErrToolDisabled), errors.Is(err, ErrCircuitOpen):
+		s.writeError(w, r, http.StatusServiceUnavailable, errCodeToolUnavailable, err.Error())
+	case errors.Is(err, ErrRateLimited):
+		s.writeError(w, r, http.StatusTooManyRequests, errCodeRateLimited, err.Error())
+	case errors.Is(err, tools.ErrQuotaExceeded):
+		s.writeError(w, r, http.StatusUnprocessableEntity, errCodeQuotaExceeded, err.Error())
+	case errors.Is(err, policy.ErrDenied):
+		s.writeError(w, r, http.StatusForbidden, errCodeForbidden, err.Error())
+	case errors.Is(err, tools.ErrInvalidArguments):
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidArguments, err.Error())
+	case errors.Is(err, tools.ErrInvalidResult):
+		s.writeError(w, r, http.StatusInternalServerError, errCodeInvalidResult, err.Error())
+	case err != nil:
+		s.writeError(w, r, http.StatusInternalServerError, errCodeToolFailed, err.Error())
+	default:
+		s.writeJSON(w, http.StatusOK, normalizeToolResult(result))
+	}
+}
+
+// handleAdminEvents handles GET /admin/events requests, streaming server events as
+// Server-Sent Events until the client disconnects. The optional "type" query parameter
+// (comma-separated or repeated) selects event types, and "tool" and "transport" keep
+// only events about that tool or transport. Every event carries an ID; a client that
+// reconnects with Last-Event-ID is first sent the matching events it missed.
+func (s *HTTPServer) handleAdminEvents(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); !ok {
+		s.writeError(w, r, http.StatusInternalServerError, errCodeNotImplemented, "Streaming is not supported")
+		return
+	}
+
+	query := r.URL.Query()
+	var types []string
+	for _, value := range query["type"] {
+		for _, eventType := range strings.Split(value, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				types = append(types, eventType)
+			}
+		}
+	}
+	tool, transport := query.Get("tool"), query.Get("transport")
+	filter := func(event SSEEvent) bool {
+		return (len(types) == 0 || slices.Contains(types, event.Type)) &&
+			(tool == "" || event.Attrs["tool"] == tool) &&
+			(transport == "" || event.Attrs["transport"] == transport)
+	}
+
+	// The stream outlives the server's write timeout, so lift the deadline for it.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	s.publishEvents.Do(s.startEventPublishing)
+	lastEventID := r.Header.Get("Last-Event-ID")
+	client, complete := s.events.Subscribe(lastEventID, filter)
+	defer s.events.RemoveClient(client.id)
+	if !complete {
+		loggerFor(r.Context(), s.logger).Info("Event stream resumed after events were dropped", "clientID", client.id, "lastEventID", lastEventID)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	_ = http.NewResponseController(w).Flush()
+	loggerFor(r.Context(), s.logger).Info("Event stream opened", "clientID", client.id, "types", types)
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	defer context.AfterFunc(s.streams, cancel)()
+	if err := s.events.Stream(ctx, w, client); err != nil {
+		loggerFor(r.Context(), s.logger).Info("Event stream write failed", "clientID", client.id, "error", err)
+		return
+	}
+	loggerFor(r.Context(), s.logger).Info("Event stream closed", "clientID", client.id)
+}
+
+// startEventPublishing publishes every server event to the admin event stream, once, so
+// events are kept for clients that reconnect. Publishing stops on shutdown.
+func (s *HTTPServer) startEventPublishing() {
+	unsubscribe := s.toolService.Events().Subscribe(func(event Event) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			s.logger.Warn("Failed to encode server event", "event", event.Type, "error", err)
+			return
+		}
+		tool, _ := event.Data["tool"].(string)
+		transport, _ := event.Data["transport"].(string)
+		s.events.Publish(SSEEvent{
+			Type:  string(event.Type),
+			Data:  data,
+			Attrs: map[string]string{"tool": tool, "transport": transport},
+		})
+	})
+	context.AfterFunc(s.streams, unsubscribe)
+}
+
+// handleAdminSessions handles GET /admin/sessions requests, listing the connected MCP
+// sessions: those of every replica when sessions are shared, else this instance's.
+func (s *HTTPServer) handleAdminSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := s.toolService.Sessions().Registered(r.Context())
+	if err != nil {
+		loggerFor(r.Context(), s.logger).Error("Failed to list sessions", "error", err)
+		s.writeError(w, r, http.StatusServiceUnavailable, errCodeStoreUnavailable, "Failed to list sessions")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// handleAdminNotify handles POST /admin/notifications requests, which push a
+// server-initiated notification to connected MCP sessions, including those of other
+// replicas when sessions are shared. The body
+// {"method": "notifications/...", "params": {...}, "transport": "websocket"} names the
+// notification; transport is optional and limits delivery to one transport.
+func (s *HTTPServer) handleAdminNotify(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Method    string          `json:"method"`
+		Params    json.RawMessage `json:"params"`
+		Transport string          `json:"transport"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if !strings.HasPrefix(body.Method, "notifications/") {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, `"method" must start with "notifications/"`)
+		return
+	}
+
+	var params json.RawMessage
+	if len(body.Params) > 0 && string(body.Params) != "null" {
+		params = body.Params
+	}
+	result, err := s.toolService.Sessions().BroadcastAll(r.Context(), body.Method, params, body.Transport)
+	if err != nil {
+		loggerFor(r.Context(), s.logger).Warn("Failed to publish notification to other replicas", "method", body.Method, "error", err)
+	}
+	loggerFor(r.Context(), s.logger).Info("Notification broadcast", "method", body.Method,
+		"transport", body.Transport, "delivered", result.Delivered, "failed", result.Failed, "published", result.Published)
+	s.writeJSON(w, http.StatusOK, result)
+}
+
+// defaultSessionRequestTimeout is how long POST /admin/sessions/{id}/messages waits for
+// the client's response to a request unless the body says otherwise.
+const defaultSessionRequestTimeout = 60 * time.Second
+
+// handleAdminSessionMessage handles POST /admin/sessions/{id}/messages requests, which
+// push a message into one of this instance's MCP sessions. The body
+// {"method": "...", "params": {...}} names it: a method starting with "notifications/",
+// such as notifications/tools/list_changed, is sent as a notification, and any other,
+// such as elicitation/create, as a request whose response from the client is returned.
+// "timeoutSeconds" bounds the wait for it.
+func (s *HTTPServer) handleAdminSessionMessage(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Method         string          `json:"method"`
+		Params         json.RawMessage `json:"params"`
+		TimeoutSeconds int             `json:"timeoutSeconds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if body.Method == "" || body.TimeoutSeconds < 0 {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, `"method" is required and "timeoutSeconds" must not be negative`)
+		return
+	}
+	var params interface{}
+	if len(body.Params) > 0 && string(body.Params) != "null" {
+		params = body.Params
+	}
+
+	id := r.PathValue("id")
+	sessions := s.toolService.Sessions()
+	logger := loggerFor(r.Context(), s.logger).With("sessionID", id, "method", body.Method)
+	if strings.HasPrefix(body.Method, "notifications/") {
+		if err := sessions.Send(id, body.Method, params); err != nil {
+			s.writeSessionMessageError(w, r, id, err)
+			return
+		}
+		logger.Info("Notification sent to session")
+		s.writeJSON(w, http.StatusOK, map[string]interface{}{"delivered": true})
+		return
+	}
+
+	timeout := defaultSessionRequestTimeout
+	if body.TimeoutSeconds > 0 {
+		timeout = time.Duration(body.TimeoutSeconds) * time.Second
+	}
+	// The wait may outlast the server's write timeout, so extend the deadline past it
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	response, err := sessions.Request(ctx, id, body.Method, params)
+	if errors.Is(err, context.DeadlineExceeded) {
+		logger.Info("Session did not answer a request in time", "timeout", timeout)
+		s.writeError(w, r, http.StatusGatewayTimeout, errCodeTimeout, fmt.Sprintf("The session did not answer within %s", timeout))
+		return
+	}
+	if err != nil {
+		s.writeSessionMessageError(w, r, id, err)
+		return
+	}
+	logger.Info("Request sent to session and answered")
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"delivered": true, "response": response})
+}
+
+// writeSessionMessageError writes the error response for a message that could not be
+// sent to session id.
+func (s *HTTPServer) writeSessionMessageError(w http.ResponseWriter, r *http.Request, id string, err error) {
+	if errors.Is(err, errSessionNotFound) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("Session not found on this instance: %s", id))
+		return
+	}
+	loggerFor(r.Context(), s.logger).Warn("Failed to send a message to a session", "sessionID", id, "error", err)
+	s.writeError(w, r, http.StatusBadGateway, errCodeDeliveryFailed, "Failed to deliver the message to the session")
+}
+
+// adminToolEntry describes a tool in the GET /admin/tools response.
+type adminToolEntry struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Healthy     bool        `json:"healthy"`
+	Health      *ToolHealth `json:"health,omitempty"`
+}
+
+// handleAdminTools handles GET /admin/tools requests, listing every tool with its health,
+// including tools hidden from clients because their health check fails. With ?check=true
+// the health checks run before the response is written.
+func (s *HTTPServer) handleAdminTools(w http.ResponseWriter, r *http.Request) {
+	monitor := s.toolService.Health()
+	if r.URL.Query().Get("check") == "true" {
+		monitor.CheckAll(r.Context())
+	}
+	checked := make(map[string]ToolHealth)
+	for _, health := range monitor.Statuses() {
+		checked[health.Tool] = health
+	}
+
+	entries := make([]adminToolEntry, 0)
+	for name, tool := range s.toolService.GetTools() {
+		entry := adminToolEntry{Name: name, Description: tool.Description(), Healthy: true}
+		if health, ok := checked[name]; ok {
+			entry.Healthy, entry.Health = health.Healthy, &health
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"tools": entries})
+}
+
+// handleAdminRegisterTool handles POST /admin/tools/register requests. The body is a
+// tools.Definition, and the tool is served immediately; live sessions are sent
+// notifications/tools/list_changed.
+func (s *HTTPServer) handleAdminRegisterTool(w http.ResponseWriter, r *http.Request) {
+	if len(s.registrable) == 0 {
+		s.writeError(w, r, http.StatusNotImplemented, errCodeNotImplemented, "Tool registration is not enabled")
+		return
+	}
+
+	var definition tools.Definition
+	if err := json.NewDecoder(r.Body).Decode(&definition); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if !s.registrable[definition.Type] {
+		s.writeError(w, r, http.StatusForbidden, errCodeForbidden, fmt.Sprintf("Registering %q tools is not enabled", definition.Type))
+		return
+	}
+	// Running programs needs more than a local connection
+	if definition.Type == tools.DefinitionExec && !s.AdminAuthenticated() {
+		s.writeError(w, r, http.StatusForbidden, errCodeForbidden, "Registering exec tools requires ADMIN_TOKEN")
+		return
+	}
+	tool, err := tools.NewDeclarativeTool(definition, s.logger)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	if err := s.toolService.AddTool(tool); err != nil {
+		s.writeError(w, r, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	s.writeJSON(w, http.StatusCreated, tool.Definition())
+}
+
+// handleAdminRemoveTool handles DELETE /admin/tools/{name} requests for tools added
+// through /admin/tools/register.
+func (s *HTTPServer) handleAdminRemoveTool(w http.ResponseWriter, r *http.Request) {
+	if !s.toolService.RemoveTool(r.PathValue("name")) {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "No registered tool with that name")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleAdminConfig handles GET /admin/config requests, listing every setting's
+// effective value and where it came from. Secrets are redacted.
+func (s *HTTPServer) handleAdminConfig(w http.ResponseWriter, r *http.Request) {
+	if s.config == nil {
+		s.writeError(w, r, http.StatusNotImplemented, errCodeNotImplemented, "Configuration reporting is not enabled")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"settings": s.config.Effective()})
+}
+
+// handleAdminLogLevel handles GET /admin/loglevel requests.
+func (s *HTTPServer) handleAdminLogLevel(w http.ResponseWriter, r *http.Request) {
+	if s.logLevel == nil {
+		s.writeError(w, r, http.StatusNotImplemented, errCodeNotImplemented, "Runtime log level changes are not enabled")
+		return
+	}
+	s.writeJSON(w, http.StatusOK, map[string]string{"level": s.logLevel.Level().String()})
+}
+
+// handleAdminSetLogLevel handles PUT /admin/loglevel requests. The body
+// {"level": "debug"} takes effect immediately for every transport.
+func (s *HTTPServer) handleAdminSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	if s.logLevel == nil {
+		s.writeError(w, r, http.StatusNotImplemented, errCodeNotImplemented, "Runtime log level changes are not enabled")
+		return
+	}
+
+	var body struct {
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Level == "" {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, `Body must be {"level": "debug|info|warn|error"}`)
+		return
+	}
+	level, err := logging.ParseLevel(body.Level)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
+		return
+	}
+	s.logLevel.Set(level)
+	s.logger.Warn("Log level changed", "level", level.String())
+	s.writeJSON(w, http.StatusOK, map[string]string{"level": s.logLevel.Level().String()})
+}
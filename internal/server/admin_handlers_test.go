@@ -0,0 +1,722 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/store"
+)
+
+func TestHTTPServer_AdminResources(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+
+	notifications := make(chan string, 10)
+	toolService.Sessions().Add("test", func(message []byte) error {
+		var notification map[string]interface{}
+		_ = json.Unmarshal(message, &notification)
+		notifications <- notification["method"].(string)
+		return nil
+	})
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/admin/resources", `{"uri":"docs://faq","text":"Q&A"}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if method := <-notifications; method != "notifications/resources/list_changed" {
+		t.Errorf("Unexpected notification: %s", method)
+	}
+
+	w := serve("GET", "/admin/resources", "")
+	var listed map[string][]Resource
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed["resources"]) != 1 || listed["resources"][0].URI != "docs://faq" {
+		t.Errorf("Unexpected resources: %v", listed)
+	}
+
+	if w := serve("POST", "/admin/resources", `{"text":"no uri"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/resources?uri=docs://faq", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/resources?uri=docs://faq", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPServer_AdminPrompts(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/admin/prompts", `{"name":"summarize","template":"Summarize {{text}}","arguments":[{"name":"text","required":true}]}`); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+	if _, ok := toolService.Catalog().Prompt("summarize"); !ok {
+		t.Error("Expected prompt to be registered")
+	}
+	if w := serve("PUT", "/admin/prompts", ""); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/prompts/summarize", ""); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/prompts/summarize", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", w.Code)
+	}
+}
+
+func TestHTTPServer_AdminQuarantine(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("upstream down")
+	}})
+	toolService.Quarantine().SetPolicy(QuarantinePolicy{FailureRate: 1, MinCalls: 1, Window: time.Minute, Cooldown: time.Minute})
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/api/tools/broken"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500 for the failing call, got %d", w.Code)
+	}
+	if w := serve("POST", "/api/tools/broken"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 for the quarantined tool, got %d", w.Code)
+	}
+
+	w := serve("GET", "/admin/quarantine")
+	var listed map[string][]QuarantineStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed["tools"]) != 1 || listed["tools"][0].Tool != "broken" {
+		t.Errorf("Expected broken to be quarantined, got %v", listed)
+	}
+
+	if w := serve("DELETE", "/admin/quarantine/broken"); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/quarantine/broken"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a tool that is not quarantined, got %d", w.Code)
+	}
+}
+
+func TestHTTPServer_AdminBreakers(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("upstream down")
+	}})
+	toolService.Breaker().SetPolicy(BreakerPolicy{Failures: 1, Cooldown: time.Minute})
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/api/tools/broken"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status 500 for the failing call, got %d", w.Code)
+	}
+	if w := serve("POST", "/api/tools/broken"); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status 503 while the circuit is open, got %d", w.Code)
+	}
+
+	w := serve("GET", "/admin/breakers")
+	var listed map[string][]BreakerStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if len(listed["tools"]) != 1 || listed["tools"][0].State != CircuitOpen || listed["tools"][0].OpenUntil == nil {
+		t.Errorf("Expected broken's circuit to be open, got %v", listed)
+	}
+
+	if w := serve("DELETE", "/admin/breakers/broken"); w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if w := serve("DELETE", "/admin/breakers/broken"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 for a tool without failures, got %d", w.Code)
+	}
+}
+
+// selfTestedMockTool is a MockTool that declares a self-test.
+type selfTestedMockTool struct {
+	MockTool
+}
+
+func (m *selfTestedMockTool) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"sample": true}
+}
+
+func TestHTTPServer_AdminSelfTest(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	var gotArgs map[string]interface{}
+	good := &selfTestedMockTool{MockTool{name: "good", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		gotArgs = args
+		return map[string]interface{}{"ok": true}, nil
+	}}}
+	bad := &selfTestedMockTool{MockTool{name: "bad", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("upstream down")
+	}}}
+	toolService := newTestToolService(logger, good, bad, &MockTool{name: "plain"})
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	selftest := func(target string) (int, map[string]interface{}, []SelfTestResult) {
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, newAdminRequest("POST", target, nil))
+		var body struct {
+			Passed  int              `json:"passed"`
+			Failed  int              `json:"failed"`
+			Skipped int              `json:"skipped"`
+			Results []SelfTestResult `json:"results"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		counts := map[string]interface{}{"passed": body.Passed, "failed": body.Failed, "skipped": body.Skipped}
+		return w.Code, counts, body.Results
+	}
+
+	t.Run("reports every tool and fails when one fails", func(t *testing.T) {
+		code, counts, results := selftest("/admin/selftest")
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503 with a failing tool, got %d", code)
+		}
+		if fmt.Sprint(counts) != "map[failed:1 passed:1 skipped:1]" {
+			t.Errorf("Expected one pass, fail, and skip, got %v", counts)
+		}
+		if len(results) != 3 || results[0].Tool != "bad" || results[0].Status != SelfTestFailed || results[0].Error != "upstream down" ||
+			results[1].Status != SelfTestPassed || results[2].Status != SelfTestSkipped {
+			t.Errorf("Expected results sorted by tool with their outcomes, got %+v", results)
+		}
+		if gotArgs["sample"] != true {
+			t.Errorf("Expected the tool to be called with its self-test arguments, got %v", gotArgs)
+		}
+	})
+
+	t.Run("selected tools", func(t *testing.T) {
+		code, counts, _ := selftest("/admin/selftest?tool=good,plain")
+		if code != http.StatusOK || fmt.Sprint(counts) != "map[failed:0 passed:1 skipped:1]" {
+			t.Errorf("Expected status 200 with good passing, got %d %v", code, counts)
+		}
+	})
+
+	t.Run("unknown tool fails", func(t *testing.T) {
+		code, _, results := selftest("/admin/selftest?tool=missing")
+		if code != http.StatusServiceUnavailable || len(results) != 1 || results[0].Status != SelfTestFailed {
+			t.Errorf("Expected an unknown tool to fail, got %d %+v", code, results)
+		}
+	})
+}
+
+func TestHTTPServer_AdminToolHealth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	db := &healthCheckedMockTool{MockTool: MockTool{name: "db_query"}, healthErr: errors.New("connection refused")}
+	toolService := newTestToolService(logger, db, &MockTool{name: "echo"})
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, nil)
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("GET", "/admin/tools?check=true")
+	var listed map[string][]adminToolEntry
+	if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	entries := listed["tools"]
+	if len(entries) != 2 || entries[0].Name != "db_query" || entries[0].Healthy || entries[0].Health == nil || !entries[1].Healthy {
+		t.Errorf("Expected db_query unhealthy and echo healthy, got %+v", entries)
+	}
+
+	w = serve("GET", "/readyz")
+	var ready map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if w.Code != http.StatusOK || fmt.Sprint(ready["unhealthyTools"]) != "[db_query]" {
+		t.Errorf("Expected ready with db_query listed as unhealthy, got %d %v", w.Code, ready)
+	}
+}
+
+func TestHTTPServer_AdminExecutions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	var calls []map[string]interface{}
+	toolService := newTestToolService(logger, &MockTool{name: "echo", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		calls = append(calls, args)
+		return map[string]interface{}{"text": args["text"]}, nil
+	}})
+	toolService.History().SetLimits(10, 64)
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := serve("POST", "/api/v1/tools/echo", `{"text":"hello"}`); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	listExecutions := func() []Execution {
+		var listed map[string][]Execution
+		if err := json.Unmarshal(serve("GET", "/admin/executions", "").Body.Bytes(), &listed); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		return listed["executions"]
+	}
+	executions := listExecutions()
+	if len(executions) != 1 || executions[0].Tool != "echo" {
+		t.Fatalf("Expected one recorded execution, got %+v", executions)
+	}
+	original := executions[0].ID
+
+	t.Run("replay repeats the call", func(t *testing.T) {
+		w := serve("POST", "/admin/executions/"+original+"/replay", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(calls) != 2 || calls[1]["text"] != "hello" {
+			t.Errorf("Expected the tool to be called again with the same arguments, got %v", calls)
+		}
+		if replay := listExecutions()[0]; replay.ReplayOf != original {
+			t.Errorf("Expected the replay to reference %s, got %+v", original, replay)
+		}
+	})
+
+	t.Run("unknown execution", func(t *testing.T) {
+		if w := serve("POST", "/admin/executions/missing/replay", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("truncated arguments cannot be replayed", func(t *testing.T) {
+		serve("POST", "/api/v1/tools/echo", `{"text":"`+strings.Repeat("a", 100)+`"}`)
+		truncated := listExecutions()[0]
+		w := serve("POST", "/admin/executions/"+truncated.ID+"/replay", "")
+		if w.Code != http.StatusConflict || !strings.Contains(w.Body.String(), errCodeNotReplayable) {
+			t.Errorf("Expected status 409 not_replayable, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+func TestHTTPServer_AdminEvents(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"}, &MockTool{name: "other"})
+	httpServer := NewHTTPServer(toolService, WithLogger(logger))
+	testServer := httptest.NewServer(httpServer.Handler())
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL + "/admin/events?type=tool.executed&tool=echo")
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Expected text/event-stream, got %q", ct)
+	}
+
+	// Only the echo call matches the filters.
+	toolService.Sessions().Add("test", func(message []byte) error { return nil })
+	_, _ = toolService.ExecuteTool("other", nil)
+	_, _ = toolService.ExecuteTool("echo", nil)
+
+	lines := readLines(resp)
+	expect := func(lines <-chan string, prefix string) string {
+		t.Helper()
+		select {
+		case line := <-lines:
+			if !strings.HasPrefix(line, prefix) {
+				t.Fatalf("Expected a line starting with %q, got %q", prefix, line)
+			}
+			return strings.TrimPrefix(line, prefix)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for %q", prefix)
+			return ""
+		}
+	}
+
+	eventID := expect(lines, "id: ")
+	if eventType := expect(lines, "event: "); eventType != string(EventToolExecuted) {
+		t.Errorf("Expected a tool.executed event, got %s", eventType)
+	}
+	var event Event
+	if err := json.Unmarshal([]byte(expect(lines, "data: ")), &event); err != nil {
+		t.Fatalf("Failed to unmarshal event: %v", err)
+	}
+	if event.Data["tool"] != "echo" {
+		t.Errorf("Expected the echo call, got %+v", event)
+	}
+
+	// A client reconnecting with the last ID it saw is sent the matching events it missed
+	resp.Body.Close()
+	_, _ = toolService.ExecuteToolContext(WithRequestID(context.Background(), "missed"), "echo", nil)
+	missedPublished := func() bool {
+		httpServer.events.mu.RLock()
+		defer httpServer.events.mu.RUnlock()
+		for _, published := range httpServer.events.history {
+			if bytes.Contains(published.Data, []byte(`"missed"`)) {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(2 * time.Second); !missedPublished(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the missed call to be published")
+		}
+	}
+	request, _ := http.NewRequest("GET", testServer.URL+"/admin/events?type=tool.executed&tool=echo", nil)
+	request.Header.Set("Last-Event-ID", eventID)
+	resumed, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Failed to reopen event stream: %v", err)
+	}
+	defer resumed.Body.Close()
+	replayed := readLines(resumed)
+	if replayedID := expect(replayed, "id: "); replayedID == eventID {
+		t.Errorf("Expected an event after %s, got it again", eventID)
+	}
+	expect(replayed, "event: ")
+	if data := expect(replayed, "data: "); !strings.Contains(data, "missed") {
+		t.Errorf("Expected the missed echo call, got %s", data)
+	}
+}
+
+// readLines returns the lines of resp's body as they arrive.
+func readLines(resp *http.Response) <-chan string {
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	return lines
+}
+
+func TestHTTPServer_AdminNotifications(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+
+	var received []map[string]interface{}
+	toolService.Sessions().Add("websocket", func(message []byte) error {
+		var notification map[string]interface{}
+		_ = json.Unmarshal(message, &notification)
+		received = append(received, notification)
+		return nil
+	})
+	toolService.Sessions().Add("streamable", func(message []byte) error { return nil })
+
+	serve := func(body string) *httptest.ResponseRecorder {
+		req := newAdminRequest("POST", "/admin/notifications", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(`{"method":"notifications/message","params":{"level":"info","data":"hello"},"transport":"websocket"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result BroadcastResult
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result.Delivered != 1 || result.Failed != 0 {
+		t.Errorf("Expected one delivery, got %+v (%v)", result, err)
+	}
+	if len(received) != 1 || received[0]["params"].(map[string]interface{})["data"] != "hello" {
+		t.Errorf("Unexpected notifications: %v", received)
+	}
+
+	if w := serve(`{"method":"tools/call"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-notification method, got %d", w.Code)
+	}
+}
+
+func TestHTTPServer_AdminSessionMessages(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+	processor := NewJSONRPCProcessor(toolService, httpServer.logger)
+
+	// The client answers every request it is sent the way its transport would, through
+	// the processor
+	sent := make(chan map[string]interface{}, 10)
+	var session *Session
+	session = toolService.Sessions().Add("websocket", func(message []byte) error {
+		var decoded map[string]interface{}
+		_ = json.Unmarshal(message, &decoded)
+		sent <- decoded
+		if decoded["id"] != nil && decoded["method"] != "roots/list" {
+			response := map[string]interface{}{"jsonrpc": "2.0", "id": decoded["id"], "result": map[string]interface{}{"action": "accept"}}
+			go processor.Process(WithSessionID(context.Background(), session.ID), response)
+		}
+		return nil
+	})
+	failing := toolService.Sessions().Add("websocket", func(message []byte) error { return errors.New("connection closed") })
+
+	serve := func(id, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest("POST", "/admin/sessions/"+id+"/messages", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("sends notifications", func(t *testing.T) {
+		w := serve(session.ID, `{"method":"notifications/tools/list_changed"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if message := <-sent; message["method"] != "notifications/tools/list_changed" || message["id"] != nil {
+			t.Errorf("Expected a notification, got %v", message)
+		}
+	})
+
+	t.Run("sends requests and returns the response", func(t *testing.T) {
+		w := serve(session.ID, `{"method":"elicitation/create","params":{"message":"Proceed?","requestedSchema":{"type":"object"}}}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		request := <-sent
+		if request["method"] != "elicitation/create" || request["params"].(map[string]interface{})["message"] != "Proceed?" {
+			t.Errorf("Unexpected request: %v", request)
+		}
+		var body struct {
+			Response map[string]interface{} `json:"response"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if body.Response["id"] != request["id"] || body.Response["result"].(map[string]interface{})["action"] != "accept" {
+			t.Errorf("Expected the client's response, got %v", body.Response)
+		}
+	})
+
+	t.Run("times out when the client does not answer", func(t *testing.T) {
+		w := serve(session.ID, `{"method":"roots/list","timeoutSeconds":1}`)
+		<-sent
+		if w.Code != http.StatusGatewayTimeout {
+			t.Errorf("Expected status 504, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("reports unknown sessions and failed deliveries", func(t *testing.T) {
+		if w := serve("missing", `{"method":"notifications/tools/list_changed"}`); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if w := serve(failing.ID, `{"method":"elicitation/create"}`); w.Code != http.StatusBadGateway {
+			t.Errorf("Expected status 502, got %d", w.Code)
+		}
+		if w := serve(session.ID, `{}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 without a method, got %d", w.Code)
+		}
+	})
+}
+
+func TestHTTPServer_AdminSessions(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+	session := toolService.Sessions().Add("websocket", func(message []byte) error { return nil })
+
+	req := newAdminRequest("GET", "/admin/sessions", nil)
+	w := httptest.NewRecorder()
+	httpServer.Handler().ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var body struct {
+		Sessions []store.SessionRecord `json:"sessions"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Sessions) != 1 || body.Sessions[0].ID != session.ID || body.Sessions[0].Transport != "websocket" || body.Sessions[0].Instance != "local" {
+		t.Errorf("Unexpected sessions: %+v", body.Sessions)
+	}
+}
+
+func TestHTTPServer_AdminRegisterTool(t *testing.T) {
+	httpServer, toolService := setupTestServer()
+
+	notifications := make(chan string, 10)
+	toolService.Sessions().Add("test", func(message []byte) error {
+		var notification map[string]interface{}
+		_ = json.Unmarshal(message, &notification)
+		notifications <- notification["method"].(string)
+		return nil
+	})
+
+	serve := func(method, target, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, target, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+	greeting := `{"type":"template","name":"greet","description":"Greets someone","template":"Hello, {{name}}!"}`
+
+	t.Run("not enabled by default", func(t *testing.T) {
+		if w := serve("POST", "/admin/tools/register", greeting); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+
+	httpServer.SetToolRegistration([]string{"template", "http"})
+
+	t.Run("registers a tool live", func(t *testing.T) {
+		if w := serve("POST", "/admin/tools/register", greeting); w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+		if method := <-notifications; method != "notifications/tools/list_changed" {
+			t.Errorf("Unexpected notification: %s", method)
+		}
+		result, err := toolService.ExecuteTool("greet", map[string]interface{}{"name": "ops"})
+		if err != nil || result["text"] != "Hello, ops!" {
+			t.Errorf("Unexpected result: %v (%v)", result, err)
+		}
+	})
+
+	t.Run("rejects duplicates, disabled types, and invalid definitions", func(t *testing.T) {
+		if w := serve("POST", "/admin/tools/register", greeting); w.Code != http.StatusConflict {
+			t.Errorf("Expected status 409, got %d", w.Code)
+		}
+		exec := `{"type":"exec","name":"ls","description":"Lists files","command":["ls"]}`
+		if w := serve("POST", "/admin/tools/register", exec); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+		if w := serve("POST", "/admin/tools/register", `{"type":"http","name":"api","description":"API"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("exec tools need an admin token", func(t *testing.T) {
+		exec := `{"type":"exec","name":"ls","description":"Lists files","command":["ls"]}`
+		httpServer.SetToolRegistration([]string{"exec"})
+		defer httpServer.SetToolRegistration([]string{"template", "http"})
+		if w := serve("POST", "/admin/tools/register", exec); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403 without an admin token, got %d", w.Code)
+		}
+		if _, ok := toolService.GetTools()["ls"]; ok {
+			t.Error("Expected the exec tool not to be registered")
+		}
+	})
+
+	t.Run("removes registered tools only", func(t *testing.T) {
+		if w := serve("DELETE", "/admin/tools/greet", ""); w.Code != http.StatusNoContent {
+			t.Errorf("Expected status 204, got %d", w.Code)
+		}
+		if method := <-notifications; method != "notifications/tools/list_changed" {
+			t.Errorf("Unexpected notification: %s", method)
+		}
+		if w := serve("DELETE", "/admin/tools/generate_uuid", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a built-in tool, got %d", w.Code)
+		}
+	})
+}
+
+func TestHTTPServer_AdminLogLevel(t *testing.T) {
+	httpServer, _ := setupTestServer()
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		req := newAdminRequest(method, "/admin/loglevel", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("not enabled without a level var", func(t *testing.T) {
+		if w := serve("GET", ""); w.Code != http.StatusNotImplemented {
+			t.Errorf("Expected status 501, got %d", w.Code)
+		}
+	})
+
+	levelVar := new(slog.LevelVar)
+	httpServer.SetLogLevel(levelVar)
+
+	t.Run("PUT changes the level", func(t *testing.T) {
+		w := serve("PUT", `{"level":"debug"}`)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if levelVar.Level() != slog.LevelDebug {
+			t.Errorf("Expected level debug, got %v", levelVar.Level())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["level"] != "DEBUG" {
+			t.Errorf("Unexpected response: %s", w.Body.String())
+		}
+	})
+
+	t.Run("PUT rejects unknown levels", func(t *testing.T) {
+		if w := serve("PUT", `{"level":"loud"}`); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+		if levelVar.Level() != slog.LevelDebug {
+			t.Errorf("Expected level to stay debug, got %v", levelVar.Level())
+		}
+	})
+}
+
+func TestHTTPServer_AdminConfig(t *testing.T) {
+	httpServer, _ := setupTestServer()
+	t.Setenv("HTTP_PORT", "9090")
+	t.Setenv("WEBHOOK_SECRET", "s3cret")
+	cfg := config.NewServerConfig()
+	cfg.SetSource("ALLOWED_ORIGINS", config.SourceFlag)
+	httpServer.SetConfig(cfg)
+
+	req := newAdminRequest("GET", "/admin/config", nil)
+	w := httptest.NewRecorder()
+	httpServer.server.Handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var body struct {
+		Settings []config.Setting `json:"settings"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	settings := make(map[string]config.Setting, len(body.Settings))
+	for _, setting := range body.Settings {
+		settings[setting.Name] = setting
+	}
+	if got := settings["HTTP_PORT"]; got.Value != float64(9090) || got.Source != config.SourceEnv {
+		t.Errorf("Unexpected HTTP_PORT: %+v", got)
+	}
+	if got := settings["ALLOWED_ORIGINS"]; got.Source != config.SourceFlag {
+		t.Errorf("Unexpected ALLOWED_ORIGINS: %+v", got)
+	}
+	if strings.Contains(w.Body.String(), "s3cret") {
+		t.Errorf("Expected the webhook secret to be redacted: %s", w.Body.String())
+	}
+}
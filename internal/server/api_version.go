@@ -0,0 +1,59 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// apiVersion is the current REST API version. Routes are served under /api/<version>/.
+const apiVersion = "v1"
+
+// apiVersionHeader lets clients request a REST API version. Responses always carry it
+// with the version that served the request.
+const apiVersionHeader = "API-Version"
+
+// supportedAPIVersions lists the versions a client may request in apiVersionHeader.
+var supportedAPIVersions = []string{apiVersion}
+
+// handleAPI registers handler at /api/<version><path> and at the unversioned /api<path>
+// alias. The alias is deprecated: its responses carry a Deprecation header and a Link to
+// the versioned route.
+func (s *HTTPServer) handleAPI(mux *http.ServeMux, method, path string, handler http.Handler) {
+	mux.Handle(method+" /api/"+apiVersion+path, s.versioned(handler))
+	mux.Handle(method+" /api"+path, s.deprecatedAlias(s.versioned(handler)))
+}
+
+// versioned rejects requests for an unsupported API version and sets the version header
+// on the response.
+func (s *HTTPServer) versioned(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requested := r.Header.Get(apiVersionHeader); requested != "" && !isSupportedAPIVersion(requested) {
+			s.writeError(w, r, http.StatusBadRequest, errCodeUnsupportedVersion,
+				fmt.Sprintf("Unsupported API version %q; supported versions: %s", requested, strings.Join(supportedAPIVersions, ", ")))
+			return
+		}
+		w.Header().Set(apiVersionHeader, apiVersion)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// deprecatedAlias marks responses from an unversioned /api/ route as deprecated.
+func (s *HTTPServer) deprecatedAlias(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		successor := "/api/" + apiVersion + strings.TrimPrefix(r.URL.Path, "/api")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isSupportedAPIVersion reports whether version is one of supportedAPIVersions.
+func isSupportedAPIVersion(version string) bool {
+	for _, supported := range supportedAPIVersions {
+		if strings.EqualFold(version, supported) {
+			return true
+		}
+	}
+	return false
+}
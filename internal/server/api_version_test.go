@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPServer_APIVersioning(t *testing.T) {
+	httpServer, _ := setupTestServer()
+
+	serve := func(method, target, version string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if version != "" {
+			req.Header.Set(apiVersionHeader, version)
+		}
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("versioned routes are served", func(t *testing.T) {
+		for _, route := range []struct{ method, path string }{
+			{"GET", "/api/v1/uuid"},
+			{"GET", "/api/v1/list"},
+			{"POST", "/api/v1/tools/generate_uuid"},
+			{"GET", "/api/v1/metrics"},
+		} {
+			w := serve(route.method, route.path, "")
+			if w.Code != http.StatusOK {
+				t.Errorf("%s %s: expected status 200, got %d", route.method, route.path, w.Code)
+			}
+			if got := w.Header().Get(apiVersionHeader); got != "v1" {
+				t.Errorf("%s %s: expected %s v1, got %q", route.method, route.path, apiVersionHeader, got)
+			}
+			if w.Header().Get("Deprecation") != "" {
+				t.Errorf("%s %s: versioned route should not be deprecated", route.method, route.path)
+			}
+		}
+	})
+
+	t.Run("unversioned aliases are deprecated", func(t *testing.T) {
+		w := serve("POST", "/api/tools/generate_uuid", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if w.Header().Get("Deprecation") != "true" {
+			t.Error("Expected Deprecation header on alias")
+		}
+		if link := w.Header().Get("Link"); link != `</api/v1/tools/generate_uuid>; rel="successor-version"` {
+			t.Errorf("Unexpected Link header: %s", link)
+		}
+	})
+
+	t.Run("supported version header is accepted", func(t *testing.T) {
+		if w := serve("GET", "/api/v1/uuid", "v1"); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("unsupported version header is rejected", func(t *testing.T) {
+		w := serve("GET", "/api/v1/uuid", "v9")
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("Expected status 400, got %d", w.Code)
+		}
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error.Code != errCodeUnsupportedVersion {
+			t.Errorf("Expected unsupported_version error, got %s", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"mcp-tools-server/internal/webhook"
+)
+
+// Headers of an approval callback besides its signature.
+const (
+	approvalTimestampHeader = "X-Approval-Timestamp" // Unix seconds when the callback was signed
+	approvalNonceHeader     = "X-Approval-Nonce"     // Value unique to the callback
+)
+
+// approvalCallbackWindow is how far an approval callback's timestamp may be from the
+// server's clock. Nonces are remembered for twice as long, so a callback cannot be
+// replayed while its timestamp is accepted.
+const approvalCallbackWindow = 5 * time.Minute
+
+// approvalDecision is the body of POST /admin/approvals/{id} and POST /approvals/{id}.
+type approvalDecision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason"` // Error of a denied call; defaults to "denied by operator"
+}
+
+// handleAdminApprovals handles GET /admin/approvals requests, listing the tool calls
+// waiting for approval with their arguments.
+func (s *HTTPServer) handleAdminApprovals(w http.ResponseWriter, r *http.Request) {
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"approvals": s.toolService.Approvals().Pending()})
+}
+
+// handleAdminDecideApproval handles POST /admin/approvals/{id} requests. The body
+// {"approved": true} runs the held call; {"approved": false, "reason": "..."} denies it.
+func (s *HTTPServer) handleAdminDecideApproval(w http.ResponseWriter, r *http.Request) {
+	var decision approvalDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	s.decideApproval(w, r, decision, "admin")
+}
+
+// handleApprovalCallback handles POST /approvals/{id} requests from a webhook receiver
+// answering an approval.requested event. The body is the same as for
+// POST /admin/approvals/{id}. The X-Webhook-Signature header holds "sha256=" and the hex
+// HMAC-SHA256, keyed with the webhook secret, of the job ID, the X-Approval-Timestamp
+// and X-Approval-Nonce headers, and the body, each followed by a newline but the body.
+// Covering the job ID keeps a signed decision from being applied to another job, and
+// the timestamp and single-use nonce keep it from being replayed.
+func (s *HTTPServer) handleApprovalCallback(w http.ResponseWriter, r *http.Request) {
+	if s.approvalKey == "" {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, "Approval callbacks are disabled")
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to read the request body")
+		return
+	}
+	id := r.PathValue("id")
+	timestamp, nonce := r.Header.Get(approvalTimestampHeader), r.Header.Get(approvalNonceHeader)
+	signature := r.Header.Get(webhook.SignatureHeader)
+	if timestamp == "" || nonce == "" || !hmac.Equal([]byte(signature), []byte(approvalSignature(s.approvalKey, id, timestamp, nonce, body))) {
+		loggerFor(r.Context(), s.logger).Warn("Rejected an approval callback with a bad signature", "jobId", id)
+		s.writeError(w, r, http.StatusUnauthorized, errCodeUnauthorized, "Invalid or missing "+webhook.SignatureHeader)
+		return
+	}
+	signed, err := strconv.ParseInt(timestamp, 10, 64)
+	if age := time.Since(time.Unix(signed, 0)); err != nil || age > approvalCallbackWindow || age < -approvalCallbackWindow {
+		loggerFor(r.Context(), s.logger).Warn("Rejected a stale approval callback", "jobId", id, "timestamp", timestamp)
+		s.writeError(w, r, http.StatusUnauthorized, errCodeUnauthorized, approvalTimestampHeader+" is not within "+approvalCallbackWindow.String()+" of the server's time")
+		return
+	}
+	fresh, err := s.toolService.Coordinator().RunOnce(r.Context(), "approval-nonce:"+nonce, 2*approvalCallbackWindow)
+	if err != nil {
+		s.writeError(w, r, http.StatusServiceUnavailable, errCodeStoreUnavailable, "Failed to check the approval callback's nonce")
+		return
+	}
+	if !fresh {
+		loggerFor(r.Context(), s.logger).Warn("Rejected a replayed approval callback", "jobId", id)
+		s.writeError(w, r, http.StatusUnauthorized, errCodeUnauthorized, approvalNonceHeader+" has already been used")
+		return
+	}
+	var decision approvalDecision
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&decision); err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	s.decideApproval(w, r, decision, "webhook")
+}
+
+// approvalSignature returns the X-Webhook-Signature value of an approval callback.
+func approvalSignature(secret, id, timestamp, nonce string, body []byte) string {
+	message := make([]byte, 0, len(id)+len(timestamp)+len(nonce)+len(body)+3)
+	for _, field := range []string{id, timestamp, nonce} {
+		message = append(append(message, field...), '\n')
+	}
+	return webhook.Sign(secret, append(message, body...))
+}
+
+// decideApproval applies an operator's decision on the held call named by the request's
+// path and writes the response.
+func (s *HTTPServer) decideApproval(w http.ResponseWriter, r *http.Request, decision approvalDecision, via string) {
+	id := r.PathValue("id")
+	err := s.toolService.Approvals().Decide(id, decision.Approved, decision.Reason)
+	switch {
+	case errors.Is(err, errApprovalNotFound):
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("No call is waiting for approval as job %s", id))
+		return
+	case errors.Is(err, ErrTooManyJobs):
+		s.writeError(w, r, http.StatusTooManyRequests, errCodeRateLimited, err.Error())
+		return
+	case err != nil:
+		s.writeError(w, r, http.StatusConflict, errCodeConflict, err.Error())
+		return
+	}
+	loggerFor(r.Context(), s.logger).Info("Approval decided", "jobId", id, "approved", decision.Approved, "via", via)
+	s.writeJSON(w, http.StatusOK, map[string]interface{}{"jobId": id, "approved": decision.Approved})
+}
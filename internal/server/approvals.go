@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// errApprovalNotFound is returned when deciding on a call that is not waiting for approval.
+var errApprovalNotFound = errors.New("approval not found")
+
+// ErrTooManyApprovals is returned for a call that needs approval while the maximum number
+// are already waiting.
+var ErrTooManyApprovals = errors.New("too many calls waiting for approval")
+
+// ErrApprovalDenied is returned by ToolService.ExecuteToolAwaitingApproval for a held call
+// that was denied, or left undecided past the approval timeout.
+var ErrApprovalDenied = errors.New("approval denied")
+
+// approvalResultMethod is the notification that delivers an approved or denied call's
+// outcome to the session that made it.
+const approvalResultMethod = "notifications/approvals/result"
+
+// Default approval limits.
+const (
+	defaultMaxPendingApprovals = 100
+	defaultApprovalTimeout     = time.Hour
+)
+
+// PendingApproval is a tool call waiting for an operator to approve or deny it.
+type PendingApproval struct {
+	JobID       string                 `json:"jobId"`
+	Tool        string                 `json:"tool"`
+	Arguments   map[string]interface{} `json:"arguments,omitempty"`
+	Tenant      string                 `json:"tenant,omitempty"`
+	SessionID   string                 `json:"sessionId,omitempty"`
+	RequestedAt time.Time              `json:"requestedAt"`
+	ExpiresAt   *time.Time             `json:"expiresAt,omitempty"`
+
+	ctx   context.Context // Context of the call, kept for its values
+	next  ToolHandler     // Rest of the middleware chain, which runs the call once approved
+	timer *time.Timer     // Denies the call when the approval timeout passes
+}
+
+// ApprovalGate holds calls to sensitive tools until an operator approves them. A held
+// call becomes a job in JobPendingApproval and its caller is answered at once with the
+// job's ID. An approved call runs as the job; a denied one, or one left undecided past the
+// timeout, finishes as JobDenied. Either way the outcome is sent to the calling session as
+// a notifications/approvals/result notification, and can be fetched as the job.
+type ApprovalGate struct {
+	mu         sync.Mutex
+	tools      map[string]bool // Tools configured to need approval, besides those marked
+	lookup     func(name string) (tools.Tool, bool)
+	pending    map[string]*PendingApproval
+	maxPending int
+	timeout    time.Duration
+	jobs       *JobManager
+	sessions   *SessionManager
+	events     *EventBus
+	logger     *slog.Logger
+}
+
+// NewApprovalGate creates an ApprovalGate that holds calls to tools marked with
+// tools.ApprovalRequirer, with the default limits.
+func NewApprovalGate(lookup func(name string) (tools.Tool, bool), jobs *JobManager, sessions *SessionManager, logger *slog.Logger) *ApprovalGate {
+	return &ApprovalGate{
+		tools:      make(map[string]bool),
+		lookup:     lookup,
+		pending:    make(map[string]*PendingApproval),
+		maxPending: defaultMaxPendingApprovals,
+		timeout:    defaultApprovalTimeout,
+		jobs:       jobs,
+		sessions:   sessions,
+		logger:     logger,
+	}
+}
+
+// SetTools makes calls to the named tools need approval too, whether or not they are
+// marked.
+func (g *ApprovalGate) SetTools(names []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tools = make(map[string]bool, len(names))
+	for _, name := range names {
+		g.tools[name] = true
+	}
+}
+
+// SetTimeout sets how long a call waits for a decision before it is denied. Zero waits
+// indefinitely. It applies to calls held afterwards.
+func (g *ApprovalGate) SetTimeout(timeout time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.timeout = timeout
+}
+
+// Requires reports whether calls to the named tool need approval.
+func (g *ApprovalGate) Requires(name string) bool {
+	g.mu.Lock()
+	configured := g.tools[name]
+	g.mu.Unlock()
+	if configured {
+		return true
+	}
+	tool, ok := g.lookup(name)
+	if !ok {
+		return false
+	}
+	requirer, ok := tool.(tools.ApprovalRequirer)
+	return ok && requirer.RequiresApproval()
+}
+
+// Middleware returns the ToolMiddleware that holds calls needing approval. A held call
+// returns a result with its job ID and the pending_approval status.
+func (g *ApprovalGate) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			if !g.Requires(name) {
+				return next(ctx, name, args)
+			}
+			approval, err := g.hold(ctx, name, args, next)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"jobId":   approval.JobID,
+				"status":  string(JobPendingApproval),
+				"message": fmt.Sprintf("The call to %s is waiting for operator approval; its result will follow when it is decided", name),
+			}, nil
+		}
+	}
+}
+
+// heldJobID returns the ID of the held job when result is Middleware's answer to a call
+// it held, which stands in for the call's result until the call is decided.
+func heldJobID(result map[string]interface{}) (string, bool) {
+	if status, _ := result["status"].(string); status != string(JobPendingApproval) {
+		return "", false
+	}
+	id, ok := result["jobId"].(string)
+	return id, ok && id != ""
+}
+
+// hold parks a call as a held job and announces it with an EventApprovalRequested event.
+func (g *ApprovalGate) hold(ctx context.Context, name string, args map[string]interface{}, next ToolHandler) (*PendingApproval, error) {
+	g.mu.Lock()
+	if len(g.pending) >= g.maxPending {
+		g.mu.Unlock()
+		return nil, ErrTooManyApprovals
+	}
+	job := g.jobs.Hold(ctx, name)
+	approval := &PendingApproval{
+		JobID:       job.ID,
+		Tool:        name,
+		Arguments:   args,
+		Tenant:      tenantName(ctx),
+		SessionID:   SessionIDFromContext(ctx),
+		RequestedAt: job.CreatedAt,
+		ctx:         context.WithoutCancel(ctx),
+		next:        next,
+	}
+	if g.timeout > 0 {
+		expires := job.CreatedAt.Add(g.timeout)
+		approval.ExpiresAt = &expires
+		approval.timer = time.AfterFunc(g.timeout, func() {
+			_ = g.Decide(job.ID, false, "approval timed out")
+		})
+	}
+	g.pending[job.ID] = approval
+	g.mu.Unlock()
+
+	loggerFor(ctx, g.logger).Info("Tool call waiting for approval", "jobId", job.ID, "tool", name)
+	data := map[string]interface{}{"jobId": job.ID, "tool": name}
+	if approval.Tenant != "" {
+		data["tenant"] = approval.Tenant
+	}
+	if approval.SessionID != "" {
+		data["sessionId"] = approval.SessionID
+	}
+	if approval.ExpiresAt != nil {
+		data["expiresAt"] = approval.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	g.events.Publish(EventApprovalRequested, data)
+	return approval, nil
+}
+
+// Pending returns the calls waiting for approval, oldest first.
+func (g *ApprovalGate) Pending() []PendingApproval {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	pending := make([]PendingApproval, 0, len(g.pending))
+	for _, approval := range g.pending {
+		pending = append(pending, *approval)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].RequestedAt.Before(pending[j].RequestedAt) })
+	return pending
+}
+
+// Decide approves or denies the held call with the given job ID. An approved call starts
+// running; a denied one finishes with reason as its error. The outcome is delivered to
+// the calling session once the job finishes.
+func (g *ApprovalGate) Decide(id string, approved bool, reason string) error {
+	g.mu.Lock()
+	approval, ok := g.pending[id]
+	if !ok {
+		g.mu.Unlock()
+		return errApprovalNotFound
+	}
+	if approved {
+		// Released before it leaves pending, so a call the job limit refuses can be
+		// approved again later
+		if err := g.jobs.Release(approval.ctx, id, approval.Arguments, approval.next); err != nil {
+			g.mu.Unlock()
+			return err
+		}
+	} else {
+		if reason == "" {
+			reason = "denied by operator"
+		}
+		if err := g.jobs.Deny(id, reason); err != nil {
+			g.mu.Unlock()
+			return err
+		}
+	}
+	delete(g.pending, id)
+	if approval.timer != nil {
+		approval.timer.Stop()
+	}
+	g.mu.Unlock()
+
+	loggerFor(approval.ctx, g.logger).Info("Tool call approval decided", "jobId", id, "tool", approval.Tool, "approved", approved)
+	go g.deliver(approval)
+	return nil
+}
+
+// deliver waits for a decided call's job to finish and sends its outcome to the calling
+// session, if the call came from one.
+func (g *ApprovalGate) deliver(approval *PendingApproval) {
+	if approval.SessionID == "" {
+		return
+	}
+	_, done, ok := g.jobs.Get(approval.ctx, approval.JobID)
+	if !ok {
+		return
+	}
+	<-done
+	job, _, ok := g.jobs.Get(approval.ctx, approval.JobID)
+	if !ok {
+		return
+	}
+	if err := g.sessions.Send(approval.SessionID, approvalResultMethod, job); err != nil {
+		g.logger.Warn("Failed to deliver an approved call's outcome", "jobId", job.ID, "sessionID", approval.SessionID, "error", err)
+	}
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/webhook"
+)
+
+// sensitiveTool is a MockTool marked as needing approval.
+type sensitiveTool struct{ MockTool }
+
+func (t *sensitiveTool) RequiresApproval() bool { return true }
+
+func TestApprovalGate(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	newService := func() (*ToolService, *int) {
+		calls := new(int)
+		service := newTestToolService(logger,
+			&sensitiveTool{MockTool{name: "deploy", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				*calls++
+				return map[string]interface{}{"deployed": args["service"]}, nil
+			}}},
+			&MockTool{name: "echo"},
+		)
+		return service, calls
+	}
+	waitForJob := func(t *testing.T, service *ToolService, id string) Job {
+		t.Helper()
+		_, done, ok := service.Jobs().Get(context.Background(), id)
+		if !ok {
+			t.Fatalf("Expected job %s to exist", id)
+		}
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Expected job %s to finish", id)
+		}
+		job, _, _ := service.Jobs().Get(context.Background(), id)
+		return job
+	}
+
+	t.Run("holds marked tools until approved", func(t *testing.T) {
+		service, calls := newService()
+		delivered := make(chan map[string]interface{}, 1)
+		session := service.Sessions().Add("websocket", func(message []byte) error {
+			var decoded map[string]interface{}
+			_ = json.Unmarshal(message, &decoded)
+			delivered <- decoded
+			return nil
+		})
+		requested := make(chan Event, 1)
+		defer service.Events().Subscribe(func(event Event) { requested <- event }, EventApprovalRequested)()
+
+		ctx := WithSessionID(context.Background(), session.ID)
+		result, err := service.ExecuteToolContext(ctx, "deploy", map[string]interface{}{"service": "api"})
+		if err != nil {
+			t.Fatalf("Expected the call to be held, got %v", err)
+		}
+		id, _ := result["jobId"].(string)
+		if result["status"] != string(JobPendingApproval) || id == "" || *calls != 0 {
+			t.Fatalf("Expected a pending result without running the tool, got %v", result)
+		}
+		select {
+		case event := <-requested:
+			if event.Data["jobId"] != id || event.Data["sessionId"] != session.ID {
+				t.Errorf("Unexpected event data: %v", event.Data)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected an approval.requested event")
+		}
+		pending := service.Approvals().Pending()
+		if len(pending) != 1 || pending[0].JobID != id || pending[0].Arguments["service"] != "api" {
+			t.Fatalf("Expected the call to be pending with its arguments, got %+v", pending)
+		}
+
+		if err := service.Approvals().Decide(id, true, ""); err != nil {
+			t.Fatalf("Decide failed: %v", err)
+		}
+		if job := waitForJob(t, service, id); job.Status != JobSucceeded || job.Result["deployed"] != "api" {
+			t.Errorf("Expected the approved call to succeed, got %+v", job)
+		}
+		select {
+		case message := <-delivered:
+			params, _ := message["params"].(map[string]interface{})
+			if message["method"] != approvalResultMethod || params["id"] != id || params["status"] != string(JobSucceeded) {
+				t.Errorf("Unexpected notification: %v", message)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected the outcome to be sent to the session")
+		}
+		if len(service.Approvals().Pending()) != 0 {
+			t.Error("Expected no pending approvals after the decision")
+		}
+	})
+
+	t.Run("denies calls", func(t *testing.T) {
+		service, calls := newService()
+		result, _ := service.ExecuteTool("deploy", nil)
+		id := result["jobId"].(string)
+		if err := service.Approvals().Decide(id, false, "not during the freeze"); err != nil {
+			t.Fatalf("Decide failed: %v", err)
+		}
+		if job := waitForJob(t, service, id); job.Status != JobDenied || job.Error != "not during the freeze" || *calls != 0 {
+			t.Errorf("Expected a denied job that never ran, got %+v", job)
+		}
+		if err := service.Approvals().Decide(id, true, ""); !errors.Is(err, errApprovalNotFound) {
+			t.Errorf("Expected errApprovalNotFound deciding twice, got %v", err)
+		}
+	})
+
+	t.Run("configured tools need approval", func(t *testing.T) {
+		service, _ := newService()
+		service.Approvals().SetTools([]string{"echo"})
+		result, _ := service.ExecuteTool("echo", nil)
+		if result["status"] != string(JobPendingApproval) {
+			t.Errorf("Expected echo to be held, got %v", result)
+		}
+	})
+
+	t.Run("other tools run at once", func(t *testing.T) {
+		service, _ := newService()
+		result, _ := service.ExecuteTool("echo", nil)
+		if result["success"] != true {
+			t.Errorf("Expected echo to run, got %v", result)
+		}
+	})
+
+	t.Run("callers that use the result wait for the decision", func(t *testing.T) {
+		service, _ := newService()
+		type outcome struct {
+			result map[string]interface{}
+			err    error
+		}
+		call := func() <-chan outcome {
+			outcomes := make(chan outcome, 1)
+			go func() {
+				result, err := service.ExecuteToolAwaitingApproval(context.Background(), "deploy", map[string]interface{}{"service": "api"})
+				outcomes <- outcome{result, err}
+			}()
+			deadline := time.Now().Add(time.Second)
+			for len(service.Approvals().Pending()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			return outcomes
+		}
+
+		outcomes := call()
+		if err := service.Approvals().Decide(service.Approvals().Pending()[0].JobID, true, ""); err != nil {
+			t.Fatalf("Decide failed: %v", err)
+		}
+		if got := <-outcomes; got.err != nil || got.result["deployed"] != "api" {
+			t.Errorf("Expected the approved call's result, got %v, %v", got.result, got.err)
+		}
+
+		outcomes = call()
+		if err := service.Approvals().Decide(service.Approvals().Pending()[0].JobID, false, "not today"); err != nil {
+			t.Fatalf("Decide failed: %v", err)
+		}
+		if got := <-outcomes; !errors.Is(got.err, ErrApprovalDenied) || !strings.Contains(got.err.Error(), "not today") {
+			t.Errorf("Expected ErrApprovalDenied with the reason, got %v, %v", got.result, got.err)
+		}
+	})
+
+	t.Run("REST calls answer held calls with the job", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "generate_uuid"}, &MockTool{name: "echo"})
+		service.Approvals().SetTools([]string{"generate_uuid", "echo"})
+		httpServer := NewHTTPServer(service, WithLogger(logger))
+		for _, req := range []*http.Request{
+			httptest.NewRequest("GET", "/api/v1/uuid", nil),
+			httptest.NewRequest("POST", "/api/v1/tools/echo", nil),
+			httptest.NewRequest("POST", "/api/v1/jobs", strings.NewReader(`{"tool": "echo"}`)),
+		} {
+			w := httptest.NewRecorder()
+			httpServer.Handler().ServeHTTP(w, req)
+			var job Job
+			if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+				t.Fatalf("%s %s: failed to decode the job: %v", req.Method, req.URL.Path, err)
+			}
+			if w.Code != http.StatusAccepted || job.Status != JobPendingApproval || w.Header().Get("Location") != "/api/v1/jobs/"+job.ID {
+				t.Errorf("%s %s: expected 202 with the held job, got %d: %s", req.Method, req.URL.Path, w.Code, w.Body.String())
+			}
+		}
+		if pending := service.Approvals().Pending(); len(pending) != 3 {
+			t.Errorf("Expected each call to be held once, got %d held", len(pending))
+		}
+	})
+
+	t.Run("undecided calls time out", func(t *testing.T) {
+		service, _ := newService()
+		service.Approvals().SetTimeout(10 * time.Millisecond)
+		result, _ := service.ExecuteTool("deploy", nil)
+		if job := waitForJob(t, service, result["jobId"].(string)); job.Status != JobDenied || job.Error != "approval timed out" {
+			t.Errorf("Expected the call to be denied on timeout, got %+v", job)
+		}
+	})
+
+	t.Run("limits pending calls", func(t *testing.T) {
+		service, _ := newService()
+		service.Approvals().maxPending = 1
+		if _, err := service.ExecuteTool("deploy", nil); err != nil {
+			t.Fatalf("Expected the first call to be held, got %v", err)
+		}
+		if _, err := service.ExecuteTool("deploy", nil); !errors.Is(err, ErrTooManyApprovals) {
+			t.Errorf("Expected ErrTooManyApprovals, got %v", err)
+		}
+	})
+}
+
+func TestHTTPServer_Approvals(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &sensitiveTool{MockTool{name: "deploy"}})
+	httpServer := NewHTTPServer(toolService, WithLogger(logger))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w
+	}
+	hold := func(t *testing.T) string {
+		t.Helper()
+		result, err := toolService.ExecuteTool("deploy", nil)
+		if err != nil {
+			t.Fatalf("Expected the call to be held, got %v", err)
+		}
+		return result["jobId"].(string)
+	}
+
+	t.Run("lists and decides through the admin API", func(t *testing.T) {
+		id := hold(t)
+		w := serve(newAdminRequest("GET", "/admin/approvals", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), id) {
+			t.Fatalf("Expected the pending call to be listed, got %d: %s", w.Code, w.Body.String())
+		}
+		w = serve(httptest.NewRequest("POST", "/admin/approvals/"+id, strings.NewReader(`{"approved":true}`)))
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("Expected status 403 for an unauthenticated decision, got %d", w.Code)
+		}
+		w = serve(newAdminRequest("POST", "/admin/approvals/"+id, strings.NewReader(`{"approved":false,"reason":"no"}`)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if job, _, _ := toolService.Jobs().Get(context.Background(), id); job.Status != JobDenied {
+			t.Errorf("Expected the job to be denied, got %+v", job)
+		}
+		w = serve(newAdminRequest("POST", "/admin/approvals/"+id, strings.NewReader(`{"approved":true}`)))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404 for a decided call, got %d", w.Code)
+		}
+	})
+
+	t.Run("callbacks are disabled without a secret", func(t *testing.T) {
+		w := serve(httptest.NewRequest("POST", "/approvals/"+hold(t), strings.NewReader(`{"approved":true}`)))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("callbacks must be signed", func(t *testing.T) {
+		httpServer.SetApprovalCallbackSecret("s3cret")
+		defer httpServer.SetApprovalCallbackSecret("")
+		id := hold(t)
+		body := `{"approved":true}`
+		callback := func(secret, id, signedID string, signedAt time.Time, nonce string) int {
+			timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+			req := httptest.NewRequest("POST", "/approvals/"+id, strings.NewReader(body))
+			req.Header.Set(approvalTimestampHeader, timestamp)
+			req.Header.Set(approvalNonceHeader, nonce)
+			req.Header.Set(webhook.SignatureHeader, approvalSignature(secret, signedID, timestamp, nonce, []byte(body)))
+			return serve(req).Code
+		}
+
+		if code := callback("wrong", id, id, time.Now(), "n1"); code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 for a bad signature, got %d", code)
+		}
+		req := httptest.NewRequest("POST", "/approvals/"+id, strings.NewReader(body))
+		req.Header.Set(webhook.SignatureHeader, webhook.Sign("s3cret", []byte(body)))
+		if w := serve(req); w.Code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 for a body-only signature, got %d", w.Code)
+		}
+		if code := callback("s3cret", id, "other-job", time.Now(), "n2"); code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 for a decision signed for another job, got %d", code)
+		}
+		if code := callback("s3cret", id, id, time.Now().Add(-time.Hour), "n3"); code != http.StatusUnauthorized {
+			t.Fatalf("Expected status 401 for a stale callback, got %d", code)
+		}
+
+		if code := callback("s3cret", id, id, time.Now(), "n4"); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		other := hold(t)
+		if code := callback("s3cret", other, other, time.Now(), "n4"); code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401 for a reused nonce, got %d", code)
+		}
+		_, done, _ := toolService.Jobs().Get(context.Background(), id)
+		<-done
+		if job, _, _ := toolService.Jobs().Get(context.Background(), id); job.Status != JobSucceeded {
+			t.Errorf("Expected the approved call to succeed, got %+v", job)
+		}
+	})
+}
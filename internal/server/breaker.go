@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned while a tool's circuit breaker is open.
+var ErrCircuitOpen = errors.New("temporarily unavailable")
+
+// CircuitState is the state of a tool's circuit breaker.
+type CircuitState string
+
+// Circuit breaker states.
+const (
+	CircuitClosed   CircuitState = "closed"    // Calls pass through
+	CircuitOpen     CircuitState = "open"      // Calls fail fast until the cooldown ends
+	CircuitHalfOpen CircuitState = "half_open" // One trial call decides whether to close or reopen
+)
+
+// BreakerPolicy controls when a tool's circuit opens. A Failures of zero turns the
+// breaker off.
+type BreakerPolicy struct {
+	Failures int           // Consecutive failures that open the circuit
+	Cooldown time.Duration // How long the circuit stays open before a trial call
+}
+
+// BreakerStatus describes a tool's circuit breaker.
+type BreakerStatus struct {
+	Tool                string       `json:"tool"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	OpenUntil           *time.Time   `json:"openUntil,omitempty"`
+}
+
+// circuit tracks one tool's breaker.
+type circuit struct {
+	state     CircuitState
+	failures  int
+	openUntil time.Time
+	trial     bool // A half-open trial call is in flight
+}
+
+// CircuitBreaker stops calling a tool after consecutive failures, protecting whatever
+// it depends on while the problem lasts. Once the cooldown ends a single trial call is
+// let through: success closes the circuit and failure opens it again. Unlike the
+// Quarantine, which reacts to a failure rate, the breaker reacts to an unbroken run of
+// failures and needs no health check to recover.
+type CircuitBreaker struct {
+	mu       sync.Mutex
+	policy   BreakerPolicy
+	circuits map[string]*circuit
+	now      func() time.Time
+	events   *EventBus
+	logger   *slog.Logger
+}
+
+// NewCircuitBreaker creates a disabled CircuitBreaker.
+func NewCircuitBreaker(logger *slog.Logger) *CircuitBreaker {
+	return &CircuitBreaker{
+		circuits: make(map[string]*circuit),
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+// SetPolicy replaces the breaker policy and closes every circuit.
+func (b *CircuitBreaker) SetPolicy(policy BreakerPolicy) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.policy = policy
+	b.circuits = make(map[string]*circuit)
+}
+
+// Middleware returns the ToolMiddleware that enforces the breaker. Calls rejected by the
+// quarantine, for unknown tools, or cancelled by the client do not count as failures.
+func (b *CircuitBreaker) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			if err := b.admit(name); err != nil {
+				return nil, err
+			}
+			result, err := next(ctx, name, args)
+			if errors.Is(err, ErrToolNotFound) || errors.Is(err, ErrToolDisabled) || cancelledByClient(ctx, err) {
+				b.abandon(name)
+			} else {
+				b.record(name, err == nil)
+			}
+			return result, err
+		}
+	}
+}
+
+// admit rejects calls while the circuit is open and lets one trial call through once the
+// cooldown ends.
+func (b *CircuitBreaker) admit(name string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.circuits[name]
+	if !ok || c.state == CircuitClosed {
+		return nil
+	}
+	if c.state == CircuitOpen {
+		if b.now().Before(c.openUntil) {
+			return b.openError(name, c)
+		}
+		c.state = CircuitHalfOpen
+		b.logger.Info("Circuit half-open, trying tool", "tool", name)
+	}
+	if c.trial {
+		return b.openError(name, c)
+	}
+	c.trial = true
+	return nil
+}
+
+// abandon forgets a trial call whose outcome says nothing about the tool.
+func (b *CircuitBreaker) abandon(name string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.circuits[name]; ok {
+		c.trial = false
+	}
+}
+
+// record counts a call outcome and opens the circuit when the policy is exceeded.
+func (b *CircuitBreaker) record(name string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.policy.Failures <= 0 {
+		return
+	}
+	c, ok := b.circuits[name]
+	if success {
+		if ok && c.state != CircuitClosed {
+			b.logger.Info("Circuit closed", "tool", name)
+		}
+		delete(b.circuits, name)
+		return
+	}
+	if !ok {
+		c = &circuit{state: CircuitClosed}
+		b.circuits[name] = c
+	}
+	c.failures++
+	c.trial = false
+	if c.state == CircuitHalfOpen || c.failures >= b.policy.Failures {
+		c.state = CircuitOpen
+		c.openUntil = b.now().Add(b.policy.Cooldown)
+		b.logger.Warn("Circuit opened after consecutive failures", "tool", name, "failures", c.failures, "until", c.openUntil)
+		b.events.Publish(EventCircuitOpened, map[string]interface{}{
+			"tool":      name,
+			"failures":  c.failures,
+			"openUntil": c.openUntil,
+		})
+	}
+}
+
+// Reset closes a tool's circuit, reporting whether it had recorded failures.
+func (b *CircuitBreaker) Reset(name string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.circuits[name]; !ok {
+		return false
+	}
+	delete(b.circuits, name)
+	b.logger.Info("Circuit reset", "tool", name)
+	return true
+}
+
+// Breakers returns the tools with an open circuit or recent failures, ordered by name.
+// Tools not listed are closed with no failures.
+func (b *CircuitBreaker) Breakers() []BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	statuses := make([]BreakerStatus, 0, len(b.circuits))
+	for name, c := range b.circuits {
+		status := BreakerStatus{Tool: name, State: c.state, ConsecutiveFailures: c.failures}
+		if c.state == CircuitOpen {
+			until := c.openUntil
+			status.OpenUntil = &until
+			if !now.Before(until) {
+				// The next call is the trial
+				status.State, status.OpenUntil = CircuitHalfOpen, nil
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Tool < statuses[j].Tool })
+	return statuses
+}
+
+// openError builds the error returned for calls to a tool whose circuit is open. The
+// caller holds b.mu.
+func (b *CircuitBreaker) openError(name string, c *circuit) error {
+	retry := c.openUntil
+	if retry.Before(b.now()) {
+		retry = b.now()
+	}
+	return fmt.Errorf("tool %s is %w after %d consecutive failures; retry after %s",
+		name, ErrCircuitOpen, c.failures, retry.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+// newBreakerTestService creates a ToolService whose "flaky" tool fails while *failing is
+// set, with a breaker that opens after two failures and a fake clock.
+func newBreakerTestService(t *testing.T) (*ToolService, *bool, *time.Time) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	failing := true
+	service := newTestToolService(logger, &MockTool{name: "flaky", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		if failing {
+			return nil, errors.New("upstream down")
+		}
+		return map[string]interface{}{"ok": true}, nil
+	}})
+	service.Breaker().SetPolicy(BreakerPolicy{Failures: 2, Cooldown: 30 * time.Second})
+	now := time.Now()
+	service.Breaker().now = func() time.Time { return now }
+	return service, &failing, &now
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("consecutive failures open the circuit", func(t *testing.T) {
+		service, _, _ := newBreakerTestService(t)
+		for i := 0; i < 2; i++ {
+			if _, err := service.ExecuteTool("flaky", nil); errors.Is(err, ErrCircuitOpen) {
+				t.Fatalf("Call %d: expected the tool's own error, got %v", i+1, err)
+			}
+		}
+		if _, err := service.ExecuteTool("flaky", nil); !errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+		}
+		statuses := service.Breaker().Breakers()
+		if len(statuses) != 1 || statuses[0].State != CircuitOpen || statuses[0].ConsecutiveFailures != 2 {
+			t.Errorf("Expected an open circuit with 2 failures, got %+v", statuses)
+		}
+	})
+
+	t.Run("a success resets the failure count", func(t *testing.T) {
+		service, failing, _ := newBreakerTestService(t)
+		_, _ = service.ExecuteTool("flaky", nil)
+		*failing = false
+		_, _ = service.ExecuteTool("flaky", nil)
+		*failing = true
+		if _, err := service.ExecuteTool("flaky", nil); errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected the circuit to stay closed, got %v", err)
+		}
+	})
+
+	t.Run("a successful trial call closes the circuit", func(t *testing.T) {
+		service, failing, now := newBreakerTestService(t)
+		_, _ = service.ExecuteTool("flaky", nil)
+		_, _ = service.ExecuteTool("flaky", nil)
+		*now = now.Add(31 * time.Second)
+		if statuses := service.Breaker().Breakers(); statuses[0].State != CircuitHalfOpen {
+			t.Errorf("Expected half-open after the cooldown, got %+v", statuses)
+		}
+
+		*failing = false
+		if _, err := service.ExecuteTool("flaky", nil); err != nil {
+			t.Fatalf("Expected the trial call to succeed, got %v", err)
+		}
+		if statuses := service.Breaker().Breakers(); len(statuses) != 0 {
+			t.Errorf("Expected the circuit to be closed, got %+v", statuses)
+		}
+	})
+
+	t.Run("a failed trial call reopens the circuit", func(t *testing.T) {
+		service, _, now := newBreakerTestService(t)
+		_, _ = service.ExecuteTool("flaky", nil)
+		_, _ = service.ExecuteTool("flaky", nil)
+		*now = now.Add(31 * time.Second)
+		if _, err := service.ExecuteTool("flaky", nil); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("Expected the trial call to reach the tool, got %v", err)
+		}
+		if _, err := service.ExecuteTool("flaky", nil); !errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected the circuit to reopen, got %v", err)
+		}
+	})
+
+	t.Run("reset closes the circuit", func(t *testing.T) {
+		service, _, _ := newBreakerTestService(t)
+		_, _ = service.ExecuteTool("flaky", nil)
+		_, _ = service.ExecuteTool("flaky", nil)
+		if !service.Breaker().Reset("flaky") {
+			t.Fatal("Expected Reset to report an open circuit")
+		}
+		if _, err := service.ExecuteTool("flaky", nil); errors.Is(err, ErrCircuitOpen) {
+			t.Errorf("Expected the circuit to be closed, got %v", err)
+		}
+	})
+
+	t.Run("quarantine rejections do not count", func(t *testing.T) {
+		service, _, _ := newBreakerTestService(t)
+		service.Quarantine().SetPolicy(QuarantinePolicy{FailureRate: 1, MinCalls: 1, Window: time.Minute, Cooldown: time.Minute})
+		_, _ = service.ExecuteTool("flaky", nil)
+		if _, err := service.ExecuteTool("flaky", nil); !errors.Is(err, ErrToolDisabled) {
+			t.Fatalf("Expected the quarantine to reject the call, got %v", err)
+		}
+		if statuses := service.Breaker().Breakers(); statuses[0].ConsecutiveFailures != 1 {
+			t.Errorf("Expected only the tool's own failure to count, got %+v", statuses)
+		}
+	})
+}
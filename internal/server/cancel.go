@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// cancelledRequestTTL is how long a cancellation for a request that has not started is
+// kept, in case the request is still queued behind others on its connection.
+const cancelledRequestTTL = time.Minute
+
+// inflightRequests tracks the requests being processed per session, so that a
+// notifications/cancelled from the same session can cancel the request's context.
+type inflightRequests struct {
+	mu       sync.Mutex
+	requests map[string]*inflightRequest
+}
+
+// inflightRequest is one request being processed, or one cancelled before it started.
+type inflightRequest struct {
+	cancel    context.CancelFunc // Nil until the request starts
+	cancelled bool               // Cancelled by the client, so its response is dropped
+	at        time.Time          // When an unstarted request was cancelled
+}
+
+// track registers a request with id from the session in ctx and returns a context that
+// is canceled when the client cancels the request. finish must be called once the
+// request has been processed; it reports whether the client cancelled it. Requests
+// without a session cannot be told apart from other clients' and are not tracked.
+func (r *inflightRequests) track(ctx context.Context, id interface{}) (context.Context, func() bool) {
+	sessionID := SessionIDFromContext(ctx)
+	if sessionID == "" {
+		return ctx, func() bool { return false }
+	}
+	key := inflightKey(sessionID, id)
+	ctx, cancel := context.WithCancel(ctx)
+	request := &inflightRequest{cancel: cancel}
+
+	r.mu.Lock()
+	if r.requests == nil {
+		r.requests = make(map[string]*inflightRequest)
+	}
+	if early, ok := r.requests[key]; ok && early.cancel == nil {
+		// Cancelled while queued; IDs are never reused within a session
+		request.cancelled = true
+		cancel()
+	}
+	r.requests[key] = request
+	r.mu.Unlock()
+
+	return ctx, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		// A client reusing the ID may have replaced this one
+		if r.requests[key] == request {
+			delete(r.requests, key)
+		}
+		cancel()
+		return request.cancelled
+	}
+}
+
+// cancel cancels the session's request with id and reports whether it was in flight. A
+// request that has not started yet is cancelled as soon as it does.
+func (r *inflightRequests) cancel(sessionID string, id interface{}) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := inflightKey(sessionID, id)
+	request, ok := r.requests[key]
+	if !ok || request.cancel == nil {
+		r.forgetExpired()
+		if r.requests == nil {
+			r.requests = make(map[string]*inflightRequest)
+		}
+		r.requests[key] = &inflightRequest{cancelled: true, at: time.Now()}
+		return false
+	}
+	request.cancelled = true
+	request.cancel()
+	return true
+}
+
+// forgetExpired drops cancellations of unstarted requests older than cancelledRequestTTL;
+// most are for requests that had already finished. The caller holds r.mu.
+func (r *inflightRequests) forgetExpired() {
+	for key, request := range r.requests {
+		if request.cancel == nil && time.Since(request.at) > cancelledRequestTTL {
+			delete(r.requests, key)
+		}
+	}
+}
+
+// inflightKey identifies a request by session and ID. IDs are compared as JSON, so a
+// numeric ID matches whether it was decoded as an int or a float64.
+func inflightKey(sessionID string, id interface{}) string {
+	encoded, _ := json.Marshal(id)
+	return sessionID + " " + string(encoded)
+}
+
+// cancelledByClient reports whether err is the outcome of the caller canceling ctx, which
+// says nothing about the health of the tool.
+func cancelledByClient(ctx context.Context, err error) bool {
+	return err != nil && errors.Is(ctx.Err(), context.Canceled)
+}
+
+// isControlMessage reports whether message is a ping or a cancellation. Transports that
+// process a connection's messages in order handle these as soon as they are read, so a
+// client can cancel a request, or check the connection, while a long tool call runs.
+func isControlMessage(message interface{}) bool {
+	request, ok := message.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	method, _ := request["method"].(string)
+	return method == "ping" || method == "notifications/cancelled"
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Resource is an MCP resource served to clients. Exactly one of Text or Blob holds its content.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MIMEType    string `json:"mimeType,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Blob        []byte `json:"blob,omitempty"`
+}
+
+// PromptArgument describes an argument accepted by a prompt.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Prompt is an MCP prompt template. Placeholders of the form {{name}} in Template are
+// replaced with the matching argument when the prompt is rendered.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+	Template    string           `json:"template"`
+}
+
+// Render fills the prompt template with the given arguments.
+func (p Prompt) Render(args map[string]string) (string, error) {
+	replacements := make([]string, 0, len(p.Arguments)*2)
+	for _, arg := range p.Arguments {
+		value, ok := args[arg.Name]
+		if !ok && arg.Required {
+			return "", fmt.Errorf("missing required argument: %s", arg.Name)
+		}
+		replacements = append(replacements, "{{"+arg.Name+"}}", value)
+	}
+	return strings.NewReplacer(replacements...).Replace(p.Template), nil
+}
+
+// Catalog holds the resources and prompts offered to MCP clients. It is safe for
+// concurrent use, so entries can be registered and removed while sessions are live.
+type Catalog struct {
+	resources map[string]Resource
+	prompts   map[string]Prompt
+	mu        sync.RWMutex
+	onChange  func(method string)
+}
+
+// NewCatalog creates a new Catalog. onChange, if set, is called with the list_changed
+// notification method after every successful change.
+func NewCatalog(onChange func(method string)) *Catalog {
+	return &Catalog{
+		resources: make(map[string]Resource),
+		prompts:   make(map[string]Prompt),
+		onChange:  onChange,
+	}
+}
+
+// AddResource registers a resource, replacing any existing resource with the same URI.
+func (c *Catalog) AddResource(resource Resource) error {
+	if resource.URI == "" {
+		return fmt.Errorf("resource uri is required")
+	}
+	if resource.Name == "" {
+		resource.Name = resource.URI
+	}
+
+	c.mu.Lock()
+	c.resources[resource.URI] = resource
+	c.mu.Unlock()
+
+	c.changed("notifications/resources/list_changed")
+	return nil
+}
+
+// RemoveResource unregisters a resource, reporting whether it existed.
+func (c *Catalog) RemoveResource(uri string) bool {
+	c.mu.Lock()
+	_, ok := c.resources[uri]
+	delete(c.resources, uri)
+	c.mu.Unlock()
+
+	if ok {
+		c.changed("notifications/resources/list_changed")
+	}
+	return ok
+}
+
+// Resource returns the resource with the given URI.
+func (c *Catalog) Resource(uri string) (Resource, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	resource, ok := c.resources[uri]
+	return resource, ok
+}
+
+// Resources returns all resources ordered by URI.
+func (c *Catalog) Resources() []Resource {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	resources := make([]Resource, 0, len(c.resources))
+	for _, resource := range c.resources {
+		resources = append(resources, resource)
+	}
+	sort.Slice(resources, func(i, j int) bool { return resources[i].URI < resources[j].URI })
+	return resources
+}
+
+// AddPrompt registers a prompt, replacing any existing prompt with the same name.
+func (c *Catalog) AddPrompt(prompt Prompt) error {
+	if prompt.Name == "" {
+		return fmt.Errorf("prompt name is required")
+	}
+
+	c.mu.Lock()
+	c.prompts[prompt.Name] = prompt
+	c.mu.Unlock()
+
+	c.changed("notifications/prompts/list_changed")
+	return nil
+}
+
+// RemovePrompt unregisters a prompt, reporting whether it existed.
+func (c *Catalog) RemovePrompt(name string) bool {
+	c.mu.Lock()
+	_, ok := c.prompts[name]
+	delete(c.prompts, name)
+	c.mu.Unlock()
+
+	if ok {
+		c.changed("notifications/prompts/list_changed")
+	}
+	return ok
+}
+
+// Prompt returns the prompt with the given name.
+func (c *Catalog) Prompt(name string) (Prompt, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	prompt, ok := c.prompts[name]
+	return prompt, ok
+}
+
+// Prompts returns all prompts ordered by name.
+func (c *Catalog) Prompts() []Prompt {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	prompts := make([]Prompt, 0, len(c.prompts))
+	for _, prompt := range c.prompts {
+		prompts = append(prompts, prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool { return prompts[i].Name < prompts[j].Name })
+	return prompts
+}
+
+// changed reports a change outside the lock so listeners may read the catalog.
+func (c *Catalog) changed(method string) {
+	if c.onChange != nil {
+		c.onChange(method)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestCatalog_Resources(t *testing.T) {
+	var changes []string
+	catalog := NewCatalog(func(method string) { changes = append(changes, method) })
+
+	if err := catalog.AddResource(Resource{Name: "no uri"}); err == nil {
+		t.Error("Expected error for resource without uri")
+	}
+
+	if err := catalog.AddResource(Resource{URI: "file:///b.txt", Text: "b"}); err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+	if err := catalog.AddResource(Resource{URI: "file:///a.txt", Text: "a"}); err != nil {
+		t.Fatalf("AddResource failed: %v", err)
+	}
+
+	resources := catalog.Resources()
+	if len(resources) != 2 || resources[0].URI != "file:///a.txt" {
+		t.Errorf("Expected resources sorted by uri, got %v", resources)
+	}
+	if resources[0].Name != "file:///a.txt" {
+		t.Errorf("Expected name to default to uri, got %s", resources[0].Name)
+	}
+
+	if !catalog.RemoveResource("file:///a.txt") {
+		t.Error("Expected RemoveResource to report removal")
+	}
+	if catalog.RemoveResource("file:///a.txt") {
+		t.Error("Expected second RemoveResource to report nothing removed")
+	}
+
+	if len(changes) != 3 {
+		t.Errorf("Expected 3 change notifications, got %d: %v", len(changes), changes)
+	}
+	for _, method := range changes {
+		if method != "notifications/resources/list_changed" {
+			t.Errorf("Unexpected notification method: %s", method)
+		}
+	}
+}
+
+func TestCatalog_Prompts(t *testing.T) {
+	var changes []string
+	catalog := NewCatalog(func(method string) { changes = append(changes, method) })
+
+	prompt := Prompt{
+		Name:      "review",
+		Arguments: []PromptArgument{{Name: "language", Required: true}, {Name: "focus"}},
+		Template:  "Review this {{language}} code focusing on {{focus}}.",
+	}
+	if err := catalog.AddPrompt(prompt); err != nil {
+		t.Fatalf("AddPrompt failed: %v", err)
+	}
+
+	stored, ok := catalog.Prompt("review")
+	if !ok {
+		t.Fatal("Expected prompt to be found")
+	}
+	text, err := stored.Render(map[string]string{"language": "Go", "focus": "errors"})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if text != "Review this Go code focusing on errors." {
+		t.Errorf("Unexpected rendered text: %s", text)
+	}
+	if _, err := stored.Render(map[string]string{}); err == nil {
+		t.Error("Expected error for missing required argument")
+	}
+
+	catalog.RemovePrompt("review")
+	if len(changes) != 2 || changes[1] != "notifications/prompts/list_changed" {
+		t.Errorf("Unexpected change notifications: %v", changes)
+	}
+}
+
+func TestCatalog_ConcurrentAccess(t *testing.T) {
+	catalog := NewCatalog(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			uri := fmt.Sprintf("mem://%d", i)
+			_ = catalog.AddResource(Resource{URI: uri})
+			_ = catalog.Resources()
+			_ = catalog.AddPrompt(Prompt{Name: uri})
+			catalog.RemoveResource(uri)
+		}(i)
+	}
+	wg.Wait()
+
+	if len(catalog.Resources()) != 0 {
+		t.Errorf("Expected all resources removed, got %d", len(catalog.Resources()))
+	}
+	if len(catalog.Prompts()) != 50 {
+		t.Errorf("Expected 50 prompts, got %d", len(catalog.Prompts()))
+	}
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// ErrChaos is returned by tool calls that chaos mode fails on purpose.
+var ErrChaos = errors.New("fault injected by chaos mode")
+
+// ChaosConfig sets how often chaos mode injects each kind of fault. Rates are fractions
+// from 0 to 1; zero injects nothing.
+type ChaosConfig struct {
+	LatencyRate float64       // Fraction of tool calls delayed
+	MaxLatency  time.Duration // Longest delay; each delay is random up to it
+	ErrorRate   float64       // Fraction of tool calls failed with ErrChaos
+	DropRate    float64       // Fraction of SSE events dropped instead of sent
+}
+
+// Chaos injects artificial latency, tool errors, and dropped SSE events so that client
+// authors can test their retry and resume logic against this server. It is meant for
+// test deployments only. A nil Chaos injects nothing.
+type Chaos struct {
+	config ChaosConfig
+	logger *slog.Logger
+}
+
+// NewChaos creates a Chaos injecting faults at the configured rates.
+func NewChaos(config ChaosConfig, logger *slog.Logger) *Chaos {
+	return &Chaos{config: config, logger: logger}
+}
+
+// Middleware returns tool middleware that delays and fails calls at the configured rates.
+// Added with ToolService.Use, it sits outside the quarantine and circuit breaker, so
+// injected errors do not disable tools.
+func (c *Chaos) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			if c.config.MaxLatency > 0 && hit(c.config.LatencyRate) {
+				delay := rand.N(c.config.MaxLatency) + 1
+				loggerFor(ctx, c.logger).Debug("Chaos: delaying tool call", "tool", name, "delay", delay)
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return nil, ctx.Err()
+				case <-timer.C:
+				}
+			}
+			if hit(c.config.ErrorRate) {
+				loggerFor(ctx, c.logger).Debug("Chaos: failing tool call", "tool", name)
+				return nil, ErrChaos
+			}
+			return next(ctx, name, args)
+		}
+	}
+}
+
+// DropEvent reports whether the next SSE event should be dropped.
+func (c *Chaos) DropEvent() bool {
+	if c == nil || !hit(c.config.DropRate) {
+		return false
+	}
+	c.logger.Debug("Chaos: dropping SSE event")
+	return true
+}
+
+// hit reports whether a random draw falls within rate.
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
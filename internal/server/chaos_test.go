@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestChaos(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	handler := func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}
+
+	t.Run("zero rates inject nothing", func(t *testing.T) {
+		chaos := NewChaos(ChaosConfig{}, logger)
+		for i := 0; i < 100; i++ {
+			if _, err := chaos.Middleware()(handler)(context.Background(), "echo", nil); err != nil {
+				t.Fatalf("Expected no injected errors, got %v", err)
+			}
+			if chaos.DropEvent() {
+				t.Fatal("Expected no dropped events")
+			}
+		}
+	})
+
+	t.Run("full rates always inject", func(t *testing.T) {
+		chaos := NewChaos(ChaosConfig{LatencyRate: 1, MaxLatency: 5 * time.Millisecond, ErrorRate: 1, DropRate: 1}, logger)
+		if _, err := chaos.Middleware()(handler)(context.Background(), "echo", nil); !errors.Is(err, ErrChaos) {
+			t.Errorf("Expected ErrChaos, got %v", err)
+		}
+		if !chaos.DropEvent() {
+			t.Error("Expected the event to be dropped")
+		}
+	})
+
+	t.Run("delays end with the call's context", func(t *testing.T) {
+		chaos := NewChaos(ChaosConfig{LatencyRate: 1, MaxLatency: time.Hour}, logger)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := chaos.Middleware()(handler)(ctx, "echo", nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the delay to stop at the deadline, got %v", err)
+		}
+	})
+
+	t.Run("nil chaos drops nothing", func(t *testing.T) {
+		var chaos *Chaos
+		if chaos.DropEvent() {
+			t.Error("Expected a nil Chaos to drop nothing")
+		}
+	})
+
+	t.Run("injected errors do not quarantine tools", func(t *testing.T) {
+		toolService := newTestToolService(logger, &MockTool{name: "echo"})
+		toolService.Breaker().SetPolicy(BreakerPolicy{Failures: 1, Cooldown: time.Minute})
+		toolService.Use(NewChaos(ChaosConfig{ErrorRate: 1}, logger).Middleware())
+		for i := 0; i < 3; i++ {
+			if _, err := toolService.ExecuteToolContext(context.Background(), "echo", nil); !errors.Is(err, ErrChaos) {
+				t.Fatalf("Expected ErrChaos, got %v", err)
+			}
+		}
+		if breakers := toolService.Breaker().Breakers(); len(breakers) != 0 {
+			t.Errorf("Expected no breaker failures from injected errors, got %+v", breakers)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies lists the networks whose forwarding headers are believed. Requests from
+// any other address are attributed to that address, whatever headers they carry.
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies parses CIDRs such as "10.0.0.0/8" or single addresses such as
+// "192.168.1.10" into TrustedProxies.
+func ParseTrustedProxies(entries []string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		addr = addr.Unmap()
+		proxies = append(proxies, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return proxies, nil
+}
+
+// contains reports whether addr belongs to a trusted proxy network.
+func (p TrustedProxies) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent r. When the connection comes from
+// a trusted proxy, the Forwarded, X-Forwarded-For, or X-Real-IP header (in that order of
+// preference) is walked from the nearest hop back, skipping trusted proxies, and the first
+// untrusted address is the client.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	remote, ok := parseHostAddr(r.RemoteAddr)
+	if !ok {
+		return r.RemoteAddr
+	}
+	if !p.contains(remote) {
+		return remote.String()
+	}
+
+	hops := forwardedFor(r.Header)
+	if len(hops) == 0 {
+		if realIP, ok := parseHostAddr(r.Header.Get("X-Real-IP")); ok {
+			return realIP.String()
+		}
+		return remote.String()
+	}
+
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHostAddr(hops[i])
+		if !ok {
+			// An unparsable hop cannot be trusted, so stop at the last good address.
+			break
+		}
+		client = hop
+		if !p.contains(hop) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedFor returns the client chain from the Forwarded header, falling back to
+// X-Forwarded-For, ordered from the original client to the nearest proxy.
+func forwardedFor(header http.Header) []string {
+	var hops []string
+	for _, value := range header.Values("Forwarded") {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				key, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if ok && strings.EqualFold(key, "for") {
+					hops = append(hops, strings.Trim(val, `"`))
+				}
+			}
+		}
+	}
+	if len(hops) > 0 {
+		return hops
+	}
+	for _, value := range header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseHostAddr parses an IP address that may carry a port or IPv6 brackets, as found in
+// RemoteAddr and forwarding headers.
+func parseHostAddr(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+type clientIPKey struct{}
+
+// WithClientIP returns a copy of ctx carrying the given client IP.
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, clientIPKey{}, ip)
+}
+
+// ClientIPFromContext returns the client IP stored in ctx, or "" if there is none.
+func ClientIPFromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(clientIPKey{}).(string)
+	return ip
+}
+
+// clientIPMiddleware resolves the client IP of each request through proxies and stores it
+// in the request context for logging and per-client limits.
+func clientIPMiddleware(proxies TrustedProxies, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithClientIP(r.Context(), proxies.ClientIP(r))))
+	})
+}
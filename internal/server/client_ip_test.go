@@ -0,0 +1,80 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	t.Run("accepts CIDRs and single addresses", func(t *testing.T) {
+		proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.10 ", "fd00::/8", ""})
+		if err != nil {
+			t.Fatalf("ParseTrustedProxies failed: %v", err)
+		}
+		if len(proxies) != 3 {
+			t.Errorf("Expected 3 proxies, got %v", proxies)
+		}
+	})
+
+	t.Run("rejects invalid entries", func(t *testing.T) {
+		for _, entry := range []string{"10.0.0.0/99", "not-an-ip"} {
+			if _, err := ParseTrustedProxies([]string{entry}); err == nil {
+				t.Errorf("Expected an error for %q", entry)
+			}
+		}
+	})
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "::1"})
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"untrusted peer cannot spoof", "203.0.113.7:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "203.0.113.7"},
+		{"trusted proxy with X-Forwarded-For", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "198.51.100.1"}, "198.51.100.1"},
+		{"spoofed hops left of the first untrusted address are ignored", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.9"}, "198.51.100.1"},
+		{"Forwarded takes precedence", "10.0.0.2:5000", map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https`, "X-Forwarded-For": "198.51.100.1"}, "2001:db8::1"},
+		{"X-Real-IP from a trusted proxy", "[::1]:5000", map[string]string{"X-Real-IP": "198.51.100.2"}, "198.51.100.2"},
+		{"trusted proxy without headers", "10.0.0.2:5000", nil, "10.0.0.2"},
+		{"all hops trusted", "10.0.0.2:5000", map[string]string{"X-Forwarded-For": "10.0.0.3"}, "10.0.0.3"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tc.remoteAddr
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+			if got := proxies.ClientIP(req); got != tc.expected {
+				t.Errorf("Expected client IP %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestClientIPMiddleware(t *testing.T) {
+	proxies, _ := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	var seen string
+	handler := clientIPMiddleware(proxies, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = ClientIPFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.1.2.3:80"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != "198.51.100.1" {
+		t.Errorf("Expected client IP in context, got %q", seen)
+	}
+}
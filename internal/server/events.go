@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"mcp-tools-server/internal/metrics"
+)
+
+// EventType names a kind of server event.
+type EventType string
+
+// Events published on the EventBus.
+const (
+	EventToolExecuted      EventType = "tool.executed"       // A tool call finished, successfully or not
+	EventSessionStarted    EventType = "session.started"     // An MCP session connected on any transport
+	EventSessionEnded      EventType = "session.ended"       // An MCP session disconnected
+	EventTransportError    EventType = "transport.error"     // A transport failed to read, write, or serve
+	EventToolQuarantined   EventType = "tool.quarantined"    // A tool was disabled for its failure rate
+	EventCircuitOpened     EventType = "circuit.opened"      // A tool's circuit breaker opened after consecutive failures
+	EventToolHealthChanged EventType = "tool.health_changed" // A tool's health check started failing or passed again
+	EventServerShutdown    EventType = "server.shutdown"     // The server began shutting down
+	EventJobFinished       EventType = "job.finished"        // An asynchronous job finished, successfully or not
+	EventScheduleExecuted  EventType = "schedule.executed"   // A scheduled tool run finished, successfully or not
+	EventApprovalRequested EventType = "approval.requested"  // A tool call is waiting for an operator to approve it
+)
+
+// eventBufferSize is how many undelivered events a subscriber may fall behind by before
+// further events are dropped for it.
+const eventBufferSize = 256
+
+// Event is something that happened inside the server.
+type Event struct {
+	Type EventType              `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// subscription delivers events to one subscriber from its own goroutine.
+type subscription struct {
+	types  map[EventType]bool
+	events chan Event
+}
+
+// EventBus fans server events out to subscribers such as metrics, the admin event
+// stream, and webhooks. Each subscriber receives events in order on its own goroutine,
+// so a slow subscriber never delays a tool call; events it cannot keep up with are
+// dropped and logged. A nil EventBus discards every event.
+type EventBus struct {
+	mu          sync.RWMutex
+	subscribers map[*subscription]struct{}
+	now         func() time.Time
+	logger      *slog.Logger
+}
+
+// NewEventBus creates an EventBus with no subscribers.
+func NewEventBus(logger *slog.Logger) *EventBus {
+	return &EventBus{
+		subscribers: make(map[*subscription]struct{}),
+		now:         time.Now,
+		logger:      logger,
+	}
+}
+
+// Subscribe calls handler for every published event of the given types, or of every
+// type when none are given. The returned function unsubscribes and returns once events
+// already queued have been handled, so it must not be called from handler.
+func (b *EventBus) Subscribe(handler func(Event), types ...EventType) (unsubscribe func()) {
+	sub := &subscription{events: make(chan Event, eventBufferSize)}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, eventType := range types {
+			sub.types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for event := range sub.events {
+			handler(event)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.mu.Unlock()
+			close(sub.events)
+		})
+		<-done
+	}
+}
+
+// Publish sends an event to every interested subscriber without blocking.
+func (b *EventBus) Publish(eventType EventType, data map[string]interface{}) {
+	if b == nil {
+		return
+	}
+	event := Event{Type: eventType, Time: b.now(), Data: data}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if sub.types != nil && !sub.types[eventType] {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			b.logger.Warn("Dropped server event, subscriber is falling behind", "event", eventType)
+		}
+	}
+}
+
+// eventsMiddleware publishes an EventToolExecuted event for every tool call.
+func eventsMiddleware(bus *EventBus) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			started := time.Now()
+			result, err := next(ctx, name, args)
+
+			data := map[string]interface{}{
+				"tool":       name,
+				"durationMs": time.Since(started).Milliseconds(),
+				"success":    err == nil,
+			}
+			if err != nil {
+				data["error"] = err.Error()
+			}
+			if requestID := RequestIDFromContext(ctx); requestID != "" {
+				data["requestId"] = requestID
+			}
+			if traceID := metrics.TraceIDFromContext(ctx); traceID != "" {
+				data["traceId"] = traceID
+			}
+			if tenant := tenantName(ctx); tenant != "" {
+				data["tenant"] = tenant
+			}
+			bus.Publish(EventToolExecuted, data)
+			return result, err
+		}
+	}
+}
+
+// publishTransportError publishes an EventTransportError for a failed transport operation.
+func (b *EventBus) publishTransportError(transport, operation string, err error) {
+	b.Publish(EventTransportError, map[string]interface{}{
+		"transport": transport,
+		"operation": operation,
+		"error":     err.Error(),
+	})
+}
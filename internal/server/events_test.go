@@ -0,0 +1,94 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+// nextEvent waits for an event on events or fails the test.
+func nextEvent(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for event")
+		return Event{}
+	}
+}
+
+func TestEventBus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("subscribers receive only the requested types", func(t *testing.T) {
+		bus := NewEventBus(logger)
+		events := make(chan Event, 10)
+		defer bus.Subscribe(func(event Event) { events <- event }, EventSessionEnded)()
+
+		bus.Publish(EventSessionStarted, nil)
+		bus.Publish(EventSessionEnded, map[string]interface{}{"sessionId": "s1"})
+		if event := nextEvent(t, events); event.Type != EventSessionEnded || event.Data["sessionId"] != "s1" {
+			t.Errorf("Expected only the session.ended event, got %+v", event)
+		}
+	})
+
+	t.Run("unsubscribed handlers stop receiving events", func(t *testing.T) {
+		bus := NewEventBus(logger)
+		events := make(chan Event, 10)
+		unsubscribe := bus.Subscribe(func(event Event) { events <- event })
+		unsubscribe()
+		unsubscribe()
+
+		bus.Publish(EventTransportError, nil)
+		select {
+		case event := <-events:
+			t.Errorf("Expected no events after unsubscribing, got %+v", event)
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+
+	t.Run("a nil bus discards events", func(t *testing.T) {
+		var bus *EventBus
+		bus.Publish(EventToolExecuted, nil)
+	})
+
+	t.Run("tool calls and sessions are published", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("upstream down")
+		}})
+		events := make(chan Event, 10)
+		defer service.Events().Subscribe(func(event Event) { events <- event })()
+
+		_, _ = service.ExecuteTool("broken", nil)
+		event := nextEvent(t, events)
+		if event.Type != EventToolExecuted || event.Data["tool"] != "broken" || event.Data["success"] != false || event.Data["error"] != "upstream down" {
+			t.Errorf("Unexpected tool event: %+v", event)
+		}
+
+		session := service.Sessions().Add("test", func(message []byte) error { return nil })
+		service.Sessions().Remove(session.ID)
+		if event := nextEvent(t, events); event.Type != EventSessionStarted || event.Data["sessionId"] != session.ID {
+			t.Errorf("Unexpected session event: %+v", event)
+		}
+		if event := nextEvent(t, events); event.Type != EventSessionEnded || event.Data["transport"] != "test" {
+			t.Errorf("Unexpected session event: %+v", event)
+		}
+	})
+
+	t.Run("quarantine is published", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("upstream down")
+		}})
+		service.Quarantine().SetPolicy(QuarantinePolicy{FailureRate: 1, MinCalls: 1, Window: time.Minute, Cooldown: time.Minute})
+		events := make(chan Event, 10)
+		defer service.Events().Subscribe(func(event Event) { events <- event }, EventToolQuarantined)()
+
+		_, _ = service.ExecuteTool("broken", nil)
+		if event := nextEvent(t, events); event.Data["tool"] != "broken" || event.Data["failures"] != 1 {
+			t.Errorf("Unexpected quarantine event: %+v", event)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/pkg/tools"
+)
+
+// quotaViolationsTotal counts executions stopped for going over a quota.
+var quotaViolationsTotal = metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: "tool",
+		Name:      "quota_violations_total",
+		Help:      "Total number of tool executions that exceeded a resource quota",
+	},
+	[]string{"tool", "resource"},
+)
+
+// ResourceGovernor holds tool executions to per-tool resource quotas. A call that runs past
+// its time limit fails: tools implementing tools.ContextTool see their context canceled
+// at the limit and should stop, while others cannot be interrupted, so they run to the
+// end and their late result is discarded. A result whose encoding is larger than the
+// output cap is replaced by an error. Violations fail with a *tools.QuotaError.
+type ResourceGovernor struct {
+	mu       sync.RWMutex
+	defaults tools.Quota
+	quotas   map[string]tools.Quota
+	logger   *slog.Logger
+}
+
+// NewResourceGovernor creates a ResourceGovernor without quotas.
+func NewResourceGovernor(logger *slog.Logger) *ResourceGovernor {
+	return &ResourceGovernor{logger: logger}
+}
+
+// SetQuotas sets the quota applied to every tool and per-tool quotas that replace it.
+func (g *ResourceGovernor) SetQuotas(defaults tools.Quota, quotas map[string]tools.Quota) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.defaults = defaults
+	g.quotas = quotas
+}
+
+// Quota returns the quota for the named tool.
+func (g *ResourceGovernor) Quota(name string) tools.Quota {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if quota, ok := g.quotas[name]; ok {
+		return quota
+	}
+	return g.defaults
+}
+
+// Middleware returns the ToolMiddleware that enforces the quotas.
+func (g *ResourceGovernor) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			quota := g.Quota(name)
+			if quota == (tools.Quota{}) {
+				return next(ctx, name, args)
+			}
+			result, err := g.run(tools.WithQuota(ctx, quota), quota, name, args, next)
+			if err == nil && quota.MaxOutputBytes > 0 {
+				if _, encodeErr := encodeResult(normalizeToolResult(result), int(quota.MaxOutputBytes)); errors.Is(encodeErr, errResultTooLarge) {
+					result, err = nil, &tools.QuotaError{Resource: tools.QuotaOutput, Limit: quota.MaxOutputBytes}
+				}
+			}
+			var quotaErr *tools.QuotaError
+			if errors.As(err, &quotaErr) {
+				quotaViolationsTotal.WithLabelValues(name, string(quotaErr.Resource)).Inc()
+				loggerFor(ctx, g.logger).Warn("Tool exceeded its quota", "tool", name, "resource", quotaErr.Resource, "limit", quotaErr.Limit)
+				return nil, fmt.Errorf("tool %s: %w", name, err)
+			}
+			return result, err
+		}
+	}
+}
+
+// run calls next with a context whose deadline is the time limit. The call runs on the
+// caller's goroutine, so a tool that ignores its context delays the caller instead of
+// leaking a goroutine.
+func (g *ResourceGovernor) run(ctx context.Context, quota tools.Quota, name string, args map[string]interface{}, next ToolHandler) (map[string]interface{}, error) {
+	if quota.TimeLimit <= 0 {
+		return next(ctx, name, args)
+	}
+	limited, cancel := context.WithTimeout(ctx, quota.TimeLimit)
+	defer cancel()
+	result, err := next(limited, name, args)
+	if limited.Err() == nil {
+		return result, err
+	}
+	// A canceled request is not the tool's fault
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, &tools.QuotaError{Resource: tools.QuotaTimeLimit, Limit: quota.TimeLimit.Milliseconds()}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// contextMockTool is a MockTool that implements tools.ContextTool.
+type contextMockTool struct {
+	MockTool
+	executeContext func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+}
+
+func (m *contextMockTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	return m.executeContext(ctx, args)
+}
+
+func TestResourceGovernor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	sawQuota := make(chan tools.Quota, 10)
+	service := newTestToolService(logger,
+		&MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			time.Sleep(50 * time.Millisecond)
+			return map[string]interface{}{}, nil
+		}},
+		&contextMockTool{MockTool: MockTool{name: "cooperative"}, executeContext: func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			quota, _ := tools.QuotaFromContext(ctx)
+			sawQuota <- quota
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}},
+		&MockTool{name: "verbose", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"text": strings.Repeat("x", 100)}, nil
+		}},
+		&MockTool{name: "quick"},
+	)
+	service.Governor().SetQuotas(tools.Quota{TimeLimit: 20 * time.Millisecond}, map[string]tools.Quota{
+		"verbose": {MaxOutputBytes: 50},
+		"quick":   {},
+	})
+
+	t.Run("time limit fails a tool that ignores its context", func(t *testing.T) {
+		before := runtime.NumGoroutine()
+		result, err := service.ExecuteTool("slow", nil)
+		var quotaErr *tools.QuotaError
+		if !errors.As(err, &quotaErr) || quotaErr.Resource != tools.QuotaTimeLimit || quotaErr.Limit != 20 || result != nil {
+			t.Fatalf("Expected time limit quota error, got %v, %v", result, err)
+		}
+		if after := runtime.NumGoroutine(); after > before {
+			t.Errorf("Expected no goroutine to be left behind, had %d and now %d", before, after)
+		}
+		if !errors.Is(err, tools.ErrQuotaExceeded) {
+			t.Error("Expected errors.Is to match ErrQuotaExceeded")
+		}
+	})
+
+	t.Run("context tools see the quota and cancellation", func(t *testing.T) {
+		_, err := service.ExecuteTool("cooperative", nil)
+		if !errors.Is(err, tools.ErrQuotaExceeded) {
+			t.Fatalf("Expected quota error, got %v", err)
+		}
+		if quota := <-sawQuota; quota.TimeLimit != 20*time.Millisecond {
+			t.Errorf("Expected the tool to see a 20ms limit, got %v", quota)
+		}
+	})
+
+	t.Run("output cap rejects large results", func(t *testing.T) {
+		result, err := service.ExecuteTool("verbose", nil)
+		var quotaErr *tools.QuotaError
+		if !errors.As(err, &quotaErr) || quotaErr.Resource != tools.QuotaOutput || result != nil {
+			t.Fatalf("Expected output quota error, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("per-tool quota replaces the default", func(t *testing.T) {
+		if _, err := service.ExecuteTool("quick", nil); err != nil {
+			t.Errorf("Expected unlimited tool to succeed, got %v", err)
+		}
+	})
+
+	t.Run("canceled request is not a quota violation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := service.ExecuteToolContext(ctx, "cooperative", nil)
+		if !errors.Is(err, context.Canceled) || errors.Is(err, tools.ErrQuotaExceeded) {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	})
+
+	t.Run("violations are reported as JSON-RPC error data", func(t *testing.T) {
+		processor := NewJSONRPCProcessor(service, logger)
+		response := processor.HandleToolsCall(context.Background(), map[string]interface{}{"name": "verbose"}, 1)
+		data, ok := response.Error.Data.(map[string]interface{})
+		if !ok || data["resource"] != tools.QuotaOutput || data["limit"] != int64(50) {
+			t.Errorf("Expected quota details in error data, got %+v", response.Error)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthState tracks liveness and readiness for load balancer probes. Readiness fails
+// during the startup grace period, while the server drains before shutdown, and whenever
+// an operator has manually failed it through the admin API.
+type HealthState struct {
+	mu           sync.RWMutex
+	startedAt    time.Time
+	startupGrace time.Duration
+	draining     bool
+	manualFail   bool
+}
+
+// NewHealthState creates a new HealthState with the given startup grace period.
+func NewHealthState(startupGrace time.Duration) *HealthState {
+	return &HealthState{
+		startedAt:    time.Now(),
+		startupGrace: startupGrace,
+	}
+}
+
+// MarkStarted records the moment the server began accepting traffic, restarting the grace period.
+func (h *HealthState) MarkStarted() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.startedAt = time.Now()
+}
+
+// StartDraining fails readiness so load balancers stop routing new traffic here.
+func (h *HealthState) StartDraining() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.draining = true
+}
+
+// SetManualFail forces readiness to fail (true) or clears the override (false).
+func (h *HealthState) SetManualFail(fail bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.manualFail = fail
+}
+
+// Ready reports whether the server should receive traffic, and if not, why.
+func (h *HealthState) Ready() (bool, string) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	switch {
+	case h.draining:
+		return false, "draining"
+	case h.manualFail:
+		return false, "manually disabled"
+	case time.Since(h.startedAt) < h.startupGrace:
+		return false, "starting"
+	default:
+		return true, ""
+	}
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthState_Ready(t *testing.T) {
+	t.Run("ready immediately without grace period", func(t *testing.T) {
+		h := NewHealthState(0)
+		if ready, reason := h.Ready(); !ready {
+			t.Errorf("Expected ready, got not ready (%s)", reason)
+		}
+	})
+
+	t.Run("not ready during startup grace period", func(t *testing.T) {
+		h := NewHealthState(time.Hour)
+		ready, reason := h.Ready()
+		if ready {
+			t.Fatal("Expected not ready during grace period")
+		}
+		if reason != "starting" {
+			t.Errorf("Expected reason 'starting', got %s", reason)
+		}
+	})
+
+	t.Run("manual fail toggles readiness", func(t *testing.T) {
+		h := NewHealthState(0)
+		h.SetManualFail(true)
+		if ready, reason := h.Ready(); ready || reason != "manually disabled" {
+			t.Errorf("Expected manually disabled, got ready=%v reason=%s", ready, reason)
+		}
+		h.SetManualFail(false)
+		if ready, _ := h.Ready(); !ready {
+			t.Error("Expected ready after clearing manual fail")
+		}
+	})
+
+	t.Run("draining takes precedence", func(t *testing.T) {
+		h := NewHealthState(0)
+		h.SetManualFail(true)
+		h.StartDraining()
+		if ready, reason := h.Ready(); ready || reason != "draining" {
+			t.Errorf("Expected draining, got ready=%v reason=%s", ready, reason)
+		}
+	})
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Execution records one tool call for later inspection and replay.
+type Execution struct {
+	ID                 string          `json:"id"`
+	Tool               string          `json:"tool"`
+	Arguments          json.RawMessage `json:"arguments,omitempty"`
+	ArgumentsTruncated bool            `json:"argumentsTruncated,omitempty"`
+	Result             json.RawMessage `json:"result,omitempty"`
+	ResultTruncated    bool            `json:"resultTruncated,omitempty"`
+	Error              string          `json:"error,omitempty"`
+	RequestID          string          `json:"requestId,omitempty"`
+	ReplayOf           string          `json:"replayOf,omitempty"`
+	StartedAt          time.Time       `json:"startedAt"`
+	DurationMS         int64           `json:"durationMs"`
+}
+
+// ExecutionHistory keeps the most recent tool executions in a fixed-size ring buffer so
+// operators can reproduce a problematic call. Arguments and results larger than the byte
+// cap are dropped from the record and flagged as truncated. A size of zero disables it.
+type ExecutionHistory struct {
+	mu       sync.Mutex
+	entries  []Execution
+	next     int
+	count    int
+	maxBytes int
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+// NewExecutionHistory creates a disabled ExecutionHistory.
+func NewExecutionHistory(logger *slog.Logger) *ExecutionHistory {
+	return &ExecutionHistory{
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// replayKey is the context key marking a call as the replay of a recorded execution.
+type replayKey struct{}
+
+// withReplayOf returns a context whose tool calls are recorded as replays of id.
+func withReplayOf(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, replayKey{}, id)
+}
+
+// SetLimits sets how many executions are kept and the byte cap applied to the arguments
+// and result of each. Recorded executions are cleared.
+func (h *ExecutionHistory) SetLimits(size, maxBytes int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if size < 0 {
+		size = 0
+	}
+	h.entries = make([]Execution, size)
+	h.next, h.count = 0, 0
+	h.maxBytes = maxBytes
+}
+
+// Middleware returns the ToolMiddleware that records each call.
+func (h *ExecutionHistory) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			if !h.enabled() {
+				return next(ctx, name, args)
+			}
+			started := h.now()
+			// Arguments are captured before the call in case the tool modifies the map.
+			arguments, argumentsTruncated := h.capture(args)
+			result, err := next(ctx, name, args)
+
+			execution := Execution{
+				ID:                 uuid.NewString(),
+				Tool:               name,
+				Arguments:          arguments,
+				ArgumentsTruncated: argumentsTruncated,
+				RequestID:          RequestIDFromContext(ctx),
+				StartedAt:          started,
+				DurationMS:         h.now().Sub(started).Milliseconds(),
+			}
+			if replayOf, ok := ctx.Value(replayKey{}).(string); ok {
+				execution.ReplayOf = replayOf
+			}
+			if err != nil {
+				execution.Error = err.Error()
+			} else {
+				execution.Result, execution.ResultTruncated = h.capture(normalizeToolResult(result))
+			}
+			h.add(execution)
+			return result, err
+		}
+	}
+}
+
+// enabled reports whether executions are being recorded.
+func (h *ExecutionHistory) enabled() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries) > 0
+}
+
+// capture encodes value, dropping it when it exceeds the byte cap.
+func (h *ExecutionHistory) capture(value map[string]interface{}) (json.RawMessage, bool) {
+	if value == nil {
+		return nil, false
+	}
+	h.mu.Lock()
+	maxBytes := h.maxBytes
+	h.mu.Unlock()
+	data, err := encodeResult(value, maxBytes)
+	if errors.Is(err, errResultTooLarge) {
+		return nil, true
+	}
+	if err != nil {
+		h.logger.Warn("Failed to record tool execution value", "error", err)
+		return nil, true
+	}
+	return data, false
+}
+
+// add stores an execution, overwriting the oldest once the buffer is full.
+func (h *ExecutionHistory) add(execution Execution) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.entries) == 0 {
+		return
+	}
+	h.entries[h.next] = execution
+	h.next = (h.next + 1) % len(h.entries)
+	if h.count < len(h.entries) {
+		h.count++
+	}
+}
+
+// List returns the recorded executions, newest first.
+func (h *ExecutionHistory) List() []Execution {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	executions := make([]Execution, 0, h.count)
+	for i := 1; i <= h.count; i++ {
+		executions = append(executions, h.entries[(h.next-i+len(h.entries))%len(h.entries)])
+	}
+	return executions
+}
+
+// Get returns the recorded execution with the given ID.
+func (h *ExecutionHistory) Get(id string) (Execution, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i := 0; i < h.count; i++ {
+		if h.entries[i].ID == id {
+			return h.entries[i], true
+		}
+	}
+	return Execution{}, false
+}
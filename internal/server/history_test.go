@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExecutionHistory(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("disabled by default", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		if _, err := service.ExecuteTool("echo", nil); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if executions := service.History().List(); len(executions) != 0 {
+			t.Errorf("Expected no recorded executions, got %v", executions)
+		}
+	})
+
+	t.Run("keeps the newest executions", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		service.History().SetLimits(2, 1024)
+		for _, text := range []string{"one", "two", "three"} {
+			ctx := WithRequestID(context.Background(), text)
+			if _, err := service.ExecuteToolContext(ctx, "echo", map[string]interface{}{"text": text}); err != nil {
+				t.Fatalf("ExecuteToolContext failed: %v", err)
+			}
+		}
+
+		executions := service.History().List()
+		if len(executions) != 2 || executions[0].RequestID != "three" || executions[1].RequestID != "two" {
+			t.Fatalf("Expected the two newest executions, got %+v", executions)
+		}
+		if string(executions[0].Arguments) != `{"text":"three"}` || len(executions[0].Result) == 0 {
+			t.Errorf("Expected arguments and result to be recorded, got %+v", executions[0])
+		}
+		if got, ok := service.History().Get(executions[1].ID); !ok || got.RequestID != "two" {
+			t.Errorf("Expected Get to find the execution, got %+v %v", got, ok)
+		}
+	})
+
+	t.Run("records errors", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("upstream down")
+		}})
+		service.History().SetLimits(5, 1024)
+		_, _ = service.ExecuteTool("broken", nil)
+
+		executions := service.History().List()
+		if len(executions) != 1 || executions[0].Error != "upstream down" || executions[0].Result != nil {
+			t.Errorf("Expected the error to be recorded, got %+v", executions)
+		}
+	})
+
+	t.Run("drops values over the size cap", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		service.History().SetLimits(5, 16)
+		_, _ = service.ExecuteTool("echo", map[string]interface{}{"text": strings.Repeat("a", 32)})
+
+		execution := service.History().List()[0]
+		if execution.Arguments != nil || !execution.ArgumentsTruncated {
+			t.Errorf("Expected arguments to be truncated, got %+v", execution)
+		}
+	})
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"mcp-tools-server/internal/codec"
+)
+
+// responseCodecs are the binary encodings REST API responses are available in besides
+// JSON, for clients that ask for them with Accept.
+var responseCodecs = []codec.Codec{codec.CBOR, codec.MessagePack}
+
+// negotiateEncoding rejects requests whose Accept header allows neither JSON nor one of
+// the binary encodings with 406, and requests whose body is not JSON with 415. A missing
+// Accept or Content-Type header is allowed.
+func (s *HTTPServer) negotiateEncoding(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireJSONBody(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsEncoded(r.Header.Get("Accept")) {
+			s.writeError(w, r, http.StatusNotAcceptable, errCodeNotAcceptable, "Responses are only available as application/json, application/cbor, or application/msgpack")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// acceptsEncoded reports whether an Accept header value allows JSON or a binary encoding.
+func acceptsEncoded(accept string) bool {
+	return acceptsJSON(accept) || responseCodec(accept) != nil
+}
+
+// writeEncoded writes body with the given status code in the encoding the request's
+// Accept header prefers: a binary encoding when Accept rates it above JSON, and JSON
+// otherwise, including when they are rated the same.
+func (s *HTTPServer) writeEncoded(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	w.Header().Add("Vary", "Accept")
+	c := responseCodec(r.Header.Get("Accept"))
+	if c == nil {
+		s.writeJSON(w, status, body)
+		return
+	}
+	encoded, err := c.Marshal(body)
+	if err != nil {
+		s.logger.Error("Failed to encode response", "encoding", c.Name(), "error", err)
+		s.writeError(w, r, http.StatusInternalServerError, errCodeToolFailed, "Failed to encode the response as "+c.ContentType())
+		return
+	}
+	w.Header().Set("Content-Type", c.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(encoded)))
+	w.WriteHeader(status)
+	if _, err := w.Write(encoded); err != nil {
+		loggerFor(r.Context(), s.logger).Error("Failed to write response", "encoding", c.Name(), "error", err)
+	}
+}
+
+// responseCodec returns the binary encoding an Accept header value rates above JSON, or
+// nil if none is. A missing header prefers JSON.
+func responseCodec(accept string) codec.Codec {
+	if accept == "" {
+		return nil
+	}
+	var best codec.Codec
+	bestQuality := acceptQuality(accept, func(mediaType string) bool { return mediaType == "application/json" })
+	for _, c := range responseCodecs {
+		quality := acceptQuality(accept, func(mediaType string) bool { return codec.ByContentType(mediaType) == c })
+		if quality > bestQuality {
+			best, bestQuality = c, quality
+		}
+	}
+	return best
+}
+
+// acceptQuality returns the quality an Accept header value gives the application media
+// types matched by match: the highest of the ranges naming one of them, or if there are
+// none, the highest of the application/* and */* ranges. Unrated ranges have quality 1.
+func acceptQuality(accept string, match func(mediaType string) bool) float64 {
+	specific, wildcard := -1.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		switch {
+		case match(mediaType):
+			specific = max(specific, quality)
+		case mediaType == "application/*" || mediaType == "*/*":
+			wildcard = max(wildcard, quality)
+		}
+	}
+	if specific >= 0 {
+		return specific
+	}
+	return wildcard
+}
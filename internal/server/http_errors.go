@@ -0,0 +1,170 @@
+package server
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Error codes returned in the "code" field of REST API error responses. Clients should
+// branch on these rather than on the message text.
+const (
+	errCodeNotFound             = "not_found"
+	errCodeMethodNotAllowed     = "method_not_allowed"
+	errCodeInvalidRequest       = "invalid_request"
+	errCodeInvalidArguments     = "invalid_arguments"
+	errCodeInvalidResult        = "invalid_result"
+	errCodeRequestTooLarge      = "request_too_large"
+	errCodeNotAcceptable        = "not_acceptable"
+	errCodeUnsupportedMediaType = "unsupported_media_type"
+	errCodeUnsupportedVersion   = "unsupported_version"
+	errCodeToolNotFound         = "tool_not_found"
+	errCodeToolUnavailable      = "tool_unavailable"
+	errCodeToolFailed           = "tool_failed"
+	errCodeQuotaExceeded        = "quota_exceeded"
+	errCodeNotImplemented       = "not_implemented"
+	errCodeNotReplayable        = "not_replayable"
+	errCodeUnauthorized         = "unauthorized"
+	errCodeRateLimited          = "rate_limited"
+	errCodeForbidden            = "forbidden"
+	errCodeConflict             = "conflict"
+	errCodeStoreUnavailable     = "store_unavailable"
+	errCodeDeliveryFailed       = "delivery_failed"
+	errCodeTimeout              = "timeout"
+)
+
+// apiError describes a failed REST API request.
+type apiError struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// errorResponse is the JSON envelope of REST API error responses.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// writeError writes a JSON error envelope with the given status, error code, and message.
+// The request ID is included so failures can be matched to server logs.
+func (s *HTTPServer) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if err := writeErrorEnvelope(w, r, status, code, message); err != nil {
+		s.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// writeErrorEnvelope writes a JSON error envelope for middleware shared by every
+// HTTP-based transport.
+func writeErrorEnvelope(w http.ResponseWriter, r *http.Request, status int, code, message string) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(errorResponse{Error: apiError{
+		Code:      code,
+		Message:   message,
+		RequestID: RequestIDFromContext(r.Context()),
+	}})
+}
+
+// routeErrors serves requests through mux, replacing its plain-text 404 and 405
+// responses with JSON error bodies. The Allow header of a 405 is preserved.
+func (s *HTTPServer) routeErrors(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			// Serve through the mux so path wildcards are populated for r.PathValue.
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		// No route matched. Let the mux decide between 404 and 405, then rewrite its body.
+		rec := &routeErrorRecorder{header: make(http.Header)}
+		handler.ServeHTTP(rec, r)
+		if allow := rec.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+		status, code := http.StatusNotFound, errCodeNotFound
+		if rec.status == http.StatusMethodNotAllowed {
+			status, code = http.StatusMethodNotAllowed, errCodeMethodNotAllowed
+		}
+		s.logger.Debug("No route for request", "method", r.Method, "path", r.URL.Path, "status", status)
+		s.writeError(w, r, status, code, http.StatusText(status))
+	})
+}
+
+// negotiateJSON rejects requests whose Accept header excludes JSON with 406 and requests
+// whose body is not JSON with 415. A missing Accept or Content-Type header is allowed.
+func (s *HTTPServer) negotiateJSON(next http.HandlerFunc) http.HandlerFunc {
+	return s.requireJSONBody(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsJSON(r.Header.Get("Accept")) {
+			s.writeError(w, r, http.StatusNotAcceptable, errCodeNotAcceptable, "Responses are only available as application/json")
+			return
+		}
+		next(w, r)
+	})
+}
+
+// requireJSONBody rejects requests whose body is not JSON with 415. Handlers wrapped only
+// in it, such as tool calls, whose result may be an image or text, check Accept themselves.
+func (s *HTTPServer) requireJSONBody(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if contentType := r.Header.Get("Content-Type"); contentType != "" && r.ContentLength != 0 {
+			if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+				s.writeError(w, r, http.StatusUnsupportedMediaType, errCodeUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// acceptsJSON reports whether an Accept header value allows an application/json response.
+func acceptsJSON(accept string) bool {
+	return accepts(accept, "application/json")
+}
+
+// accepts reports whether an Accept header value allows a response of the given media
+// type. Parameters such as charset are ignored.
+func accepts(accept, contentType string) bool {
+	if accept == "" {
+		return true
+	}
+	target, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	kind, _, _ := strings.Cut(target, "/")
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		switch mediaType {
+		case target, kind + "/*", "*/*":
+			return true
+		}
+	}
+	return false
+}
+
+// routeErrorRecorder captures the status and headers of the mux's fallback handlers and
+// discards their body.
+type routeErrorRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *routeErrorRecorder) Header() http.Header { return r.header }
+
+func (r *routeErrorRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (r *routeErrorRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
@@ -3,97 +3,166 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 
+	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/internal/policy"
 	"mcp-tools-server/internal/version"
+	"mcp-tools-server/pkg/tools"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-var (
-	requestsTotal = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"code", "method", "endpoint"},
-	)
-	requestDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_request_duration_seconds",
-			Help:    "HTTP request duration in seconds",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"code", "method", "endpoint"},
-	)
-)
+// recordToolExecution counts an EventToolExecuted event in metrics.ToolExecutionsTotal,
+// with the call's trace ID as the exemplar.
+func recordToolExecution(event Event) {
+	tool, _ := event.Data["tool"].(string)
+	tenant, _ := event.Data["tenant"].(string)
+	traceID, _ := event.Data["traceId"].(string)
+	status := "success"
+	if success, _ := event.Data["success"].(bool); !success {
+		status = "error"
+	}
+	metrics.Add(metrics.ToolExecutionsTotal.WithLabelValues(tool, status, tenant), 1, traceID)
+}
 
 // HTTPServer handles HTTP API requests
 type HTTPServer struct {
-	toolService *ToolService
-	port        int
-	server      *http.Server
-	logger      *slog.Logger
+	toolService   *ToolService
+	port          int
+	server        *http.Server
+	logger        *slog.Logger
+	health        *HealthState
+	logLevel      *slog.LevelVar
+	config        *config.ServerConfig
+	registrable   map[string]bool // Declarative tool types /admin/tools/register accepts
+	approvalKey   string          // Key for the signatures of approval callbacks; empty disables them
+	events        *SSEManager
+	publishEvents sync.Once       // Starts publishing server events to events
+	streams       context.Context // Canceled on shutdown to end open event streams
+	options       serverOptions
 }
 
-// NewHTTPServer creates a new HTTP server
-func NewHTTPServer(toolService *ToolService, port int, logger *slog.Logger) *HTTPServer {
+// NewHTTPServer creates a new HTTP server, listening on port 8080 unless WithPort is given
+func NewHTTPServer(toolService *ToolService, opts ...ServerOption) *HTTPServer {
+	options := newServerOptions(8080, opts)
 	mux := http.NewServeMux()
 	httpServer := &HTTPServer{
 		toolService: toolService,
-		port:        port,
-		server: &http.Server{
-			Addr:    fmt.Sprintf(":%d", port),
-			Handler: mux,
-		},
-		logger: logger,
+		port:        options.port,
+		server:      options.httpServer(nil),
+		logger:      options.logger,
+		health:      NewHealthState(0),
+		events:      NewSSEManager(options.logger),
+		options:     options,
 	}
 
-	if err := prometheus.Register(requestsTotal); err != nil {
-		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
-			panic(err)
-		}
-	}
-	if err := prometheus.Register(requestDuration); err != nil {
-		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
-			panic(err)
-		}
-	}
+	httpServer.events.SetKeepAlive(options.keepAlive.SSEInterval, options.keepAlive.SSERetry)
 
-	// Create API subrouter
-	apiMux := http.NewServeMux()
-	apiMux.HandleFunc("/uuid", httpServer.instrumentHandler("uuid", httpServer.handleUUID))
-	apiMux.HandleFunc("/list", httpServer.instrumentHandler("list", httpServer.handleList))
-	apiMux.Handle("/metrics", promhttp.Handler())
+	streams, closeStreams := context.WithCancel(context.Background())
+	httpServer.streams = streams
+	httpServer.server.RegisterOnShutdown(closeStreams)
 
-	// Mount API subrouter under /api/
-	mux.Handle("/api/", http.StripPrefix("/api", apiMux))
+	// Routes are scoped by method, so the mux answers other methods with 405
+	httpServer.handleAPI(mux, "GET", "/uuid", httpServer.instrumentHandler("uuid", httpServer.negotiateEncoding(httpServer.handleUUID)))
+	httpServer.handleAPI(mux, "GET", "/list", httpServer.instrumentHandler("list", httpServer.negotiateEncoding(httpServer.handleList)))
+	httpServer.handleAPI(mux, "GET", "/tools", httpServer.instrumentHandler("search", httpServer.negotiateEncoding(httpServer.handleSearch)))
+	httpServer.handleAPI(mux, "POST", "/tools/{name}", httpServer.instrumentHandler("tools", httpServer.requireJSONBody(httpServer.handleToolCall)))
+	httpServer.handleAPI(mux, "POST", "/tools/{name}/stream", httpServer.instrumentHandler("tools", httpServer.requireJSONBody(httpServer.handleToolStream)))
+	httpServer.handleAPI(mux, "POST", "/jobs", httpServer.instrumentHandler("jobs", httpServer.negotiateEncoding(httpServer.handleJobStart)))
+	httpServer.handleAPI(mux, "GET", "/jobs/{id}", httpServer.instrumentHandler("jobs", httpServer.negotiateEncoding(httpServer.handleJobStatus)))
+	httpServer.handleAPI(mux, "GET", "/jobs/{id}/events", httpServer.instrumentHandler("jobs", httpServer.handleJobEvents))
+	httpServer.handleAPI(mux, "GET", "/metrics", metrics.Handler())
 
-	// Register other routes
-	mux.HandleFunc("/health", httpServer.handleHealth)
-	mux.HandleFunc("/", httpServer.handleIndex)
+	mux.HandleFunc("GET /health", httpServer.handleHealth)
+	mux.HandleFunc("GET /livez", httpServer.handleLivez)
+	mux.HandleFunc("GET /readyz", httpServer.handleReadyz)
+	mux.HandleFunc("POST /approvals/{id}", httpServer.handleApprovalCallback)
+	httpServer.registerAdminRoutes(mux)
+	mux.HandleFunc("GET /{$}", httpServer.handleIndex)
+
+	httpServer.server.Handler = options.wrap(httpServer.routeErrors(mux))
 
 	return httpServer
 }
 
-// instrumentHandler wraps a handler with Prometheus metrics instrumentation
+// Handler returns the server's HTTP handler with all routes mounted.
+func (s *HTTPServer) Handler() http.Handler {
+	return s.server.Handler
+}
+
+// SetLogLevel connects the admin log level endpoint to the server's logger level.
+func (s *HTTPServer) SetLogLevel(level *slog.LevelVar) {
+	s.logLevel = level
+}
+
+// SetConfig connects the admin config endpoint to the server's configuration.
+func (s *HTTPServer) SetConfig(cfg *config.ServerConfig) {
+	s.config = cfg
+}
+
+// SetToolRegistration enables POST /admin/tools/register for the given declarative
+// tool types: exec, http, or template.
+func (s *HTTPServer) SetToolRegistration(types []string) {
+	s.registrable = make(map[string]bool, len(types))
+	for _, toolType := range types {
+		s.registrable[strings.TrimSpace(toolType)] = true
+	}
+}
+
+// SetApprovalCallbackSecret enables POST /approvals/{id}, through which a webhook receiver
+// decides on a call waiting for approval, for requests signed with secret.
+func (s *HTTPServer) SetApprovalCallbackSecret(secret string) {
+	s.approvalKey = secret
+}
+
+// instrumentHandler wraps a handler with Prometheus metrics instrumentation, attaching the
+// request's trace ID as an exemplar
 func (s *HTTPServer) instrumentHandler(endpoint string, handler http.HandlerFunc) http.HandlerFunc {
+	exemplar := promhttp.WithExemplarFromContext(metrics.Exemplar)
 	return promhttp.InstrumentHandlerDuration(
-		requestDuration.MustCurryWith(prometheus.Labels{"endpoint": endpoint}),
+		metrics.HTTPRequestDuration.MustCurryWith(prometheus.Labels{"endpoint": endpoint}),
 		promhttp.InstrumentHandlerCounter(
-			requestsTotal.MustCurryWith(prometheus.Labels{"endpoint": endpoint}),
+			metrics.HTTPRequestsTotal.MustCurryWith(prometheus.Labels{"endpoint": endpoint}),
 			handler,
+			exemplar,
 		),
+		exemplar,
 	)
 }
 
+// Listen binds the server's listener so it accepts connections before Start is called.
+// Start binds it if Listen was not called.
+func (s *HTTPServer) Listen() error {
+	_, err := s.options.bind()
+	return err
+}
+
+// Addr returns the address the server is bound to, or its configured address before
+// Listen.
+func (s *HTTPServer) Addr() string {
+	return s.options.boundAddr()
+}
+
+// TLS reports whether the server serves HTTPS.
+func (s *HTTPServer) TLS() bool {
+	return s.options.certFile != ""
+}
+
 // Start begins the HTTP server
 func (s *HTTPServer) Start() error {
-	s.logger.Info("Starting HTTP server", "port", s.port)
-	return s.server.ListenAndServe()
+	s.logger.Info("Starting HTTP server", "address", s.Addr(), "tls", s.TLS())
+	return s.options.listenAndServe(s.server)
 }
 
 // Stop gracefully shuts down the HTTP server
@@ -102,74 +171,281 @@ func (s *HTTPServer) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
-// handleUUID handles GET /api/uuid requests
+// handleUUID handles GET /api/uuid requests. A call held for approval is answered with
+// 202 Accepted and the held job.
 func (s *HTTPServer) handleUUID(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.logger.Warn("Method not allowed", "method", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
-	result, err := s.toolService.ExecuteTool("generate_uuid", nil)
+	result, err := s.toolService.ExecuteToolContext(r.Context(), "generate_uuid", nil)
 	if err != nil {
 		s.logger.Error("Failed to execute generate_uuid tool", "error", err)
-		http.Error(w, "Failed to generate UUID", http.StatusInternalServerError)
+		s.writeError(w, r, http.StatusInternalServerError, errCodeToolFailed, "Failed to generate UUID")
+		return
+	}
+	if s.writeHeldJob(w, r, result) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(map[string]string{
-		"uuid": result["uuid"].(string),
-	}); err != nil {
-		s.logger.Error("Failed to encode JSON response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	id, ok := result["uuid"].(string)
+	if !ok {
+		s.logger.Error("generate_uuid returned no UUID", "result", s.toolService.LogPayload(result))
+		s.writeError(w, r, http.StatusInternalServerError, errCodeInvalidResult, "Failed to generate UUID")
 		return
 	}
+	s.writeEncoded(w, r, http.StatusOK, map[string]string{"uuid": id})
+}
+
+// toolListEntry is a tool in the detailed /api/list response.
+type toolListEntry struct {
+	Description       string   `json:"description"`
+	Category          string   `json:"category,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	Version           string   `json:"version,omitempty"`
+	Deprecated        bool     `json:"deprecated,omitempty"`
+	DeprecationNotice string   `json:"deprecationNotice,omitempty"`
 }
 
-// handleList handles GET /api/list requests
+// handleList handles GET /api/list requests. The "category" and "tag" query parameters
+// filter the tools, and "details=true" includes each tool's category, tags, version, and
+// deprecation. When tool lists are paged, the "cursor" parameter selects the page after
+// it, and pages other than the last link to the next with a Link header.
 func (s *HTTPServer) handleList(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.logger.Warn("Method not allowed", "method", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	query := r.URL.Query()
+	filter := ToolFilter{Category: query.Get("category"), Tag: query.Get("tag"), Tenant: TenantFromContext(r.Context())}
+	details := query.Get("details") == "true"
+	page, next, err := s.toolService.ListPage(filter, query.Get("cursor"))
+	if err != nil {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, err.Error())
 		return
 	}
+	if next != "" {
+		query.Set("cursor", next)
+		// Added rather than set, since deprecated routes link to their successor
+		w.Header().Add("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(s.toolService.ListTools()); err != nil {
-		s.logger.Error("Failed to encode JSON response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+	var response interface{}
+	if details {
+		entries := make(map[string]toolListEntry)
+		for _, listed := range page {
+			category, tags := toolMetadata(listed.Tool)
+			notice := deprecation(listed.Tool)
+			entries[listed.Name] = toolListEntry{
+				Description:       listed.Tool.Description(),
+				Category:          category,
+				Tags:              tags,
+				Version:           listed.Version(),
+				Deprecated:        notice != "",
+				DeprecationNotice: notice,
+			}
+		}
+		response = entries
+	} else {
+		descriptions := make(map[string]string)
+		for _, listed := range page {
+			descriptions[listed.Name] = listed.Tool.Description()
+		}
+		response = descriptions
+	}
+
+	s.writeEncoded(w, r, http.StatusOK, response)
+}
+
+// handleSearch handles GET /api/tools requests, returning the tools whose name,
+// description, or tags match the "query" parameter, most relevant first. The "tag" and
+// "category" parameters filter the tools, and "limit" caps how many are returned.
+func (s *HTTPServer) handleSearch(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := ToolFilter{Category: query.Get("category"), Tag: query.Get("tag"), Tenant: TenantFromContext(r.Context())}
+	matches := s.toolService.SearchTools(filter, query.Get("query"))
+	total := len(matches)
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "limit must be a positive integer")
+			return
+		}
+		if len(matches) > limit {
+			matches = matches[:limit]
+		}
+	}
+	s.writeEncoded(w, r, http.StatusOK, map[string]interface{}{
+		"tools": s.toolService.searchResults(matches),
+		"total": total,
+	})
+}
+
+// handleToolCall handles POST /api/tools/{name} requests. The optional JSON body is passed
+// to the tool as its arguments, and a call held for approval is answered with 202 Accepted
+// and the held job. When the "download" query parameter names a binary field
+// of the result, that field is returned as a file download instead of JSON. A result of
+// one content block is returned in the block's own media type when Accept allows it, and
+// other results as JSON, CBOR, or MessagePack, as Accept prefers.
+func (s *HTTPServer) handleToolCall(w http.ResponseWriter, r *http.Request) {
+	name, args, ok := s.toolCallArgs(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := s.toolService.ExecuteToolContext(r.Context(), name, args)
+	if err != nil {
+		status, code, message := s.toolCallError(r, name, err)
+		s.writeError(w, r, status, code, message)
+		return
+	}
+	if s.writeHeldJob(w, r, result) {
+		return
+	}
+
+	if field := r.URL.Query().Get("download"); field != "" {
+		attachment, ok := findAttachment(result, field)
+		if !ok {
+			s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("No binary field named %q in result", field))
+			return
+		}
+		w.Header().Set("Content-Type", attachment.ContentType())
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+		w.Header().Set("Content-Length", fmt.Sprint(len(attachment.Data)))
+		if _, err := w.Write(attachment.Data); err != nil {
+			loggerFor(r.Context(), s.logger).Error("Failed to write attachment", "tool", name, "error", err)
+		}
 		return
 	}
+
+	accept := r.Header.Get("Accept")
+	if blocks, ok := tools.ResultContent(result); ok && len(blocks) == 1 && blocks[0].Type != tools.ContentResourceLink && accepts(accept, blocks[0].ContentType()) {
+		s.writeContent(w, r, blocks[0])
+		return
+	}
+	if !acceptsEncoded(accept) {
+		s.writeError(w, r, http.StatusNotAcceptable, errCodeNotAcceptable, "The result is only available as application/json, application/cbor, or application/msgpack")
+		return
+	}
+
+	s.writeEncoded(w, r, http.StatusOK, s.toolService.Results().Limit(normalizeToolResult(result)))
+}
+
+// toolCallArgs returns the name of the tool a tool call request is for and the arguments
+// in its optional JSON body. It writes the error response and returns false when the tool
+// does not exist or the body cannot be decoded.
+func (s *HTTPServer) toolCallArgs(w http.ResponseWriter, r *http.Request) (string, map[string]interface{}, bool) {
+	name := r.PathValue("name")
+	if resolved, exists := s.toolService.Resolve(name); !exists || !TenantFromContext(r.Context()).Allows(resolved) {
+		s.writeError(w, r, http.StatusNotFound, errCodeToolNotFound, fmt.Sprintf("Tool not found: %s", name))
+		return "", nil, false
+	}
+
+	var args map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil && !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.writeError(w, r, http.StatusRequestEntityTooLarge, errCodeRequestTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+			return "", nil, false
+		}
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return "", nil, false
+	}
+	return name, args, true
+}
+
+// toolCallError returns the status, error code, and message a failed tool call is
+// reported with. Failures of the tool itself are logged, and their details kept from the
+// client.
+func (s *HTTPServer) toolCallError(r *http.Request, name string, err error) (int, string, string) {
+	switch {
+	case errors.Is(err, ErrToolDisabled) || errors.Is(err, ErrCircuitOpen):
+		return http.StatusServiceUnavailable, errCodeToolUnavailable, err.Error()
+	case errors.Is(err, ErrRateLimited) || errors.Is(err, ErrTooManyApprovals):
+		return http.StatusTooManyRequests, errCodeRateLimited, err.Error()
+	case errors.Is(err, tools.ErrQuotaExceeded):
+		return http.StatusUnprocessableEntity, errCodeQuotaExceeded, err.Error()
+	case errors.Is(err, policy.ErrDenied) || errors.Is(err, ErrApprovalDenied):
+		return http.StatusForbidden, errCodeForbidden, err.Error()
+	case errors.Is(err, tools.ErrInvalidArguments):
+		return http.StatusBadRequest, errCodeInvalidArguments, err.Error()
+	case errors.Is(err, tools.ErrInvalidResult):
+		return http.StatusInternalServerError, errCodeInvalidResult, err.Error()
+	default:
+		loggerFor(r.Context(), s.logger).Error("Failed to execute tool", "tool", name, "error", err)
+		return http.StatusInternalServerError, errCodeToolFailed, "Tool execution failed"
+	}
+}
+
+// writeContent writes a content block in its own media type: text as text/plain, a JSON
+// value as itself, and images and files as their bytes.
+func (s *HTTPServer) writeContent(w http.ResponseWriter, r *http.Request, content tools.Content) {
+	var body []byte
+	switch content.Type {
+	case tools.ContentText:
+		body = []byte(content.Text)
+	case tools.ContentJSON:
+		encoded, err := json.Marshal(content.Value)
+		if err != nil {
+			s.writeError(w, r, http.StatusInternalServerError, errCodeToolFailed, "Tool returned an unencodable JSON value")
+			return
+		}
+		body = append(encoded, '\n')
+	default:
+		body = content.Data
+		if content.Name != "" {
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": content.Name}))
+		}
+	}
+	w.Header().Set("Content-Type", content.ContentType())
+	w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+	if _, err := w.Write(body); err != nil {
+		loggerFor(r.Context(), s.logger).Error("Failed to write content", "error", err)
+	}
 }
 
 // handleHealth handles GET /health requests
 func (s *HTTPServer) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.logger.Warn("Method not allowed", "method", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(map[string]string{
+		"status": "healthy",
+	}); err != nil {
+		s.logger.Error("Failed to encode JSON response", "error", err)
 	}
+}
 
+// handleLivez handles GET /livez requests. Liveness only reports that the process is serving.
+func (s *HTTPServer) handleLivez(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(map[string]string{
-		"status": "healthy",
+		"status": "alive",
 	}); err != nil {
 		s.logger.Error("Failed to encode JSON response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
 	}
 }
 
-// handleIndex handles GET / requests
-func (s *HTTPServer) handleIndex(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		s.logger.Warn("Method not allowed", "method", r.Method)
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
+// handleReadyz handles GET /readyz requests. It returns 503 while the server is starting,
+// draining for shutdown, manually disabled, or overloaded, so load balancers route around
+// it. Tools failing their health check are listed but do not fail readiness, since the
+// server still serves its other tools.
+func (s *HTTPServer) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{"status": "ready"}
+	status := http.StatusOK
+	if ready, reason := s.health.Ready(); !ready {
+		response = map[string]interface{}{"status": "not ready", "reason": reason}
+		status = http.StatusServiceUnavailable
+	} else if overloaded, reason := s.toolService.Overload().Overloaded(); overloaded {
+		response = map[string]interface{}{"status": "not ready", "reason": "overloaded: " + reason}
+		status = http.StatusServiceUnavailable
+		s.toolService.Overload().setRetryAfter(w)
+	}
+	if unhealthy := s.toolService.Health().Unhealthy(); len(unhealthy) > 0 {
+		response["unhealthyTools"] = unhealthy
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		s.logger.Error("Failed to encode JSON response", "error", err)
+	}
+}
+
+// handleIndex handles GET / requests
+func (s *HTTPServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
 		"service":   "MCP Tools Server",
 		"version":   version.GetVersion(),
@@ -182,7 +458,5 @@ func (s *HTTPServer) handleIndex(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		s.logger.Error("Failed to encode JSON response", "error", err)
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return
 	}
 }
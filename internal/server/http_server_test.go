@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"mcp-tools-server/internal/codec"
 	"mcp-tools-server/pkg/tools"
 )
 
@@ -20,7 +21,7 @@ func setupTestServer() (*HTTPServer, *ToolService) {
 	if err != nil {
 		panic(fmt.Sprintf("Failed to create tool service: %v", err))
 	}
-	httpServer := NewHTTPServer(toolService, 8080, logger)
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
 	return httpServer, toolService
 }
 
@@ -63,7 +64,7 @@ func TestHTTPServer_handleIndex(t *testing.T) {
 		req := httptest.NewRequest("POST", "/", nil)
 		w := httptest.NewRecorder()
 
-		httpServer.handleIndex(w, req)
+		httpServer.Handler().ServeHTTP(w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d", w.Code)
@@ -103,7 +104,7 @@ func TestHTTPServer_handleHealth(t *testing.T) {
 		req := httptest.NewRequest("POST", "/health", nil)
 		w := httptest.NewRecorder()
 
-		httpServer.handleHealth(w, req)
+		httpServer.Handler().ServeHTTP(w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d", w.Code)
@@ -154,7 +155,7 @@ func TestHTTPServer_handleUUID(t *testing.T) {
 		req := httptest.NewRequest("POST", "/api/uuid", nil)
 		w := httptest.NewRecorder()
 
-		httpServer.handleUUID(w, req)
+		httpServer.Handler().ServeHTTP(w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d", w.Code)
@@ -164,11 +165,8 @@ func TestHTTPServer_handleUUID(t *testing.T) {
 	t.Run("handles missing UUID tool", func(t *testing.T) {
 		// Create a tool service with no tools
 		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
-		toolService := &ToolService{
-			tools:  make(map[string]tools.Tool),
-			logger: logger,
-		}
-		httpServer := NewHTTPServer(toolService, 8080, logger)
+		toolService := newTestToolService(logger)
+		httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
 
 		req := httptest.NewRequest("GET", "/api/uuid", nil)
 		w := httptest.NewRecorder()
@@ -179,10 +177,13 @@ func TestHTTPServer_handleUUID(t *testing.T) {
 			t.Errorf("Expected status 500, got %d", w.Code)
 		}
 
-		body := strings.TrimSpace(w.Body.String())
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal error response: %v", err)
+		}
 		expectedError := "Failed to generate UUID"
-		if body != expectedError {
-			t.Errorf("Expected error '%s', got '%s'", expectedError, body)
+		if response.Error.Code != "tool_failed" || response.Error.Message != expectedError {
+			t.Errorf("Expected tool_failed error '%s', got %+v", expectedError, response.Error)
 		}
 	})
 
@@ -197,13 +198,8 @@ func TestHTTPServer_handleUUID(t *testing.T) {
 			},
 		}
 
-		toolService := &ToolService{
-			tools: map[string]tools.Tool{
-				"generate_uuid": mockTool,
-			},
-			logger: logger,
-		}
-		httpServer := NewHTTPServer(toolService, 8080, logger)
+		toolService := newTestToolService(logger, mockTool)
+		httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
 
 		req := httptest.NewRequest("GET", "/api/uuid", nil)
 		w := httptest.NewRecorder()
@@ -214,10 +210,13 @@ func TestHTTPServer_handleUUID(t *testing.T) {
 			t.Errorf("Expected status 500, got %d", w.Code)
 		}
 
-		body := strings.TrimSpace(w.Body.String())
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal error response: %v", err)
+		}
 		expectedError := "Failed to generate UUID"
-		if body != expectedError {
-			t.Errorf("Expected error '%s', got '%s'", expectedError, body)
+		if response.Error.Code != "tool_failed" || response.Error.Message != expectedError {
+			t.Errorf("Expected tool_failed error '%s', got %+v", expectedError, response.Error)
 		}
 	})
 }
@@ -256,11 +255,45 @@ func TestHTTPServer_handleList(t *testing.T) {
 		}
 	})
 
+	t.Run("category filter excludes other tools", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/list?category=no-such-category", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.handleList(w, req)
+
+		var response map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(response) != 0 {
+			t.Errorf("Expected no tools, got %v", response)
+		}
+	})
+
+	t.Run("details include category and tags", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/list?details=true&tag=uuid", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.handleList(w, req)
+
+		var response map[string]toolListEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		entry, ok := response["generate_uuid"]
+		if !ok {
+			t.Fatalf("Expected generate_uuid in response, got %v", response)
+		}
+		if entry.Category != "generators" || len(entry.Tags) == 0 {
+			t.Errorf("Expected category and tags, got %+v", entry)
+		}
+	})
+
 	t.Run("POST request returns method not allowed", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/api/list", nil)
 		w := httptest.NewRecorder()
 
-		httpServer.handleList(w, req)
+		httpServer.Handler().ServeHTTP(w, req)
 
 		if w.Code != http.StatusMethodNotAllowed {
 			t.Errorf("Expected status 405, got %d", w.Code)
@@ -273,7 +306,7 @@ func TestNewHTTPServer(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	toolService, _ := NewToolService(registry, logger)
 
-	httpServer := NewHTTPServer(toolService, 8080, logger)
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
 
 	if httpServer == nil {
 		t.Fatal("NewHTTPServer returned nil")
@@ -304,9 +337,9 @@ func TestHTTPServer_Routes(t *testing.T) {
 		{"/health", http.StatusOK},
 		{"/api/uuid", http.StatusOK},
 		{"/api/list", http.StatusOK},
-		// Note: The current implementation doesn't have a 404 handler,
-		// so unknown routes fall through to the root handler
-		{"/nonexistent", http.StatusOK}, // This actually gets handled by the root handler
+		{"/api/metrics", http.StatusOK},
+		{"/nonexistent", http.StatusNotFound},
+		{"/api/nonexistent", http.StatusNotFound},
 	}
 
 	for _, tc := range testCases {
@@ -320,3 +353,457 @@ func TestHTTPServer_Routes(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPServer_MetricsExemplars(t *testing.T) {
+	httpServer, _ := setupTestServer()
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	req := httptest.NewRequest("GET", "/api/v1/uuid", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	httpServer.Handler().ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest("GET", "/api/v1/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	w := httptest.NewRecorder()
+	httpServer.Handler().ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if !strings.Contains(body, `mcp_tools_http_requests_total{code="200",endpoint="uuid",method="get"}`) {
+		t.Errorf("Expected namespaced HTTP request metrics, got:\n%s", body)
+	}
+	if !strings.Contains(body, `# {trace_id="`+traceID+`"}`) {
+		t.Errorf("Expected an exemplar with the request's trace ID, got:\n%s", body)
+	}
+}
+
+func TestHTTPServer_RouteErrors(t *testing.T) {
+	httpServer, _ := setupTestServer()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest(method, target, nil))
+		return w
+	}
+
+	t.Run("unknown path returns JSON 404", func(t *testing.T) {
+		w := serve("GET", "/nonexistent")
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("Expected status 404, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %s", ct)
+		}
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error.Code != "not_found" {
+			t.Errorf("Expected JSON error body, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("wrong method returns JSON 405 with Allow", func(t *testing.T) {
+		w := serve("DELETE", "/api/uuid")
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Fatalf("Expected status 405, got %d", w.Code)
+		}
+		if allow := w.Header().Get("Allow"); !strings.Contains(allow, "GET") {
+			t.Errorf("Expected Allow header to list GET, got %q", allow)
+		}
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error.Code != "method_not_allowed" {
+			t.Errorf("Expected JSON error body, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("HEAD is served by GET routes", func(t *testing.T) {
+		if w := serve("HEAD", "/health"); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+}
+
+func TestHTTPServer_ErrorEnvelope(t *testing.T) {
+	httpServer, _ := setupTestServer()
+
+	serve := func(req *http.Request) (*httptest.ResponseRecorder, errorResponse) {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal error response %q: %v", w.Body.String(), err)
+		}
+		return w, response
+	}
+
+	t.Run("includes code, message, and request ID", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/missing", nil)
+		req.Header.Set(requestIDHeader, "envelope-1")
+		w, response := serve(req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+		if response.Error.Code != errCodeToolNotFound || response.Error.Message == "" {
+			t.Errorf("Unexpected error: %+v", response.Error)
+		}
+		if response.Error.RequestID != "envelope-1" {
+			t.Errorf("Expected request ID envelope-1, got %q", response.Error.RequestID)
+		}
+	})
+
+	t.Run("malformed body returns invalid_request", func(t *testing.T) {
+		w, response := serve(httptest.NewRequest("POST", "/api/tools/generate_uuid", strings.NewReader("{")))
+		if w.Code != http.StatusBadRequest || response.Error.Code != errCodeInvalidRequest {
+			t.Errorf("Expected 400 invalid_request, got %d %+v", w.Code, response.Error)
+		}
+	})
+
+	t.Run("Accept without JSON returns 406", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/list", nil)
+		req.Header.Set("Accept", "text/html")
+		w, response := serve(req)
+		if w.Code != http.StatusNotAcceptable || response.Error.Code != errCodeNotAcceptable {
+			t.Errorf("Expected 406 not_acceptable, got %d %+v", w.Code, response.Error)
+		}
+	})
+
+	t.Run("non-JSON body returns 415", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/generate_uuid", strings.NewReader("version=v7"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w, response := serve(req)
+		if w.Code != http.StatusUnsupportedMediaType || response.Error.Code != errCodeUnsupportedMediaType {
+			t.Errorf("Expected 415 unsupported_media_type, got %d %+v", w.Code, response.Error)
+		}
+	})
+}
+
+func TestAcceptsJSON(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected bool
+	}{
+		{"", true},
+		{"application/json", true},
+		{"text/html, application/json;q=0.9", true},
+		{"*/*", true},
+		{"application/*", true},
+		{"text/html", false},
+		{"application/json;q=0", false},
+	}
+
+	for _, tc := range testCases {
+		if got := acceptsJSON(tc.accept); got != tc.expected {
+			t.Errorf("acceptsJSON(%q) = %v, expected %v", tc.accept, got, tc.expected)
+		}
+	}
+
+	if !accepts("image/*", "image/png") || !accepts("text/plain", "text/plain; charset=utf-8") || accepts("image/jpeg", "image/png") {
+		t.Error("Expected accepts to match media types, wildcards, and parameters")
+	}
+}
+
+func TestResponseCodec(t *testing.T) {
+	testCases := []struct {
+		accept   string
+		expected codec.Codec
+	}{
+		{"", nil},
+		{"*/*", nil},
+		{"application/json", nil},
+		{"application/cbor", codec.CBOR},
+		{"application/msgpack", codec.MessagePack},
+		{"application/x-msgpack", codec.MessagePack},
+		{"application/msgpack, application/json", nil},
+		{"application/msgpack, application/json;q=0.5", codec.MessagePack},
+		{"application/cbor;q=0.8, */*;q=0.1", codec.CBOR},
+		{"application/cbor;q=0", nil},
+		{"text/html", nil},
+	}
+
+	for _, tc := range testCases {
+		if got := responseCodec(tc.accept); got != tc.expected {
+			t.Errorf("responseCodec(%q) = %v, expected %v", tc.accept, got, tc.expected)
+		}
+	}
+}
+
+func TestHTTPServer_ResponseEncoding(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tool := &MockTool{
+		name: "stats",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"count": 3, "mean": 1.5}, nil
+		},
+	}
+	httpServer := NewHTTPServer(newTestToolService(logger, tool), WithPort(8080), WithLogger(logger))
+
+	for _, c := range []codec.Codec{codec.CBOR, codec.MessagePack} {
+		t.Run("encodes tool results as "+c.Name(), func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/api/tools/stats", nil)
+			req.Header.Set("Accept", c.ContentType())
+			w := httptest.NewRecorder()
+
+			httpServer.server.Handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			if ct := w.Header().Get("Content-Type"); ct != c.ContentType() {
+				t.Errorf("Expected Content-Type %s, got %s", c.ContentType(), ct)
+			}
+			if vary := w.Header().Get("Vary"); vary != "Accept" {
+				t.Errorf("Expected Vary: Accept, got %q", vary)
+			}
+			decoded, err := c.Unmarshal(w.Body.Bytes())
+			if err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			result := decoded.(map[string]interface{})
+			if result["count"] != int64(3) || result["mean"] != 1.5 {
+				t.Errorf("Unexpected result: %v", result)
+			}
+		})
+	}
+
+	t.Run("keeps JSON as the default", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/list", nil)
+		req.Header.Set("Accept", "application/msgpack, application/json")
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Expected Content-Type application/json, got %s", ct)
+		}
+	})
+
+	t.Run("encodes other endpoints", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/list", nil)
+		req.Header.Set("Accept", "application/cbor")
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/cbor" {
+			t.Fatalf("Expected a CBOR response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		decoded, err := codec.CBOR.Unmarshal(w.Body.Bytes())
+		if err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if _, ok := decoded.(map[string]interface{})["stats"]; !ok {
+			t.Errorf("Expected the tool list, got %v", decoded)
+		}
+	})
+
+	t.Run("rejects other media types", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/stats", nil)
+		req.Header.Set("Accept", "application/xml")
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got %d", w.Code)
+		}
+	})
+}
+
+func TestHTTPServer_handleToolCall(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	binaryTool := &MockTool{
+		name:        "make_report",
+		description: "Returns a binary report",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{
+				"title": args["title"],
+				"report": tools.Attachment{
+					Filename: "report.pdf",
+					MIMEType: "application/pdf",
+					Data:     []byte("%PDF-1.4"),
+				},
+			}, nil
+		},
+	}
+	toolService := newTestToolService(logger, binaryTool)
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	t.Run("returns normalized JSON by default", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/make_report", strings.NewReader(`{"title":"Q3"}`))
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["title"] != "Q3" {
+			t.Errorf("Expected title 'Q3', got %v", response["title"])
+		}
+		report, ok := response["report"].(map[string]interface{})
+		if !ok || report["type"] != "resource" {
+			t.Errorf("Expected report to be a resource content block, got %v", response["report"])
+		}
+	})
+
+	t.Run("downloads binary field with Content-Disposition", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/make_report?download=report", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "application/pdf" {
+			t.Errorf("Expected Content-Type application/pdf, got %s", ct)
+		}
+		if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename=report.pdf` {
+			t.Errorf("Unexpected Content-Disposition: %s", cd)
+		}
+		if w.Body.String() != "%PDF-1.4" {
+			t.Errorf("Unexpected body: %q", w.Body.String())
+		}
+	})
+
+	t.Run("download of non-binary field returns not found", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/make_report?download=title", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("unknown tool returns not found", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/tools/nonexistent", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("returns a single content block in its media type", func(t *testing.T) {
+		service := newTestToolService(logger,
+			&MockTool{name: "render", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				return tools.ContentResult(tools.ImageContent([]byte("\x89PNG\r\n\x1a\n"), "image/png")), nil
+			}},
+			&MockTool{name: "greet", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				return tools.ContentResult(tools.TextContent("hello")), nil
+			}},
+		)
+		server := NewHTTPServer(service, WithPort(8080), WithLogger(logger))
+		call := func(tool, accept string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/api/tools/"+tool, nil)
+			if accept != "" {
+				req.Header.Set("Accept", accept)
+			}
+			w := httptest.NewRecorder()
+			server.Handler().ServeHTTP(w, req)
+			return w
+		}
+
+		if w := call("render", "image/*"); w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" || w.Body.String() != "\x89PNG\r\n\x1a\n" {
+			t.Errorf("Expected raw PNG, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		if w := call("greet", ""); w.Header().Get("Content-Type") != "text/plain; charset=utf-8" || w.Body.String() != "hello" {
+			t.Errorf("Expected plain text, got %s %q", w.Header().Get("Content-Type"), w.Body.String())
+		}
+		if w := call("render", "application/json"); w.Header().Get("Content-Type") != "application/json" || !strings.Contains(w.Body.String(), `"type":"image"`) {
+			t.Errorf("Expected MCP content blocks as JSON, got %s", w.Body.String())
+		}
+		if w := call("greet", "image/png"); w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got %d", w.Code)
+		}
+	})
+
+	t.Run("GET request returns method not allowed", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/tools/make_report", nil)
+		w := httptest.NewRecorder()
+
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+func TestHTTPServer_Probes(t *testing.T) {
+	httpServer, _ := setupTestServer()
+
+	t.Run("livez always reports alive", func(t *testing.T) {
+		httpServer.health.StartDraining()
+		defer func() { httpServer.health = NewHealthState(0) }()
+
+		req := httptest.NewRequest("GET", "/livez", nil)
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("readyz reflects health state", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/readyz", nil)
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+
+		httpServer.health.StartDraining()
+		defer func() { httpServer.health = NewHealthState(0) }()
+
+		w = httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("Expected status 503, got %d", w.Code)
+		}
+		var response map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if response["reason"] != "draining" {
+			t.Errorf("Expected reason 'draining', got %s", response["reason"])
+		}
+	})
+
+	t.Run("admin toggle fails and restores readiness", func(t *testing.T) {
+		post := func(body string) int {
+			req := newAdminRequest("POST", "/admin/readiness", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			httpServer.server.Handler.ServeHTTP(w, req)
+			return w.Code
+		}
+		ready := func() int {
+			w := httptest.NewRecorder()
+			httpServer.server.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+			return w.Code
+		}
+
+		if code := post(`{"ready": false}`); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if code := ready(); code != http.StatusServiceUnavailable {
+			t.Errorf("Expected readyz 503 after manual fail, got %d", code)
+		}
+		if code := post(`{"ready": true}`); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if code := ready(); code != http.StatusOK {
+			t.Errorf("Expected readyz 200 after restore, got %d", code)
+		}
+		if code := post(`{}`); code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for missing field, got %d", code)
+		}
+	})
+}
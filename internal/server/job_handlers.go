@@ -0,0 +1,150 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// handleJobStart handles POST /api/jobs requests. The body
+// {"tool": "name", "arguments": {...}} names the tool to run in the background; the
+// response is 202 Accepted with the job, and Location points at its status. With an
+// Mcp-Session-Id header naming a Streamable HTTP session of the caller's tenant, the
+// finished job is also sent to that session; any other session ID gets 404. A tool that
+// needs approval is not started: the response is its held job instead. A tool that
+// needs approval is not started: the response is its held job instead.
+func (s *HTTPServer) handleJobStart(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Tool      string                 `json:"tool"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			s.writeError(w, r, http.StatusRequestEntityTooLarge, errCodeRequestTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "Failed to decode JSON body")
+		return
+	}
+	if body.Tool == "" {
+		s.writeError(w, r, http.StatusBadRequest, errCodeInvalidRequest, "tool is required")
+		return
+	}
+	if resolved, exists := s.toolService.Resolve(body.Tool); !exists || !TenantFromContext(r.Context()).Allows(resolved) {
+		s.writeError(w, r, http.StatusNotFound, errCodeToolNotFound, fmt.Sprintf("Tool not found: %s", body.Tool))
+		return
+	}
+
+	ctx := r.Context()
+	if id := r.Header.Get(sessionIDHeader); id != "" {
+		owned, err := s.toolService.Sessions().Owns(ctx, id)
+		if err != nil {
+			s.writeError(w, r, http.StatusServiceUnavailable, errCodeStoreUnavailable, "Failed to look up the session")
+			return
+		}
+		if !owned {
+			s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("Session not found: %s", id))
+			return
+		}
+		ctx = WithSessionID(ctx, id)
+	}
+	if resolved, _ := s.toolService.Resolve(body.Tool); s.toolService.Approvals().Requires(resolved) {
+		// The approval gate holds the call as a job of its own, which is the one to poll
+		result, err := s.toolService.ExecuteToolContext(ctx, body.Tool, body.Arguments)
+		if err != nil {
+			status, code, message := s.toolCallError(r, body.Tool, err)
+			s.writeError(w, r, status, code, message)
+			return
+		}
+		if !s.writeHeldJob(w, r, result) {
+			s.writeEncoded(w, r, http.StatusOK, normalizeToolResult(result))
+		}
+		return
+	}
+	job, err := s.toolService.Jobs().Start(ctx, body.Tool, body.Arguments, s.toolService.ExecuteToolAwaitingApproval)
+	if err != nil {
+		s.writeError(w, r, http.StatusTooManyRequests, errCodeRateLimited, err.Error())
+		return
+	}
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+job.ID)
+	s.writeEncoded(w, r, http.StatusAccepted, job)
+}
+
+// writeHeldJob answers a call the approval gate held with 202 Accepted and the held job,
+// whose status is polled at Location. It reports false, writing nothing, when result is
+// not the answer to a held call.
+func (s *HTTPServer) writeHeldJob(w http.ResponseWriter, r *http.Request, result map[string]interface{}) bool {
+	id, held := heldJobID(result)
+	if !held {
+		return false
+	}
+	job, _, ok := s.toolService.Jobs().Get(r.Context(), id)
+	if !ok {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("Job not found: %s", id))
+		return true
+	}
+	w.Header().Set("Location", "/api/"+apiVersion+"/jobs/"+id)
+	s.writeEncoded(w, r, http.StatusAccepted, job)
+	return true
+}
+
+// handleJobStatus handles GET /api/jobs/{id} requests, returning the job's status and,
+// once it has finished, its result or error.
+func (s *HTTPServer) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	job, _, ok := s.toolService.Jobs().Get(r.Context(), id)
+	if !ok {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("Job not found: %s", id))
+		return
+	}
+	s.writeEncoded(w, r, http.StatusOK, job)
+}
+
+// handleJobEvents handles GET /api/jobs/{id}/events requests. It streams a single
+// "job" Server-Sent Event with the finished job, waiting for it if it is still running,
+// and then closes the stream.
+func (s *HTTPServer) handleJobEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, r, http.StatusInternalServerError, errCodeNotImplemented, "Streaming is not supported")
+		return
+	}
+	id := r.PathValue("id")
+	_, done, ok := s.toolService.Jobs().Get(r.Context(), id)
+	if !ok {
+		s.writeError(w, r, http.StatusNotFound, errCodeNotFound, fmt.Sprintf("Job not found: %s", id))
+		return
+	}
+
+	// The job may outlast the server's write timeout, so lift the deadline for it.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	select {
+	case <-done:
+	case <-r.Context().Done():
+		return
+	case <-s.streams.Done():
+		return
+	}
+	job, _, ok := s.toolService.Jobs().Get(r.Context(), id)
+	if !ok {
+		return
+	}
+	data, err := json.Marshal(job)
+	if err != nil {
+		s.logger.Warn("Failed to encode job", "jobId", id, "error", err)
+		return
+	}
+	fmt.Fprintf(w, "event: job\ndata: %s\n\n", data)
+	flusher.Flush()
+}
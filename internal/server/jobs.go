@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrTooManyJobs is returned when starting a job while the maximum number are running.
+var ErrTooManyJobs = errors.New("too many running jobs")
+
+// errJobNotHeld is returned when releasing or denying a job that is not waiting for approval.
+var errJobNotHeld = errors.New("job is not waiting for approval")
+
+// JobStatus is the state of an asynchronous tool call.
+type JobStatus string
+
+// Job statuses.
+const (
+	JobPendingApproval JobStatus = "pending_approval"
+	JobRunning         JobStatus = "running"
+	JobSucceeded       JobStatus = "succeeded"
+	JobFailed          JobStatus = "failed"
+	JobDenied          JobStatus = "denied"
+)
+
+// jobFinishedMethod is the notification that delivers a finished job to the session that
+// started it.
+const jobFinishedMethod = "notifications/jobs/finished"
+
+// Default job limits.
+const (
+	defaultJobsMaxRunning = 16
+	defaultJobsRetention  = time.Hour
+)
+
+// Job is a tool call running in the background.
+type Job struct {
+	ID         string                 `json:"id"`
+	Tool       string                 `json:"tool"`
+	Status     JobStatus              `json:"status"`
+	Result     map[string]interface{} `json:"result,omitempty"`
+	Error      string                 `json:"error,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	FinishedAt *time.Time             `json:"finishedAt,omitempty"`
+	DurationMS int64                  `json:"durationMs,omitempty"`
+
+	tenant    string        // Tenant that started the job; only it may see the job
+	sessionID string        // Session sent the finished job; empty for held jobs, whose approval gate reports them
+	done      chan struct{} // Closed when the job finishes
+}
+
+// JobManager runs tool calls in the background for clients that cannot wait on one
+// request, such as tools that outlast HTTP timeouts. Finished jobs are kept for the
+// retention period so clients can collect their results, and a job started from an MCP
+// session is sent to it as a notifications/jobs/finished notification when it finishes.
+type JobManager struct {
+	mu         sync.Mutex
+	jobs       map[string]*Job
+	running    int
+	maxRunning int
+	retention  time.Duration
+	now        func() time.Time
+	events     *EventBus
+	sessions   *SessionManager
+	logger     *slog.Logger
+}
+
+// NewJobManager creates a JobManager with the default limits.
+func NewJobManager(events *EventBus, logger *slog.Logger) *JobManager {
+	return &JobManager{
+		jobs:       make(map[string]*Job),
+		maxRunning: defaultJobsMaxRunning,
+		retention:  defaultJobsRetention,
+		now:        time.Now,
+		events:     events,
+		logger:     logger,
+	}
+}
+
+// SetLimits sets how many jobs may run at once and how long finished jobs are kept.
+// Non-positive values keep the defaults.
+func (m *JobManager) SetLimits(maxRunning int, retention time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxRunning, m.retention = defaultJobsMaxRunning, defaultJobsRetention
+	if maxRunning > 0 {
+		m.maxRunning = maxRunning
+	}
+	if retention > 0 {
+		m.retention = retention
+	}
+}
+
+// Start runs the tool through handler in the background and returns the new job. The
+// call keeps ctx's values, such as the tenant and request ID, but not its cancellation,
+// so it continues after the request that started it ends.
+func (m *JobManager) Start(ctx context.Context, tool string, args map[string]interface{}, handler ToolHandler) (Job, error) {
+	m.mu.Lock()
+	m.prune()
+	if m.running >= m.maxRunning {
+		m.mu.Unlock()
+		return Job{}, ErrTooManyJobs
+	}
+	job := &Job{
+		ID:        uuid.NewString(),
+		Tool:      tool,
+		Status:    JobRunning,
+		CreatedAt: m.now(),
+		tenant:    tenantName(ctx),
+		sessionID: SessionIDFromContext(ctx),
+		done:      make(chan struct{}),
+	}
+	m.jobs[job.ID] = job
+	m.running++
+	snapshot := *job
+	m.mu.Unlock()
+
+	loggerFor(ctx, m.logger).Info("Job started", "jobId", job.ID, "tool", tool)
+	go m.run(context.WithoutCancel(ctx), job, args, handler)
+	return snapshot, nil
+}
+
+// Hold creates a job for a call to tool that waits, in JobPendingApproval, until Release
+// runs it or Deny ends it. Held jobs do not count against the running limit.
+func (m *JobManager) Hold(ctx context.Context, tool string) Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	job := &Job{
+		ID:        uuid.NewString(),
+		Tool:      tool,
+		Status:    JobPendingApproval,
+		CreatedAt: m.now(),
+		tenant:    tenantName(ctx),
+		done:      make(chan struct{}),
+	}
+	m.jobs[job.ID] = job
+	return *job
+}
+
+// Release runs a held job through handler in the background, like Start. Its duration
+// includes the time it was held.
+func (m *JobManager) Release(ctx context.Context, id string, args map[string]interface{}, handler ToolHandler) error {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok || job.Status != JobPendingApproval {
+		m.mu.Unlock()
+		return errJobNotHeld
+	}
+	if m.running >= m.maxRunning {
+		m.mu.Unlock()
+		return ErrTooManyJobs
+	}
+	job.Status = JobRunning
+	m.running++
+	m.mu.Unlock()
+
+	loggerFor(ctx, m.logger).Info("Job released", "jobId", job.ID, "tool", job.Tool)
+	go m.run(context.WithoutCancel(ctx), job, args, handler)
+	return nil
+}
+
+// Deny finishes a held job without running it, with reason as its error.
+func (m *JobManager) Deny(id, reason string) error {
+	m.mu.Lock()
+	job, ok := m.jobs[id]
+	if !ok || job.Status != JobPendingApproval {
+		m.mu.Unlock()
+		return errJobNotHeld
+	}
+	m.finish(job, JobDenied, nil, reason)
+	m.mu.Unlock()
+
+	m.logger.Info("Job denied", "jobId", job.ID, "tool", job.Tool, "reason", reason)
+	m.publishFinished(job)
+	return nil
+}
+
+// run executes a job and records its outcome.
+func (m *JobManager) run(ctx context.Context, job *Job, args map[string]interface{}, handler ToolHandler) {
+	result, err := handler(ctx, job.Tool, args)
+
+	m.mu.Lock()
+	if err != nil {
+		m.finish(job, JobFailed, nil, err.Error())
+	} else {
+		m.finish(job, JobSucceeded, normalizeToolResult(result), "")
+	}
+	m.running--
+	finished := *job
+	m.mu.Unlock()
+
+	loggerFor(ctx, m.logger).Info("Job finished", "jobId", job.ID, "tool", job.Tool, "status", finished.Status)
+	m.publishFinished(job)
+	if finished.sessionID != "" && m.sessions != nil {
+		if err := m.sessions.Send(finished.sessionID, jobFinishedMethod, finished); err != nil {
+			loggerFor(ctx, m.logger).Warn("Failed to deliver a finished job", "jobId", job.ID, "sessionID", finished.sessionID, "error", err)
+		}
+	}
+}
+
+// finish records a job's outcome. The caller holds m.mu.
+func (m *JobManager) finish(job *Job, status JobStatus, result map[string]interface{}, message string) {
+	finished := m.now()
+	job.FinishedAt = &finished
+	job.DurationMS = finished.Sub(job.CreatedAt).Milliseconds()
+	job.Status, job.Result, job.Error = status, result, message
+	close(job.done)
+}
+
+// publishFinished publishes an EventJobFinished event for a finished job.
+func (m *JobManager) publishFinished(job *Job) {
+	data := map[string]interface{}{"jobId": job.ID, "tool": job.Tool, "status": string(job.Status)}
+	if job.tenant != "" {
+		data["tenant"] = job.tenant
+	}
+	m.events.Publish(EventJobFinished, data)
+}
+
+// Get returns the job with the given ID if the tenant in ctx started it, along with a
+// channel closed when the job finishes.
+func (m *JobManager) Get(ctx context.Context, id string) (Job, <-chan struct{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	job, ok := m.jobs[id]
+	if !ok || job.tenant != tenantName(ctx) {
+		return Job{}, nil, false
+	}
+	return *job, job.done, true
+}
+
+// Await waits until the job with the given ID finishes, or ctx is done, and returns its
+// result. A failed job returns its error, and a denied one ErrApprovalDenied.
+func (m *JobManager) Await(ctx context.Context, id string) (map[string]interface{}, error) {
+	_, done, ok := m.Get(ctx, id)
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for job %s: %w", id, ctx.Err())
+	}
+	job, _, ok := m.Get(ctx, id)
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	switch job.Status {
+	case JobSucceeded:
+		return job.Result, nil
+	case JobDenied:
+		return nil, fmt.Errorf("tool %s: %w: %s", job.Tool, ErrApprovalDenied, job.Error)
+	default:
+		return nil, errors.New(job.Error)
+	}
+}
+
+// prune forgets finished jobs older than the retention period. The caller holds m.mu.
+func (m *JobManager) prune() {
+	cutoff := m.now().Add(-m.retention)
+	for id, job := range m.jobs {
+		if job.FinishedAt != nil && job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+		}
+	}
+}
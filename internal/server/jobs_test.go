@@ -0,0 +1,227 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJobManager(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("runs tools in the background", func(t *testing.T) {
+		release := make(chan struct{})
+		service := newTestToolService(logger, &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			<-release
+			return map[string]interface{}{"echo": args["text"]}, nil
+		}})
+		finished := make(chan Event, 1)
+		defer service.Events().Subscribe(func(event Event) { finished <- event }, EventJobFinished)()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		job, err := service.Jobs().Start(ctx, "slow", map[string]interface{}{"text": "hi"}, service.ExecuteToolContext)
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		cancel() // The job outlives the request that started it
+		if job.Status != JobRunning || job.ID == "" {
+			t.Fatalf("Expected a running job, got %+v", job)
+		}
+
+		close(release)
+		_, done, ok := service.Jobs().Get(context.Background(), job.ID)
+		if !ok {
+			t.Fatal("Expected the job to be found")
+		}
+		<-done
+		got, _, _ := service.Jobs().Get(context.Background(), job.ID)
+		if got.Status != JobSucceeded || got.Result["echo"] != "hi" || got.FinishedAt == nil {
+			t.Errorf("Expected a succeeded job with its result, got %+v", got)
+		}
+		select {
+		case event := <-finished:
+			if event.Data["jobId"] != job.ID || event.Data["status"] != "succeeded" {
+				t.Errorf("Unexpected event data: %v", event.Data)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected a job.finished event")
+		}
+	})
+
+	t.Run("records failures", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("upstream down")
+		}})
+		job, _ := service.Jobs().Start(context.Background(), "broken", nil, service.ExecuteToolContext)
+		_, done, _ := service.Jobs().Get(context.Background(), job.ID)
+		<-done
+		got, _, _ := service.Jobs().Get(context.Background(), job.ID)
+		if got.Status != JobFailed || got.Error != "upstream down" {
+			t.Errorf("Expected a failed job, got %+v", got)
+		}
+	})
+
+	t.Run("limits running jobs and keeps results for the retention period", func(t *testing.T) {
+		release := make(chan struct{})
+		service := newTestToolService(logger, &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			<-release
+			return map[string]interface{}{}, nil
+		}})
+		jobs := service.Jobs()
+		jobs.SetLimits(1, time.Minute)
+		now := time.Now()
+		jobs.now = func() time.Time { return now }
+
+		job, err := jobs.Start(context.Background(), "slow", nil, service.ExecuteToolContext)
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		if _, err := jobs.Start(context.Background(), "slow", nil, service.ExecuteToolContext); !errors.Is(err, ErrTooManyJobs) {
+			t.Errorf("Expected ErrTooManyJobs, got %v", err)
+		}
+		close(release)
+		_, done, _ := jobs.Get(context.Background(), job.ID)
+		<-done
+
+		now = now.Add(2 * time.Minute)
+		if _, _, ok := jobs.Get(context.Background(), job.ID); ok {
+			t.Error("Expected the finished job to be forgotten after the retention period")
+		}
+	})
+
+	t.Run("sends the finished job to the session that started it", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		messages := make(chan []byte, 1)
+		session := service.Sessions().Add("test", func(message []byte) error {
+			messages <- message
+			return nil
+		})
+		job, err := service.Jobs().Start(WithSessionID(context.Background(), session.ID), "echo", nil, service.ExecuteToolContext)
+		if err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		select {
+		case message := <-messages:
+			var notification struct {
+				Method string `json:"method"`
+				Params Job    `json:"params"`
+			}
+			if err := json.Unmarshal(message, &notification); err != nil {
+				t.Fatalf("Failed to unmarshal notification: %v", err)
+			}
+			if notification.Method != jobFinishedMethod || notification.Params.ID != job.ID || notification.Params.Status != JobSucceeded {
+				t.Errorf("Unexpected notification: %s", message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the finished job to be sent to the session")
+		}
+	})
+
+	t.Run("hides jobs from other tenants", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		ctx := WithTenant(context.Background(), &Tenant{Name: "acme"})
+		job, _ := service.Jobs().Start(ctx, "echo", nil, service.ExecuteToolContext)
+		if _, _, ok := service.Jobs().Get(context.Background(), job.ID); ok {
+			t.Error("Expected the job to be hidden without the tenant")
+		}
+		if _, _, ok := service.Jobs().Get(ctx, job.ID); !ok {
+			t.Error("Expected the tenant to see its job")
+		}
+	})
+}
+
+func TestHTTPServer_Jobs(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{name: "echo"})
+	httpServer := NewHTTPServer(service, WithPort(8080), WithLogger(logger))
+
+	t.Run("starts, polls, and streams a job", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/jobs", strings.NewReader(`{"tool": "echo", "arguments": {"text": "hi"}}`))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		var job Job
+		if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+			t.Fatalf("Failed to unmarshal job: %v", err)
+		}
+		if location := w.Header().Get("Location"); location != "/api/v1/jobs/"+job.ID {
+			t.Errorf("Unexpected Location: %s", location)
+		}
+
+		req = httptest.NewRequest("GET", "/api/v1/jobs/"+job.ID+"/events", nil)
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if !strings.HasPrefix(w.Body.String(), "event: job\ndata: ") || !strings.Contains(w.Body.String(), `"status":"succeeded"`) {
+			t.Errorf("Unexpected event stream: %q", w.Body.String())
+		}
+
+		req = httptest.NewRequest("GET", "/api/v1/jobs/"+job.ID, nil)
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"status":"succeeded"`) {
+			t.Errorf("Expected the finished job, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("sends the finished job to the Mcp-Session-Id session", func(t *testing.T) {
+		id, err := service.Sessions().Issue(context.Background())
+		if err != nil {
+			t.Fatalf("Failed to issue a session: %v", err)
+		}
+		messages := make(chan []byte, 1)
+		session := service.Sessions().AddWithID(id, "streamable", func(message []byte) error {
+			messages <- message
+			return nil
+		})
+		defer service.Sessions().Remove(session.ID)
+
+		req := httptest.NewRequest("POST", "/api/v1/jobs", strings.NewReader(`{"tool": "echo"}`))
+		req.Header.Set("Mcp-Session-Id", session.ID)
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusAccepted {
+			t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+		}
+		select {
+		case message := <-messages:
+			if !strings.Contains(string(message), `"method":"`+jobFinishedMethod+`"`) {
+				t.Errorf("Unexpected notification: %s", message)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected the finished job to be sent to the session")
+		}
+	})
+
+	t.Run("rejects a session ID the server did not issue", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/jobs", strings.NewReader(`{"tool": "echo"}`))
+		req.Header.Set("Mcp-Session-Id", "unknown")
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("unknown tools and jobs return not found", func(t *testing.T) {
+		for _, req := range []*http.Request{
+			httptest.NewRequest("POST", "/api/v1/jobs", strings.NewReader(`{"tool": "missing"}`)),
+			httptest.NewRequest("GET", "/api/v1/jobs/unknown", nil),
+			httptest.NewRequest("GET", "/api/v1/jobs/unknown/events", nil),
+		} {
+			w := httptest.NewRecorder()
+			httpServer.Handler().ServeHTTP(w, req)
+			if w.Code != http.StatusNotFound {
+				t.Errorf("%s %s: expected status 404, got %d", req.Method, req.URL.Path, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// BatchOptions configure how JSON-RPC 2.0 batches, arrays of requests sent as one
+// message, are handled.
+type BatchOptions struct {
+	MaxSize  int  // Most messages in a batch; larger batches are rejected whole. Zero means no limit
+	Parallel bool // Run a batch's requests concurrently instead of one after another
+}
+
+// DefaultBatchOptions returns the batch handling used unless configured otherwise.
+func DefaultBatchOptions() BatchOptions {
+	return BatchOptions{MaxSize: 50}
+}
+
+// SetBatchOptions sets how batches are handled.
+func (p *JSONRPCProcessor) SetBatchOptions(options BatchOptions) {
+	p.batch = options
+}
+
+// ProcessMessage processes a decoded JSON-RPC message, a single request or a batch. It
+// returns a *JSONRPCResponse for a request, a []*JSONRPCResponse for a batch, or nil when
+// nothing is to be sent back: for a notification, or a batch of only notifications.
+func (p *JSONRPCProcessor) ProcessMessage(ctx context.Context, message interface{}) interface{} {
+	switch message := message.(type) {
+	case map[string]interface{}:
+		if response := p.Process(ctx, message); response != nil {
+			return response
+		}
+		return nil
+	case []interface{}:
+		if len(message) == 0 {
+			return p.CreateErrorResponse(nil, -32600, "Invalid Request: Empty batch")
+		}
+		if p.batch.MaxSize > 0 && len(message) > p.batch.MaxSize {
+			return p.CreateErrorResponse(nil, -32600, fmt.Sprintf("Invalid Request: Batch exceeds %d messages", p.batch.MaxSize))
+		}
+		if responses := p.ProcessBatch(ctx, message); responses != nil {
+			return responses
+		}
+		return nil
+	default:
+		return p.CreateErrorResponse(nil, -32600, "Invalid Request: Expected an object or array")
+	}
+}
+
+// ProcessBatch processes the messages of a batch and returns the responses in the order
+// of their requests, or nil if all were notifications. Entries that are not objects get
+// an Invalid Request error each, as JSON-RPC 2.0 requires. The batch's size is not
+// checked; ProcessMessage does that.
+func (p *JSONRPCProcessor) ProcessBatch(ctx context.Context, batch []interface{}) []*JSONRPCResponse {
+	responses := make([]*JSONRPCResponse, len(batch))
+	process := func(i int) {
+		request, ok := batch[i].(map[string]interface{})
+		if !ok {
+			responses[i] = p.CreateErrorResponse(nil, -32600, "Invalid Request: Expected an object")
+			return
+		}
+		responses[i] = p.Process(ctx, request)
+	}
+	if p.batch.Parallel {
+		var wg sync.WaitGroup
+		for i := range batch {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				process(i)
+			}()
+		}
+		wg.Wait()
+	} else {
+		for i := range batch {
+			process(i)
+		}
+	}
+
+	var sent []*JSONRPCResponse
+	for _, response := range responses {
+		if response != nil {
+			sent = append(sent, response)
+		}
+	}
+	return sent
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestJSONRPCProcessor_ProcessMessage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	processor := NewJSONRPCProcessor(newTestToolService(logger, &MockTool{name: "echo"}), logger)
+	ctx := context.Background()
+	decode := func(message string) interface{} {
+		var decoded interface{}
+		if err := json.Unmarshal([]byte(message), &decoded); err != nil {
+			t.Fatalf("Invalid test message: %v", err)
+		}
+		return decoded
+	}
+
+	t.Run("single request", func(t *testing.T) {
+		response, ok := processor.ProcessMessage(ctx, decode(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)).(*JSONRPCResponse)
+		if !ok || response.ID != 1.0 || response.Error != nil {
+			t.Errorf("Expected a single response, got %+v", response)
+		}
+		if response := processor.ProcessMessage(ctx, decode(`{"jsonrpc":"2.0","method":"initialized"}`)); response != nil {
+			t.Errorf("Expected no response to a notification, got %+v", response)
+		}
+	})
+
+	t.Run("batch responses follow request order", func(t *testing.T) {
+		response := processor.ProcessMessage(ctx, decode(`[
+			{"jsonrpc":"2.0","id":"a","method":"tools/list"},
+			{"jsonrpc":"2.0","method":"initialized"},
+			{"jsonrpc":"2.0","id":"b","method":"no/such/method"},
+			1,
+			{"jsonrpc":"2.0","id":"c","method":"tools/call","params":{"name":"echo"}}
+		]`))
+		responses, ok := response.([]*JSONRPCResponse)
+		if !ok || len(responses) != 4 {
+			t.Fatalf("Expected 4 responses, got %+v", response)
+		}
+		if responses[0].ID != "a" || responses[0].Error != nil {
+			t.Errorf("Expected a result for a, got %+v", responses[0])
+		}
+		if responses[1].ID != "b" || responses[1].Error == nil || responses[1].Error.Code != -32601 {
+			t.Errorf("Expected method not found for b, got %+v", responses[1])
+		}
+		if responses[2].ID != nil || responses[2].Error == nil || responses[2].Error.Code != -32600 {
+			t.Errorf("Expected invalid request with a null id for the number, got %+v", responses[2])
+		}
+		if responses[3].ID != "c" || responses[3].Error != nil {
+			t.Errorf("Expected a result for c, got %+v", responses[3])
+		}
+	})
+
+	t.Run("batch of notifications gets no response", func(t *testing.T) {
+		if response := processor.ProcessMessage(ctx, decode(`[{"jsonrpc":"2.0","method":"initialized"}]`)); response != nil {
+			t.Errorf("Expected no response, got %+v", response)
+		}
+	})
+
+	t.Run("invalid batches get a single error", func(t *testing.T) {
+		processor.SetBatchOptions(BatchOptions{MaxSize: 2})
+		defer processor.SetBatchOptions(DefaultBatchOptions())
+		for _, message := range []string{`[]`, `[{}, {}, {}]`, `"text"`} {
+			response, ok := processor.ProcessMessage(ctx, decode(message)).(*JSONRPCResponse)
+			if !ok || response.Error == nil || response.Error.Code != -32600 || response.ID != nil {
+				t.Errorf("Expected invalid request for %s, got %+v", message, response)
+			}
+		}
+	})
+
+	t.Run("parallel batches run concurrently", func(t *testing.T) {
+		var running, peak atomic.Int32
+		slow := &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				current := peak.Load()
+				if n <= current || peak.CompareAndSwap(current, n) {
+					break
+				}
+			}
+			time.Sleep(50 * time.Millisecond)
+			return map[string]interface{}{}, nil
+		}}
+		parallel := NewJSONRPCProcessor(newTestToolService(logger, slow), logger)
+		parallel.SetBatchOptions(BatchOptions{Parallel: true})
+		var batch []interface{}
+		for i := 0; i < 3; i++ {
+			batch = append(batch, map[string]interface{}{"jsonrpc": "2.0", "id": i, "method": "tools/call", "params": map[string]interface{}{"name": "slow"}})
+		}
+		responses := parallel.ProcessBatch(ctx, batch)
+		if len(responses) != 3 || responses[0].ID != 0 || responses[2].ID != 2 {
+			t.Errorf("Expected 3 responses in request order, got %+v", responses)
+		}
+		if peak.Load() < 2 {
+			t.Errorf("Expected calls to overlap, peak concurrency was %d", peak.Load())
+		}
+	})
+}
+
+func TestBatchTransports(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","method":"initialized"},{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}]`
+	checkResponses := func(t *testing.T, responses []JSONRPCResponse) {
+		t.Helper()
+		if len(responses) != 2 || responses[0].ID != 1.0 || responses[1].ID != 2.0 {
+			t.Errorf("Expected responses for ids 1 and 2, got %+v", responses)
+		}
+	}
+
+	t.Run("streamable", func(t *testing.T) {
+		streamable := NewStreamableHTTPServer(toolService, WithLogger(logger))
+		testServer := httptest.NewServer(http.HandlerFunc(streamable.handleMCP))
+		defer testServer.Close()
+		resp, err := http.Post(testServer.URL, "application/json", strings.NewReader(batch))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var responses []JSONRPCResponse
+		if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+			t.Fatalf("Expected a response array: %v", err)
+		}
+		checkResponses(t, responses)
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		wsServer := NewWebSocketServer(toolService, WithLogger(logger))
+		testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+		defer testServer.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(testServer.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		if err := conn.Write(ctx, websocket.MessageText, []byte(batch)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		var responses []JSONRPCResponse
+		if err := wsjson.Read(ctx, conn, &responses); err != nil {
+			t.Fatalf("Expected a response array: %v", err)
+		}
+		checkResponses(t, responses)
+	})
+
+	t.Run("stdio", func(t *testing.T) {
+		var out bytes.Buffer
+		mcpServer := NewMCPServer(toolService, logger)
+		mcpServer.SetHeartbeat(0)
+		mcpServer.SetIO(strings.NewReader(`{"jsonrpc":"2.0","id":0,"method":"initialize"}`+"\n"+batch+"\n"), &out)
+		_ = mcpServer.Start(context.Background())
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		var responses []JSONRPCResponse
+		if len(lines) != 2 || json.Unmarshal([]byte(lines[1]), &responses) != nil {
+			t.Fatalf("Expected a response array after initialize, got %q", out.String())
+		}
+		checkResponses(t, responses)
+	})
+}
@@ -2,13 +2,24 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+
+	"mcp-tools-server/internal/policy"
+	"mcp-tools-server/pkg/tools"
 )
 
 // JSONRPCProcessor handles the logic for JSON-RPC messages, independent of transport.
+// It is the single MCP protocol implementation: the stdio, Streamable HTTP, and WebSocket
+// transports only frame messages and delegate everything else to Process, so results and
+// error codes cannot diverge between them.
 type JSONRPCProcessor struct {
 	toolService *ToolService
+	batch       BatchOptions
+	inflight    inflightRequests
 	logger      *slog.Logger
 }
 
@@ -16,35 +27,106 @@ type JSONRPCProcessor struct {
 func NewJSONRPCProcessor(toolService *ToolService, logger *slog.Logger) *JSONRPCProcessor {
 	return &JSONRPCProcessor{
 		toolService: toolService,
+		batch:       DefaultBatchOptions(),
 		logger:      logger,
 	}
 }
 
-// Process takes a raw JSON-RPC request and returns the appropriate response.
+// Process takes a raw JSON-RPC request and returns the appropriate response. Both are
+// recorded when the ToolService has a SessionRecorder and ctx names a session.
 func (p *JSONRPCProcessor) Process(ctx context.Context, request map[string]interface{}) *JSONRPCResponse {
+	recorder, sessionID := p.toolService.Recorder(), SessionIDFromContext(ctx)
+	recorder.Record(sessionID, RecordIn, request)
+	response := p.process(ctx, request)
+	if response != nil {
+		recorder.Record(sessionID, RecordOut, response)
+	}
+	return response
+}
+
+// process dispatches a request to the handler for its method.
+func (p *JSONRPCProcessor) process(ctx context.Context, request map[string]interface{}) *JSONRPCResponse {
+	// A client's response to a server-initiated request gets no response of its own
+	if isResponse(request) {
+		if !p.toolService.Sessions().deliverResponse(request) {
+			loggerFor(ctx, p.logger).Debug("Ignoring a response to no pending request", "id", request["id"])
+		}
+		return nil
+	}
 	method, ok := request["method"].(string)
 	if !ok {
 		return p.CreateErrorResponse(request["id"], -32600, "Invalid Request: Missing method")
 	}
 
-	id := request["id"]
 	params, _ := request["params"].(map[string]interface{})
+	id, hasID := request["id"]
+	if !hasID {
+		p.handleNotification(ctx, method, params)
+		return nil
+	}
+	// initialize must not be cancelled, and a ping finishes at once
+	if method == "initialize" || method == "ping" {
+		return p.dispatch(ctx, method, params, id)
+	}
+	ctx, finish := p.inflight.track(ctx, id)
+	response := p.dispatch(ctx, method, params, id)
+	if finish() {
+		loggerFor(ctx, p.logger).Debug("Dropping the response to a cancelled request", "method", method, "id", id)
+		return nil
+	}
+	return response
+}
 
+// handleNotification handles a message without an id. JSON-RPC 2.0 forbids replying to
+// one, even with an error, so unknown notifications are only logged. A request method
+// sent as a notification is still carried out, and its response dropped.
+func (p *JSONRPCProcessor) handleNotification(ctx context.Context, method string, params map[string]interface{}) {
+	logger := loggerFor(ctx, p.logger)
+	switch method {
+	case "initialized", "notifications/initialized":
+		logger.Info("Client initialized notification received")
+	case "notifications/cancelled":
+		found := p.inflight.cancel(SessionIDFromContext(ctx), params["requestId"])
+		logger.Debug("Client cancelled a request", "requestId", params["requestId"], "reason", params["reason"], "inFlight", found)
+	default:
+		if !requestMethods[method] {
+			logger.Debug("Ignoring unknown notification", "method", method)
+			return
+		}
+		p.dispatch(ctx, method, params, nil)
+		logger.Debug("Processed request sent as a notification; response dropped", "method", method)
+	}
+}
+
+// requestMethods are the methods dispatch handles.
+var requestMethods = map[string]bool{
+	"initialize": true, "tools/list": true, "tools/call": true,
+	"resources/list": true, "resources/read": true, "prompts/list": true, "prompts/get": true,
+	"logging/setLevel": true, "ping": true,
+}
+
+// dispatch routes a request to the handler for its method.
+func (p *JSONRPCProcessor) dispatch(ctx context.Context, method string, params map[string]interface{}, id interface{}) *JSONRPCResponse {
 	switch method {
 	case "initialize":
-		return p.HandleInitialize(id)
-	case "initialized":
-		p.logger.Info("Client initialized notification received")
-		return nil
+		return p.HandleInitializeContext(ctx, params, id)
 	case "tools/list":
-		return p.HandleToolsList(id)
+		return p.handleToolsListPage(ctx, params, id)
 	case "tools/call":
-		return p.HandleToolsCall(params, id)
+		return p.HandleToolsCall(ctx, params, id)
+	case "resources/list":
+		return p.HandleResourcesList(id)
+	case "resources/read":
+		return p.HandleResourcesRead(params, id)
+	case "prompts/list":
+		return p.HandlePromptsList(id)
+	case "prompts/get":
+		return p.HandlePromptsGet(params, id)
+	case "logging/setLevel":
+		return p.HandleLoggingSetLevel(ctx, params, id)
+	case "ping":
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: id, Result: map[string]interface{}{}}
 	default:
-		if id == nil {
-			p.logger.Warn("Ignoring notification for unknown method", "method", method)
-			return nil
-		}
 		return p.CreateErrorResponse(id, -32601, fmt.Sprintf("Method not found: %s", method))
 	}
 }
@@ -55,12 +137,22 @@ type InitializeResult struct {
 	ProtocolVersion string                 `json:"protocolVersion"`
 	Capabilities    map[string]interface{} `json:"capabilities"`
 	ServerInfo      map[string]interface{} `json:"serverInfo"`
+	Meta            map[string]interface{} `json:"_meta,omitempty"`
 }
 
 type ToolDefinition struct {
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
 	InputSchema interface{} `json:"inputSchema"`
+	Category    string      `json:"category,omitempty"`
+	Tags        []string    `json:"tags,omitempty"`
+
+	// Advertised to clients of protocol 2025-06-18 and later, which introduced it
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+
+	Version           string `json:"version,omitempty"`
+	Deprecated        bool   `json:"deprecated,omitempty"`
+	DeprecationNotice string `json:"deprecationNotice,omitempty"`
 }
 
 type JSONRPCResponse struct {
@@ -71,22 +163,36 @@ type JSONRPCResponse struct {
 }
 
 type ErrorObject struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
 // --- Public Methods ---
 
-// HandleInitialize creates the response for an "initialize" request.
+// HandleInitialize creates the response for an "initialize" request without parameters,
+// which negotiates the latest protocol version.
 func (p *JSONRPCProcessor) HandleInitialize(id interface{}) *JSONRPCResponse {
+	return p.HandleInitializeContext(context.Background(), nil, id)
+}
+
+// HandleInitializeContext creates the response for an "initialize" request, negotiating
+// the protocol version the client requests in params and advertising only the tools the
+// calling tenant may use. The version is recorded for the session in ctx, if any.
+func (p *JSONRPCProcessor) HandleInitializeContext(ctx context.Context, params map[string]interface{}, id interface{}) *JSONRPCResponse {
+	requested, _ := params["protocolVersion"].(string)
+	version := NegotiateProtocolVersion(requested)
+	if requested != "" && requested != version {
+		loggerFor(ctx, p.logger).Info("Client requested an unsupported protocol version", "requested", requested, "negotiated", version)
+	}
+	p.toolService.Sessions().SetProtocolVersion(SessionIDFromContext(ctx), version)
+
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result: InitializeResult{
-			ProtocolVersion: "2024-11-05",
-			Capabilities: map[string]interface{}{
-				"tools": p.getAvailableTools(),
-			},
+			ProtocolVersion: version,
+			Capabilities:    serverCapabilities(version),
 			ServerInfo: map[string]interface{}{
 				"name":    "mcp-tools-server",
 				"version": "1.0.0",
@@ -95,34 +201,242 @@ func (p *JSONRPCProcessor) HandleInitialize(id interface{}) *JSONRPCResponse {
 	}
 }
 
+// serverCapabilities returns the capabilities advertised to a client of the given
+// protocol version. Every list sends list_changed notifications: tools when tools are
+// registered or change health, resources and prompts when the catalog changes. Logging
+// covers notifications/message and logging/setLevel. Resource subscriptions and
+// completions are not implemented, so they are not advertised. All of these capabilities
+// exist since 2024-11-05; capabilities added in later versions must only be advertised
+// when protocolAtLeast(version, ...) holds.
+func serverCapabilities(version string) map[string]interface{} {
+	return map[string]interface{}{
+		"tools":     map[string]interface{}{"listChanged": true},
+		"resources": map[string]interface{}{"listChanged": true},
+		"prompts":   map[string]interface{}{"listChanged": true},
+		"logging":   map[string]interface{}{},
+	}
+}
+
+// HandleLoggingSetLevel handles a "logging/setLevel" request, setting the least severe
+// level of logging notifications sent to the session in ctx. Without a session, as for
+// Streamable HTTP POSTs, the request succeeds without effect.
+func (p *JSONRPCProcessor) HandleLoggingSetLevel(ctx context.Context, params map[string]interface{}, id interface{}) *JSONRPCResponse {
+	level, _ := params["level"].(string)
+	if logSeverity(level) < 0 {
+		return p.CreateErrorResponse(id, -32602, fmt.Sprintf("Invalid params: level must be one of %s", strings.Join(LogLevels, ", ")))
+	}
+	p.toolService.Sessions().SetLogLevel(SessionIDFromContext(ctx), level)
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  map[string]interface{}{},
+	}
+}
+
 // HandleToolsList creates the response for a "tools/list" request.
 func (p *JSONRPCProcessor) HandleToolsList(id interface{}) *JSONRPCResponse {
+	return p.HandleToolsListContext(context.Background(), id)
+}
+
+// HandleToolsListContext creates the response for a "tools/list" request, listing only
+// the tools the calling tenant may use.
+func (p *JSONRPCProcessor) HandleToolsListContext(ctx context.Context, id interface{}) *JSONRPCResponse {
+	return p.handleToolsListPage(ctx, nil, id)
+}
+
+// handleToolsListPage creates the response for a "tools/list" request, returning the page
+// after the cursor in params and, unless it is the last, the cursor of the next.
+func (p *JSONRPCProcessor) handleToolsListPage(ctx context.Context, params map[string]interface{}, id interface{}) *JSONRPCResponse {
+	cursor, _ := params["cursor"].(string)
+	listed, next, err := p.toolService.ListPage(ToolFilter{Tenant: TenantFromContext(ctx)}, cursor)
+	if err != nil {
+		return p.CreateErrorResponse(id, -32602, fmt.Sprintf("Invalid params: %s", err.Error()))
+	}
+	result := map[string]interface{}{"tools": p.toolDefinitions(ctx, listed)}
+	if next != "" {
+		result["nextCursor"] = next
+	}
+	return &JSONRPCResponse{JSONRPC: "2.0", ID: id, Result: result}
+}
+
+// HandleToolsCall handles a "tools/call" request and returns a response.
+func (p *JSONRPCProcessor) HandleToolsCall(ctx context.Context, params map[string]interface{}, id interface{}) *JSONRPCResponse {
+	name, ok := params["name"].(string)
+	if !ok {
+		loggerFor(ctx, p.logger).Error("Missing tool name in tools/call")
+		return p.CreateErrorResponse(id, -32602, "Invalid params: Missing tool name")
+	}
+
+	arguments, _ := params["arguments"].(map[string]interface{})
+
+	result, err := p.toolService.ExecuteToolContext(ctx, name, arguments)
+	if cancelledByClient(ctx, err) {
+		// The response is dropped, so only note the cancellation
+		loggerFor(ctx, p.logger).Info("Tool call cancelled", "tool", name)
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: id, Error: &ErrorObject{Code: -32000, Message: "Tool execution error: " + err.Error()}}
+	}
+	if errors.Is(err, tools.ErrInvalidArguments) {
+		loggerFor(ctx, p.logger).Info("Tool call with invalid arguments", "tool", name, "error", err)
+		return p.CreateErrorResponse(id, -32602, fmt.Sprintf("Invalid params: %s", err.Error()))
+	}
+	if err != nil && isCallRefusal(err) {
+		loggerFor(ctx, p.logger).Warn("Tool call refused", "tool", name, "error", err)
+		response := p.CreateErrorResponse(id, -32000, fmt.Sprintf("Tool execution error: %s", err.Error()))
+		var quotaErr *tools.QuotaError
+		if errors.As(err, &quotaErr) {
+			response.Error.Data = map[string]interface{}{"resource": quotaErr.Resource, "limit": quotaErr.Limit}
+		}
+		return response
+	}
+	if err != nil {
+		// The tool ran and failed, which the model calling it should see and can act on
+		loggerFor(ctx, p.logger).Error("Error executing tool", "tool", name, "error", err)
+		return &JSONRPCResponse{JSONRPC: "2.0", ID: id, Result: toolErrorResult(err)}
+	}
+
+	loggerFor(ctx, p.logger).Info("Tool call completed", "tool", name, "result", p.toolService.LogPayload(result))
+
+	version := p.protocolVersion(ctx)
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result:  wrapToolResult(p.toolService.Results().Limit(downgradeResult(normalizeToolResult(result), version)), version),
+	}
+}
+
+// isCallRefusal reports whether err is the server refusing a tool call rather than the
+// tool failing: the tool is unknown or unavailable, or the caller is throttled, over
+// quota, or denied. Refusals are JSON-RPC errors; failures are tool results with isError.
+func isCallRefusal(err error) bool {
+	for _, refusal := range []error{
+		ErrToolNotFound, ErrToolDisabled, ErrCircuitOpen,
+		ErrRateLimited, ErrTooManyApprovals, tools.ErrQuotaExceeded, policy.ErrDenied,
+	} {
+		if errors.Is(err, refusal) {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleResourcesList creates the response for a "resources/list" request.
+func (p *JSONRPCProcessor) HandleResourcesList(id interface{}) *JSONRPCResponse {
+	resources := make([]map[string]interface{}, 0)
+	for _, resource := range p.toolService.Catalog().Resources() {
+		entry := map[string]interface{}{
+			"uri":  resource.URI,
+			"name": resource.Name,
+		}
+		if resource.Description != "" {
+			entry["description"] = resource.Description
+		}
+		if resource.MIMEType != "" {
+			entry["mimeType"] = resource.MIMEType
+		}
+		resources = append(resources, entry)
+	}
+
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
 		ID:      id,
 		Result: map[string]interface{}{
-			"tools": p.getAvailableTools(),
+			"resources": resources,
 		},
 	}
 }
 
-// HandleToolsCall handles a "tools/call" request and returns a response.
-func (p *JSONRPCProcessor) HandleToolsCall(params map[string]interface{}, id interface{}) *JSONRPCResponse {
+// HandleResourcesRead handles a "resources/read" request and returns the resource contents.
+func (p *JSONRPCProcessor) HandleResourcesRead(params map[string]interface{}, id interface{}) *JSONRPCResponse {
+	uri, ok := params["uri"].(string)
+	if !ok {
+		return p.CreateErrorResponse(id, -32602, "Invalid params: Missing resource uri")
+	}
+
+	resource, ok := p.toolService.Catalog().Resource(uri)
+	if !ok {
+		return p.CreateErrorResponse(id, -32002, fmt.Sprintf("Resource not found: %s", uri))
+	}
+
+	contents := map[string]interface{}{"uri": resource.URI}
+	if resource.MIMEType != "" {
+		contents["mimeType"] = resource.MIMEType
+	}
+	if resource.Blob != nil {
+		contents["blob"] = base64.StdEncoding.EncodeToString(resource.Blob)
+	} else {
+		contents["text"] = resource.Text
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: map[string]interface{}{
+			"contents": []interface{}{contents},
+		},
+	}
+}
+
+// HandlePromptsList creates the response for a "prompts/list" request.
+func (p *JSONRPCProcessor) HandlePromptsList(id interface{}) *JSONRPCResponse {
+	prompts := make([]map[string]interface{}, 0)
+	for _, prompt := range p.toolService.Catalog().Prompts() {
+		entry := map[string]interface{}{"name": prompt.Name}
+		if prompt.Description != "" {
+			entry["description"] = prompt.Description
+		}
+		if len(prompt.Arguments) > 0 {
+			entry["arguments"] = prompt.Arguments
+		}
+		prompts = append(prompts, entry)
+	}
+
+	return &JSONRPCResponse{
+		JSONRPC: "2.0",
+		ID:      id,
+		Result: map[string]interface{}{
+			"prompts": prompts,
+		},
+	}
+}
+
+// HandlePromptsGet handles a "prompts/get" request and returns the rendered prompt.
+func (p *JSONRPCProcessor) HandlePromptsGet(params map[string]interface{}, id interface{}) *JSONRPCResponse {
 	name, ok := params["name"].(string)
 	if !ok {
-		p.logger.Error("Missing tool name in tools/call")
-		return p.CreateErrorResponse(id, -32602, "Invalid params: Missing tool name")
+		return p.CreateErrorResponse(id, -32602, "Invalid params: Missing prompt name")
 	}
 
-	arguments, _ := params["arguments"].(map[string]interface{})
+	prompt, ok := p.toolService.Catalog().Prompt(name)
+	if !ok {
+		return p.CreateErrorResponse(id, -32602, fmt.Sprintf("Prompt not found: %s", name))
+	}
+
+	args := make(map[string]string)
+	if rawArgs, ok := params["arguments"].(map[string]interface{}); ok {
+		for key, value := range rawArgs {
+			args[key] = fmt.Sprint(value)
+		}
+	}
 
-	result, err := p.toolService.ExecuteTool(name, arguments)
+	text, err := prompt.Render(args)
 	if err != nil {
-		p.logger.Error("Error executing tool", "tool", name, "error", err)
-		return p.CreateErrorResponse(id, -32000, fmt.Sprintf("Tool execution error: %s", err.Error()))
+		return p.CreateErrorResponse(id, -32602, fmt.Sprintf("Invalid params: %s", err.Error()))
 	}
 
-	p.logger.Info("Tool call completed", "tool", name, "result", result)
+	result := map[string]interface{}{
+		"messages": []interface{}{
+			map[string]interface{}{
+				"role": "user",
+				"content": map[string]interface{}{
+					"type": "text",
+					"text": text,
+				},
+			},
+		},
+	}
+	if prompt.Description != "" {
+		result["description"] = prompt.Description
+	}
 
 	return &JSONRPCResponse{
 		JSONRPC: "2.0",
@@ -146,21 +460,33 @@ func (p *JSONRPCProcessor) CreateErrorResponse(id interface{}, code int, message
 
 // --- Private Helpers ---
 
-// getAvailableTools returns the list of available tools in the required format.
-func (p *JSONRPCProcessor) getAvailableTools() []ToolDefinition {
-	var tools []ToolDefinition
-	for _, tool := range p.toolService.GetTools() {
-		// For now, schema is a generic object. This could be expanded later.
-		schema := map[string]interface{}{
-			"type":       "object",
-			"properties": map[string]interface{}{},
-		}
+// getAvailableTools returns the tools available to the tenant in ctx in the required format.
+func (p *JSONRPCProcessor) getAvailableTools(ctx context.Context) []ToolDefinition {
+	return p.toolDefinitions(ctx, p.toolService.ListedTools(ToolFilter{Tenant: TenantFromContext(ctx)}))
+}
 
-		tools = append(tools, ToolDefinition{
-			Name:        tool.Name(),
-			Description: tool.Description(),
-			InputSchema: schema,
+// toolDefinitions converts listed tools to the format tools/list returns them in.
+func (p *JSONRPCProcessor) toolDefinitions(ctx context.Context, listedTools []ListedTool) []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(listedTools))
+	outputSchemas := protocolAtLeast(p.protocolVersion(ctx), ProtocolVersion20250618)
+	for _, listed := range listedTools {
+		category, tags := toolMetadata(listed.Tool)
+		notice := deprecation(listed.Tool)
+		var outputSchema map[string]interface{}
+		if outputSchemas {
+			outputSchema = p.toolService.OutputSchema(listed.Tool)
+		}
+		definitions = append(definitions, ToolDefinition{
+			Name:              listed.Name,
+			Description:       listed.Tool.Description(),
+			InputSchema:       p.toolService.InputSchema(listed.Tool),
+			Category:          category,
+			Tags:              tags,
+			OutputSchema:      outputSchema,
+			Version:           listed.Version(),
+			Deprecated:        notice != "",
+			DeprecationNotice: notice,
 		})
 	}
-	return tools
+	return definitions
 }
@@ -1,11 +1,17 @@
 package server
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"mcp-tools-server/internal/policy"
 	"mcp-tools-server/pkg/tools"
 )
 
@@ -38,12 +44,96 @@ func TestJSONRPCProcessor_HandleInitialize(t *testing.T) {
 		t.Fatalf("Unexpected result type: %T", resp.Result)
 	}
 
-	if result.ProtocolVersion != "2024-11-05" {
+	if result.ProtocolVersion != LatestProtocolVersion {
 		t.Errorf("Wrong protocol version: %s", result.ProtocolVersion)
 	}
-	if len(result.Capabilities) == 0 {
-		t.Error("Expected capabilities, got none")
+	for _, capability := range []string{"tools", "resources", "prompts"} {
+		flags, ok := result.Capabilities[capability].(map[string]interface{})
+		if !ok || flags["listChanged"] != true {
+			t.Errorf("Expected %s with listChanged, got %v", capability, result.Capabilities[capability])
+		}
+	}
+	if _, ok := result.Capabilities["logging"]; !ok {
+		t.Error("Expected the logging capability")
+	}
+}
+
+func TestJSONRPCProcessor_LoggingSetLevel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger)
+	p := NewJSONRPCProcessor(service, logger)
+	var received int
+	session := service.Sessions().Add("test", func([]byte) error { received++; return nil })
+	defer service.Sessions().Remove(session.ID)
+	ctx := WithSessionID(context.Background(), session.ID)
+
+	t.Run("filters logging notifications", func(t *testing.T) {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "logging/setLevel", "params": map[string]interface{}{"level": "warning"}})
+		if resp == nil || resp.Error != nil {
+			t.Fatalf("Expected success, got %+v", resp)
+		}
+		service.Sessions().Log("info", "", "ignored")
+		service.Sessions().Log("error", "", "sent")
+		if received != 1 {
+			t.Errorf("Expected only the error to be sent, got %d notifications", received)
+		}
+	})
+
+	t.Run("rejects unknown levels", func(t *testing.T) {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "logging/setLevel", "params": map[string]interface{}{"level": "verbose"}})
+		if resp == nil || resp.Error == nil || resp.Error.Code != -32602 {
+			t.Errorf("Expected invalid params, got %+v", resp)
+		}
+	})
+}
+
+func TestJSONRPCProcessor_ProtocolVersions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	linked := &MockTool{name: "linked", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"report": tools.ResourceLink("file:///tmp/report.pdf", "report.pdf", "application/pdf")}, nil
+	}}
+	service := newTestToolService(logger, linked)
+	p := NewJSONRPCProcessor(service, logger)
+	initialize := func(ctx context.Context, requested string) string {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{"protocolVersion": requested}})
+		return resp.Result.(InitializeResult).ProtocolVersion
 	}
+
+	t.Run("supported versions are echoed", func(t *testing.T) {
+		for _, version := range SupportedProtocolVersions {
+			if got := initialize(context.Background(), version); got != version {
+				t.Errorf("Expected %s to be echoed, got %s", version, got)
+			}
+		}
+	})
+
+	t.Run("unsupported versions get the latest", func(t *testing.T) {
+		if got := initialize(context.Background(), "2023-01-01"); got != LatestProtocolVersion {
+			t.Errorf("Expected %s, got %s", LatestProtocolVersion, got)
+		}
+	})
+
+	t.Run("older clients get no resource links", func(t *testing.T) {
+		session := service.Sessions().Add("test", func([]byte) error { return nil })
+		defer service.Sessions().Remove(session.ID)
+		ctx := WithSessionID(context.Background(), session.ID)
+		call := func() map[string]interface{} {
+			resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]interface{}{"name": "linked"}})
+			return resp.Result.(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})
+		}
+
+		initialize(ctx, ProtocolVersion20250618)
+		if block := call(); block["type"] != "resource_link" {
+			t.Errorf("Expected a resource link for %s, got %v", ProtocolVersion20250618, block)
+		}
+		initialize(ctx, ProtocolVersion20241105)
+		if block := call(); block["type"] != "text" || block["text"] != "report.pdf: file:///tmp/report.pdf" {
+			t.Errorf("Expected a text block for %s, got %v", ProtocolVersion20241105, block)
+		}
+		if block := p.Process(WithProtocolVersion(ctx, ProtocolVersion20250618), map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": map[string]interface{}{"name": "linked"}}); block.Result.(map[string]interface{})["content"].([]interface{})[0].(map[string]interface{})["type"] != "resource_link" {
+			t.Error("Expected the context's version to take precedence over the session's")
+		}
+	})
 }
 
 func TestJSONRPCProcessor_HandleToolsList(t *testing.T) {
@@ -75,6 +165,25 @@ func TestJSONRPCProcessor_HandleToolsList(t *testing.T) {
 	}
 }
 
+func TestJSONRPCProcessor_HandleToolsListEmpty(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tenants, err := NewTenants([]Tenant{{Name: "empty", APIKey: "key-empty", Tools: []string{"missing"}}}, logger)
+	if err != nil {
+		t.Fatalf("NewTenants failed: %v", err)
+	}
+	p := NewJSONRPCProcessor(newTestToolService(logger, &MockTool{name: "echo"}), logger)
+	ctx := WithTenant(context.Background(), tenants.lookup("key-empty"))
+
+	response := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	encoded, err := json.Marshal(response)
+	if err != nil {
+		t.Fatalf("Failed to marshal response: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"tools":[]`) {
+		t.Errorf("Expected an empty tools array, got %s", encoded)
+	}
+}
+
 func TestJSONRPCProcessor_HandleToolsCall(t *testing.T) {
 	p := setupProcessor(t)
 
@@ -83,7 +192,7 @@ func TestJSONRPCProcessor_HandleToolsCall(t *testing.T) {
 			"name":      "generate_uuid",
 			"arguments": map[string]interface{}{},
 		}
-		resp := p.HandleToolsCall(params, 1)
+		resp := p.HandleToolsCall(context.Background(), params, 1)
 
 		if resp.Error != nil {
 			t.Errorf("Expected no error, got %v", resp.Error)
@@ -95,14 +204,17 @@ func TestJSONRPCProcessor_HandleToolsCall(t *testing.T) {
 		if !ok {
 			t.Fatalf("Unexpected result type: %T", resp.Result)
 		}
-		if _, ok := result["uuid"]; !ok {
+		if _, ok := result["structuredContent"].(map[string]interface{})["uuid"]; !ok {
 			t.Error("Expected uuid in result")
 		}
+		if result["isError"] != false || len(result["content"].([]interface{})) != 1 {
+			t.Errorf("Expected a successful result with a text block, got %v", result)
+		}
 	})
 
 	t.Run("missing tool name", func(t *testing.T) {
 		params := map[string]interface{}{"arguments": map[string]interface{}{}}
-		resp := p.HandleToolsCall(params, 2)
+		resp := p.HandleToolsCall(context.Background(), params, 2)
 		if resp.Error == nil {
 			t.Fatal("Expected error, got nil")
 		}
@@ -113,7 +225,7 @@ func TestJSONRPCProcessor_HandleToolsCall(t *testing.T) {
 
 	t.Run("unknown tool", func(t *testing.T) {
 		params := map[string]interface{}{"name": "nonexistent_tool"}
-		resp := p.HandleToolsCall(params, 3)
+		resp := p.HandleToolsCall(context.Background(), params, 3)
 		if resp.Error == nil {
 			t.Fatal("Expected error, got nil")
 		}
@@ -131,20 +243,36 @@ func TestJSONRPCProcessor_HandleToolsCall(t *testing.T) {
 				return nil, fmt.Errorf("mock execution error")
 			},
 		}
-		failingToolService := &ToolService{
-			tools:  map[string]tools.Tool{"failing_tool": mockTool},
-			logger: logger,
-		}
+		failingToolService := newTestToolService(logger, mockTool)
 		pWithFailingTool := NewJSONRPCProcessor(failingToolService, logger)
 
 		params := map[string]interface{}{"name": "failing_tool"}
-		resp := pWithFailingTool.HandleToolsCall(params, 4)
+		resp := pWithFailingTool.HandleToolsCall(context.Background(), params, 4)
 
-		if resp.Error == nil {
-			t.Fatal("Expected error, got nil")
+		if resp.Error != nil {
+			t.Fatalf("Expected a tool result, got error %+v", resp.Error)
 		}
-		if resp.Error.Code != -32000 {
-			t.Errorf("Expected code -32000, got %d", resp.Error.Code)
+		result := resp.Result.(map[string]interface{})
+		content := result["content"].([]interface{})
+		if result["isError"] != true || len(content) != 1 || content[0].(map[string]interface{})["text"] != "mock execution error" {
+			t.Errorf("Expected an isError result with the error's text, got %v", result)
+		}
+	})
+
+	t.Run("refusals stay JSON-RPC errors", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		for _, err := range []error{
+			fmt.Errorf("tenant a: %w", ErrRateLimited),
+			fmt.Errorf("tool t: %w: quiet hours", policy.ErrDenied),
+			fmt.Errorf("tool t: %w", ErrCircuitOpen),
+		} {
+			refusing := newTestToolService(logger, &MockTool{name: "refused", executeFunc: func(map[string]interface{}) (map[string]interface{}, error) {
+				return nil, err
+			}})
+			resp := NewJSONRPCProcessor(refusing, logger).HandleToolsCall(context.Background(), map[string]interface{}{"name": "refused"}, 5)
+			if resp.Error == nil || resp.Error.Code != -32000 {
+				t.Errorf("Expected a -32000 error for %v, got %+v", err, resp)
+			}
 		}
 	})
 }
@@ -169,3 +297,244 @@ func TestJSONRPCProcessor_CreateErrorResponse(t *testing.T) {
 		t.Errorf("Wrong error message: %s", resp.Error.Message)
 	}
 }
+
+func TestJSONRPCProcessor_Notifications(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	calls := 0
+	service := newTestToolService(logger, &MockTool{name: "count", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		calls++
+		return map[string]interface{}{}, nil
+	}})
+	p := NewJSONRPCProcessor(service, logger)
+	ctx := context.Background()
+
+	t.Run("notifications get no response", func(t *testing.T) {
+		for _, method := range []string{"initialized", "notifications/initialized", "notifications/cancelled", "notifications/no_such_thing"} {
+			if resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": method}); resp != nil {
+				t.Errorf("Expected no response to %s, got %+v", method, resp)
+			}
+		}
+		if strings.Contains(logs.String(), "level=ERROR") || strings.Contains(logs.String(), "level=WARN") {
+			t.Errorf("Expected notifications to log below warning level, got:\n%s", logs.String())
+		}
+		if !strings.Contains(logs.String(), "Ignoring unknown notification") {
+			t.Errorf("Expected the unknown notification to be logged, got:\n%s", logs.String())
+		}
+	})
+
+	t.Run("request methods run without a response", func(t *testing.T) {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": "tools/call", "params": map[string]interface{}{"name": "count"}})
+		if resp != nil || calls != 1 {
+			t.Errorf("Expected the call to run without a response, got %+v after %d calls", resp, calls)
+		}
+	})
+
+	t.Run("client responses get no response", func(t *testing.T) {
+		for _, message := range []map[string]interface{}{
+			{"jsonrpc": "2.0", "id": "server-1", "result": map[string]interface{}{}},
+			{"jsonrpc": "2.0", "id": "server-2", "error": map[string]interface{}{"code": -32601, "message": "Method not found"}},
+		} {
+			if resp := p.Process(ctx, message); resp != nil {
+				t.Errorf("Expected no response to %v, got %+v", message, resp)
+			}
+		}
+	})
+
+	t.Run("null id is a request", func(t *testing.T) {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": nil, "method": "no/such/method"})
+		if resp == nil || resp.Error == nil || resp.Error.Code != -32601 {
+			t.Errorf("Expected method not found, got %+v", resp)
+		}
+	})
+}
+
+func TestJSONRPCProcessor_PingAndCancellation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	started := make(chan struct{}, 1)
+	service := newTestToolService(logger, &contextMockTool{
+		MockTool: MockTool{name: "slow"},
+		executeContext: func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			started <- struct{}{}
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	service.Breaker().SetPolicy(BreakerPolicy{Failures: 1, Cooldown: time.Minute})
+	p := NewJSONRPCProcessor(service, logger)
+	session := service.Sessions().Add("test", func([]byte) error { return nil })
+	defer service.Sessions().Remove(session.ID)
+	ctx := WithSessionID(context.Background(), session.ID)
+
+	t.Run("ping gets an empty result", func(t *testing.T) {
+		resp := p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "ping"})
+		if result, ok := resp.Result.(map[string]interface{}); resp.Error != nil || !ok || len(result) != 0 {
+			t.Errorf("Expected an empty result, got %+v", resp)
+		}
+	})
+
+	t.Run("cancelled calls stop without a response", func(t *testing.T) {
+		done := make(chan *JSONRPCResponse)
+		go func() {
+			done <- p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]interface{}{"name": "slow"}})
+		}()
+		<-started
+		// Decoded JSON numbers are float64
+		p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": map[string]interface{}{"requestId": float64(2), "reason": "user aborted"}})
+		select {
+		case resp := <-done:
+			if resp != nil {
+				t.Errorf("Expected no response to a cancelled request, got %+v", resp)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the call to be cancelled")
+		}
+		if breakers := service.Breaker().Breakers(); len(breakers) != 0 {
+			t.Errorf("Expected the cancellation not to count as a failure, got %+v", breakers)
+		}
+	})
+
+	t.Run("cancellations only reach their own session", func(t *testing.T) {
+		done := make(chan *JSONRPCResponse)
+		go func() {
+			done <- p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": map[string]interface{}{"name": "slow"}})
+		}()
+		<-started
+		p.Process(WithSessionID(context.Background(), "other"), map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": map[string]interface{}{"requestId": 3}})
+		select {
+		case resp := <-done:
+			t.Fatalf("Expected the call to keep running, got %+v", resp)
+		case <-time.After(50 * time.Millisecond):
+		}
+		p.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/cancelled", "params": map[string]interface{}{"requestId": 3}})
+		if resp := <-done; resp != nil {
+			t.Errorf("Expected no response to a cancelled request, got %+v", resp)
+		}
+	})
+}
+
+// schemaMockTool is a MockTool that advertises an input schema.
+type schemaMockTool struct {
+	MockTool
+	schema map[string]interface{}
+}
+
+func (m *schemaMockTool) InputSchema() map[string]interface{} { return m.schema }
+
+func TestJSONRPCProcessor_ToolSchemas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	schema := map[string]interface{}{
+		"type":     "object",
+		"required": []string{"expected"},
+	}
+	toolService := newTestToolService(logger, &schemaMockTool{MockTool: MockTool{name: "with_schema"}, schema: schema})
+	p := NewJSONRPCProcessor(toolService, logger)
+
+	definitions := p.getAvailableTools(context.Background())
+	if len(definitions) != 1 {
+		t.Fatalf("Expected 1 tool, got %d", len(definitions))
+	}
+	advertised, ok := definitions[0].InputSchema.(map[string]interface{})
+	if !ok || advertised["required"] == nil {
+		t.Errorf("Expected the tool's own schema to be advertised, got %v", definitions[0].InputSchema)
+	}
+}
+
+func TestJSONRPCProcessor_ResourcesAndPrompts(t *testing.T) {
+	p := setupProcessor(t)
+	catalog := p.toolService.Catalog()
+	_ = catalog.AddResource(Resource{URI: "docs://readme", MIMEType: "text/markdown", Text: "# Hello"})
+	_ = catalog.AddResource(Resource{URI: "docs://logo", MIMEType: "image/png", Blob: []byte{0x89, 'P'}})
+	_ = catalog.AddPrompt(Prompt{
+		Name:      "greet",
+		Arguments: []PromptArgument{{Name: "name", Required: true}},
+		Template:  "Say hello to {{name}}.",
+	})
+
+	t.Run("resources/list", func(t *testing.T) {
+		resp := p.Process(context.Background(), map[string]interface{}{"id": 1, "method": "resources/list"})
+		resources := resp.Result.(map[string]interface{})["resources"].([]map[string]interface{})
+		if len(resources) != 2 {
+			t.Fatalf("Expected 2 resources, got %d", len(resources))
+		}
+	})
+
+	t.Run("resources/read text and blob", func(t *testing.T) {
+		resp := p.HandleResourcesRead(map[string]interface{}{"uri": "docs://readme"}, 2)
+		contents := resp.Result.(map[string]interface{})["contents"].([]interface{})
+		if contents[0].(map[string]interface{})["text"] != "# Hello" {
+			t.Errorf("Unexpected contents: %v", contents)
+		}
+
+		resp = p.HandleResourcesRead(map[string]interface{}{"uri": "docs://logo"}, 3)
+		contents = resp.Result.(map[string]interface{})["contents"].([]interface{})
+		if contents[0].(map[string]interface{})["blob"] != "iVA=" {
+			t.Errorf("Expected base64 blob, got %v", contents)
+		}
+	})
+
+	t.Run("resources/read unknown uri", func(t *testing.T) {
+		resp := p.HandleResourcesRead(map[string]interface{}{"uri": "docs://missing"}, 4)
+		if resp.Error == nil || resp.Error.Code != -32002 {
+			t.Errorf("Expected resource not found error, got %v", resp.Error)
+		}
+	})
+
+	t.Run("prompts/list and prompts/get", func(t *testing.T) {
+		resp := p.Process(context.Background(), map[string]interface{}{"id": 5, "method": "prompts/list"})
+		prompts := resp.Result.(map[string]interface{})["prompts"].([]map[string]interface{})
+		if len(prompts) != 1 || prompts[0]["name"] != "greet" {
+			t.Fatalf("Unexpected prompts: %v", prompts)
+		}
+
+		resp = p.HandlePromptsGet(map[string]interface{}{
+			"name":      "greet",
+			"arguments": map[string]interface{}{"name": "Ada"},
+		}, 6)
+		messages := resp.Result.(map[string]interface{})["messages"].([]interface{})
+		content := messages[0].(map[string]interface{})["content"].(map[string]interface{})
+		if content["text"] != "Say hello to Ada." {
+			t.Errorf("Unexpected prompt text: %v", content["text"])
+		}
+
+		resp = p.HandlePromptsGet(map[string]interface{}{"name": "greet"}, 7)
+		if resp.Error == nil || resp.Error.Code != -32602 {
+			t.Errorf("Expected invalid params error, got %v", resp.Error)
+		}
+	})
+}
+
+func BenchmarkJSONRPCProcessor_Process(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{
+		name: "echo",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"echo": args["text"], "items": []interface{}{1, 2, 3}}, nil
+		},
+	})
+	p := NewJSONRPCProcessor(service, logger)
+	messages := map[string]string{
+		"tools/call": `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo","arguments":{"text":"hello"}}}`,
+		"tools/list": `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`,
+	}
+	for _, method := range []string{"tools/call", "tools/list"} {
+		message := []byte(messages[method])
+		b.Run(method, func(b *testing.B) {
+			b.ReportAllocs()
+			// Decode, process, and encode, as the transports do for every message
+			for b.Loop() {
+				var request map[string]interface{}
+				if err := json.Unmarshal(message, &request); err != nil {
+					b.Fatal(err)
+				}
+				response := p.Process(context.Background(), request)
+				if response.Error != nil {
+					b.Fatalf("Unexpected error: %v", response.Error.Message)
+				}
+				if _, err := json.Marshal(response); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
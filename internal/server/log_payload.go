@@ -0,0 +1,32 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+)
+
+// logPayload logs tool arguments or a result. In safe mode only the SHA-256 of the JSON
+// encoding and its size are logged, which is enough to tell whether two calls saw the same
+// data without exposing it. The value is only encoded when the record is written.
+type logPayload struct {
+	value map[string]interface{}
+	safe  bool
+}
+
+// LogValue implements slog.LogValuer.
+func (p logPayload) LogValue() slog.Value {
+	if !p.safe {
+		return slog.AnyValue(p.value)
+	}
+	encoded, err := json.Marshal(p.value)
+	if err != nil {
+		return slog.GroupValue(slog.String("error", "unencodable payload"))
+	}
+	sum := sha256.Sum256(encoded)
+	return slog.GroupValue(
+		slog.String("sha256", hex.EncodeToString(sum[:])),
+		slog.Int("bytes", len(encoded)),
+	)
+}
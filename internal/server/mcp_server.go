@@ -4,14 +4,50 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
 )
 
+// defaultStdioHeartbeat is how often the stdio server logs a heartbeat.
+const defaultStdioHeartbeat = time.Minute
+
+// stdioRequestsTotal counts JSON-RPC messages read over stdio by method.
+var stdioRequestsTotal = metrics.NewCounterVec(
+	prometheus.CounterOpts{
+		Subsystem: "stdio",
+		Name:      "requests_total",
+		Help:      "Total number of JSON-RPC messages received over stdio by method",
+	},
+	[]string{"method"},
+)
+
+// stdioMethods are the methods counted under their own name; others count as "other" so
+// clients cannot create unbounded label values.
+var stdioMethods = map[string]bool{
+	"initialize": true, "initialized": true, "notifications/initialized": true, "ping": true,
+	"tools/list": true, "tools/call": true, "resources/list": true, "resources/read": true,
+	"prompts/list": true, "prompts/get": true,
+}
+
 // MCPServer handles MCP protocol communication over stdio.
 type MCPServer struct {
 	logger    *slog.Logger
 	processor *JSONRPCProcessor
+	sessions  *SessionManager
+	in        io.Reader     // Protocol input, stdin by default
+	out       io.Writer     // Protocol output, stdout by default; nothing else may write here
+	writeMu   sync.Mutex    // Serializes responses and server-initiated notifications on stdout
+	heartbeat time.Duration // Interval between heartbeat logs; zero turns them off
+	requests  atomic.Int64  // Messages read since Start
 }
 
 // NewMCPServer creates a new MCP server.
@@ -19,13 +55,35 @@ func NewMCPServer(toolService *ToolService, logger *slog.Logger) *MCPServer {
 	return &MCPServer{
 		logger:    logger,
 		processor: NewJSONRPCProcessor(toolService, logger),
+		sessions:  toolService.Sessions(),
+		in:        os.Stdin,
+		out:       os.Stdout,
+		heartbeat: defaultStdioHeartbeat,
 	}
 }
 
+// SetHeartbeat sets how often a heartbeat with the session's uptime and request count is
+// logged, so headless deployments show they are alive. Zero turns heartbeats off. Logs
+// never go to stdout, which carries the protocol.
+func (s *MCPServer) SetHeartbeat(interval time.Duration) {
+	s.heartbeat = interval
+}
+
+// SetBatchOptions sets how JSON-RPC batches are handled.
+func (s *MCPServer) SetBatchOptions(options BatchOptions) {
+	s.processor.SetBatchOptions(options)
+}
+
+// SetIO replaces the protocol input and output, stdin and stdout by default. It must be
+// called before Start or ServeOnce.
+func (s *MCPServer) SetIO(in io.Reader, out io.Writer) {
+	s.in, s.out = in, out
+}
+
 // Start begins the MCP server, reading from stdin and writing to stdout
 func (s *MCPServer) Start(ctx context.Context) error {
 	s.logger.Info("Starting MCP server")
-	decoder := json.NewDecoder(os.Stdin)
+	decoder := json.NewDecoder(s.in)
 
 	// Wait for initialize request first
 	var initMessage map[string]interface{}
@@ -33,6 +91,8 @@ func (s *MCPServer) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to read initialize request: %w", err)
 	}
 
+	s.countRequest(initMessage)
+
 	// Validate it's an initialize request
 	method, ok := initMessage["method"].(string)
 	if !ok || method != "initialize" {
@@ -40,38 +100,154 @@ func (s *MCPServer) Start(ctx context.Context) error {
 	}
 
 	id := initMessage["id"]
-	initResponse := s.processor.HandleInitialize(id)
+	initParams, _ := initMessage["params"].(map[string]interface{})
+	initResponse := s.processor.HandleInitializeContext(ctx, initParams, id)
 
 	if err := s.sendResponse(initResponse); err != nil {
 		s.logger.Error("Failed to send initialize response", "error", err)
 		return fmt.Errorf("failed to send initialize response: %w", err)
 	}
 
+	session := s.sessions.Add("stdio", s.writeMessage)
+	defer s.sessions.Remove(session.ID)
+	ctx = WithSessionID(ctx, session.ID)
+	version := initResponse.Result.(InitializeResult).ProtocolVersion
+	s.sessions.SetProtocolVersion(session.ID, version)
+	// The initialize exchange happened before the session existed
+	recorder := s.processor.toolService.Recorder()
+	recorder.Record(session.ID, RecordIn, initMessage)
+	recorder.Record(session.ID, RecordOut, initResponse)
+	if s.heartbeat > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go s.logHeartbeats(session.ID, time.Now(), stop)
+	}
+
 	s.logger.Info("MCP server is up and ready for requests")
 
+	// Messages are read apart from the main loop, which handles them in order, so pings
+	// and cancellations are handled while a long tool call runs
+	messages := make(chan stdioMessage)
+	go s.readMessages(ctx, decoder, messages)
+
 	// Main message loop
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
-		default:
-			var message map[string]interface{}
-			if err := decoder.Decode(&message); err != nil {
-				s.logger.Error("Failed to decode message", "error", err)
-				return fmt.Errorf("failed to decode message: %w", err)
+		case message := <-messages:
+			if message.err != nil {
+				s.logger.Error("Failed to decode message", "error", message.err)
+				s.processor.toolService.Events().publishTransportError("stdio", "read", message.err)
+				return fmt.Errorf("failed to decode message: %w", message.err)
 			}
 
-			if err := s.handleMessage(ctx, message); err != nil {
+			if err := s.handleMessage(ctx, message.message); err != nil {
 				s.logger.Error("Failed to handle message", "error", err)
+				s.processor.toolService.Events().publishTransportError("stdio", "write", err)
 				return fmt.Errorf("failed to handle message: %w", err)
 			}
 		}
 	}
 }
 
-// handleMessage processes incoming MCP messages
-func (s *MCPServer) handleMessage(ctx context.Context, message map[string]interface{}) error {
-	response := s.processor.Process(ctx, message)
+// stdioMessage is a message read from stdin, or the error that ended reading.
+type stdioMessage struct {
+	message interface{}
+	err     error
+}
+
+// readMessages decodes messages until an error and sends them to messages, handling pings
+// and cancellations itself. The error is sent last.
+func (s *MCPServer) readMessages(ctx context.Context, decoder *json.Decoder, messages chan<- stdioMessage) {
+	for {
+		// A message is a single request or a batch
+		var message interface{}
+		err := decoder.Decode(&message)
+		if err == nil {
+			s.countRequest(message)
+			if isControlMessage(message) {
+				if err := s.handleMessage(ctx, message); err != nil {
+					s.logger.Error("Failed to handle message", "error", err)
+				}
+				continue
+			}
+		}
+		select {
+		case messages <- stdioMessage{message: message, err: err}:
+		case <-ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// countRequest records a message read from stdin, counting each request of a batch.
+func (s *MCPServer) countRequest(message interface{}) {
+	if batch, ok := message.([]interface{}); ok {
+		for _, request := range batch {
+			s.countRequest(request)
+		}
+		return
+	}
+	s.requests.Add(1)
+	request, _ := message.(map[string]interface{})
+	method, _ := request["method"].(string)
+	if !stdioMethods[method] {
+		method = "other"
+	}
+	stdioRequestsTotal.WithLabelValues(method).Inc()
+}
+
+// logHeartbeats logs the session's uptime and request count every heartbeat interval
+// until stop is closed.
+func (s *MCPServer) logHeartbeats(sessionID string, started time.Time, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.logger.Info("Stdio heartbeat",
+				"sessionId", sessionID,
+				"uptime", time.Since(started).Round(time.Second).String(),
+				"requests", s.requests.Load(),
+			)
+		}
+	}
+}
+
+// ServeOnce reads a single JSON-RPC request from stdin, writes its response to stdout, and
+// returns the response. No initialize handshake is required and no session is registered.
+// Notifications produce no output and a nil response.
+func (s *MCPServer) ServeOnce(ctx context.Context) (*JSONRPCResponse, error) {
+	var message map[string]interface{}
+	if err := json.NewDecoder(s.in).Decode(&message); err != nil {
+		response := s.processor.CreateErrorResponse(nil, -32700, "Parse error")
+		if sendErr := s.sendResponse(response); sendErr != nil {
+			return nil, fmt.Errorf("failed to send response: %w", sendErr)
+		}
+		return response, nil
+	}
+	s.countRequest(message)
+
+	response := s.processor.Process(WithRequestID(ctx, uuid.NewString()), message)
+	if response == nil {
+		return nil, nil
+	}
+	if err := s.sendResponse(response); err != nil {
+		return nil, fmt.Errorf("failed to send response: %w", err)
+	}
+	return response, nil
+}
+
+// handleMessage processes incoming MCP messages. Stdio has no headers, so every message
+// gets a fresh request ID for log correlation.
+func (s *MCPServer) handleMessage(ctx context.Context, message interface{}) error {
+	response := s.processor.ProcessMessage(WithRequestID(ctx, uuid.NewString()), message)
 	if response == nil {
 		return nil
 	}
@@ -81,5 +257,15 @@ func (s *MCPServer) handleMessage(ctx context.Context, message map[string]interf
 
 // sendResponse sends a JSON-RPC response
 func (s *MCPServer) sendResponse(response interface{}) error {
-	return json.NewEncoder(os.Stdout).Encode(response)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return json.NewEncoder(s.out).Encode(response)
+}
+
+// writeMessage writes a pre-encoded JSON-RPC message as a single line
+func (s *MCPServer) writeMessage(message []byte) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.out.Write(append(message, '\n'))
+	return err
 }
@@ -1,10 +1,19 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"mcp-tools-server/internal/logging"
 	"mcp-tools-server/pkg/tools"
 )
 
@@ -30,3 +39,225 @@ func TestNewMCPServer(t *testing.T) {
 		t.Error("MCP server did not initialize the JSONRPCProcessor")
 	}
 }
+
+func TestMCPServer_StdoutCarriesOnlyProtocolFrames(t *testing.T) {
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create stdout pipe: %v", err)
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create stderr pipe: %v", err)
+	}
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdoutWriter, stderrWriter
+	defer func() { os.Stdout, os.Stderr = originalStdout, originalStderr }()
+
+	readAll := func(r *os.File) <-chan []byte {
+		done := make(chan []byte, 1)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- data
+		}()
+		return done
+	}
+	stdoutData, stderrData := readAll(stdoutReader), readAll(stderrReader)
+
+	// Debug logging maximizes the chance of a stray log line reaching stdout.
+	logger, _, _, err := logging.New(logging.Config{Level: "debug", Stdio: true})
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+	mcpServer := NewMCPServer(toolService, logger)
+	mcpServer.in = strings.NewReader(strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize"}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"missing"}}`,
+	}, "\n"))
+
+	// Start returns once stdin is exhausted.
+	_ = mcpServer.Start(context.Background())
+	stdoutWriter.Close()
+	stderrWriter.Close()
+
+	lines := strings.Split(strings.TrimSpace(string(<-stdoutData)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 protocol frames on stdout, got %d: %q", len(lines), lines)
+	}
+	for _, line := range lines {
+		var frame map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &frame); err != nil || frame["jsonrpc"] != "2.0" {
+			t.Errorf("Expected only JSON-RPC frames on stdout, got %q", line)
+		}
+	}
+	if len(<-stderrData) == 0 {
+		t.Error("Expected logs to be written to stderr")
+	}
+}
+
+func TestMCPServer_ServeOnce(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+
+	serveOnce := func(input string) (*JSONRPCResponse, string) {
+		var out strings.Builder
+		mcpServer := NewMCPServer(toolService, logger)
+		mcpServer.in = strings.NewReader(input)
+		mcpServer.out = &out
+		response, err := mcpServer.ServeOnce(context.Background())
+		if err != nil {
+			t.Fatalf("ServeOnce failed: %v", err)
+		}
+		return response, out.String()
+	}
+
+	t.Run("handles a request without initialize", func(t *testing.T) {
+		response, out := serveOnce(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"echo"}}` + "\n" +
+			`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		if response == nil || response.Error != nil {
+			t.Fatalf("Expected a successful response, got %+v", response)
+		}
+		if lines := strings.Split(strings.TrimSpace(out), "\n"); len(lines) != 1 {
+			t.Errorf("Expected exactly one response line, got %d", len(lines))
+		}
+	})
+
+	t.Run("invalid JSON returns a parse error", func(t *testing.T) {
+		response, out := serveOnce(`{not json`)
+		if response == nil || response.Error == nil || response.Error.Code != -32700 {
+			t.Fatalf("Expected parse error, got %+v", response)
+		}
+		if !strings.Contains(out, "-32700") {
+			t.Errorf("Expected the parse error to be written, got %q", out)
+		}
+	})
+
+	t.Run("notifications write nothing", func(t *testing.T) {
+		response, out := serveOnce(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+		if response != nil || out != "" {
+			t.Errorf("Expected no response, got %+v and %q", response, out)
+		}
+	})
+}
+
+func TestMCPServer_Observability(t *testing.T) {
+	t.Run("counts requests by method", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		toolService := newTestToolService(logger, &MockTool{name: "echo"})
+		mcpServer := NewMCPServer(toolService, logger)
+		mcpServer.in = strings.NewReader(strings.Join([]string{
+			`{"jsonrpc":"2.0","id":1,"method":"initialize"}`,
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"echo"}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"echo"}}`,
+			`{"jsonrpc":"2.0","id":4,"method":"made/up"}`,
+		}, "\n"))
+		mcpServer.out = io.Discard
+		initialize := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("initialize"))
+		calls := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("tools/call"))
+		other := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("other"))
+
+		_ = mcpServer.Start(context.Background())
+
+		if got := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("initialize")) - initialize; got != 1 {
+			t.Errorf("Expected 1 initialize request, got %v", got)
+		}
+		if got := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("tools/call")) - calls; got != 2 {
+			t.Errorf("Expected 2 tools/call requests, got %v", got)
+		}
+		if got := testutil.ToFloat64(stdioRequestsTotal.WithLabelValues("other")) - other; got != 1 {
+			t.Errorf("Expected unknown methods to count as other, got %v", got)
+		}
+	})
+
+	t.Run("logs heartbeats while the session is open", func(t *testing.T) {
+		var logs syncBuffer
+		logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		toolService := newTestToolService(logger, &MockTool{name: "echo"})
+		mcpServer := NewMCPServer(toolService, logger)
+		mcpServer.SetHeartbeat(10 * time.Millisecond)
+		in, stdin := io.Pipe()
+		mcpServer.in = in
+		mcpServer.out = io.Discard
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_ = mcpServer.Start(context.Background())
+		}()
+		_, _ = stdin.Write([]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize"}` + "\n"))
+		time.Sleep(50 * time.Millisecond)
+		stdin.Close()
+		<-done
+
+		output := logs.String()
+		if !strings.Contains(output, "Stdio heartbeat") || !strings.Contains(output, "uptime=") || !strings.Contains(output, "requests=1") {
+			t.Errorf("Expected heartbeat logs with uptime and request count, got %q", output)
+		}
+	})
+}
+
+// syncBuffer is a strings.Builder safe for concurrent writes.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMCPServer_PingAndCancellationDuringCall(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &contextMockTool{
+		MockTool: MockTool{name: "slow"},
+		executeContext: func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	})
+	stdinReader, stdin := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	mcpServer := NewMCPServer(toolService, logger)
+	mcpServer.SetIO(stdinReader, stdoutWriter)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = mcpServer.Start(ctx) }()
+	defer stdin.Close()
+
+	decoder := json.NewDecoder(stdoutReader)
+	exchange := func(message string, wantID float64) {
+		t.Helper()
+		if _, err := io.WriteString(stdin, message+"\n"); err != nil {
+			t.Fatalf("Failed to write %s: %v", message, err)
+		}
+		if wantID == 0 {
+			return
+		}
+		var response map[string]interface{}
+		if err := decoder.Decode(&response); err != nil {
+			t.Fatalf("Failed to read the response to %s: %v", message, err)
+		}
+		if response["id"] != wantID {
+			t.Fatalf("Expected the response to request %v, got %v", wantID, response)
+		}
+	}
+
+	exchange(`{"jsonrpc":"2.0","id":1,"method":"initialize"}`, 1)
+	exchange(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"slow"}}`, 0)
+	// The call is still running, yet the ping is answered and the cancellation handled
+	exchange(`{"jsonrpc":"2.0","id":3,"method":"ping"}`, 3)
+	exchange(`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":2}}`, 0)
+	// The cancelled call gets no response, so the next one is for the following request
+	exchange(`{"jsonrpc":"2.0","id":4,"method":"tools/list"}`, 4)
+}
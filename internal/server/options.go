@@ -0,0 +1,321 @@
+package server
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-tools-server/internal/listener"
+)
+
+// ServerOption configures the HTTP-based transport servers: NewHTTPServer,
+// NewStreamableHTTPServer, and NewWebSocketServer. Options that do not apply to a server
+// are ignored by it.
+type ServerOption func(*serverOptions)
+
+// serverOptions holds the settings shared by the HTTP-based transport servers.
+type serverOptions struct {
+	logger         *slog.Logger
+	bindAddress    string
+	network        string
+	port           int
+	certFile       string
+	keyFile        string
+	middleware     []func(http.Handler) http.Handler
+	originCheck    bool
+	allowedOrigins []string
+	limits         Limits
+	keepAlive      KeepAlive
+	listener       net.Listener
+	reusePort      bool
+	trustedProxies TrustedProxies
+	tenants        *Tenants
+	adminToken     string
+	chaos          *Chaos
+	batch          BatchOptions
+	sseResume      SSEResumeOptions
+	stateless      bool
+	wsResumeTTL    time.Duration
+}
+
+// Limits bounds how long a client may take to send a request and how large it may be,
+// protecting listeners from slow-loris connections and oversized payloads. A zero value
+// disables the corresponding limit.
+type Limits struct {
+	ReadHeaderTimeout time.Duration // Time allowed to read request headers
+	ReadTimeout       time.Duration // Time allowed to read the entire request, including the body
+	WriteTimeout      time.Duration // Time allowed to write the response
+	IdleTimeout       time.Duration // Time a keep-alive connection may sit idle
+	MaxHeaderBytes    int           // Maximum size of request headers
+	MaxBodyBytes      int64         // Maximum size of a request body or WebSocket message
+}
+
+// DefaultLimits returns the limits applied when WithLimits is not given.
+func DefaultLimits() Limits {
+	return Limits{
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20,
+		MaxBodyBytes:      1 << 20,
+	}
+}
+
+// KeepAlive configures how the streaming transports keep idle connections open, notice
+// dead peers, and tell clients when to reconnect.
+type KeepAlive struct {
+	SSEInterval          time.Duration // Between keepalive comments on idle Streamable HTTP and admin event SSE streams
+	SSERetry             time.Duration // Reconnection delay suggested to SSE clients with a retry field; zero sends none
+	WebSocketPing        time.Duration // Between pings on WebSocket connections; zero sends none
+	WebSocketPongTimeout time.Duration // Time a WebSocket peer has to answer a ping before it is disconnected
+}
+
+// DefaultKeepAlive returns the keepalive settings applied when WithKeepAlive is not given.
+func DefaultKeepAlive() KeepAlive {
+	return KeepAlive{
+		SSEInterval:          15 * time.Second,
+		WebSocketPing:        30 * time.Second,
+		WebSocketPongTimeout: 10 * time.Second,
+	}
+}
+
+// newServerOptions applies opts over the defaults for a server listening on defaultPort.
+func newServerOptions(defaultPort int, opts []ServerOption) serverOptions {
+	options := serverOptions{
+		logger:         slog.Default(),
+		port:           defaultPort,
+		network:        "tcp",
+		allowedOrigins: []string{"*"},
+		limits:         DefaultLimits(),
+		keepAlive:      DefaultKeepAlive(),
+		batch:          DefaultBatchOptions(),
+		sseResume:      DefaultSSEResumeOptions(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithLogger sets the server's logger. The default is slog.Default().
+func WithLogger(logger *slog.Logger) ServerOption {
+	return func(o *serverOptions) {
+		o.logger = logger
+	}
+}
+
+// WithPort sets the port the server listens on.
+func WithPort(port int) ServerOption {
+	return func(o *serverOptions) {
+		o.port = port
+	}
+}
+
+// WithBindAddress restricts the server to one interface, such as "127.0.0.1" or "::1".
+// The default, an empty address, listens on all interfaces.
+func WithBindAddress(address string) ServerOption {
+	return func(o *serverOptions) {
+		// Accept bracketed IPv6 literals; JoinHostPort adds the brackets back.
+		o.bindAddress = strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	}
+}
+
+// WithNetwork sets the listener network: "tcp" (dual-stack, the default), "tcp4", or
+// "tcp6" (IPv6 only). See listener.Network.
+func WithNetwork(network string) ServerOption {
+	return func(o *serverOptions) {
+		o.network = network
+	}
+}
+
+// WithTLS serves HTTPS using the given certificate and key files.
+func WithTLS(certFile, keyFile string) ServerOption {
+	return func(o *serverOptions) {
+		o.certFile = certFile
+		o.keyFile = keyFile
+	}
+}
+
+// WithMiddleware wraps the server's handler with HTTP middleware. Middleware runs in the
+// order given, inside request ID and client IP resolution, so it can read
+// RequestIDFromContext and ClientIPFromContext.
+func WithMiddleware(middleware ...func(http.Handler) http.Handler) ServerOption {
+	return func(o *serverOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithOriginCheck configures Origin header validation on the Streamable HTTP server.
+func WithOriginCheck(enabled bool, allowedOrigins []string) ServerOption {
+	return func(o *serverOptions) {
+		o.originCheck = enabled
+		o.allowedOrigins = allowedOrigins
+	}
+}
+
+// WithLimits sets the request timeouts and size limits. The default is DefaultLimits().
+func WithLimits(limits Limits) ServerOption {
+	return func(o *serverOptions) {
+		o.limits = limits
+	}
+}
+
+// WithKeepAlive sets the keepalive intervals and timeouts of the streaming transports.
+// The default is DefaultKeepAlive().
+func WithKeepAlive(keepAlive KeepAlive) ServerOption {
+	return func(o *serverOptions) {
+		o.keepAlive = keepAlive
+	}
+}
+
+// WithListener serves on an existing listener, such as one inherited through socket
+// activation, instead of binding the configured port.
+func WithListener(l net.Listener) ServerOption {
+	return func(o *serverOptions) {
+		o.listener = l
+	}
+}
+
+// WithReusePort binds the port with SO_REUSEPORT so a replacement process can start
+// listening before this one stops.
+func WithReusePort(enabled bool) ServerOption {
+	return func(o *serverOptions) {
+		o.reusePort = enabled
+	}
+}
+
+// WithTrustedProxies sets the proxies whose forwarding headers determine the client IP.
+// By default no proxy is trusted and the client IP is the connection's remote address.
+func WithTrustedProxies(proxies TrustedProxies) ServerOption {
+	return func(o *serverOptions) {
+		o.trustedProxies = proxies
+	}
+}
+
+// WithTenants requires an API key on every request and scopes the request to the key's
+// tenant. Exempt are the health probes (/health, /livez, /readyz), /admin/ endpoints,
+// which authenticate operators instead (see WithAdminToken), and /approvals/ callbacks,
+// which are authenticated by their signature. Add tenants.ToolMiddleware() to the
+// ToolService to enforce each tenant's allow-list and rate limit.
+func WithTenants(tenants *Tenants) ServerOption {
+	return func(o *serverOptions) {
+		o.tenants = tenants
+	}
+}
+
+// WithChaos drops Streamable HTTP SSE events at the chaos rate. Add chaos.Middleware() to
+// the ToolService to inject tool latency and errors as well.
+func WithChaos(chaos *Chaos) ServerOption {
+	return func(o *serverOptions) {
+		o.chaos = chaos
+	}
+}
+
+// WithBatch sets how the Streamable HTTP and WebSocket transports handle JSON-RPC batches.
+func WithBatch(options BatchOptions) ServerOption {
+	return func(o *serverOptions) {
+		o.batch = options
+	}
+}
+
+// WithSSEResume sets how many events of each Streamable HTTP SSE stream are kept for
+// clients resuming the stream with Last-Event-ID.
+func WithSSEResume(options SSEResumeOptions) ServerOption {
+	return func(o *serverOptions) {
+		o.sseResume = options
+	}
+}
+
+// WithStateless runs the Streamable HTTP server without sessions, for load balancers that
+// route each request to any replica. Each POST is handled on its own, and GET /mcp, the
+// stream of server-initiated messages, is refused.
+func WithStateless(stateless bool) ServerOption {
+	return func(o *serverOptions) {
+		o.stateless = stateless
+	}
+}
+
+// wrap applies the configured middleware, tenant identification, client IP resolution,
+// request ID assignment, and body size limit to handler.
+func (o serverOptions) wrap(handler http.Handler) http.Handler {
+	for i := len(o.middleware) - 1; i >= 0; i-- {
+		handler = o.middleware[i](handler)
+	}
+	if o.tenants != nil {
+		handler = o.tenants.Middleware(handler)
+	}
+	handler = clientIPMiddleware(o.trustedProxies, handler)
+	handler = requestIDMiddleware(handler)
+	if o.limits.MaxBodyBytes > 0 {
+		handler = http.MaxBytesHandler(handler, o.limits.MaxBodyBytes)
+	}
+	return handler
+}
+
+// addr returns the host:port address the server binds.
+func (o serverOptions) addr() string {
+	return net.JoinHostPort(o.bindAddress, strconv.Itoa(o.port))
+}
+
+// httpServer creates an http.Server for handler on the configured address with the
+// configured timeouts and header limit.
+func (o serverOptions) httpServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              o.addr(),
+		Handler:           handler,
+		ReadHeaderTimeout: o.limits.ReadHeaderTimeout,
+		ReadTimeout:       o.limits.ReadTimeout,
+		WriteTimeout:      o.limits.WriteTimeout,
+		IdleTimeout:       o.limits.IdleTimeout,
+		MaxHeaderBytes:    o.limits.MaxHeaderBytes,
+	}
+}
+
+// bind creates the server's listener on the configured address unless one was given
+// with WithListener or already bound, and returns it.
+func (o *serverOptions) bind() (net.Listener, error) {
+	if o.listener == nil {
+		l, err := listener.Listen(o.network, o.addr(), o.reusePort)
+		if err != nil {
+			return nil, err
+		}
+		o.listener = l
+	}
+	return o.listener, nil
+}
+
+// boundAddr returns the address of the bound listener, which reports the actual port when
+// port 0 was requested, or the configured address before binding.
+func (o *serverOptions) boundAddr() string {
+	if o.listener != nil {
+		return o.listener.Addr().String()
+	}
+	return o.addr()
+}
+
+// listenAndServe serves srv on the server's listener, binding it first if needed, over
+// HTTPS when TLS is configured and plain HTTP otherwise.
+func (o *serverOptions) listenAndServe(srv *http.Server) error {
+	l, err := o.bind()
+	if err != nil {
+		return err
+	}
+	if o.certFile != "" {
+		return srv.ServeTLS(l, o.certFile, o.keyFile)
+	}
+	return srv.Serve(l)
+}
+
+// WithWebSocketResume lets WebSocket clients resume their session for ttl after their
+// connection drops. The initialize result carries a resume token in its _meta; connecting
+// to /ws?resume=<token> re-attaches the session, with its state and the messages queued
+// for it. Zero, the default, ends sessions when their connection does.
+func WithWebSocketResume(ttl time.Duration) ServerOption {
+	return func(o *serverOptions) {
+		o.wsResumeTTL = ttl
+	}
+}
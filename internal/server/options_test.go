@@ -0,0 +1,103 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestServerOptions(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		options := newServerOptions(8080, nil)
+		if options.port != 8080 {
+			t.Errorf("Expected default port 8080, got %d", options.port)
+		}
+		if addr := options.addr(); addr != ":8080" {
+			t.Errorf("Expected to bind all interfaces, got %s", addr)
+		}
+		if options.logger == nil {
+			t.Error("Expected a default logger")
+		}
+		if options.originCheck || len(options.allowedOrigins) != 1 || options.allowedOrigins[0] != "*" {
+			t.Errorf("Expected origin check disabled with wildcard origins, got %v %v", options.originCheck, options.allowedOrigins)
+		}
+	})
+
+	t.Run("options override defaults", func(t *testing.T) {
+		options := newServerOptions(8080, []ServerOption{
+			WithPort(9090),
+			WithBindAddress("127.0.0.1"),
+			WithTLS("cert.pem", "key.pem"),
+			WithOriginCheck(true, []string{"https://example.com"}),
+		})
+		if addr := options.addr(); addr != "127.0.0.1:9090" {
+			t.Errorf("Expected address 127.0.0.1:9090, got %s", addr)
+		}
+		if options.certFile != "cert.pem" || options.keyFile != "key.pem" {
+			t.Errorf("Expected TLS files to be set, got %q %q", options.certFile, options.keyFile)
+		}
+		if !options.originCheck || options.allowedOrigins[0] != "https://example.com" {
+			t.Errorf("Expected origin check enabled, got %v %v", options.originCheck, options.allowedOrigins)
+		}
+	})
+
+	t.Run("middleware runs in order and sees the request ID", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		var order []string
+		var seenID string
+		tag := func(name string) func(http.Handler) http.Handler {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					seenID = RequestIDFromContext(r.Context())
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+
+		httpServer := NewHTTPServer(newTestToolService(logger), WithLogger(logger), WithMiddleware(tag("first"), tag("second")))
+		req := httptest.NewRequest("GET", "/health", nil)
+		req.Header.Set(requestIDHeader, "opt-1")
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+
+		if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+			t.Errorf("Expected middleware order [first second], got %v", order)
+		}
+		if seenID != "opt-1" {
+			t.Errorf("Expected middleware to see request ID opt-1, got %q", seenID)
+		}
+	})
+
+	t.Run("IPv6 bind addresses are bracketed", func(t *testing.T) {
+		for _, address := range []string{"::1", "[::1]"} {
+			options := newServerOptions(8080, []ServerOption{WithBindAddress(address)})
+			if addr := options.addr(); addr != "[::1]:8080" {
+				t.Errorf("WithBindAddress(%q): expected [::1]:8080, got %s", address, addr)
+			}
+		}
+	})
+
+	t.Run("limits are applied to the listener and request bodies", func(t *testing.T) {
+		limits := DefaultLimits()
+		limits.MaxBodyBytes = 16
+		options := newServerOptions(8080, []ServerOption{WithLimits(limits)})
+
+		srv := options.httpServer(nil)
+		if srv.ReadHeaderTimeout != limits.ReadHeaderTimeout || srv.MaxHeaderBytes != limits.MaxHeaderBytes {
+			t.Errorf("Expected listener limits to be applied, got %+v", srv)
+		}
+
+		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		httpServer := NewHTTPServer(newTestToolService(logger, &MockTool{name: "echo"}), WithLogger(logger), WithLimits(limits))
+		req := httptest.NewRequest("POST", "/api/v1/tools/echo", strings.NewReader(`{"text":"more than sixteen bytes"}`))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	runtimemetrics "runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+)
+
+// Metrics for overload shedding.
+var (
+	overloadedGauge = metrics.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "overload",
+			Name:      "active",
+			Help:      "Whether the server is overloaded and shedding new sessions (1) or not (0)",
+		},
+	)
+	overloadShedTotal = metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "overload",
+			Name:      "shed_total",
+			Help:      "Total number of new sessions rejected because the server was overloaded",
+		},
+		[]string{"transport"},
+	)
+)
+
+// overloadSampleInterval bounds how often goroutines and memory are measured.
+const overloadSampleInterval = time.Second
+
+// heapLiveMetric is the runtime metric compared against OverloadPolicy.MaxMemoryBytes:
+// the heap still reachable at the last garbage collection.
+const heapLiveMetric = "/gc/heap/live:bytes"
+
+// OverloadPolicy sets the limits past which the server is overloaded. Zero limits are
+// not checked, so the zero policy never sheds.
+type OverloadPolicy struct {
+	MaxInFlight    int           // Tool calls running or queued at once
+	MaxGoroutines  int           // Goroutines in the process
+	MaxMemoryBytes uint64        // Live heap after the last garbage collection
+	RetryAfter     time.Duration // Sent in Retry-After to clients that are turned away
+}
+
+// OverloadDetector decides whether the server is saturated. While it is, readiness fails
+// so load balancers route new traffic elsewhere, and new MCP sessions are rejected with
+// Retry-After. Sessions already open keep being served, so in-flight work finishes.
+type OverloadDetector struct {
+	mu        sync.Mutex
+	policy    OverloadPolicy
+	inFlight  atomic.Int64
+	sampledAt time.Time
+	reason    string // Why the last sample was over a limit; empty when it was not
+	shedding  bool   // Overloaded at the last check, to log and export changes
+	logger    *slog.Logger
+}
+
+// NewOverloadDetector creates an OverloadDetector with no limits.
+func NewOverloadDetector(logger *slog.Logger) *OverloadDetector {
+	return &OverloadDetector{logger: logger}
+}
+
+// SetPolicy replaces the overload limits.
+func (d *OverloadDetector) SetPolicy(policy OverloadPolicy) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.policy = policy
+	d.sampledAt = time.Time{}
+}
+
+// Policy returns the overload limits.
+func (d *OverloadDetector) Policy() OverloadPolicy {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.policy
+}
+
+// Middleware counts the tool calls in flight, including calls queued by a throttle.
+func (d *OverloadDetector) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			d.inFlight.Add(1)
+			defer d.inFlight.Add(-1)
+			return next(ctx, name, args)
+		}
+	}
+}
+
+// Overloaded reports whether the server is over one of its limits, and if so, which.
+// Tool calls in flight are counted exactly; goroutines and memory are measured at most
+// once per second.
+func (d *OverloadDetector) Overloaded() (bool, string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	policy := d.policy
+	reason := ""
+	if inFlight := d.inFlight.Load(); policy.MaxInFlight > 0 && inFlight >= int64(policy.MaxInFlight) {
+		reason = fmt.Sprintf("%d tool calls in flight", inFlight)
+	} else {
+		if time.Since(d.sampledAt) >= overloadSampleInterval {
+			d.reason = sampleOverload(policy)
+			d.sampledAt = time.Now()
+		}
+		reason = d.reason
+	}
+
+	overloaded := reason != ""
+	if overloaded != d.shedding {
+		d.shedding = overloaded
+		if overloaded {
+			overloadedGauge.Set(1)
+			d.logger.Warn("Server overloaded; shedding new sessions", "reason", reason)
+		} else {
+			overloadedGauge.Set(0)
+			d.logger.Info("Server no longer overloaded")
+		}
+	}
+	return overloaded, reason
+}
+
+// sampleOverload measures goroutines and memory against policy and returns why they are
+// over a limit, or "" if they are not.
+func sampleOverload(policy OverloadPolicy) string {
+	if goroutines := runtime.NumGoroutine(); policy.MaxGoroutines > 0 && goroutines >= policy.MaxGoroutines {
+		return fmt.Sprintf("%d goroutines", goroutines)
+	}
+	if policy.MaxMemoryBytes > 0 {
+		sample := []runtimemetrics.Sample{{Name: heapLiveMetric}}
+		runtimemetrics.Read(sample)
+		if sample[0].Value.Kind() == runtimemetrics.KindUint64 && sample[0].Value.Uint64() >= policy.MaxMemoryBytes {
+			return fmt.Sprintf("%d bytes of live heap", sample[0].Value.Uint64())
+		}
+	}
+	return ""
+}
+
+// Shed rejects a new session on transport with 503 and Retry-After when the server is
+// overloaded, and reports whether it did.
+func (d *OverloadDetector) Shed(w http.ResponseWriter, transport string) bool {
+	overloaded, reason := d.Overloaded()
+	if !overloaded {
+		return false
+	}
+	overloadShedTotal.WithLabelValues(transport).Inc()
+	d.setRetryAfter(w)
+	http.Error(w, "Server overloaded ("+reason+"); retry later", http.StatusServiceUnavailable)
+	return true
+}
+
+// setRetryAfter sets the Retry-After header from the policy, if it has one.
+func (d *OverloadDetector) setRetryAfter(w http.ResponseWriter) {
+	if retryAfter := d.Policy().RetryAfter; retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((retryAfter+time.Second-1)/time.Second)))
+	}
+}
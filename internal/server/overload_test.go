@@ -0,0 +1,107 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOverloadDetector(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	release := make(chan struct{})
+	started := make(chan struct{})
+	slow := &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		close(started)
+		<-release
+		return map[string]interface{}{"done": true}, nil
+	}}
+	toolService := newTestToolService(logger, slow)
+	toolService.Overload().SetPolicy(OverloadPolicy{MaxInFlight: 1, RetryAfter: 5 * time.Second})
+	httpServer := NewHTTPServer(toolService, WithLogger(logger))
+
+	readyz := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		return w
+	}
+
+	t.Run("ready below the limits", func(t *testing.T) {
+		if w := readyz(); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = toolService.ExecuteTool("slow", nil)
+	}()
+	<-started
+
+	t.Run("readiness fails while overloaded", func(t *testing.T) {
+		w := readyz()
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+			t.Fatalf("Expected 503 with Retry-After 5, got %d %q", w.Code, w.Header().Get("Retry-After"))
+		}
+		if !strings.Contains(w.Body.String(), "overloaded: 1 tool calls in flight") {
+			t.Errorf("Expected the overload reason, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("new sessions are shed", func(t *testing.T) {
+		shed := testutil.ToFloat64(overloadShedTotal.WithLabelValues("streamable"))
+		streamable := NewStreamableHTTPServer(toolService, WithLogger(logger))
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		streamable.handleMCP(w, req)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "5" {
+			t.Errorf("Expected initialize to be shed with 503, got %d", w.Code)
+		}
+		if got := testutil.ToFloat64(overloadShedTotal.WithLabelValues("streamable")); got != shed+1 {
+			t.Errorf("Expected %v shed sessions, got %v", shed+1, got)
+		}
+
+		w = httptest.NewRecorder()
+		NewWebSocketServer(toolService, WithLogger(logger)).handleWebSocket(w, httptest.NewRequest(http.MethodGet, "/ws", nil))
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected the WebSocket upgrade to be shed with 503, got %d", w.Code)
+		}
+	})
+
+	t.Run("requests of open sessions are still served", func(t *testing.T) {
+		streamable := NewStreamableHTTPServer(toolService, WithLogger(logger))
+		body := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		streamable.handleMCP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	close(release)
+	<-done
+
+	t.Run("ready again once in-flight work finishes", func(t *testing.T) {
+		if w := readyz(); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("goroutine limit", func(t *testing.T) {
+		detector := NewOverloadDetector(logger)
+		detector.SetPolicy(OverloadPolicy{MaxGoroutines: 1})
+		if overloaded, reason := detector.Overloaded(); !overloaded || !strings.Contains(reason, "goroutines") {
+			t.Errorf("Expected the goroutine limit to be exceeded, got %v %q", overloaded, reason)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"context"
+	"slices"
+)
+
+// MCP protocol versions this server speaks.
+const (
+	ProtocolVersion20250618 = "2025-06-18"
+	ProtocolVersion20250326 = "2025-03-26"
+	ProtocolVersion20241105 = "2024-11-05"
+)
+
+// SupportedProtocolVersions lists the protocol versions this server speaks, newest first.
+var SupportedProtocolVersions = []string{ProtocolVersion20250618, ProtocolVersion20250326, ProtocolVersion20241105}
+
+// LatestProtocolVersion is the newest protocol version this server speaks.
+const LatestProtocolVersion = ProtocolVersion20250618
+
+// protocolVersionHeader carries the negotiated protocol version on Streamable HTTP
+// requests after initialization.
+const protocolVersionHeader = "Mcp-Protocol-Version"
+
+// NegotiateProtocolVersion returns the version to use with a client requesting the
+// given version: the requested version when supported, otherwise the latest, which the
+// client may reject by disconnecting.
+func NegotiateProtocolVersion(requested string) string {
+	if IsSupportedProtocolVersion(requested) {
+		return requested
+	}
+	return LatestProtocolVersion
+}
+
+// IsSupportedProtocolVersion reports whether version is one this server speaks.
+func IsSupportedProtocolVersion(version string) bool {
+	return slices.Contains(SupportedProtocolVersions, version)
+}
+
+// protocolAtLeast reports whether version is the given version or newer. Versions are
+// dates, so they compare as strings.
+func protocolAtLeast(version, since string) bool {
+	return version >= since
+}
+
+type protocolVersionKey struct{}
+
+// WithProtocolVersion returns a copy of ctx carrying the protocol version negotiated
+// with the client that sent a message.
+func WithProtocolVersion(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, protocolVersionKey{}, version)
+}
+
+// ProtocolVersionFromContext returns the protocol version stored in ctx, or "" if there
+// is none.
+func ProtocolVersionFromContext(ctx context.Context) string {
+	version, _ := ctx.Value(protocolVersionKey{}).(string)
+	return version
+}
+
+// protocolVersion returns the protocol version negotiated with the client that sent the
+// message being processed: the one in ctx, else the one recorded for its session, else
+// the latest.
+func (p *JSONRPCProcessor) protocolVersion(ctx context.Context) string {
+	if version := ProtocolVersionFromContext(ctx); version != "" {
+		return version
+	}
+	if version := p.toolService.Sessions().ProtocolVersion(SessionIDFromContext(ctx)); version != "" {
+		return version
+	}
+	return LatestProtocolVersion
+}
+
+// downgradeResult rewrites the content blocks of a normalized tool result that clients of
+// the given protocol version do not know. resource_link blocks, added in 2025-06-18,
+// become text blocks naming the link.
+func downgradeResult(result map[string]interface{}, version string) map[string]interface{} {
+	if result == nil || protocolAtLeast(version, ProtocolVersion20250618) {
+		return result
+	}
+	return downgradeContent(result).(map[string]interface{})
+}
+
+// downgradeContent replaces the resource_link blocks in value with text blocks.
+func downgradeContent(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if v["type"] == "resource_link" {
+			text, _ := v["uri"].(string)
+			if name, _ := v["name"].(string); name != "" {
+				text = name + ": " + text
+			}
+			return map[string]interface{}{"type": "text", "text": text}
+		}
+		downgraded := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			downgraded[key] = downgradeContent(item)
+		}
+		return downgraded
+	case []interface{}:
+		downgraded := make([]interface{}, len(v))
+		for i, item := range v {
+			downgraded[i] = downgradeContent(item)
+		}
+		return downgraded
+	default:
+		return value
+	}
+}
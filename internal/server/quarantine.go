@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// ErrToolNotFound is returned when a call names a tool that is not registered.
+var ErrToolNotFound = errors.New("tool not found")
+
+// ErrToolDisabled is returned while a tool is quarantined after repeated failures.
+var ErrToolDisabled = errors.New("temporarily disabled")
+
+// QuarantinePolicy controls when a failing tool is disabled. A FailureRate of zero turns
+// quarantine off.
+type QuarantinePolicy struct {
+	FailureRate float64       // Fraction of failed calls (0-1] that trips quarantine
+	MinCalls    int           // Calls in the window before the rate is evaluated
+	Window      time.Duration // Period over which calls and failures are counted
+	Cooldown    time.Duration // How long a tool stays disabled before it is retried
+}
+
+// toolHealth tracks recent outcomes for one tool.
+type toolHealth struct {
+	windowStart   time.Time
+	calls         int
+	failures      int
+	disabledUntil time.Time
+	probation     bool
+	probing       bool // A caller is running the health check that may re-enable the tool
+}
+
+// QuarantineStatus describes a quarantined tool.
+type QuarantineStatus struct {
+	Tool          string    `json:"tool"`
+	DisabledUntil time.Time `json:"disabledUntil"`
+	Calls         int       `json:"calls"`
+	Failures      int       `json:"failures"`
+}
+
+// Quarantine disables tools whose failure rate exceeds the policy so agents stop burning
+// turns on a broken integration. After the cooldown a tool that implements
+// tools.HealthChecker must pass its check to be re-enabled; any other tool is re-enabled
+// on probation, where a single failure quarantines it again.
+type Quarantine struct {
+	mu     sync.Mutex
+	policy QuarantinePolicy
+	health map[string]*toolHealth
+	lookup func(name string) (tools.Tool, bool)
+	now    func() time.Time
+	events *EventBus
+	logger *slog.Logger
+}
+
+// NewQuarantine creates a disabled Quarantine. lookup resolves tools for health checks.
+func NewQuarantine(lookup func(name string) (tools.Tool, bool), logger *slog.Logger) *Quarantine {
+	return &Quarantine{
+		health: make(map[string]*toolHealth),
+		lookup: lookup,
+		now:    time.Now,
+		logger: logger,
+	}
+}
+
+// SetPolicy replaces the quarantine policy and clears any recorded outcomes.
+func (q *Quarantine) SetPolicy(policy QuarantinePolicy) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.policy = policy
+	q.health = make(map[string]*toolHealth)
+}
+
+// Middleware returns the ToolMiddleware that enforces the quarantine.
+func (q *Quarantine) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			if err := q.admit(ctx, name); err != nil {
+				return nil, err
+			}
+			result, err := next(ctx, name, args)
+			if !errors.Is(err, ErrToolNotFound) && !cancelledByClient(ctx, err) {
+				q.record(name, err == nil)
+			}
+			return result, err
+		}
+	}
+}
+
+// admit rejects calls to a quarantined tool and retries the tool once its cooldown ends.
+// Only the first call after the cooldown runs the health check; calls arriving while it
+// runs are still rejected.
+func (q *Quarantine) admit(ctx context.Context, name string) error {
+	q.mu.Lock()
+	h, ok := q.health[name]
+	if !ok || h.disabledUntil.IsZero() {
+		q.mu.Unlock()
+		return nil
+	}
+	if until := h.disabledUntil; h.probing || q.now().Before(until) {
+		q.mu.Unlock()
+		return disabledError(name, until)
+	}
+	h.probing = true
+	q.mu.Unlock()
+
+	// The cooldown has passed. Run the health check outside the lock since it may be slow.
+	var err error
+	if tool, ok := q.lookup(name); ok {
+		if checker, ok := tool.(tools.HealthChecker); ok {
+			checkCtx, cancel := context.WithTimeout(ctx, defaultHealthCheckTimeout)
+			err = checker.HealthCheck(checkCtx)
+			cancel()
+		}
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h.probing = false
+	if q.health[name] != h {
+		// Released, or cleared by SetPolicy, while the check ran
+		return nil
+	}
+	if err != nil {
+		until := q.now().Add(q.policy.Cooldown)
+		h.disabledUntil = until
+		q.logger.Warn("Tool health check failed, quarantine extended", "tool", name, "until", until, "error", err)
+		return disabledError(name, until)
+	}
+	*h = toolHealth{windowStart: q.now(), probation: true}
+	q.logger.Info("Tool re-enabled after quarantine", "tool", name)
+	return nil
+}
+
+// record counts a call outcome and quarantines the tool when the policy is exceeded.
+func (q *Quarantine) record(name string, success bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.policy.FailureRate <= 0 {
+		return
+	}
+
+	now := q.now()
+	h, ok := q.health[name]
+	if !ok || now.Sub(h.windowStart) > q.policy.Window {
+		probation := ok && h.probation
+		h = &toolHealth{windowStart: now, probation: probation}
+		q.health[name] = h
+	}
+	h.calls++
+	if success {
+		h.probation = false
+		return
+	}
+	h.failures++
+
+	rate := float64(h.failures) / float64(h.calls)
+	if h.probation || (h.calls >= q.policy.MinCalls && rate >= q.policy.FailureRate) {
+		h.disabledUntil = now.Add(q.policy.Cooldown)
+		q.logger.Warn("Tool quarantined after repeated failures",
+			"tool", name, "calls", h.calls, "failures", h.failures, "until", h.disabledUntil)
+		q.events.Publish(EventToolQuarantined, map[string]interface{}{
+			"tool":          name,
+			"calls":         h.calls,
+			"failures":      h.failures,
+			"failureRate":   rate,
+			"disabledUntil": h.disabledUntil,
+		})
+	}
+}
+
+// Release re-enables a quarantined tool immediately, reporting whether it was quarantined.
+func (q *Quarantine) Release(name string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	h, ok := q.health[name]
+	if !ok || h.disabledUntil.IsZero() {
+		return false
+	}
+	delete(q.health, name)
+	q.logger.Info("Tool released from quarantine", "tool", name)
+	return true
+}
+
+// Quarantined returns the tools currently disabled, ordered by name.
+func (q *Quarantine) Quarantined() []QuarantineStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	statuses := make([]QuarantineStatus, 0)
+	for name, h := range q.health {
+		if h.disabledUntil.After(now) {
+			statuses = append(statuses, QuarantineStatus{
+				Tool:          name,
+				DisabledUntil: h.disabledUntil,
+				Calls:         h.calls,
+				Failures:      h.failures,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Tool < statuses[j].Tool })
+	return statuses
+}
+
+// disabledError builds the error returned for calls to a quarantined tool.
+func disabledError(name string, until time.Time) error {
+	return fmt.Errorf("tool %s is %w after repeated failures; retry after %s",
+		name, ErrToolDisabled, until.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// healthCheckedMockTool is a MockTool whose health check result can be controlled.
+type healthCheckedMockTool struct {
+	MockTool
+	healthErr error
+}
+
+func (m *healthCheckedMockTool) HealthCheck(ctx context.Context) error { return m.healthErr }
+
+// slowHealthMockTool is a MockTool whose health check counts its runs and waits for
+// release to be closed.
+type slowHealthMockTool struct {
+	MockTool
+	checks  atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (m *slowHealthMockTool) HealthCheck(ctx context.Context) error {
+	if m.checks.Add(1) == 1 {
+		close(m.started)
+	}
+	<-m.release
+	return nil
+}
+
+// newQuarantineTestService creates a ToolService with a quarantine policy and a fake clock.
+func newQuarantineTestService(t *testing.T, tool tools.Tool) (*ToolService, *time.Time) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, tool)
+	service.Quarantine().SetPolicy(QuarantinePolicy{
+		FailureRate: 0.5,
+		MinCalls:    2,
+		Window:      time.Minute,
+		Cooldown:    30 * time.Second,
+	})
+	now := time.Now()
+	service.Quarantine().now = func() time.Time { return now }
+	return service, &now
+}
+
+func TestQuarantine(t *testing.T) {
+	failing := errors.New("upstream down")
+
+	t.Run("repeated failures disable the tool", func(t *testing.T) {
+		calls := 0
+		tool := &MockTool{name: "flaky", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			calls++
+			return nil, failing
+		}}
+		service, _ := newQuarantineTestService(t, tool)
+
+		for i := 0; i < 2; i++ {
+			if _, err := service.ExecuteTool("flaky", nil); !errors.Is(err, failing) {
+				t.Fatalf("Expected tool error on call %d, got %v", i, err)
+			}
+		}
+		_, err := service.ExecuteTool("flaky", nil)
+		if !errors.Is(err, ErrToolDisabled) {
+			t.Fatalf("Expected ErrToolDisabled, got %v", err)
+		}
+		if calls != 2 {
+			t.Errorf("Expected the disabled tool not to run, got %d calls", calls)
+		}
+		if quarantined := service.Quarantine().Quarantined(); len(quarantined) != 1 || quarantined[0].Tool != "flaky" {
+			t.Errorf("Expected flaky to be listed as quarantined, got %v", quarantined)
+		}
+	})
+
+	t.Run("successes keep the rate below the threshold", func(t *testing.T) {
+		fail := false
+		tool := &MockTool{name: "mostly_ok", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			fail = !fail
+			if fail {
+				return nil, failing
+			}
+			return map[string]interface{}{}, nil
+		}}
+		service, _ := newQuarantineTestService(t, tool)
+		service.Quarantine().SetPolicy(QuarantinePolicy{FailureRate: 0.75, MinCalls: 2, Window: time.Minute, Cooldown: time.Minute})
+
+		for i := 0; i < 6; i++ {
+			if _, err := service.ExecuteTool("mostly_ok", nil); errors.Is(err, ErrToolDisabled) {
+				t.Fatalf("Expected tool to stay enabled, disabled on call %d", i)
+			}
+		}
+	})
+
+	t.Run("tool is re-enabled on probation after the cooldown", func(t *testing.T) {
+		fail := true
+		tool := &MockTool{name: "recovering", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			if fail {
+				return nil, failing
+			}
+			return map[string]interface{}{}, nil
+		}}
+		service, now := newQuarantineTestService(t, tool)
+		service.ExecuteTool("recovering", nil)
+		service.ExecuteTool("recovering", nil)
+
+		*now = now.Add(31 * time.Second)
+		if _, err := service.ExecuteTool("recovering", nil); !errors.Is(err, failing) {
+			t.Fatalf("Expected the tool to run after the cooldown, got %v", err)
+		}
+		if _, err := service.ExecuteTool("recovering", nil); !errors.Is(err, ErrToolDisabled) {
+			t.Fatalf("Expected a failure on probation to quarantine again, got %v", err)
+		}
+
+		*now = now.Add(31 * time.Second)
+		fail = false
+		if _, err := service.ExecuteTool("recovering", nil); err != nil {
+			t.Fatalf("Expected success after recovery, got %v", err)
+		}
+		if quarantined := service.Quarantine().Quarantined(); len(quarantined) != 0 {
+			t.Errorf("Expected no quarantined tools, got %v", quarantined)
+		}
+	})
+
+	t.Run("failing health check extends the quarantine", func(t *testing.T) {
+		tool := &healthCheckedMockTool{
+			MockTool: MockTool{name: "checked", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				return nil, failing
+			}},
+			healthErr: errors.New("still down"),
+		}
+		service, now := newQuarantineTestService(t, tool)
+		service.ExecuteTool("checked", nil)
+		service.ExecuteTool("checked", nil)
+
+		*now = now.Add(31 * time.Second)
+		if _, err := service.ExecuteTool("checked", nil); !errors.Is(err, ErrToolDisabled) {
+			t.Fatalf("Expected the failed health check to keep the tool disabled, got %v", err)
+		}
+
+		*now = now.Add(31 * time.Second)
+		tool.healthErr = nil
+		tool.executeFunc = nil
+		if _, err := service.ExecuteTool("checked", nil); err != nil {
+			t.Fatalf("Expected the tool to run once healthy, got %v", err)
+		}
+	})
+
+	t.Run("one caller runs the health check after the cooldown", func(t *testing.T) {
+		fail := true
+		tool := &slowHealthMockTool{
+			MockTool: MockTool{name: "probed", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				if fail {
+					return nil, failing
+				}
+				return map[string]interface{}{}, nil
+			}},
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+		service, now := newQuarantineTestService(t, tool)
+		service.ExecuteTool("probed", nil)
+		service.ExecuteTool("probed", nil)
+		fail = false
+		*now = now.Add(31 * time.Second)
+
+		probe := make(chan error, 1)
+		go func() {
+			_, err := service.ExecuteTool("probed", nil)
+			probe <- err
+		}()
+		<-tool.started
+
+		var wg sync.WaitGroup
+		var rejected atomic.Int32
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := service.ExecuteTool("probed", nil); errors.Is(err, ErrToolDisabled) {
+					rejected.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		close(tool.release)
+
+		if err := <-probe; err != nil {
+			t.Fatalf("Expected the probing call to run, got %v", err)
+		}
+		if checks := tool.checks.Load(); checks != 1 {
+			t.Errorf("Expected one health check, got %d", checks)
+		}
+		if n := rejected.Load(); n != 10 {
+			t.Errorf("Expected calls during the check to be rejected, got %d of 10", n)
+		}
+		if _, err := service.ExecuteTool("probed", nil); err != nil {
+			t.Errorf("Expected the tool to be re-enabled, got %v", err)
+		}
+	})
+
+	t.Run("release during the health check is kept", func(t *testing.T) {
+		tool := &slowHealthMockTool{
+			MockTool: MockTool{name: "released_probe", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+				return nil, failing
+			}},
+			started: make(chan struct{}),
+			release: make(chan struct{}),
+		}
+		service, now := newQuarantineTestService(t, tool)
+		service.ExecuteTool("released_probe", nil)
+		service.ExecuteTool("released_probe", nil)
+		*now = now.Add(31 * time.Second)
+
+		probe := make(chan error, 1)
+		go func() {
+			_, err := service.ExecuteTool("released_probe", nil)
+			probe <- err
+		}()
+		<-tool.started
+		service.Quarantine().Release("released_probe")
+		close(tool.release)
+		if err := <-probe; errors.Is(err, ErrToolDisabled) {
+			t.Fatalf("Expected the probing call to run, got %v", err)
+		}
+		if _, err := service.ExecuteTool("released_probe", nil); errors.Is(err, ErrToolDisabled) {
+			t.Errorf("Expected the released tool not to be on probation, got %v", err)
+		}
+	})
+
+	t.Run("release re-enables immediately", func(t *testing.T) {
+		tool := &MockTool{name: "released", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, failing
+		}}
+		service, _ := newQuarantineTestService(t, tool)
+		service.ExecuteTool("released", nil)
+		service.ExecuteTool("released", nil)
+
+		if !service.Quarantine().Release("released") {
+			t.Fatal("Expected Release to report the tool was quarantined")
+		}
+		if _, err := service.ExecuteTool("released", nil); errors.Is(err, ErrToolDisabled) {
+			t.Error("Expected the released tool to run")
+		}
+		if service.Quarantine().Release("unknown") {
+			t.Error("Expected Release of an unknown tool to report false")
+		}
+	})
+
+	t.Run("zero failure rate disables quarantine", func(t *testing.T) {
+		logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+		service := newTestToolService(logger, &MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, failing
+		}})
+		for i := 0; i < 10; i++ {
+			if _, err := service.ExecuteTool("broken", nil); errors.Is(err, ErrToolDisabled) {
+				t.Fatal("Expected quarantine to be off by default")
+			}
+		}
+	})
+}
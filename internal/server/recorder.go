@@ -0,0 +1,197 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-tools-server/internal/redact"
+)
+
+// Directions of recorded messages.
+const (
+	RecordIn  = "in"  // Sent by the client
+	RecordOut = "out" // Sent by the server: responses and notifications
+)
+
+// RecordedMessage is one JSON-RPC message of a session recording.
+type RecordedMessage struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Message   json.RawMessage `json:"message"`
+}
+
+// SessionRecorder writes the JSON-RPC traffic of every session to a JSON Lines file per
+// session in a directory, for replay with JSONRPCProcessor.Replay. Messages pass through
+// the redactor, if any, before they are written. A nil SessionRecorder records nothing.
+type SessionRecorder struct {
+	mu       sync.Mutex
+	dir      string
+	redactor *redact.Redactor
+	logger   *slog.Logger
+}
+
+// NewSessionRecorder creates a SessionRecorder writing to dir, creating it if needed.
+func NewSessionRecorder(dir string, redactor *redact.Redactor, logger *slog.Logger) (*SessionRecorder, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	return &SessionRecorder{dir: dir, redactor: redactor, logger: logger}, nil
+}
+
+// Path returns the file the session's messages are written to.
+func (r *SessionRecorder) Path(sessionID string) string {
+	return filepath.Join(r.dir, sanitizeFileName(sessionID)+".jsonl")
+}
+
+// Record appends a message sent in direction to the session's recording. The message
+// may be encoded JSON or any value that encodes to it. Messages without a session are
+// not recorded.
+func (r *SessionRecorder) Record(sessionID, direction string, message interface{}) {
+	if r == nil || sessionID == "" {
+		return
+	}
+	var value interface{}
+	var err error
+	if raw, ok := message.([]byte); ok {
+		err = json.Unmarshal(raw, &value)
+	} else {
+		value, err = normalizeJSON(message)
+	}
+	if err != nil {
+		r.logger.Warn("Failed to record message", "sessionID", sessionID, "error", err)
+		return
+	}
+	encoded, err := json.Marshal(r.redactor.Value(value))
+	if err != nil {
+		r.logger.Warn("Failed to record message", "sessionID", sessionID, "error", err)
+		return
+	}
+	line, err := json.Marshal(RecordedMessage{Time: time.Now().UTC(), Direction: direction, Message: encoded})
+	if err != nil {
+		r.logger.Warn("Failed to record message", "sessionID", sessionID, "error", err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Opening per message keeps no handles for sessions that end without notice
+	file, err := os.OpenFile(r.Path(sessionID), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		r.logger.Warn("Failed to open session recording", "sessionID", sessionID, "error", err)
+		return
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		r.logger.Warn("Failed to write session recording", "sessionID", sessionID, "error", err)
+	}
+}
+
+// ReadRecording reads a session recording written by SessionRecorder.
+func ReadRecording(in io.Reader) ([]RecordedMessage, error) {
+	var messages []RecordedMessage
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var message RecordedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &message); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		messages = append(messages, message)
+	}
+	return messages, scanner.Err()
+}
+
+// ReplayResult pairs a replayed request's recorded response with the one the processor
+// gives now. Notifications have neither.
+type ReplayResult struct {
+	Request  json.RawMessage `json:"request"`
+	Recorded json.RawMessage `json:"recorded,omitempty"`
+	Replayed json.RawMessage `json:"replayed,omitempty"`
+	Match    bool            `json:"match"`
+}
+
+// Replay feeds the inbound messages of a recording through the processor in order and
+// compares each response with the recorded response carrying the same ID. Responses are
+// compared as JSON values, so tools with random or time-dependent output, such as
+// generate_uuid, never match.
+func (p *JSONRPCProcessor) Replay(ctx context.Context, messages []RecordedMessage) ([]ReplayResult, error) {
+	// Recorded responses by ID, in order, so repeated IDs pair up with their requests
+	recorded := make(map[string][]json.RawMessage)
+	for _, message := range messages {
+		var frame struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		if message.Direction != RecordOut || json.Unmarshal(message.Message, &frame) != nil || frame.Method != "" || len(frame.ID) == 0 {
+			continue
+		}
+		recorded[string(frame.ID)] = append(recorded[string(frame.ID)], message.Message)
+	}
+
+	var results []ReplayResult
+	for _, message := range messages {
+		if message.Direction != RecordIn {
+			continue
+		}
+		var request map[string]interface{}
+		if err := json.Unmarshal(message.Message, &request); err != nil {
+			return nil, fmt.Errorf("invalid recorded request: %w", err)
+		}
+		result := ReplayResult{Request: message.Message, Match: true}
+		if response := p.Process(WithRequestID(ctx, "replay"), request); response != nil {
+			replayed, err := json.Marshal(response)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode replayed response: %w", err)
+			}
+			result.Replayed = replayed
+		}
+		if id, ok := request["id"]; ok {
+			key, _ := json.Marshal(id)
+			if queue := recorded[string(key)]; len(queue) > 0 {
+				result.Recorded, recorded[string(key)] = queue[0], queue[1:]
+			}
+		}
+		if result.Recorded != nil || result.Replayed != nil {
+			result.Match = sameJSON(result.Recorded, result.Replayed)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// sameJSON reports whether a and b encode equal JSON values.
+func sameJSON(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	leftEncoded, _ := json.Marshal(left)
+	rightEncoded, _ := json.Marshal(right)
+	return bytes.Equal(leftEncoded, rightEncoded)
+}
+
+// sanitizeFileName replaces characters that are unsafe in file names.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.TrimLeft(name, "."))
+}
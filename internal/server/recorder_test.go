@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/internal/redact"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	owner := "jane@example.com"
+	service := newTestToolService(logger,
+		&MockTool{name: "lookup", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"owner": owner}, nil
+		}},
+	)
+	rules, err := redact.Rules([]string{"email"}, nil)
+	if err != nil {
+		t.Fatalf("Rules failed: %v", err)
+	}
+	recorder, err := NewSessionRecorder(t.TempDir(), redact.New(rules), logger)
+	if err != nil {
+		t.Fatalf("NewSessionRecorder failed: %v", err)
+	}
+	service.SetRecorder(recorder)
+	processor := NewJSONRPCProcessor(service, logger)
+
+	ctx := WithSessionID(context.Background(), "session/1")
+	processor.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+	processor.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "tools/call", "params": map[string]interface{}{"name": "lookup", "arguments": map[string]interface{}{}}})
+	processor.Process(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/initialized"})
+	processor.Process(context.Background(), map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "tools/list"})
+
+	data, err := os.ReadFile(recorder.Path("session/1"))
+	if err != nil {
+		t.Fatalf("Expected a recording file: %v", err)
+	}
+	messages, err := ReadRecording(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadRecording failed: %v", err)
+	}
+
+	t.Run("records both directions", func(t *testing.T) {
+		var directions []string
+		for _, message := range messages {
+			directions = append(directions, message.Direction)
+		}
+		if got := strings.Join(directions, ","); got != "in,out,in,out,in" {
+			t.Errorf("Expected in,out,in,out,in, got %s", got)
+		}
+		if strings.Contains(string(data), `"id":3`) {
+			t.Error("Expected messages without a session to be left out")
+		}
+	})
+
+	t.Run("recordings are redacted", func(t *testing.T) {
+		if strings.Contains(string(data), owner) || !strings.Contains(string(data), "[REDACTED:email]") {
+			t.Errorf("Expected the email to be redacted, got:\n%s", data)
+		}
+	})
+
+	t.Run("replay matches an unchanged server", func(t *testing.T) {
+		owner = "[REDACTED:email]"
+		results, err := processor.Replay(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("Expected 3 replayed requests, got %d", len(results))
+		}
+		for _, result := range results {
+			if !result.Match {
+				t.Errorf("Expected %s to match, recorded %s, replayed %s", result.Request, result.Recorded, result.Replayed)
+			}
+		}
+	})
+
+	t.Run("replay reports changed responses", func(t *testing.T) {
+		owner = "someone else"
+		results, err := processor.Replay(context.Background(), messages)
+		if err != nil {
+			t.Fatalf("Replay failed: %v", err)
+		}
+		if results[0].Match != true || results[1].Match != false {
+			t.Errorf("Expected only the tool call to differ, got %+v", results)
+		}
+	})
+
+	t.Run("nil recorder records nothing", func(t *testing.T) {
+		var nilRecorder *SessionRecorder
+		nilRecorder.Record("session", RecordIn, map[string]interface{}{})
+	})
+}
+
+func TestReadRecording(t *testing.T) {
+	t.Run("reports the bad line", func(t *testing.T) {
+		in := `{"time":"2024-01-01T00:00:00Z","direction":"in","message":{}}` + "\n\nnot json\n"
+		if _, err := ReadRecording(strings.NewReader(in)); err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Errorf("Expected an error on line 3, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"mcp-tools-server/internal/metrics"
+)
+
+// requestIDHeader names the header that carries the correlation ID of a request.
+const requestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs so they stay log friendly.
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the given request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// loggerFor returns logger annotated with the request ID, client IP, and MCP session ID
+// from ctx, if any. The session ID lets SessionLogHandler forward the records to the
+// session.
+func loggerFor(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if id := RequestIDFromContext(ctx); id != "" {
+		logger = logger.With("requestID", id)
+	}
+	if ip := ClientIPFromContext(ctx); ip != "" {
+		logger = logger.With("clientIP", ip)
+	}
+	if id := SessionIDFromContext(ctx); id != "" {
+		logger = logger.With("sessionID", id)
+	}
+	return logger
+}
+
+// requestIDMiddleware accepts a client-supplied X-Request-ID or generates a new one,
+// echoes it in the response, and stores it in the request context along with the trace
+// ID of a W3C traceparent header, which metrics attach as exemplars.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(requestIDHeader, id)
+		ctx := WithRequestID(r.Context(), id)
+		if traceID := metrics.ParseTraceParent(r.Header.Get(metrics.TraceParentHeader)); traceID != "" {
+			ctx = metrics.WithTraceID(ctx, traceID)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID reports whether a client-supplied ID is short, printable ASCII.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
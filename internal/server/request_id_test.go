@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	t.Run("generates an ID when none is supplied", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		id := w.Header().Get(requestIDHeader)
+		if id == "" {
+			t.Fatal("Expected a generated request ID header")
+		}
+		if seen != id {
+			t.Errorf("Expected context ID %s to match header, got %s", id, seen)
+		}
+	})
+
+	t.Run("accepts a client-supplied ID", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(requestIDHeader, "client-abc-123")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get(requestIDHeader); got != "client-abc-123" {
+			t.Errorf("Expected client ID to be echoed, got %s", got)
+		}
+		if seen != "client-abc-123" {
+			t.Errorf("Expected client ID in context, got %s", seen)
+		}
+	})
+
+	t.Run("replaces an invalid client ID", func(t *testing.T) {
+		for _, invalid := range []string{"has space", strings.Repeat("a", maxRequestIDLength+1)} {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(requestIDHeader, invalid)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if got := w.Header().Get(requestIDHeader); got == invalid || got == "" {
+				t.Errorf("Expected invalid ID %q to be replaced, got %q", invalid, got)
+			}
+		}
+	})
+}
+
+func TestRequestIDPropagation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+
+	var toolRequestID string
+	toolService.Use(func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			toolRequestID = RequestIDFromContext(ctx)
+			return next(ctx, name, args)
+		}
+	})
+	httpServer := NewHTTPServer(toolService, WithPort(8080), WithLogger(logger))
+
+	req := httptest.NewRequest("POST", "/api/tools/echo", nil)
+	req.Header.Set(requestIDHeader, "trace-42")
+	w := httptest.NewRecorder()
+	httpServer.server.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	if toolRequestID != "trace-42" {
+		t.Errorf("Expected the tool call to see request ID trace-42, got %q", toolRequestID)
+	}
+	if got := w.Header().Get(requestIDHeader); got != "trace-42" {
+		t.Errorf("Expected response header trace-42, got %q", got)
+	}
+}
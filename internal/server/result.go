@@ -0,0 +1,258 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// normalizeToolResult converts a raw tool result into a JSON-safe map. Binary values
+// ([]byte and tools.Attachment) are encoded explicitly as MCP content blocks rather than
+// being handed to encoding/json, which would otherwise emit an untyped base64 string.
+// Maps and slices are copied only when a value in them changes, so a result of plain
+// values, the common case, is returned as is without allocating.
+func normalizeToolResult(result map[string]interface{}) map[string]interface{} {
+	normalized, _ := normalizeMap(result)
+	return normalized
+}
+
+// normalizeMap normalizes the values of m and reports whether any changed, in which case
+// the returned map is a copy.
+func normalizeMap(m map[string]interface{}) (map[string]interface{}, bool) {
+	var normalized map[string]interface{}
+	for key, value := range m {
+		if v, changed := normalizeValue(key, value); changed {
+			if normalized == nil {
+				normalized = maps.Clone(m)
+			}
+			normalized[key] = v
+		}
+	}
+	if normalized == nil {
+		return m, false
+	}
+	return normalized, true
+}
+
+// normalizeValue normalizes a single result value, recursing into nested maps and slices,
+// and reports whether it changed.
+func normalizeValue(key string, value interface{}) (interface{}, bool) {
+	if attachment, ok := asAttachment(key, value); ok {
+		return attachmentContent(key, attachment), true
+	}
+
+	switch v := value.(type) {
+	case tools.Content:
+		return contentBlock(key, v), true
+	case []tools.Content:
+		blocks := make([]interface{}, len(v))
+		for i, block := range v {
+			blocks[i] = contentBlock(key, block)
+		}
+		return blocks, true
+	case map[string]interface{}:
+		return normalizeMap(v)
+	case []interface{}:
+		var items []interface{}
+		for i, item := range v {
+			if normalized, changed := normalizeValue(key, item); changed {
+				if items == nil {
+					items = slices.Clone(v)
+				}
+				items[i] = normalized
+			}
+		}
+		if items == nil {
+			return value, false
+		}
+		return items, true
+	default:
+		return value, false
+	}
+}
+
+// asAttachment reports whether value is binary and returns it as an attachment.
+// Raw byte slices are named after the result key they were stored under.
+func asAttachment(key string, value interface{}) (tools.Attachment, bool) {
+	switch v := value.(type) {
+	case tools.Attachment:
+		return v, true
+	case *tools.Attachment:
+		if v == nil {
+			return tools.Attachment{}, false
+		}
+		return *v, true
+	case []byte:
+		return tools.Attachment{Filename: key, Data: v}, true
+	default:
+		return tools.Attachment{}, false
+	}
+}
+
+// attachmentContent encodes an attachment as an MCP content block. Images map to image
+// content; everything else becomes an embedded resource carrying a base64 blob.
+func attachmentContent(key string, attachment tools.Attachment) map[string]interface{} {
+	mimeType := attachment.ContentType()
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+
+	if strings.HasPrefix(mimeType, "image/") {
+		return map[string]interface{}{
+			"type":     "image",
+			"data":     encoded,
+			"mimeType": mimeType,
+		}
+	}
+
+	name := attachment.Filename
+	if name == "" {
+		name = key
+	}
+	return map[string]interface{}{
+		"type": "resource",
+		"resource": map[string]interface{}{
+			"uri":      "attachment://" + name,
+			"mimeType": mimeType,
+			"blob":     encoded,
+		},
+	}
+}
+
+// contentBlock encodes a typed content block as an MCP content block. JSON values are
+// sent as text, which every MCP client can read.
+func contentBlock(key string, content tools.Content) interface{} {
+	switch content.Type {
+	case tools.ContentText:
+		return map[string]interface{}{"type": "text", "text": content.Text}
+	case tools.ContentJSON:
+		encoded, err := json.Marshal(content.Value)
+		if err != nil {
+			return map[string]interface{}{"type": "text", "text": fmt.Sprintf("unencodable JSON value: %v", err)}
+		}
+		return map[string]interface{}{"type": "text", "text": string(encoded)}
+	case tools.ContentImage, tools.ContentResource:
+		return attachmentContent(key, content.Attachment())
+	case tools.ContentResourceLink:
+		block := map[string]interface{}{"type": "resource_link", "uri": content.URI}
+		if content.Name != "" {
+			block["name"] = content.Name
+		}
+		if content.MIMEType != "" {
+			block["mimeType"] = content.MIMEType
+		}
+		return block
+	default:
+		return map[string]interface{}{"type": "text", "text": content.Text}
+	}
+}
+
+// findAttachment returns the binary value stored under key in a raw tool result.
+func findAttachment(result map[string]interface{}, key string) (tools.Attachment, bool) {
+	value, ok := result[key]
+	if !ok {
+		return tools.Attachment{}, false
+	}
+	attachment, ok := asAttachment(key, value)
+	if ok && attachment.Filename == "" {
+		attachment.Filename = key
+	}
+	return attachment, ok
+}
+
+// wrapToolResult wraps a normalized tool result in the MCP CallToolResult shape. Values
+// that are content blocks, or lists of them, become the result's content in key order.
+// The remaining values form the structured content, which clients of 2025-06-18 and
+// later receive as structuredContent and every client receives as a JSON text block
+// ahead of the other content. A "_meta" value, such as the paging metadata added by
+// ResultPager, moves to the result's own _meta.
+func wrapToolResult(result map[string]interface{}, version string) map[string]interface{} {
+	keys := make([]string, 0, len(result))
+	for key := range result {
+		if key != "_meta" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var blocks []interface{}
+	structured := make(map[string]interface{})
+	for _, key := range keys {
+		value := result[key]
+		if isContentBlock(value) {
+			blocks = append(blocks, value)
+			continue
+		}
+		if items, ok := value.([]interface{}); ok && len(items) > 0 && allContentBlocks(items) {
+			blocks = append(blocks, items...)
+			continue
+		}
+		structured[key] = value
+	}
+
+	content := make([]interface{}, 0, len(blocks)+1)
+	if len(structured) > 0 {
+		encoded, err := json.Marshal(structured)
+		if err != nil {
+			encoded = []byte(fmt.Sprintf("unencodable result: %v", err))
+		}
+		content = append(content, map[string]interface{}{"type": "text", "text": string(encoded)})
+	}
+	content = append(content, blocks...)
+
+	wrapped := map[string]interface{}{
+		"content": content,
+		"isError": false,
+	}
+	if protocolAtLeast(version, ProtocolVersion20250618) {
+		wrapped["structuredContent"] = structured
+	}
+	if meta, ok := result["_meta"]; ok {
+		wrapped["_meta"] = meta
+	}
+	return wrapped
+}
+
+// toolErrorResult is the MCP tool result of a call whose tool failed: the error as a
+// text block, with isError set.
+func toolErrorResult(err error) map[string]interface{} {
+	return map[string]interface{}{
+		"content": []interface{}{map[string]interface{}{"type": "text", "text": err.Error()}},
+		"isError": true,
+	}
+}
+
+// isContentBlock reports whether a normalized value is an MCP content block.
+func isContentBlock(value interface{}) bool {
+	block, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	switch block["type"] {
+	case "text":
+		_, ok = block["text"].(string)
+	case "image", "audio":
+		_, ok = block["data"].(string)
+	case "resource":
+		_, ok = block["resource"].(map[string]interface{})
+	case "resource_link":
+		_, ok = block["uri"].(string)
+	default:
+		ok = false
+	}
+	return ok
+}
+
+// allContentBlocks reports whether every item is an MCP content block.
+func allContentBlocks(items []interface{}) bool {
+	for _, item := range items {
+		if !isContentBlock(item) {
+			return false
+		}
+	}
+	return true
+}
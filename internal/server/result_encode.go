@@ -0,0 +1,256 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// errResultTooLarge is returned by encodeResult for a value whose encoding passes the limit.
+var errResultTooLarge = errors.New("encoded result exceeds the size limit")
+
+// maxPooledEncoderBytes is the largest buffer kept for reuse, so one huge result does not
+// pin its memory after it has been sent.
+const maxPooledEncoderBytes = 4 << 20
+
+// resultEncoders holds encoders for reuse, sparing large results a buffer grown from empty.
+var resultEncoders = sync.Pool{New: func() interface{} { return new(resultEncoder) }}
+
+// encodeResult encodes value exactly as json.Marshal would. The maps, slices, and scalars
+// that tool results are made of are written in a single pass without reflection; other
+// values are handed to encoding/json. When maxBytes is above zero, encoding stops with
+// errResultTooLarge as soon as the output passes it, so an oversized result is never
+// encoded in full just to learn that it does not fit.
+func encodeResult(value interface{}, maxBytes int) ([]byte, error) {
+	e := newResultEncoder(maxBytes)
+	defer e.release()
+	if err := e.encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.Clone(e.buf), nil
+}
+
+// encodedSize returns the length of value's JSON encoding, or 0 if it cannot be encoded.
+func encodedSize(value interface{}) int {
+	e := newResultEncoder(0)
+	defer e.release()
+	if err := e.encode(value); err != nil {
+		return 0
+	}
+	return len(e.buf)
+}
+
+// resultEncoder appends the JSON encoding of values to buf.
+type resultEncoder struct {
+	buf      []byte
+	keys     []string // Sorted keys of the maps being encoded, innermost last
+	maxBytes int      // Largest encoding allowed; 0 allows any size
+}
+
+// newResultEncoder returns an empty encoder from the pool.
+func newResultEncoder(maxBytes int) *resultEncoder {
+	e := resultEncoders.Get().(*resultEncoder)
+	e.buf, e.keys, e.maxBytes = e.buf[:0], e.keys[:0], maxBytes
+	return e
+}
+
+// release returns the encoder to the pool unless its buffer has grown too large to keep.
+func (e *resultEncoder) release() {
+	if cap(e.buf) <= maxPooledEncoderBytes {
+		clear(e.keys[:cap(e.keys)])
+		resultEncoders.Put(e)
+	}
+}
+
+// encode appends the encoding of value, failing once the output is over the limit.
+func (e *resultEncoder) encode(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		e.buf = append(e.buf, "null"...)
+	case string:
+		e.buf = appendJSONString(e.buf, v)
+	case bool:
+		e.buf = strconv.AppendBool(e.buf, v)
+	case int:
+		e.buf = strconv.AppendInt(e.buf, int64(v), 10)
+	case int64:
+		e.buf = strconv.AppendInt(e.buf, v, 10)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &json.UnsupportedValueError{Str: strconv.FormatFloat(v, 'g', -1, 64)}
+		}
+		e.buf = appendJSONFloat(e.buf, v)
+	case map[string]interface{}:
+		if v == nil {
+			e.buf = append(e.buf, "null"...)
+			break
+		}
+		start := len(e.keys)
+		for key := range v {
+			e.keys = append(e.keys, key)
+		}
+		keys := e.keys[start:]
+		slices.Sort(keys)
+		e.buf = append(e.buf, '{')
+		for i, key := range keys {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			e.buf = append(appendJSONString(e.buf, key), ':')
+			if err := e.encode(v[key]); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, '}')
+		e.keys = e.keys[:start]
+	case []interface{}:
+		if v == nil {
+			e.buf = append(e.buf, "null"...)
+			break
+		}
+		e.buf = append(e.buf, '[')
+		for i, item := range v {
+			if i > 0 {
+				e.buf = append(e.buf, ',')
+			}
+			if err := e.encode(item); err != nil {
+				return err
+			}
+		}
+		e.buf = append(e.buf, ']')
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		e.buf = append(e.buf, encoded...)
+	}
+	if e.maxBytes > 0 && len(e.buf) > e.maxBytes {
+		return errResultTooLarge
+	}
+	return nil
+}
+
+// appendJSONFloat appends f formatted as encoding/json formats a float64.
+func appendJSONFloat(dst []byte, f float64) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// Shorten e-09 to e-9
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// appendJSONString appends s as a JSON string, escaped as encoding/json escapes it: HTML
+// characters, U+2028 and U+2029 are escaped, and invalid UTF-8 becomes U+FFFD.
+func appendJSONString(dst []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// decodedCopy returns a deep copy of value in the form encoding/json decodes into, as
+// encoding and decoding it would, but converting maps, slices, and scalars directly.
+// Other values take the round trip through encoding/json.
+func decodedCopy(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case nil, string, bool:
+		return v, nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("unsupported value: %v", v)
+		}
+		return v, nil
+	case map[string]interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			c, err := decodedCopy(item)
+			if err != nil {
+				return nil, err
+			}
+			copied[key] = c
+		}
+		return copied, nil
+	case []interface{}:
+		if v == nil {
+			return nil, nil
+		}
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			c, err := decodedCopy(item)
+			if err != nil {
+				return nil, err
+			}
+			copied[i] = c
+		}
+		return copied, nil
+	default:
+		return normalizeJSON(v)
+	}
+}
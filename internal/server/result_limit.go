@@ -0,0 +1,315 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// nextPageToolName is the tool clients call with a cursor to continue a paged result.
+const nextPageToolName = "next_page"
+
+// Default result pager limits.
+const (
+	defaultResultCursorTTL = 10 * time.Minute
+	maxResultCursors       = 1000
+	maxStringTruncations   = 32
+)
+
+// ErrCursorNotFound is returned for a cursor that is unknown or has expired.
+var ErrCursorNotFound = errors.New("cursor not found or expired")
+
+// ResultPager keeps tool results under a size limit so large outputs fit in an LLM's
+// context and in transport frames. A result over the limit has its largest list cut to
+// the items that fit, with the rest kept behind a cursor that the next_page tool returns,
+// and, if that is not enough, its longest strings shortened with a truncation marker.
+// Either change is reported under the result's "_meta" key.
+type ResultPager struct {
+	mu       sync.Mutex
+	maxBytes int
+	ttl      time.Duration
+	pages    map[string]*resultPage
+	now      func() time.Time
+}
+
+// resultPage holds the list items not yet returned for a cursor.
+type resultPage struct {
+	field   string
+	items   []interface{}
+	expires time.Time
+}
+
+// NewResultPager creates a ResultPager with no size limit.
+func NewResultPager() *ResultPager {
+	return &ResultPager{
+		ttl:   defaultResultCursorTTL,
+		pages: make(map[string]*resultPage),
+		now:   time.Now,
+	}
+}
+
+// SetLimits sets the largest encoded result in bytes, where zero turns limiting off, and
+// how long cursors stay valid. A non-positive ttl keeps the default.
+func (p *ResultPager) SetLimits(maxBytes int, ttl time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.maxBytes = maxBytes
+	p.ttl = defaultResultCursorTTL
+	if ttl > 0 {
+		p.ttl = ttl
+	}
+}
+
+// Limit returns a normalized tool result that fits within the size limit.
+func (p *ResultPager) Limit(result map[string]interface{}) map[string]interface{} {
+	p.mu.Lock()
+	maxBytes := p.maxBytes
+	p.mu.Unlock()
+	if maxBytes <= 0 {
+		return result
+	}
+	if _, err := encodeResult(result, maxBytes); !errors.Is(err, errResultTooLarge) {
+		return result
+	}
+
+	// Work on a decoded copy, so typed slices can be cut and the tool's values are untouched
+	decoded, err := decodedCopy(result)
+	limited, ok := decoded.(map[string]interface{})
+	if err != nil || !ok {
+		return result
+	}
+	meta, _ := limited["_meta"].(map[string]interface{})
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+	meta["truncated"] = true
+	limited["_meta"] = meta
+	if field, items := largestList(limited); field != "" {
+		p.page(limited, meta, field, items, maxBytes)
+	}
+	truncateStrings(limited, maxBytes, meta)
+	return limited
+}
+
+// Next returns the next page of the result behind cursor. Each cursor can be used once;
+// the page carries a new cursor when items remain.
+func (p *ResultPager) Next(cursor string) (map[string]interface{}, error) {
+	p.mu.Lock()
+	page, ok := p.pages[cursor]
+	if ok {
+		delete(p.pages, cursor)
+	}
+	maxBytes := p.maxBytes
+	p.mu.Unlock()
+	if !ok || p.now().After(page.expires) {
+		return nil, fmt.Errorf("%w: %s", ErrCursorNotFound, cursor)
+	}
+
+	meta := map[string]interface{}{}
+	result := map[string]interface{}{"_meta": meta}
+	p.page(result, meta, page.field, page.items, maxBytes)
+	return result, nil
+}
+
+// page stores as many items as fit in maxBytes in result[field] and records the split in
+// meta, which result holds under "_meta". The remaining items are kept behind a new
+// cursor. At least one item is returned so that paging always makes progress.
+func (p *ResultPager) page(result, meta map[string]interface{}, field string, items []interface{}, maxBytes int) {
+	meta["field"] = field
+	// The placeholder has a cursor's length so that sizes include the final metadata
+	meta["nextCursor"] = uuid.Nil.String()
+	take := func(n int) {
+		result[field] = items[:n]
+		meta["returned"], meta["remaining"] = n, len(items)-n
+	}
+	// Binary search for the longest prefix of items that fits
+	low, high := 1, len(items)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if take(mid); encodedSize(result) <= maxBytes {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	take(low)
+	if low == len(items) {
+		delete(meta, "nextCursor")
+		return
+	}
+	meta["nextCursor"] = p.store(field, items[low:])
+}
+
+// store keeps items behind a new cursor, dropping expired cursors and, past the cursor
+// limit, the ones closest to expiring.
+func (p *ResultPager) store(field string, items []interface{}) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	for cursor, page := range p.pages {
+		if now.After(page.expires) {
+			delete(p.pages, cursor)
+		}
+	}
+	for len(p.pages) >= maxResultCursors {
+		var oldest string
+		for cursor, page := range p.pages {
+			if oldest == "" || page.expires.Before(p.pages[oldest].expires) {
+				oldest = cursor
+			}
+		}
+		delete(p.pages, oldest)
+	}
+	cursor := uuid.NewString()
+	p.pages[cursor] = &resultPage{field: field, items: items, expires: now.Add(p.ttl)}
+	return cursor
+}
+
+// largestList returns the top-level list field with the largest encoding.
+func largestList(result map[string]interface{}) (string, []interface{}) {
+	var field string
+	var items []interface{}
+	largest := 0
+	for key, value := range result {
+		list, ok := value.([]interface{})
+		if !ok || len(list) == 0 {
+			continue
+		}
+		if size := encodedSize(list); size > largest || (size == largest && key < field) {
+			field, items, largest = key, list, size
+		}
+	}
+	return field, items
+}
+
+// truncateStrings shortens the longest strings in result, ending each with a truncation
+// marker, until the result fits in maxBytes or no string is long enough to help. The
+// paths of shortened fields are listed in meta.
+func truncateStrings(result map[string]interface{}, maxBytes int, meta map[string]interface{}) {
+	var truncated []string
+	for i := 0; i < maxStringTruncations && encodedSize(result) > maxBytes; i++ {
+		path, value, set := longestString(result, "")
+		marker := truncationMarker(len(value))
+		if set == nil || len(value) <= len(marker) {
+			return
+		}
+		// List the field first so the cut leaves room for its entry
+		if !slices.Contains(truncated, path) {
+			truncated = append(truncated, path)
+			meta["truncatedFields"] = truncated
+		}
+		keep := max(len(value)-(encodedSize(result)-maxBytes)-len(marker), 0)
+		for keep > 0 && !utf8.RuneStart(value[keep]) {
+			keep--
+		}
+		set(value[:keep] + truncationMarker(len(value)-keep))
+	}
+}
+
+// truncationMarker returns the text that replaces the cut end of a string.
+func truncationMarker(cut int) string {
+	return fmt.Sprintf("…[truncated %d bytes]", cut)
+}
+
+// longestString finds the longest string under value, returning its path, its text, and
+// a function that replaces it. Keys starting with an underscore are skipped.
+func longestString(value interface{}, path string) (string, string, func(string)) {
+	var bestPath, best string
+	var bestSet func(string)
+	consider := func(childPath string, child interface{}, set func(string)) {
+		if s, ok := child.(string); ok {
+			if len(s) > len(best) {
+				bestPath, best, bestSet = childPath, s, set
+			}
+			return
+		}
+		if p, s, setter := longestString(child, childPath); len(s) > len(best) {
+			bestPath, best, bestSet = p, s, setter
+		}
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if len(key) > 0 && key[0] == '_' {
+				continue
+			}
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			consider(childPath, child, func(s string) { v[key] = s })
+		}
+	case []interface{}:
+		for i, child := range v {
+			consider(fmt.Sprintf("%s[%d]", path, i), child, func(s string) { v[i] = s })
+		}
+	}
+	return bestPath, best, bestSet
+}
+
+// normalizeJSON converts a value to the form encoding/json decodes into.
+func normalizeJSON(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
+
+// nextPageTool continues a paged result and implements tools.Tool.
+type nextPageTool struct {
+	pager *ResultPager
+}
+
+// Name returns the tool's name
+func (t *nextPageTool) Name() string {
+	return nextPageToolName
+}
+
+// Description returns the tool's description
+func (t *nextPageTool) Description() string {
+	return "Returns the next page of a tool result that was too large to return at once. Pass the nextCursor from the result's _meta"
+}
+
+// Category returns the tool's category
+func (t *nextPageTool) Category() string {
+	return "utility"
+}
+
+// Tags returns the tool's tags
+func (t *nextPageTool) Tags() []string {
+	return []string{"pagination", "cursor"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *nextPageTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"cursor": map[string]interface{}{
+				"type":        "string",
+				"description": "The nextCursor value from a truncated result's _meta",
+			},
+		},
+		"required": []string{"cursor"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *nextPageTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	cursor, _ := args["cursor"].(string)
+	if cursor == "" {
+		return nil, errors.New("cursor is required")
+	}
+	return t.pager.Next(cursor)
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultPager(t *testing.T) {
+	items := make([]map[string]interface{}, 50)
+	for i := range items {
+		items[i] = map[string]interface{}{"id": i, "name": fmt.Sprintf("item-%02d", i)}
+	}
+
+	t.Run("leaves results alone without a limit or under it", func(t *testing.T) {
+		pager := NewResultPager()
+		result := map[string]interface{}{"items": items}
+		if limited := pager.Limit(result); limited["_meta"] != nil {
+			t.Errorf("Expected no limit by default, got %v", limited["_meta"])
+		}
+		pager.SetLimits(1<<20, 0)
+		if limited := pager.Limit(result); limited["_meta"] != nil {
+			t.Errorf("Expected a small result to pass through, got %v", limited["_meta"])
+		}
+	})
+
+	t.Run("pages the largest list", func(t *testing.T) {
+		pager := NewResultPager()
+		pager.SetLimits(600, time.Minute)
+		limited := pager.Limit(map[string]interface{}{"count": 50, "items": items})
+		if size := encodedSize(limited); size > 600 {
+			t.Errorf("Expected the result to fit in 600 bytes, got %d", size)
+		}
+		meta := limited["_meta"].(map[string]interface{})
+		returned := meta["returned"].(int)
+		if meta["truncated"] != true || meta["field"] != "items" || returned == 0 || meta["remaining"] != 50-returned {
+			t.Fatalf("Unexpected metadata: %v", meta)
+		}
+		if limited["count"] != 50.0 {
+			t.Errorf("Expected other fields to be kept, got %v", limited["count"])
+		}
+
+		// Follow the cursors until every item has been returned exactly once
+		seen := len(limited["items"].([]interface{}))
+		cursor, _ := meta["nextCursor"].(string)
+		for pages := 0; cursor != ""; pages++ {
+			if pages > 50 {
+				t.Fatal("Paging did not finish")
+			}
+			page, err := pager.Next(cursor)
+			if err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+			if encodedSize(page) > 600 {
+				t.Errorf("Expected every page to fit, got %d bytes", encodedSize(page))
+			}
+			pageItems := page["items"].([]interface{})
+			if first := pageItems[0].(map[string]interface{}); first["id"] != float64(seen) {
+				t.Fatalf("Expected page to start at item %d, got %v", seen, first["id"])
+			}
+			seen += len(pageItems)
+			cursor, _ = page["_meta"].(map[string]interface{})["nextCursor"].(string)
+		}
+		if seen != 50 {
+			t.Errorf("Expected 50 items across pages, got %d", seen)
+		}
+	})
+
+	t.Run("cursors are single-use and expire", func(t *testing.T) {
+		pager := NewResultPager()
+		pager.SetLimits(200, time.Minute)
+		now := time.Now()
+		pager.now = func() time.Time { return now }
+
+		cursor := pager.Limit(map[string]interface{}{"items": items})["_meta"].(map[string]interface{})["nextCursor"].(string)
+		if _, err := pager.Next(cursor); err != nil {
+			t.Fatalf("Next failed: %v", err)
+		}
+		if _, err := pager.Next(cursor); !errors.Is(err, ErrCursorNotFound) {
+			t.Errorf("Expected a used cursor to be rejected, got %v", err)
+		}
+
+		cursor = pager.Limit(map[string]interface{}{"items": items})["_meta"].(map[string]interface{})["nextCursor"].(string)
+		now = now.Add(2 * time.Minute)
+		if _, err := pager.Next(cursor); !errors.Is(err, ErrCursorNotFound) {
+			t.Errorf("Expected an expired cursor to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("truncates long strings with a marker", func(t *testing.T) {
+		pager := NewResultPager()
+		pager.SetLimits(1000, 0)
+		limited := pager.Limit(map[string]interface{}{
+			"title":   "short",
+			"content": strings.Repeat("é", 2000),
+		})
+		if size := encodedSize(limited); size > 1000 {
+			t.Errorf("Expected the result to fit in 1000 bytes, got %d", size)
+		}
+		content := limited["content"].(string)
+		if !strings.Contains(content, "…[truncated ") || !strings.HasPrefix(content, "éé") {
+			t.Errorf("Expected a truncation marker, got %q", content)
+		}
+		if !json.Valid([]byte(`"`+content+`"`)) || limited["title"] != "short" {
+			t.Errorf("Unexpected result: %v", limited)
+		}
+		if fields := limited["_meta"].(map[string]interface{})["truncatedFields"]; fmt.Sprint(fields) != "[content]" {
+			t.Errorf("Expected content to be reported, got %v", fields)
+		}
+	})
+}
+
+func TestJSONRPCProcessor_ResultLimit(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	names := make([]string, 200)
+	for i := range names {
+		names[i] = fmt.Sprintf("pod-%03d", i)
+	}
+	service := newTestToolService(logger, &MockTool{name: "list_pods", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"pods": names}, nil
+	}})
+	if _, ok := service.GetTools()[nextPageToolName]; ok {
+		t.Fatal("Expected next_page to be registered only with a limit")
+	}
+	service.SetResultLimit(512, 0)
+	processor := NewJSONRPCProcessor(service, logger)
+
+	resp := processor.HandleToolsCall(context.Background(), map[string]interface{}{"name": "list_pods"}, 1)
+	result := resp.Result.(map[string]interface{})
+	meta := result["_meta"].(map[string]interface{})
+	if meta["nextCursor"] == nil || len(result["structuredContent"].(map[string]interface{})["pods"].([]interface{})) == 200 {
+		t.Fatalf("Expected a paged result, got %v", meta)
+	}
+
+	resp = processor.HandleToolsCall(context.Background(), map[string]interface{}{
+		"name":      nextPageToolName,
+		"arguments": map[string]interface{}{"cursor": meta["nextCursor"]},
+	}, 2)
+	if resp.Error != nil {
+		t.Fatalf("next_page failed: %v", resp.Error.Message)
+	}
+	page := resp.Result.(map[string]interface{})["structuredContent"].(map[string]interface{})
+	if first := page["pods"].([]interface{})[0]; first != names[meta["returned"].(int)] {
+		t.Errorf("Expected the next page to continue the list, got %v", first)
+	}
+}
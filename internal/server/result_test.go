@@ -0,0 +1,342 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+func TestNormalizeToolResult(t *testing.T) {
+	t.Run("leaves plain values untouched", func(t *testing.T) {
+		result := normalizeToolResult(map[string]interface{}{"uuid": "abc", "count": 3})
+		if result["uuid"] != "abc" || result["count"] != 3 {
+			t.Errorf("Unexpected normalized result: %v", result)
+		}
+	})
+
+	t.Run("returns plain results without copying", func(t *testing.T) {
+		raw := map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 1}}}
+		if allocs := testing.AllocsPerRun(10, func() { normalizeToolResult(raw) }); allocs != 0 {
+			t.Errorf("Expected no allocations, got %v", allocs)
+		}
+	})
+
+	t.Run("copies only what changes", func(t *testing.T) {
+		items := []interface{}{"a", []byte("b")}
+		raw := map[string]interface{}{"items": items}
+		result := normalizeToolResult(raw)
+		if _, ok := result["items"].([]interface{})[1].(map[string]interface{}); !ok {
+			t.Errorf("Expected the bytes to become a content block, got %v", result["items"])
+		}
+		if _, ok := items[1].([]byte); !ok || raw["items"].([]interface{})[1] == nil {
+			t.Errorf("Expected the tool's values to be untouched, got %v", items)
+		}
+	})
+
+	t.Run("encodes raw bytes as embedded resource", func(t *testing.T) {
+		data := []byte("%PDF-1.4 fake")
+		result := normalizeToolResult(map[string]interface{}{"report": data})
+
+		content, ok := result["report"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected content block, got %T", result["report"])
+		}
+		if content["type"] != "resource" {
+			t.Errorf("Expected type 'resource', got %v", content["type"])
+		}
+		resource := content["resource"].(map[string]interface{})
+		if resource["blob"] != base64.StdEncoding.EncodeToString(data) {
+			t.Errorf("Unexpected blob: %v", resource["blob"])
+		}
+		if resource["uri"] != "attachment://report" {
+			t.Errorf("Unexpected uri: %v", resource["uri"])
+		}
+	})
+
+	t.Run("encodes image attachments as image content", func(t *testing.T) {
+		attachment := tools.Attachment{Filename: "a.png", MIMEType: "image/png", Data: []byte{1, 2, 3}}
+		result := normalizeToolResult(map[string]interface{}{"image": attachment})
+
+		content := result["image"].(map[string]interface{})
+		if content["type"] != "image" {
+			t.Errorf("Expected type 'image', got %v", content["type"])
+		}
+		if content["mimeType"] != "image/png" {
+			t.Errorf("Expected mimeType image/png, got %v", content["mimeType"])
+		}
+	})
+
+	t.Run("recurses into nested values", func(t *testing.T) {
+		result := normalizeToolResult(map[string]interface{}{
+			"files": []interface{}{[]byte("x")},
+			"meta":  map[string]interface{}{"raw": []byte("y")},
+		})
+		files := result["files"].([]interface{})
+		if _, ok := files[0].(map[string]interface{}); !ok {
+			t.Errorf("Expected nested slice item to be a content block, got %T", files[0])
+		}
+		meta := result["meta"].(map[string]interface{})
+		if _, ok := meta["raw"].(map[string]interface{}); !ok {
+			t.Errorf("Expected nested map value to be a content block, got %T", meta["raw"])
+		}
+	})
+
+	t.Run("encodes typed content as MCP content blocks", func(t *testing.T) {
+		result := normalizeToolResult(tools.ContentResult(
+			tools.TextContent("hello"),
+			tools.JSONContent(map[string]int{"count": 2}),
+			tools.ImageContent([]byte{1, 2, 3}, "image/png"),
+			tools.ResourceContent("report.pdf", []byte("%PDF"), "application/pdf"),
+			tools.ResourceLink("https://example.com/data.csv", "data.csv", "text/csv"),
+		))
+
+		blocks := result["content"].([]interface{})
+		expected := []map[string]interface{}{
+			{"type": "text", "text": "hello"},
+			{"type": "text", "text": `{"count":2}`},
+			{"type": "image", "mimeType": "image/png"},
+			{"type": "resource"},
+			{"type": "resource_link", "uri": "https://example.com/data.csv", "name": "data.csv", "mimeType": "text/csv"},
+		}
+		for i, want := range expected {
+			block := blocks[i].(map[string]interface{})
+			for key, value := range want {
+				if block[key] != value {
+					t.Errorf("Block %d: expected %s=%v, got %v", i, key, value, block[key])
+				}
+			}
+		}
+		if uri := blocks[3].(map[string]interface{})["resource"].(map[string]interface{})["uri"]; uri != "attachment://report.pdf" {
+			t.Errorf("Unexpected resource uri: %v", uri)
+		}
+	})
+}
+
+func TestFindAttachment(t *testing.T) {
+	result := map[string]interface{}{
+		"archive": &tools.Attachment{MIMEType: "application/zip", Data: []byte("PK")},
+		"name":    "not binary",
+	}
+
+	attachment, ok := findAttachment(result, "archive")
+	if !ok {
+		t.Fatal("Expected attachment to be found")
+	}
+	if attachment.Filename != "archive" {
+		t.Errorf("Expected default filename 'archive', got %s", attachment.Filename)
+	}
+
+	if _, ok := findAttachment(result, "name"); ok {
+		t.Error("Expected non-binary field not to be an attachment")
+	}
+	if _, ok := findAttachment(result, "missing"); ok {
+		t.Error("Expected missing field not to be an attachment")
+	}
+}
+
+func TestWrapToolResult(t *testing.T) {
+	link := map[string]interface{}{"type": "resource_link", "uri": "file:///tmp/report.pdf"}
+	image := map[string]interface{}{"type": "image", "data": "aGk=", "mimeType": "image/png"}
+
+	t.Run("plain values become structured content and text", func(t *testing.T) {
+		wrapped := wrapToolResult(map[string]interface{}{"uuid": "abc"}, ProtocolVersion20250618)
+		content := wrapped["content"].([]interface{})
+		if len(content) != 1 || content[0].(map[string]interface{})["text"] != `{"uuid":"abc"}` {
+			t.Errorf("Expected a JSON text block, got %v", content)
+		}
+		if wrapped["structuredContent"].(map[string]interface{})["uuid"] != "abc" || wrapped["isError"] != false {
+			t.Errorf("Unexpected wrapped result: %v", wrapped)
+		}
+	})
+
+	t.Run("content blocks become content in key order", func(t *testing.T) {
+		wrapped := wrapToolResult(map[string]interface{}{
+			"thumbnails": []interface{}{image, image},
+			"report":     link,
+			"size":       3,
+		}, ProtocolVersion20250618)
+		content := wrapped["content"].([]interface{})
+		if len(content) != 4 || content[1].(map[string]interface{})["type"] != "resource_link" || content[2].(map[string]interface{})["type"] != "image" {
+			t.Errorf("Expected the text block, the link, then the images, got %v", content)
+		}
+		structured := wrapped["structuredContent"].(map[string]interface{})
+		if len(structured) != 1 || structured["size"] != 3 {
+			t.Errorf("Expected only size in structured content, got %v", structured)
+		}
+	})
+
+	t.Run("content-only results have no text block", func(t *testing.T) {
+		wrapped := wrapToolResult(map[string]interface{}{"report": link}, ProtocolVersion20250618)
+		if content := wrapped["content"].([]interface{}); len(content) != 1 || content[0].(map[string]interface{})["type"] != "resource_link" {
+			t.Errorf("Expected only the link, got %v", content)
+		}
+	})
+
+	t.Run("older versions get no structured content", func(t *testing.T) {
+		wrapped := wrapToolResult(map[string]interface{}{"uuid": "abc"}, ProtocolVersion20250326)
+		if _, ok := wrapped["structuredContent"]; ok {
+			t.Errorf("Expected no structuredContent, got %v", wrapped)
+		}
+		if len(wrapped["content"].([]interface{})) != 1 {
+			t.Errorf("Expected a JSON text block, got %v", wrapped["content"])
+		}
+	})
+
+	t.Run("meta moves to the result", func(t *testing.T) {
+		meta := map[string]interface{}{"truncated": true}
+		wrapped := wrapToolResult(map[string]interface{}{"pods": []interface{}{"a"}, "_meta": meta}, ProtocolVersion20250618)
+		if wrapped["_meta"] == nil || wrapped["structuredContent"].(map[string]interface{})["_meta"] != nil {
+			t.Errorf("Expected _meta on the result only, got %v", wrapped)
+		}
+	})
+
+	t.Run("nil results are empty", func(t *testing.T) {
+		wrapped := wrapToolResult(nil, ProtocolVersion20250618)
+		if content := wrapped["content"].([]interface{}); len(content) != 0 {
+			t.Errorf("Expected no content, got %v", content)
+		}
+	})
+}
+
+func TestEncodeResult(t *testing.T) {
+	values := []interface{}{
+		nil,
+		"plain",
+		"quote \" backslash \\ <html> & \b\f\n\r\t \x01 \u2028 \u2029 é 日本 \xff",
+		true,
+		42,
+		int64(-7),
+		3.5,
+		1e21,
+		1e-7,
+		0.000001,
+		-0.0,
+		map[string]interface{}{"b": 1, "a": []interface{}{"x", nil, 2.5}, "c": map[string]interface{}{}},
+		[]interface{}{},
+		map[string]interface{}(nil),
+		[]interface{}(nil),
+		[]string{"typed", "slice"},
+		time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+		json.RawMessage(`{"raw": true}`),
+	}
+
+	t.Run("matches encoding/json", func(t *testing.T) {
+		for _, value := range values {
+			want, err := json.Marshal(value)
+			if err != nil {
+				t.Fatalf("Failed to marshal %v: %v", value, err)
+			}
+			got, err := encodeResult(value, 0)
+			if err != nil || string(got) != string(want) {
+				t.Errorf("Expected %s, got %s (%v)", want, got, err)
+			}
+		}
+	})
+
+	t.Run("stops at the limit", func(t *testing.T) {
+		large := map[string]interface{}{"items": make([]interface{}, 1000)}
+		if _, err := encodeResult(large, 100); !errors.Is(err, errResultTooLarge) {
+			t.Errorf("Expected errResultTooLarge, got %v", err)
+		}
+		encoded, err := encodeResult(large, 10000)
+		if err != nil || len(encoded) != encodedSize(large) {
+			t.Errorf("Expected the full encoding under the limit, got %d bytes (%v)", len(encoded), err)
+		}
+	})
+
+	t.Run("rejects unsupported values", func(t *testing.T) {
+		if _, err := encodeResult(map[string]interface{}{"n": math.NaN()}, 0); err == nil {
+			t.Error("Expected an error for NaN")
+		}
+	})
+}
+
+func TestDecodedCopy(t *testing.T) {
+	value := map[string]interface{}{
+		"count": 3,
+		"items": []interface{}{map[string]interface{}{"id": int64(1)}, "x"},
+		"typed": []string{"a"},
+		"when":  time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	want, err := normalizeJSON(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := decodedCopy(value)
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v (%v)", want, got, err)
+	}
+	got.(map[string]interface{})["items"].([]interface{})[1] = "changed"
+	if value["items"].([]interface{})[1] != "x" {
+		t.Error("Expected the copy not to share values with the original")
+	}
+}
+
+// largeToolResult returns a tool result of n records, like a large listing.
+func largeToolResult(n int) map[string]interface{} {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = map[string]interface{}{
+			"id":      i,
+			"name":    fmt.Sprintf("item-%d", i),
+			"score":   float64(i) / 3,
+			"active":  i%2 == 0,
+			"summary": strings.Repeat("lorem ipsum ", 8),
+		}
+	}
+	return map[string]interface{}{"items": items, "count": n}
+}
+
+func BenchmarkNormalizeToolResult(b *testing.B) {
+	result := largeToolResult(10000)
+	b.ReportAllocs()
+	for b.Loop() {
+		normalizeToolResult(result)
+	}
+}
+
+func BenchmarkEncodeResult(b *testing.B) {
+	result := largeToolResult(10000)
+	b.Run("encodeResult", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := encodeResult(result, 0); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("json.Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := json.Marshal(result); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("over limit", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := encodeResult(result, 64<<10); !errors.Is(err, errResultTooLarge) {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkResultPagerLimit(b *testing.B) {
+	result := largeToolResult(10000)
+	pager := NewResultPager()
+	pager.SetLimits(64<<10, 0)
+	b.ReportAllocs()
+	for b.Loop() {
+		pager.Limit(result)
+	}
+}
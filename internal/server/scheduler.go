@@ -0,0 +1,297 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrScheduleNotFound is returned for operations on a schedule that does not exist.
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+// Schedule runs a tool on a cron schedule.
+type Schedule struct {
+	Name      string                 // Unique name used by the admin API
+	Cron      string                 // Five-field cron expression or descriptor such as @hourly or @every 5m
+	Tool      string                 // Tool to run
+	Arguments map[string]interface{} // Arguments passed to the tool on every run
+	Enabled   bool                   // Whether runs happen; disabled schedules keep their place
+}
+
+// ScheduleRun is the outcome of one scheduled execution.
+type ScheduleRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMS int64     `json:"durationMs"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ScheduleStatus describes a schedule for the admin API.
+type ScheduleStatus struct {
+	Name      string                 `json:"name"`
+	Cron      string                 `json:"cron"`
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	Enabled   bool                   `json:"enabled"`
+	NextRun   *time.Time             `json:"nextRun,omitempty"`
+	LastRun   *ScheduleRun           `json:"lastRun,omitempty"`
+}
+
+// scheduledTool is a Schedule with its parsed expression and run state.
+type scheduledTool struct {
+	Schedule
+	spec    cron.Schedule
+	nextRun time.Time
+	lastRun *ScheduleRun
+}
+
+// Scheduler runs tools on cron schedules through the ToolService, so scheduled calls get
+// the same middleware as client calls. Each run publishes an EventScheduleExecuted event
+// with the result. When replicas share a store, only one of them runs each occurrence, and
+// enabling or disabling a schedule on one of them applies to all.
+type Scheduler struct {
+	mu        sync.Mutex
+	schedules map[string]*scheduledTool
+	service   *ToolService
+	logger    *slog.Logger
+	now       func() time.Time
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewScheduler creates a Scheduler that runs tools through service.
+func NewScheduler(service *ToolService, logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		schedules: make(map[string]*scheduledTool),
+		service:   service,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Add registers a schedule. It fails when the name is taken, the cron expression does
+// not parse, or the tool is not registered. Schedules added after Start begin at once.
+func (s *Scheduler) Add(schedule Schedule) error {
+	if schedule.Name == "" {
+		return errors.New("schedule name is required")
+	}
+	spec, err := cron.ParseStandard(schedule.Cron)
+	if err != nil {
+		return fmt.Errorf("schedule %s: invalid cron expression %q: %w", schedule.Name, schedule.Cron, err)
+	}
+	if _, ok := s.service.Resolve(schedule.Tool); !ok {
+		return fmt.Errorf("schedule %s: unknown tool %s", schedule.Name, schedule.Tool)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.schedules[schedule.Name]; exists {
+		return fmt.Errorf("schedule %s already exists", schedule.Name)
+	}
+	entry := &scheduledTool{Schedule: schedule, spec: spec}
+	s.schedules[schedule.Name] = entry
+	if s.stop != nil {
+		s.wg.Add(1)
+		go s.loop(entry, s.stop)
+	}
+	return nil
+}
+
+// List returns every schedule sorted by name, with the enabled state shared by the
+// replicas.
+func (s *Scheduler) List(ctx context.Context) ([]ScheduleStatus, error) {
+	s.mu.Lock()
+	entries := make([]*scheduledTool, 0, len(s.schedules))
+	for _, entry := range s.schedules {
+		entries = append(entries, entry)
+	}
+	s.mu.Unlock()
+	for _, entry := range entries {
+		if err := s.refresh(ctx, entry); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]ScheduleStatus, 0, len(entries))
+	for _, entry := range entries {
+		statuses = append(statuses, entry.status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+// SetEnabled enables or disables the named schedule on every replica and returns its new
+// status. It fails when the shared state cannot be stored.
+func (s *Scheduler) SetEnabled(ctx context.Context, name string, enabled bool) (ScheduleStatus, error) {
+	s.mu.Lock()
+	entry, ok := s.schedules[name]
+	s.mu.Unlock()
+	if !ok {
+		return ScheduleStatus{}, fmt.Errorf("%w: %s", ErrScheduleNotFound, name)
+	}
+	if err := s.service.Coordinator().SetScheduleEnabled(ctx, name, enabled); err != nil {
+		return ScheduleStatus{}, fmt.Errorf("failed to store schedule %s: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry.Enabled != enabled {
+		entry.Enabled = enabled
+		s.logger.Info("Schedule updated", "schedule", name, "enabled", enabled)
+	}
+	return entry.status(), nil
+}
+
+// refresh updates a schedule's enabled state from the one shared by the replicas, if any
+// has been set.
+func (s *Scheduler) refresh(ctx context.Context, entry *scheduledTool) error {
+	enabled, ok, err := s.service.Coordinator().ScheduleEnabled(ctx, entry.Name)
+	if err != nil {
+		return fmt.Errorf("failed to load schedule %s: %w", entry.Name, err)
+	}
+	if ok {
+		s.mu.Lock()
+		entry.Enabled = enabled
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// enabled reports whether a schedule should run now. When the shared state cannot be
+// read, the last known state is used.
+func (s *Scheduler) enabled(entry *scheduledTool) bool {
+	if err := s.refresh(context.Background(), entry); err != nil {
+		s.logger.Warn("Failed to load the schedule's state; using the last known one", "schedule", entry.Name, "error", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return entry.Enabled
+}
+
+// Start begins running the schedules. It does nothing if the scheduler is running.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	for _, entry := range s.schedules {
+		s.wg.Add(1)
+		go s.loop(entry, s.stop)
+	}
+	if len(s.schedules) > 0 {
+		s.logger.Info("Scheduler started", "schedules", len(s.schedules))
+	}
+}
+
+// Stop stops the schedules and waits for runs in progress to finish.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop := s.stop
+	s.stop = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	s.wg.Wait()
+}
+
+// loop waits for each occurrence of a schedule and runs it if the schedule is enabled.
+func (s *Scheduler) loop(entry *scheduledTool, stop <-chan struct{}) {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		next := entry.spec.Next(s.now())
+		entry.nextRun = next
+		s.mu.Unlock()
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if s.enabled(entry) {
+			s.run(entry, next, stop)
+		}
+	}
+}
+
+// run executes one occurrence of a schedule, unless another replica has claimed it. A
+// call held for approval waits until it is decided or the scheduler stops.
+func (s *Scheduler) run(entry *scheduledTool, occurrence time.Time, stop <-chan struct{}) {
+	ctx := WithRequestID(context.Background(), uuid.NewString())
+	lease := entry.spec.Next(occurrence).Sub(occurrence)
+	key := fmt.Sprintf("schedule:%s:%d", entry.Name, occurrence.Unix())
+	if won, err := s.service.Coordinator().RunOnce(ctx, key, lease); err != nil {
+		s.logger.Warn("Failed to claim scheduled run; running anyway", "schedule", entry.Name, "error", err)
+	} else if !won {
+		s.logger.Debug("Scheduled run claimed by another instance", "schedule", entry.Name)
+		return
+	}
+
+	start := s.now()
+	result, err := s.service.ExecuteToolContext(ctx, entry.Tool, entry.Arguments)
+	if _, held := heldJobID(result); held {
+		waitCtx, cancel := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-stop:
+				cancel()
+			case <-waitCtx.Done():
+			}
+		}()
+		result, err = s.service.awaitApproval(waitCtx, result, err)
+		cancel()
+	}
+	run := &ScheduleRun{StartedAt: start, DurationMS: s.now().Sub(start).Milliseconds(), Success: err == nil}
+	data := map[string]interface{}{
+		"schedule":   entry.Name,
+		"tool":       entry.Tool,
+		"success":    run.Success,
+		"durationMs": run.DurationMS,
+		"requestId":  RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		run.Error = err.Error()
+		data["error"] = run.Error
+		loggerFor(ctx, s.logger).Warn("Scheduled run failed", "schedule", entry.Name, "tool", entry.Tool, "error", err)
+	} else {
+		data["result"] = normalizeToolResult(result)
+	}
+
+	s.mu.Lock()
+	entry.lastRun = run
+	s.mu.Unlock()
+	s.service.Events().Publish(EventScheduleExecuted, data)
+}
+
+// status returns the schedule's state. The caller holds the scheduler's lock.
+func (e *scheduledTool) status() ScheduleStatus {
+	status := ScheduleStatus{
+		Name:      e.Name,
+		Cron:      e.Cron,
+		Tool:      e.Tool,
+		Arguments: e.Arguments,
+		Enabled:   e.Enabled,
+		LastRun:   e.lastRun,
+	}
+	if e.Enabled && !e.nextRun.IsZero() {
+		next := e.nextRun
+		status.NextRun = &next
+	}
+	return status
+}
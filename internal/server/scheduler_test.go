@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/store"
+)
+
+func TestScheduler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("validates schedules", func(t *testing.T) {
+		scheduler := newTestToolService(logger, &MockTool{name: "echo"}).Scheduler()
+		if err := scheduler.Add(Schedule{Name: "ok", Cron: "*/5 * * * *", Tool: "echo"}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		for _, schedule := range []Schedule{
+			{Cron: "@hourly", Tool: "echo"},
+			{Name: "bad_cron", Cron: "every tuesday", Tool: "echo"},
+			{Name: "bad_tool", Cron: "@hourly", Tool: "missing"},
+			{Name: "ok", Cron: "@hourly", Tool: "echo"},
+		} {
+			if err := scheduler.Add(schedule); err == nil {
+				t.Errorf("Expected %+v to be rejected", schedule)
+			}
+		}
+	})
+
+	t.Run("runs enabled schedules and publishes results", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"echo": args["text"]}, nil
+		}})
+		executed := make(chan Event, 4)
+		defer service.Events().Subscribe(func(event Event) { executed <- event }, EventScheduleExecuted)()
+		scheduler := service.Scheduler()
+		if err := scheduler.Add(Schedule{Name: "tick", Cron: "@every 1s", Tool: "echo", Arguments: map[string]interface{}{"text": "hi"}, Enabled: true}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		scheduler.Start()
+		defer scheduler.Stop()
+
+		select {
+		case event := <-executed:
+			if event.Data["schedule"] != "tick" || event.Data["success"] != true {
+				t.Errorf("Unexpected event data: %v", event.Data)
+			}
+			if result, _ := event.Data["result"].(map[string]interface{}); result["echo"] != "hi" {
+				t.Errorf("Expected the tool result in the event, got %v", event.Data["result"])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatal("Expected a scheduled run")
+		}
+		if statuses, _ := scheduler.List(context.Background()); len(statuses) != 1 {
+			t.Fatalf("Expected one schedule, got %+v", statuses)
+		} else if status := statuses[0]; status.LastRun == nil || !status.LastRun.Success || status.NextRun == nil {
+			t.Errorf("Expected the last and next runs to be reported, got %+v", status)
+		}
+	})
+
+	t.Run("held runs wait for the decision", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		service.Approvals().SetTools([]string{"echo"})
+		scheduler := service.Scheduler()
+		if err := scheduler.Add(Schedule{Name: "held", Cron: "@hourly", Tool: "echo", Enabled: true}); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+		stop := make(chan struct{})
+		finished := make(chan struct{})
+		go func() {
+			scheduler.run(scheduler.schedules["held"], time.Now(), stop)
+			close(finished)
+		}()
+		deadline := time.Now().Add(time.Second)
+		for len(service.Approvals().Pending()) == 0 && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if err := service.Approvals().Decide(service.Approvals().Pending()[0].JobID, false, "not now"); err != nil {
+			t.Fatalf("Decide failed: %v", err)
+		}
+		<-finished
+		statuses, _ := scheduler.List(context.Background())
+		if run := statuses[0].LastRun; run == nil || run.Success || !strings.Contains(run.Error, "not now") {
+			t.Errorf("Expected the denied run to fail, got %+v", run)
+		}
+
+		finished = make(chan struct{})
+		go func() {
+			scheduler.run(scheduler.schedules["held"], time.Now().Add(time.Hour), stop)
+			close(finished)
+		}()
+		close(stop)
+		select {
+		case <-finished:
+		case <-time.After(time.Second):
+			t.Fatal("Expected stopping to end the wait")
+		}
+	})
+
+	t.Run("skips disabled schedules and occurrences claimed elsewhere", func(t *testing.T) {
+		// Two replicas share a store, as they would through Redis
+		calls := 0
+		counter := &MockTool{name: "count", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			calls++
+			return map[string]interface{}{}, nil
+		}}
+		shared := store.NewMemoryStore()
+		var schedulers []*Scheduler
+		for _, instance := range []string{"a", "b"} {
+			service := newTestToolService(logger, counter)
+			service.SetCoordinator(store.NewCoordinator(shared, instance))
+			if err := service.Scheduler().Add(Schedule{Name: "count", Cron: "@hourly", Tool: "count", Enabled: true}); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			schedulers = append(schedulers, service.Scheduler())
+		}
+
+		occurrence := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+		for _, scheduler := range schedulers {
+			scheduler.run(scheduler.schedules["count"], occurrence, nil)
+		}
+		if calls != 1 {
+			t.Errorf("Expected one run per occurrence across replicas, got %d", calls)
+		}
+
+		scheduler, other := schedulers[0], schedulers[1]
+
+		status, err := scheduler.SetEnabled(context.Background(), "count", false)
+		if err != nil || status.Enabled || status.NextRun != nil {
+			t.Errorf("Expected the schedule to be disabled, got %+v (%v)", status, err)
+		}
+		if other.enabled(other.schedules["count"]) {
+			t.Error("Expected disabling on one replica to disable the schedule on the other")
+		}
+		if statuses, err := other.List(context.Background()); err != nil || statuses[0].Enabled {
+			t.Errorf("Expected the other replica to list the schedule as disabled, got %+v (%v)", statuses, err)
+		}
+		if _, err := other.SetEnabled(context.Background(), "count", true); err != nil || !scheduler.enabled(scheduler.schedules["count"]) {
+			t.Errorf("Expected enabling on the other replica to apply to both, got %v", err)
+		}
+		if _, err := scheduler.SetEnabled(context.Background(), "missing", true); !errors.Is(err, ErrScheduleNotFound) {
+			t.Errorf("Expected ErrScheduleNotFound, got %v", err)
+		}
+	})
+}
+
+func TestHTTPServer_AdminSchedules(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{name: "echo"})
+	if err := service.Scheduler().Add(Schedule{Name: "nightly", Cron: "0 2 * * *", Tool: "echo", Enabled: true}); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	httpServer := NewHTTPServer(service, WithPort(8080), WithLogger(logger))
+
+	t.Run("lists schedules", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, newAdminRequest("GET", "/admin/schedules", nil))
+		var body struct {
+			Schedules []ScheduleStatus `json:"schedules"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(body.Schedules) != 1 || body.Schedules[0].Cron != "0 2 * * *" || !body.Schedules[0].Enabled {
+			t.Errorf("Unexpected schedules: %+v", body.Schedules)
+		}
+	})
+
+	t.Run("disables and enables schedules", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, newAdminRequest("POST", "/admin/schedules/nightly/disable", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":false`) {
+			t.Errorf("Expected the schedule to be disabled, got %d: %s", w.Code, w.Body.String())
+		}
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, newAdminRequest("POST", "/admin/schedules/nightly/enable", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"enabled":true`) {
+			t.Errorf("Expected the schedule to be enabled, got %d: %s", w.Code, w.Body.String())
+		}
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, newAdminRequest("POST", "/admin/schedules/missing/enable", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
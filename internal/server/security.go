@@ -30,16 +30,17 @@ func (sm *SecurityManager) OriginCheckMiddleware(next http.Handler) http.Handler
 			return
 		}
 
+		logger := loggerFor(r.Context(), sm.logger)
 		origin := r.Header.Get("Origin")
 		if origin == "" {
-			sm.logger.Warn("Security check: Rejecting request with missing Origin header")
+			logger.Warn("Security check: Rejecting request with missing Origin header")
 			http.Error(w, "Forbidden: Missing Origin header", http.StatusForbidden)
 			return
 		}
 
 		originURL, err := url.Parse(origin)
 		if err != nil {
-			sm.logger.Warn("Security check: Rejecting request with invalid Origin header", "origin", origin)
+			logger.Warn("Security check: Rejecting request with invalid Origin header", "origin", origin)
 			http.Error(w, "Forbidden: Invalid Origin header", http.StatusForbidden)
 			return
 		}
@@ -56,7 +57,7 @@ func (sm *SecurityManager) OriginCheckMiddleware(next http.Handler) http.Handler
 		}
 
 		if !isAllowed {
-			sm.logger.Warn("Security check: Rejecting request from disallowed origin", "origin", origin)
+			logger.Warn("Security check: Rejecting request from disallowed origin", "origin", origin)
 			http.Error(w, "Forbidden: Origin not allowed", http.StatusForbidden)
 			return
 		}
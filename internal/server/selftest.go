@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// SelfTestStatus is the outcome of a tool's self-test.
+type SelfTestStatus string
+
+// Self-test outcomes.
+const (
+	SelfTestPassed  SelfTestStatus = "pass"
+	SelfTestFailed  SelfTestStatus = "fail"
+	SelfTestSkipped SelfTestStatus = "skipped" // The tool declares no self-test
+)
+
+// SelfTestResult is the outcome of one tool's self-test.
+type SelfTestResult struct {
+	Tool       string         `json:"tool"`
+	Status     SelfTestStatus `json:"status"`
+	DurationMS int64          `json:"durationMs"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// SelfTest calls every tool that implements tools.SelfTester with its sample arguments,
+// or only the named tools when names is not empty, and returns the results sorted by
+// tool name. Calls run concurrently through the full middleware chain, so a tool that is
+// quarantined, throttled, or behind an open circuit fails as it would for a client.
+func (s *ToolService) SelfTest(ctx context.Context, names []string) []SelfTestResult {
+	available := s.GetTools()
+	if len(names) == 0 {
+		for name := range available {
+			names = append(names, name)
+		}
+	}
+
+	results := make([]SelfTestResult, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		results[i] = SelfTestResult{Tool: name, Status: SelfTestSkipped}
+		tester, ok := available[name].(tools.SelfTester)
+		if !ok {
+			if _, exists := available[name]; !exists {
+				results[i].Status, results[i].Error = SelfTestFailed, ErrToolNotFound.Error()
+			}
+			continue
+		}
+		args := tester.SelfTestArgs()
+		if args == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			_, err := s.ExecuteToolContext(ctx, name, args)
+			results[i].DurationMS = time.Since(start).Milliseconds()
+			results[i].Status = SelfTestPassed
+			if err != nil {
+				results[i].Status, results[i].Error = SelfTestFailed, err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Tool < results[j].Tool })
+	return results
+}
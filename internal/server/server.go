@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
@@ -18,6 +19,8 @@ type Server struct {
 	httpServer           *HTTPServer
 	streamableHTTPServer *StreamableHTTPServer
 	webSocketServer      *WebSocketServer
+	health               *HealthState
+	events               *EventBus
 }
 
 // NewServer creates a new combined server.
@@ -28,15 +31,26 @@ func NewServer(
 	streamableHTTPServer *StreamableHTTPServer,
 	webSocketServer *WebSocketServer,
 ) *Server {
+	health := NewHealthState(time.Duration(cfg.StartupGracePeriod) * time.Second)
+	if httpServer != nil {
+		httpServer.health = health
+	}
+
 	return &Server{
 		config:               cfg,
 		mcpServer:            mcpServer,
 		httpServer:           httpServer,
 		streamableHTTPServer: streamableHTTPServer,
 		webSocketServer:      webSocketServer,
+		health:               health,
 	}
 }
 
+// SetEvents sets the bus on which the server announces its shutdown.
+func (s *Server) SetEvents(events *EventBus) {
+	s.events = events
+}
+
 // Start begins all configured servers and handles graceful shutdown.
 func (s *Server) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
@@ -45,6 +59,24 @@ func (s *Server) Start(ctx context.Context) error {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
+	// Bind every listener before serving, so the startup summary is only written once
+	// all of them accept connections.
+	if s.httpServer != nil {
+		if err := s.httpServer.Listen(); err != nil {
+			return fmt.Errorf("HTTP server failed to listen: %w", err)
+		}
+	}
+	if s.streamableHTTPServer != nil {
+		if err := s.streamableHTTPServer.Listen(); err != nil {
+			return fmt.Errorf("streamable HTTP server failed to listen: %w", err)
+		}
+	}
+	if s.webSocketServer != nil {
+		if err := s.webSocketServer.Listen(); err != nil {
+			return fmt.Errorf("WebSocket server failed to listen: %w", err)
+		}
+	}
+
 	errChan := make(chan error, 4) // One for each potential server
 
 	if s.mcpServer != nil {
@@ -71,13 +103,24 @@ func (s *Server) Start(ctx context.Context) error {
 		}()
 	}
 
+	s.health.MarkStarted()
+	summary := s.startupSummary()
+	if toolService := s.toolService(); toolService != nil {
+		toolService.setTransports(summary.Transports)
+	}
+	if err := s.writeStartupSummary(summary); err != nil {
+		slog.Default().Warn("Failed to write startup summary", "error", err)
+	}
+
 	// Wait for a shutdown signal or a server error.
 	select {
 	case <-sigChan:
 		cancel()
+		s.events.Publish(EventServerShutdown, map[string]interface{}{"reason": "signal"})
 		return s.shutdown(context.Background()) // Use a new context for shutdown
 	case err := <-errChan:
 		cancel()
+		s.events.Publish(EventServerShutdown, map[string]interface{}{"reason": "error", "error": err.Error()})
 		return fmt.Errorf("server error: %w", err)
 	}
 }
@@ -87,6 +130,16 @@ func (s *Server) shutdown(ctx context.Context) error {
 	shutdownCtx, shutdownCancel := context.WithTimeout(ctx, time.Duration(s.config.ShutdownTimeout)*time.Second)
 	defer shutdownCancel()
 
+	// Fail readiness first and give load balancers time to stop routing here
+	// before the listeners are closed.
+	s.health.StartDraining()
+	if s.config.ShutdownDrainDelay > 0 {
+		select {
+		case <-time.After(time.Duration(s.config.ShutdownDrainDelay) * time.Second):
+		case <-shutdownCtx.Done():
+		}
+	}
+
 	var shutdownError error
 
 	if s.httpServer != nil {
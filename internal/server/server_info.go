@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"time"
+
+	"mcp-tools-server/internal/version"
+)
+
+// serverInfoToolName is the meta-tool agents call to learn about the server they use.
+const serverInfoToolName = "server_info"
+
+// EnableServerInfo registers the server_info tool, whose uptime counts from this call.
+// Call it before serving requests.
+func (s *ToolService) EnableServerInfo() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[serverInfoToolName]; !exists {
+		s.tools[serverInfoToolName] = &serverInfoTool{service: s, started: time.Now()}
+	}
+}
+
+// setTransports records the enabled transports server_info reports.
+func (s *ToolService) setTransports(transports []TransportSummary) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transports = transports
+}
+
+// Transports returns the enabled transports and their bound addresses, or none before
+// the server has started.
+func (s *ToolService) Transports() []TransportSummary {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]TransportSummary{}, s.transports...)
+}
+
+// serverInfoTool reports the server's version, uptime, transports, tools, and resource
+// usage and implements tools.Tool.
+type serverInfoTool struct {
+	service *ToolService
+	started time.Time
+}
+
+// Name returns the tool's name
+func (t *serverInfoTool) Name() string {
+	return serverInfoToolName
+}
+
+// Description returns the tool's description
+func (t *serverInfoTool) Description() string {
+	return "Returns the server's version, uptime, enabled transports, number of registered tools, and memory and goroutine usage"
+}
+
+// Category returns the tool's category
+func (t *serverInfoTool) Category() string {
+	return "utility"
+}
+
+// Tags returns the tool's tags
+func (t *serverInfoTool) Tags() []string {
+	return []string{"introspection", "server"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *serverInfoTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *serverInfoTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext describes the server, counting the tools the calling tenant may use
+func (t *serverInfoTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	var memory runtime.MemStats
+	runtime.ReadMemStats(&memory)
+	uptime := time.Since(t.started)
+
+	return map[string]interface{}{
+		"name":          "mcp-tools-server",
+		"version":       version.GetVersion(),
+		"gitCommit":     version.GetGitCommit(),
+		"buildTime":     version.GetBuildTime(),
+		"goVersion":     runtime.Version(),
+		"instanceId":    t.service.Coordinator().InstanceID(),
+		"pid":           os.Getpid(),
+		"startedAt":     t.started.UTC().Format(time.RFC3339),
+		"uptime":        uptime.Round(time.Second).String(),
+		"uptimeSeconds": int64(uptime.Seconds()),
+		"transports":    t.service.Transports(),
+		"tools": map[string]interface{}{
+			"registered": len(t.service.GetTools()),
+			"available":  len(t.service.FilterTools(ToolFilter{Tenant: TenantFromContext(ctx)})),
+		},
+		"resources": map[string]interface{}{
+			"goroutines":     runtime.NumGoroutine(),
+			"heapAllocBytes": memory.HeapAlloc,
+			"heapInUseBytes": memory.HeapInuse,
+			"sysBytes":       memory.Sys,
+			"gcCycles":       memory.NumGC,
+			"cpus":           runtime.NumCPU(),
+			"gomaxprocs":     runtime.GOMAXPROCS(0),
+		},
+	}, nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+
+	"mcp-tools-server/internal/version"
+)
+
+func TestToolService_ServerInfo(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	newService := func() *ToolService {
+		service := newTestToolService(logger, &MockTool{name: "echo"}, &MockTool{name: "secret"})
+		service.EnableServerInfo()
+		return service
+	}
+
+	t.Run("reports version, uptime, tools, and resources", func(t *testing.T) {
+		result, err := newService().ExecuteTool(serverInfoToolName, nil)
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if result["version"] != version.GetVersion() || result["pid"] != os.Getpid() || result["startedAt"] == "" {
+			t.Errorf("Unexpected result: %v", result)
+		}
+		if uptime, ok := result["uptimeSeconds"].(int64); !ok || uptime < 0 {
+			t.Errorf("Expected a non-negative uptime, got %v", result["uptimeSeconds"])
+		}
+		if counts := result["tools"].(map[string]interface{}); counts["registered"] != 3 || counts["available"] != 3 {
+			t.Errorf("Expected 3 tools including server_info, got %v", counts)
+		}
+		resources := result["resources"].(map[string]interface{})
+		if goroutines, _ := resources["goroutines"].(int); goroutines < 1 || resources["heapAllocBytes"].(uint64) == 0 {
+			t.Errorf("Unexpected resources: %v", resources)
+		}
+		if transports := result["transports"].([]TransportSummary); len(transports) != 0 {
+			t.Errorf("Expected no transports before the server starts, got %v", transports)
+		}
+	})
+
+	t.Run("reports transports once started", func(t *testing.T) {
+		service := newService()
+		service.setTransports([]TransportSummary{{Name: "stdio"}, {Name: "http", Address: "127.0.0.1:8080"}})
+		result, err := service.ExecuteTool(serverInfoToolName, nil)
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if transports := result["transports"].([]TransportSummary); len(transports) != 2 || transports[1].Address != "127.0.0.1:8080" {
+			t.Errorf("Unexpected transports: %v", transports)
+		}
+	})
+
+	t.Run("counts the tools a tenant may use", func(t *testing.T) {
+		tenants, err := NewTenants([]Tenant{{Name: "team-a", APIKey: "key-a", Tools: []string{"echo", serverInfoToolName}}}, logger)
+		if err != nil {
+			t.Fatalf("NewTenants failed: %v", err)
+		}
+		ctx := WithTenant(context.Background(), tenants.lookup("key-a"))
+		result, err := newService().ExecuteToolContext(ctx, serverInfoToolName, nil)
+		if err != nil {
+			t.Fatalf("ExecuteToolContext failed: %v", err)
+		}
+		if counts := result["tools"].(map[string]interface{}); counts["registered"] != 3 || counts["available"] != 2 {
+			t.Errorf("Expected 2 of 3 tools available, got %v", counts)
+		}
+	})
+}
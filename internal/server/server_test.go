@@ -2,10 +2,13 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"mcp-tools-server/internal/config"
 	"mcp-tools-server/pkg/tools"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -16,7 +19,7 @@ func TestNewServer(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	toolService, _ := NewToolService(registry, logger)
 	mcpServer := NewMCPServer(toolService, logger)
-	httpServer := NewHTTPServer(toolService, cfg.HTTPPort, logger)
+	httpServer := NewHTTPServer(toolService, WithPort(cfg.HTTPPort), WithLogger(logger))
 
 	server := NewServer(cfg, mcpServer, httpServer, nil, nil)
 
@@ -35,6 +38,9 @@ func TestNewServer(t *testing.T) {
 	if server.streamableHTTPServer != nil {
 		t.Error("Expected nil streamableHTTPServer")
 	}
+	if httpServer.health != server.health {
+		t.Error("HTTP server does not share the server's health state")
+	}
 }
 
 func TestServer_shutdown(t *testing.T) {
@@ -46,7 +52,7 @@ func TestServer_shutdown(t *testing.T) {
 	registry := tools.NewToolRegistry()
 	toolService, _ := NewToolService(registry, logger)
 	mcpServer := NewMCPServer(toolService, logger)
-	httpServer := NewHTTPServer(toolService, cfg.HTTPPort, logger)
+	httpServer := NewHTTPServer(toolService, WithPort(cfg.HTTPPort), WithLogger(logger))
 
 	server := NewServer(cfg, mcpServer, httpServer, nil, nil)
 
@@ -59,3 +65,62 @@ func TestServer_shutdown(t *testing.T) {
 		t.Errorf("shutdown failed: %v", err)
 	}
 }
+
+func TestServer_shutdownDrains(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	cfg := &config.ServerConfig{
+		HTTPPort:           8080,
+		ShutdownTimeout:    5,
+		ShutdownDrainDelay: 1,
+	}
+	registry := tools.NewToolRegistry()
+	toolService, _ := NewToolService(registry, logger)
+	httpServer := NewHTTPServer(toolService, WithPort(cfg.HTTPPort), WithLogger(logger))
+
+	server := NewServer(cfg, nil, httpServer, nil, nil)
+
+	start := time.Now()
+	if err := server.shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("Expected shutdown to wait for the drain delay, took %v", elapsed)
+	}
+	if ready, reason := server.health.Ready(); ready || reason != "draining" {
+		t.Errorf("Expected readiness to fail with draining, got ready=%v reason=%s", ready, reason)
+	}
+}
+
+func TestServer_StartupSummary(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo"})
+	httpServer := NewHTTPServer(toolService, WithLogger(logger), WithBindAddress("127.0.0.1"), WithPort(0))
+	if err := httpServer.Listen(); err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer httpServer.options.listener.Close()
+
+	path := filepath.Join(t.TempDir(), "ready.json")
+	server := NewServer(&config.ServerConfig{StartupSummary: path}, nil, httpServer, nil, nil)
+	if err := server.writeStartupSummary(server.startupSummary()); err != nil {
+		t.Fatalf("writeStartupSummary failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read summary: %v", err)
+	}
+	var summary StartupSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatalf("Summary is not JSON: %v", err)
+	}
+	if summary.Event != "ready" || summary.Tools != 1 || summary.PID != os.Getpid() {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	if len(summary.Transports) != 1 || summary.Transports[0].Name != "http" {
+		t.Fatalf("Expected the http transport, got %+v", summary.Transports)
+	}
+	if address := summary.Transports[0].Address; address == "127.0.0.1:0" || !strings.HasPrefix(address, "127.0.0.1:") {
+		t.Errorf("Expected the bound address with its actual port, got %s", address)
+	}
+}
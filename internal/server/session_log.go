@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sessionLogger names the server as the source of the log records it forwards to sessions.
+const sessionLogger = "mcp-tools-server"
+
+// SessionLogHandler is a slog.Handler that forwards log records about a session to that
+// session as MCP logging notifications (notifications/message), once the session has
+// chosen a level with logging/setLevel. A record is about a session when it carries a
+// sessionID attribute, as loggers from loggerFor do while handling the session's
+// messages. Records at levels below the session's level, and records about no session,
+// are not forwarded, so one client never sees another's logs.
+//
+// The handler is created before the logger, and so before the sessions it forwards to;
+// it forwards nothing until Attach is called.
+type SessionLogHandler struct {
+	state *sessionLogState
+	attrs []slog.Attr
+	group string // Prefix of the keys of attributes added from now on
+}
+
+// sessionLogState is shared by a SessionLogHandler and the handlers derived from it.
+type sessionLogState struct {
+	sessions   atomic.Pointer[SessionManager]
+	forwarding sync.Map // Sessions a record is being sent to
+}
+
+// NewSessionLogHandler creates a SessionLogHandler with no sessions attached.
+func NewSessionLogHandler() *SessionLogHandler {
+	return &SessionLogHandler{state: &sessionLogState{}}
+}
+
+// Attach forwards records to the sessions managed by sessions.
+func (h *SessionLogHandler) Attach(sessions *SessionManager) {
+	h.state.sessions.Store(sessions)
+}
+
+// Enabled reports whether any session wants records at level.
+func (h *SessionLogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	sessions := h.state.sessions.Load()
+	return sessions != nil && sessions.wantsLogs(logSeverity(mcpLogLevel(level)))
+}
+
+// Handle sends the record to the session it is about. Records logged while a record is
+// being sent to the same session are dropped, so a failing delivery that logs cannot
+// loop.
+func (h *SessionLogHandler) Handle(_ context.Context, record slog.Record) error {
+	sessions := h.state.sessions.Load()
+	if sessions == nil {
+		return nil
+	}
+	data := map[string]interface{}{"message": record.Message}
+	var sessionID string
+	add := func(prefix string, attr slog.Attr) {
+		if attr.Key == "sessionID" && prefix == "" {
+			sessionID = attr.Value.String()
+			return
+		}
+		addLogAttr(data, prefix, attr)
+	}
+	for _, attr := range h.attrs {
+		add("", attr)
+	}
+	record.Attrs(func(attr slog.Attr) bool {
+		add(h.group, attr)
+		return true
+	})
+	if sessionID == "" {
+		return nil
+	}
+	if _, busy := h.state.forwarding.LoadOrStore(sessionID, true); busy {
+		return nil
+	}
+	defer h.state.forwarding.Delete(sessionID)
+	sessions.logTo(sessionID, mcpLogLevel(record.Level), sessionLogger, data)
+	return nil
+}
+
+// WithAttrs returns a handler that adds attrs to every record.
+func (h *SessionLogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *h
+	derived.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, attr := range attrs {
+		if h.group != "" {
+			attr.Key = h.group + attr.Key
+		}
+		derived.attrs = append(derived.attrs, attr)
+	}
+	return &derived
+}
+
+// WithGroup returns a handler that prefixes the keys of attributes added from now on.
+func (h *SessionLogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	derived := *h
+	derived.group = h.group + name + "."
+	return &derived
+}
+
+// addLogAttr adds an attribute to the data of a logging notification, flattening groups
+// into dotted keys.
+func addLogAttr(data map[string]interface{}, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+	if value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, member := range value.Group() {
+			addLogAttr(data, prefix, member)
+		}
+		return
+	}
+	if attr.Key == "" {
+		return
+	}
+	switch v := value.Any().(type) {
+	case error:
+		data[prefix+attr.Key] = v.Error()
+	case time.Duration:
+		data[prefix+attr.Key] = v.String()
+	default:
+		data[prefix+attr.Key] = v
+	}
+}
+
+// mcpLogLevel maps a slog level to the MCP logging level of the same severity.
+func mcpLogLevel(level slog.Level) string {
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warning"
+	case level == slog.LevelError:
+		return "error"
+	default:
+		return "critical"
+	}
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+
+	"mcp-tools-server/internal/store"
+)
+
+func TestSessionLogHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	manager := NewSessionManager(store.NewCoordinator(store.NewMemoryStore(), "pod-a"), logger)
+	handler := NewSessionLogHandler()
+	forward := slog.New(handler)
+
+	var received []map[string]interface{}
+	session := manager.Add("websocket", func(message []byte) error {
+		var notification map[string]interface{}
+		if err := json.Unmarshal(message, &notification); err != nil {
+			t.Fatalf("Invalid notification: %v", err)
+		}
+		received = append(received, notification["params"].(map[string]interface{}))
+		return nil
+	})
+	other := manager.Add("websocket", func([]byte) error {
+		t.Error("Expected no records for another session")
+		return nil
+	})
+	forSession := forward.With("sessionID", session.ID)
+
+	t.Run("nothing is forwarded before Attach", func(t *testing.T) {
+		manager.SetLogLevel(session.ID, "debug")
+		forSession.Error("early")
+		if len(received) != 0 {
+			t.Errorf("Expected no records, got %v", received)
+		}
+	})
+
+	handler.Attach(manager)
+
+	t.Run("records at or above the session's level are forwarded", func(t *testing.T) {
+		received = nil
+		manager.SetLogLevel(session.ID, "warning")
+		if handler.Enabled(t.Context(), slog.LevelInfo) || !handler.Enabled(t.Context(), slog.LevelWarn) {
+			t.Error("Expected the handler to be enabled from warnings up")
+		}
+		forSession.Info("too detailed")
+		forSession.WithGroup("tool").Warn("slow tool", "name", "dns_lookup", "error", errors.New("timeout"))
+		if len(received) != 1 {
+			t.Fatalf("Expected 1 record, got %v", received)
+		}
+		params := received[0]
+		data := params["data"].(map[string]interface{})
+		if params["level"] != "warning" || params["logger"] != sessionLogger || data["message"] != "slow tool" {
+			t.Errorf("Unexpected notification: %v", params)
+		}
+		if data["tool.name"] != "dns_lookup" || data["tool.error"] != "timeout" || data["sessionID"] != nil {
+			t.Errorf("Expected grouped attributes without the session ID, got %v", data)
+		}
+	})
+
+	t.Run("records about no session or a silent session are not forwarded", func(t *testing.T) {
+		received = nil
+		forward.Error("server-wide")
+		forward.With("sessionID", other.ID).Error("about the other session")
+		if len(received) != 0 {
+			t.Errorf("Expected no records, got %v", received)
+		}
+	})
+
+	t.Run("delivery that logs does not loop", func(t *testing.T) {
+		sends := 0
+		var looping *Session
+		looping = manager.Add("stdio", func([]byte) error {
+			sends++
+			forward.With("sessionID", looping.ID).Error("delivery failed")
+			return errors.New("closed")
+		})
+		manager.SetLogLevel(looping.ID, "debug")
+		forward.With("sessionID", looping.ID).Error("first")
+		if sends != 1 {
+			t.Errorf("Expected one delivery, got %d", sends)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"mcp-tools-server/internal/store"
+)
+
+// sessionRegistryTTL is how long a session stays in the shared registry unless its
+// instance renews it; instances renew their sessions three times per period.
+const sessionRegistryTTL = time.Minute
+
+// broadcastTopic names the shared store topic that carries broadcasts between replicas.
+const broadcastTopic = "broadcasts"
+
+// sharedBroadcast is a broadcast published to the other replicas.
+type sharedBroadcast struct {
+	Instance  string          `json:"instance"` // The publishing instance, which has delivered it already
+	Method    string          `json:"method"`
+	Params    json.RawMessage `json:"params,omitempty"`
+	Transport string          `json:"transport,omitempty"`
+}
+
+// SetShared makes the manager share its sessions through the coordinator's store: every
+// session is listed in a registry that all replicas read, the protocol version negotiated
+// with a session can be read by any replica, and BroadcastAll reaches the sessions of
+// every replica. Call it before Start.
+func (m *SessionManager) SetShared(shared bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shared = shared
+}
+
+// isShared reports whether sessions are shared with other replicas, and the coordinator
+// they are shared through.
+func (m *SessionManager) isShared() (bool, *store.Coordinator) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.shared, m.coordinator
+}
+
+// Start begins renewing this instance's sessions in the shared registry and delivering
+// broadcasts published by other replicas. It does nothing unless sessions are shared or
+// if the manager is running.
+func (m *SessionManager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.shared || m.stop != nil {
+		return nil
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	broadcasts, err := m.coordinator.Subscribe(ctx, broadcastTopic)
+	if err != nil {
+		stop()
+		return err
+	}
+	m.stop = stop
+	m.wg.Add(2)
+	go m.renewLoop(ctx)
+	go m.deliverLoop(broadcasts)
+	m.logger.Info("Shared session registry started", "instance", m.coordinator.InstanceID())
+	return nil
+}
+
+// Stop stops renewing sessions and delivering other replicas' broadcasts.
+func (m *SessionManager) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	stop()
+	m.wg.Wait()
+}
+
+// renewLoop renews this instance's sessions in the registry until ctx is done.
+func (m *SessionManager) renewLoop(ctx context.Context) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(sessionRegistryTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, session := range m.List() {
+				m.register(ctx, session)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// deliverLoop delivers broadcasts published by other replicas to this instance's
+// sessions until broadcasts is closed.
+func (m *SessionManager) deliverLoop(broadcasts <-chan string) {
+	defer m.wg.Done()
+	_, coordinator := m.isShared()
+	for message := range broadcasts {
+		var broadcast sharedBroadcast
+		if err := json.Unmarshal([]byte(message), &broadcast); err != nil {
+			m.logger.Warn("Ignoring malformed shared broadcast", "error", err)
+			continue
+		}
+		if broadcast.Instance == coordinator.InstanceID() {
+			continue
+		}
+		var params interface{}
+		if len(broadcast.Params) > 0 {
+			params = broadcast.Params
+		}
+		result := m.Broadcast(broadcast.Method, params, broadcast.Transport)
+		m.logger.Info("Delivered broadcast from another replica", "method", broadcast.Method,
+			"from", broadcast.Instance, "delivered", result.Delivered, "failed", result.Failed)
+	}
+}
+
+// register adds or renews a session in the shared registry.
+func (m *SessionManager) register(ctx context.Context, session *Session) {
+	_, coordinator := m.isShared()
+	record := store.SessionRecord{
+		ID:        session.ID,
+		Transport: session.Transport,
+		CreatedAt: session.CreatedAt,
+		ExpiresAt: time.Now().Add(sessionRegistryTTL),
+	}
+	if err := coordinator.RegisterSession(ctx, record); err != nil {
+		m.logger.Warn("Failed to register session", "sessionID", session.ID, "error", err)
+	}
+}
+
+// BroadcastAll sends a JSON-RPC notification like Broadcast and, when sessions are
+// shared, publishes it for the other replicas to deliver to their sessions. The result
+// counts this instance's sessions only.
+func (m *SessionManager) BroadcastAll(ctx context.Context, method string, params json.RawMessage, transport string) (BroadcastResult, error) {
+	var local interface{}
+	if len(params) > 0 {
+		local = params
+	}
+	result := m.Broadcast(method, local, transport)
+	shared, coordinator := m.isShared()
+	if !shared {
+		return result, nil
+	}
+	message, err := json.Marshal(sharedBroadcast{
+		Instance:  coordinator.InstanceID(),
+		Method:    method,
+		Params:    params,
+		Transport: transport,
+	})
+	if err != nil {
+		return result, err
+	}
+	if err := coordinator.Publish(ctx, broadcastTopic, string(message)); err != nil {
+		return result, err
+	}
+	result.Published = true
+	return result, nil
+}
+
+// Registered returns the sessions of every replica when sessions are shared, or this
+// instance's sessions otherwise, ordered by creation time.
+func (m *SessionManager) Registered(ctx context.Context) ([]store.SessionRecord, error) {
+	shared, coordinator := m.isShared()
+	if shared {
+		return coordinator.Sessions(ctx)
+	}
+	sessions := m.List()
+	records := make([]store.SessionRecord, 0, len(sessions))
+	for _, session := range sessions {
+		records = append(records, store.SessionRecord{
+			ID:        session.ID,
+			Transport: session.Transport,
+			Instance:  coordinator.InstanceID(),
+			CreatedAt: session.CreatedAt,
+		})
+	}
+	return records, nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// errSessionNotFound is returned for a message to a session this instance does not have.
+var errSessionNotFound = errors.New("session not found")
+
+// Send sends a JSON-RPC notification to one session.
+func (m *SessionManager) Send(id, method string, params interface{}) error {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return errSessionNotFound
+	}
+	notification := map[string]interface{}{"jsonrpc": "2.0", "method": method}
+	if params != nil {
+		notification["params"] = params
+	}
+	message, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	return session.send(message)
+}
+
+// Request sends a server-initiated JSON-RPC request, such as elicitation/create, to one
+// session and waits until ctx is done for the client's response, which it returns as
+// received: with either a result or an error. The request's ID is random, so the response
+// is matched by ID alone; it may arrive through any session, as a Streamable HTTP
+// client's does, since it posts its responses apart from its stream.
+func (m *SessionManager) Request(ctx context.Context, id, method string, params interface{}) (map[string]interface{}, error) {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, errSessionNotFound
+	}
+	requestID := "server-" + uuid.NewString()
+	request := map[string]interface{}{"jsonrpc": "2.0", "id": requestID, "method": method}
+	if params != nil {
+		request["params"] = params
+	}
+	message, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make(chan map[string]interface{}, 1)
+	m.mu.Lock()
+	if m.requests == nil {
+		m.requests = make(map[string]chan map[string]interface{})
+	}
+	m.requests[requestID] = responses
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.requests, requestID)
+		m.mu.Unlock()
+	}()
+
+	if err := session.send(message); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	select {
+	case response := <-responses:
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliverResponse hands a client's response to the Request waiting for it, and reports
+// whether one was.
+func (m *SessionManager) deliverResponse(response map[string]interface{}) bool {
+	id, _ := response["id"].(string)
+	m.mu.Lock()
+	responses, ok := m.requests[id]
+	delete(m.requests, id)
+	m.mu.Unlock()
+	if ok {
+		responses <- response
+	}
+	return ok
+}
+
+// isResponse reports whether a JSON-RPC message is a response: it has an ID and a result
+// or an error, and no method.
+func isResponse(message map[string]interface{}) bool {
+	if _, hasMethod := message["method"]; hasMethod {
+		return false
+	}
+	_, hasResult := message["result"]
+	_, hasError := message["error"]
+	_, hasID := message["id"]
+	return hasID && (hasResult || hasError)
+}
@@ -0,0 +1,401 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"mcp-tools-server/internal/store"
+)
+
+// instanceIDHeader names the response header that tells load balancers which instance
+// owns a session, so they can route follow-up requests back to it.
+const instanceIDHeader = "X-Instance-ID"
+
+// sessionAffinityTTL bounds how long a session's owning instance is remembered in the
+// shared store if the instance dies without removing it.
+const sessionAffinityTTL = 24 * time.Hour
+
+// Session is a connected MCP client on any transport.
+type Session struct {
+	ID        string
+	Transport string
+	CreatedAt time.Time
+	send      func(message []byte) error
+
+	// Guarded by the manager's lock
+	protocolVersion string // Negotiated at initialization
+	logLevel        string // Least severe level of logging notifications sent; empty sends all
+}
+
+// LogLevels are the MCP logging levels, the syslog severities, from least to most severe.
+var LogLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// logSeverity returns the position of level in LogLevels, or -1 if it is not one.
+func logSeverity(level string) int {
+	return slices.Index(LogLevels, level)
+}
+
+// SessionManager tracks active MCP sessions across all transports so that the server
+// can push notifications to every connected client.
+type SessionManager struct {
+	sessions    map[string]*Session
+	mu          sync.RWMutex
+	coordinator *store.Coordinator
+	recorder    *SessionRecorder
+	events      *EventBus
+	logger      *slog.Logger
+	requests    map[string]chan map[string]interface{} // Server-initiated requests awaiting a response, by ID
+
+	shared bool               // Sessions are listed in the coordinator's registry
+	stop   context.CancelFunc // Stops the registry loops; nil when not running
+	wg     sync.WaitGroup
+}
+
+type sessionIDKey struct{}
+
+// WithSessionID returns a copy of ctx carrying the ID of the session a message came from.
+func WithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, id)
+}
+
+// SessionIDFromContext returns the session ID stored in ctx, or "" if there is none.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey{}).(string)
+	return id
+}
+
+// NewSessionManager creates a new SessionManager.
+func NewSessionManager(coordinator *store.Coordinator, logger *slog.Logger) *SessionManager {
+	return &SessionManager{
+		sessions:    make(map[string]*Session),
+		coordinator: coordinator,
+		logger:      logger,
+	}
+}
+
+// Add registers a new session whose messages are delivered through send.
+// The session's owning instance is recorded in the shared store as an affinity hint.
+func (m *SessionManager) Add(transport string, send func(message []byte) error) *Session {
+	return m.AddWithID(uuid.NewString(), transport, send)
+}
+
+// AddWithID registers the connection of a session whose ID was issued earlier, such as
+// a Streamable HTTP client's SSE stream, like Add. It replaces the connection the session
+// already has, such as a stream the client is reconnecting from.
+func (m *SessionManager) AddWithID(id, transport string, send func(message []byte) error) *Session {
+	session := &Session{
+		ID:        id,
+		Transport: transport,
+		CreatedAt: time.Now(),
+		send:      send,
+	}
+
+	m.mu.Lock()
+	m.sessions[session.ID] = session
+	coordinator, shared := m.coordinator, m.shared
+	if recorder := m.recorder; recorder != nil {
+		session.send = func(message []byte) error {
+			recorder.Record(session.ID, RecordOut, message)
+			return send(message)
+		}
+	}
+	m.mu.Unlock()
+
+	if err := coordinator.SetSessionAffinity(context.Background(), session.ID, sessionAffinityTTL); err != nil {
+		m.logger.Warn("Failed to record session affinity", "sessionID", session.ID, "error", err)
+	}
+	if shared {
+		m.register(context.Background(), session)
+	}
+	m.logger.Info("MCP session started", "sessionID", session.ID, "transport", transport)
+	m.events.Publish(EventSessionStarted, map[string]interface{}{"sessionId": session.ID, "transport": transport})
+	return session
+}
+
+// Remove unregisters a session.
+func (m *SessionManager) Remove(id string) {
+	m.remove(id, nil)
+}
+
+// removeConnection unregisters session unless AddWithID has replaced its connection.
+func (m *SessionManager) removeConnection(session *Session) {
+	m.remove(session.ID, session)
+}
+
+// remove unregisters the session with id, only if it is connection when that is not nil.
+func (m *SessionManager) remove(id string, connection *Session) {
+	m.mu.Lock()
+	session, ok := m.sessions[id]
+	if ok && connection != nil && session != connection {
+		ok = false
+	}
+	if ok {
+		delete(m.sessions, id)
+	}
+	coordinator, shared := m.coordinator, m.shared
+	m.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := coordinator.ClearSessionAffinity(context.Background(), id); err != nil {
+		m.logger.Warn("Failed to clear session affinity", "sessionID", id, "error", err)
+	}
+	if shared {
+		if err := coordinator.UnregisterSession(context.Background(), id); err != nil {
+			m.logger.Warn("Failed to unregister session", "sessionID", id, "error", err)
+		}
+	}
+	m.logger.Info("MCP session ended", "sessionID", id, "transport", session.Transport)
+	m.events.Publish(EventSessionEnded, map[string]interface{}{
+		"sessionId":  id,
+		"transport":  session.Transport,
+		"durationMs": time.Since(session.CreatedAt).Milliseconds(),
+	})
+}
+
+// sessionOwnerValue names the session value recording the tenant an issued session
+// belongs to.
+const sessionOwnerValue = "tenant"
+
+// Issue creates the ID of a new session for a transport whose requests share no
+// connection, such as Streamable HTTP, and records the tenant in ctx as its owner, where
+// every replica can read it.
+func (m *SessionManager) Issue(ctx context.Context) (string, error) {
+	m.mu.RLock()
+	coordinator := m.coordinator
+	m.mu.RUnlock()
+	id := uuid.NewString()
+	if err := coordinator.SetSessionValue(ctx, id, sessionOwnerValue, tenantName(ctx), sessionAffinityTTL); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Owns reports whether id was issued by Issue to the tenant in ctx.
+func (m *SessionManager) Owns(ctx context.Context, id string) (bool, error) {
+	m.mu.RLock()
+	coordinator := m.coordinator
+	m.mu.RUnlock()
+	owner, ok, err := coordinator.SessionValue(ctx, id, sessionOwnerValue)
+	if err != nil {
+		return false, err
+	}
+	return ok && owner == tenantName(ctx), nil
+}
+
+// SetProtocolVersion records the protocol version negotiated with a session. Sessions
+// unknown to this instance are ignored unless sessions are shared, in which case any
+// replica can read the version.
+func (m *SessionManager) SetProtocolVersion(id, version string) {
+	m.mu.Lock()
+	if session, ok := m.sessions[id]; ok {
+		session.protocolVersion = version
+	}
+	coordinator, shared := m.coordinator, m.shared
+	m.mu.Unlock()
+
+	if shared && id != "" {
+		if err := coordinator.SetSessionValue(context.Background(), id, "protocolVersion", version, sessionAffinityTTL); err != nil {
+			m.logger.Warn("Failed to share session protocol version", "sessionID", id, "error", err)
+		}
+	}
+}
+
+// ProtocolVersion returns the protocol version negotiated with a session, or "" if the
+// session is unknown or has not initialized. With shared sessions, the version
+// negotiated on another replica is returned.
+func (m *SessionManager) ProtocolVersion(id string) string {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	coordinator, shared := m.coordinator, m.shared
+	var version string
+	if ok {
+		version = session.protocolVersion
+	}
+	m.mu.RUnlock()
+
+	if version != "" || !shared || id == "" {
+		return version
+	}
+	version, _, err := coordinator.SessionValue(context.Background(), id, "protocolVersion")
+	if err != nil {
+		m.logger.Warn("Failed to read shared session protocol version", "sessionID", id, "error", err)
+	}
+	return version
+}
+
+// SetLogLevel sets the least severe level of logging notifications sent to a session, as
+// requested with logging/setLevel. Unknown sessions are ignored.
+func (m *SessionManager) SetLogLevel(id, level string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, ok := m.sessions[id]; ok {
+		session.logLevel = level
+	}
+}
+
+// SetRecorder records the notifications sent to sessions added from now on.
+func (m *SessionManager) SetRecorder(recorder *SessionRecorder) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.recorder = recorder
+}
+
+// SetCoordinator replaces the coordinator used to record session affinity.
+func (m *SessionManager) SetCoordinator(coordinator *store.Coordinator) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.coordinator = coordinator
+}
+
+// List returns the active sessions ordered by creation time.
+func (m *SessionManager) List() []*Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	sessions := make([]*Session, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions
+}
+
+// Notify sends a JSON-RPC notification to every active session. Delivery failures are
+// logged and do not stop delivery to the remaining sessions.
+func (m *SessionManager) Notify(method string, params interface{}) {
+	m.Broadcast(method, params, "")
+}
+
+// Log sends an MCP logging notification (notifications/message) to every active session
+// whose log level lets it through.
+func (m *SessionManager) Log(level, logger string, data interface{}) BroadcastResult {
+	params := map[string]interface{}{"level": level, "data": data}
+	if logger != "" {
+		params["logger"] = logger
+	}
+	severity := logSeverity(level)
+	return m.broadcast("notifications/message", params, func(session *Session) bool {
+		return session.logLevel == "" || severity >= logSeverity(session.logLevel)
+	})
+}
+
+// announceShutdown sends the sessions on transport a logging notification that the
+// server is shutting down, so clients reconnect to another instance rather than treat
+// the closing connection as a failure. Sessions whose log level is above warning are
+// not sent it.
+func (m *SessionManager) announceShutdown(transport string) BroadcastResult {
+	params := map[string]interface{}{
+		"level":  "warning",
+		"logger": sessionLogger,
+		"data": map[string]interface{}{
+			"message": "Server is shutting down; reconnect to continue",
+			"reason":  "shutdown",
+		},
+	}
+	severity := logSeverity("warning")
+	return m.broadcast("notifications/message", params, func(session *Session) bool {
+		return session.Transport == transport && (session.logLevel == "" || severity >= logSeverity(session.logLevel))
+	})
+}
+
+// wantsLogs reports whether a session has set a log level at or below severity with
+// logging/setLevel.
+func (m *SessionManager) wantsLogs(severity int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, session := range m.sessions {
+		if session.logLevel != "" && severity >= logSeverity(session.logLevel) {
+			return true
+		}
+	}
+	return false
+}
+
+// logTo sends an MCP logging notification to a session that has set a log level with
+// logging/setLevel and whose level lets it through. Delivery failures are not logged,
+// since the record would be forwarded to the same session again.
+func (m *SessionManager) logTo(id, level, logger string, data interface{}) {
+	m.mu.RLock()
+	session, ok := m.sessions[id]
+	wanted := ok && session.logLevel != "" && logSeverity(level) >= logSeverity(session.logLevel)
+	m.mu.RUnlock()
+	if !wanted {
+		return
+	}
+	message, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "notifications/message",
+		"params":  map[string]interface{}{"level": level, "logger": logger, "data": data},
+	})
+	if err != nil {
+		return
+	}
+	_ = session.send(message)
+}
+
+// BroadcastResult counts the sessions a notification was sent to.
+type BroadcastResult struct {
+	Delivered int  `json:"delivered"`
+	Failed    int  `json:"failed"`
+	Published bool `json:"published,omitempty"` // Also sent to the other replicas
+}
+
+// Broadcast sends a JSON-RPC notification to the active sessions on transport, or to
+// every session when transport is empty. Delivery failures are logged and counted and do
+// not stop delivery to the remaining sessions.
+func (m *SessionManager) Broadcast(method string, params interface{}, transport string) BroadcastResult {
+	return m.broadcast(method, params, func(session *Session) bool {
+		return transport == "" || session.Transport == transport
+	})
+}
+
+// broadcast sends a JSON-RPC notification to the active sessions for which include,
+// called under the manager's lock, returns true.
+func (m *SessionManager) broadcast(method string, params interface{}, include func(*Session) bool) BroadcastResult {
+	var result BroadcastResult
+	notification := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  method,
+	}
+	if params != nil {
+		notification["params"] = params
+	}
+	message, err := json.Marshal(notification)
+	if err != nil {
+		m.logger.Error("Failed to marshal notification", "method", method, "error", err)
+		return result
+	}
+
+	m.mu.RLock()
+	var recipients []*Session
+	for _, session := range m.sessions {
+		if include(session) {
+			recipients = append(recipients, session)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(recipients, func(i, j int) bool {
+		return recipients[i].CreatedAt.Before(recipients[j].CreatedAt)
+	})
+
+	for _, session := range recipients {
+		if err := session.send(message); err != nil {
+			m.logger.Warn("Failed to deliver notification", "method", method, "sessionID", session.ID, "error", err)
+			result.Failed++
+			continue
+		}
+		result.Delivered++
+	}
+	return result
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/store"
+)
+
+func TestSessionManager(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	coordinator := store.NewCoordinator(store.NewMemoryStore(), "pod-a")
+	manager := NewSessionManager(coordinator, logger)
+
+	var received [][]byte
+	first := manager.Add("websocket", func(message []byte) error {
+		received = append(received, message)
+		return nil
+	})
+	manager.Add("streamable", func(message []byte) error {
+		return fmt.Errorf("stream closed")
+	})
+
+	if len(manager.List()) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(manager.List()))
+	}
+	if manager.List()[0].ID != first.ID {
+		t.Error("Expected sessions ordered by creation time")
+	}
+	if owner, ok, _ := coordinator.SessionAffinity(context.Background(), first.ID); !ok || owner != "pod-a" {
+		t.Errorf("Expected session affinity for pod-a, got %q %v", owner, ok)
+	}
+
+	manager.Notify("notifications/tools/list_changed", nil)
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 delivered notification, got %d", len(received))
+	}
+	var notification map[string]interface{}
+	if err := json.Unmarshal(received[0], &notification); err != nil {
+		t.Fatalf("Failed to unmarshal notification: %v", err)
+	}
+	if notification["method"] != "notifications/tools/list_changed" {
+		t.Errorf("Unexpected method: %v", notification["method"])
+	}
+	if _, hasID := notification["id"]; hasID {
+		t.Error("Notifications must not carry an id")
+	}
+
+	if result := manager.Broadcast("notifications/custom", nil, "streamable"); result.Delivered != 0 || result.Failed != 1 {
+		t.Errorf("Expected only the streamable session to be tried, got %+v", result)
+	}
+	received = nil
+	if result := manager.Log("warning", "ops", "maintenance at 02:00"); result.Delivered != 1 || result.Failed != 1 {
+		t.Errorf("Expected one delivery and one failure, got %+v", result)
+	}
+	if err := json.Unmarshal(received[0], &notification); err != nil {
+		t.Fatalf("Failed to unmarshal notification: %v", err)
+	}
+	if params, _ := notification["params"].(map[string]interface{}); notification["method"] != "notifications/message" || params["level"] != "warning" || params["logger"] != "ops" {
+		t.Errorf("Unexpected logging notification: %v", notification)
+	}
+
+	manager.Remove(first.ID)
+	if len(manager.List()) != 1 {
+		t.Errorf("Expected 1 session after removal, got %d", len(manager.List()))
+	}
+	if _, ok, _ := coordinator.SessionAffinity(context.Background(), first.ID); ok {
+		t.Error("Expected session affinity to be cleared on removal")
+	}
+}
+
+func TestSessionManager_Shared(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	ctx := context.Background()
+	shared := store.NewMemoryStore()
+	newReplica := func(instanceID string) *SessionManager {
+		manager := NewSessionManager(store.NewCoordinator(shared, instanceID), logger)
+		manager.SetShared(true)
+		if err := manager.Start(); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		t.Cleanup(manager.Stop)
+		return manager
+	}
+	a := newReplica("pod-a")
+	b := newReplica("pod-b")
+
+	received := make(chan []byte, 10)
+	sessionA := a.Add("websocket", func(message []byte) error { return nil })
+	sessionB := b.Add("streamable", func(message []byte) error {
+		received <- message
+		return nil
+	})
+
+	t.Run("every replica's sessions are listed", func(t *testing.T) {
+		for _, manager := range []*SessionManager{a, b} {
+			records, err := manager.Registered(ctx)
+			if err != nil || len(records) != 2 {
+				t.Fatalf("Expected 2 sessions, got %+v %v", records, err)
+			}
+			if records[0].ID != sessionA.ID || records[0].Instance != "pod-a" || records[1].Instance != "pod-b" {
+				t.Errorf("Unexpected sessions: %+v", records)
+			}
+		}
+	})
+
+	t.Run("broadcasts reach other replicas", func(t *testing.T) {
+		result, err := a.BroadcastAll(ctx, "notifications/message", json.RawMessage(`{"data":"hello"}`), "")
+		if err != nil || result.Delivered != 1 || !result.Published {
+			t.Fatalf("Expected a local delivery and a publish, got %+v %v", result, err)
+		}
+		select {
+		case message := <-received:
+			var notification map[string]interface{}
+			_ = json.Unmarshal(message, &notification)
+			if params, _ := notification["params"].(map[string]interface{}); params["data"] != "hello" {
+				t.Errorf("Unexpected notification: %s", message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for the broadcast on the other replica")
+		}
+	})
+
+	t.Run("protocol versions are read on any replica", func(t *testing.T) {
+		a.SetProtocolVersion("client-session", ProtocolVersion20250326)
+		if version := b.ProtocolVersion("client-session"); version != ProtocolVersion20250326 {
+			t.Errorf("Expected %s, got %q", ProtocolVersion20250326, version)
+		}
+	})
+
+	t.Run("removed sessions leave the registry", func(t *testing.T) {
+		b.Remove(sessionB.ID)
+		records, _ := a.Registered(ctx)
+		if len(records) != 1 || records[0].ID != sessionA.ID {
+			t.Errorf("Expected only %s, got %+v", sessionA.ID, records)
+		}
+	})
+}
+
+func BenchmarkSessionManager_Broadcast(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	manager := NewSessionManager(store.NewCoordinator(store.NewMemoryStore(), "pod-a"), logger)
+	for range 100 {
+		manager.Add("streamable", func([]byte) error { return nil })
+	}
+	params := map[string]interface{}{"level": "info", "data": "tools changed"}
+	b.ReportAllocs()
+	for b.Loop() {
+		if result := manager.Broadcast("notifications/message", params, ""); result.Delivered != 100 {
+			b.Fatalf("Expected 100 deliveries, got %+v", result)
+		}
+	}
+}
@@ -1,27 +1,57 @@
 package server
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Defaults for SSE clients and published events.
+const (
+	sseClientBuffer   = 256 // Messages queued per client before broadcasts to it are dropped
+	sseEventHistory   = 256 // Published events kept for clients reconnecting with Last-Event-ID
+	sseHeartbeatFrame = ": heartbeat\n\n"
+)
+
 // Client represents a single SSE client connection.
 type Client struct {
 	id      string
 	send    chan []byte // Channel to send messages to this client.
 	logger  *slog.Logger
 	isAlive bool
+	filter  func(SSEEvent) bool // Published events the client wants; nil wants none
+}
+
+// SSEEvent is an event published to the SSE clients that want it.
+type SSEEvent struct {
+	ID    uint64            // Assigned by Publish, in publication order
+	Type  string            // Sent as the event field; empty sends none
+	Data  []byte            // Sent as the data field, one line per line of data
+	Attrs map[string]string // Attributes clients filter on; not sent
 }
 
-// SSEManager handles all active SSE client connections.
+// SSEManager handles all active SSE client connections. Events given to Publish carry
+// an ID and are kept in a ring buffer, so a client that reconnects with the last ID it
+// saw is sent the events it missed. Stream writes a client's messages with a retry field
+// and periodic heartbeat comments.
 type SSEManager struct {
 	clients map[string]*Client
 	mu      sync.RWMutex
 	logger  *slog.Logger
+
+	epoch     string     // Distinguishes this manager's event IDs from a previous process's
+	history   []SSEEvent // Ring buffer; the event with ID n is at (n-1) % len(history)
+	lastID    uint64
+	heartbeat time.Duration // Between heartbeat comments on idle streams; zero sends none
+	retry     time.Duration // Reconnection delay sent in the retry field; zero sends none
 }
 
 // NewSSEManager creates a new SSEManager.
@@ -29,9 +59,19 @@ func NewSSEManager(logger *slog.Logger) *SSEManager {
 	return &SSEManager{
 		clients: make(map[string]*Client),
 		logger:  logger,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		history: make([]SSEEvent, sseEventHistory),
 	}
 }
 
+// SetKeepAlive sets the interval between heartbeat comments on idle streams and the
+// reconnection delay sent to clients. Zero turns either off.
+func (m *SSEManager) SetKeepAlive(heartbeat, retry time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heartbeat, m.retry = heartbeat, retry
+}
+
 // AddClient registers a new client and returns it.
 func (m *SSEManager) AddClient() *Client {
 	m.mu.Lock()
@@ -40,7 +80,7 @@ func (m *SSEManager) AddClient() *Client {
 	clientID := uuid.NewString()
 	client := &Client{
 		id:      clientID,
-		send:    make(chan []byte, 256), // Buffered channel
+		send:    make(chan []byte, sseClientBuffer), // Buffered channel
 		logger:  m.logger.With("clientID", clientID),
 		isAlive: true,
 	}
@@ -104,3 +144,147 @@ func (m *SSEManager) Broadcast(message []byte) {
 		}
 	}
 }
+
+// Subscribe registers a client for the published events that filter accepts. When
+// lastEventID is the ID of an event this manager published, the matching events
+// published after it are queued first; complete reports whether all of them were still
+// kept. A client reconnecting after events were dropped from the buffer, or after a
+// restart, is told so by complete being false.
+func (m *SSEManager) Subscribe(lastEventID string, filter func(SSEEvent) bool) (client *Client, complete bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var replay [][]byte
+	complete = true
+	if lastEventID != "" {
+		after, ok := m.parseEventID(lastEventID)
+		oldest := uint64(1)
+		if m.lastID > uint64(len(m.history)) {
+			oldest = m.lastID - uint64(len(m.history)) + 1
+		}
+		complete = ok && after+1 >= oldest
+		if ok {
+			for id := max(after+1, oldest); id <= m.lastID; id++ {
+				if event := m.history[(id-1)%uint64(len(m.history))]; filter(event) {
+					replay = append(replay, m.frame(event))
+				}
+			}
+		}
+	}
+
+	clientID := uuid.NewString()
+	client = &Client{
+		id:      clientID,
+		send:    make(chan []byte, sseClientBuffer+len(replay)),
+		logger:  m.logger.With("clientID", clientID),
+		isAlive: true,
+		filter:  filter,
+	}
+	for _, frame := range replay {
+		client.send <- frame
+	}
+	m.clients[client.id] = client
+	m.logger.Info("SSE client subscribed", "clientID", client.id, "lastEventID", lastEventID, "replayed", len(replay), "complete", complete)
+	return client, complete
+}
+
+// Publish assigns the event the next ID, keeps it for clients that reconnect, and sends
+// it to the subscribed clients whose filter accepts it. Like Broadcast, it drops the
+// event for a client whose queue is full rather than wait.
+func (m *SSEManager) Publish(event SSEEvent) uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastID++
+	event.ID = m.lastID
+	m.history[(event.ID-1)%uint64(len(m.history))] = event
+	var frame []byte
+	for id, client := range m.clients {
+		if !client.isAlive || client.filter == nil || !client.filter(event) {
+			continue
+		}
+		if frame == nil {
+			frame = m.frame(event)
+		}
+		select {
+		case client.send <- frame:
+		default:
+			m.logger.Warn("Failed to publish event to client, channel full", "clientID", id, "eventID", event.ID)
+		}
+	}
+	return event.ID
+}
+
+// Stream writes the client's messages to w until the client is removed or ctx is done.
+// It starts with the retry field, if one is set, and sends a heartbeat comment whenever
+// the stream has been idle for the heartbeat interval, so proxies keep it open and a
+// disconnected client is noticed. It returns the error that ended a failed write.
+func (m *SSEManager) Stream(ctx context.Context, w http.ResponseWriter, client *Client) error {
+	m.mu.RLock()
+	heartbeat, retry := m.heartbeat, m.retry
+	m.mu.RUnlock()
+
+	controller := http.NewResponseController(w)
+	write := func(frame []byte) error {
+		if _, err := w.Write(frame); err != nil {
+			return err
+		}
+		return controller.Flush()
+	}
+	if retry > 0 {
+		if err := write([]byte(fmt.Sprintf("retry: %d\n\n", retry.Milliseconds()))); err != nil {
+			return err
+		}
+	}
+
+	var beats <-chan time.Time
+	if heartbeat > 0 {
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		beats = ticker.C
+	}
+	for {
+		select {
+		case frame, ok := <-client.send:
+			if !ok {
+				return nil
+			}
+			if err := write(frame); err != nil {
+				return err
+			}
+		case <-beats:
+			if err := write([]byte(sseHeartbeatFrame)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// frame formats a published event for the wire.
+func (m *SSEManager) frame(event SSEEvent) []byte {
+	var frame bytes.Buffer
+	fmt.Fprintf(&frame, "id: %s:%d\n", m.epoch, event.ID)
+	if event.Type != "" {
+		fmt.Fprintf(&frame, "event: %s\n", event.Type)
+	}
+	for _, line := range bytes.Split(event.Data, []byte("\n")) {
+		fmt.Fprintf(&frame, "data: %s\n", line)
+	}
+	frame.WriteByte('\n')
+	return frame.Bytes()
+}
+
+// parseEventID returns the number of an event ID this manager assigned.
+func (m *SSEManager) parseEventID(eventID string) (uint64, bool) {
+	epoch, number, found := strings.Cut(eventID, ":")
+	if !found || epoch != m.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(number, 10, 64)
+	if err != nil || id > m.lastID {
+		return 0, false
+	}
+	return id, true
+}
@@ -1,8 +1,13 @@
 package server
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -118,3 +123,116 @@ func TestSSEManager_Broadcast(t *testing.T) {
 		t.Errorf("Removed client should not have received a message, but got: %s", msg)
 	}
 }
+
+func TestSSEManager_Publish(t *testing.T) {
+	m := setupSSEManager()
+	m.history = make([]SSEEvent, 3)
+	onlyA := func(event SSEEvent) bool { return event.Attrs["tool"] == "a" }
+	client, complete := m.Subscribe("", onlyA)
+	if !complete {
+		t.Error("Expected a new subscription to be complete")
+	}
+	plain := m.AddClient()
+
+	frames := make([]string, 0, 4)
+	for i, tool := range []string{"a", "b", "a", "a"} {
+		m.Publish(SSEEvent{Type: "tool.executed", Data: []byte(fmt.Sprintf("{\"n\":%d}", i)), Attrs: map[string]string{"tool": tool}})
+	}
+	for len(client.send) > 0 {
+		frames = append(frames, string(<-client.send))
+	}
+
+	t.Run("sends matching events with IDs", func(t *testing.T) {
+		if len(frames) != 3 {
+			t.Fatalf("Expected 3 events for tool a, got %q", frames)
+		}
+		want := fmt.Sprintf("id: %s:1\nevent: tool.executed\ndata: {\"n\":0}\n\n", m.epoch)
+		if frames[0] != want {
+			t.Errorf("Expected %q, got %q", want, frames[0])
+		}
+		if len(plain.send) != 0 {
+			t.Error("Expected clients without a filter to get no published events")
+		}
+	})
+
+	t.Run("replays missed events", func(t *testing.T) {
+		resumed, complete := m.Subscribe(m.epoch+":2", onlyA)
+		if !complete || len(resumed.send) != 2 {
+			t.Fatalf("Expected events 3 and 4, complete, got %d (%v)", len(resumed.send), complete)
+		}
+		if frame := string(<-resumed.send); !strings.HasPrefix(frame, "id: "+m.epoch+":3\n") {
+			t.Errorf("Expected event 3 first, got %q", frame)
+		}
+	})
+
+	t.Run("reports events dropped from the buffer", func(t *testing.T) {
+		resumed, complete := m.Subscribe(m.epoch+":0", onlyA)
+		if complete || len(resumed.send) != 2 {
+			t.Errorf("Expected the kept events 3 and 4, incomplete, got %d (%v)", len(resumed.send), complete)
+		}
+	})
+
+	t.Run("does not replay another process's events", func(t *testing.T) {
+		resumed, complete := m.Subscribe("earlier:1", onlyA)
+		if complete || len(resumed.send) != 0 {
+			t.Errorf("Expected nothing replayed, incomplete, got %d (%v)", len(resumed.send), complete)
+		}
+	})
+
+	t.Run("splits data into lines", func(t *testing.T) {
+		frame := string(m.frame(SSEEvent{ID: 9, Data: []byte("one\ntwo")}))
+		if want := "id: " + m.epoch + ":9\ndata: one\ndata: two\n\n"; frame != want {
+			t.Errorf("Expected %q, got %q", want, frame)
+		}
+	})
+}
+
+func TestSSEManager_Stream(t *testing.T) {
+	m := setupSSEManager()
+	m.SetKeepAlive(10*time.Millisecond, 1500*time.Millisecond)
+	client, _ := m.Subscribe("", func(SSEEvent) bool { return true })
+	m.Publish(SSEEvent{Type: "ping", Data: []byte("{}")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	w := httptest.NewRecorder()
+	if err := m.Stream(ctx, w, client); err != nil {
+		t.Fatalf("Stream failed: %v", err)
+	}
+
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "retry: 1500\n\nid: ") {
+		t.Errorf("Expected the retry field, then the event, got %q", body)
+	}
+	if !strings.Contains(body, sseHeartbeatFrame) {
+		t.Errorf("Expected heartbeats on the idle stream, got %q", body)
+	}
+}
+
+func BenchmarkSSEManager_Broadcast(b *testing.B) {
+	m := setupSSEManager()
+	var delivered, drained sync.WaitGroup
+	for range 100 {
+		client := m.AddClient()
+		drained.Add(1)
+		go func() {
+			defer drained.Done()
+			for range client.send {
+				delivered.Done()
+			}
+		}()
+	}
+	message := []byte(`{"jsonrpc":"2.0","id":1,"result":{"content":[{"type":"text","text":"hello"}]}}`)
+	b.ReportAllocs()
+	// Each broadcast is measured until every client has received it
+	for b.Loop() {
+		delivered.Add(100)
+		m.Broadcast(message)
+		delivered.Wait()
+	}
+	b.StopTimer()
+	for id := range m.clients {
+		m.RemoveClient(id)
+	}
+	drained.Wait()
+}
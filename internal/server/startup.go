@@ -0,0 +1,125 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"mcp-tools-server/internal/version"
+)
+
+// StartupSummary is the machine-readable record written once every listener is accepting
+// connections, so orchestration scripts can wait for it instead of polling ports.
+type StartupSummary struct {
+	Event      string             `json:"event"` // Always "ready"
+	Version    string             `json:"version"`
+	InstanceID string             `json:"instanceId"`
+	PID        int                `json:"pid"`
+	Tools      int                `json:"tools"`
+	Transports []TransportSummary `json:"transports"`
+	Time       time.Time          `json:"time"`
+}
+
+// TransportSummary describes one enabled transport in a StartupSummary.
+type TransportSummary struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	TLS     bool   `json:"tls,omitempty"`
+}
+
+// startupSummary describes the enabled transports and their bound addresses.
+func (s *Server) startupSummary() StartupSummary {
+	summary := StartupSummary{
+		Event:      "ready",
+		Version:    version.GetVersion(),
+		PID:        os.Getpid(),
+		Transports: []TransportSummary{},
+		Time:       time.Now().UTC(),
+	}
+	if toolService := s.toolService(); toolService != nil {
+		summary.Tools = len(toolService.GetTools())
+		summary.InstanceID = toolService.Coordinator().InstanceID()
+	}
+
+	if s.mcpServer != nil {
+		summary.Transports = append(summary.Transports, TransportSummary{Name: "stdio"})
+	}
+	if s.httpServer != nil {
+		summary.Transports = append(summary.Transports, TransportSummary{Name: "http", Address: s.httpServer.Addr(), TLS: s.httpServer.TLS()})
+	}
+	if s.streamableHTTPServer != nil {
+		summary.Transports = append(summary.Transports, TransportSummary{Name: "streamable", Address: s.streamableHTTPServer.Addr(), TLS: s.streamableHTTPServer.TLS()})
+	}
+	if s.webSocketServer != nil {
+		summary.Transports = append(summary.Transports, TransportSummary{Name: "websocket", Address: s.webSocketServer.Addr(), TLS: s.webSocketServer.TLS()})
+	}
+	return summary
+}
+
+// toolService returns the ToolService shared by the configured transports.
+func (s *Server) toolService() *ToolService {
+	switch {
+	case s.httpServer != nil:
+		return s.httpServer.toolService
+	case s.streamableHTTPServer != nil:
+		return s.streamableHTTPServer.processor.toolService
+	case s.webSocketServer != nil:
+		return s.webSocketServer.processor.toolService
+	case s.mcpServer != nil:
+		return s.mcpServer.processor.toolService
+	}
+	return nil
+}
+
+// writeStartupSummary writes summary as one JSON line to the destination named by the
+// StartupSummary setting: "stderr", "stdout", "off", or a file path. Stdout is swapped for
+// stderr while the stdio transport owns it. Files are written atomically, so a script can
+// wait for the file to appear and then read a complete record.
+func (s *Server) writeStartupSummary(summary StartupSummary) error {
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("failed to encode startup summary: %w", err)
+	}
+	line = append(line, '\n')
+
+	var w io.Writer
+	switch destination := s.config.StartupSummary; destination {
+	case "off":
+		return nil
+	case "", "stderr":
+		w = os.Stderr
+	case "stdout":
+		w = os.Stdout
+		if s.mcpServer != nil {
+			w = os.Stderr
+		}
+	default:
+		return writeFileAtomic(destination, line)
+	}
+	_, err = w.Write(line)
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file beside path and renames it into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write startup summary: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write startup summary: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write startup summary: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write startup summary: %w", err)
+	}
+	return nil
+}
@@ -3,13 +3,84 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"mcp-tools-server/internal/config"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/internal/store"
+)
+
+// Streamable HTTP metrics, exported alongside the HTTP metrics on /metrics.
+var (
+	streamableSessionsActive = metrics.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "streamable",
+			Name:      "sessions_active",
+			Help:      "Number of open Streamable HTTP SSE streams registered as MCP sessions",
+		},
+	)
+	streamableInitializeTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "streamable",
+			Name:      "initialize_total",
+			Help:      "Total number of MCP initialize requests over Streamable HTTP",
+		},
+	)
+	streamableResumeTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "streamable",
+			Name:      "resume_total",
+			Help:      "Total number of SSE streams opened with a Last-Event-ID to resume a stream",
+		},
+	)
+	streamableStreamDuration = metrics.NewHistogram(
+		prometheus.HistogramOpts{
+			Subsystem: "streamable",
+			Name:      "sse_stream_duration_seconds",
+			Help:      "How long Streamable HTTP SSE streams stayed open",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		},
+	)
+	streamableKeepAliveFailuresTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "streamable",
+			Name:      "keepalive_failures_total",
+			Help:      "Total number of SSE streams closed because a keepalive could not be written",
+		},
+	)
 )
 
+// SSEResumeOptions configure the event history kept for resuming SSE streams. Events are
+// kept in the ToolService's coordinator, so with a shared store a client can resume its
+// stream on any replica, without sticky sessions.
+type SSEResumeOptions struct {
+	History int           // Events kept per stream; zero sends events without IDs and turns resuming off
+	TTL     time.Duration // How long a stream's events are kept after its latest event
+}
+
+// DefaultSSEResumeOptions returns the event history kept unless configured otherwise.
+func DefaultSSEResumeOptions() SSEResumeOptions {
+	return SSEResumeOptions{History: 100, TTL: 5 * time.Minute}
+}
+
+// sessionIDHeader carries the ID of a Streamable HTTP session. The server issues it in
+// the response to initialize, and the client sends it with the requests and streams of
+// the session.
+const sessionIDHeader = "Mcp-Session-Id"
+
+// shutdownRetry is the reconnection delay suggested to SSE clients when their stream is
+// closed for shutdown, unless a retry hint is configured.
+const shutdownRetry = time.Second
+
 // StreamableHTTPServer handles the streamable HTTP transport for MCP.
 type StreamableHTTPServer struct {
 	logger          *slog.Logger
@@ -18,47 +89,77 @@ type StreamableHTTPServer struct {
 	securityManager *SecurityManager
 	server          *http.Server
 	port            int
+	options         serverOptions
+	closing         chan struct{} // Closed on Stop to end the SSE streams
+	closeOnce       sync.Once
 }
 
-// NewStreamableHTTPServer creates a new server for the streamable HTTP transport.
-func NewStreamableHTTPServer(cfg *config.ServerConfig, toolService *ToolService, logger *slog.Logger) *StreamableHTTPServer {
-	processor := NewJSONRPCProcessor(toolService, logger)
-	sseManager := NewSSEManager(logger)
-	securityManager := NewSecurityManager(cfg.AllowedOrigins, cfg.EnableOriginCheck, logger)
+// NewStreamableHTTPServer creates a new server for the streamable HTTP transport,
+// listening on port 8081 unless WithPort is given.
+func NewStreamableHTTPServer(toolService *ToolService, opts ...ServerOption) *StreamableHTTPServer {
+	options := newServerOptions(8081, opts)
+	processor := NewJSONRPCProcessor(toolService, options.logger)
+	processor.SetBatchOptions(options.batch)
+	sseManager := NewSSEManager(options.logger)
+	securityManager := NewSecurityManager(options.allowedOrigins, options.originCheck, options.logger)
 
 	return &StreamableHTTPServer{
-		port:            cfg.StreamableHTTPPort,
-		logger:          logger,
+		port:            options.port,
+		logger:          options.logger,
 		processor:       processor,
 		sseManager:      sseManager,
 		securityManager: securityManager,
+		options:         options,
+		closing:         make(chan struct{}),
 	}
 }
 
-// Start runs the streamable HTTP server.
-func (s *StreamableHTTPServer) Start() error {
-	s.logger.Info("Starting Streamable HTTP MCP server", "port", s.port)
+// Handler returns the server's HTTP handler, serving /mcp behind the origin check and
+// any configured middleware.
+func (s *StreamableHTTPServer) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/mcp", s.handleMCP)
+	return s.options.wrap(s.securityManager.OriginCheckMiddleware(mux))
+}
 
-	// Apply security middleware
-	handler := s.securityManager.OriginCheckMiddleware(mux)
+// Listen binds the server's listener so it accepts connections before Start is called.
+// Start binds it if Listen was not called.
+func (s *StreamableHTTPServer) Listen() error {
+	_, err := s.options.bind()
+	return err
+}
 
-	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: handler,
-	}
+// Addr returns the address the server is bound to, or its configured address before
+// Listen.
+func (s *StreamableHTTPServer) Addr() string {
+	return s.options.boundAddr()
+}
+
+// TLS reports whether the server serves HTTPS.
+func (s *StreamableHTTPServer) TLS() bool {
+	return s.options.certFile != ""
+}
 
-	if err := s.server.ListenAndServe(); err != http.ErrServerClosed {
+// Start runs the streamable HTTP server.
+func (s *StreamableHTTPServer) Start() error {
+	s.logger.Info("Starting Streamable HTTP MCP server", "address", s.Addr())
+	s.server = s.options.httpServer(s.Handler())
+
+	if err := s.options.listenAndServe(s.server); err != http.ErrServerClosed {
 		return fmt.Errorf("streamable http server failed: %w", err)
 	}
 
 	return nil
 }
 
-// Stop gracefully shuts down the server.
+// Stop gracefully shuts down the server. Sessions are told the server is shutting down,
+// and SSE streams are closed with a retry hint, before the listener stops, so clients
+// reconnect to another instance.
 func (s *StreamableHTTPServer) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping Streamable HTTP MCP server")
+	result := s.processor.toolService.Sessions().announceShutdown("streamable")
+	s.logger.Info("Announced shutdown to Streamable HTTP sessions", "delivered", result.Delivered, "failed", result.Failed)
+	s.closeOnce.Do(func() { close(s.closing) })
 	if s.server == nil {
 		return nil // Server was never started
 	}
@@ -71,10 +172,21 @@ func (s *StreamableHTTPServer) Stop(ctx context.Context) error {
 
 // handleMCP is the single endpoint for all MCP communication.
 func (s *StreamableHTTPServer) handleMCP(w http.ResponseWriter, r *http.Request) {
-	s.logger.Info("Received request for /mcp", "method", r.Method, "remoteAddr", r.RemoteAddr)
+	loggerFor(r.Context(), s.logger).Info("Received request for /mcp", "method", r.Method)
+	w.Header().Set(instanceIDHeader, s.processor.toolService.Coordinator().InstanceID())
 
 	switch r.Method {
 	case http.MethodGet:
+		if s.options.stateless {
+			// Without sessions there is nobody to send server-initiated messages to
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Server-initiated messages are not available in stateless mode", http.StatusMethodNotAllowed)
+			return
+		}
+		// A new stream is a new session; a resumed one continues a session
+		if r.Header.Get("Last-Event-ID") == "" && s.processor.toolService.Overload().Shed(w, "streamable") {
+			return
+		}
 		s.handleSSEConnection(w, r)
 	case http.MethodPost:
 		s.handlePostRequest(w, r)
@@ -90,76 +202,115 @@ func (s *StreamableHTTPServer) handlePostRequest(w http.ResponseWriter, r *http.
 		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 		return
 	}
+	// Clients send the negotiated protocol version on every request after initialization.
+	ctx := r.Context()
+	if version := r.Header.Get(protocolVersionHeader); version != "" {
+		if !IsSupportedProtocolVersion(version) {
+			http.Error(w, "Unsupported "+protocolVersionHeader+": "+version, http.StatusBadRequest)
+			return
+		}
+		ctx = WithProtocolVersion(ctx, version)
+	}
 
-	// Decode the incoming JSON-RPC message
-	var message map[string]interface{}
+	// Decode the incoming JSON-RPC message, a single request or a batch
+	var message interface{}
 	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "Failed to decode JSON body", http.StatusBadRequest)
 		return
 	}
 
-	method, ok := message["method"].(string)
-	if !ok {
-		http.Error(w, "Invalid JSON-RPC: missing method", http.StatusBadRequest)
+	requests := []interface{}{message}
+	if batch, ok := message.([]interface{}); ok {
+		requests = batch
+	}
+	initializations := 0
+	for _, request := range requests {
+		if request, _ := request.(map[string]interface{}); request["method"] == "initialize" {
+			initializations++
+		}
+	}
+	// Initializing starts a new session, which an overloaded server turns away
+	if initializations > 0 && s.processor.toolService.Overload().Shed(w, "streamable") {
 		return
 	}
+	streamableInitializeTotal.Add(float64(initializations))
 
-	id, hasId := message["id"]
-	var response *JSONRPCResponse
-
-	// Process the message
-	switch method {
-	case "initialize":
-		if !hasId {
-			http.Error(w, "Invalid initialize: missing id", http.StatusBadRequest)
+	// Stateless servers keep nothing per session, so requests carry no session ID
+	if !s.options.stateless {
+		sessionID, ok := s.postSession(w, r, initializations > 0)
+		if !ok {
 			return
 		}
-		response = s.processor.HandleInitialize(id)
-	case "initialized":
-		// This is a notification, respond with 202 Accepted
+		if sessionID != "" {
+			ctx = WithSessionID(ctx, sessionID)
+		}
+	}
+
+	// Every message goes through the shared processor so results and error codes match
+	// the other transports. Notifications produce no response and are acknowledged.
+	response := s.processor.ProcessMessage(ctx, message)
+	if response == nil {
 		w.WriteHeader(http.StatusAccepted)
 		return
-	case "tools/list":
-		if !hasId {
-			http.Error(w, "Invalid tools/list: missing id", http.StatusBadRequest)
-			return
-		}
-		response = s.processor.HandleToolsList(id)
-	case "tools/call":
-		if !hasId {
-			http.Error(w, "Invalid tools/call: missing id", http.StatusBadRequest)
-			return
-		}
-		params, _ := message["params"].(map[string]interface{})
-		response = s.processor.HandleToolsCall(params, id)
-	default:
-		if hasId {
-			response = s.processor.CreateErrorResponse(id, -32601, "Method not found")
-		} else {
-			// It's an unknown notification, just accept it
-			w.WriteHeader(http.StatusAccepted)
-			return
-		}
 	}
 
-	// For now, always send an immediate JSON response.
+	// The response goes only to the caller, in the body. SSE streams carry
+	// server-initiated messages, which are sent to the session they are meant for.
 	w.Header().Set("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
 	if err := enc.Encode(response); err != nil {
 		s.logger.Error("Failed to encode and send response", "error", err)
+		s.processor.toolService.Events().publishTransportError("streamable", "write", err)
 		http.Error(w, "Failed to send response", http.StatusInternalServerError)
 		return
 	}
+}
 
-	// Also broadcast the JSON-RPC response to any connected SSE clients so
-	// GET /mcp listeners can receive server-generated messages (streaming).
-	if s.sseManager != nil && response != nil {
-		if b, err := json.Marshal(response); err == nil {
-			s.sseManager.Broadcast(b)
-		} else {
-			s.logger.Warn("Failed to marshal response for SSE broadcast", "error", err)
-		}
+// postSession returns the session a POST belongs to. Initializing starts a new session,
+// whose ID is sent in the Mcp-Session-Id response header; other requests continue the
+// session named by their Mcp-Session-Id (see requestSession). It reports false once it
+// has answered the request with an error.
+func (s *StreamableHTTPServer) postSession(w http.ResponseWriter, r *http.Request, initialize bool) (string, bool) {
+	if !initialize {
+		return s.requestSession(w, r)
+	}
+	id, err := s.processor.toolService.Sessions().Issue(r.Context())
+	if err != nil {
+		s.logger.Error("Failed to start a session", "error", err)
+		http.Error(w, "Failed to start a session", http.StatusServiceUnavailable)
+		return "", false
+	}
+	w.Header().Set(sessionIDHeader, id)
+	return id, true
+}
+
+// requestSession returns the session named by the request's Mcp-Session-Id, or "" when
+// it names none and the request runs outside any session. An ID the server did not
+// issue, or issued to another tenant, is answered with 404 Not Found, as an expired
+// session is, so the client initializes again. It reports false once it has answered
+// the request with an error.
+func (s *StreamableHTTPServer) requestSession(w http.ResponseWriter, r *http.Request) (string, bool) {
+	id := r.Header.Get(sessionIDHeader)
+	if id == "" {
+		return "", true
 	}
+	owned, err := s.processor.toolService.Sessions().Owns(r.Context(), id)
+	if err != nil {
+		s.logger.Error("Failed to look up a session", "error", err)
+		http.Error(w, "Failed to look up the session", http.StatusServiceUnavailable)
+		return "", false
+	}
+	if !owned {
+		loggerFor(r.Context(), s.logger).Warn("Rejected an unknown or foreign session ID", "remote", r.RemoteAddr)
+		http.Error(w, "Session not found", http.StatusNotFound)
+		return "", false
+	}
+	return id, true
 }
 
 // handleSSEConnection handles a new client connection for receiving server-sent events.
@@ -170,6 +321,17 @@ func (s *StreamableHTTPServer) handleSSEConnection(w http.ResponseWriter, r *htt
 		http.Error(w, "Streaming unsupported!", http.StatusInternalServerError)
 		return
 	}
+	sessionID, ok := s.requestSession(w, r)
+	if !ok {
+		return
+	}
+
+	// The stream outlives the server's read and write timeouts, so lift the deadlines for
+	// it; an expired read deadline would otherwise cancel the request and end the stream.
+	controller := http.NewResponseController(w)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+	stream := s.openEventStream(r)
 
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -182,9 +344,57 @@ func (s *StreamableHTTPServer) handleSSEConnection(w http.ResponseWriter, r *htt
 	client := s.sseManager.AddClient()
 	defer s.sseManager.RemoveClient(client.id)
 
-	s.logger.Info("SSE client connected", "clientID", client.id)
+	// Register the stream as an MCP session so server-initiated notifications reach it:
+	// the client's session when it names one, or a session of its own otherwise
+	sessions := s.processor.toolService.Sessions()
+	notify := func(message []byte) error {
+		return s.sseManager.Send(client.id, message)
+	}
+	var session *Session
+	if sessionID != "" {
+		session = sessions.AddWithID(sessionID, "streamable", notify)
+	} else {
+		session = sessions.Add("streamable", notify)
+	}
+	defer sessions.removeConnection(session)
+	streamableSessionsActive.Inc()
+	defer streamableSessionsActive.Dec()
+	opened := time.Now()
+	defer func() { streamableStreamDuration.Observe(time.Since(opened).Seconds()) }()
+
+	s.logger.Info("SSE client connected", "clientID", client.id, "streamID", stream.id)
+
+	// A retry hint tells the client how long to wait before reconnecting, and events the
+	// client missed come first, in order
+	if retry := s.options.keepAlive.SSERetry; retry > 0 {
+		fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds())
+	}
+	for _, event := range stream.replay {
+		fmt.Fprintf(w, "id: %s\ndata: %s\n\n", stream.eventID(event.Seq), event.Data)
+	}
+	flusher.Flush()
 
 	// Keep connection alive and listen for messages
+	// Idle streams are sent a comment, so proxies keep them open and dead clients are noticed
+	var keepAlive <-chan time.Time
+	if interval := s.options.keepAlive.SSEInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	send := func(message []byte) {
+		// Events are kept before chaos can drop them, so resuming recovers them
+		id := s.recordEvent(r.Context(), stream, message)
+		if s.options.chaos.DropEvent() {
+			return
+		}
+		// Format as SSE message (id: <stream>:<seq>\ndata: <message>\n\n)
+		if id != "" {
+			fmt.Fprintf(w, "id: %s\n", id)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", message)
+		flusher.Flush()
+	}
 	for {
 		select {
 		case message, ok := <-client.send:
@@ -193,9 +403,30 @@ func (s *StreamableHTTPServer) handleSSEConnection(w http.ResponseWriter, r *htt
 				s.logger.Info("SSE channel closed for client", "clientID", client.id)
 				return
 			}
-			// Format as SSE message (data: <message>\n\n)
-			fmt.Fprintf(w, "data: %s\n\n", message)
+			send(message)
+		case <-s.closing:
+			// Deliver what is queued, such as the shutdown notice, then say when to reconnect
+			for pending := len(client.send); pending > 0; pending-- {
+				send(<-client.send)
+			}
+			retry := s.options.keepAlive.SSERetry
+			if retry <= 0 {
+				retry = shutdownRetry
+			}
+			fmt.Fprintf(w, "retry: %d\n\n", retry.Milliseconds())
 			flusher.Flush()
+			s.logger.Info("SSE stream closed for shutdown", "clientID", client.id)
+			return
+		case <-keepAlive:
+			_, err := fmt.Fprint(w, ": keepalive\n\n")
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				streamableKeepAliveFailuresTotal.Inc()
+				s.logger.Info("SSE keepalive failed; closing stream", "clientID", client.id, "error", err)
+				return
+			}
 		case <-r.Context().Done():
 			// Client has disconnected
 			s.logger.Info("SSE client disconnected", "clientID", client.id)
@@ -203,3 +434,65 @@ func (s *StreamableHTTPServer) handleSSEConnection(w http.ResponseWriter, r *htt
 		}
 	}
 }
+
+// eventStream is the identity and position of an SSE stream whose events are kept for
+// resuming.
+type eventStream struct {
+	id     string
+	seq    int64         // Number of the last event sent
+	replay []store.Event // Events to send again to a resuming client
+}
+
+// eventID returns the SSE event ID of the stream's event numbered seq.
+func (e *eventStream) eventID(seq int64) string {
+	return e.id + ":" + strconv.FormatInt(seq, 10)
+}
+
+// openEventStream starts a new stream, or continues the one named by the request's
+// Last-Event-ID with the events sent after it. Events that expired or were never kept
+// cannot be sent again; the stream continues from the client's position.
+func (s *StreamableHTTPServer) openEventStream(r *http.Request) *eventStream {
+	stream := &eventStream{id: uuid.NewString()}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		return stream
+	}
+	streamableResumeTotal.Inc()
+	separator := strings.LastIndex(lastEventID, ":")
+	seq, err := strconv.ParseInt(lastEventID[separator+1:], 10, 64)
+	if err == nil && separator > 0 {
+		_, err = uuid.Parse(lastEventID[:separator])
+	}
+	if separator <= 0 || err != nil || s.options.sseResume.History <= 0 {
+		s.logger.Info("SSE stream cannot be resumed; starting a new one", "lastEventID", lastEventID)
+		return stream
+	}
+	stream.id, stream.seq = lastEventID[:separator], seq
+
+	replay, err := s.processor.toolService.Coordinator().EventsAfter(r.Context(), stream.id, seq)
+	if err != nil {
+		s.logger.Warn("Failed to load SSE events to resume a stream", "streamID", stream.id, "error", err)
+		return stream
+	}
+	stream.replay = replay
+	if len(replay) > 0 {
+		stream.seq = replay[len(replay)-1].Seq
+	}
+	s.logger.Info("SSE stream resumed", "streamID", stream.id, "lastEventID", lastEventID, "replayed", len(replay))
+	return stream
+}
+
+// recordEvent numbers the stream's next event and keeps it for resuming. It returns the
+// event's ID, or "" when events are not kept.
+func (s *StreamableHTTPServer) recordEvent(ctx context.Context, stream *eventStream, message []byte) string {
+	resume := s.options.sseResume
+	if resume.History <= 0 {
+		return ""
+	}
+	stream.seq++
+	event := store.Event{Seq: stream.seq, Data: string(message)}
+	if err := s.processor.toolService.Coordinator().AppendEvent(ctx, stream.id, event, resume.History, resume.TTL); err != nil {
+		s.logger.Warn("Failed to keep SSE event for resuming", "streamID", stream.id, "error", err)
+	}
+	return stream.eventID(stream.seq)
+}
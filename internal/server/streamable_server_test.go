@@ -9,12 +9,19 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+
 	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/store"
 	"mcp-tools-server/pkg/tools"
 )
 
@@ -37,7 +44,7 @@ func setupTestServerWithListener(t *testing.T) (*StreamableHTTPServer, net.Liste
 
 	// The server will be configured with the listener's port, but we pass the whole config for other settings
 	cfg.StreamableHTTPPort = listener.Addr().(*net.TCPAddr).Port
-	server := NewStreamableHTTPServer(cfg, toolService, logger)
+	server := NewStreamableHTTPServer(toolService, WithPort(cfg.StreamableHTTPPort), WithLogger(logger))
 
 	return server, listener
 }
@@ -140,3 +147,428 @@ func TestStreamableHTTPServer_FullFlow(t *testing.T) {
 		}
 	})
 }
+
+func TestStreamableHTTPServer_Metrics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := NewStreamableHTTPServer(newTestToolService(logger), WithLogger(logger),
+		WithKeepAlive(KeepAlive{SSEInterval: 10 * time.Millisecond}))
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleMCP))
+	defer testServer.Close()
+
+	t.Run("counts initialize requests", func(t *testing.T) {
+		before := testutil.ToFloat64(streamableInitializeTotal)
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+		resp, err := http.Post(testServer.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		if got := testutil.ToFloat64(streamableInitializeTotal); got != before+1 {
+			t.Errorf("Expected %v initialize requests, got %v", before+1, got)
+		}
+	})
+
+	t.Run("tracks resumed streams, keepalives, and stream duration", func(t *testing.T) {
+		resumes := testutil.ToFloat64(streamableResumeTotal)
+		streams := histogramCount(t, streamableStreamDuration)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL, nil)
+		req.Header.Set("Last-Event-ID", "42")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+
+		line, err := bufio.NewReader(resp.Body).ReadString('\n')
+		if err != nil || line != ": keepalive\n" {
+			t.Fatalf("Expected a keepalive comment, got %q, %v", line, err)
+		}
+		if got := testutil.ToFloat64(streamableResumeTotal); got != resumes+1 {
+			t.Errorf("Expected %v resumes, got %v", resumes+1, got)
+		}
+		if active := testutil.ToFloat64(streamableSessionsActive); active < 1 {
+			t.Errorf("Expected an active session, got %v", active)
+		}
+
+		cancel()
+		deadline := time.Now().Add(2 * time.Second)
+		for histogramCount(t, streamableStreamDuration) == streams && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if histogramCount(t, streamableStreamDuration) != streams+1 {
+			t.Error("Expected the closed stream's duration to be recorded")
+		}
+	})
+}
+
+// histogramCount returns the number of observations in h.
+func histogramCount(t *testing.T, h prometheus.Histogram) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := h.Write(&metric); err != nil {
+		t.Fatalf("Failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestStreamableHTTPServer_ProtocolVersionHeader(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	streamable := NewStreamableHTTPServer(newTestToolService(logger, &MockTool{name: "echo"}), WithLogger(logger))
+	testServer := httptest.NewServer(http.HandlerFunc(streamable.handleMCP))
+	defer testServer.Close()
+	post := func(version string) int {
+		req, _ := http.NewRequest(http.MethodPost, testServer.URL, strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if version != "" {
+			req.Header.Set("Mcp-Protocol-Version", version)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("supported or absent versions are accepted", func(t *testing.T) {
+		for _, version := range []string{"", ProtocolVersion20241105, LatestProtocolVersion} {
+			if status := post(version); status != http.StatusOK {
+				t.Errorf("Expected 200 for version %q, got %d", version, status)
+			}
+		}
+	})
+
+	t.Run("unsupported versions are rejected", func(t *testing.T) {
+		if status := post("1999-01-01"); status != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", status)
+		}
+	})
+}
+
+func TestStreamableHTTPServer_ResumeAcrossReplicas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	redisServer := miniredis.RunT(t)
+	newReplica := func(instanceID string) (*ToolService, *httptest.Server) {
+		shared, err := store.NewRedisStore("redis://" + redisServer.Addr())
+		if err != nil {
+			t.Fatalf("Failed to create redis store: %v", err)
+		}
+		t.Cleanup(func() { _ = shared.Close() })
+		service := newTestToolService(logger)
+		service.SetCoordinator(store.NewCoordinator(shared, instanceID))
+		streamable := NewStreamableHTTPServer(service, WithLogger(logger))
+		testServer := httptest.NewServer(http.HandlerFunc(streamable.handleMCP))
+		t.Cleanup(testServer.Close)
+		return service, testServer
+	}
+	serviceA, replicaA := newReplica("pod-a")
+	_, replicaB := newReplica("pod-b")
+
+	// openStream opens an SSE stream and returns its events as "id data" lines
+	openStream := func(ctx context.Context, url, lastEventID string) <-chan string {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		events := make(chan string, 10)
+		go func() {
+			defer resp.Body.Close()
+			defer close(events)
+			reader := bufio.NewReader(resp.Body)
+			var id string
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if value, ok := strings.CutPrefix(line, "id: "); ok {
+					id = strings.TrimSpace(value)
+				}
+				if value, ok := strings.CutPrefix(line, "data: "); ok {
+					events <- id + " " + strings.TrimSpace(value)
+				}
+			}
+		}()
+		return events
+	}
+	next := func(events <-chan string) string {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for an SSE event")
+			return ""
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := openStream(ctx, replicaA.URL, "")
+	deadline := time.Now().Add(2 * time.Second)
+	for len(serviceA.Sessions().List()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	for _, n := range []string{"1", "2", "3"} {
+		serviceA.Sessions().Notify("notifications/test", map[string]interface{}{"n": n})
+	}
+	first := next(events)
+	lastEventID, _, _ := strings.Cut(first, " ")
+	if !strings.HasSuffix(lastEventID, ":1") {
+		t.Fatalf("Expected the first event to be numbered 1, got %q", first)
+	}
+	next(events)
+	next(events)
+	// The client drops the stream after the first event and resumes it on the other replica
+	cancel()
+
+	resumeCtx, stopResumed := context.WithCancel(context.Background())
+	defer stopResumed()
+	resumed := openStream(resumeCtx, replicaB.URL, lastEventID)
+	for _, want := range []string{":2 ", ":3 "} {
+		if event := next(resumed); !strings.Contains(event, want) || !strings.HasPrefix(event, strings.TrimSuffix(lastEventID, ":1")) {
+			t.Errorf("Expected event %s of the same stream, got %q", want, event)
+		}
+	}
+}
+
+func TestStreamableHTTPServer_Stateless(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger)
+	// Shared sessions keep the protocol version of any session ID a request carries
+	service.Sessions().SetShared(true)
+	server := NewStreamableHTTPServer(service, WithLogger(logger), WithStateless(true))
+
+	t.Run("GET is refused", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		server.handleMCP(w, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+		if w.Code != http.StatusMethodNotAllowed || w.Header().Get("Allow") != http.MethodPost {
+			t.Errorf("Expected 405 allowing POST, got %d %q", w.Code, w.Header().Get("Allow"))
+		}
+	})
+
+	t.Run("POST is handled without a session", func(t *testing.T) {
+		body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`
+		req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Mcp-Session-Id", "client-session")
+		w := httptest.NewRecorder()
+		server.handleMCP(w, req)
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"protocolVersion":"2025-03-26"`) {
+			t.Fatalf("Expected an initialize result, got %d %s", w.Code, w.Body.String())
+		}
+		if version := service.Sessions().ProtocolVersion("client-session"); version != "" {
+			t.Errorf("Expected no session state, got protocol version %q", version)
+		}
+	})
+}
+
+func TestStreamableHTTPServer_RetryHint(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := NewStreamableHTTPServer(newTestToolService(logger), WithLogger(logger),
+		WithKeepAlive(KeepAlive{SSEInterval: time.Minute, SSERetry: 3 * time.Second}))
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleMCP))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	if err != nil || line != "retry: 3000\n" {
+		t.Errorf("Expected a retry hint first, got %q %v", line, err)
+	}
+}
+
+func TestStreamableHTTPServer_StopClosesStreams(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger)
+	server := NewStreamableHTTPServer(service, WithLogger(logger))
+	testServer := httptest.NewServer(http.HandlerFunc(server.handleMCP))
+	defer testServer.Close()
+
+	resp, err := http.Get(testServer.URL)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(service.Sessions().List()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := server.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	// The stream ends after the shutdown notice and a retry hint
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read the stream: %v", err)
+	}
+	notice := strings.Index(string(body), `"reason":"shutdown"`)
+	retry := strings.Index(string(body), "retry: 1000\n")
+	if notice < 0 || retry < notice {
+		t.Errorf("Expected a shutdown notice followed by a retry hint, got %q", body)
+	}
+}
+
+func TestStreamableHTTPServer_TenantIsolation(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{name: "secret", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"value": "tenant-a-secret"}, nil
+	}})
+	tenants, err := NewTenants([]Tenant{{Name: "a", APIKey: "key-a"}, {Name: "b", APIKey: "key-b"}}, logger)
+	if err != nil {
+		t.Fatalf("NewTenants failed: %v", err)
+	}
+	server := NewStreamableHTTPServer(service, WithLogger(logger), WithTenants(tenants))
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	// Tenant B listens for server-initiated messages
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/mcp", nil)
+	req.Header.Set(apiKeyHeader, "key-b")
+	stream, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer stream.Body.Close()
+	received := make(chan string, 10)
+	go func() {
+		scanner := bufio.NewScanner(stream.Body)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data: "); ok {
+				received <- data
+			}
+		}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(service.Sessions().List()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Tenant A calls a tool
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"secret"}}`
+	post, _ := http.NewRequest(http.MethodPost, testServer.URL+"/mcp", strings.NewReader(body))
+	post.Header.Set("Content-Type", "application/json")
+	post.Header.Set(apiKeyHeader, "key-a")
+	resp, err := http.DefaultClient.Do(post)
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	result, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(result), "tenant-a-secret") {
+		t.Fatalf("Expected tenant A to get its result, got %s", result)
+	}
+
+	select {
+	case data := <-received:
+		t.Errorf("Expected tenant B to receive nothing, got %s", data)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestStreamableHTTPServer_Sessions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{name: "echo"})
+	tenants, err := NewTenants([]Tenant{{Name: "a", APIKey: "key-a"}, {Name: "b", APIKey: "key-b"}}, logger)
+	if err != nil {
+		t.Fatalf("NewTenants failed: %v", err)
+	}
+	server := NewStreamableHTTPServer(service, WithLogger(logger), WithTenants(tenants))
+	testServer := httptest.NewServer(server.Handler())
+	defer testServer.Close()
+
+	post := func(t *testing.T, key, sessionID, body string) *http.Response {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, testServer.URL+"/mcp", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(apiKeyHeader, key)
+		if sessionID != "" {
+			req.Header.Set(sessionIDHeader, sessionID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp
+	}
+	call := `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`
+
+	resp := post(t, "key-a", "", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`)
+	sessionID := resp.Header.Get(sessionIDHeader)
+	if resp.StatusCode != http.StatusOK || sessionID == "" {
+		t.Fatalf("Expected initialize to issue a session ID, got %d with %q", resp.StatusCode, sessionID)
+	}
+
+	t.Run("accepts the session from its tenant", func(t *testing.T) {
+		if resp := post(t, "key-a", sessionID, call); resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rejects unknown session IDs", func(t *testing.T) {
+		if resp := post(t, "key-a", "streamable-127.0.0.1", call); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("rejects the session from another tenant", func(t *testing.T) {
+		if resp := post(t, "key-b", sessionID, call); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", resp.StatusCode)
+		}
+
+		req, _ := http.NewRequest(http.MethodGet, testServer.URL+"/mcp", nil)
+		req.Header.Set(apiKeyHeader, "key-b")
+		req.Header.Set(sessionIDHeader, sessionID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404 for the stream, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("the stream joins its session", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/mcp", nil)
+		req.Header.Set(apiKeyHeader, "key-a")
+		req.Header.Set(sessionIDHeader, sessionID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			for _, session := range service.Sessions().List() {
+				if session.ID == sessionID {
+					return
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Error("Expected the stream to be registered under the session ID")
+	})
+}
@@ -0,0 +1,246 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-tools-server/internal/store"
+	"mcp-tools-server/pkg/tools"
+)
+
+// apiKeyHeader names the header that carries a tenant's API key. An
+// "Authorization: Bearer <key>" header is accepted as well.
+const apiKeyHeader = "X-API-Key"
+
+// ErrRateLimited is returned when a tenant calls tools faster than its rate limit allows.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Tenant is a team sharing the deployment, identified by its API key.
+type Tenant struct {
+	Name      string
+	APIKey    string
+	Tools     []string // Tools the tenant may list and call; empty allows every tool
+	RateLimit float64  // Sustained tool calls per second; zero means unlimited
+	Burst     int      // Calls allowed at once above the rate; defaults to the rate rounded up
+
+	allowed map[string]bool
+	limiter *tokenBucket
+}
+
+// Allows reports whether the tenant may list and call the named tool. A nil Tenant,
+// as used by requests that are not tenant-scoped, allows every tool. Allowing a tool's
+// bare name allows every version of it.
+func (t *Tenant) Allows(tool string) bool {
+	if t == nil || t.allowed == nil || t.allowed[tool] {
+		return true
+	}
+	base, _ := tools.SplitVersion(tool)
+	return t.allowed[base]
+}
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the calling tenant.
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant stored in ctx, or nil if there is none.
+func TenantFromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return tenant
+}
+
+// tenantName returns the name of the tenant in ctx, or "" if there is none.
+func tenantName(ctx context.Context) string {
+	if tenant := TenantFromContext(ctx); tenant != nil {
+		return tenant.Name
+	}
+	return ""
+}
+
+// Tenants identifies the tenant of each HTTP request by its API key and enforces the
+// tenant's tool allow-list and rate limit on every tool call.
+type Tenants struct {
+	tenants     []*Tenant
+	logger      *slog.Logger
+	coordinator *store.Coordinator // Counts rate limited calls across replicas; nil counts them per replica
+}
+
+// NewTenants validates tenants and returns them ready for use. Every tenant needs a name
+// and a unique API key.
+func NewTenants(tenants []Tenant, logger *slog.Logger) (*Tenants, error) {
+	t := &Tenants{logger: logger}
+	keys := make(map[string]string, len(tenants))
+	for i := range tenants {
+		tenant := tenants[i]
+		if tenant.Name == "" {
+			return nil, fmt.Errorf("tenant %d has no name", i)
+		}
+		if tenant.APIKey == "" {
+			return nil, fmt.Errorf("tenant %s has no API key", tenant.Name)
+		}
+		if other, ok := keys[tenant.APIKey]; ok {
+			return nil, fmt.Errorf("tenants %s and %s share an API key", other, tenant.Name)
+		}
+		keys[tenant.APIKey] = tenant.Name
+
+		if len(tenant.Tools) > 0 {
+			tenant.allowed = make(map[string]bool, len(tenant.Tools))
+			for _, tool := range tenant.Tools {
+				tenant.allowed[tool] = true
+			}
+		}
+		if tenant.RateLimit > 0 {
+			burst := tenant.Burst
+			if burst <= 0 {
+				burst = int(math.Ceil(tenant.RateLimit))
+			}
+			tenant.limiter = newTokenBucket(tenant.RateLimit, burst)
+		}
+		t.tenants = append(t.tenants, &tenant)
+	}
+	sort.Slice(t.tenants, func(i, j int) bool { return t.tenants[i].Name < t.tenants[j].Name })
+	return t, nil
+}
+
+// lookup returns the tenant owning key. Every key is compared in constant time.
+func (t *Tenants) lookup(key string) *Tenant {
+	var found *Tenant
+	for _, tenant := range t.tenants {
+		if subtle.ConstantTimeCompare([]byte(tenant.APIKey), []byte(key)) == 1 {
+			found = tenant
+		}
+	}
+	return found
+}
+
+// SetCoordinator counts each tenant's calls in coordinator's shared counters, so a rate
+// limit holds across every replica using the same store rather than per replica. Call
+// it before serving requests.
+func (t *Tenants) SetCoordinator(coordinator *store.Coordinator) {
+	t.coordinator = coordinator
+}
+
+// allow takes one of the tenant's rate limited calls, reporting false when the limit is
+// used up. With a coordinator, calls are counted across replicas in fixed windows of
+// burst calls each lasting burst/rate seconds; while the store cannot be reached, and
+// without a coordinator, this replica's token bucket decides.
+func (t *Tenants) allow(ctx context.Context, tenant *Tenant) bool {
+	if t.coordinator != nil {
+		burst := tenant.limiter.burst
+		window := time.Duration(burst / tenant.limiter.rate * float64(time.Second))
+		allowed, err := t.coordinator.Allow(ctx, "tenant:"+tenant.Name, int64(burst), window)
+		if err == nil {
+			return allowed
+		}
+		loggerFor(ctx, t.logger).Warn("Shared rate limit unavailable, using this replica's limit", "tenant", tenant.Name, "error", err)
+	}
+	return tenant.limiter.allow()
+}
+
+// tenantExempt reports whether path is served without an API key: the health probes,
+// which load balancers call unauthenticated, the operator endpoints under /admin/,
+// which require the operator credential instead of a tenant's key (see requireAdmin),
+// and approval callbacks, which are signed instead.
+func tenantExempt(path string) bool {
+	switch path {
+	case "/health", "/livez", "/readyz":
+		return true
+	}
+	return strings.HasPrefix(path, "/admin/") || strings.HasPrefix(path, "/approvals/")
+}
+
+// Middleware rejects HTTP requests without a valid API key with 401 and stores the
+// caller's tenant in the request context.
+func (t *Tenants) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if tenantExempt(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(apiKeyHeader)
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && key == "" {
+			key = strings.TrimSpace(bearer)
+		}
+		tenant := t.lookup(key)
+		if tenant == nil {
+			loggerFor(r.Context(), t.logger).Warn("Rejected request without a valid API key", "path", r.URL.Path)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-tools-server"`)
+			writeErrorEnvelope(w, r, http.StatusUnauthorized, errCodeUnauthorized, "A valid API key is required")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
+
+// ToolMiddleware returns the ToolMiddleware that hides tools outside the calling
+// tenant's allow-list and enforces its rate limit. Calls without a tenant, such as
+// those arriving over stdio, are not restricted.
+func (t *Tenants) ToolMiddleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			tenant := TenantFromContext(ctx)
+			if tenant == nil {
+				return next(ctx, name, args)
+			}
+			if !tenant.Allows(name) {
+				return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
+			}
+			if tenant.limiter != nil && !t.allow(ctx, tenant) {
+				loggerFor(ctx, t.logger).Warn("Tenant rate limit exceeded", "tenant", tenant.Name, "tool", name)
+				return nil, fmt.Errorf("tenant %s: %w", tenant.Name, ErrRateLimited)
+			}
+			return next(ctx, name, args)
+		}
+	}
+}
+
+// tokenBucket is a rate limiter that refills rate tokens per second up to burst.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// newTokenBucket creates a full token bucket.
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now(), now: time.Now}
+}
+
+// allow takes a token, reporting false when none is available.
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// next returns how long until a token is available.
+func (b *tokenBucket) next() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	missing := 1 - math.Min(b.burst, b.tokens+b.now().Sub(b.last).Seconds()*b.rate)
+	if missing <= 0 {
+		return 0
+	}
+	return time.Duration(missing / b.rate * float64(time.Second))
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/store"
+	"mcp-tools-server/pkg/tools"
+)
+
+func TestTenants(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tenants, err := NewTenants([]Tenant{
+		{Name: "team-a", APIKey: "key-a", Tools: []string{"echo"}, RateLimit: 1, Burst: 1},
+		{Name: "team-b", APIKey: "key-b"},
+	}, logger)
+	if err != nil {
+		t.Fatalf("NewTenants failed: %v", err)
+	}
+	toolService := newTestToolService(logger, &MockTool{name: "echo"}, &MockTool{name: "secret"})
+	toolService.Use(tenants.ToolMiddleware())
+	httpServer := NewHTTPServer(toolService, WithLogger(logger), WithTenants(tenants))
+
+	serve := func(method, target string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		for key, values := range header {
+			req.Header.Set(key, values[0])
+		}
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		return w
+	}
+	keyA := http.Header{apiKeyHeader: {"key-a"}}
+
+	t.Run("requests need a valid API key", func(t *testing.T) {
+		for _, header := range []http.Header{nil, {apiKeyHeader: {"wrong"}}} {
+			w := serve("GET", "/api/v1/list", header)
+			if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected status 401, got %d", w.Code)
+			}
+		}
+		if w := serve("GET", "/api/v1/list", http.Header{"Authorization": {"Bearer key-b"}}); w.Code != http.StatusOK {
+			t.Errorf("Expected a bearer token to be accepted, got %d", w.Code)
+		}
+	})
+
+	t.Run("health probes and admin endpoints need no API key", func(t *testing.T) {
+		for _, target := range []string{"/health", "/livez", "/admin/quarantine"} {
+			if w := serve("GET", target, nil); w.Code == http.StatusUnauthorized {
+				t.Errorf("Expected %s to be served without a key", target)
+			}
+		}
+	})
+
+	t.Run("tools outside the allow-list are hidden", func(t *testing.T) {
+		var listed map[string]string
+		if err := json.Unmarshal(serve("GET", "/api/v1/list", keyA).Body.Bytes(), &listed); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if _, ok := listed["secret"]; ok || len(listed) != 1 {
+			t.Errorf("Expected only echo to be listed, got %v", listed)
+		}
+		if w := serve("POST", "/api/v1/tools/secret", keyA); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+
+		processor := NewJSONRPCProcessor(toolService, logger)
+		response := processor.Process(WithTenant(context.Background(), tenants.lookup("key-a")), map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"})
+		if definitions := response.Result.(map[string]interface{})["tools"].([]ToolDefinition); len(definitions) != 1 || definitions[0].Name != "echo" {
+			t.Errorf("Expected tools/list to be scoped to the tenant, got %+v", definitions)
+		}
+		if _, err := toolService.ExecuteToolContext(WithTenant(context.Background(), tenants.lookup("key-a")), "secret", nil); !errors.Is(err, ErrToolNotFound) {
+			t.Errorf("Expected ErrToolNotFound, got %v", err)
+		}
+	})
+
+	t.Run("calls beyond the rate limit are rejected", func(t *testing.T) {
+		tenants.lookup("key-a").limiter.now = func() time.Time { return tenants.lookup("key-a").limiter.last }
+		serve("POST", "/api/v1/tools/echo", keyA)
+		w := serve("POST", "/api/v1/tools/echo", keyA)
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429, got %d", w.Code)
+		}
+		if w := serve("POST", "/api/v1/tools/echo", http.Header{apiKeyHeader: {"key-b"}}); w.Code != http.StatusOK {
+			t.Errorf("Expected other tenants to be unaffected, got %d", w.Code)
+		}
+	})
+
+	t.Run("a shared coordinator enforces the limit across replicas", func(t *testing.T) {
+		coordinator := store.NewCoordinator(store.NewMemoryStore(), "test")
+		ctx := context.Background()
+		var replicas []*Tenant
+		for i := 0; i < 2; i++ {
+			replica, err := NewTenants([]Tenant{{Name: "team-c", APIKey: "key-c", RateLimit: 0.001, Burst: 2}}, logger)
+			if err != nil {
+				t.Fatalf("NewTenants failed: %v", err)
+			}
+			replica.SetCoordinator(coordinator)
+			replicaService := newTestToolService(logger, &MockTool{name: "echo"})
+			replicaService.Use(replica.ToolMiddleware())
+			tenant := replica.lookup("key-c")
+			replicas = append(replicas, tenant)
+			if _, err := replicaService.ExecuteToolContext(WithTenant(ctx, tenant), "echo", nil); err != nil {
+				t.Fatalf("Expected call %d within the limit to succeed, got %v", i+1, err)
+			}
+			if i == 1 {
+				if _, err := replicaService.ExecuteToolContext(WithTenant(ctx, tenant), "echo", nil); !errors.Is(err, ErrRateLimited) {
+					t.Errorf("Expected the third call across replicas to be rate limited, got %v", err)
+				}
+			}
+		}
+		for _, tenant := range replicas {
+			if tenant.limiter.tokens != 2 {
+				t.Errorf("Expected the local buckets to be untouched, got %v tokens", tenant.limiter.tokens)
+			}
+		}
+	})
+
+	t.Run("pipeline steps run as the calling tenant", func(t *testing.T) {
+		pipeline, err := tools.NewPipeline(tools.PipelineDefinition{
+			Name:        "echo_secret",
+			Description: "Calls a tool the tenant may not",
+			Steps:       []tools.PipelineStep{{Tool: "secret"}},
+		}, toolService.ExecuteToolContext, logger)
+		if err != nil {
+			t.Fatalf("NewPipeline failed: %v", err)
+		}
+		if err := toolService.AddTool(pipeline); err != nil {
+			t.Fatalf("AddTool failed: %v", err)
+		}
+		tenant := &Tenant{Name: "pipeline-only", allowed: map[string]bool{"echo_secret": true}}
+		if _, err := toolService.ExecuteToolContext(WithTenant(context.Background(), tenant), "echo_secret", nil); !errors.Is(err, ErrToolNotFound) {
+			t.Errorf("Expected the step outside the allow-list to fail, got %v", err)
+		}
+	})
+
+	t.Run("calls without a tenant are unrestricted", func(t *testing.T) {
+		if _, err := toolService.ExecuteTool("secret", nil); err != nil {
+			t.Errorf("Expected an untenanted call to succeed, got %v", err)
+		}
+	})
+
+	t.Run("API keys must be unique", func(t *testing.T) {
+		if _, err := NewTenants([]Tenant{{Name: "a", APIKey: "k"}, {Name: "b", APIKey: "k"}}, logger); err == nil {
+			t.Error("Expected an error for a shared API key")
+		}
+	})
+}
@@ -1,6 +1,13 @@
 package server
 
-import "mcp-tools-server/pkg/tools"
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	"mcp-tools-server/pkg/tools"
+)
 
 // MockTool is a helper for testing that implements the tools.Tool interface.
 type MockTool struct {
@@ -22,3 +29,16 @@ func (m *MockTool) Execute(args map[string]interface{}) (map[string]interface{},
 
 // Ensure MockTool implements the interface.
 var _ tools.Tool = &MockTool{}
+
+// newTestToolService creates a ToolService serving exactly the given tools.
+func newTestToolService(logger *slog.Logger, testTools ...tools.Tool) *ToolService {
+	return newToolService(logger, testTools)
+}
+
+// newAdminRequest creates a request for an /admin/ endpoint from a loopback address,
+// which requireAdmin admits when no admin token is configured.
+func newAdminRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.RemoteAddr = "127.0.0.1:1234"
+	return req
+}
@@ -0,0 +1,243 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/internal/store"
+)
+
+// Metrics for throttled tools.
+var (
+	throttledTotal = metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "tool",
+			Name:      "throttled_total",
+			Help:      "Total number of tool calls rejected by a tool's rate or concurrency limit",
+		},
+		[]string{"tool", "reason"},
+	)
+	throttleQueued = metrics.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "tool",
+			Name:      "throttle_queued",
+			Help:      "Number of tool calls waiting for a tool's rate or concurrency limit",
+		},
+		[]string{"tool"},
+	)
+	throttleInFlight = metrics.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "tool",
+			Name:      "throttle_in_flight",
+			Help:      "Number of calls running for tools with a concurrency limit",
+		},
+		[]string{"tool"},
+	)
+)
+
+// Reasons a call is throttled, used as the metric's reason label.
+const (
+	throttleRate        = "rate"
+	throttleConcurrency = "concurrency"
+)
+
+// ThrottlePolicy limits how often and how many at once a tool may be called. Zero
+// fields are unlimited.
+type ThrottlePolicy struct {
+	Rate        float64       // Sustained calls per second
+	Burst       int           // Calls allowed at once above the rate; defaults to the rate rounded up
+	Concurrency int           // Calls that may run at once on this replica
+	Queue       bool          // Wait for capacity instead of failing at once
+	MaxWait     time.Duration // Longest a queued call waits; zero waits until the call's context ends
+}
+
+// toolThrottle is a ThrottlePolicy with its limiter state.
+type toolThrottle struct {
+	throttle *Throttle
+	policy   ThrottlePolicy
+	bucket   *tokenBucket
+	slots    chan struct{}
+}
+
+// Throttle enforces per-tool rate and concurrency limits so that expensive tools cannot
+// be overrun. A call over a limit either fails with ErrRateLimited or, for tools set to
+// queue, waits for capacity. Rate limits can be shared by every replica through a
+// coordinator; concurrency limits are always per replica, since they count calls
+// running in this process.
+type Throttle struct {
+	mu          sync.RWMutex
+	tools       map[string]*toolThrottle
+	coordinator *store.Coordinator // Counts rate limited calls across replicas; nil counts them per replica
+	logger      *slog.Logger
+}
+
+// NewThrottle creates a Throttle without limits.
+func NewThrottle(logger *slog.Logger) *Throttle {
+	return &Throttle{tools: make(map[string]*toolThrottle), logger: logger}
+}
+
+// SetPolicies replaces the per-tool policies, keyed by tool name. Tools without a policy
+// are unlimited. Calls already waiting keep the limits they started with.
+func (t *Throttle) SetPolicies(policies map[string]ThrottlePolicy) {
+	throttles := make(map[string]*toolThrottle, len(policies))
+	for name, policy := range policies {
+		throttle := &toolThrottle{throttle: t, policy: policy}
+		if policy.Rate > 0 {
+			burst := policy.Burst
+			if burst <= 0 {
+				burst = int(math.Ceil(policy.Rate))
+			}
+			throttle.bucket = newTokenBucket(policy.Rate, burst)
+		}
+		if policy.Concurrency > 0 {
+			throttle.slots = make(chan struct{}, policy.Concurrency)
+		}
+		throttles[name] = throttle
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tools = throttles
+}
+
+// SetCoordinator counts rate limited calls in coordinator's shared counters, so a tool's
+// rate holds across every replica using the same store rather than per replica.
+func (t *Throttle) SetCoordinator(coordinator *store.Coordinator) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.coordinator = coordinator
+}
+
+// Policy returns the named tool's policy.
+func (t *Throttle) Policy(name string) (ThrottlePolicy, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	throttle, ok := t.tools[name]
+	if !ok {
+		return ThrottlePolicy{}, false
+	}
+	return throttle.policy, true
+}
+
+// Middleware returns the ToolMiddleware that enforces the limits.
+func (t *Throttle) Middleware() ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			t.mu.RLock()
+			throttle := t.tools[name]
+			t.mu.RUnlock()
+			if throttle == nil || (throttle.bucket == nil && throttle.slots == nil) {
+				return next(ctx, name, args)
+			}
+
+			release, reason, err := throttle.acquire(ctx, name)
+			if err != nil {
+				throttledTotal.WithLabelValues(name, reason).Inc()
+				loggerFor(ctx, t.logger).Warn("Tool call throttled", "tool", name, "reason", reason, "error", err)
+				return nil, err
+			}
+			defer release()
+			return next(ctx, name, args)
+		}
+	}
+}
+
+// acquire takes a concurrency slot and then a rate token, waiting for them when the
+// policy queues. It returns a function that gives the slot back, or the reason and error
+// when the call is throttled.
+func (t *toolThrottle) acquire(ctx context.Context, name string) (func(), string, error) {
+	wait := ctx
+	if t.policy.Queue && t.policy.MaxWait > 0 {
+		var cancel context.CancelFunc
+		wait, cancel = context.WithTimeout(ctx, t.policy.MaxWait)
+		defer cancel()
+	}
+
+	release := func() {}
+	if t.slots != nil {
+		if !t.take(wait, name) {
+			return nil, throttleConcurrency, fmt.Errorf("tool %s: too many concurrent calls: %w", name, ErrRateLimited)
+		}
+		throttleInFlight.WithLabelValues(name).Inc()
+		release = func() {
+			throttleInFlight.WithLabelValues(name).Dec()
+			<-t.slots
+		}
+	}
+	if t.bucket != nil && !t.wait(wait, name) {
+		release()
+		return nil, throttleRate, fmt.Errorf("tool %s: %w", name, ErrRateLimited)
+	}
+	return release, "", nil
+}
+
+// take claims a concurrency slot, waiting until ctx ends when the policy queues.
+func (t *toolThrottle) take(ctx context.Context, name string) bool {
+	select {
+	case t.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if !t.policy.Queue {
+		return false
+	}
+	throttleQueued.WithLabelValues(name).Inc()
+	defer throttleQueued.WithLabelValues(name).Dec()
+	select {
+	case t.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait takes a rate token, waiting until ctx ends when the policy queues.
+func (t *toolThrottle) wait(ctx context.Context, name string) bool {
+	allowed, retry := t.allow(ctx, name)
+	if allowed {
+		return true
+	}
+	if !t.policy.Queue {
+		return false
+	}
+	throttleQueued.WithLabelValues(name).Inc()
+	defer throttleQueued.WithLabelValues(name).Dec()
+	for {
+		timer := time.NewTimer(retry)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false
+		case <-timer.C:
+		}
+		if allowed, retry = t.allow(ctx, name); allowed {
+			return true
+		}
+	}
+}
+
+// allow takes a rate token and, when none is left, reports how long to wait before
+// trying again. With a coordinator, calls are counted across replicas in fixed windows
+// of burst calls each lasting burst/rate seconds, and a waiting call tries again every
+// 1/rate seconds; while the store cannot be reached, and without a coordinator, this
+// replica's token bucket decides.
+func (t *toolThrottle) allow(ctx context.Context, name string) (bool, time.Duration) {
+	t.throttle.mu.RLock()
+	coordinator := t.throttle.coordinator
+	t.throttle.mu.RUnlock()
+	if coordinator != nil {
+		window := time.Duration(t.bucket.burst / t.bucket.rate * float64(time.Second))
+		allowed, err := coordinator.Allow(ctx, "tool:"+name, int64(t.bucket.burst), window)
+		if err == nil {
+			return allowed, time.Duration(float64(time.Second) / t.bucket.rate)
+		}
+		loggerFor(ctx, t.throttle.logger).Warn("Shared rate limit unavailable, using this replica's limit", "tool", name, "error", err)
+	}
+	return t.bucket.allow(), t.bucket.next()
+}
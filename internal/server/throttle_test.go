@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/store"
+)
+
+func TestThrottle(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("rejects calls over the concurrency limit", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		service := newTestToolService(logger, &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			started <- struct{}{}
+			<-release
+			return map[string]interface{}{}, nil
+		}})
+		service.Throttle().SetPolicies(map[string]ThrottlePolicy{"slow": {Concurrency: 1}})
+
+		done := make(chan error)
+		go func() {
+			_, err := service.ExecuteTool("slow", nil)
+			done <- err
+		}()
+		<-started
+		if _, err := service.ExecuteTool("slow", nil); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited, got %v", err)
+		}
+		close(release)
+		if err := <-done; err != nil {
+			t.Errorf("Expected the first call to succeed, got %v", err)
+		}
+	})
+
+	t.Run("queued calls wait for a slot", func(t *testing.T) {
+		var mu sync.Mutex
+		running, peak := 0, 0
+		service := newTestToolService(logger, &MockTool{name: "slow", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return map[string]interface{}{}, nil
+		}})
+		service.Throttle().SetPolicies(map[string]ThrottlePolicy{"slow": {Concurrency: 2, Queue: true}})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 6; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := service.ExecuteTool("slow", nil); err != nil {
+					t.Errorf("Expected queued call to succeed, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if peak > 2 {
+			t.Errorf("Expected at most 2 concurrent calls, saw %d", peak)
+		}
+	})
+
+	t.Run("rate limit rejects or queues", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "reject"}, &MockTool{name: "queue"}, &MockTool{name: "free"})
+		service.Throttle().SetPolicies(map[string]ThrottlePolicy{
+			"reject": {Rate: 1},
+			"queue":  {Rate: 50, Burst: 1, Queue: true},
+			"free":   {},
+		})
+
+		if _, err := service.ExecuteTool("reject", nil); err != nil {
+			t.Fatalf("Expected the first call to succeed, got %v", err)
+		}
+		if _, err := service.ExecuteTool("reject", nil); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited, got %v", err)
+		}
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			if _, err := service.ExecuteTool("queue", nil); err != nil {
+				t.Fatalf("Expected queued call to succeed, got %v", err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+			t.Errorf("Expected queued calls to be spaced by the rate, took %v", elapsed)
+		}
+
+		for i := 0; i < 5; i++ {
+			if _, err := service.ExecuteTool("free", nil); err != nil {
+				t.Errorf("Expected an empty policy to be unlimited, got %v", err)
+			}
+		}
+	})
+
+	t.Run("a shared coordinator enforces the rate across replicas", func(t *testing.T) {
+		coordinator := store.NewCoordinator(store.NewMemoryStore(), "test")
+		var replicas []*ToolService
+		for i := 0; i < 2; i++ {
+			service := newTestToolService(logger, &MockTool{name: "shared"})
+			service.Throttle().SetPolicies(map[string]ThrottlePolicy{"shared": {Rate: 0.001, Burst: 1}})
+			service.Throttle().SetCoordinator(coordinator)
+			replicas = append(replicas, service)
+		}
+		if _, err := replicas[0].ExecuteTool("shared", nil); err != nil {
+			t.Fatalf("Expected the first call to succeed, got %v", err)
+		}
+		if _, err := replicas[1].ExecuteTool("shared", nil); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected the other replica to be rate limited, got %v", err)
+		}
+	})
+
+	t.Run("queued call gives up after the maximum wait", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "limited"})
+		service.Throttle().SetPolicies(map[string]ThrottlePolicy{"limited": {Rate: 0.01, Queue: true, MaxWait: 20 * time.Millisecond}})
+		if _, err := service.ExecuteTool("limited", nil); err != nil {
+			t.Fatalf("Expected the first call to succeed, got %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if _, err := service.ExecuteToolContext(ctx, "limited", nil); !errors.Is(err, ErrRateLimited) {
+			t.Errorf("Expected ErrRateLimited after the wait, got %v", err)
+		}
+	})
+
+	t.Run("throttled calls do not trip the breaker", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "limited"})
+		service.Breaker().SetPolicy(BreakerPolicy{Failures: 1, Cooldown: time.Minute})
+		service.Throttle().SetPolicies(map[string]ThrottlePolicy{"limited": {Rate: 0.01}})
+		for i := 0; i < 3; i++ {
+			_, _ = service.ExecuteTool("limited", nil)
+		}
+		for _, status := range service.Breaker().Breakers() {
+			if status.State != CircuitClosed {
+				t.Errorf("Expected the breaker to stay closed, got %s", status.State)
+			}
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"fmt"
+	"maps"
+)
+
+// SetAliases sets additional names tools can be called and listed by, mapping each alias
+// to a tool's name or a versioned tool's bare name, such as {"uuid": "generate_uuid"}, so
+// hosts expecting their own naming conventions find the tools. It fails, leaving the
+// aliases unchanged, for an alias that already names a tool or a target that does not.
+func (s *ToolService) SetAliases(aliases map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for alias, target := range aliases {
+		if _, exists := s.tools[alias]; exists {
+			return fmt.Errorf("alias %s: %w", alias, ErrToolExists)
+		}
+		if _, versioned := s.defaultVersion(alias); versioned {
+			return fmt.Errorf("alias %s: %w", alias, ErrToolExists)
+		}
+		if _, exists := s.tools[target]; !exists {
+			if _, ok := s.defaultVersion(target); !ok {
+				return fmt.Errorf("alias %s: unknown tool %s", alias, target)
+			}
+		}
+	}
+	s.aliases = maps.Clone(aliases)
+	return nil
+}
+
+// Aliases returns the configured aliases, mapped to the names they stand for.
+func (s *ToolService) Aliases() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return maps.Clone(s.aliases)
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestToolService_Aliases(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	newService := func() *ToolService {
+		return newTestToolService(logger,
+			&MockTool{name: "generate_uuid", description: "Generates a UUID"},
+			&MockTool{name: "convert@v1", description: "Converts"},
+			&MockTool{name: "hidden", description: "Not for tenants"},
+		)
+	}
+
+	t.Run("aliases run and list their tool", func(t *testing.T) {
+		service := newService()
+		if err := service.SetAliases(map[string]string{"uuid": "generate_uuid", "transform": "convert", "secret": "hidden"}); err != nil {
+			t.Fatalf("SetAliases failed: %v", err)
+		}
+		if resolved, ok := service.Resolve("uuid"); !ok || resolved != "generate_uuid" {
+			t.Errorf("Expected generate_uuid, got %q, %v", resolved, ok)
+		}
+		if resolved, ok := service.Resolve("transform"); !ok || resolved != "convert@v1" {
+			t.Errorf("Expected an alias of a bare name to run its default version, got %q, %v", resolved, ok)
+		}
+		if result, err := service.ExecuteTool("uuid", nil); err != nil || result["success"] != true {
+			t.Errorf("Expected the alias to run, got %v, %v", result, err)
+		}
+
+		tenant := &Tenant{Name: "team", allowed: map[string]bool{"generate_uuid": true}}
+		ctx := WithTenant(context.Background(), tenant)
+		definitions := NewJSONRPCProcessor(service, logger).getAvailableTools(ctx)
+		var names []string
+		for _, definition := range definitions {
+			names = append(names, definition.Name)
+			if definition.Name == "uuid" && definition.Description != "Generates a UUID" {
+				t.Errorf("Expected the alias to be described like its tool, got %+v", definition)
+			}
+		}
+		if len(names) != 2 || names[0] != "generate_uuid" || names[1] != "uuid" {
+			t.Errorf("Expected the tenant to see generate_uuid and its alias, got %v", names)
+		}
+	})
+
+	t.Run("rejects aliases that are taken or point nowhere", func(t *testing.T) {
+		service := newService()
+		for _, aliases := range []map[string]string{
+			{"generate_uuid": "hidden"},
+			{"convert": "generate_uuid"},
+		} {
+			if err := service.SetAliases(aliases); !errors.Is(err, ErrToolExists) {
+				t.Errorf("Expected ErrToolExists for %v, got %v", aliases, err)
+			}
+		}
+		if err := service.SetAliases(map[string]string{"uuid": "missing"}); err == nil {
+			t.Error("Expected an error for an unknown target")
+		}
+		if _, ok := service.Resolve("uuid"); ok {
+			t.Error("Expected failed aliases not to be applied")
+		}
+	})
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// Default health check timing.
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+)
+
+// ToolHealth is the outcome of a tool's last health check.
+type ToolHealth struct {
+	Tool      string    `json:"tool"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+	LatencyMS int64     `json:"latencyMs"`
+}
+
+// HealthMonitor runs the health checks of tools implementing tools.HealthChecker,
+// periodically and on demand. Tools whose last check failed are left out of tool lists
+// until a check passes; tools without a check are always healthy. Changes in health are
+// published as EventToolHealthChanged events.
+type HealthMonitor struct {
+	mu       sync.Mutex
+	results  map[string]ToolHealth
+	interval time.Duration
+	timeout  time.Duration
+	list     func() map[string]tools.Tool
+	onChange func() // Called after a tool's health changes
+	now      func() time.Time
+	events   *EventBus
+	logger   *slog.Logger
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewHealthMonitor creates a HealthMonitor checking the tools returned by list.
+func NewHealthMonitor(list func() map[string]tools.Tool, logger *slog.Logger) *HealthMonitor {
+	return &HealthMonitor{
+		results:  make(map[string]ToolHealth),
+		interval: defaultHealthCheckInterval,
+		timeout:  defaultHealthCheckTimeout,
+		list:     list,
+		now:      time.Now,
+		logger:   logger,
+	}
+}
+
+// SetInterval sets how often checks run in the background, where zero leaves checks to
+// CheckAll and Check, and how long each check may take. A non-positive timeout keeps the
+// default. Call it before Start.
+func (m *HealthMonitor) SetInterval(interval, timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.interval = interval
+	m.timeout = defaultHealthCheckTimeout
+	if timeout > 0 {
+		m.timeout = timeout
+	}
+}
+
+// Start checks every tool at once and then at each interval. It does nothing if the
+// monitor is running or periodic checks are off.
+func (m *HealthMonitor) Start() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop != nil || m.interval <= 0 {
+		return
+	}
+	m.stop = make(chan struct{})
+	m.wg.Add(1)
+	go m.loop(m.interval, m.stop)
+}
+
+// Stop ends periodic checks and waits for checks in progress to finish.
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	stop := m.stop
+	m.stop = nil
+	m.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	m.wg.Wait()
+}
+
+// loop runs CheckAll until stop is closed.
+func (m *HealthMonitor) loop(interval time.Duration, stop <-chan struct{}) {
+	defer m.wg.Done()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.CheckAll(ctx)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll runs every tool's health check concurrently and returns the results ordered
+// by tool name.
+func (m *HealthMonitor) CheckAll(ctx context.Context) []ToolHealth {
+	available := m.list()
+	var wg sync.WaitGroup
+	for name, tool := range available {
+		checker, ok := tool.(tools.HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.check(ctx, name, checker)
+		}()
+	}
+	wg.Wait()
+
+	// Forget tools that were removed
+	m.mu.Lock()
+	for name := range m.results {
+		if _, ok := available[name]; !ok {
+			delete(m.results, name)
+		}
+	}
+	m.mu.Unlock()
+	return m.Statuses()
+}
+
+// Check runs the named tool's health check. It reports false when the tool does not exist
+// or has no health check.
+func (m *HealthMonitor) Check(ctx context.Context, name string) (ToolHealth, bool) {
+	tool, ok := m.list()[name]
+	if !ok {
+		return ToolHealth{}, false
+	}
+	checker, ok := tool.(tools.HealthChecker)
+	if !ok {
+		return ToolHealth{}, false
+	}
+	return m.check(ctx, name, checker), true
+}
+
+// check runs one health check and records the outcome.
+func (m *HealthMonitor) check(ctx context.Context, name string, checker tools.HealthChecker) ToolHealth {
+	m.mu.Lock()
+	timeout := m.timeout
+	m.mu.Unlock()
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := m.now()
+	err := checker.HealthCheck(checkCtx)
+	health := ToolHealth{Tool: name, Healthy: err == nil, CheckedAt: start, LatencyMS: m.now().Sub(start).Milliseconds()}
+	if err != nil {
+		health.Error = err.Error()
+	}
+
+	m.mu.Lock()
+	previous, seen := m.results[name]
+	m.results[name] = health
+	onChange := m.onChange
+	m.mu.Unlock()
+
+	changed := (seen && previous.Healthy != health.Healthy) || (!seen && !health.Healthy)
+	if !changed {
+		return health
+	}
+	if health.Healthy {
+		m.logger.Info("Tool is healthy again", "tool", name)
+	} else {
+		m.logger.Warn("Tool health check failed", "tool", name, "error", err)
+	}
+	m.events.Publish(EventToolHealthChanged, map[string]interface{}{
+		"tool":    name,
+		"healthy": health.Healthy,
+		"error":   health.Error,
+	})
+	if onChange != nil {
+		onChange()
+	}
+	return health
+}
+
+// Healthy reports whether the named tool passed its last health check. Tools that have
+// not been checked are healthy.
+func (m *HealthMonitor) Healthy(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	health, ok := m.results[name]
+	return !ok || health.Healthy
+}
+
+// Statuses returns the last health check of every checked tool, ordered by name.
+func (m *HealthMonitor) Statuses() []ToolHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	statuses := make([]ToolHealth, 0, len(m.results))
+	for _, health := range m.results {
+		statuses = append(statuses, health)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Tool < statuses[j].Tool })
+	return statuses
+}
+
+// Unhealthy returns the names of the tools whose last health check failed, sorted.
+func (m *HealthMonitor) Unhealthy() []string {
+	var names []string
+	for _, health := range m.Statuses() {
+		if !health.Healthy {
+			names = append(names, health.Tool)
+		}
+	}
+	return names
+}
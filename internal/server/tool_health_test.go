@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	db := &healthCheckedMockTool{MockTool: MockTool{name: "db_query"}}
+	service := newTestToolService(logger, db, &MockTool{name: "echo"})
+	monitor := service.Health()
+	changes := make(chan Event, 4)
+	service.Events().Subscribe(func(event Event) { changes <- event }, EventToolHealthChanged)
+
+	listed := func() map[string]bool {
+		names := make(map[string]bool)
+		for _, tool := range service.FilterTools(ToolFilter{}) {
+			names[tool.Name()] = true
+		}
+		return names
+	}
+
+	t.Run("passing checks keep tools listed", func(t *testing.T) {
+		statuses := monitor.CheckAll(context.Background())
+		if len(statuses) != 1 || statuses[0].Tool != "db_query" || !statuses[0].Healthy {
+			t.Fatalf("Expected one healthy check, got %+v", statuses)
+		}
+		if names := listed(); !names["db_query"] || !names["echo"] {
+			t.Errorf("Expected both tools listed, got %v", names)
+		}
+	})
+
+	t.Run("failing checks hide the tool", func(t *testing.T) {
+		db.healthErr = errors.New("connection refused")
+		health, ok := monitor.Check(context.Background(), "db_query")
+		if !ok || health.Healthy || health.Error != "connection refused" {
+			t.Fatalf("Expected a failed check, got %+v, %v", health, ok)
+		}
+		if names := listed(); names["db_query"] || !names["echo"] {
+			t.Errorf("Expected db_query hidden, got %v", names)
+		}
+		if unhealthy := monitor.Unhealthy(); len(unhealthy) != 1 || unhealthy[0] != "db_query" {
+			t.Errorf("Expected db_query unhealthy, got %v", unhealthy)
+		}
+		select {
+		case event := <-changes:
+			if event.Data["tool"] != "db_query" || event.Data["healthy"] != false {
+				t.Errorf("Unexpected event data: %v", event.Data)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected a tool.health_changed event")
+		}
+	})
+
+	t.Run("recovery lists the tool again", func(t *testing.T) {
+		db.healthErr = nil
+		monitor.CheckAll(context.Background())
+		if names := listed(); !names["db_query"] {
+			t.Errorf("Expected db_query listed again, got %v", names)
+		}
+	})
+
+	t.Run("tools without a check are not checked", func(t *testing.T) {
+		if _, ok := monitor.Check(context.Background(), "echo"); ok {
+			t.Error("Expected no check for echo")
+		}
+		if !monitor.Healthy("echo") {
+			t.Error("Expected echo to be healthy")
+		}
+	})
+
+	t.Run("periodic checks run until stopped", func(t *testing.T) {
+		service := newTestToolService(logger, &healthCheckedMockTool{MockTool: MockTool{name: "db_query"}, healthErr: errors.New("down")})
+		service.Health().SetInterval(10*time.Millisecond, time.Second)
+		service.Health().Start()
+		deadline := time.Now().Add(time.Second)
+		for service.Health().Healthy("db_query") && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		service.Health().Stop()
+		if service.Health().Healthy("db_query") {
+			t.Error("Expected the background check to mark db_query unhealthy")
+		}
+	})
+}
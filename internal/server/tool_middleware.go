@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"mcp-tools-server/internal/redact"
+	"mcp-tools-server/pkg/tools"
+)
+
+// ToolHandler executes a named tool with the given arguments.
+type ToolHandler func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error)
+
+// ToolMiddleware wraps a ToolHandler to add behaviour around every tool call, regardless
+// of the transport the call arrived on.
+type ToolMiddleware func(next ToolHandler) ToolHandler
+
+// chainToolMiddleware wraps handler so that middleware[0] is the outermost layer.
+func chainToolMiddleware(handler ToolHandler, middleware ...ToolMiddleware) ToolHandler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// defaultsMiddleware merges configured per-tool default arguments under the client's
+// arguments, so explicit client values always win.
+func defaultsMiddleware(defaults func(name string) map[string]interface{}) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			toolDefaults := defaults(name)
+			if len(toolDefaults) == 0 {
+				return next(ctx, name, args)
+			}
+			merged := make(map[string]interface{}, len(toolDefaults)+len(args))
+			for key, value := range toolDefaults {
+				merged[key] = value
+			}
+			for key, value := range args {
+				merged[key] = value
+			}
+			return next(ctx, name, merged)
+		}
+	}
+}
+
+// argumentsMiddleware checks every call's arguments against its tool's input schema,
+// failing calls whose arguments do not satisfy it with tools.ErrInvalidArguments. Tools
+// implementing tools.ArgCoercer receive the arguments converted to Go types; others
+// receive them as tools.ValidateArgs leaves them.
+func argumentsMiddleware(lookup func(name string) (tools.Tool, bool), inputSchema func(tools.Tool) map[string]interface{}) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			tool, ok := lookup(name)
+			if !ok {
+				return next(ctx, name, args)
+			}
+			coerce := tools.ValidateArgs
+			if coercer, ok := tool.(tools.ArgCoercer); ok && coercer.CoercesArgs() {
+				coerce = tools.CoerceArgs
+			}
+			coerced, err := coerce(inputSchema(tool), args)
+			if err != nil {
+				return nil, fmt.Errorf("tool %s: %w", name, err)
+			}
+			return next(ctx, name, coerced)
+		}
+	}
+}
+
+// redactMiddleware redacts the result and error message of every tool call.
+func redactMiddleware(r *redact.Redactor) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			result, err := next(ctx, name, args)
+			if err != nil {
+				if message := r.String(err.Error(), redact.TargetResult); message != err.Error() {
+					err = &redactedError{message: message, err: err}
+				}
+			}
+			return r.Map(result), err
+		}
+	}
+}
+
+// redactedError replaces an error's message while keeping it matchable with errors.Is.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string { return e.message }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// OutputSchema returns the schema a tool's results are described by, or nil when the
+// tool does not declare one.
+func (s *ToolService) OutputSchema(tool tools.Tool) map[string]interface{} {
+	if provider, ok := tool.(tools.OutputSchemaProvider); ok {
+		return provider.OutputSchema()
+	}
+	return nil
+}
+
+// SetStrictOutput sets whether calls whose results do not match their tool's output
+// schema fail with tools.ErrInvalidResult. Otherwise mismatches are only logged.
+func (s *ToolService) SetStrictOutput(strict bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.strictOutput = strict
+}
+
+// StrictOutput reports whether results that do not match their output schema fail.
+func (s *ToolService) StrictOutput() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.strictOutput
+}
+
+// outputMiddleware returns a ToolMiddleware that checks results against their tool's
+// output schema, failing mismatched calls when strict returns true and logging them
+// otherwise.
+func outputMiddleware(lookup func(name string) (tools.Tool, bool), outputSchema func(tools.Tool) map[string]interface{}, strict func() bool, logger *slog.Logger) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			result, err := next(ctx, name, args)
+			if err != nil {
+				return result, err
+			}
+			tool, ok := lookup(name)
+			if !ok {
+				return result, nil
+			}
+			schema := outputSchema(tool)
+			if schema == nil {
+				return result, nil
+			}
+			if err := tools.ValidateResult(schema, result); err != nil {
+				if strict() {
+					loggerFor(ctx, logger).Error("Tool result does not match its output schema", "tool", name, "error", err)
+					return nil, err
+				}
+				loggerFor(ctx, logger).Warn("Tool result does not match its output schema", "tool", name, "error", err)
+			}
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// outputTool is a MockTool with an output schema.
+type outputTool struct {
+	MockTool
+	schema map[string]interface{}
+}
+
+func (o *outputTool) OutputSchema() map[string]interface{} { return o.schema }
+
+func TestToolService_OutputSchemas(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"count": map[string]interface{}{"type": "integer"}},
+		"required":   []string{"count"},
+	}
+	newService := func(count interface{}) *ToolService {
+		return newTestToolService(logger,
+			&outputTool{MockTool: MockTool{name: "counter", executeFunc: func(map[string]interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"count": count}, nil
+			}}, schema: schema},
+			&MockTool{name: "plain", executeFunc: func(map[string]interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"anything": true}, nil
+			}},
+		)
+	}
+
+	t.Run("mismatches are only logged by default", func(t *testing.T) {
+		result, err := newService("three").ExecuteTool("counter", nil)
+		if err != nil || result["count"] != "three" {
+			t.Errorf("Expected the result to pass through, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("strict mode fails mismatches", func(t *testing.T) {
+		service := newService("three")
+		service.SetStrictOutput(true)
+		if _, err := service.ExecuteTool("counter", nil); !errors.Is(err, tools.ErrInvalidResult) {
+			t.Errorf("Expected ErrInvalidResult, got %v", err)
+		}
+		if _, err := service.ExecuteTool("plain", nil); err != nil {
+			t.Errorf("Expected tools without an output schema to run, got %v", err)
+		}
+
+		service = newService(3)
+		service.SetStrictOutput(true)
+		if result, err := service.ExecuteTool("counter", nil); err != nil || result["count"] != 3 {
+			t.Errorf("Expected a matching result, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("REST reports invalid_result", func(t *testing.T) {
+		service := newService("three")
+		service.SetStrictOutput(true)
+		httpServer := NewHTTPServer(service, WithPort(8080), WithLogger(logger))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/api/tools/counter", nil))
+		var response errorResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal error response %q: %v", w.Body.String(), err)
+		}
+		if w.Code != http.StatusInternalServerError || response.Error.Code != errCodeInvalidResult {
+			t.Errorf("Expected 500 invalid_result, got %d %+v", w.Code, response.Error)
+		}
+	})
+
+	t.Run("tools/list advertises schemas to newer clients", func(t *testing.T) {
+		processor := NewJSONRPCProcessor(newService(3), logger)
+		outputSchemas := func(version string) map[string]map[string]interface{} {
+			schemas := make(map[string]map[string]interface{})
+			for _, definition := range processor.getAvailableTools(WithProtocolVersion(context.Background(), version)) {
+				schemas[definition.Name] = definition.OutputSchema
+			}
+			return schemas
+		}
+		if schemas := outputSchemas(ProtocolVersion20250618); schemas["counter"] == nil || schemas["plain"] != nil {
+			t.Errorf("Expected only counter to have an output schema, got %v", schemas)
+		}
+		if schemas := outputSchemas(ProtocolVersion20241105); schemas["counter"] != nil {
+			t.Errorf("Expected no output schemas for %s, got %v", ProtocolVersion20241105, schemas)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+)
+
+// ErrInvalidCursor is returned for a malformed tools/list cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// SetListPageSize sets how many tools tools/list and /api/list return per page. Zero or
+// less returns every tool at once.
+func (s *ToolService) SetListPageSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listPageSize = size
+}
+
+// ListPage returns the page of ListedTools after cursor, which is empty for the first
+// page, and the cursor of the next page, which is empty on the last. Cursors hold the last
+// name of their page, so tools added or removed between pages neither repeat nor shift
+// the names that follow.
+func (s *ToolService) ListPage(filter ToolFilter, cursor string) ([]ListedTool, string, error) {
+	listed := s.ListedTools(filter)
+	if cursor != "" {
+		after, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil || len(after) == 0 {
+			return nil, "", ErrInvalidCursor
+		}
+		start := sort.Search(len(listed), func(i int) bool { return listed[i].Name > string(after) })
+		listed = listed[start:]
+	}
+
+	s.mu.RLock()
+	size := s.listPageSize
+	s.mu.RUnlock()
+	if size <= 0 || len(listed) <= size {
+		return listed, "", nil
+	}
+	listed = listed[:size]
+	return listed, base64.RawURLEncoding.EncodeToString([]byte(listed[size-1].Name)), nil
+}
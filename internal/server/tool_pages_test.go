@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestToolService_ListPage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	newService := func() *ToolService {
+		service := newTestToolService(logger,
+			&MockTool{name: "alpha"}, &MockTool{name: "bravo"}, &MockTool{name: "charlie"},
+			&MockTool{name: "delta"}, &MockTool{name: "echo"},
+		)
+		service.SetListPageSize(2)
+		return service
+	}
+	names := func(listed []ListedTool) string {
+		var names []string
+		for _, tool := range listed {
+			names = append(names, tool.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	t.Run("pages follow cursors to the end", func(t *testing.T) {
+		service := newService()
+		var pages []string
+		cursor := ""
+		for {
+			listed, next, err := service.ListPage(ToolFilter{}, cursor)
+			if err != nil {
+				t.Fatalf("ListPage failed: %v", err)
+			}
+			pages = append(pages, names(listed))
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		if got := strings.Join(pages, "|"); got != "alpha,bravo|charlie,delta|echo" {
+			t.Errorf("Unexpected pages: %s", got)
+		}
+	})
+
+	t.Run("tools added before a cursor do not shift its page", func(t *testing.T) {
+		service := newService()
+		_, next, _ := service.ListPage(ToolFilter{}, "")
+		if err := service.AddTool(&MockTool{name: "aardvark"}); err != nil {
+			t.Fatalf("AddTool failed: %v", err)
+		}
+		listed, _, err := service.ListPage(ToolFilter{}, next)
+		if err != nil || names(listed) != "charlie,delta" {
+			t.Errorf("Expected charlie,delta, got %s, %v", names(listed), err)
+		}
+	})
+
+	t.Run("no page size lists everything", func(t *testing.T) {
+		service := newService()
+		service.SetListPageSize(0)
+		listed, next, err := service.ListPage(ToolFilter{}, "")
+		if err != nil || next != "" || len(listed) != 5 {
+			t.Errorf("Expected all 5 tools, got %s, %q, %v", names(listed), next, err)
+		}
+	})
+
+	t.Run("rejects invalid cursors", func(t *testing.T) {
+		if _, _, err := newService().ListPage(ToolFilter{}, "not a cursor!"); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("Expected ErrInvalidCursor, got %v", err)
+		}
+	})
+
+	t.Run("tools/list returns nextCursor", func(t *testing.T) {
+		processor := NewJSONRPCProcessor(newService(), logger)
+		list := func(params map[string]interface{}) *JSONRPCResponse {
+			request := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/list"}
+			if params != nil {
+				request["params"] = params
+			}
+			return processor.Process(context.Background(), request)
+		}
+		first := list(nil).Result.(map[string]interface{})
+		next, _ := first["nextCursor"].(string)
+		if len(first["tools"].([]ToolDefinition)) != 2 || next == "" {
+			t.Fatalf("Unexpected first page: %v", first)
+		}
+		second := list(map[string]interface{}{"cursor": next}).Result.(map[string]interface{})
+		if tools := second["tools"].([]ToolDefinition); tools[0].Name != "charlie" {
+			t.Errorf("Expected the second page to start at charlie, got %v", tools)
+		}
+		if response := list(map[string]interface{}{"cursor": "%%%"}); response.Error == nil || response.Error.Code != -32602 {
+			t.Errorf("Expected -32602 for an invalid cursor, got %+v", response)
+		}
+	})
+
+	t.Run("/api/list links to the next page", func(t *testing.T) {
+		httpServer := NewHTTPServer(newService(), WithPort(8080), WithLogger(logger))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/list?details=true", nil))
+		link := w.Header().Get("Link")
+		if w.Code != http.StatusOK || !strings.HasPrefix(link, "</api/v1/list?cursor=") || !strings.Contains(link, "details=true") || !strings.HasSuffix(link, `>; rel="next"`) {
+			t.Fatalf("Unexpected response: %d %q", w.Code, link)
+		}
+		next := strings.TrimSuffix(strings.TrimPrefix(link, "<"), `>; rel="next"`)
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", next, nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "charlie") || strings.Contains(w.Body.String(), "alpha") {
+			t.Errorf("Unexpected second page: %d %s", w.Code, w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/list?cursor=%25", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid cursor, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"mcp-tools-server/internal/policy"
+)
+
+// policyMiddleware evaluates every call against engine before it runs. A denied call
+// fails with policy.ErrDenied, as does one the engine cannot decide, and an allowed call
+// runs with the arguments the engine set, if any.
+func policyMiddleware(engine policy.Engine, logger *slog.Logger) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			decision, err := engine.Evaluate(ctx, policy.Input{
+				Identity: policy.Identity{
+					Tenant:    tenantName(ctx),
+					SessionID: SessionIDFromContext(ctx),
+					ClientIP:  ClientIPFromContext(ctx),
+					RequestID: RequestIDFromContext(ctx),
+				},
+				Tool: name,
+				Args: args,
+			})
+			if err != nil {
+				loggerFor(ctx, logger).Error("Policy evaluation failed; denying the call", "tool", name, "error", err)
+				return nil, fmt.Errorf("tool %s: %w: the policy could not be evaluated", name, policy.ErrDenied)
+			}
+			if !decision.Allow {
+				loggerFor(ctx, logger).Info("Tool call denied by policy", "tool", name, "reason", decision.Reason)
+				return nil, fmt.Errorf("tool %s: %w: %s", name, policy.ErrDenied, decision.Reason)
+			}
+			if decision.Args != nil {
+				args = decision.Args
+			}
+			return next(ctx, name, args)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/internal/policy"
+)
+
+// failingEngine is a policy.Engine that cannot decide.
+type failingEngine struct{}
+
+func (failingEngine) Evaluate(context.Context, policy.Input) (policy.Decision, error) {
+	return policy.Decision{}, errors.New("agent unreachable")
+}
+
+func TestToolService_Policy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	var seen map[string]interface{}
+	service := newTestToolService(logger,
+		&MockTool{name: "echo", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			seen = args
+			return map[string]interface{}{"ok": true}, nil
+		}},
+		&sensitiveTool{MockTool{name: "deploy"}},
+	)
+	rules, err := policy.NewRuleSet([]policy.Rule{
+		{When: `identity.tenant == "guest"`, Effect: policy.EffectDeny, Reason: "guests may not call tools"},
+		{When: `tool == "echo"`, Set: map[string]string{"text": `has(args.text) ? args.text + "!" : "default"`}},
+		{When: `tool == "deploy"`, Effect: policy.EffectDeny},
+	}, true)
+	if err != nil {
+		t.Fatalf("NewRuleSet failed: %v", err)
+	}
+	service.SetPolicy(rules)
+
+	t.Run("rewrites arguments", func(t *testing.T) {
+		if _, err := service.ExecuteTool("echo", map[string]interface{}{"text": "hi"}); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if seen["text"] != "hi!" {
+			t.Errorf("Expected the policy's arguments, got %v", seen)
+		}
+	})
+
+	t.Run("denies by identity", func(t *testing.T) {
+		ctx := WithTenant(context.Background(), &Tenant{Name: "guest"})
+		_, err := service.ExecuteToolContext(ctx, "echo", nil)
+		if !errors.Is(err, policy.ErrDenied) || !strings.Contains(err.Error(), "guests may not call tools") {
+			t.Errorf("Expected a policy denial, got %v", err)
+		}
+	})
+
+	t.Run("denies before holding for approval", func(t *testing.T) {
+		if _, err := service.ExecuteTool("deploy", nil); !errors.Is(err, policy.ErrDenied) {
+			t.Errorf("Expected a policy denial, got %v", err)
+		}
+		if len(service.Approvals().Pending()) != 0 {
+			t.Error("Expected the denied call not to be held")
+		}
+	})
+
+	t.Run("fails closed", func(t *testing.T) {
+		service.SetPolicy(failingEngine{})
+		defer service.SetPolicy(rules)
+		if _, err := service.ExecuteTool("echo", nil); !errors.Is(err, policy.ErrDenied) {
+			t.Errorf("Expected a denial when the policy cannot be evaluated, got %v", err)
+		}
+	})
+
+	t.Run("denials are forbidden over HTTP", func(t *testing.T) {
+		httpServer := NewHTTPServer(service, WithLogger(logger))
+		req := httptest.NewRequest("POST", "/api/tools/deploy", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), errCodeForbidden) {
+			t.Errorf("Expected 403 forbidden, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
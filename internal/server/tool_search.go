@@ -0,0 +1,273 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// searchToolsToolName is the meta-tool agents call to find tools by what they do.
+const searchToolsToolName = "search_tools"
+
+// Limits on the matches the search_tools tool returns.
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// ToolMatch is a listed tool that matched a search, with its relevance.
+type ToolMatch struct {
+	ListedTool
+	Score int
+}
+
+// SearchTools returns the ListedTools satisfying filter whose name, description, category,
+// or tags match every word of query, most relevant first. Words match exactly, as a
+// prefix or substring, or, from four letters on, with a typo or two, and matches in names
+// count most. An empty query matches every tool the filter does, in name order.
+func (s *ToolService) SearchTools(filter ToolFilter, query string) []ToolMatch {
+	terms := searchWords(query)
+	var matches []ToolMatch
+	for _, listed := range s.ListedTools(filter) {
+		score, ok := searchScore(listed, terms)
+		if ok {
+			matches = append(matches, ToolMatch{ListedTool: listed, Score: score})
+		}
+	}
+	// ListedTools is in name order, which ties keep
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// EnableToolSearch registers the search_tools tool. Call it before serving requests.
+func (s *ToolService) EnableToolSearch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[searchToolsToolName]; !exists {
+		s.tools[searchToolsToolName] = &searchToolsTool{service: s}
+	}
+}
+
+// searchScore scores how well a tool matches the search terms, reporting whether it
+// matches them all.
+func searchScore(listed ListedTool, terms []string) (int, bool) {
+	name := strings.ToLower(listed.Name)
+	category, tags := toolMetadata(listed.Tool)
+	nameWords := searchWords(listed.Name)
+	tagWords := searchWords(category + " " + strings.Join(tags, " "))
+	descriptionWords := searchWords(listed.Tool.Description())
+
+	total := 0
+	if strings.Join(terms, "_") == name {
+		total += 100
+	}
+	for _, term := range terms {
+		score := 0
+		switch {
+		case containsWord(nameWords, term):
+			score = 20
+		case strings.Contains(name, term):
+			score = 12
+		case containsWord(tagWords, term):
+			score = 10
+		case hasPrefixWord(descriptionWords, term):
+			score = 6
+		case fuzzyWord(nameWords, term):
+			score = 5
+		case fuzzyWord(tagWords, term) || fuzzyWord(descriptionWords, term):
+			score = 2
+		}
+		if score == 0 {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// searchWords splits text into lowercase words at anything but letters and digits, so
+// names like currency_convert and text with punctuation split alike.
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// containsWord reports whether words holds term.
+func containsWord(words []string, term string) bool {
+	for _, word := range words {
+		if word == term {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPrefixWord reports whether a word of words starts with term.
+func hasPrefixWord(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyWord reports whether a word of words is within a typo of term: one edit from four
+// letters on, and two from seven, so "generator" finds "generate".
+func fuzzyWord(words []string, term string) bool {
+	allowed := 0
+	switch n := len([]rune(term)); {
+	case n >= 7:
+		allowed = 2
+	case n >= 4:
+		allowed = 1
+	default:
+		return false
+	}
+	for _, word := range words {
+		if editDistance(word, term, allowed) <= allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b, or more than limit as
+// soon as it is known to exceed it.
+func editDistance(a, b string, limit int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		best := current[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			best = min(best, current[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// toolSearchResult is a tool in the results of GET /api/tools and search_tools.
+type toolSearchResult struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Category    string                 `json:"category,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Score       int                    `json:"score"`
+}
+
+// searchResults converts search matches to the format they are returned in.
+func (s *ToolService) searchResults(matches []ToolMatch) []toolSearchResult {
+	results := make([]toolSearchResult, 0, len(matches))
+	for _, match := range matches {
+		category, tags := toolMetadata(match.Tool)
+		results = append(results, toolSearchResult{
+			Name:        match.Name,
+			Description: match.Tool.Description(),
+			Category:    category,
+			Tags:        tags,
+			InputSchema: s.InputSchema(match.Tool),
+			Score:       match.Score,
+		})
+	}
+	return results
+}
+
+// searchToolsTool finds tools by what they do and implements tools.ContextTool.
+type searchToolsTool struct {
+	service *ToolService
+}
+
+// Name returns the tool's name
+func (t *searchToolsTool) Name() string {
+	return searchToolsToolName
+}
+
+// Description returns the tool's description
+func (t *searchToolsTool) Description() string {
+	return "Searches the available tools by name, description, and tags, and returns the best matches with their input schemas. Use it to find a tool for a task when there are many"
+}
+
+// Category returns the tool's category
+func (t *searchToolsTool) Category() string {
+	return "utility"
+}
+
+// Tags returns the tool's tags
+func (t *searchToolsTool) Tags() []string {
+	return []string{"discovery", "search"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *searchToolsTool) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"query": map[string]interface{}{
+				"type":        "string",
+				"description": "Words describing the tool, such as \"convert currency\"",
+			},
+			"tag": map[string]interface{}{
+				"type":        "string",
+				"description": "Only return tools with this tag",
+			},
+			"category": map[string]interface{}{
+				"type":        "string",
+				"description": "Only return tools in this category",
+			},
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Most tools to return (at most %d)", maxSearchLimit),
+				"default":     defaultSearchLimit,
+				"minimum":     1,
+			},
+		},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *searchToolsTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext searches the tools the calling tenant may use
+func (t *searchToolsTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	query, _ := args["query"].(string)
+	filter := ToolFilter{Tenant: TenantFromContext(ctx)}
+	filter.Tag, _ = args["tag"].(string)
+	filter.Category, _ = args["category"].(string)
+	limit := defaultSearchLimit
+	if value, ok := args["limit"].(float64); ok && value >= 1 {
+		limit = min(int(value), maxSearchLimit)
+	}
+
+	matches := t.service.SearchTools(filter, query)
+	total := len(matches)
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return map[string]interface{}{
+		"tools": t.service.searchResults(matches),
+		"total": total,
+	}, nil
+}
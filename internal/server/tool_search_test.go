@@ -0,0 +1,104 @@
+package server
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestToolService_SearchTools(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	newService := func() *ToolService {
+		service := newTestToolService(logger,
+			&taggedMockTool{MockTool: MockTool{name: "generate_uuid", description: "Generates a random UUID v4 string"}, category: "generators", tags: []string{"uuid", "identifier"}},
+			&taggedMockTool{MockTool: MockTool{name: "currency_convert", description: "Converts an amount between currencies"}, category: "finance", tags: []string{"money"}},
+			&taggedMockTool{MockTool: MockTool{name: "text_stats", description: "Counts words, lines, and characters in text"}, category: "text", tags: []string{"statistics"}},
+			&taggedMockTool{MockTool: MockTool{name: "verify_checksum", description: "Verifies a file against a checksum"}, category: "security", tags: []string{"hash"}},
+		)
+		service.EnableToolSearch()
+		return service
+	}
+	names := func(matches []ToolMatch) []string {
+		var names []string
+		for _, match := range matches {
+			names = append(names, match.Name)
+		}
+		return names
+	}
+
+	t.Run("matches names, descriptions, and tags", func(t *testing.T) {
+		service := newService()
+		for query, expected := range map[string]string{
+			"convert currency": "currency_convert",
+			"uuid":             "generate_uuid",
+			"words":            "text_stats",
+			"hash":             "verify_checksum",
+			"money":            "currency_convert",
+		} {
+			if matches := names(service.SearchTools(ToolFilter{}, query)); len(matches) == 0 || matches[0] != expected {
+				t.Errorf("Expected %q to find %s first, got %v", query, expected, matches)
+			}
+		}
+	})
+
+	t.Run("tolerates typos", func(t *testing.T) {
+		service := newService()
+		for _, query := range []string{"curency", "checksun", "uuid generator"} {
+			if matches := service.SearchTools(ToolFilter{}, query); len(matches) != 1 {
+				t.Errorf("Expected one match for %q, got %v", query, names(matches))
+			}
+		}
+	})
+
+	t.Run("requires every word to match", func(t *testing.T) {
+		if matches := newService().SearchTools(ToolFilter{}, "uuid money"); len(matches) != 0 {
+			t.Errorf("Expected no matches, got %v", names(matches))
+		}
+	})
+
+	t.Run("filters by tag and category", func(t *testing.T) {
+		service := newService()
+		if matches := names(service.SearchTools(ToolFilter{Tag: "hash"}, "")); len(matches) != 1 || matches[0] != "verify_checksum" {
+			t.Errorf("Expected verify_checksum, got %v", matches)
+		}
+		if matches := names(service.SearchTools(ToolFilter{Category: "finance"}, "text")); len(matches) != 0 {
+			t.Errorf("Expected no matches, got %v", matches)
+		}
+	})
+
+	t.Run("search_tools returns matches with their schemas", func(t *testing.T) {
+		result, err := newService().ExecuteTool(searchToolsToolName, map[string]interface{}{"query": "convert", "limit": 1})
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		found := result["tools"].([]toolSearchResult)
+		if len(found) != 1 || found[0].Name != "currency_convert" || found[0].InputSchema == nil || result["total"] != 1 {
+			t.Errorf("Unexpected result: %v", result)
+		}
+	})
+
+	t.Run("GET /api/tools searches", func(t *testing.T) {
+		httpServer := NewHTTPServer(newService(), WithPort(8080), WithLogger(logger))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/tools?query=statistics&limit=5", nil))
+		var response struct {
+			Tools []toolSearchResult `json:"tools"`
+			Total int                `json:"total"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode %q: %v", w.Body.String(), err)
+		}
+		if w.Code != http.StatusOK || response.Total != 1 || response.Tools[0].Name != "text_stats" || response.Tools[0].Category != "text" {
+			t.Errorf("Unexpected response: %d %+v", w.Code, response)
+		}
+
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/v1/tools?limit=0", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid limit, got %d", w.Code)
+		}
+	})
+}
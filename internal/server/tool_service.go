@@ -1,40 +1,264 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
+	"time"
 
+	"mcp-tools-server/internal/policy"
+	"mcp-tools-server/internal/redact"
+	"mcp-tools-server/internal/store"
 	"mcp-tools-server/pkg/tools"
 )
 
-// ToolService handles the creation and execution of tools
+// ErrToolExists is returned when registering a tool whose name is already taken.
+var ErrToolExists = errors.New("tool already exists")
+
+// ToolService handles the creation and execution of tools. It also owns the resource and
+// prompt catalog and the set of active sessions shared by every transport.
 type ToolService struct {
-	tools  map[string]tools.Tool
-	logger *slog.Logger
+	tools       map[string]tools.Tool
+	added       map[string]bool // Tools registered at runtime with AddTool
+	catalog     *Catalog
+	sessions    *SessionManager
+	coordinator *store.Coordinator
+	logger      *slog.Logger
+
+	// Versions calls to versioned tools' bare names run, keyed by the name
+	defaultVersions map[string]string
+	// Additional names of tools, mapped to the names they stand for
+	aliases map[string]string
+	// Whether results that do not match their output schema fail
+	strictOutput bool
+	// Tools per tools/list page; zero lists every tool at once
+	listPageSize int
+	// Enabled transports, reported by server_info once the server starts
+	transports []TransportSummary
+
+	middleware   []ToolMiddleware
+	handler      ToolHandler
+	toolDefaults map[string]map[string]interface{}
+	quarantine   *Quarantine
+	breaker      *CircuitBreaker
+	throttle     *Throttle
+	overload     *OverloadDetector
+	health       *HealthMonitor
+	history      *ExecutionHistory
+	governor     *ResourceGovernor
+	jobs         *JobManager
+	approvals    *ApprovalGate
+	scheduler    *Scheduler
+	results      *ResultPager
+	redactor     *redact.Redactor
+	policy       policy.Engine
+	recorder     *SessionRecorder
+	logSafeMode  bool
+	events       *EventBus
+	mu           sync.RWMutex
 }
 
 // NewToolService creates a new ToolService
 func NewToolService(registry *tools.ToolRegistry, logger *slog.Logger) (*ToolService, error) {
-	service := &ToolService{
-		tools:  make(map[string]tools.Tool),
-		logger: logger,
-	}
-
 	availableTools, err := registry.CreateAllAvailable(logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create tools from registry: %w", err)
 	}
 
+	service := newToolService(logger, availableTools)
+	logger.Info("Registered tools", "count", len(service.tools))
+	return service, nil
+}
+
+// NewToolServiceWithTools creates a ToolService serving only the given tools, without the
+// registry's built-in tools. It suits tests that need a server with known tools.
+func NewToolServiceWithTools(availableTools []tools.Tool, logger *slog.Logger) *ToolService {
+	return newToolService(logger, availableTools)
+}
+
+// newToolService creates a ToolService serving the given, already constructed tools.
+func newToolService(logger *slog.Logger, availableTools []tools.Tool) *ToolService {
+	// A memory-backed coordinator serves a single instance until SetCoordinator is called.
+	coordinator := store.NewCoordinator(store.NewMemoryStore(), "local")
+	events := NewEventBus(logger)
+	events.Subscribe(recordToolExecution, EventToolExecuted)
+	sessions := NewSessionManager(coordinator, logger)
+	sessions.events = events
+	service := &ToolService{
+		tools:       make(map[string]tools.Tool),
+		added:       make(map[string]bool),
+		catalog:     NewCatalog(func(method string) { sessions.Notify(method, nil) }),
+		sessions:    sessions,
+		coordinator: coordinator,
+		events:      events,
+		logger:      logger,
+	}
 	for _, tool := range availableTools {
 		service.tools[tool.Name()] = tool
 	}
+	service.quarantine = NewQuarantine(service.lookupTool, logger)
+	service.quarantine.events = events
+	service.health = NewHealthMonitor(service.GetTools, logger)
+	service.health.events = events
+	service.health.onChange = func() { sessions.Notify("notifications/tools/list_changed", nil) }
+	service.breaker = NewCircuitBreaker(logger)
+	service.breaker.events = events
+	service.throttle = NewThrottle(logger)
+	service.overload = NewOverloadDetector(logger)
+	service.history = NewExecutionHistory(logger)
+	service.governor = NewResourceGovernor(logger)
+	service.jobs = NewJobManager(events, logger)
+	service.jobs.sessions = sessions
+	service.approvals = NewApprovalGate(service.lookupTool, service.jobs, sessions, logger)
+	service.approvals.events = events
+	service.scheduler = NewScheduler(service, logger)
+	service.results = NewResultPager()
+	service.buildHandler()
+	return service
+}
 
-	logger.Info("Registered tools", "count", len(service.tools))
-	return service, nil
+// Use appends middleware to the chain wrapping every tool call. Middleware runs in the
+// order added, between the built-in defaults and the events and quarantine layers. Call
+// it before serving requests.
+func (s *ToolService) Use(middleware ...ToolMiddleware) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.middleware = append(s.middleware, middleware...)
+	s.buildHandler()
+}
+
+// buildHandler recomputes the middleware chain. Callers hold s.mu or own s exclusively.
+func (s *ToolService) buildHandler() {
+	// The breaker and quarantine sit innermost so only the tool's own failures count
+	// against them, and history inside them so executions are recorded with the exact
+	// arguments the tool saw. Quota violations are the tool's failures too; throttled
+	// calls are not, so the throttle sits outside the breaker.
+	chain := append([]ToolMiddleware{s.overload.Middleware(), versionMiddleware(s.lookupTool, s.logger), defaultsMiddleware(s.ToolDefaults)}, s.middleware...)
+	// Calls the policy denies are never held for approval. Calls held for approval run the
+	// rest of the chain once approved, so they are only counted, throttled, and recorded
+	// when they actually run.
+	if s.policy != nil {
+		chain = append(chain, policyMiddleware(s.policy, s.logger))
+	}
+	// Results that do not match their output schema are the tool's failures.
+	chain = append(chain, argumentsMiddleware(s.lookupTool, s.InputSchema), s.approvals.Middleware(), eventsMiddleware(s.events), s.throttle.Middleware(), s.breaker.Middleware(), s.quarantine.Middleware(), s.history.Middleware(), s.governor.Middleware(), outputMiddleware(s.lookupTool, s.OutputSchema, s.StrictOutput, s.logger))
+	if s.redactor != nil {
+		// Innermost, so history, events, and every transport only see redacted output
+		chain = append(chain, redactMiddleware(s.redactor))
+	}
+	s.handler = chainToolMiddleware(s.execute, chain...)
+}
+
+// SetToolDefaults replaces the per-tool default arguments, keyed by tool name.
+func (s *ToolService) SetToolDefaults(defaults map[string]map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.toolDefaults = defaults
+}
+
+// ToolDefaults returns the configured default arguments for a tool. Versions of a tool
+// without defaults of their own share those of its bare name.
+func (s *ToolService) ToolDefaults(name string) map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if defaults, ok := s.toolDefaults[name]; ok {
+		return defaults
+	}
+	base, _ := tools.SplitVersion(name)
+	return s.toolDefaults[base]
+}
+
+// InputSchema returns the schema advertised for a tool, with any configured defaults
+// reflected as "default" values on the matching properties.
+func (s *ToolService) InputSchema(tool tools.Tool) map[string]interface{} {
+	// Tools without arguments are advertised with a generic object schema.
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{},
+	}
+	if provider, ok := tool.(tools.SchemaProvider); ok {
+		schema = provider.InputSchema()
+	}
+
+	defaults := s.ToolDefaults(tool.Name())
+	if len(defaults) == 0 {
+		return schema
+	}
+
+	// Copy the schema so the tool's own definition is never mutated.
+	withDefaults := make(map[string]interface{}, len(schema))
+	for key, value := range schema {
+		withDefaults[key] = value
+	}
+	properties := make(map[string]interface{})
+	if existing, ok := schema["properties"].(map[string]interface{}); ok {
+		for key, value := range existing {
+			properties[key] = value
+		}
+	}
+	for key, value := range defaults {
+		property := make(map[string]interface{})
+		if existing, ok := properties[key].(map[string]interface{}); ok {
+			for k, v := range existing {
+				property[k] = v
+			}
+		}
+		property["default"] = value
+		properties[key] = property
+	}
+	withDefaults["properties"] = properties
+	return withDefaults
+}
+
+// AddTool registers a tool while the server is running and sends
+// notifications/tools/list_changed to live sessions. It fails if the name is taken.
+func (s *ToolService) AddTool(tool tools.Tool) error {
+	s.mu.Lock()
+	if _, exists := s.tools[tool.Name()]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrToolExists, tool.Name())
+	}
+	s.tools[tool.Name()] = tool
+	s.added[tool.Name()] = true
+	s.mu.Unlock()
+
+	s.logger.Info("Tool registered", "tool", tool.Name())
+	s.sessions.Notify("notifications/tools/list_changed", nil)
+	return nil
+}
+
+// RemoveTool unregisters a tool added with AddTool, reporting whether it existed. Tools
+// built into the server cannot be removed.
+func (s *ToolService) RemoveTool(name string) bool {
+	s.mu.Lock()
+	if !s.added[name] {
+		s.mu.Unlock()
+		return false
+	}
+	delete(s.tools, name)
+	delete(s.added, name)
+	s.mu.Unlock()
+
+	s.logger.Info("Tool unregistered", "tool", name)
+	s.sessions.Notify("notifications/tools/list_changed", nil)
+	return true
+}
+
+// lookupTool returns the named tool.
+func (s *ToolService) lookupTool(name string) (tools.Tool, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	tool, ok := s.tools[name]
+	return tool, ok
 }
 
 // ListTools returns a map of tool names to their descriptions
 func (s *ToolService) ListTools() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	toolList := make(map[string]string)
 	for name, tool := range s.tools {
 		toolList[name] = tool.Description()
@@ -42,26 +266,298 @@ func (s *ToolService) ListTools() map[string]string {
 	return toolList
 }
 
+// ToolFilter selects tools by their optional metadata and the calling tenant's
+// allow-list. Empty fields match every tool.
+type ToolFilter struct {
+	Category string
+	Tag      string
+	Tenant   *Tenant
+}
+
+// toolMetadata returns a tool's category and tags, or empty values for tools that do not
+// implement tools.MetadataProvider.
+func toolMetadata(tool tools.Tool) (string, []string) {
+	if provider, ok := tool.(tools.MetadataProvider); ok {
+		return provider.Category(), provider.Tags()
+	}
+	return "", nil
+}
+
+// Matches reports whether the tool satisfies the filter.
+func (f ToolFilter) Matches(tool tools.Tool) bool {
+	if !f.Tenant.Allows(tool.Name()) {
+		return false
+	}
+	category, tags := toolMetadata(tool)
+	if f.Category != "" && f.Category != category {
+		return false
+	}
+	if f.Tag == "" {
+		return true
+	}
+	for _, tag := range tags {
+		if tag == f.Tag {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTools returns the tools matching filter ordered by name. Tools failing their
+// health check are left out.
+func (s *ToolService) FilterTools(filter ToolFilter) []tools.Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	matched := make([]tools.Tool, 0, len(s.tools))
+	for _, tool := range s.tools {
+		if filter.Matches(tool) && s.health.Healthy(tool.Name()) {
+			matched = append(matched, tool)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Name() < matched[j].Name() })
+	return matched
+}
+
 // ExecuteTool executes a tool with the given name and arguments
 func (s *ToolService) ExecuteTool(name string, args map[string]interface{}) (map[string]interface{}, error) {
-	tool, exists := s.tools[name]
+	return s.ExecuteToolContext(context.Background(), name, args)
+}
+
+// ExecuteToolContext executes a tool through the middleware chain. Aliases run the tool
+// they stand for, and a versioned tool's bare name runs its default version.
+func (s *ToolService) ExecuteToolContext(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	if resolved, ok := s.Resolve(name); ok {
+		name = resolved
+	}
+	s.mu.RLock()
+	handler := s.handler
+	s.mu.RUnlock()
+	return handler(ctx, name, args)
+}
+
+// ExecuteToolAwaitingApproval executes a tool like ExecuteToolContext, but a call held for
+// approval waits until it is decided, or ctx is done, and returns the approved call's
+// result. Callers that use the result rather than hand it to a client, such as pipelines,
+// call it so they never take the pending_approval answer for the result.
+func (s *ToolService) ExecuteToolAwaitingApproval(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	result, err := s.ExecuteToolContext(ctx, name, args)
+	return s.awaitApproval(ctx, result, err)
+}
+
+// awaitApproval returns the outcome of a call once it is decided if the call was held for
+// approval, waiting until ctx is done. Other outcomes are returned unchanged.
+func (s *ToolService) awaitApproval(ctx context.Context, result map[string]interface{}, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	id, held := heldJobID(result)
+	if !held {
+		return result, nil
+	}
+	loggerFor(ctx, s.logger).Info("Waiting for a held call to be decided", "jobId", id)
+	return s.Jobs().Await(ctx, id)
+}
+
+// execute runs the tool itself and is the innermost ToolHandler.
+func (s *ToolService) execute(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	tool, exists := s.lookupTool(name)
 	if !exists {
-		return nil, fmt.Errorf("tool not found: %s", name)
+		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
 	}
 
-	result, err := tool.Execute(args)
+	logger := loggerFor(ctx, s.logger)
+	logger.Debug("Executing tool", "tool", name, "arguments", s.LogPayload(args))
+	var result map[string]interface{}
+	var err error
+	if contextTool, ok := tool.(tools.ContextTool); ok {
+		result, err = contextTool.ExecuteContext(ctx, args)
+	} else {
+		result, err = tool.Execute(args)
+	}
 	if err != nil {
-		s.logger.Error("Tool execution failed", "tool", name, "error", err)
+		logger.Error("Tool execution failed", "tool", name, "error", err)
 		return nil, err
 	}
 
 	// Log the result for cross-verification
-	s.logger.Info("Tool executed successfully", "tool", name, "result", result)
+	logger.Info("Tool executed successfully", "tool", name, "result", s.LogPayload(result))
 
 	return result, nil
 }
 
-// GetTools returns the map of tools
+// WarmUp builds every tool whose construction was deferred, in parallel, and returns once
+// all builds have finished. Tools that fail to build are retried on first use.
+func (s *ToolService) WarmUp() {
+	var wg sync.WaitGroup
+	for _, tool := range s.GetTools() {
+		lazy, ok := tool.(*tools.LazyTool)
+		if !ok || lazy.Built() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = lazy.Build()
+		}()
+	}
+	wg.Wait()
+}
+
+// GetTools returns a snapshot of the tools keyed by name
 func (s *ToolService) GetTools() map[string]tools.Tool {
-	return s.tools
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot := make(map[string]tools.Tool, len(s.tools))
+	for name, tool := range s.tools {
+		snapshot[name] = tool
+	}
+	return snapshot
+}
+
+// Catalog returns the resources and prompts offered to clients
+func (s *ToolService) Catalog() *Catalog {
+	return s.catalog
+}
+
+// Sessions returns the active MCP sessions across all transports
+func (s *ToolService) Sessions() *SessionManager {
+	return s.sessions
+}
+
+// Quarantine returns the guard that disables tools after repeated failures
+func (s *ToolService) Quarantine() *Quarantine {
+	return s.quarantine
+}
+
+// Health returns the monitor that runs tool health checks
+func (s *ToolService) Health() *HealthMonitor {
+	return s.health
+}
+
+// Breaker returns the circuit breaker that short-circuits tools after consecutive failures
+func (s *ToolService) Breaker() *CircuitBreaker {
+	return s.breaker
+}
+
+// Throttle returns the enforcer of per-tool rate and concurrency limits
+func (s *ToolService) Throttle() *Throttle {
+	return s.throttle
+}
+
+// Overload returns the detector that sheds new sessions while the server is saturated
+func (s *ToolService) Overload() *OverloadDetector {
+	return s.overload
+}
+
+// History returns the record of recent tool executions
+func (s *ToolService) History() *ExecutionHistory {
+	return s.history
+}
+
+// Governor returns the enforcer of per-tool resource quotas
+func (s *ToolService) Governor() *ResourceGovernor {
+	return s.governor
+}
+
+// Jobs returns the manager of tool calls running in the background
+func (s *ToolService) Jobs() *JobManager {
+	return s.jobs
+}
+
+// Approvals returns the gate holding calls to tools that need operator approval.
+func (s *ToolService) Approvals() *ApprovalGate {
+	return s.approvals
+}
+
+// Scheduler returns the scheduler that runs tools on cron schedules
+func (s *ToolService) Scheduler() *Scheduler {
+	return s.scheduler
+}
+
+// SetPolicy evaluates every tool call against engine before it is held for approval or
+// runs. A nil engine turns policy evaluation off. Call it before serving requests.
+func (s *ToolService) SetPolicy(engine policy.Engine) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = engine
+	s.buildHandler()
+}
+
+// SetRedactor redacts tool results and error messages with r before anything else sees
+// them. A nil Redactor turns redaction off. Call it before serving requests.
+func (s *ToolService) SetRedactor(r *redact.Redactor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.redactor = r
+	s.buildHandler()
+}
+
+// SetRecorder records the JSON-RPC traffic of every session with r. A nil recorder turns
+// recording off. Call it before serving requests.
+func (s *ToolService) SetRecorder(r *SessionRecorder) {
+	s.mu.Lock()
+	s.recorder = r
+	s.mu.Unlock()
+	s.sessions.SetRecorder(r)
+}
+
+// Recorder returns the session recorder, or nil when recording is off.
+func (s *ToolService) Recorder() *SessionRecorder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recorder
+}
+
+// SetLogSafeMode sets whether tool arguments and results are logged as a hash and size
+// rather than in full, so sensitive tool data never reaches the logs.
+func (s *ToolService) SetLogSafeMode(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.logSafeMode = enabled
+}
+
+// LogPayload returns a log value for tool arguments or a tool result, following the
+// safe logging mode.
+func (s *ToolService) LogPayload(value map[string]interface{}) slog.LogValuer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return logPayload{value: value, safe: s.logSafeMode}
+}
+
+// Results returns the pager that keeps tool results under the size limit
+func (s *ToolService) Results() *ResultPager {
+	return s.results
+}
+
+// SetResultLimit caps the encoded size of tool results returned to clients at maxBytes,
+// with cursors for the rest of a paged result kept for cursorTTL. A positive limit also
+// registers the next_page tool. Call it before serving requests.
+func (s *ToolService) SetResultLimit(maxBytes int, cursorTTL time.Duration) {
+	s.results.SetLimits(maxBytes, cursorTTL)
+	if maxBytes <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.tools[nextPageToolName]; !exists {
+		s.tools[nextPageToolName] = &nextPageTool{pager: s.results}
+	}
+}
+
+// Events returns the bus on which tool executions, session lifecycle changes, and
+// transport errors are published
+func (s *ToolService) Events() *EventBus {
+	return s.events
+}
+
+// Coordinator returns the cross-replica coordinator backed by the shared store
+func (s *ToolService) Coordinator() *store.Coordinator {
+	return s.coordinator
+}
+
+// SetCoordinator replaces the cross-replica coordinator, typically with one backed by
+// Redis when several replicas run behind a load balancer. Call it before serving requests.
+func (s *ToolService) SetCoordinator(coordinator *store.Coordinator) {
+	s.coordinator = coordinator
+	s.sessions.SetCoordinator(coordinator)
 }
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-tools-server/internal/redact"
+	"mcp-tools-server/pkg/tools"
+)
+
+func TestToolService_Middleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("middleware runs in the order added", func(t *testing.T) {
+		service := newTestToolService(logger, &MockTool{name: "echo"})
+		var order []string
+		record := func(label string) ToolMiddleware {
+			return func(next ToolHandler) ToolHandler {
+				return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+					order = append(order, label)
+					return next(ctx, name, args)
+				}
+			}
+		}
+		service.Use(record("first"), record("second"))
+
+		if _, err := service.ExecuteTool("echo", nil); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+			t.Errorf("Expected [first second], got %v", order)
+		}
+	})
+
+	t.Run("unknown tool still fails through the chain", func(t *testing.T) {
+		service := newTestToolService(logger)
+		if _, err := service.ExecuteTool("missing", nil); err == nil {
+			t.Error("Expected error for unknown tool")
+		}
+	})
+}
+
+func TestToolService_ToolDefaults(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	var received map[string]interface{}
+	echo := &MockTool{
+		name: "echo",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			received = args
+			return map[string]interface{}{"ok": true}, nil
+		},
+	}
+	service := newTestToolService(logger, echo)
+	service.SetToolDefaults(map[string]map[string]interface{}{
+		"echo": {"version": "v7", "count": 3},
+	})
+
+	t.Run("defaults fill missing arguments", func(t *testing.T) {
+		if _, err := service.ExecuteTool("echo", nil); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if received["version"] != "v7" || received["count"] != 3 {
+			t.Errorf("Expected defaults to be applied, got %v", received)
+		}
+	})
+
+	t.Run("client arguments override defaults", func(t *testing.T) {
+		if _, err := service.ExecuteTool("echo", map[string]interface{}{"version": "v4"}); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if received["version"] != "v4" || received["count"] != 3 {
+			t.Errorf("Expected client value to win, got %v", received)
+		}
+	})
+
+	t.Run("schema advertises defaults without mutating the tool schema", func(t *testing.T) {
+		schema := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"version": map[string]interface{}{"type": "string"},
+			},
+		}
+		tool := &schemaMockTool{MockTool: MockTool{name: "echo"}, schema: schema}
+
+		advertised := service.InputSchema(tool)
+
+		property := advertised["properties"].(map[string]interface{})["version"].(map[string]interface{})
+		if property["default"] != "v7" || property["type"] != "string" {
+			t.Errorf("Expected version default v7 with type preserved, got %v", property)
+		}
+		original := schema["properties"].(map[string]interface{})["version"].(map[string]interface{})
+		if _, ok := original["default"]; ok {
+			t.Error("Expected the tool's own schema to be left unchanged")
+		}
+	})
+}
+
+// coercingTool is a schemaMockTool that receives its arguments as Go types.
+type coercingTool struct{ schemaMockTool }
+
+func (t *coercingTool) CoercesArgs() bool { return true }
+
+func TestToolService_Arguments(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count": map[string]interface{}{"type": "integer"},
+			"since": map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required": []string{"count"},
+	}
+	var received map[string]interface{}
+	record := func(args map[string]interface{}) (map[string]interface{}, error) {
+		received = args
+		return map[string]interface{}{"ok": true}, nil
+	}
+	service := newTestToolService(logger,
+		&schemaMockTool{MockTool: MockTool{name: "plain", executeFunc: record}, schema: schema},
+		&coercingTool{schemaMockTool{MockTool: MockTool{name: "typed", executeFunc: record}, schema: schema}},
+	)
+	args := map[string]interface{}{"count": "3", "since": "2025-01-02T00:00:00Z"}
+
+	t.Run("validates arguments for every tool", func(t *testing.T) {
+		if _, err := service.ExecuteTool("plain", args); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if received["count"] != float64(3) || received["since"] != "2025-01-02T00:00:00Z" {
+			t.Errorf("Expected JSON types, got %#v", received)
+		}
+		if _, err := service.ExecuteTool("plain", map[string]interface{}{"count": "three"}); !errors.Is(err, tools.ErrInvalidArguments) {
+			t.Errorf("Expected ErrInvalidArguments, got %v", err)
+		}
+		if _, err := service.ExecuteTool("plain", nil); !errors.Is(err, tools.ErrInvalidArguments) {
+			t.Errorf("Expected ErrInvalidArguments for a missing required argument, got %v", err)
+		}
+	})
+
+	t.Run("coerces arguments for tools that ask", func(t *testing.T) {
+		if _, err := service.ExecuteTool("typed", args); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if received["count"] != int64(3) {
+			t.Errorf("Expected an int64, got %#v", received["count"])
+		}
+		if since, ok := received["since"].(time.Time); !ok || since.Year() != 2025 {
+			t.Errorf("Expected a time.Time, got %#v", received["since"])
+		}
+	})
+
+	t.Run("invalid arguments are invalid params over JSON-RPC", func(t *testing.T) {
+		processor := NewJSONRPCProcessor(service, logger)
+		response := processor.HandleToolsCall(context.Background(), map[string]interface{}{"name": "plain"}, 1)
+		if response.Error == nil || response.Error.Code != -32602 || !strings.Contains(response.Error.Message, "count is required") {
+			t.Errorf("Expected an invalid params error, got %+v", response.Error)
+		}
+	})
+}
+
+func TestToolService_Redactor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	errDenied := errors.New("denied")
+	service := newTestToolService(logger,
+		&MockTool{name: "lookup", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"owner": "jane@example.com"}, nil
+		}},
+		&MockTool{name: "broken", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, fmt.Errorf("login jane@example.com: %w", errDenied)
+		}},
+	)
+	rules, err := redact.Rules([]string{"email"}, nil)
+	if err != nil {
+		t.Fatalf("Rules failed: %v", err)
+	}
+	service.SetRedactor(redact.New(rules))
+
+	t.Run("results are redacted", func(t *testing.T) {
+		result, err := service.ExecuteTool("lookup", nil)
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if result["owner"] != "[REDACTED:email]" {
+			t.Errorf("Expected owner redacted, got %v", result["owner"])
+		}
+	})
+
+	t.Run("error messages are redacted and still match", func(t *testing.T) {
+		_, err := service.ExecuteTool("broken", nil)
+		if err == nil || err.Error() != "login [REDACTED:email]: denied" {
+			t.Errorf("Expected redacted error, got %v", err)
+		}
+		if !errors.Is(err, errDenied) {
+			t.Error("Expected errors.Is to match the original error")
+		}
+	})
+}
+
+func TestToolService_LogSafeMode(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	service := newTestToolService(logger, &MockTool{name: "lookup", executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"owner": "jane"}, nil
+	}})
+
+	t.Run("full payloads are logged by default", func(t *testing.T) {
+		buf.Reset()
+		if _, err := service.ExecuteTool("lookup", map[string]interface{}{"query": "ssn"}); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		if out := buf.String(); !strings.Contains(out, "ssn") || !strings.Contains(out, "jane") {
+			t.Errorf("Expected arguments and result in logs, got %s", out)
+		}
+	})
+
+	t.Run("safe mode logs hashes and sizes", func(t *testing.T) {
+		service.SetLogSafeMode(true)
+		buf.Reset()
+		if _, err := service.ExecuteTool("lookup", map[string]interface{}{"query": "ssn"}); err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		out := buf.String()
+		if strings.Contains(out, "ssn") || strings.Contains(out, "jane") {
+			t.Errorf("Expected no tool data in logs, got %s", out)
+		}
+		if !strings.Contains(out, "result.sha256=") || !strings.Contains(out, "result.bytes=16") || !strings.Contains(out, "arguments.bytes=15") {
+			t.Errorf("Expected hashes and sizes in logs, got %s", out)
+		}
+	})
+}
+
+// taggedMockTool is a MockTool with category and tag metadata.
+type taggedMockTool struct {
+	MockTool
+	category string
+	tags     []string
+}
+
+func (m *taggedMockTool) Category() string { return m.category }
+
+func (m *taggedMockTool) Tags() []string { return m.tags }
+
+func TestToolService_FilterTools(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger,
+		&taggedMockTool{MockTool: MockTool{name: "b_hash"}, category: "security", tags: []string{"hash"}},
+		&taggedMockTool{MockTool: MockTool{name: "a_lookup"}, category: "network", tags: []string{"ip", "dns"}},
+		&MockTool{name: "plain"},
+	)
+
+	tests := []struct {
+		name     string
+		filter   ToolFilter
+		expected []string
+	}{
+		{"empty filter matches all in name order", ToolFilter{}, []string{"a_lookup", "b_hash", "plain"}},
+		{"category", ToolFilter{Category: "security"}, []string{"b_hash"}},
+		{"tag", ToolFilter{Tag: "dns"}, []string{"a_lookup"}},
+		{"category and tag must both match", ToolFilter{Category: "security", Tag: "dns"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := service.FilterTools(tt.filter)
+			if len(matched) != len(tt.expected) {
+				t.Fatalf("Expected %v, got %d tools", tt.expected, len(matched))
+			}
+			for i, tool := range matched {
+				if tool.Name() != tt.expected[i] {
+					t.Errorf("Expected %s at %d, got %s", tt.expected[i], i, tool.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestToolService_WarmUp(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	lazy := tools.NewLazyTool(&MockTool{name: "slow"}, func() (tools.Tool, error) {
+		return &MockTool{name: "slow"}, nil
+	}, logger)
+	failing := tools.NewLazyTool(&MockTool{name: "broken"}, func() (tools.Tool, error) {
+		return nil, errors.New("connection refused")
+	}, logger)
+	service := newTestToolService(logger, lazy, failing, &MockTool{name: "echo"})
+
+	service.WarmUp()
+	if !lazy.Built() {
+		t.Error("Expected warm-up to build the lazy tool")
+	}
+	if failing.Built() {
+		t.Error("Expected the failing tool to stay unbuilt")
+	}
+	if _, err := service.ExecuteTool("broken", nil); err == nil {
+		t.Error("Expected calls to the failing tool to fail")
+	}
+}
+
+func BenchmarkToolService_ExecuteTool(b *testing.B) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	service := newTestToolService(logger, &MockTool{
+		name: "echo",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"echo": args["text"]}, nil
+		},
+	})
+	args := map[string]interface{}{"text": "hello"}
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := service.ExecuteTool("echo", args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// ndjsonContentType is the media type of newline-delimited JSON.
+const ndjsonContentType = "application/x-ndjson"
+
+// handleToolStream handles POST /api/tools/{name}/stream requests. It runs the tool like
+// handleToolCall, but streams its output as newline-delimited JSON: a "progress" line
+// for each progress report and a "partial" line for each partial result, as the tool
+// emits them, then a "result" line with the final result, or an "error" line. A call that
+// fails before any output gets an ordinary error response instead.
+func (s *HTTPServer) handleToolStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(w, r, http.StatusInternalServerError, errCodeNotImplemented, "Streaming is not supported")
+		return
+	}
+	if !accepts(r.Header.Get("Accept"), ndjsonContentType) {
+		s.writeError(w, r, http.StatusNotAcceptable, errCodeNotAcceptable, "The stream is only available as "+ndjsonContentType)
+		return
+	}
+	name, args, ok := s.toolCallArgs(w, r)
+	if !ok {
+		return
+	}
+
+	// The call may outlast the server's write timeout, so lift the deadline for it.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	stream := &ndjsonStream{w: w, flusher: flusher}
+	result, err := s.toolService.ExecuteToolContext(tools.WithStreamEmitter(r.Context(), stream), name, args)
+	if err != nil {
+		status, code, message := s.toolCallError(r, name, err)
+		failed := map[string]interface{}{"type": "error", "error": apiError{
+			Code:      code,
+			Message:   message,
+			RequestID: RequestIDFromContext(r.Context()),
+		}}
+		if !stream.fail(failed) {
+			s.writeError(w, r, status, code, message)
+		}
+		return
+	}
+	stream.finish(map[string]interface{}{"type": "result", "result": s.toolService.Results().Limit(normalizeToolResult(result))})
+}
+
+// ndjsonStream is the tools.StreamEmitter of a streamed tool call, writing each emission
+// as a line of JSON. The response starts with the first line, and lines emitted once the
+// call has finished, as a tool abandoned past its deadline may, are dropped.
+type ndjsonStream struct {
+	mu      sync.Mutex
+	w       http.ResponseWriter
+	flusher http.Flusher
+	started bool  // The response has been started
+	closed  bool  // The call has finished; no more lines are written
+	err     error // Error of the last write; the client has likely gone
+}
+
+// Progress writes a "progress" line.
+func (n *ndjsonStream) Progress(progress, total float64, message string) {
+	line := map[string]interface{}{"type": "progress", "progress": progress}
+	if total > 0 {
+		line["total"] = total
+	}
+	if message != "" {
+		line["message"] = message
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.write(line)
+}
+
+// Partial writes a "partial" line with the partial result, normalized like a final one.
+func (n *ndjsonStream) Partial(result map[string]interface{}) {
+	line := map[string]interface{}{"type": "partial", "result": normalizeToolResult(result)}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.write(line)
+}
+
+// finish writes the last line of the stream.
+func (n *ndjsonStream) finish(line interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.write(line)
+	n.closed = true
+}
+
+// fail ends a stream that has started with line and reports whether it had started. A
+// stream that has not is left for the caller to answer with an error response.
+func (n *ndjsonStream) fail(line interface{}) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.started {
+		n.write(line)
+	}
+	n.closed = true
+	return n.started
+}
+
+// write writes a line, starting the response if it has not started. The caller holds
+// n.mu.
+func (n *ndjsonStream) write(line interface{}) {
+	if n.closed || n.err != nil {
+		return
+	}
+	encoded, err := json.Marshal(line)
+	if err != nil {
+		encoded, _ = json.Marshal(map[string]interface{}{"type": "error", "error": apiError{
+			Code:    errCodeToolFailed,
+			Message: "Tool output could not be encoded as JSON",
+		}})
+	}
+	if !n.started {
+		n.w.Header().Set("Content-Type", ndjsonContentType)
+		n.w.Header().Set("Cache-Control", "no-cache")
+		n.w.WriteHeader(http.StatusOK)
+		n.started = true
+	}
+	if _, n.err = n.w.Write(append(encoded, '\n')); n.err == nil {
+		n.flusher.Flush()
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+func TestHTTPServer_handleToolStream(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	streaming := &contextMockTool{
+		MockTool: MockTool{name: "count"},
+		executeContext: func(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+			for i := 1; i <= 2; i++ {
+				tools.ReportProgress(ctx, float64(i), 2, "counting")
+				tools.EmitPartial(ctx, map[string]interface{}{"n": i})
+			}
+			if args["fail"] == true {
+				return nil, errors.New("boom")
+			}
+			return map[string]interface{}{"total": 2}, nil
+		},
+	}
+	failing := &MockTool{
+		name: "fail",
+		executeFunc: func(args map[string]interface{}) (map[string]interface{}, error) {
+			return nil, errors.New("boom")
+		},
+	}
+	httpServer := NewHTTPServer(newTestToolService(logger, streaming, failing, &MockTool{name: "plain"}), WithPort(8080), WithLogger(logger))
+
+	// stream calls a tool's stream endpoint and returns the response and its decoded lines
+	stream := func(t *testing.T, tool, body string) (*httptest.ResponseRecorder, []map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/tools/"+tool+"/stream", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+
+		var lines []map[string]interface{}
+		scanner := bufio.NewScanner(w.Body)
+		for scanner.Scan() {
+			var line map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+				t.Fatalf("Invalid NDJSON line %q: %v", scanner.Text(), err)
+			}
+			lines = append(lines, line)
+		}
+		return w, lines
+	}
+
+	t.Run("streams progress, partial results, and the result", func(t *testing.T) {
+		w, lines := stream(t, "count", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != ndjsonContentType {
+			t.Errorf("Expected Content-Type %s, got %s", ndjsonContentType, ct)
+		}
+		var types []string
+		for _, line := range lines {
+			types = append(types, line["type"].(string))
+		}
+		if got := strings.Join(types, ","); got != "progress,partial,progress,partial,result" {
+			t.Fatalf("Unexpected line types: %s", got)
+		}
+		if lines[0]["progress"] != 1.0 || lines[0]["total"] != 2.0 || lines[0]["message"] != "counting" {
+			t.Errorf("Unexpected progress line: %v", lines[0])
+		}
+		if partial := lines[1]["result"].(map[string]interface{}); partial["n"] != 1.0 {
+			t.Errorf("Unexpected partial line: %v", lines[1])
+		}
+		if result := lines[4]["result"].(map[string]interface{}); result["total"] != 2.0 {
+			t.Errorf("Unexpected result line: %v", lines[4])
+		}
+	})
+
+	t.Run("ends a started stream with an error line", func(t *testing.T) {
+		_, lines := stream(t, "count", `{"fail": true}`)
+		last := lines[len(lines)-1]
+		failure, _ := last["error"].(map[string]interface{})
+		if last["type"] != "error" || failure["code"] != errCodeToolFailed {
+			t.Errorf("Expected an error line, got %v", last)
+		}
+	})
+
+	t.Run("non-streaming tools send only the result", func(t *testing.T) {
+		_, lines := stream(t, "plain", "")
+		if len(lines) != 1 || lines[0]["type"] != "result" {
+			t.Errorf("Expected a single result line, got %v", lines)
+		}
+	})
+
+	t.Run("failures before any output get an error response", func(t *testing.T) {
+		w, _ := stream(t, "fail", "")
+		if w.Code != http.StatusInternalServerError || w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON error response, got %d %s", w.Code, w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("unknown tools are not found", func(t *testing.T) {
+		if w, _ := stream(t, "missing", ""); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("requires Accept to allow NDJSON", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/api/v1/tools/count/stream", nil)
+		req.Header.Set("Accept", "application/json")
+		w := httptest.NewRecorder()
+		httpServer.server.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotAcceptable {
+			t.Errorf("Expected status 406, got %d", w.Code)
+		}
+	})
+}
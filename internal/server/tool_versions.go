@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"sort"
+
+	"mcp-tools-server/pkg/tools"
+)
+
+// SetDefaultVersions sets the version calls to a versioned tool's bare name run, keyed
+// by the name, such as {"convert": "v1"}. Tools without an entry, or whose entry names a
+// version that is not registered, default to their newest version that is not deprecated.
+func (s *ToolService) SetDefaultVersions(versions map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaultVersions = versions
+}
+
+// Resolve returns the registered name of the tool a call to name runs: name itself when
+// a tool is registered under it, the tool an alias stands for, or the default version of
+// a versioned tool called by its bare name. It reports false when there is no such tool.
+func (s *ToolService) Resolve(name string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.tools[name]; ok {
+		return name, true
+	}
+	if target, ok := s.aliases[name]; ok {
+		name = target
+		if _, ok := s.tools[name]; ok {
+			return name, true
+		}
+	}
+	if _, version := tools.SplitVersion(name); version != "" {
+		return "", false
+	}
+	resolved, ok := s.defaultVersion(name)
+	return resolved, ok
+}
+
+// DefaultVersions returns the default version of every versioned tool, keyed by its bare
+// name. Tools registered under a bare name as well are left out, since that tool is the
+// one the name runs.
+func (s *ToolService) DefaultVersions() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	defaults := make(map[string]string)
+	for name := range s.tools {
+		base, version := tools.SplitVersion(name)
+		if version == "" || defaults[base] != "" {
+			continue
+		}
+		if _, ok := s.tools[base]; ok {
+			continue
+		}
+		defaults[base], _ = s.defaultVersion(base)
+	}
+	return defaults
+}
+
+// defaultVersion returns the registered name of the default version of the tool named
+// base. Callers hold s.mu.
+func (s *ToolService) defaultVersion(base string) (string, bool) {
+	if version, ok := s.defaultVersions[base]; ok {
+		if _, registered := s.tools[base+tools.VersionSeparator+version]; registered {
+			return base + tools.VersionSeparator + version, true
+		}
+	}
+	var best, bestVersion string
+	bestDeprecated := true
+	for name, tool := range s.tools {
+		toolBase, version := tools.SplitVersion(name)
+		if toolBase != base || version == "" {
+			continue
+		}
+		deprecated := deprecation(tool) != ""
+		newer := best == "" || tools.CompareVersions(version, bestVersion) > 0
+		if (bestDeprecated && !deprecated) || (deprecated == bestDeprecated && newer) {
+			best, bestVersion, bestDeprecated = name, version, deprecated
+		}
+	}
+	return best, best != ""
+}
+
+// deprecation returns a tool's deprecation notice, or an empty string if it is current.
+func deprecation(tool tools.Tool) string {
+	if provider, ok := tool.(tools.DeprecationProvider); ok {
+		return provider.Deprecation()
+	}
+	return ""
+}
+
+// versionMiddleware returns a ToolMiddleware that reports which version of a versioned
+// tool ran, and any deprecation notice, in the result's _meta, so callers of a bare name
+// or a deprecated version learn about it.
+func versionMiddleware(lookup func(name string) (tools.Tool, bool), logger *slog.Logger) ToolMiddleware {
+	return func(next ToolHandler) ToolHandler {
+		return func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+			result, err := next(ctx, name, args)
+			if err != nil {
+				return result, err
+			}
+			tool, ok := lookup(name)
+			if !ok {
+				return result, nil
+			}
+			_, version := tools.SplitVersion(name)
+			notice := deprecation(tool)
+			if version == "" && notice == "" {
+				return result, nil
+			}
+
+			meta := make(map[string]interface{})
+			if existing, ok := result["_meta"].(map[string]interface{}); ok {
+				maps.Copy(meta, existing)
+			}
+			if version != "" {
+				meta["tool"] = name
+				meta["version"] = version
+			}
+			if notice != "" {
+				meta["deprecated"] = true
+				meta["deprecationNotice"] = notice
+				loggerFor(ctx, logger).Info("Deprecated tool called", "tool", name, "notice", notice)
+			}
+			annotated := maps.Clone(result)
+			if annotated == nil {
+				annotated = make(map[string]interface{})
+			}
+			annotated["_meta"] = meta
+			return annotated, nil
+		}
+	}
+}
+
+// ListedTool is a tool under a name it is listed and called by. The name is the tool's
+// own, an alias of it, or the bare name of a versioned tool for its default version.
+type ListedTool struct {
+	Name string
+	Tool tools.Tool
+}
+
+// Version returns the version of the listed tool, or an empty string if it has none.
+func (t ListedTool) Version() string {
+	_, version := tools.SplitVersion(t.Tool.Name())
+	return version
+}
+
+// ListedTools returns the tools matching filter, as FilterTools does, and the aliases and
+// versioned tools' bare names that stand for one of them, ordered by name.
+func (s *ToolService) ListedTools(filter ToolFilter) []ListedTool {
+	matched := s.FilterTools(filter)
+	listed := make([]ListedTool, 0, len(matched))
+	byName := make(map[string]tools.Tool, len(matched))
+	for _, tool := range matched {
+		listed = append(listed, ListedTool{Name: tool.Name(), Tool: tool})
+		byName[tool.Name()] = tool
+	}
+	for base, name := range s.DefaultVersions() {
+		if tool, ok := byName[name]; ok {
+			listed = append(listed, ListedTool{Name: base, Tool: tool})
+		}
+	}
+	for alias := range s.Aliases() {
+		name, ok := s.Resolve(alias)
+		if tool, matched := byName[name]; ok && matched && name != alias {
+			listed = append(listed, ListedTool{Name: alias, Tool: tool})
+		}
+	}
+	sort.Slice(listed, func(i, j int) bool { return listed[i].Name < listed[j].Name })
+	return listed
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+// deprecatedTool is a MockTool with a deprecation notice.
+type deprecatedTool struct {
+	MockTool
+	notice string
+}
+
+func (d *deprecatedTool) Deprecation() string { return d.notice }
+
+func TestToolService_Versions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	version := func(name string) *MockTool {
+		return &MockTool{name: name, description: "Converts " + name, executeFunc: func(map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"ran": name}, nil
+		}}
+	}
+	newService := func() *ToolService {
+		return newTestToolService(logger,
+			&deprecatedTool{MockTool: *version("convert@v1"), notice: "Use convert@v2"},
+			version("convert@v2"),
+			&deprecatedTool{MockTool: *version("convert@v3"), notice: "Experimental, do not use"},
+			version("plain"),
+		)
+	}
+
+	t.Run("bare names run the newest current version", func(t *testing.T) {
+		service := newService()
+		if resolved, ok := service.Resolve("convert"); !ok || resolved != "convert@v2" {
+			t.Errorf("Expected convert@v2, got %q, %v", resolved, ok)
+		}
+		result, err := service.ExecuteTool("convert", nil)
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		meta, _ := result["_meta"].(map[string]interface{})
+		if result["ran"] != "convert@v2" || meta["tool"] != "convert@v2" || meta["version"] != "v2" || meta["deprecated"] != nil {
+			t.Errorf("Unexpected result: %v", result)
+		}
+		if _, ok := service.Resolve("convert@v9"); ok {
+			t.Error("Expected an unknown version not to resolve")
+		}
+		if resolved, ok := service.Resolve("plain"); !ok || resolved != "plain" {
+			t.Errorf("Expected plain, got %q, %v", resolved, ok)
+		}
+	})
+
+	t.Run("configured defaults win", func(t *testing.T) {
+		service := newService()
+		service.SetDefaultVersions(map[string]string{"convert": "v1"})
+		result, err := service.ExecuteTool("convert", nil)
+		if err != nil {
+			t.Fatalf("ExecuteTool failed: %v", err)
+		}
+		meta, _ := result["_meta"].(map[string]interface{})
+		if result["ran"] != "convert@v1" || meta["deprecated"] != true || meta["deprecationNotice"] != "Use convert@v2" {
+			t.Errorf("Expected the deprecated v1 with its notice, got %v", result)
+		}
+
+		service.SetDefaultVersions(map[string]string{"convert": "v7"})
+		if resolved, _ := service.Resolve("convert"); resolved != "convert@v2" {
+			t.Errorf("Expected an unregistered default to fall back to convert@v2, got %q", resolved)
+		}
+	})
+
+	t.Run("unversioned results are untouched", func(t *testing.T) {
+		result, err := newService().ExecuteTool("plain", nil)
+		if err != nil || result["_meta"] != nil {
+			t.Errorf("Expected no _meta, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("tools/list includes bare names and deprecations", func(t *testing.T) {
+		processor := NewJSONRPCProcessor(newService(), logger)
+		definitions := processor.getAvailableTools(context.Background())
+		byName := make(map[string]ToolDefinition)
+		var names []string
+		for _, definition := range definitions {
+			byName[definition.Name] = definition
+			names = append(names, definition.Name)
+		}
+		expected := []string{"convert", "convert@v1", "convert@v2", "convert@v3", "plain"}
+		if len(names) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, names)
+		}
+		for i, name := range expected {
+			if names[i] != name {
+				t.Fatalf("Expected %v, got %v", expected, names)
+			}
+		}
+		if bare := byName["convert"]; bare.Version != "v2" || bare.Description != "Converts convert@v2" || bare.Deprecated {
+			t.Errorf("Unexpected bare entry: %+v", bare)
+		}
+		if old := byName["convert@v1"]; !old.Deprecated || old.DeprecationNotice != "Use convert@v2" || old.Version != "v1" {
+			t.Errorf("Unexpected deprecated entry: %+v", old)
+		}
+		if plain := byName["plain"]; plain.Version != "" {
+			t.Errorf("Expected no version for plain, got %+v", plain)
+		}
+	})
+
+	t.Run("REST calls and lists bare names", func(t *testing.T) {
+		httpServer := NewHTTPServer(newService(), WithPort(8080), WithLogger(logger))
+		w := httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("POST", "/api/tools/convert", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		var result map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil || result["ran"] != "convert@v2" {
+			t.Errorf("Expected convert@v2 to run, got %s", w.Body.String())
+		}
+
+		w = httptest.NewRecorder()
+		httpServer.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/api/list?details=true", nil))
+		var entries map[string]toolListEntry
+		if err := json.Unmarshal(w.Body.Bytes(), &entries); err != nil {
+			t.Fatalf("Failed to decode list: %v", err)
+		}
+		if entries["convert"].Version != "v2" || !entries["convert@v3"].Deprecated {
+			t.Errorf("Unexpected entries: %+v", entries)
+		}
+	})
+
+	t.Run("tenants allowed a bare name may call every version", func(t *testing.T) {
+		tenant := &Tenant{Name: "team", allowed: map[string]bool{"convert": true}}
+		if !tenant.Allows("convert@v1") || tenant.Allows("plain") {
+			t.Error("Expected convert's versions to be allowed and plain not")
+		}
+	})
+}
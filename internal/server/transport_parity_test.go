@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// TestTransportParity sends the same messages through the processor and each transport
+// and checks that every transport returns exactly what the processor does.
+func TestTransportParity(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger, &MockTool{name: "echo", description: "Echoes"})
+	processor := NewJSONRPCProcessor(toolService, logger)
+
+	streamable := NewStreamableHTTPServer(toolService, WithLogger(logger))
+	streamableServer := httptest.NewServer(http.HandlerFunc(streamable.handleMCP))
+	defer streamableServer.Close()
+
+	wsServer := NewWebSocketServer(toolService, WithLogger(logger))
+	webSocketServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+	defer webSocketServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(webSocketServer.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial WebSocket server: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// Stdio requires the initialize handshake before anything else
+	stdinReader, stdin := io.Pipe()
+	stdoutReader, stdoutWriter := io.Pipe()
+	stdioServer := NewMCPServer(toolService, logger)
+	stdioServer.SetHeartbeat(0)
+	stdioServer.SetIO(stdinReader, stdoutWriter)
+	go stdioServer.Start(ctx)
+	defer stdin.Close()
+	stdout := json.NewDecoder(stdoutReader)
+	stdio := func(t *testing.T, message interface{}) json.RawMessage {
+		t.Helper()
+		line, _ := json.Marshal(message)
+		if _, err := stdin.Write(append(line, '\n')); err != nil {
+			t.Fatalf("Stdio write failed: %v", err)
+		}
+		var response json.RawMessage
+		if err := stdout.Decode(&response); err != nil {
+			t.Fatalf("Stdio read failed: %v", err)
+		}
+		return response
+	}
+	stdio(t, map[string]interface{}{"jsonrpc": "2.0", "id": 0, "method": "initialize"})
+
+	messages := []struct {
+		name    string
+		request map[string]interface{}
+	}{
+		{"initialize", map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize"}},
+		{"tools/list", map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "tools/list"}},
+		{"tools/call", map[string]interface{}{"jsonrpc": "2.0", "id": 3, "method": "tools/call", "params": map[string]interface{}{"name": "echo"}}},
+		{"unknown tool", map[string]interface{}{"jsonrpc": "2.0", "id": 4, "method": "tools/call", "params": map[string]interface{}{"name": "missing"}}},
+		{"missing tool name", map[string]interface{}{"jsonrpc": "2.0", "id": 5, "method": "tools/call", "params": map[string]interface{}{}}},
+		{"unknown method", map[string]interface{}{"jsonrpc": "2.0", "id": 6, "method": "no/such/method"}},
+		{"missing method", map[string]interface{}{"jsonrpc": "2.0", "id": 7}},
+		{"unknown resource", map[string]interface{}{"jsonrpc": "2.0", "id": 8, "method": "resources/read", "params": map[string]interface{}{"uri": "missing://"}}},
+	}
+
+	for _, msg := range messages {
+		t.Run(msg.name, func(t *testing.T) {
+			expected, err := json.Marshal(processor.Process(context.Background(), msg.request))
+			if err != nil {
+				t.Fatalf("Failed to marshal processor response: %v", err)
+			}
+
+			body, _ := json.Marshal(msg.request)
+			resp, err := http.Post(streamableServer.URL, "application/json", bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("Streamable request failed: %v", err)
+			}
+			var streamableResponse json.RawMessage
+			err = json.NewDecoder(resp.Body).Decode(&streamableResponse)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatalf("Failed to decode streamable response: %v", err)
+			}
+			if !bytes.Equal(expected, streamableResponse) {
+				t.Errorf("Streamable response differs\nexpected: %s\ngot:      %s", expected, streamableResponse)
+			}
+
+			if err := wsjson.Write(ctx, conn, msg.request); err != nil {
+				t.Fatalf("WebSocket write failed: %v", err)
+			}
+			var webSocketResponse json.RawMessage
+			if err := wsjson.Read(ctx, conn, &webSocketResponse); err != nil {
+				t.Fatalf("WebSocket read failed: %v", err)
+			}
+			if !bytes.Equal(expected, webSocketResponse) {
+				t.Errorf("WebSocket response differs\nexpected: %s\ngot:      %s", expected, webSocketResponse)
+			}
+
+			if stdioResponse := stdio(t, msg.request); !bytes.Equal(expected, stdioResponse) {
+				t.Errorf("Stdio response differs\nexpected: %s\ngot:      %s", expected, stdioResponse)
+			}
+		})
+	}
+
+	t.Run("batch", func(t *testing.T) {
+		batch := []interface{}{messages[1].request, messages[2].request, messages[3].request}
+		expected, _ := json.Marshal(processor.ProcessMessage(context.Background(), batch))
+
+		body, _ := json.Marshal(batch)
+		resp, err := http.Post(streamableServer.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Streamable request failed: %v", err)
+		}
+		var streamableResponse json.RawMessage
+		err = json.NewDecoder(resp.Body).Decode(&streamableResponse)
+		resp.Body.Close()
+		if err != nil || !bytes.Equal(expected, streamableResponse) {
+			t.Errorf("Streamable batch response differs\nexpected: %s\ngot:      %s", expected, streamableResponse)
+		}
+
+		if err := wsjson.Write(ctx, conn, batch); err != nil {
+			t.Fatalf("WebSocket write failed: %v", err)
+		}
+		var webSocketResponse json.RawMessage
+		if err := wsjson.Read(ctx, conn, &webSocketResponse); err != nil || !bytes.Equal(expected, webSocketResponse) {
+			t.Errorf("WebSocket batch response differs\nexpected: %s\ngot:      %s", expected, webSocketResponse)
+		}
+
+		if stdioResponse := stdio(t, batch); !bytes.Equal(expected, stdioResponse) {
+			t.Errorf("Stdio batch response differs\nexpected: %s\ngot:      %s", expected, stdioResponse)
+		}
+	})
+
+	t.Run("notifications produce no response", func(t *testing.T) {
+		notification := map[string]interface{}{"jsonrpc": "2.0", "method": "notifications/initialized"}
+		if response := processor.Process(context.Background(), notification); response != nil {
+			t.Fatalf("Expected no processor response, got %+v", response)
+		}
+
+		body, _ := json.Marshal(notification)
+		resp, err := http.Post(streamableServer.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Streamable request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			t.Errorf("Expected streamable status 202, got %d", resp.StatusCode)
+		}
+
+		// The WebSocket transport must stay silent, so the next response belongs to the
+		// next request rather than to the notification.
+		if err := wsjson.Write(ctx, conn, notification); err != nil {
+			t.Fatalf("WebSocket write failed: %v", err)
+		}
+		if err := wsjson.Write(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 99, "method": "tools/list"}); err != nil {
+			t.Fatalf("WebSocket write failed: %v", err)
+		}
+		var response JSONRPCResponse
+		if err := wsjson.Read(ctx, conn, &response); err != nil {
+			t.Fatalf("WebSocket read failed: %v", err)
+		}
+		if response.ID != float64(99) {
+			t.Errorf("Expected response to id 99, got %v", response.ID)
+		}
+	})
+}
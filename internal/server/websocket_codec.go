@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"nhooyr.io/websocket"
+
+	"mcp-tools-server/internal/codec"
+)
+
+// wsSubprotocolPrefix starts the WebSocket subprotocols that pick a binary encoding for
+// a connection's JSON-RPC messages.
+const wsSubprotocolPrefix = "mcp."
+
+// wsSubprotocols are the subprotocols offered to clients, in order of preference.
+var wsSubprotocols = []string{wsSubprotocolPrefix + codec.CBOR.Name(), wsSubprotocolPrefix + codec.MessagePack.Name()}
+
+// wsCodec returns the codec picked by a negotiated subprotocol, or nil for JSON.
+func wsCodec(subprotocol string) codec.Codec {
+	name, ok := strings.CutPrefix(strings.ToLower(subprotocol), wsSubprotocolPrefix)
+	if !ok {
+		return nil
+	}
+	return codec.ByName(name)
+}
+
+// encodeFrame returns message, which is JSON, as a frame for the session's connection:
+// encoded with the connection's codec in a binary frame if it negotiated one, and
+// otherwise as JSON in a text frame, or in a binary frame once the client has sent one.
+// The caller holds ws.mu.
+func (ws *wsSession) encodeFrame(message []byte) (websocket.MessageType, []byte, error) {
+	if ws.codec != nil {
+		encoded, err := codec.FromJSON(ws.codec, message)
+		return websocket.MessageBinary, encoded, err
+	}
+	if ws.binary {
+		return websocket.MessageBinary, message, nil
+	}
+	return websocket.MessageText, message, nil
+}
+
+// readMessage reads a JSON-RPC message, a request or a batch, from conn. Text frames
+// carry JSON; binary frames are in the connection's codec, or JSON if it has none. A
+// message that cannot be decoded closes the connection.
+func (s *WebSocketServer) readMessage(ctx context.Context, conn *websocket.Conn, ws *wsSession) (interface{}, error) {
+	typ, data, err := conn.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if typ == websocket.MessageBinary {
+		ws.mu.Lock()
+		ws.binary = true
+		c := ws.codec
+		ws.mu.Unlock()
+		if c != nil {
+			if data, err = codec.ToJSON(c, data); err != nil {
+				conn.Close(websocket.StatusInvalidFramePayloadData, "failed to decode "+c.Name())
+				return nil, fmt.Errorf("failed to decode %s message: %w", c.Name(), err)
+			}
+		}
+	}
+	var message interface{}
+	if err := json.Unmarshal(data, &message); err != nil {
+		conn.Close(websocket.StatusInvalidFramePayloadData, "failed to unmarshal JSON")
+		return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+	}
+	return message, nil
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"nhooyr.io/websocket"
+
+	"mcp-tools-server/internal/codec"
+	"mcp-tools-server/internal/metrics"
+)
+
+// wsResumePending bounds the messages queued for a detached session; past it the oldest
+// are dropped.
+const wsResumePending = 256
+
+// errSessionDetached is returned for a message to a session that has no connection and
+// cannot be resumed.
+var errSessionDetached = errors.New("websocket session is not connected")
+
+// Metrics for WebSocket session resumption.
+var (
+	wsResumesTotal = metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "websocket",
+			Name:      "resumes_total",
+			Help:      "Total number of reconnections with a resume token by result (resumed or rejected)",
+		},
+		[]string{"result"},
+	)
+	wsSessionsDetached = metrics.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "websocket",
+			Name:      "sessions_detached",
+			Help:      "Number of WebSocket sessions whose connection dropped and that can still be resumed",
+		},
+	)
+)
+
+// wsSession is the MCP session of a WebSocket connection. Once initialized with resuming
+// on, the session has a resume token, and when its connection drops it is kept, detached,
+// for the resume TTL: messages for it are queued, and a client reconnecting with the
+// token re-attaches to the session, its state, and the queued messages.
+type wsSession struct {
+	mu      sync.Mutex
+	session *Session
+	tenant  string          // Tenant that opened the session; only it may resume it
+	conn    *websocket.Conn // Nil while detached
+	codec   codec.Codec     // Binary encoding negotiated for conn; nil for JSON
+	binary  bool            // The client has sent conn a binary frame, so JSON is sent in binary frames
+	token   string          // Empty until a resume token is issued
+	pending [][]byte        // Messages that could not be delivered while detached
+	expiry  *time.Timer     // Ends the session if it is not resumed; nil while attached
+}
+
+// send writes a message to the session's connection. A resumable session whose
+// connection is gone queues the message for the client's return; the write error is
+// still returned, so the caller knows the connection is unusable.
+func (ws *wsSession) send(ctx context.Context, message []byte) error {
+	ws.mu.Lock()
+	conn := ws.conn
+	if conn == nil {
+		defer ws.mu.Unlock()
+		return ws.queue(message)
+	}
+	typ, frame, err := ws.encodeFrame(message)
+	ws.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	writeCtx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+	defer cancel()
+	err = conn.Write(writeCtx, typ, frame)
+	if err != nil {
+		ws.mu.Lock()
+		if ws.token != "" {
+			_ = ws.queue(message)
+		}
+		ws.mu.Unlock()
+	}
+	return err
+}
+
+// queue keeps a message for a detached session, dropping the oldest past the limit. The
+// caller holds ws.mu.
+func (ws *wsSession) queue(message []byte) error {
+	if ws.token == "" {
+		return errSessionDetached
+	}
+	if len(ws.pending) >= wsResumePending {
+		ws.pending = ws.pending[1:]
+	}
+	ws.pending = append(ws.pending, message)
+	return nil
+}
+
+// attach makes conn, which negotiated c, the session's connection and sends it the queued
+// messages, in order. A connection the session still had, which the server had not yet
+// seen drop, is closed.
+func (ws *wsSession) attach(ctx context.Context, conn *websocket.Conn, c codec.Codec) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if previous := ws.conn; previous != nil {
+		go previous.Close(websocket.StatusPolicyViolation, "session resumed on another connection")
+	}
+	ws.conn, ws.codec, ws.binary = conn, c, false
+	for len(ws.pending) > 0 {
+		typ, frame, err := ws.encodeFrame(ws.pending[0])
+		if err != nil {
+			return err
+		}
+		writeCtx, cancel := context.WithTimeout(ctx, wsWriteTimeout)
+		err = conn.Write(writeCtx, typ, frame)
+		cancel()
+		if err != nil {
+			return err
+		}
+		wsMessagesTotal.WithLabelValues("out").Inc()
+		ws.pending = ws.pending[1:]
+	}
+	ws.pending = nil
+	return nil
+}
+
+// resumeToken returns the session's resume token, issuing one the first time.
+func (s *WebSocketServer) resumeToken(ws *wsSession) string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.token == "" {
+		ws.token = rand.Text()
+		s.mu.Lock()
+		s.resumable[ws.token] = ws
+		s.mu.Unlock()
+	}
+	return ws.token
+}
+
+// offerResume adds the session's resume token to the result of each successful
+// initialize in response, which is a response or a batch of them.
+func (s *WebSocketServer) offerResume(ws *wsSession, response interface{}) {
+	ttl := s.options.wsResumeTTL
+	if ttl <= 0 {
+		return
+	}
+	var responses []*JSONRPCResponse
+	switch response := response.(type) {
+	case *JSONRPCResponse:
+		responses = []*JSONRPCResponse{response}
+	case []*JSONRPCResponse:
+		responses = response
+	}
+	for _, response := range responses {
+		result, ok := response.Result.(InitializeResult)
+		if !ok || response.Error != nil {
+			continue
+		}
+		result.Meta = map[string]interface{}{
+			"resumeToken":      s.resumeToken(ws),
+			"resumeTtlSeconds": int(ttl.Seconds()),
+		}
+		response.Result = result
+	}
+}
+
+// claim returns the detached session with token for tenant, stopping its expiry, or nil
+// if there is none. A session still attached to a connection is claimed too; attaching
+// the new connection closes the old one.
+func (s *WebSocketServer) claim(token, tenant string) *wsSession {
+	s.mu.Lock()
+	ws := s.resumable[token]
+	s.mu.Unlock()
+	if ws == nil {
+		return nil
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.tenant != tenant {
+		return nil
+	}
+	if ws.expiry != nil {
+		if !ws.expiry.Stop() {
+			return nil // Expired while being claimed
+		}
+		ws.expiry = nil
+		wsSessionsDetached.Dec()
+	}
+	return ws
+}
+
+// release handles the end of conn. If conn is still the session's connection, a
+// resumable session is detached until it is resumed or its TTL passes, unless the client
+// closed it normally, and any other session ends. A nil conn releases a claimed session
+// that was never attached.
+func (s *WebSocketServer) release(ws *wsSession, conn *websocket.Conn, closedNormally bool) {
+	ws.mu.Lock()
+	if ws.conn != conn || ws.expiry != nil {
+		// Another connection has taken the session over
+		ws.mu.Unlock()
+		return
+	}
+	ws.conn = nil
+	if ttl := s.options.wsResumeTTL; ws.token != "" && ttl > 0 && !closedNormally {
+		ws.expiry = time.AfterFunc(ttl, func() { s.expire(ws) })
+		wsSessionsDetached.Inc()
+		ws.mu.Unlock()
+		s.logger.Info("WebSocket session detached; waiting for the client to resume it", "sessionID", ws.session.ID, "ttl", ttl)
+		return
+	}
+	token := ws.token
+	ws.mu.Unlock()
+	if token != "" {
+		s.mu.Lock()
+		delete(s.resumable, token)
+		s.mu.Unlock()
+	}
+	s.processor.toolService.Sessions().Remove(ws.session.ID)
+}
+
+// expire ends a detached session that was not resumed in time.
+func (s *WebSocketServer) expire(ws *wsSession) {
+	ws.mu.Lock()
+	token := ws.token
+	ws.expiry = nil
+	ws.pending = nil
+	ws.mu.Unlock()
+
+	s.mu.Lock()
+	delete(s.resumable, token)
+	s.mu.Unlock()
+	wsSessionsDetached.Dec()
+	s.logger.Info("WebSocket session was not resumed in time", "sessionID", ws.session.ID)
+	s.processor.toolService.Sessions().Remove(ws.session.ID)
+}
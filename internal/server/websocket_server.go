@@ -2,91 +2,371 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
 
-	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/metrics"
+)
+
+// wsWriteTimeout bounds how long writing one message to a WebSocket may take.
+const wsWriteTimeout = 10 * time.Second
+
+// WebSocket metrics, exported alongside the HTTP metrics on /metrics.
+var (
+	wsConnectionsActive = metrics.NewGauge(
+		prometheus.GaugeOpts{
+			Subsystem: "websocket",
+			Name:      "connections_active",
+			Help:      "Number of open WebSocket connections",
+		},
+	)
+	wsConnectionsTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "websocket",
+			Name:      "connections_total",
+			Help:      "Total number of accepted WebSocket connections",
+		},
+	)
+	wsUpgradeFailuresTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "websocket",
+			Name:      "upgrade_failures_total",
+			Help:      "Total number of requests that failed to upgrade to a WebSocket",
+		},
+	)
+	wsMessagesTotal = metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "websocket",
+			Name:      "messages_total",
+			Help:      "Total number of WebSocket messages by direction",
+		},
+		[]string{"direction"},
+	)
+	wsPingFailuresTotal = metrics.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "websocket",
+			Name:      "ping_failures_total",
+			Help:      "Total number of WebSocket connections closed because a ping was not answered",
+		},
+	)
+	wsMessageDuration = metrics.NewHistogram(
+		prometheus.HistogramOpts{
+			Subsystem: "websocket",
+			Name:      "message_duration_seconds",
+			Help:      "Time taken to handle a WebSocket message and write its response",
+			Buckets:   prometheus.DefBuckets,
+		},
+	)
 )
 
 // WebSocketServer handles WebSocket connections.
 type WebSocketServer struct {
-	config     *config.ServerConfig
 	processor  *JSONRPCProcessor
 	httpServer *http.Server
+	logger     *slog.Logger
+	port       int
+	options    serverOptions
+
+	// Shutdown does not track hijacked connections, so open WebSockets are tracked here
+	// and drained on Stop.
+	mu        sync.Mutex
+	conns     map[*websocket.Conn]struct{}
+	done      chan struct{}         // closed when the last tracked connection ends
+	resumable map[string]*wsSession // Sessions by resume token
 }
 
-// NewWebSocketServer creates a new WebSocket server.
-func NewWebSocketServer(cfg *config.ServerConfig, processor *JSONRPCProcessor) *WebSocketServer {
+// NewWebSocketServer creates a new WebSocket server, listening on port 8082 unless
+// WithPort is given.
+func NewWebSocketServer(toolService *ToolService, opts ...ServerOption) *WebSocketServer {
+	options := newServerOptions(8082, opts)
+	processor := NewJSONRPCProcessor(toolService, options.logger)
+	processor.SetBatchOptions(options.batch)
 	return &WebSocketServer{
-		config:    cfg,
 		processor: processor,
+		logger:    options.logger,
+		port:      options.port,
+		options:   options,
+		conns:     make(map[*websocket.Conn]struct{}),
+		resumable: make(map[string]*wsSession),
 	}
 }
 
-// Start initializes and starts the WebSocket server.
-func (s *WebSocketServer) Start() error {
+// Handler returns the server's HTTP handler, serving /ws behind any configured middleware.
+func (s *WebSocketServer) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/ws", s.handleWebSocket)
+	return s.options.wrap(mux)
+}
 
-	s.httpServer = &http.Server{
-		Addr:    s.config.WebSocketAddr(),
-		Handler: mux,
-	}
+// Listen binds the server's listener so it accepts connections before Start is called.
+// Start binds it if Listen was not called.
+func (s *WebSocketServer) Listen() error {
+	_, err := s.options.bind()
+	return err
+}
 
-	log.Printf("WebSocket server listening on %s", s.config.WebSocketAddr())
-	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+// Addr returns the address the server is bound to, or its configured address before
+// Listen.
+func (s *WebSocketServer) Addr() string {
+	return s.options.boundAddr()
+}
+
+// TLS reports whether the server serves HTTPS.
+func (s *WebSocketServer) TLS() bool {
+	return s.options.certFile != ""
+}
+
+// Start initializes and starts the WebSocket server.
+func (s *WebSocketServer) Start() error {
+	s.httpServer = s.options.httpServer(s.Handler())
+
+	s.logger.Info("Starting WebSocket server", "address", s.Addr(), "tls", s.TLS())
+	if err := s.options.listenAndServe(s.httpServer); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
 	return nil
 }
 
-// Stop gracefully shuts down the WebSocket server.
+// Stop gracefully shuts down the WebSocket server. It tells connected sessions the server
+// is shutting down and stops accepting connections, then waits for open connections to
+// finish until ctx expires, at which point the remaining ones are closed with
+// StatusGoingAway so clients reconnect elsewhere.
 func (s *WebSocketServer) Stop(ctx context.Context) error {
+	result := s.processor.toolService.Sessions().announceShutdown("websocket")
+	s.logger.Info("Announced shutdown to WebSocket sessions", "delivered", result.Delivered, "failed", result.Failed)
+
+	var err error
 	if s.httpServer != nil {
-		return s.httpServer.Shutdown(ctx)
+		err = s.httpServer.Shutdown(ctx)
+	}
+
+	s.mu.Lock()
+	if len(s.conns) == 0 {
+		s.mu.Unlock()
+		return err
+	}
+	if s.done == nil {
+		s.done = make(chan struct{})
+	}
+	done := s.done
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.mu.Lock()
+		open := make([]*websocket.Conn, 0, len(s.conns))
+		for conn := range s.conns {
+			open = append(open, conn)
+		}
+		s.mu.Unlock()
+		// Close concurrently, since each close waits for the peer's handshake.
+		var wg sync.WaitGroup
+		for _, conn := range open {
+			wg.Add(1)
+			go func(conn *websocket.Conn) {
+				defer wg.Done()
+				_ = conn.Close(websocket.StatusGoingAway, "server shutting down")
+			}(conn)
+		}
+		wg.Wait()
+	}
+	return err
+}
+
+// track records an open connection and returns a function that forgets it.
+func (s *WebSocketServer) track(conn *websocket.Conn) func() {
+	s.mu.Lock()
+	s.conns[conn] = struct{}{}
+	s.mu.Unlock()
+	wsConnectionsTotal.Inc()
+	wsConnectionsActive.Inc()
+
+	return func() {
+		wsConnectionsActive.Dec()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.conns, conn)
+		if len(s.conns) == 0 && s.done != nil {
+			close(s.done)
+			s.done = nil
+		}
 	}
-	return nil
 }
 
 // handleWebSocket upgrades HTTP connections to WebSocket connections.
 func (s *WebSocketServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set(instanceIDHeader, s.processor.toolService.Coordinator().InstanceID())
+	if s.processor.toolService.Overload().Shed(w, "websocket") {
+		return
+	}
+
+	// A client whose connection dropped may re-attach to its session with its resume token
+	var ws *wsSession
+	if token := r.URL.Query().Get("resume"); token != "" {
+		if ws = s.claim(token, tenantName(r.Context())); ws == nil {
+			wsResumesTotal.WithLabelValues("rejected").Inc()
+			http.Error(w, "Unknown or expired resume token", http.StatusNotFound)
+			return
+		}
+	}
+
+	// The connection outlives the server's read and write timeouts, so lift the deadlines
+	// for it. Message size is bounded by the read limit below instead.
+	controller := http.NewResponseController(w)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
 		InsecureSkipVerify: true, // TODO: Make this configurable
+		Subprotocols:       wsSubprotocols,
 	})
 	if err != nil {
-		log.Printf("Failed to upgrade to WebSocket: %v", err)
+		wsUpgradeFailuresTotal.Inc()
+		s.logger.Warn("Failed to upgrade to WebSocket", "error", err)
+		s.processor.toolService.Events().publishTransportError("websocket", "upgrade", err)
+		if ws != nil {
+			s.release(ws, nil, false)
+		}
 		return
 	}
 	defer conn.Close(websocket.StatusInternalError, "internal server error")
+	defer s.track(conn)()
+	if s.options.limits.MaxBodyBytes > 0 {
+		conn.SetReadLimit(s.options.limits.MaxBodyBytes)
+	}
 
-	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	// The connection lasts until the client closes it or stops answering pings
+	ctx, cancel := context.WithCancel(r.Context())
 	defer cancel()
+	go s.keepAlive(ctx, cancel, conn)
 
-	for {
-		var request map[string]interface{}
-		err := wsjson.Read(ctx, conn, &request)
+	var closedNormally atomic.Bool
+	if ws == nil {
+		ws = &wsSession{tenant: tenantName(r.Context()), conn: conn, codec: wsCodec(conn.Subprotocol())}
+		ws.session = s.processor.toolService.Sessions().Add("websocket", func(message []byte) error {
+			wsMessagesTotal.WithLabelValues("out").Inc()
+			return ws.send(context.Background(), message)
+		})
+	} else {
+		err := ws.attach(ctx, conn, wsCodec(conn.Subprotocol()))
 		if err != nil {
-			var closeErr websocket.CloseError
-			if errors.As(err, &closeErr) {
-				if closeErr.Code == websocket.StatusNormalClosure {
+			s.release(ws, conn, false)
+			return
+		}
+		wsResumesTotal.WithLabelValues("resumed").Inc()
+		loggerFor(r.Context(), s.logger).Info("WebSocket session resumed", "sessionID", ws.session.ID)
+	}
+	defer func() { s.release(ws, conn, closedNormally.Load()) }()
+
+	// Messages are read apart from the loop below, which handles them in order, so pings
+	// and cancellations are handled while a long tool call runs
+	messages := make(chan interface{})
+	readCtx, stopReading := context.WithCancel(ctx)
+	defer stopReading()
+	go func() {
+		defer close(messages)
+		for {
+			message, err := s.readMessage(readCtx, conn, ws)
+			if err != nil {
+				var closeErr websocket.CloseError
+				if errors.As(err, &closeErr) && closeErr.Code == websocket.StatusNormalClosure {
+					closedNormally.Store(true)
+					return
+				}
+				if readCtx.Err() != nil {
 					return
 				}
+				loggerFor(r.Context(), s.logger).Warn("Failed to read from WebSocket", "error", err)
+				s.processor.toolService.Events().publishTransportError("websocket", "read", err)
+				return
+			}
+			wsMessagesTotal.WithLabelValues("in").Inc()
+			if isControlMessage(message) {
+				if !s.handleMessage(ctx, r, ws, message) {
+					return
+				}
+				continue
+			}
+			select {
+			case messages <- message:
+			case <-readCtx.Done():
+				return
 			}
-			log.Printf("Failed to read from WebSocket: %v", err)
-			return
 		}
+	}()
 
-		response := s.processor.Process(r.Context(), request)
+	for message := range messages {
+		if !s.handleMessage(ctx, r, ws, message) {
+			return
+		}
+	}
+}
 
-		err = wsjson.Write(ctx, conn, response)
-		if err != nil {
-			log.Printf("Failed to write to WebSocket: %v", err)
+// keepAlive pings the connection until ctx is done, calling disconnect when the peer does
+// not answer a ping in time.
+func (s *WebSocketServer) keepAlive(ctx context.Context, disconnect func(), conn *websocket.Conn) {
+	interval := s.options.keepAlive.WebSocketPing
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pingCtx := ctx
+			var cancel context.CancelFunc = func() {}
+			if timeout := s.options.keepAlive.WebSocketPongTimeout; timeout > 0 {
+				pingCtx, cancel = context.WithTimeout(ctx, timeout)
+			}
+			err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				if ctx.Err() == nil {
+					wsPingFailuresTotal.Inc()
+					s.logger.Info("WebSocket peer did not answer a ping; closing connection", "error", err)
+					disconnect()
+				}
+				return
+			}
+		case <-ctx.Done():
 			return
 		}
 	}
 }
+
+// handleMessage processes a message from the session's connection and writes the
+// response, if any. It reports whether the connection is still usable. A response that
+// cannot be written to a resumable session is kept for the client's return.
+func (s *WebSocketServer) handleMessage(ctx context.Context, r *http.Request, ws *wsSession, message interface{}) bool {
+	start := time.Now()
+	response := s.processor.ProcessMessage(WithSessionID(r.Context(), ws.session.ID), message)
+	if response == nil {
+		// Notifications have no response.
+		metrics.Observe(wsMessageDuration, time.Since(start).Seconds(), metrics.TraceIDFromContext(r.Context()))
+		return true
+	}
+	s.offerResume(ws, response)
+
+	encoded, err := json.Marshal(response)
+	if err == nil {
+		err = ws.send(ctx, encoded)
+	}
+	metrics.Observe(wsMessageDuration, time.Since(start).Seconds(), metrics.TraceIDFromContext(r.Context()))
+	if err != nil {
+		loggerFor(r.Context(), s.logger).Warn("Failed to write to WebSocket", "error", err)
+		s.processor.toolService.Events().publishTransportError("websocket", "write", err)
+		return false
+	}
+	wsMessagesTotal.WithLabelValues("out").Inc()
+	return true
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -12,9 +13,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"nhooyr.io/websocket"
 
-	"mcp-tools-server/internal/config"
+	"mcp-tools-server/internal/codec"
 	"mcp-tools-server/pkg/tools"
 )
 
@@ -22,10 +24,6 @@ import (
 func TestWebSocketServer_E2E(t *testing.T) {
 	// --- Test Setup ---
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
-	cfg := &config.ServerConfig{
-		WebSocketPort: 9999,
-	}
-
 	// Create a real tool registry and service
 	registry := tools.NewToolRegistry()
 	toolService, err := NewToolService(registry, logger)
@@ -33,11 +31,8 @@ func TestWebSocketServer_E2E(t *testing.T) {
 		t.Fatalf("Failed to create tool service: %v", err)
 	}
 
-	// Create the JSON-RPC processor
-	processor := NewJSONRPCProcessor(toolService, logger)
-
 	// Create and start the WebSocket server in a goroutine
-	wsServer := NewWebSocketServer(cfg, processor)
+	wsServer := NewWebSocketServer(toolService, WithPort(9999), WithLogger(logger))
 	testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
 	defer testServer.Close()
 
@@ -102,10 +97,14 @@ func TestWebSocketServer_E2E(t *testing.T) {
 	if !ok {
 		t.Fatalf("Expected result to be a map, got %T", callResp["result"])
 	}
-	if _, ok := result["uuid"]; !ok {
+	structured, _ := result["structuredContent"].(map[string]interface{})
+	if _, ok := structured["uuid"]; !ok {
 		t.Error("Expected 'uuid' in tools/call response")
 	}
-	t.Logf("Received UUID: %s", result["uuid"])
+	if _, ok := result["content"].([]interface{}); !ok {
+		t.Error("Expected content in tools/call response")
+	}
+	t.Logf("Received UUID: %s", structured["uuid"])
 }
 
 // writeRequest is a helper to send a JSON request to the WebSocket connection.
@@ -133,3 +132,424 @@ func readResponse(ctx context.Context, conn *websocket.Conn) (map[string]interfa
 	}
 	return resp, nil
 }
+
+// TestWebSocketServer_StopDrainsConnections checks that Stop serves on an injected
+// listener and closes connections still open at the deadline with StatusGoingAway.
+func TestWebSocketServer_StopDrainsConnections(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := newTestToolService(logger)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	wsServer := NewWebSocketServer(toolService, WithLogger(logger), WithListener(l))
+	go func() { _ = wsServer.Start() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws://"+l.Addr().String()+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Failed to dial WebSocket server: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	// Wait for the handler to register the connection.
+	for i := 0; i < 100; i++ {
+		wsServer.mu.Lock()
+		open := len(wsServer.conns)
+		wsServer.mu.Unlock()
+		if open == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	notices := make(chan []byte, 1)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, data, err := conn.Read(ctx)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			notices <- data
+		}
+	}()
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer stopCancel()
+	_ = wsServer.Stop(stopCtx)
+
+	// The client is told about the shutdown before the connection is closed
+	select {
+	case notice := <-notices:
+		if !strings.Contains(string(notice), `"method":"notifications/message"`) || !strings.Contains(string(notice), `"reason":"shutdown"`) {
+			t.Errorf("Expected a shutdown notice, got %s", notice)
+		}
+	default:
+		t.Error("Expected a shutdown notice before the connection closed")
+	}
+	err = <-readErr
+	if status := websocket.CloseStatus(err); status != websocket.StatusGoingAway {
+		t.Errorf("Expected close status %v, got %v (%v)", websocket.StatusGoingAway, status, err)
+	}
+}
+
+func TestWebSocketServer_Metrics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	wsServer := NewWebSocketServer(newTestToolService(logger, &MockTool{name: "echo"}), WithLogger(logger))
+	testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+	defer testServer.Close()
+
+	t.Run("counts connections and messages", func(t *testing.T) {
+		connections := testutil.ToFloat64(wsConnectionsTotal)
+		in := testutil.ToFloat64(wsMessagesTotal.WithLabelValues("in"))
+		out := testutil.ToFloat64(wsMessagesTotal.WithLabelValues("out"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(testServer.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		if err := writeRequest(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "ping"}); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		if _, err := readResponse(ctx, conn); err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		if active := testutil.ToFloat64(wsConnectionsActive); active < 1 {
+			t.Errorf("Expected an active connection, got %v", active)
+		}
+		conn.Close(websocket.StatusNormalClosure, "")
+
+		if got := testutil.ToFloat64(wsConnectionsTotal); got != connections+1 {
+			t.Errorf("Expected %v connections, got %v", connections+1, got)
+		}
+		if got := testutil.ToFloat64(wsMessagesTotal.WithLabelValues("in")); got != in+1 {
+			t.Errorf("Expected %v messages in, got %v", in+1, got)
+		}
+		if got := testutil.ToFloat64(wsMessagesTotal.WithLabelValues("out")); got != out+1 {
+			t.Errorf("Expected %v messages out, got %v", out+1, got)
+		}
+	})
+
+	t.Run("counts failed upgrades", func(t *testing.T) {
+		failures := testutil.ToFloat64(wsUpgradeFailuresTotal)
+		resp, err := http.Get(testServer.URL)
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		resp.Body.Close()
+		if got := testutil.ToFloat64(wsUpgradeFailuresTotal); got != failures+1 {
+			t.Errorf("Expected %v upgrade failures, got %v", failures+1, got)
+		}
+	})
+}
+
+func TestWebSocketServer_KeepAlive(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	wsServer := NewWebSocketServer(newTestToolService(logger), WithLogger(logger),
+		WithKeepAlive(KeepAlive{WebSocketPing: 20 * time.Millisecond, WebSocketPongTimeout: 50 * time.Millisecond}))
+	testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+	defer testServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http")
+
+	t.Run("connections that answer pings stay open", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		// Pongs are only sent while the client reads, so keep a read running
+		responses := make(chan []byte, 1)
+		go func() {
+			_, data, err := conn.Read(ctx)
+			if err == nil {
+				responses <- data
+			}
+		}()
+		time.Sleep(200 * time.Millisecond)
+		if err := writeRequest(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "ping"}); err != nil {
+			t.Fatalf("Failed to send request after several pings: %v", err)
+		}
+		select {
+		case <-responses:
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the ping response")
+		}
+	})
+
+	t.Run("connections that stop answering pings are closed", func(t *testing.T) {
+		failures := testutil.ToFloat64(wsPingFailuresTotal)
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		defer conn.CloseNow()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for testutil.ToFloat64(wsPingFailuresTotal) == failures && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if got := testutil.ToFloat64(wsPingFailuresTotal); got != failures+1 {
+			t.Errorf("Expected %v ping failures, got %v", failures+1, got)
+		}
+	})
+}
+
+func TestWebSocketServer_Resume(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	// initialize dials the server and initializes a session, returning its resume token
+	initialize := func(t *testing.T, ctx context.Context, wsURL string) (*websocket.Conn, string) {
+		t.Helper()
+		conn, _, err := websocket.Dial(ctx, wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		if err := writeRequest(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "initialize", "params": map[string]interface{}{}}); err != nil {
+			t.Fatalf("Failed to send initialize: %v", err)
+		}
+		resp, err := readResponse(ctx, conn)
+		if err != nil {
+			t.Fatalf("Failed to read initialize response: %v", err)
+		}
+		result, _ := resp["result"].(map[string]interface{})
+		meta, _ := result["_meta"].(map[string]interface{})
+		token, _ := meta["resumeToken"].(string)
+		if token == "" {
+			t.Fatalf("Expected a resume token in the initialize result, got %v", resp)
+		}
+		return conn, token
+	}
+	// waitDetached waits for the server to see the session's connection drop
+	waitDetached := func(t *testing.T, wsServer *WebSocketServer, token string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			wsServer.mu.Lock()
+			ws := wsServer.resumable[token]
+			wsServer.mu.Unlock()
+			if ws != nil {
+				ws.mu.Lock()
+				detached := ws.conn == nil
+				ws.mu.Unlock()
+				if detached {
+					return
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatal("Timed out waiting for the session to be detached")
+	}
+
+	t.Run("resumes a dropped session and delivers queued messages", func(t *testing.T) {
+		toolService := newTestToolService(logger)
+		wsServer := NewWebSocketServer(toolService, WithLogger(logger), WithWebSocketResume(time.Minute))
+		testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+		defer testServer.Close()
+		wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, token := initialize(t, ctx, wsURL)
+		sessionID := toolService.Sessions().List()[0].ID
+		conn.CloseNow()
+		waitDetached(t, wsServer, token)
+
+		if sessions := toolService.Sessions().List(); len(sessions) != 1 {
+			t.Fatalf("Expected the detached session to be kept, got %d sessions", len(sessions))
+		}
+		toolService.Sessions().Notify("notifications/tools/list_changed", nil)
+
+		resumed := testutil.ToFloat64(wsResumesTotal.WithLabelValues("resumed"))
+		conn, _, err := websocket.Dial(ctx, wsURL+"?resume="+token, nil)
+		if err != nil {
+			t.Fatalf("Failed to resume session: %v", err)
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		notification, err := readResponse(ctx, conn)
+		if err != nil {
+			t.Fatalf("Failed to read queued notification: %v", err)
+		}
+		if notification["method"] != "notifications/tools/list_changed" {
+			t.Errorf("Expected the queued notification, got %v", notification)
+		}
+		if got := testutil.ToFloat64(wsResumesTotal.WithLabelValues("resumed")); got != resumed+1 {
+			t.Errorf("Expected %v resumes, got %v", resumed+1, got)
+		}
+		if sessions := toolService.Sessions().List(); len(sessions) != 1 || sessions[0].ID != sessionID {
+			t.Errorf("Expected the resumed session %s, got %v", sessionID, sessions)
+		}
+
+		if err := writeRequest(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "ping"}); err != nil {
+			t.Fatalf("Failed to send request on the resumed session: %v", err)
+		}
+		if resp, err := readResponse(ctx, conn); err != nil || resp["id"] != float64(2) {
+			t.Errorf("Expected a ping response, got %v (%v)", resp, err)
+		}
+	})
+
+	t.Run("rejects unknown tokens", func(t *testing.T) {
+		wsServer := NewWebSocketServer(newTestToolService(logger), WithLogger(logger), WithWebSocketResume(time.Minute))
+		testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+		defer testServer.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		rejected := testutil.ToFloat64(wsResumesTotal.WithLabelValues("rejected"))
+		_, resp, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(testServer.URL, "http")+"?resume=unknown", nil)
+		if err == nil {
+			t.Fatal("Expected resuming with an unknown token to fail")
+		}
+		if resp == nil || resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %v", http.StatusNotFound, resp)
+		}
+		if got := testutil.ToFloat64(wsResumesTotal.WithLabelValues("rejected")); got != rejected+1 {
+			t.Errorf("Expected %v rejected resumes, got %v", rejected+1, got)
+		}
+	})
+
+	t.Run("ends sessions that are not resumed in time", func(t *testing.T) {
+		toolService := newTestToolService(logger)
+		wsServer := NewWebSocketServer(toolService, WithLogger(logger), WithWebSocketResume(50*time.Millisecond))
+		testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+		defer testServer.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, _ := initialize(t, ctx, "ws"+strings.TrimPrefix(testServer.URL, "http"))
+		conn.CloseNow()
+
+		deadline := time.Now().Add(2 * time.Second)
+		for len(toolService.Sessions().List()) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if sessions := toolService.Sessions().List(); len(sessions) != 0 {
+			t.Errorf("Expected the expired session to be removed, got %d sessions", len(sessions))
+		}
+	})
+
+	t.Run("ends sessions closed normally", func(t *testing.T) {
+		toolService := newTestToolService(logger)
+		wsServer := NewWebSocketServer(toolService, WithLogger(logger), WithWebSocketResume(time.Minute))
+		testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+		defer testServer.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		conn, token := initialize(t, ctx, "ws"+strings.TrimPrefix(testServer.URL, "http"))
+		conn.Close(websocket.StatusNormalClosure, "")
+
+		deadline := time.Now().Add(2 * time.Second)
+		for len(toolService.Sessions().List()) > 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if sessions := toolService.Sessions().List(); len(sessions) != 0 {
+			t.Errorf("Expected the closed session to be removed, got %d sessions", len(sessions))
+		}
+		wsServer.mu.Lock()
+		_, kept := wsServer.resumable[token]
+		wsServer.mu.Unlock()
+		if kept {
+			t.Error("Expected the resume token of a closed session to be forgotten")
+		}
+	})
+}
+
+func TestWebSocketServer_BinaryFrames(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	wsServer := NewWebSocketServer(newTestToolService(logger), WithLogger(logger))
+	testServer := httptest.NewServer(http.HandlerFunc(wsServer.handleWebSocket))
+	defer testServer.Close()
+	wsURL := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	ping := map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "ping"}
+
+	t.Run("answers JSON in binary frames with binary frames", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+
+		data, _ := json.Marshal(ping)
+		if err := conn.Write(ctx, websocket.MessageBinary, data); err != nil {
+			t.Fatalf("Failed to send request: %v", err)
+		}
+		typ, data, err := conn.Read(ctx)
+		if err != nil {
+			t.Fatalf("Failed to read response: %v", err)
+		}
+		var resp map[string]interface{}
+		if typ != websocket.MessageBinary || json.Unmarshal(data, &resp) != nil || resp["id"] != float64(1) {
+			t.Errorf("Expected a binary JSON response, got %v %s", typ, data)
+		}
+	})
+
+	for _, c := range []codec.Codec{codec.CBOR, codec.MessagePack} {
+		t.Run("negotiates "+c.Name(), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{Subprotocols: []string{"mcp." + c.Name()}})
+			if err != nil {
+				t.Fatalf("Failed to dial WebSocket server: %v", err)
+			}
+			defer conn.Close(websocket.StatusNormalClosure, "")
+			if got := conn.Subprotocol(); got != "mcp."+c.Name() {
+				t.Fatalf("Expected subprotocol mcp.%s, got %q", c.Name(), got)
+			}
+
+			data, _ := c.Marshal(ping)
+			if err := conn.Write(ctx, websocket.MessageBinary, data); err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			// Text frames still carry JSON
+			if err := writeRequest(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 2, "method": "ping"}); err != nil {
+				t.Fatalf("Failed to send request: %v", err)
+			}
+			for _, id := range []int64{1, 2} {
+				typ, data, err := conn.Read(ctx)
+				if err != nil {
+					t.Fatalf("Failed to read response: %v", err)
+				}
+				if typ != websocket.MessageBinary {
+					t.Fatalf("Expected a binary frame, got %v", typ)
+				}
+				resp, err := c.Unmarshal(data)
+				if err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if got := resp.(map[string]interface{})["id"]; got != id {
+					t.Errorf("Expected response to request %d, got %v", id, resp)
+				}
+			}
+		})
+	}
+
+	t.Run("closes connections sending undecodable messages", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, wsURL, &websocket.DialOptions{Subprotocols: []string{"mcp.cbor"}})
+		if err != nil {
+			t.Fatalf("Failed to dial WebSocket server: %v", err)
+		}
+		defer conn.CloseNow()
+
+		if err := conn.Write(ctx, websocket.MessageBinary, []byte{0xff}); err != nil {
+			t.Fatalf("Failed to send message: %v", err)
+		}
+		_, _, err = conn.Read(ctx)
+		if status := websocket.CloseStatus(err); status != websocket.StatusInvalidFramePayloadData {
+			t.Errorf("Expected close status %v, got %v (%v)", websocket.StatusInvalidFramePayloadData, status, err)
+		}
+	})
+}
@@ -0,0 +1,191 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyPrefix namespaces every key the server writes to a shared store.
+const keyPrefix = "mcp-tools:"
+
+// Coordinator implements the cross-replica primitives built on a Store: shared rate
+// limit and quota counters, run-once leases and the enabled state of scheduled work,
+// session affinity hints recording which instance owns a session, a registry of every
+// replica's sessions, and messages published to every replica.
+type Coordinator struct {
+	store      Store
+	instanceID string
+}
+
+// NewCoordinator creates a Coordinator for the instance with the given ID.
+func NewCoordinator(store Store, instanceID string) *Coordinator {
+	return &Coordinator{
+		store:      store,
+		instanceID: instanceID,
+	}
+}
+
+// InstanceID returns the ID of this server instance.
+func (c *Coordinator) InstanceID() string {
+	return c.instanceID
+}
+
+// Allow counts one use of key in the current fixed window and reports whether the count
+// is still within limit. The count is shared by every replica using the same store.
+func (c *Coordinator) Allow(ctx context.Context, key string, limit int64, window time.Duration) (bool, error) {
+	count, err := c.store.Incr(ctx, keyPrefix+"limit:"+key, window)
+	if err != nil {
+		return false, err
+	}
+	return count <= limit, nil
+}
+
+// RunOnce takes a lease on key for ttl and reports whether this instance won it. Only one
+// replica wins per lease period, so scheduled work is not run twice.
+func (c *Coordinator) RunOnce(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return c.store.SetNX(ctx, keyPrefix+"once:"+key, c.instanceID, ttl)
+}
+
+// SetScheduleEnabled records whether a schedule runs. The state does not expire, so it
+// applies to every replica, including ones started later, until it is set again.
+func (c *Coordinator) SetScheduleEnabled(ctx context.Context, name string, enabled bool) error {
+	return c.store.Set(ctx, keyPrefix+"schedule-enabled:"+name, strconv.FormatBool(enabled), 0)
+}
+
+// ScheduleEnabled returns the recorded state of a schedule. ok is false when it was never
+// set, in which case the schedule's configuration applies.
+func (c *Coordinator) ScheduleEnabled(ctx context.Context, name string) (enabled, ok bool, err error) {
+	value, ok, err := c.store.Get(ctx, keyPrefix+"schedule-enabled:"+name)
+	if err != nil || !ok {
+		return false, false, err
+	}
+	enabled, err = strconv.ParseBool(value)
+	return enabled, err == nil, err
+}
+
+// SetSessionAffinity records this instance as the owner of a session.
+func (c *Coordinator) SetSessionAffinity(ctx context.Context, sessionID string, ttl time.Duration) error {
+	return c.store.Set(ctx, keyPrefix+"session:"+sessionID, c.instanceID, ttl)
+}
+
+// SessionAffinity returns the ID of the instance that owns a session.
+func (c *Coordinator) SessionAffinity(ctx context.Context, sessionID string) (string, bool, error) {
+	return c.store.Get(ctx, keyPrefix+"session:"+sessionID)
+}
+
+// ClearSessionAffinity removes the ownership record of a session.
+func (c *Coordinator) ClearSessionAffinity(ctx context.Context, sessionID string) error {
+	return c.store.Delete(ctx, keyPrefix+"session:"+sessionID)
+}
+
+// SessionRecord describes a session in the shared session registry.
+type SessionRecord struct {
+	ID        string    `json:"id"`
+	Transport string    `json:"transport"`
+	Instance  string    `json:"instance"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"` // Renewed while the owning instance is alive
+}
+
+// RegisterSession adds or renews a session owned by this instance in the registry. The
+// record is dropped after its ExpiresAt unless renewed, so sessions of an instance that
+// died are forgotten.
+func (c *Coordinator) RegisterSession(ctx context.Context, record SessionRecord) error {
+	record.Instance = c.instanceID
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return c.store.SetField(ctx, keyPrefix+"sessions", record.ID, string(encoded))
+}
+
+// UnregisterSession removes a session from the registry.
+func (c *Coordinator) UnregisterSession(ctx context.Context, sessionID string) error {
+	return c.store.DeleteField(ctx, keyPrefix+"sessions", sessionID)
+}
+
+// Sessions returns the registered sessions of every instance ordered by creation time.
+// Expired records are removed.
+func (c *Coordinator) Sessions(ctx context.Context) ([]SessionRecord, error) {
+	fields, err := c.store.Fields(ctx, keyPrefix+"sessions")
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	records := make([]SessionRecord, 0, len(fields))
+	for id, value := range fields {
+		var record SessionRecord
+		if err := json.Unmarshal([]byte(value), &record); err != nil || now.After(record.ExpiresAt) {
+			if err := c.store.DeleteField(ctx, keyPrefix+"sessions", id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// SetSessionValue stores a named value of a session's state for ttl, where every
+// instance can read it.
+func (c *Coordinator) SetSessionValue(ctx context.Context, sessionID, name, value string, ttl time.Duration) error {
+	return c.store.Set(ctx, keyPrefix+"session-state:"+sessionID+":"+name, value, ttl)
+}
+
+// SessionValue returns a named value of a session's state.
+func (c *Coordinator) SessionValue(ctx context.Context, sessionID, name string) (string, bool, error) {
+	return c.store.Get(ctx, keyPrefix+"session-state:"+sessionID+":"+name)
+}
+
+// Publish sends message to every instance subscribed to topic, including this one.
+func (c *Coordinator) Publish(ctx context.Context, topic, message string) error {
+	return c.store.Publish(ctx, keyPrefix+topic, message)
+}
+
+// Subscribe returns the messages published to topic by any instance until ctx is done.
+func (c *Coordinator) Subscribe(ctx context.Context, topic string) (<-chan string, error) {
+	return c.store.Subscribe(ctx, keyPrefix+topic)
+}
+
+// Event is a numbered message of an event stream, such as an SSE stream.
+type Event struct {
+	Seq  int64
+	Data string
+}
+
+// AppendEvent records an event of a stream, keeping the last maxLen events of the stream
+// until ttl after the latest. Every replica using the same store sees the events, so a
+// client can resume the stream on any of them.
+func (c *Coordinator) AppendEvent(ctx context.Context, streamID string, event Event, maxLen int, ttl time.Duration) error {
+	return c.store.Append(ctx, keyPrefix+"events:"+streamID, strconv.FormatInt(event.Seq, 10)+" "+event.Data, maxLen, ttl)
+}
+
+// EventsAfter returns the recorded events of a stream numbered after seq, oldest first.
+func (c *Coordinator) EventsAfter(ctx context.Context, streamID string, seq int64) ([]Event, error) {
+	values, err := c.store.List(ctx, keyPrefix+"events:"+streamID)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, value := range values {
+		number, data, _ := strings.Cut(value, " ")
+		eventSeq, err := strconv.ParseInt(number, 10, 64)
+		if err != nil || eventSeq <= seq {
+			continue
+		}
+		events = append(events, Event{Seq: eventSeq, Data: data})
+	}
+	return events, nil
+}
+
+// Close closes the underlying store.
+func (c *Coordinator) Close() error {
+	return c.store.Close()
+}
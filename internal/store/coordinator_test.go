@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestCoordinator_SharedAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+
+	newReplica := func(id string) *Coordinator {
+		store, err := NewRedisStore("redis://" + server.Addr())
+		if err != nil {
+			t.Fatalf("Failed to create redis store: %v", err)
+		}
+		coordinator := NewCoordinator(store, id)
+		t.Cleanup(func() { _ = coordinator.Close() })
+		return coordinator
+	}
+	a := newReplica("pod-a")
+	b := newReplica("pod-b")
+
+	t.Run("rate limits are shared", func(t *testing.T) {
+		allowed := 0
+		for i := 0; i < 3; i++ {
+			for _, replica := range []*Coordinator{a, b} {
+				ok, err := replica.Allow(ctx, "tool:http_fetch", 4, time.Minute)
+				if err != nil {
+					t.Fatalf("Allow failed: %v", err)
+				}
+				if ok {
+					allowed++
+				}
+			}
+		}
+		if allowed != 4 {
+			t.Errorf("Expected 4 calls allowed across replicas, got %d", allowed)
+		}
+	})
+
+	t.Run("run-once leases have a single winner", func(t *testing.T) {
+		wonA, _ := a.RunOnce(ctx, "schedule:nightly:2026-10-16T00:00", time.Minute)
+		wonB, _ := b.RunOnce(ctx, "schedule:nightly:2026-10-16T00:00", time.Minute)
+		if wonA == wonB {
+			t.Errorf("Expected exactly one winner, got a=%v b=%v", wonA, wonB)
+		}
+	})
+
+	t.Run("schedule state is shared", func(t *testing.T) {
+		if _, ok, err := b.ScheduleEnabled(ctx, "nightly"); ok || err != nil {
+			t.Errorf("Expected no state before a toggle, got %v %v", ok, err)
+		}
+		if err := a.SetScheduleEnabled(ctx, "nightly", false); err != nil {
+			t.Fatalf("SetScheduleEnabled failed: %v", err)
+		}
+		enabled, ok, err := b.ScheduleEnabled(ctx, "nightly")
+		if err != nil || !ok || enabled {
+			t.Errorf("Expected the schedule to be disabled on every replica, got %v %v %v", enabled, ok, err)
+		}
+	})
+
+	t.Run("session affinity is visible to other replicas", func(t *testing.T) {
+		if err := a.SetSessionAffinity(ctx, "session-1", time.Hour); err != nil {
+			t.Fatalf("SetSessionAffinity failed: %v", err)
+		}
+		owner, ok, err := b.SessionAffinity(ctx, "session-1")
+		if err != nil || !ok || owner != "pod-a" {
+			t.Errorf("Expected owner pod-a, got %q %v %v", owner, ok, err)
+		}
+		_ = a.ClearSessionAffinity(ctx, "session-1")
+		if _, ok, _ := b.SessionAffinity(ctx, "session-1"); ok {
+			t.Error("Expected affinity to be cleared")
+		}
+	})
+
+	t.Run("events can be resumed on another replica", func(t *testing.T) {
+		for seq := int64(1); seq <= 3; seq++ {
+			if err := a.AppendEvent(ctx, "stream-1", Event{Seq: seq, Data: `{"n": 1}`}, 10, time.Minute); err != nil {
+				t.Fatalf("AppendEvent failed: %v", err)
+			}
+		}
+		events, err := b.EventsAfter(ctx, "stream-1", 1)
+		if err != nil || len(events) != 2 || events[0].Seq != 2 || events[0].Data != `{"n": 1}` {
+			t.Errorf("Expected events 2 and 3, got %+v %v", events, err)
+		}
+	})
+
+	t.Run("sessions are registered for every replica", func(t *testing.T) {
+		now := time.Now()
+		_ = a.RegisterSession(ctx, SessionRecord{ID: "session-a", Transport: "websocket", CreatedAt: now, ExpiresAt: now.Add(time.Minute)})
+		_ = b.RegisterSession(ctx, SessionRecord{ID: "session-b", Transport: "streamable", CreatedAt: now.Add(time.Second), ExpiresAt: now.Add(time.Minute)})
+		_ = b.RegisterSession(ctx, SessionRecord{ID: "session-dead", CreatedAt: now, ExpiresAt: now.Add(-time.Second)})
+
+		records, err := a.Sessions(ctx)
+		if err != nil || len(records) != 2 {
+			t.Fatalf("Expected 2 live sessions, got %+v %v", records, err)
+		}
+		if records[0].ID != "session-a" || records[0].Instance != "pod-a" || records[1].Instance != "pod-b" {
+			t.Errorf("Expected sessions ordered by creation with their instances, got %+v", records)
+		}
+		_ = b.UnregisterSession(ctx, "session-b")
+		if records, _ := a.Sessions(ctx); len(records) != 1 {
+			t.Errorf("Expected 1 session after unregistering, got %+v", records)
+		}
+	})
+
+	t.Run("session state is visible to other replicas", func(t *testing.T) {
+		_ = a.SetSessionValue(ctx, "session-1", "protocolVersion", "2025-06-18", time.Hour)
+		value, ok, err := b.SessionValue(ctx, "session-1", "protocolVersion")
+		if err != nil || !ok || value != "2025-06-18" {
+			t.Errorf("Expected 2025-06-18, got %q %v %v", value, ok, err)
+		}
+	})
+
+	t.Run("published messages reach other replicas", func(t *testing.T) {
+		subscribeCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		messages, err := b.Subscribe(subscribeCtx, "broadcasts")
+		if err != nil {
+			t.Fatalf("Subscribe failed: %v", err)
+		}
+		_ = a.Publish(ctx, "broadcasts", "hello")
+		select {
+		case message := <-messages:
+			if message != "hello" {
+				t.Errorf("Expected 'hello', got %q", message)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for the published message")
+		}
+	})
+}
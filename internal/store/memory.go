@@ -0,0 +1,234 @@
+package store
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryEntry is a stored value, list, or map and its optional expiry.
+type memoryEntry struct {
+	value     string
+	list      []string
+	fields    map[string]string
+	expiresAt time.Time
+}
+
+// subscriberBuffer is how many published messages a subscriber of a MemoryStore can fall
+// behind before further messages to it are dropped.
+const subscriberBuffer = 64
+
+// expired reports whether the entry has passed its expiry.
+func (e memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryStore is an in-process Store. It is the default and is only suitable for a
+// single instance, since replicas cannot see each other's state.
+type MemoryStore struct {
+	entries     map[string]memoryEntry
+	subscribers map[string]map[chan string]struct{}
+	mu          sync.Mutex
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:     make(map[string]memoryEntry),
+		subscribers: make(map[string]map[chan string]struct{}),
+	}
+}
+
+// lookup returns the live entry at key, evicting it if it has expired. Callers hold mu.
+func (s *MemoryStore) lookup(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := s.entries[key]
+	if ok && entry.expired(now) {
+		delete(s.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, ok
+}
+
+// expiry converts a ttl into an absolute expiry time; zero means no expiry.
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return now.Add(ttl)
+}
+
+// Incr implements Store.
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := s.lookup(key, now)
+	if !ok {
+		s.entries[key] = memoryEntry{value: "1", expiresAt: expiry(now, window)}
+		return 1, nil
+	}
+
+	count, err := strconv.ParseInt(entry.value, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	s.entries[key] = entry
+	return count, nil
+}
+
+// SetNX implements Store.
+func (s *MemoryStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := s.lookup(key, now); ok {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{value: value, expiresAt: expiry(now, ttl)}
+	return true, nil
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[key] = memoryEntry{value: value, expiresAt: expiry(time.Now(), ttl)}
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(ctx context.Context, key string) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.lookup(key, time.Now())
+	return entry.value, ok, nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
+
+// Append implements Store.
+func (s *MemoryStore) Append(ctx context.Context, key, value string, maxLen int, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry, _ := s.lookup(key, now)
+	entry.list = append(entry.list, value)
+	if maxLen > 0 && len(entry.list) > maxLen {
+		entry.list = append([]string(nil), entry.list[len(entry.list)-maxLen:]...)
+	}
+	entry.expiresAt = expiry(now, ttl)
+	s.entries[key] = entry
+	return nil
+}
+
+// List implements Store.
+func (s *MemoryStore) List(ctx context.Context, key string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, _ := s.lookup(key, time.Now())
+	return append([]string(nil), entry.list...), nil
+}
+
+// SetField implements Store.
+func (s *MemoryStore) SetField(ctx context.Context, key, field, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, _ := s.lookup(key, time.Now())
+	fields := make(map[string]string, len(entry.fields)+1)
+	for name, current := range entry.fields {
+		fields[name] = current
+	}
+	fields[field] = value
+	entry.fields = fields
+	s.entries[key] = entry
+	return nil
+}
+
+// Fields implements Store.
+func (s *MemoryStore) Fields(ctx context.Context, key string) (map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, _ := s.lookup(key, time.Now())
+	fields := make(map[string]string, len(entry.fields))
+	for name, value := range entry.fields {
+		fields[name] = value
+	}
+	return fields, nil
+}
+
+// DeleteField implements Store.
+func (s *MemoryStore) DeleteField(ctx context.Context, key, field string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.lookup(key, time.Now())
+	if !ok {
+		return nil
+	}
+	fields := make(map[string]string, len(entry.fields))
+	for name, value := range entry.fields {
+		if name != field {
+			fields[name] = value
+		}
+	}
+	entry.fields = fields
+	s.entries[key] = entry
+	return nil
+}
+
+// Publish implements Store. Subscribers that have fallen behind miss the message.
+func (s *MemoryStore) Publish(ctx context.Context, channel, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for subscriber := range s.subscribers[channel] {
+		select {
+		case subscriber <- message:
+		default:
+		}
+	}
+	return nil
+}
+
+// Subscribe implements Store.
+func (s *MemoryStore) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	subscriber := make(chan string, subscriberBuffer)
+	s.mu.Lock()
+	if s.subscribers[channel] == nil {
+		s.subscribers[channel] = make(map[chan string]struct{})
+	}
+	s.subscribers[channel][subscriber] = struct{}{}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.subscribers[channel], subscriber)
+		close(subscriber)
+	}()
+	return subscriber, nil
+}
+
+// Close implements Store.
+func (s *MemoryStore) Close() error {
+	return nil
+}
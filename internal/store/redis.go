@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// incrScript increments KEYS[1] and, when the increment opens the window, sets its
+// expiry to ARGV[1] milliseconds. Running both in one script keeps a crash or lost
+// connection between them from leaving a counter that never expires.
+var incrScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 and tonumber(ARGV[1]) > 0 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// RedisStore is a Store backed by Redis, shared by every replica pointing at the same server.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a RedisStore from a redis:// or rediss:// URL.
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	return &RedisStore{client: redis.NewClient(opts)}, nil
+}
+
+// Incr implements Store. The increment and the expiry of a new window are atomic.
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	return incrScript.Run(ctx, s.client, []string{key}, window.Milliseconds()).Int64()
+}
+
+// SetNX implements Store.
+func (s *RedisStore) SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, key, value, ttl).Result()
+}
+
+// Set implements Store.
+func (s *RedisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return s.client.Set(ctx, key, value, ttl).Err()
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, key string) (string, bool, error) {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// Delete implements Store.
+func (s *RedisStore) Delete(ctx context.Context, key string) error {
+	return s.client.Del(ctx, key).Err()
+}
+
+// Append implements Store.
+func (s *RedisStore) Append(ctx context.Context, key, value string, maxLen int, ttl time.Duration) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.RPush(ctx, key, value)
+		if maxLen > 0 {
+			pipe.LTrim(ctx, key, int64(-maxLen), -1)
+		}
+		if ttl > 0 {
+			pipe.PExpire(ctx, key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+// List implements Store.
+func (s *RedisStore) List(ctx context.Context, key string) ([]string, error) {
+	return s.client.LRange(ctx, key, 0, -1).Result()
+}
+
+// SetField implements Store.
+func (s *RedisStore) SetField(ctx context.Context, key, field, value string) error {
+	return s.client.HSet(ctx, key, field, value).Err()
+}
+
+// Fields implements Store.
+func (s *RedisStore) Fields(ctx context.Context, key string) (map[string]string, error) {
+	return s.client.HGetAll(ctx, key).Result()
+}
+
+// DeleteField implements Store.
+func (s *RedisStore) DeleteField(ctx context.Context, key, field string) error {
+	return s.client.HDel(ctx, key, field).Err()
+}
+
+// Publish implements Store.
+func (s *RedisStore) Publish(ctx context.Context, channel, message string) error {
+	return s.client.Publish(ctx, channel, message).Err()
+}
+
+// Subscribe implements Store. It returns once Redis has confirmed the subscription, so
+// messages published after it returns are received.
+func (s *RedisStore) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := s.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+	messages := make(chan string)
+	go func() {
+		defer close(messages)
+		defer pubsub.Close()
+		incoming := pubsub.Channel()
+		for {
+			select {
+			case message, ok := <-incoming:
+				if !ok {
+					return
+				}
+				select {
+				case messages <- message.Payload:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return messages, nil
+}
+
+// Close implements Store.
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
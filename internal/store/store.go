@@ -0,0 +1,57 @@
+// Package store provides the shared state used to coordinate multiple server replicas.
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Store is key/value state with expiry. The memory Store serves a single instance; the
+// Redis Store lets replicas running behind a load balancer share state.
+type Store interface {
+	// Incr increments the counter at key and returns the new count. A key that does not
+	// exist starts a new counter that expires after window.
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+	// SetNX stores value at key only if the key is absent, reporting whether it was stored.
+	SetNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// Set stores value at key. A zero ttl means the key does not expire.
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// Get returns the value at key and whether it exists.
+	Get(ctx context.Context, key string) (string, bool, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+	// Append adds value to the end of the list at key, keeps only its last maxLen values,
+	// and makes the list expire ttl after this append. A zero ttl means it does not expire.
+	Append(ctx context.Context, key, value string, maxLen int, ttl time.Duration) error
+	// List returns the values of the list at key, oldest first, or none if it is absent.
+	List(ctx context.Context, key string) ([]string, error)
+	// SetField stores value in field of the map at key.
+	SetField(ctx context.Context, key, field, value string) error
+	// Fields returns the fields of the map at key, or none if it is absent.
+	Fields(ctx context.Context, key string) (map[string]string, error)
+	// DeleteField removes field from the map at key.
+	DeleteField(ctx context.Context, key, field string) error
+	// Publish sends message to the current subscribers of channel.
+	Publish(ctx context.Context, channel, message string) error
+	// Subscribe returns the messages published to channel from now on. The returned
+	// channel is closed once ctx is done.
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+	// Close releases any resources held by the store.
+	Close() error
+}
+
+// New creates a Store for the given backend ("memory" or "redis").
+func New(backend, redisURL string) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis store backend")
+		}
+		return NewRedisStore(redisURL)
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s", backend)
+	}
+}
@@ -0,0 +1,180 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// storeFactories returns a fresh instance of every Store implementation.
+func storeFactories(t *testing.T) map[string]func() Store {
+	return map[string]func() Store{
+		"memory": func() Store { return NewMemoryStore() },
+		"redis": func() Store {
+			server := miniredis.RunT(t)
+			store, err := NewRedisStore("redis://" + server.Addr())
+			if err != nil {
+				t.Fatalf("Failed to create redis store: %v", err)
+			}
+			t.Cleanup(func() { _ = store.Close() })
+			return store
+		},
+	}
+}
+
+func TestStore_Contract(t *testing.T) {
+	ctx := context.Background()
+
+	for name, newStore := range storeFactories(t) {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Incr counts within a window", func(t *testing.T) {
+				store := newStore()
+				for want := int64(1); want <= 3; want++ {
+					got, err := store.Incr(ctx, "counter", time.Minute)
+					if err != nil {
+						t.Fatalf("Incr failed: %v", err)
+					}
+					if got != want {
+						t.Errorf("Expected count %d, got %d", want, got)
+					}
+				}
+			})
+
+			t.Run("Incr expires the window it opens", func(t *testing.T) {
+				store := newStore()
+				if _, err := store.Incr(ctx, "window", 50*time.Millisecond); err != nil {
+					t.Fatalf("Incr failed: %v", err)
+				}
+				if redis, ok := store.(*RedisStore); ok {
+					ttl, err := redis.client.PTTL(ctx, "window").Result()
+					if err != nil || ttl <= 0 {
+						t.Fatalf("Expected the first increment to set an expiry, got %v (%v)", ttl, err)
+					}
+				}
+			})
+
+			t.Run("SetNX only sets absent keys", func(t *testing.T) {
+				store := newStore()
+				ok, err := store.SetNX(ctx, "lease", "a", time.Minute)
+				if err != nil || !ok {
+					t.Fatalf("Expected first SetNX to succeed, got ok=%v err=%v", ok, err)
+				}
+				ok, err = store.SetNX(ctx, "lease", "b", time.Minute)
+				if err != nil || ok {
+					t.Fatalf("Expected second SetNX to fail, got ok=%v err=%v", ok, err)
+				}
+				value, _, _ := store.Get(ctx, "lease")
+				if value != "a" {
+					t.Errorf("Expected value 'a', got %s", value)
+				}
+			})
+
+			t.Run("Set, Get, and Delete", func(t *testing.T) {
+				store := newStore()
+				if _, ok, _ := store.Get(ctx, "missing"); ok {
+					t.Error("Expected missing key not to exist")
+				}
+				if err := store.Set(ctx, "key", "value", 0); err != nil {
+					t.Fatalf("Set failed: %v", err)
+				}
+				value, ok, err := store.Get(ctx, "key")
+				if err != nil || !ok || value != "value" {
+					t.Errorf("Unexpected Get result: %q %v %v", value, ok, err)
+				}
+				if err := store.Delete(ctx, "key"); err != nil {
+					t.Fatalf("Delete failed: %v", err)
+				}
+				if _, ok, _ := store.Get(ctx, "key"); ok {
+					t.Error("Expected key to be deleted")
+				}
+			})
+
+			t.Run("Append keeps the last values in order", func(t *testing.T) {
+				store := newStore()
+				if values, err := store.List(ctx, "events"); err != nil || len(values) != 0 {
+					t.Fatalf("Expected an absent list to be empty, got %v %v", values, err)
+				}
+				for _, value := range []string{"a", "b", "c", "d"} {
+					if err := store.Append(ctx, "events", value, 3, time.Minute); err != nil {
+						t.Fatalf("Append failed: %v", err)
+					}
+				}
+				values, err := store.List(ctx, "events")
+				if err != nil || len(values) != 3 || values[0] != "b" || values[2] != "d" {
+					t.Errorf("Expected [b c d], got %v %v", values, err)
+				}
+			})
+
+			t.Run("fields are set and deleted", func(t *testing.T) {
+				store := newStore()
+				_ = store.SetField(ctx, "map", "a", "1")
+				_ = store.SetField(ctx, "map", "b", "2")
+				_ = store.DeleteField(ctx, "map", "a")
+				fields, err := store.Fields(ctx, "map")
+				if err != nil || len(fields) != 1 || fields["b"] != "2" {
+					t.Errorf("Expected {b: 2}, got %v %v", fields, err)
+				}
+				if fields, _ := store.Fields(ctx, "absent"); len(fields) != 0 {
+					t.Errorf("Expected an absent map to be empty, got %v", fields)
+				}
+			})
+
+			t.Run("Publish reaches subscribers", func(t *testing.T) {
+				store := newStore()
+				subscribeCtx, cancel := context.WithCancel(ctx)
+				messages, err := store.Subscribe(subscribeCtx, "channel")
+				if err != nil {
+					t.Fatalf("Subscribe failed: %v", err)
+				}
+				if err := store.Publish(ctx, "channel", "hello"); err != nil {
+					t.Fatalf("Publish failed: %v", err)
+				}
+				select {
+				case message := <-messages:
+					if message != "hello" {
+						t.Errorf("Expected 'hello', got %q", message)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatal("Timed out waiting for the published message")
+				}
+				cancel()
+				for range messages {
+				}
+			})
+		})
+	}
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+
+	_ = store.Set(ctx, "short", "value", 10*time.Millisecond)
+	_, _ = store.Incr(ctx, "window", 10*time.Millisecond)
+	_, _ = store.Incr(ctx, "window", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if _, ok, _ := store.Get(ctx, "short"); ok {
+		t.Error("Expected key to expire")
+	}
+	if count, _ := store.Incr(ctx, "window", 10*time.Millisecond); count != 1 {
+		t.Errorf("Expected a new window to start at 1, got %d", count)
+	}
+	if ok, _ := store.SetNX(ctx, "short", "again", time.Minute); !ok {
+		t.Error("Expected SetNX to succeed after expiry")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if store, err := New("", ""); err != nil || store == nil {
+		t.Errorf("Expected default memory store, got %v %v", store, err)
+	}
+	if _, err := New("redis", ""); err == nil {
+		t.Error("Expected error for redis backend without URL")
+	}
+	if _, err := New("etcd", ""); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
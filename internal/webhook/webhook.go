@@ -0,0 +1,187 @@
+// Package webhook delivers server events to operator-configured HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Headers sent with every delivery.
+const (
+	EventHeader     = "X-Webhook-Event"     // The event type
+	DeliveryHeader  = "X-Webhook-Delivery"  // A unique ID, unchanged across retries
+	SignatureHeader = "X-Webhook-Signature" // "sha256=" and the hex HMAC of the body
+)
+
+// queueSize is how many undelivered events may wait before new ones are dropped.
+const queueSize = 256
+
+// Config describes where and how events are delivered.
+type Config struct {
+	URLs       []string      // Endpoints that receive every event
+	Secret     string        // Key for the HMAC-SHA256 body signature; empty disables signing
+	MaxRetries int           // Retries after a failed delivery, with exponential backoff
+	Timeout    time.Duration // Timeout for each delivery attempt
+}
+
+// delivery is one event waiting to be sent.
+type delivery struct {
+	id        string
+	eventType string
+	body      []byte
+}
+
+// Notifier posts events as JSON to the configured URLs from a background goroutine.
+// Failed deliveries (network errors, 429, and 5xx responses) are retried; other 4xx
+// responses are not.
+type Notifier struct {
+	config  Config
+	client  *http.Client
+	queue   chan delivery
+	backoff time.Duration
+	logger  *slog.Logger
+
+	mu     sync.Mutex // Guards closed so Send never writes to a closed queue
+	closed bool
+	done   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a Notifier and starts its delivery goroutine.
+func New(config Config, logger *slog.Logger) *Notifier {
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &Notifier{
+		config:  config,
+		client:  &http.Client{Timeout: config.Timeout},
+		queue:   make(chan delivery, queueSize),
+		backoff: time.Second,
+		logger:  logger,
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	go n.run()
+	return n
+}
+
+// Send queues payload, encoded as JSON, for delivery to every URL. It never blocks; if
+// the queue is full or the Notifier is closed the event is dropped and logged.
+func (n *Notifier) Send(eventType string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		n.logger.Error("Failed to encode webhook payload", "event", eventType, "error", err)
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		n.logger.Warn("Dropped webhook event, notifier is closed", "event", eventType)
+		return
+	}
+	select {
+	case n.queue <- delivery{id: uuid.NewString(), eventType: eventType, body: body}:
+	default:
+		n.logger.Warn("Dropped webhook event, delivery queue is full", "event", eventType)
+	}
+}
+
+// Close stops accepting events and waits for queued ones to be delivered. If ctx ends
+// first, pending retries are abandoned and ctx's error is returned.
+func (n *Notifier) Close(ctx context.Context) error {
+	n.mu.Lock()
+	if !n.closed {
+		n.closed = true
+		close(n.queue)
+	}
+	n.mu.Unlock()
+	select {
+	case <-n.done:
+		return nil
+	case <-ctx.Done():
+		n.cancel()
+		<-n.done
+		return ctx.Err()
+	}
+}
+
+// run delivers queued events until the queue is closed.
+func (n *Notifier) run() {
+	defer close(n.done)
+	for d := range n.queue {
+		for _, url := range n.config.URLs {
+			n.deliver(url, d)
+		}
+	}
+}
+
+// deliver posts d to url, retrying with exponential backoff.
+func (n *Notifier) deliver(url string, d delivery) {
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(url, d)
+		if err == nil {
+			n.logger.Debug("Webhook delivered", "url", url, "event", d.eventType, "delivery", d.id)
+			return
+		}
+		if !retry || attempt >= n.config.MaxRetries {
+			n.logger.Warn("Webhook delivery failed", "url", url, "event", d.eventType, "delivery", d.id, "attempts", attempt+1, "error", err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-n.ctx.Done():
+			n.logger.Warn("Webhook delivery abandoned on shutdown", "url", url, "event", d.eventType, "delivery", d.id)
+			return
+		}
+	}
+}
+
+// post makes one delivery attempt and reports whether a failure is worth retrying.
+func (n *Notifier) post(url string, d delivery) (bool, error) {
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, url, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, d.eventType)
+	req.Header.Set(DeliveryHeader, d.id)
+	if n.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(n.config.Secret, d.body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+}
+
+// Sign returns the signature header value for body: "sha256=" followed by the hex
+// HMAC-SHA256 of body keyed with secret. Receivers should recompute it and compare with
+// hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
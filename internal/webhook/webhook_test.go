@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestNotifier creates a Notifier whose retries back off by only a millisecond.
+func newTestNotifier(config Config) *Notifier {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	n := New(config, logger)
+	n.backoff = time.Millisecond
+	return n
+}
+
+func TestNotifier(t *testing.T) {
+	t.Run("delivers signed events", func(t *testing.T) {
+		received := make(chan *http.Request, 1)
+		var body []byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ = io.ReadAll(r.Body)
+			received <- r
+		}))
+		defer server.Close()
+
+		n := newTestNotifier(Config{URLs: []string{server.URL}, Secret: "s3cret", Timeout: time.Second})
+		n.Send("server.shutdown", map[string]string{"reason": "signal"})
+		if err := n.Close(context.Background()); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		r := <-received
+		if r.Header.Get(EventHeader) != "server.shutdown" || r.Header.Get(DeliveryHeader) == "" {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+		if got := r.Header.Get(SignatureHeader); got != Sign("s3cret", body) {
+			t.Errorf("Expected signature %s, got %s", Sign("s3cret", body), got)
+		}
+		if string(body) != `{"reason":"signal"}` {
+			t.Errorf("Unexpected body: %s", body)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		n := newTestNotifier(Config{URLs: []string{server.URL}, MaxRetries: 3, Timeout: time.Second})
+		n.Send("tool.quarantined", nil)
+		_ = n.Close(context.Background())
+		if got := attempts.Load(); got != 3 {
+			t.Errorf("Expected 3 attempts, got %d", got)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		n := newTestNotifier(Config{URLs: []string{server.URL}, MaxRetries: 3, Timeout: time.Second})
+		n.Send("tool.quarantined", nil)
+		_ = n.Close(context.Background())
+		if got := attempts.Load(); got != 1 {
+			t.Errorf("Expected 1 attempt, got %d", got)
+		}
+	})
+
+	t.Run("close gives up when the context ends", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		n := newTestNotifier(Config{URLs: []string{server.URL}, MaxRetries: 100, Timeout: time.Second})
+		n.backoff = time.Hour
+		n.Send("tool.quarantined", nil)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := n.Close(ctx); err == nil {
+			t.Error("Expected Close to report the expired context")
+		}
+	})
+}
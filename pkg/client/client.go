@@ -0,0 +1,199 @@
+// Package client provides typed Go clients for the MCP tools server: Client for the REST
+// API and MCPClient for the Streamable HTTP MCP endpoint.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StatusError is returned when the REST API responds with a non-success status code.
+// Code and RequestID are filled in from the server's JSON error envelope when present.
+type StatusError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *StatusError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("server returned status %d (%s): %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("server returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// newStatusError builds a StatusError from an error response body, decoding the
+// {"error": {"code", "message", "requestId"}} envelope when the body contains one.
+func newStatusError(statusCode int, body []byte) *StatusError {
+	var envelope struct {
+		Error struct {
+			Code      string `json:"code"`
+			Message   string `json:"message"`
+			RequestID string `json:"requestId"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err == nil && envelope.Error.Code != "" {
+		return &StatusError{
+			StatusCode: statusCode,
+			Code:       envelope.Error.Code,
+			Message:    envelope.Error.Message,
+			RequestID:  envelope.Error.RequestID,
+		}
+	}
+	return &StatusError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+}
+
+// Client calls the REST API of the MCP tools server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a REST client for the server at baseURL (e.g. http://localhost:8080). A nil
+// httpClient uses http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// ListTools returns the available tools mapped to their descriptions, following the
+// server's Link headers when it lists them in pages.
+func (c *Client) ListTools(ctx context.Context) (map[string]string, error) {
+	tools := make(map[string]string)
+	path := "/api/v1/list"
+	for path != "" {
+		var page map[string]string
+		header, err := c.doHeader(ctx, http.MethodGet, path, nil, &page)
+		if err != nil {
+			return nil, err
+		}
+		for name, description := range page {
+			tools[name] = description
+		}
+		path = nextLink(header)
+	}
+	return tools, nil
+}
+
+// CallTool executes a tool with the given arguments and returns its result.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := c.do(ctx, http.MethodPost, "/api/v1/tools/"+url.PathEscape(name), args, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GenerateUUID returns a new UUID from the /api/v1/uuid endpoint.
+func (c *Client) GenerateUUID(ctx context.Context) (string, error) {
+	var result struct {
+		UUID string `json:"uuid"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/api/v1/uuid", nil, &result); err != nil {
+		return "", err
+	}
+	return result.UUID, nil
+}
+
+// Health reports whether the server's /health endpoint responds as healthy.
+func (c *Client) Health(ctx context.Context) error {
+	var result struct {
+		Status string `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/health", nil, &result); err != nil {
+		return err
+	}
+	if result.Status != "healthy" {
+		return fmt.Errorf("server reported status: %s", result.Status)
+	}
+	return nil
+}
+
+// Ready reports whether the server's /readyz probe passes.
+func (c *Client) Ready(ctx context.Context) (bool, error) {
+	err := c.do(ctx, http.MethodGet, "/readyz", nil, nil)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusServiceUnavailable {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	_, err := c.doHeader(ctx, method, path, body, out)
+	return err
+}
+
+// doHeader is do, also returning the response headers.
+func (c *Client) doHeader(ctx context.Context, method, path string, body, out interface{}) (http.Header, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, newStatusError(resp.StatusCode, message)
+	}
+	if out == nil {
+		return resp.Header, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return resp.Header, nil
+}
+
+// nextLink returns the path of the Link header entry with rel="next", or "" when there is
+// none. Only paths on the same server are followed.
+func nextLink(header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.Contains(strings.ReplaceAll(params, " ", ""), `rel="next"`) {
+				continue
+			}
+			target = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(target), "<"), ">")
+			if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+				return target
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"mcp-tools-server/internal/server"
+	"mcp-tools-server/pkg/tools"
+)
+
+// newTestToolService creates a ToolService from the built-in tool registry.
+func newTestToolService(t *testing.T) *server.ToolService {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService, err := server.NewToolService(tools.NewToolRegistry(), logger)
+	if err != nil {
+		t.Fatalf("Failed to create tool service: %v", err)
+	}
+	return toolService
+}
+
+func TestClient(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	httpServer := server.NewHTTPServer(newTestToolService(t), server.WithLogger(logger))
+	testServer := httptest.NewServer(httpServer.Handler())
+	defer testServer.Close()
+
+	c := New(testServer.URL+"/", nil)
+	ctx := context.Background()
+
+	t.Run("ListTools", func(t *testing.T) {
+		listed, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		if _, ok := listed["generate_uuid"]; !ok {
+			t.Errorf("Expected generate_uuid, got %v", listed)
+		}
+	})
+
+	t.Run("ListTools follows pages", func(t *testing.T) {
+		all, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		toolService := newTestToolService(t)
+		toolService.SetListPageSize(2)
+		paged := httptest.NewServer(server.NewHTTPServer(toolService, server.WithLogger(logger)).Handler())
+		defer paged.Close()
+		listed, err := New(paged.URL, nil).ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		if len(listed) != len(all) {
+			t.Errorf("Expected %d tools across pages, got %d", len(all), len(listed))
+		}
+	})
+
+	t.Run("CallTool", func(t *testing.T) {
+		result, err := c.CallTool(ctx, "generate_uuid", map[string]interface{}{"version": "v7"})
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if id, _ := result["uuid"].(string); len(id) != 36 {
+			t.Errorf("Expected a UUID, got %v", result)
+		}
+	})
+
+	t.Run("CallTool unknown tool returns StatusError", func(t *testing.T) {
+		_, err := c.CallTool(ctx, "missing", nil)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("Expected a 404 StatusError, got %v", err)
+		}
+		if statusErr.Code != "tool_not_found" || statusErr.RequestID == "" {
+			t.Errorf("Expected the error envelope to be decoded, got %+v", statusErr)
+		}
+	})
+
+	t.Run("GenerateUUID", func(t *testing.T) {
+		id, err := c.GenerateUUID(ctx)
+		if err != nil || len(id) != 36 {
+			t.Errorf("Expected a UUID, got %q (%v)", id, err)
+		}
+	})
+
+	t.Run("Health and Ready", func(t *testing.T) {
+		if err := c.Health(ctx); err != nil {
+			t.Errorf("Health failed: %v", err)
+		}
+		ready, err := c.Ready(ctx)
+		if err != nil || !ready {
+			t.Errorf("Expected ready, got %v (%v)", ready, err)
+		}
+	})
+}
+
+func TestMCPClient(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	streamable := server.NewStreamableHTTPServer(newTestToolService(t), server.WithLogger(logger))
+	testServer := httptest.NewServer(streamable.Handler())
+	defer testServer.Close()
+
+	c := NewMCPClient(testServer.URL+"/mcp", nil)
+	ctx := context.Background()
+
+	t.Run("Initialize", func(t *testing.T) {
+		result, err := c.Initialize(ctx)
+		if err != nil {
+			t.Fatalf("Initialize failed: %v", err)
+		}
+		if result.ProtocolVersion == "" || result.ServerInfo["name"] != "mcp-tools-server" {
+			t.Errorf("Unexpected initialize result: %+v", result)
+		}
+	})
+
+	t.Run("ListTools", func(t *testing.T) {
+		listed, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		found := false
+		for _, tool := range listed {
+			if tool.Name == "generate_uuid" {
+				found = tool.Category == "generators" && tool.InputSchema["type"] == "object"
+			}
+		}
+		if !found {
+			t.Errorf("Expected generate_uuid with metadata, got %+v", listed)
+		}
+	})
+
+	t.Run("ListTools follows cursors", func(t *testing.T) {
+		all, err := c.ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		toolService := newTestToolService(t)
+		toolService.SetListPageSize(2)
+		paged := httptest.NewServer(server.NewStreamableHTTPServer(toolService, server.WithLogger(logger)).Handler())
+		defer paged.Close()
+		listed, err := NewMCPClient(paged.URL+"/mcp", nil).ListTools(ctx)
+		if err != nil {
+			t.Fatalf("ListTools failed: %v", err)
+		}
+		if len(listed) != len(all) {
+			t.Errorf("Expected %d tools across pages, got %d", len(all), len(listed))
+		}
+	})
+
+	t.Run("CallTool", func(t *testing.T) {
+		result, err := c.CallTool(ctx, "generate_uuid", nil)
+		if err != nil {
+			t.Fatalf("CallTool failed: %v", err)
+		}
+		if id, _ := result["uuid"].(string); len(id) != 36 {
+			t.Errorf("Expected a UUID, got %v", result)
+		}
+	})
+
+	t.Run("errors are returned as RPCError", func(t *testing.T) {
+		_, err := c.CallTool(ctx, "missing", nil)
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != -32000 {
+			t.Errorf("Expected a -32000 RPCError, got %v", err)
+		}
+		if err := c.Call(ctx, "no/such/method", nil, nil); !errors.As(err, &rpcErr) || rpcErr.Code != -32601 {
+			t.Errorf("Expected a -32601 RPCError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// RPCError is a JSON-RPC error returned by the MCP endpoint.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// ToolError is a tool call whose tool ran and failed: a tool result with isError set.
+type ToolError struct {
+	Message string // The text content of the result
+}
+
+func (e *ToolError) Error() string {
+	return "tool failed: " + e.Message
+}
+
+// Tool describes a tool advertised by tools/list.
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Category    string                 `json:"category,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+
+	// OutputSchema describes the tool's results, which CallTool returns; nil when the
+	// tool does not declare one
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+}
+
+// InitializeResult is the server's response to initialize.
+type InitializeResult struct {
+	ProtocolVersion string                 `json:"protocolVersion"`
+	Capabilities    map[string]interface{} `json:"capabilities"`
+	ServerInfo      map[string]interface{} `json:"serverInfo"`
+}
+
+// MCPClient speaks JSON-RPC to the Streamable HTTP MCP endpoint. It is safe for
+// concurrent use.
+type MCPClient struct {
+	endpoint   string
+	httpClient *http.Client
+	nextID     atomic.Int64
+
+	// sessionID is the Mcp-Session-Id the server issued on initialize, sent with every
+	// later request
+	sessionID atomic.Value
+}
+
+// NewMCPClient creates a client for the MCP endpoint (e.g. http://localhost:8081/mcp). A
+// nil httpClient uses http.DefaultClient.
+func NewMCPClient(endpoint string, httpClient *http.Client) *MCPClient {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &MCPClient{
+		endpoint:   endpoint,
+		httpClient: httpClient,
+	}
+}
+
+// Initialize performs the initialize handshake and returns the server's capabilities.
+func (c *MCPClient) Initialize(ctx context.Context) (*InitializeResult, error) {
+	var result InitializeResult
+	if err := c.Call(ctx, "initialize", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListTools returns the tools advertised by the server, following its cursors when it
+// lists them in pages.
+func (c *MCPClient) ListTools(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	var params map[string]interface{}
+	for {
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.Call(ctx, "tools/list", params, &result); err != nil {
+			return nil, err
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		params = map[string]interface{}{"cursor": result.NextCursor}
+	}
+}
+
+// CallTool executes a tool with the given arguments and returns its result: the
+// structured content of the MCP tool result when the server sends it, else the whole
+// result. A tool that fails is returned as *ToolError, and a call the server refuses,
+// such as one to an unknown tool, as *RPCError.
+func (c *MCPClient) CallTool(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+	params := map[string]interface{}{"name": name}
+	if args != nil {
+		params["arguments"] = args
+	}
+	var result map[string]interface{}
+	if err := c.Call(ctx, "tools/call", params, &result); err != nil {
+		return nil, err
+	}
+	if isError, _ := result["isError"].(bool); isError {
+		var text []string
+		content, _ := result["content"].([]interface{})
+		for _, block := range content {
+			if block, ok := block.(map[string]interface{}); ok && block["type"] == "text" {
+				s, _ := block["text"].(string)
+				text = append(text, s)
+			}
+		}
+		return nil, &ToolError{Message: strings.Join(text, "\n")}
+	}
+	if structured, ok := result["structuredContent"].(map[string]interface{}); ok {
+		return structured, nil
+	}
+	return result, nil
+}
+
+// Call sends a JSON-RPC request and decodes its result into out. A JSON-RPC error
+// response is returned as *RPCError.
+func (c *MCPClient) Call(ctx context.Context, method string, params, out interface{}) error {
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      c.nextID.Add(1),
+		"method":  method,
+	}
+	if params != nil {
+		request["params"] = params
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id, _ := c.sessionID.Load().(string); id != "" {
+		req.Header.Set("Mcp-Session-Id", id)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" && method == "initialize" {
+		c.sessionID.Store(id)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &StatusError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if response.Error != nil {
+		return response.Error
+	}
+	if out == nil || len(response.Result) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(response.Result, out); err != nil {
+		return fmt.Errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Package conformance runs a standard battery of MCP protocol checks against a server over
+// any of its transports. Call Test from a go test, or Run for a report, as the server's
+// "conformance" subcommand does.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Options configure the checks.
+type Options struct {
+	Tool    string                 // Tool called by the tools/call check; defaults to generate_uuid
+	Args    map[string]interface{} // Arguments of that call
+	Timeout time.Duration          // Limit on each check; defaults to 10 seconds
+}
+
+// Status is the outcome of a check.
+type Status string
+
+// Check outcomes.
+const (
+	Passed  Status = "pass"
+	Failed  Status = "fail"
+	Skipped Status = "skipped" // The transport does not support what the check needs
+)
+
+// Result is the outcome of one check over one transport.
+type Result struct {
+	Transport string `json:"transport"`
+	Check     string `json:"check"`
+	Status    Status `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Check is one conformance check. Checks run in order over the same connection, so a
+// check may rely on the session that earlier checks initialized.
+type Check struct {
+	Name string
+	Run  func(ctx context.Context, t Transport, opts Options) error
+}
+
+// ErrUnsupported is returned by transports for operations they do not support, such as
+// Reconnect. Checks that need the operation are skipped.
+var ErrUnsupported = errors.New("not supported by this transport")
+
+// Checks returns the standard battery, in the order it runs.
+func Checks() []Check {
+	return []Check{
+		{Name: "initialize", Run: checkInitialize},
+		{Name: "notification", Run: checkNotification},
+		{Name: "tools/list", Run: checkToolsList},
+		{Name: "tools/call", Run: checkToolsCall},
+		{Name: "string id", Run: checkStringID},
+		{Name: "error: unknown method", Run: expectError(map[string]interface{}{"method": "no/such/method"}, -32601)},
+		{Name: "error: missing method", Run: expectError(map[string]interface{}{}, -32600)},
+		{Name: "error: missing tool name", Run: expectError(map[string]interface{}{"method": "tools/call", "params": map[string]interface{}{}}, -32602)},
+		{Name: "error: unknown tool", Run: expectError(map[string]interface{}{"method": "tools/call", "params": map[string]interface{}{"name": "conformance_no_such_tool"}}, 0)},
+		{Name: "session resume", Run: checkResume},
+	}
+}
+
+// Run runs the standard battery over t and returns a result per check.
+func Run(ctx context.Context, t Transport, opts Options) []Result {
+	opts = withDefaults(opts)
+	var results []Result
+	for _, check := range Checks() {
+		results = append(results, runCheck(ctx, t, opts, check))
+	}
+	return results
+}
+
+// Test runs the standard battery over t as subtests of tt, failing those that do not
+// pass and skipping those that do not apply.
+func Test(tt *testing.T, t Transport, opts Options) {
+	tt.Helper()
+	opts = withDefaults(opts)
+	for _, check := range Checks() {
+		tt.Run(check.Name, func(tt *testing.T) {
+			result := runCheck(context.Background(), t, opts, check)
+			switch result.Status {
+			case Failed:
+				tt.Error(result.Error)
+			case Skipped:
+				tt.Skip(result.Error)
+			}
+		})
+	}
+}
+
+// withDefaults fills in unset options.
+func withDefaults(opts Options) Options {
+	if opts.Tool == "" {
+		opts.Tool = "generate_uuid"
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return opts
+}
+
+// runCheck runs a single check within the timeout.
+func runCheck(ctx context.Context, t Transport, opts Options, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	result := Result{Transport: t.Name(), Check: check.Name, Status: Passed}
+	if err := check.Run(ctx, t, opts); err != nil {
+		result.Status, result.Error = Failed, err.Error()
+		if errors.Is(err, ErrUnsupported) {
+			result.Status = Skipped
+		}
+	}
+	return result
+}
+
+// request builds a JSON-RPC request.
+func request(id interface{}, method string, params map[string]interface{}) map[string]interface{} {
+	message := map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method}
+	if params != nil {
+		message["params"] = params
+	}
+	return message
+}
+
+// call sends a request and returns its result, failing on an error response or a
+// malformed envelope.
+func call(ctx context.Context, t Transport, id interface{}, method string, params map[string]interface{}) (map[string]interface{}, error) {
+	response, err := t.Send(ctx, request(id, method, params))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEnvelope(response, id); err != nil {
+		return nil, err
+	}
+	if response["error"] != nil {
+		return nil, fmt.Errorf("%s returned an error: %v", method, response["error"])
+	}
+	result, ok := response["result"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s result is not an object: %v", method, response["result"])
+	}
+	return result, nil
+}
+
+// checkEnvelope checks a response's jsonrpc version and that it echoes id.
+func checkEnvelope(response map[string]interface{}, id interface{}) error {
+	if response == nil {
+		return fmt.Errorf("expected a response, got none")
+	}
+	if response["jsonrpc"] != "2.0" {
+		return fmt.Errorf(`expected "jsonrpc": "2.0", got %v`, response["jsonrpc"])
+	}
+	want, _ := json.Marshal(id)
+	got, _ := json.Marshal(response["id"])
+	if string(want) != string(got) {
+		return fmt.Errorf("expected id %s, got %s", want, got)
+	}
+	return nil
+}
+
+func checkInitialize(ctx context.Context, t Transport, opts Options) error {
+	result, err := call(ctx, t, 1, "initialize", map[string]interface{}{
+		"protocolVersion": "2024-11-05",
+		"clientInfo":      map[string]interface{}{"name": "conformance", "version": "1.0.0"},
+	})
+	if err != nil {
+		return err
+	}
+	if version, _ := result["protocolVersion"].(string); version == "" {
+		return fmt.Errorf("expected a protocolVersion, got %v", result["protocolVersion"])
+	}
+	if capabilities, _ := result["capabilities"].(map[string]interface{}); capabilities["tools"] == nil {
+		return fmt.Errorf("expected tools capability, got %v", result["capabilities"])
+	} else if _, ok := capabilities["tools"].(map[string]interface{}); !ok {
+		return fmt.Errorf("expected the tools capability to be an object, got %v", capabilities["tools"])
+	}
+	if info, _ := result["serverInfo"].(map[string]interface{}); info["name"] == nil {
+		return fmt.Errorf("expected serverInfo with a name, got %v", result["serverInfo"])
+	}
+	return nil
+}
+
+func checkNotification(ctx context.Context, t Transport, opts Options) error {
+	response, err := t.Send(ctx, map[string]interface{}{"jsonrpc": "2.0", "method": "initialized"})
+	if err != nil {
+		return err
+	}
+	if response != nil {
+		return fmt.Errorf("expected no response to a notification, got %v", response)
+	}
+	// The next request's response must not be preceded by one for the notification
+	_, err = call(ctx, t, 2, "tools/list", nil)
+	return err
+}
+
+func checkToolsList(ctx context.Context, t Transport, opts Options) error {
+	result, err := call(ctx, t, 3, "tools/list", nil)
+	if err != nil {
+		return err
+	}
+	tools, ok := result["tools"].([]interface{})
+	if !ok {
+		return fmt.Errorf("expected a tools array, got %v", result["tools"])
+	}
+	found := false
+	for _, entry := range tools {
+		tool, _ := entry.(map[string]interface{})
+		name, _ := tool["name"].(string)
+		if name == "" {
+			return fmt.Errorf("tool without a name: %v", entry)
+		}
+		if schema, _ := tool["inputSchema"].(map[string]interface{}); schema["type"] != "object" {
+			return fmt.Errorf("tool %s: expected an object inputSchema, got %v", name, tool["inputSchema"])
+		}
+		found = found || name == opts.Tool
+	}
+	if !found {
+		return fmt.Errorf("tool %s is not listed", opts.Tool)
+	}
+	return nil
+}
+
+func checkToolsCall(ctx context.Context, t Transport, opts Options) error {
+	params := map[string]interface{}{"name": opts.Tool}
+	if opts.Args != nil {
+		params["arguments"] = opts.Args
+	}
+	result, err := call(ctx, t, 4, "tools/call", params)
+	if err != nil {
+		return err
+	}
+	if _, ok := result["content"].([]interface{}); !ok {
+		return fmt.Errorf("expected a content array, got %v", result["content"])
+	}
+	if _, ok := result["isError"].(bool); !ok {
+		return fmt.Errorf("expected isError, got %v", result["isError"])
+	}
+	return nil
+}
+
+func checkStringID(ctx context.Context, t Transport, opts Options) error {
+	_, err := call(ctx, t, "conformance-5", "tools/list", nil)
+	return err
+}
+
+// expectError returns a check sending message with an id and expecting an error response
+// with code, or any code when it is 0.
+func expectError(message map[string]interface{}, code int) func(context.Context, Transport, Options) error {
+	return func(ctx context.Context, t Transport, opts Options) error {
+		request := map[string]interface{}{"jsonrpc": "2.0", "id": 6}
+		for key, value := range message {
+			request[key] = value
+		}
+		response, err := t.Send(ctx, request)
+		if err != nil {
+			return err
+		}
+		if err := checkEnvelope(response, 6); err != nil {
+			return err
+		}
+		if response["result"] != nil {
+			return fmt.Errorf("expected an error, got result %v", response["result"])
+		}
+		rpcError, ok := response["error"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected an error object, got %v", response["error"])
+		}
+		if message, _ := rpcError["message"].(string); message == "" {
+			return fmt.Errorf("expected an error message, got %v", rpcError)
+		}
+		got, ok := rpcError["code"].(float64)
+		if !ok {
+			return fmt.Errorf("expected a numeric error code, got %v", rpcError["code"])
+		}
+		if code != 0 && int(got) != code {
+			return fmt.Errorf("expected error code %d, got %v", code, got)
+		}
+		return nil
+	}
+}
+
+func checkResume(ctx context.Context, t Transport, opts Options) error {
+	if err := t.Reconnect(ctx); err != nil {
+		return err
+	}
+	if err := checkInitialize(ctx, t, opts); err != nil {
+		return fmt.Errorf("after reconnecting: %w", err)
+	}
+	if _, err := call(ctx, t, 7, "tools/list", nil); err != nil {
+		return fmt.Errorf("after reconnecting: %w", err)
+	}
+	return nil
+}
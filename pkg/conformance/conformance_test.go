@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"mcp-tools-server/pkg/tools/tooltest"
+)
+
+func TestConformance(t *testing.T) {
+	srv := tooltest.NewServer(t, tooltest.NewFakeTool("generate_uuid").Returns(map[string]interface{}{"uuid": "fake"}))
+	ctx := context.Background()
+
+	t.Run("streamable", func(t *testing.T) {
+		Test(t, NewStreamableTransport(srv.StreamableURL, nil), Options{})
+	})
+
+	t.Run("websocket", func(t *testing.T) {
+		transport, err := NewWebSocketTransport(ctx, srv.WebSocketURL)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer transport.Close()
+		Test(t, transport, Options{})
+	})
+
+	t.Run("stdio", func(t *testing.T) {
+		transport, err := NewStdioTransport(ctx, func(context.Context) (io.WriteCloser, io.Reader, error) {
+			stdin, stdout := srv.Stdio()
+			return stdin, stdout, nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer transport.Close()
+		Test(t, transport, Options{})
+	})
+}
+
+func TestRun(t *testing.T) {
+	srv := tooltest.NewServer(t, tooltest.NewFakeTool("echo"))
+
+	t.Run("reports failed checks", func(t *testing.T) {
+		results := Run(context.Background(), NewStreamableTransport(srv.StreamableURL, nil), Options{})
+		failed := map[string]bool{}
+		for _, result := range results {
+			if result.Transport != "streamable" {
+				t.Errorf("Expected the streamable transport, got %s", result.Transport)
+			}
+			if result.Status == Failed {
+				failed[result.Check] = true
+			}
+		}
+		// generate_uuid is not offered
+		if len(failed) != 2 || !failed["tools/list"] || !failed["tools/call"] {
+			t.Errorf("Expected only tools/list and tools/call to fail, got %v", results)
+		}
+	})
+
+	t.Run("unsupported operations are skipped", func(t *testing.T) {
+		results := Run(context.Background(), noReconnect{NewStreamableTransport(srv.StreamableURL, nil)}, Options{Tool: "echo"})
+		last := results[len(results)-1]
+		if last.Check != "session resume" || last.Status != Skipped {
+			t.Errorf("Expected session resume to be skipped, got %+v", last)
+		}
+	})
+}
+
+// noReconnect is a transport that cannot reconnect.
+type noReconnect struct {
+	*StreamableTransport
+}
+
+func (noReconnect) Reconnect(context.Context) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,277 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+// Transport carries JSON-RPC messages to an MCP server.
+type Transport interface {
+	// Name identifies the transport in results.
+	Name() string
+	// Send sends a message and returns the server's response, or nil for a notification,
+	// which is any message without an id.
+	Send(ctx context.Context, message map[string]interface{}) (map[string]interface{}, error)
+	// Reconnect drops the connection and opens a new one, resuming the session where the
+	// transport supports it. Transports that cannot reconnect return ErrUnsupported.
+	Reconnect(ctx context.Context) error
+	// Close closes the connection.
+	Close() error
+}
+
+// isNotification reports whether message expects no response.
+func isNotification(message map[string]interface{}) bool {
+	_, ok := message["id"]
+	return !ok
+}
+
+// StreamableTransport speaks to a Streamable HTTP MCP endpoint. Reconnecting opens the
+// SSE stream with a Last-Event-ID, as a client resuming after a dropped stream does.
+type StreamableTransport struct {
+	endpoint   string
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	sessionID string // Issued by the server on initialize, and sent with every later request
+}
+
+// NewStreamableTransport creates a transport for the Streamable HTTP endpoint, e.g.
+// "http://localhost:8081/mcp". A nil httpClient uses http.DefaultClient.
+func NewStreamableTransport(endpoint string, httpClient *http.Client) *StreamableTransport {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &StreamableTransport{endpoint: endpoint, httpClient: httpClient}
+}
+
+// Name returns "streamable".
+func (t *StreamableTransport) Name() string {
+	return "streamable"
+}
+
+// Send POSTs the message and decodes the response body.
+func (t *StreamableTransport) Send(ctx context.Context, message map[string]interface{}) (map[string]interface{}, error) {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	t.setSession(req)
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if id := resp.Header.Get("Mcp-Session-Id"); id != "" && message["method"] == "initialize" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+
+	if isNotification(message) {
+		if resp.StatusCode != http.StatusAccepted {
+			return nil, fmt.Errorf("expected 202 Accepted for a notification, got %d", resp.StatusCode)
+		}
+		return nil, nil
+	}
+	var response map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response (status %d): %w", resp.StatusCode, err)
+	}
+	return response, nil
+}
+
+// Reconnect opens the SSE stream with a Last-Event-ID and checks that the server accepts
+// it. POSTs carry no connection state and the session ID is kept, so nothing else changes.
+func (t *StreamableTransport) Reconnect(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Last-Event-ID", "1")
+	t.setSession(req)
+	resp, err := t.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to open SSE stream: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return fmt.Errorf("expected a 200 event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return nil
+}
+
+// Close does nothing; requests hold no connection.
+func (t *StreamableTransport) Close() error {
+	return nil
+}
+
+// setSession sends the session the server issued, once there is one, with req.
+func (t *StreamableTransport) setSession(req *http.Request) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", t.sessionID)
+	}
+}
+
+// WebSocketTransport speaks to a WebSocket MCP endpoint. Reconnecting dials a new
+// connection.
+type WebSocketTransport struct {
+	url  string
+	mu   sync.Mutex
+	conn *websocket.Conn
+}
+
+// NewWebSocketTransport dials the WebSocket endpoint, e.g. "ws://localhost:8082/ws".
+func NewWebSocketTransport(ctx context.Context, url string) (*WebSocketTransport, error) {
+	t := &WebSocketTransport{url: url}
+	if err := t.Reconnect(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Name returns "websocket".
+func (t *WebSocketTransport) Name() string {
+	return "websocket"
+}
+
+// Send writes the message and reads the next message as its response.
+func (t *WebSocketTransport) Send(ctx context.Context, message map[string]interface{}) (map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := wsjson.Write(ctx, t.conn, message); err != nil {
+		return nil, fmt.Errorf("write failed: %w", err)
+	}
+	if isNotification(message) {
+		return nil, nil
+	}
+	var response map[string]interface{}
+	if err := wsjson.Read(ctx, t.conn, &response); err != nil {
+		return nil, fmt.Errorf("read failed: %w", err)
+	}
+	return response, nil
+}
+
+// Reconnect closes the connection, if any, and dials a new one.
+func (t *WebSocketTransport) Reconnect(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn != nil {
+		t.conn.Close(websocket.StatusNormalClosure, "reconnecting")
+	}
+	conn, _, err := websocket.Dial(ctx, t.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %w", t.url, err)
+	}
+	t.conn = conn
+	return nil
+}
+
+// Close closes the connection.
+func (t *WebSocketTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conn.Close(websocket.StatusNormalClosure, "")
+}
+
+// StdioDialer starts a stdio MCP session, such as a server subprocess, and returns its
+// stdin and stdout.
+type StdioDialer func(ctx context.Context) (stdin io.WriteCloser, stdout io.Reader, err error)
+
+// StdioTransport speaks newline-delimited JSON-RPC to a stdio MCP server. Reconnecting
+// closes stdin, ending the session, and dials a new one.
+type StdioTransport struct {
+	dial    StdioDialer
+	mu      sync.Mutex
+	stdin   io.WriteCloser
+	decoder *json.Decoder
+}
+
+// NewStdioTransport starts a session with dial.
+func NewStdioTransport(ctx context.Context, dial StdioDialer) (*StdioTransport, error) {
+	t := &StdioTransport{dial: dial}
+	if err := t.Reconnect(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Name returns "stdio".
+func (t *StdioTransport) Name() string {
+	return "stdio"
+}
+
+// Send writes the message and reads the next message from stdout as its response.
+func (t *StdioTransport) Send(ctx context.Context, message map[string]interface{}) (map[string]interface{}, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	line, err := json.Marshal(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode message: %w", err)
+	}
+	if _, err := t.stdin.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("write failed: %w", err)
+	}
+	if isNotification(message) {
+		return nil, nil
+	}
+
+	// Decoding cannot be interrupted, so a done context abandons the read
+	done := make(chan error, 1)
+	var response map[string]interface{}
+	go func() { done <- t.decoder.Decode(&response) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("read failed: %w", err)
+		}
+		return response, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reconnect closes stdin, if open, and dials a new session.
+func (t *StdioTransport) Reconnect(ctx context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stdin != nil {
+		t.stdin.Close()
+	}
+	stdin, stdout, err := t.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start stdio session: %w", err)
+	}
+	t.stdin, t.decoder = stdin, json.NewDecoder(stdout)
+	return nil
+}
+
+// Close closes stdin, ending the session.
+func (t *StdioTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stdin == nil {
+		return errors.New("not connected")
+	}
+	return t.stdin.Close()
+}
@@ -0,0 +1,240 @@
+// Package egress decides which network destinations tools may reach, so that a tool
+// taking a URL or host name from a client cannot be pointed at internal services such as
+// a cloud metadata endpoint.
+package egress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// ErrDenied is returned for a destination the policy does not allow.
+var ErrDenied = errors.New("destination denied by egress policy")
+
+// DefaultDeny lists the destinations denied unless Config.NoDefaultDeny is set: LocalDeny,
+// link-local addresses, which include the metadata endpoints of AWS, GCP, and Azure,
+// AWS's IPv6 metadata endpoint, and GCP's metadata host name.
+var DefaultDeny = append(append([]string(nil), LocalDeny...),
+	"169.254.0.0/16",
+	"fe80::/10",
+	"fd00:ec2::254/128",
+	"metadata.google.internal",
+)
+
+// LocalDeny lists the loopback and unspecified addresses, which reach services on the
+// server's own host. They are part of DefaultDeny unless Config.AllowLocal is set, for
+// tools that are meant to call a sidecar or another local service.
+var LocalDeny = []string{
+	"127.0.0.0/8",
+	"::1/128",
+	"0.0.0.0/8",
+	"::/128",
+}
+
+// Config lists allowed and denied destinations. Each entry is a CIDR, an IP address, a
+// host name, or a wildcard such as *.example.com that matches subdomains.
+type Config struct {
+	Allow         []string // When set, only destinations matching an entry are allowed
+	Deny          []string // Destinations denied even when they match Allow
+	NoDefaultDeny bool     // Leave DefaultDeny out of the denied destinations
+	AllowLocal    bool     // Leave LocalDeny out of DefaultDeny, keeping the rest of it
+}
+
+// Policy checks destinations against allow and deny lists. Deny entries win over allow
+// entries, and with no allow entries every destination that is not denied is allowed.
+type Policy struct {
+	allow rules
+	deny  rules
+}
+
+// rules are parsed Config entries.
+type rules struct {
+	prefixes []netip.Prefix
+	hosts    []string // Exact host names, or suffixes starting with "." for wildcards
+}
+
+// New creates a Policy from c. It fails on an entry that is neither an address, a CIDR,
+// nor a host name.
+func New(c Config) (*Policy, error) {
+	deny := c.Deny
+	if !c.NoDefaultDeny {
+		deny = nil
+		for _, entry := range DefaultDeny {
+			if !c.AllowLocal || !slices.Contains(LocalDeny, entry) {
+				deny = append(deny, entry)
+			}
+		}
+		deny = append(deny, c.Deny...)
+	}
+	p := &Policy{}
+	var err error
+	if p.allow, err = parseRules(c.Allow); err != nil {
+		return nil, err
+	}
+	if p.deny, err = parseRules(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Default returns the policy that only denies DefaultDeny.
+func Default() *Policy {
+	p, err := New(Config{})
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// parseRules sorts entries into prefixes and host names.
+func parseRules(entries []string) (rules, error) {
+	var r rules
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			r.prefixes = append(r.prefixes, prefix.Masked())
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			r.prefixes = append(r.prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		host := strings.TrimSuffix(entry, ".")
+		if wildcard, ok := strings.CutPrefix(host, "*."); ok {
+			host = "." + wildcard
+		}
+		if strings.ContainsAny(host, "/:*@ ") || host == "" || host == "." {
+			return rules{}, fmt.Errorf("invalid egress entry %q: use a CIDR, an IP address, or a host name", entry)
+		}
+		r.hosts = append(r.hosts, host)
+	}
+	return r, nil
+}
+
+// matchHost reports whether host matches a host name entry.
+func (r rules) matchHost(host string) bool {
+	for _, entry := range r.hosts {
+		if host == entry || (entry[0] == '.' && strings.HasSuffix(host, entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchAddr reports whether addr is in a prefix entry.
+func (r rules) matchAddr(addr netip.Addr) bool {
+	for _, prefix := range r.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// empty reports whether there are no entries.
+func (r rules) empty() bool {
+	return len(r.prefixes) == 0 && len(r.hosts) == 0
+}
+
+// Check reports whether a connection to addr, resolved from host, is allowed. Either may
+// be empty or invalid when unknown. A nil Policy allows everything.
+func (p *Policy) Check(host string, addr netip.Addr) error {
+	if p == nil {
+		return nil
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	addr = addr.Unmap()
+	if literal, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		host, addr = "", literal.Unmap()
+	}
+	destination := host
+	if addr.IsValid() {
+		destination = addr.String()
+	}
+
+	if (host != "" && p.deny.matchHost(host)) || (addr.IsValid() && p.deny.matchAddr(addr)) {
+		return fmt.Errorf("%w: %s", ErrDenied, destination)
+	}
+	if p.allow.empty() || (host != "" && p.allow.matchHost(host)) || (addr.IsValid() && p.allow.matchAddr(addr)) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrDenied, destination)
+}
+
+// CheckHost checks a destination known only by name or address literal, before it is
+// resolved. Host names are only held to the deny list, since whether an allow-listed
+// address serves them is known once they are resolved; use Resolve or DialContext for
+// the full check.
+func (p *Policy) CheckHost(host string) error {
+	if p == nil {
+		return nil
+	}
+	if literal, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return p.Check("", literal)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if p.deny.matchHost(host) {
+		return fmt.Errorf("%w: %s", ErrDenied, host)
+	}
+	return nil
+}
+
+// Resolve looks up host and returns its addresses, failing if any of them is denied so
+// that a name with one internal address cannot be used to reach it.
+func (p *Policy) Resolve(ctx context.Context, host string) ([]netip.Addr, error) {
+	if literal, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return []netip.Addr{literal.Unmap()}, p.Check("", literal)
+	}
+	if err := p.CheckHost(host); err != nil {
+		return nil, err
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, err
+	}
+	for i, addr := range addrs {
+		if err := p.Check(host, addr); err != nil {
+			return nil, fmt.Errorf("%s resolves to a denied address: %w", host, err)
+		}
+		addrs[i] = addr.Unmap()
+	}
+	return addrs, nil
+}
+
+// DialContext wraps dialer so that every connection is checked. The destination is
+// resolved once and only the checked addresses are dialed, so a DNS answer cannot change
+// between the check and the connection.
+func (p *Policy) DialContext(dialer *net.Dialer) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := p.Resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		var firstErr error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no addresses found for %s", host)
+		}
+		return nil, firstErr
+	}
+}
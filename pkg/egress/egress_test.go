@@ -0,0 +1,147 @@
+package egress
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	policy, err := New(Config{
+		Allow: []string{"10.0.0.0/8", "api.example.com", "*.trusted.org"},
+		Deny:  []string{"10.0.0.5"},
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		host    string
+		addr    string
+		allowed bool
+	}{
+		{"allowed host name", "api.example.com", "", true},
+		{"wildcard subdomain", "cdn.trusted.org", "", true},
+		{"wildcard does not match the bare domain", "trusted.org", "", false},
+		{"allowed CIDR", "", "10.1.2.3", true},
+		{"deny wins over allow", "", "10.0.0.5", false},
+		{"metadata endpoint denied by default", "169.254.169.254", "", false},
+		{"IPv4-mapped metadata endpoint", "", "::ffff:169.254.169.254", false},
+		{"GCP metadata host name", "metadata.google.internal.", "", false},
+		{"outside the allow list", "example.net", "93.184.216.34", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var addr netip.Addr
+			if tt.addr != "" {
+				addr = netip.MustParseAddr(tt.addr)
+			}
+			err := policy.Check(tt.host, addr)
+			if tt.allowed && err != nil {
+				t.Errorf("Expected allowed, got %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrDenied) {
+				t.Errorf("Expected ErrDenied, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPolicy_Defaults(t *testing.T) {
+	t.Run("default policy allows public and private addresses", func(t *testing.T) {
+		for _, addr := range []string{"93.184.216.34", "10.0.0.1"} {
+			if err := Default().Check("", netip.MustParseAddr(addr)); err != nil {
+				t.Errorf("Expected %s to be allowed, got %v", addr, err)
+			}
+		}
+	})
+
+	t.Run("default policy denies loopback and unspecified addresses", func(t *testing.T) {
+		for _, host := range []string{"127.0.0.1", "127.1.2.3", "::1", "[::1]", "0.0.0.0", "::", "::ffff:127.0.0.1"} {
+			if err := Default().CheckHost(host); !errors.Is(err, ErrDenied) {
+				t.Errorf("Expected %s to be denied, got %v", host, err)
+			}
+		}
+	})
+
+	t.Run("local addresses can be allowed on their own", func(t *testing.T) {
+		policy, err := New(Config{AllowLocal: true})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if err := policy.CheckHost("127.0.0.1"); err != nil {
+			t.Errorf("Expected loopback to be allowed, got %v", err)
+		}
+		if err := policy.CheckHost("169.254.169.254"); !errors.Is(err, ErrDenied) {
+			t.Errorf("Expected the metadata endpoint to stay denied, got %v", err)
+		}
+	})
+
+	t.Run("default denies can be turned off", func(t *testing.T) {
+		policy, err := New(Config{NoDefaultDeny: true})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if err := policy.CheckHost("169.254.169.254"); err != nil {
+			t.Errorf("Expected the metadata endpoint to be allowed, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid entries", func(t *testing.T) {
+		if _, err := New(Config{Deny: []string{"http://example.com/"}}); err == nil {
+			t.Error("Expected an error for a URL entry")
+		}
+	})
+}
+
+func TestPolicy_DialContext(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	t.Run("dials allowed addresses", func(t *testing.T) {
+		policy, err := New(Config{AllowLocal: true})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		conn, err := policy.DialContext(&net.Dialer{})(context.Background(), "tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		conn.Close()
+	})
+
+	t.Run("refuses denied addresses", func(t *testing.T) {
+		policy, err := New(Config{AllowLocal: true, Deny: []string{"127.0.0.0/8"}})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if _, err := policy.DialContext(&net.Dialer{})(context.Background(), "tcp", listener.Addr().String()); !errors.Is(err, ErrDenied) {
+			t.Errorf("Expected ErrDenied, got %v", err)
+		}
+	})
+
+	t.Run("refuses names resolving to denied addresses", func(t *testing.T) {
+		policy, err := New(Config{Deny: []string{"127.0.0.0/8", "::1/128"}})
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		if _, err := policy.Resolve(context.Background(), "localhost"); !errors.Is(err, ErrDenied) {
+			t.Errorf("Expected ErrDenied, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,227 @@
+// Package httpclient builds the HTTP clients tools use for outbound requests, so that
+// timeouts, proxying, TLS settings, metrics, and destination checks are configured in one
+// place rather than by each tool. Every client enforces the egress policy.
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"mcp-tools-server/internal/metrics"
+	"mcp-tools-server/pkg/egress"
+)
+
+// ErrHostNotAllowed is returned for a request, or a redirect, to a host the client's
+// allow-list rejects.
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// Metrics for outbound requests, labeled by the name given to New.
+var (
+	requestsTotal = metrics.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Total number of outbound HTTP requests made by tools",
+		},
+		[]string{"client", "code"},
+	)
+	requestDuration = metrics.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of outbound HTTP requests made by tools until response headers arrive",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"client"},
+	)
+)
+
+// Default client settings.
+const (
+	defaultTimeout             = 30 * time.Second
+	defaultDialTimeout         = 10 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultMaxRedirects        = 10
+)
+
+// Config holds the settings shared by every client.
+type Config struct {
+	Timeout   time.Duration  // Default time limit for a whole request, including the body; non-positive uses 30s
+	ProxyURL  string         // Proxy for every request; empty uses HTTP_PROXY, HTTPS_PROXY, and NO_PROXY
+	CAFile    string         // PEM bundle trusted in addition to the system roots
+	UserAgent string         // User-Agent sent when a request does not set one
+	Policy    *egress.Policy // Destinations tools may reach; nil uses egress.Default
+}
+
+var (
+	mu     sync.RWMutex
+	config = Config{Timeout: defaultTimeout, Policy: egress.Default()}
+	roots  *x509.CertPool // System roots plus Config.CAFile, or nil for the system roots
+	proxy  = http.ProxyFromEnvironment
+)
+
+// Configure replaces the shared settings used by clients created afterwards. It fails,
+// leaving the settings unchanged, when the proxy URL or CA file is invalid.
+func Configure(c Config) error {
+	if c.Timeout <= 0 {
+		c.Timeout = defaultTimeout
+	}
+	if c.Policy == nil {
+		c.Policy = egress.Default()
+	}
+	proxyFunc := http.ProxyFromEnvironment
+	if c.ProxyURL != "" {
+		proxyURL, err := url.Parse(c.ProxyURL)
+		if err != nil || proxyURL.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", c.ProxyURL)
+		}
+		proxyFunc = http.ProxyURL(proxyURL)
+	}
+	var pool *x509.CertPool
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA file: %w", err)
+		}
+		if pool, err = x509.SystemCertPool(); err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file %s", c.CAFile)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	config, roots, proxy = c, pool, proxyFunc
+	return nil
+}
+
+// Option adjusts one client created by New.
+type Option func(*options)
+
+// options are the per-client settings.
+type options struct {
+	timeout   time.Duration
+	tlsConfig *tls.Config
+	noProxy   bool
+	allowHost func(host string) bool
+}
+
+// WithTimeout overrides the shared request time limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) { o.timeout = timeout }
+}
+
+// WithTLSConfig uses tlsConfig, such as one with a private CA or client certificate,
+// instead of the shared TLS settings.
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(o *options) { o.tlsConfig = tlsConfig }
+}
+
+// WithoutProxy connects directly, ignoring the shared proxy settings.
+func WithoutProxy() Option {
+	return func(o *options) { o.noProxy = true }
+}
+
+// WithAllowedHost rejects requests and redirects to hosts for which allow returns false,
+// with an error wrapping ErrHostNotAllowed.
+func WithAllowedHost(allow func(host string) bool) Option {
+	return func(o *options) { o.allowHost = allow }
+}
+
+// New creates a client with the shared settings and opts. The name identifies the client,
+// usually the tool using it, in the mcp_tools_http_client_* metrics.
+func New(name string, opts ...Option) *http.Client {
+	mu.RLock()
+	shared, pool, proxyFunc := config, roots, proxy
+	mu.RUnlock()
+
+	o := options{timeout: shared.Timeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tlsConfig := o.tlsConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	if o.noProxy {
+		proxyFunc = nil
+	}
+
+	dialer := &net.Dialer{Timeout: defaultDialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 proxyFunc,
+		DialContext:           shared.Policy.DialContext(dialer),
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   defaultTLSHandshakeTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	return &http.Client{
+		Timeout: o.timeout,
+		Transport: &instrumentedTransport{
+			next:      transport,
+			name:      name,
+			userAgent: shared.UserAgent,
+			allowHost: o.allowHost,
+			policy:    shared.Policy,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= defaultMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", defaultMaxRedirects)
+			}
+			if o.allowHost != nil && !o.allowHost(req.URL.Hostname()) {
+				return fmt.Errorf("redirect to %w: %s", ErrHostNotAllowed, req.URL.Hostname())
+			}
+			return shared.Policy.CheckHost(req.URL.Hostname())
+		},
+	}
+}
+
+// instrumentedTransport checks each request's host, sets the User-Agent, and records
+// metrics around the next RoundTripper. Host names are checked against the egress policy
+// here as well as when dialing, so denied names are refused when a proxy does the dialing.
+type instrumentedTransport struct {
+	next      http.RoundTripper
+	name      string
+	userAgent string
+	allowHost func(host string) bool
+	policy    *egress.Policy
+}
+
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.allowHost != nil && !t.allowHost(req.URL.Hostname()) {
+		return nil, fmt.Errorf("%w: %s", ErrHostNotAllowed, req.URL.Hostname())
+	}
+	if err := t.policy.CheckHost(req.URL.Hostname()); err != nil {
+		return nil, err
+	}
+	if t.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	metrics.Observe(requestDuration.WithLabelValues(t.name), time.Since(start).Seconds(), metrics.TraceIDFromContext(req.Context()))
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	requestsTotal.WithLabelValues(t.name, code).Inc()
+	return resp, err
+}
@@ -0,0 +1,120 @@
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"mcp-tools-server/pkg/egress"
+)
+
+func TestNew(t *testing.T) {
+	t.Cleanup(func() { _ = Configure(Config{}) })
+	// The test servers listen on loopback, which the default policy denies
+	local, err := egress.New(egress.Config{AllowLocal: true})
+	if err != nil {
+		t.Fatalf("egress.New failed: %v", err)
+	}
+
+	t.Run("sets the user agent and counts requests", func(t *testing.T) {
+		var userAgent string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userAgent = r.UserAgent()
+		}))
+		defer server.Close()
+		if err := Configure(Config{UserAgent: "mcp-tools-server/test", Policy: local}); err != nil {
+			t.Fatalf("Configure failed: %v", err)
+		}
+
+		before := testutil.ToFloat64(requestsTotal.WithLabelValues("test_ua", "200"))
+		resp, err := New("test_ua").Get(server.URL)
+		if err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+		resp.Body.Close()
+		if userAgent != "mcp-tools-server/test" {
+			t.Errorf("Expected the configured user agent, got %q", userAgent)
+		}
+		if after := testutil.ToFloat64(requestsTotal.WithLabelValues("test_ua", "200")); after != before+1 {
+			t.Errorf("Expected the request to be counted, got %v -> %v", before, after)
+		}
+	})
+
+	t.Run("applies the shared timeout unless overridden", func(t *testing.T) {
+		if err := Configure(Config{Timeout: 5 * time.Second}); err != nil {
+			t.Fatalf("Configure failed: %v", err)
+		}
+		if timeout := New("test").Timeout; timeout != 5*time.Second {
+			t.Errorf("Expected 5s, got %v", timeout)
+		}
+		if timeout := New("test", WithTimeout(time.Minute)).Timeout; timeout != time.Minute {
+			t.Errorf("Expected 1m, got %v", timeout)
+		}
+	})
+
+	t.Run("rejects disallowed hosts and redirects", func(t *testing.T) {
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer target.Close()
+		targetURL, _ := url.Parse(target.URL)
+		// The same server under a host name the allow-list does not include
+		elsewhere := "http://localhost:" + targetURL.Port()
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, elsewhere, http.StatusFound)
+		}))
+		defer redirect.Close()
+
+		if err := Configure(Config{Policy: local}); err != nil {
+			t.Fatalf("Configure failed: %v", err)
+		}
+		client := New("test", WithAllowedHost(func(host string) bool { return host == "127.0.0.1" }))
+		resp, err := client.Get(target.URL)
+		if err != nil {
+			t.Fatalf("Expected an allowed host to be fetched, got %v", err)
+		}
+		resp.Body.Close()
+		if _, err := client.Get(elsewhere); !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("Expected ErrHostNotAllowed, got %v", err)
+		}
+		if _, err := client.Get(redirect.URL); !errors.Is(err, ErrHostNotAllowed) {
+			t.Errorf("Expected ErrHostNotAllowed for the redirect, got %v", err)
+		}
+	})
+
+	t.Run("enforces the egress policy", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+		policy, err := egress.New(egress.Config{Deny: []string{"127.0.0.0/8"}})
+		if err != nil {
+			t.Fatalf("egress.New failed: %v", err)
+		}
+		if err := Configure(Config{Policy: policy}); err != nil {
+			t.Fatalf("Configure failed: %v", err)
+		}
+		if _, err := New("test").Get(server.URL); !errors.Is(err, egress.ErrDenied) {
+			t.Errorf("Expected egress.ErrDenied, got %v", err)
+		}
+		if _, err := New("test").Get("http://169.254.169.254/latest/meta-data/"); !errors.Is(err, egress.ErrDenied) {
+			t.Errorf("Expected the metadata endpoint to be denied, got %v", err)
+		}
+	})
+
+	t.Run("rejects an invalid configuration", func(t *testing.T) {
+		if err := Configure(Config{ProxyURL: "://bad"}); err == nil {
+			t.Error("Expected an error for an invalid proxy URL")
+		}
+		empty := filepath.Join(t.TempDir(), "ca.pem")
+		if err := os.WriteFile(empty, []byte("not a certificate"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := Configure(Config{CAFile: empty}); err == nil {
+			t.Error("Expected an error for a CA file without certificates")
+		}
+	})
+}
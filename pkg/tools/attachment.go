@@ -0,0 +1,20 @@
+package tools
+
+import "net/http"
+
+// Attachment is a binary payload returned by a tool, such as an image, PDF, or archive.
+// Tools may place an Attachment (or a raw []byte) as a value in their result map; the
+// server encodes it as base64 content on MCP transports and as a download on REST.
+type Attachment struct {
+	Filename string // Suggested file name for downloads (optional)
+	MIMEType string // Media type of Data; detected from the content when empty
+	Data     []byte
+}
+
+// ContentType returns the attachment's MIME type, sniffing the data when none was set.
+func (a Attachment) ContentType() string {
+	if a.MIMEType != "" {
+		return a.MIMEType
+	}
+	return http.DetectContentType(a.Data)
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// defaultChecksumMaxBytes caps how much data verify_checksum will read from a single source.
+const defaultChecksumMaxBytes int64 = 100 << 20 // 100 MiB
+
+// checksumAlgorithms lists the supported digest algorithms by name.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ChecksumVerifier streams a sandboxed file or an allow-listed URL and verifies it against
+// an expected digest. It implements Tool.
+type ChecksumVerifier struct {
+	logger       *slog.Logger
+	sandboxDir   string
+	allowedHosts []string
+	maxBytes     int64
+	client       *http.Client
+}
+
+// NewChecksumVerifier creates a new checksum verifier. An empty sandboxDir disables file
+// sources and an empty allowedHosts list disables URL sources.
+func NewChecksumVerifier(logger *slog.Logger, sandboxDir string, allowedHosts []string, maxBytes int64) *ChecksumVerifier {
+	if maxBytes <= 0 {
+		maxBytes = defaultChecksumMaxBytes
+	}
+	v := &ChecksumVerifier{
+		logger:       logger,
+		sandboxDir:   sandboxDir,
+		allowedHosts: allowedHosts,
+		maxBytes:     maxBytes,
+	}
+	v.client = httpclient.New("verify_checksum", httpclient.WithTimeout(60*time.Second), httpclient.WithAllowedHost(v.hostAllowed))
+	return v
+}
+
+// NewChecksumVerifierFromConfig creates a checksum verifier from CHECKSUM_SANDBOX_DIR,
+// CHECKSUM_ALLOWED_HOSTS, and CHECKSUM_MAX_BYTES. It fails when no source is configured,
+// so the registry skips the tool.
+func NewChecksumVerifierFromConfig(logger *slog.Logger, config map[string]string) (*ChecksumVerifier, error) {
+	sandboxDir := config["CHECKSUM_SANDBOX_DIR"]
+	var allowedHosts []string
+	for _, host := range strings.Split(config["CHECKSUM_ALLOWED_HOSTS"], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			allowedHosts = append(allowedHosts, strings.ToLower(host))
+		}
+	}
+	if sandboxDir == "" && len(allowedHosts) == 0 {
+		return nil, fmt.Errorf("CHECKSUM_SANDBOX_DIR or CHECKSUM_ALLOWED_HOSTS must be set")
+	}
+
+	var maxBytes int64
+	if raw := config["CHECKSUM_MAX_BYTES"]; raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CHECKSUM_MAX_BYTES: %w", err)
+		}
+		maxBytes = parsed
+	}
+
+	return NewChecksumVerifier(logger, sandboxDir, allowedHosts, maxBytes), nil
+}
+
+// Name returns the tool's name
+func (v *ChecksumVerifier) Name() string {
+	return "verify_checksum"
+}
+
+// Description returns the tool's description
+func (v *ChecksumVerifier) Description() string {
+	return "Verifies a sandboxed file or allow-listed URL against an expected checksum digest"
+}
+
+// Category returns the tool's category
+func (v *ChecksumVerifier) Category() string {
+	return "security"
+}
+
+// Tags returns the tool's tags
+func (v *ChecksumVerifier) Tags() []string {
+	return []string{"checksum", "hash", "integrity"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (v *ChecksumVerifier) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "File path relative to the sandbox directory",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP(S) URL on an allow-listed host",
+			},
+			"expected": map[string]interface{}{
+				"type":        "string",
+				"description": "Expected hex digest, optionally prefixed with the algorithm (e.g. sha256:ab12...)",
+			},
+			"algorithm": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"md5", "sha1", "sha256", "sha512"},
+				"description": "Digest algorithm; inferred from the prefix or digest length when omitted",
+			},
+		},
+		"required": []string{"expected"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (v *ChecksumVerifier) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	path, _ := args["path"].(string)
+	rawURL, _ := args["url"].(string)
+	expected, _ := args["expected"].(string)
+	algorithm, _ := args["algorithm"].(string)
+
+	if (path == "") == (rawURL == "") {
+		return nil, fmt.Errorf("exactly one of path or url is required")
+	}
+	if expected == "" {
+		return nil, fmt.Errorf("missing required argument: expected")
+	}
+
+	algorithm, expected, err := parseExpectedDigest(algorithm, expected)
+	if err != nil {
+		return nil, err
+	}
+
+	var source io.ReadCloser
+	var sourceName string
+	if path != "" {
+		source, err = v.openFile(path)
+		sourceName = path
+	} else {
+		source, err = v.openURL(rawURL)
+		sourceName = rawURL
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer source.Close()
+
+	hashes := make(map[string]hash.Hash, len(checksumAlgorithms))
+	writers := make([]io.Writer, 0, len(checksumAlgorithms))
+	for name, newHash := range checksumAlgorithms {
+		h := newHash()
+		hashes[name] = h
+		writers = append(writers, h)
+	}
+
+	size, err := io.Copy(io.MultiWriter(writers...), io.LimitReader(source, v.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", sourceName, err)
+	}
+	if size > v.maxBytes {
+		return nil, fmt.Errorf("%s exceeds the maximum size of %d bytes", sourceName, v.maxBytes)
+	}
+
+	digests := make(map[string]interface{}, len(hashes))
+	for name, h := range hashes {
+		digests[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	match := digests[algorithm] == expected
+
+	v.logger.Info("Verified checksum", "source", sourceName, "algorithm", algorithm, "match", match)
+	return map[string]interface{}{
+		"match":     match,
+		"algorithm": algorithm,
+		"expected":  expected,
+		"digests":   digests,
+		"size":      size,
+		"source":    sourceName,
+	}, nil
+}
+
+// openFile opens a file inside the sandbox directory. os.Root rejects any path, including
+// one reached through a symlink, that escapes the sandbox.
+func (v *ChecksumVerifier) openFile(path string) (io.ReadCloser, error) {
+	if v.sandboxDir == "" {
+		return nil, fmt.Errorf("file sources are disabled: CHECKSUM_SANDBOX_DIR is not set")
+	}
+	root, err := os.OpenRoot(v.sandboxDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sandbox directory: %w", err)
+	}
+	defer root.Close()
+
+	file, err := root.Open(strings.TrimPrefix(path, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// openURL starts a GET request to an allow-listed http(s) URL.
+func (v *ChecksumVerifier) openURL(rawURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported url scheme: %s", u.Scheme)
+	}
+	if !v.hostAllowed(u.Hostname()) {
+		return nil, fmt.Errorf("host not allowed: %s", u.Hostname())
+	}
+
+	resp, err := v.client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("fetching %s returned status: %d", rawURL, resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// hostAllowed reports whether host is on the URL allow-list.
+func (v *ChecksumVerifier) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range v.allowedHosts {
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExpectedDigest normalizes the expected digest and resolves the algorithm from the
+// explicit argument, an "algorithm:" prefix, or the digest length, in that order.
+func parseExpectedDigest(algorithm, expected string) (string, string, error) {
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if prefix, digest, ok := strings.Cut(expected, ":"); ok {
+		if algorithm == "" {
+			algorithm = prefix
+		}
+		expected = digest
+	}
+
+	if algorithm == "" {
+		switch len(expected) {
+		case 32:
+			algorithm = "md5"
+		case 40:
+			algorithm = "sha1"
+		case 64:
+			algorithm = "sha256"
+		case 128:
+			algorithm = "sha512"
+		default:
+			return "", "", fmt.Errorf("cannot infer algorithm from a %d character digest", len(expected))
+		}
+	}
+
+	algorithm = strings.ToLower(algorithm)
+	if _, ok := checksumAlgorithms[algorithm]; !ok {
+		return "", "", fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	if _, err := hex.DecodeString(expected); err != nil {
+		return "", "", fmt.Errorf("expected digest is not valid hex")
+	}
+	return algorithm, expected, nil
+}
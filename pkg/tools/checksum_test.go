@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newTestChecksumVerifier(t *testing.T, allowedHosts []string, maxBytes int64) (*ChecksumVerifier, string) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.txt"), []byte("hello world"), 0o644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return NewChecksumVerifier(logger, dir, allowedHosts, maxBytes), dir
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestChecksumVerifier_File(t *testing.T) {
+	verifier, _ := newTestChecksumVerifier(t, nil, 0)
+
+	t.Run("matching digest", func(t *testing.T) {
+		result, err := verifier.Execute(map[string]interface{}{
+			"path":     "data.txt",
+			"expected": "sha256:" + sha256Hex("hello world"),
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["match"] != true {
+			t.Errorf("Expected match, got %v", result["match"])
+		}
+		if result["algorithm"] != "sha256" {
+			t.Errorf("Expected algorithm sha256, got %v", result["algorithm"])
+		}
+		if result["size"] != int64(11) {
+			t.Errorf("Expected size 11, got %v", result["size"])
+		}
+		digests := result["digests"].(map[string]interface{})
+		if digests["md5"] != "5eb63bbbe01eeed093cb22bb8f5acdc3" {
+			t.Errorf("Unexpected md5 digest: %v", digests["md5"])
+		}
+	})
+
+	t.Run("mismatched digest infers algorithm from length", func(t *testing.T) {
+		result, err := verifier.Execute(map[string]interface{}{
+			"path":     "data.txt",
+			"expected": sha256Hex("something else"),
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["match"] != false {
+			t.Errorf("Expected mismatch, got %v", result["match"])
+		}
+		if result["algorithm"] != "sha256" {
+			t.Errorf("Expected inferred algorithm sha256, got %v", result["algorithm"])
+		}
+	})
+
+	t.Run("rejects paths escaping the sandbox", func(t *testing.T) {
+		_, err := verifier.Execute(map[string]interface{}{
+			"path":     "../../etc/passwd",
+			"expected": sha256Hex("x"),
+		})
+		if err == nil {
+			t.Error("Expected error for path outside sandbox")
+		}
+	})
+
+	t.Run("enforces max size", func(t *testing.T) {
+		small, _ := newTestChecksumVerifier(t, nil, 4)
+		_, err := small.Execute(map[string]interface{}{
+			"path":     "data.txt",
+			"expected": sha256Hex("hello world"),
+		})
+		if err == nil {
+			t.Error("Expected error for oversized file")
+		}
+	})
+
+	t.Run("requires exactly one source", func(t *testing.T) {
+		_, err := verifier.Execute(map[string]interface{}{"expected": sha256Hex("x")})
+		if err == nil {
+			t.Error("Expected error when no source is given")
+		}
+	})
+
+	t.Run("rejects unsupported algorithm", func(t *testing.T) {
+		_, err := verifier.Execute(map[string]interface{}{
+			"path":     "data.txt",
+			"expected": "crc32:deadbeef",
+		})
+		if err == nil {
+			t.Error("Expected error for unsupported algorithm")
+		}
+	})
+}
+
+func TestChecksumVerifier_URL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello world"))
+	}))
+	defer server.Close()
+
+	t.Run("allow-listed host", func(t *testing.T) {
+		verifier, _ := newTestChecksumVerifier(t, []string{"127.0.0.1"}, 0)
+		result, err := verifier.Execute(map[string]interface{}{
+			"url":      server.URL + "/file",
+			"expected": sha256Hex("hello world"),
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["match"] != true {
+			t.Errorf("Expected match, got %v", result["match"])
+		}
+	})
+
+	t.Run("host not on allow-list", func(t *testing.T) {
+		verifier, _ := newTestChecksumVerifier(t, []string{"example.com"}, 0)
+		_, err := verifier.Execute(map[string]interface{}{
+			"url":      server.URL + "/file",
+			"expected": sha256Hex("hello world"),
+		})
+		if err == nil {
+			t.Error("Expected error for disallowed host")
+		}
+	})
+}
+
+func TestNewChecksumVerifierFromConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	if _, err := NewChecksumVerifierFromConfig(logger, map[string]string{}); err == nil {
+		t.Error("Expected error when no source is configured")
+	}
+
+	verifier, err := NewChecksumVerifierFromConfig(logger, map[string]string{
+		"CHECKSUM_ALLOWED_HOSTS": "Example.com, downloads.example.com",
+		"CHECKSUM_MAX_BYTES":     "1024",
+	})
+	if err != nil {
+		t.Fatalf("Expected verifier, got error: %v", err)
+	}
+	if len(verifier.allowedHosts) != 2 || verifier.allowedHosts[0] != "example.com" {
+		t.Errorf("Unexpected allowed hosts: %v", verifier.allowedHosts)
+	}
+	if verifier.maxBytes != 1024 {
+		t.Errorf("Expected maxBytes 1024, got %d", verifier.maxBytes)
+	}
+
+	if _, err := NewChecksumVerifierFromConfig(logger, map[string]string{
+		"CHECKSUM_SANDBOX_DIR": "/tmp",
+		"CHECKSUM_MAX_BYTES":   "lots",
+	}); err == nil {
+		t.Error("Expected error for invalid CHECKSUM_MAX_BYTES")
+	}
+}
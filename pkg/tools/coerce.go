@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidArguments is returned for arguments that do not satisfy a tool's input schema.
+var ErrInvalidArguments = errors.New("invalid arguments")
+
+// ArgCoercer is an optional interface for tools that want their arguments as Go values.
+// When CoercesArgs returns true, the server converts arguments with CoerceArgs before
+// calling the tool; other tools receive them as ValidateArgs leaves them.
+type ArgCoercer interface {
+	CoercesArgs() bool
+}
+
+// CoerceArgs checks args against a JSON Schema and converts them to the Go types the
+// schema describes, so tools need not parse them: integers become int64, numbers
+// float64, booleans bool, and strings with the date-time or date format time.Time.
+// Strings holding a number or boolean are accepted for those types, and integral numbers
+// for integers. Missing properties with a "default" get it, and missing "required"
+// properties are an error wrapping ErrInvalidArguments. Nested objects and array items
+// are handled the same way; properties the schema does not describe are kept as they
+// are. args itself is not modified.
+func CoerceArgs(schema, args map[string]interface{}) (map[string]interface{}, error) {
+	return coerceObject(schema, args, "", true)
+}
+
+// ValidateArgs checks args against a JSON Schema like CoerceArgs, applying defaults, but
+// keeps the types encoding/json decodes to: integers and numbers become float64, and
+// date-times stay strings, so tools written against decoded JSON keep working.
+func ValidateArgs(schema, args map[string]interface{}) (map[string]interface{}, error) {
+	return coerceObject(schema, args, "", false)
+}
+
+// coerceObject coerces the properties of an object value. goTypes selects CoerceArgs's
+// conversions over ValidateArgs's.
+func coerceObject(schema, object map[string]interface{}, path string, goTypes bool) (map[string]interface{}, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	result := maps.Clone(object)
+	if result == nil {
+		result = make(map[string]interface{})
+	}
+
+	// Sorted so the first error reported does not depend on map order
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, _ := properties[name].(map[string]interface{})
+		value, ok := result[name]
+		if !ok {
+			if def, hasDefault := property["default"]; hasDefault {
+				value, ok = def, true
+			}
+		}
+		if !ok {
+			continue
+		}
+		coerced, err := coerceValue(property, value, joinPath(path, name), goTypes)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = coerced
+	}
+
+	for _, name := range requiredProperties(schema) {
+		if _, ok := result[name]; !ok {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidArguments, joinPath(path, name))
+		}
+	}
+	return result, nil
+}
+
+// coerceValue coerces a value to the type its schema names. A schema without a type, or
+// with several, accepts any value that one of them does.
+func coerceValue(schema map[string]interface{}, value interface{}, path string, goTypes bool) (interface{}, error) {
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) == 0 {
+		return value, nil
+	}
+
+	var firstErr error
+	for _, typ := range types {
+		coerced, err := coerceToType(schema, typ, value, path, goTypes)
+		if err == nil {
+			return coerced, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(types) > 1 {
+		return nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidArguments, path, strings.Join(types, ", "))
+	}
+	return nil, firstErr
+}
+
+// coerceToType coerces a value to a single JSON Schema type.
+func coerceToType(schema map[string]interface{}, typ string, value interface{}, path string, goTypes bool) (interface{}, error) {
+	invalid := func(expected string) error {
+		return fmt.Errorf("%w: %s must be %s", ErrInvalidArguments, path, expected)
+	}
+	switch typ {
+	case "null":
+		if value == nil {
+			return nil, nil
+		}
+		return nil, invalid("null")
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+		return nil, invalid("a boolean")
+	case "integer", "number":
+		f, ok := toFloat(value)
+		if !ok {
+			if typ == "integer" {
+				return nil, invalid("an integer")
+			}
+			return nil, invalid("a number")
+		}
+		if typ == "integer" {
+			if f != math.Trunc(f) || math.IsInf(f, 0) || math.Abs(f) > 1<<53 {
+				return nil, invalid("an integer")
+			}
+			if goTypes {
+				return int64(f), nil
+			}
+		}
+		return f, nil
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, invalid("a string")
+		}
+		format, _ := schema["format"].(string)
+		var layout string
+		switch format {
+		case "date-time":
+			layout = time.RFC3339
+		case "date":
+			layout = time.DateOnly
+		default:
+			return s, nil
+		}
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return nil, invalid("a " + format + " such as " + time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC).Format(layout))
+		}
+		if goTypes {
+			return t, nil
+		}
+		return s, nil
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, invalid("an array")
+		}
+		itemSchema, _ := schema["items"].(map[string]interface{})
+		coerced := make([]interface{}, len(items))
+		for i, item := range items {
+			var err error
+			if coerced[i], err = coerceValue(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), goTypes); err != nil {
+				return nil, err
+			}
+		}
+		return coerced, nil
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, invalid("an object")
+		}
+		return coerceObject(schema, object, path, goTypes)
+	}
+	return value, nil
+}
+
+// toFloat converts a decoded JSON number, a Go number, or a numeric string to a float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+	}
+	return 0, false
+}
+
+// requiredProperties returns the names a schema's "required" list holds.
+func requiredProperties(schema map[string]interface{}) []string {
+	var names []string
+	switch required := schema["required"].(type) {
+	case []string:
+		names = required
+	case []interface{}:
+		for _, item := range required {
+			if name, ok := item.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// joinPath names a property within the argument at path.
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
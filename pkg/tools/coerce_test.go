@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCoerceArgs(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"count":   map[string]interface{}{"type": "integer", "default": float64(10)},
+			"ratio":   map[string]interface{}{"type": "number"},
+			"verbose": map[string]interface{}{"type": "boolean"},
+			"since":   map[string]interface{}{"type": "string", "format": "date-time"},
+			"day":     map[string]interface{}{"type": "string", "format": "date"},
+			"name":    map[string]interface{}{"type": "string"},
+			"ids":     map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer"}},
+			"limit":   map[string]interface{}{"type": []interface{}{"integer", "null"}},
+			"filter": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"max": map[string]interface{}{"type": "integer"}},
+				"required":   []interface{}{"max"},
+			},
+		},
+		"required": []string{"name"},
+	}
+	args := map[string]interface{}{
+		"ratio":   "0.5",
+		"verbose": "true",
+		"since":   "2025-01-02T15:04:05Z",
+		"day":     "2025-01-02",
+		"name":    "report",
+		"ids":     []interface{}{float64(1), "2"},
+		"limit":   nil,
+		"filter":  map[string]interface{}{"max": "3"},
+		"extra":   "kept",
+	}
+
+	t.Run("converts to Go types", func(t *testing.T) {
+		got, err := CoerceArgs(schema, args)
+		if err != nil {
+			t.Fatalf("CoerceArgs failed: %v", err)
+		}
+		want := map[string]interface{}{
+			"count":   int64(10),
+			"ratio":   0.5,
+			"verbose": true,
+			"since":   time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC),
+			"day":     time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+			"name":    "report",
+			"ids":     []interface{}{int64(1), int64(2)},
+			"limit":   nil,
+			"filter":  map[string]interface{}{"max": int64(3)},
+			"extra":   "kept",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %v, got %v", want, got)
+		}
+		if _, ok := args["count"]; ok {
+			t.Error("Expected the caller's arguments to be left unchanged")
+		}
+	})
+
+	t.Run("validation keeps JSON types", func(t *testing.T) {
+		got, err := ValidateArgs(schema, args)
+		if err != nil {
+			t.Fatalf("ValidateArgs failed: %v", err)
+		}
+		if got["count"] != float64(10) || got["since"] != "2025-01-02T15:04:05Z" || got["verbose"] != true || got["ratio"] != 0.5 {
+			t.Errorf("Unexpected arguments: %v", got)
+		}
+	})
+
+	t.Run("reports invalid arguments", func(t *testing.T) {
+		tests := map[string]map[string]interface{}{
+			"missing required":       {},
+			"fractional integer":     {"name": "x", "count": 1.5},
+			"unparseable number":     {"name": "x", "ratio": "half"},
+			"bad date-time":          {"name": "x", "since": "yesterday"},
+			"wrong string":           {"name": float64(1)},
+			"bad array item":         {"name": "x", "ids": []interface{}{"one"}},
+			"nested required":        {"name": "x", "filter": map[string]interface{}{}},
+			"no type of several":     {"name": "x", "limit": "none"},
+			"object for a scalar":    {"name": "x", "verbose": map[string]interface{}{}},
+			"scalar for an array":    {"name": "x", "ids": "1,2"},
+			"scalar for an object":   {"name": "x", "filter": "max=3"},
+			"boolean for an integer": {"name": "x", "count": true},
+			"infinite number string": {"name": "x", "ratio": "Inf"},
+			"unparseable boolean":    {"name": "x", "verbose": "yes"},
+		}
+		for name, args := range tests {
+			t.Run(name, func(t *testing.T) {
+				if _, err := CoerceArgs(schema, args); !errors.Is(err, ErrInvalidArguments) {
+					t.Errorf("Expected ErrInvalidArguments, got %v", err)
+				}
+			})
+		}
+	})
+
+	t.Run("schemas without properties accept anything", func(t *testing.T) {
+		got, err := CoerceArgs(map[string]interface{}{"type": "object"}, nil)
+		if err != nil || len(got) != 0 {
+			t.Errorf("Expected empty arguments, got %v, %v", got, err)
+		}
+	})
+}
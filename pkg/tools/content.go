@@ -0,0 +1,82 @@
+package tools
+
+// ContentKey is the result key under which a tool returns typed content blocks. A result
+// built with ContentResult is sent over MCP as a list of content blocks, and on REST a
+// single block is returned in its own media type, such as image/png or text/plain.
+const ContentKey = "content"
+
+// ContentType identifies the kind of a content block.
+type ContentType string
+
+// Content block types.
+const (
+	ContentText         ContentType = "text"          // Plain text
+	ContentJSON         ContentType = "json"          // A JSON value
+	ContentImage        ContentType = "image"         // Image bytes with a MIME type
+	ContentResource     ContentType = "resource"      // Embedded binary, such as a PDF or archive
+	ContentResourceLink ContentType = "resource_link" // A URI the client can fetch
+)
+
+// Content is one typed block of a tool result.
+type Content struct {
+	Type     ContentType
+	Text     string      // Text for ContentText
+	Value    interface{} // Value for ContentJSON
+	Data     []byte      // Bytes for ContentImage and ContentResource
+	MIMEType string      // Media type of Data or of the linked resource; detected from Data when empty
+	URI      string      // Location for ContentResourceLink, or a name for ContentResource
+	Name     string      // Display or file name (optional)
+}
+
+// TextContent returns a text block.
+func TextContent(text string) Content {
+	return Content{Type: ContentText, Text: text}
+}
+
+// JSONContent returns a block holding a JSON value.
+func JSONContent(value interface{}) Content {
+	return Content{Type: ContentJSON, Value: value}
+}
+
+// ImageContent returns an image block. The MIME type is detected when empty.
+func ImageContent(data []byte, mimeType string) Content {
+	return Content{Type: ContentImage, Data: data, MIMEType: mimeType}
+}
+
+// ResourceContent returns a block embedding a binary file.
+func ResourceContent(name string, data []byte, mimeType string) Content {
+	return Content{Type: ContentResource, Name: name, Data: data, MIMEType: mimeType}
+}
+
+// ResourceLink returns a block pointing at a resource the client can fetch.
+func ResourceLink(uri, name, mimeType string) Content {
+	return Content{Type: ContentResourceLink, URI: uri, Name: name, MIMEType: mimeType}
+}
+
+// ContentResult returns a tool result made of the given content blocks.
+func ContentResult(blocks ...Content) map[string]interface{} {
+	return map[string]interface{}{ContentKey: blocks}
+}
+
+// ResultContent returns the content blocks of a result built with ContentResult.
+func ResultContent(result map[string]interface{}) ([]Content, bool) {
+	blocks, ok := result[ContentKey].([]Content)
+	return blocks, ok
+}
+
+// Attachment returns an image or resource block's data as an Attachment.
+func (c Content) Attachment() Attachment {
+	return Attachment{Filename: c.Name, MIMEType: c.MIMEType, Data: c.Data}
+}
+
+// ContentType returns the media type the block is served as on REST.
+func (c Content) ContentType() string {
+	switch c.Type {
+	case ContentText:
+		return "text/plain; charset=utf-8"
+	case ContentImage, ContentResource:
+		return c.Attachment().ContentType()
+	default:
+		return "application/json"
+	}
+}
@@ -0,0 +1,379 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// defaultECBURL publishes the European Central Bank's daily reference rates.
+const defaultECBURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// defaultRatesCacheTTL is how long fetched rates are reused. The ECB publishes once a day.
+const defaultRatesCacheTTL = 6 * time.Hour
+
+// maxCurrencyDecimals caps the decimal places currency_convert returns.
+const maxCurrencyDecimals = 12
+
+// currencyCodePattern matches ISO 4217 currency codes.
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// RateTable holds exchange rates against a base currency, as exact decimals.
+type RateTable struct {
+	Base  string
+	Date  string // Day the rates apply to, when the source says
+	Rates map[string]*big.Rat
+}
+
+// RateProvider supplies exchange rates.
+type RateProvider interface {
+	Name() string
+	Rates() (*RateTable, error)
+}
+
+// StaticRates serves rates read once from a JSON file.
+type StaticRates struct {
+	table *RateTable
+}
+
+// LoadStaticRates reads rates from a JSON file of the form
+// {"base": "USD", "date": "2026-01-02", "rates": {"EUR": "0.9132", "JPY": 157.2}}.
+// Rates may be strings or numbers; both are kept exactly as written.
+func LoadStaticRates(path string) (*StaticRates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file: %w", err)
+	}
+	var file struct {
+		Base  string                 `json:"base"`
+		Date  string                 `json:"date"`
+		Rates map[string]json.Number `json:"rates"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("invalid rates file: %w", err)
+	}
+	rates := make(map[string]string, len(file.Rates))
+	for code, rate := range file.Rates {
+		rates[code] = rate.String()
+	}
+	table, err := newRateTable(file.Base, file.Date, rates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rates file: %w", err)
+	}
+	return &StaticRates{table: table}, nil
+}
+
+// Name returns the provider's name
+func (s *StaticRates) Name() string {
+	return "static"
+}
+
+// Rates returns the rates read from the file
+func (s *StaticRates) Rates() (*RateTable, error) {
+	return s.table, nil
+}
+
+// ECBRates fetches the European Central Bank's daily reference rates, which are quoted
+// against the euro, and caches them. When a refresh fails, the last rates fetched are
+// served until a refresh succeeds.
+type ECBRates struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	table   *RateTable
+	fetched time.Time
+}
+
+// NewECBRates creates an ECB provider. An empty url uses the ECB's public feed.
+func NewECBRates(logger *slog.Logger, url string, ttl time.Duration) *ECBRates {
+	if url == "" {
+		url = defaultECBURL
+	}
+	if ttl <= 0 {
+		ttl = defaultRatesCacheTTL
+	}
+	return &ECBRates{
+		url:    url,
+		ttl:    ttl,
+		client: httpclient.New("currency_convert", httpclient.WithTimeout(10*time.Second)),
+		logger: logger,
+	}
+}
+
+// Name returns the provider's name
+func (e *ECBRates) Name() string {
+	return "ecb"
+}
+
+// Rates returns cached rates, refreshing them once they are older than the cache TTL
+func (e *ECBRates) Rates() (*RateTable, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.table != nil && time.Since(e.fetched) < e.ttl {
+		return e.table, nil
+	}
+	table, err := e.fetch()
+	if err != nil {
+		if e.table != nil {
+			e.logger.Warn("Failed to refresh ECB rates; serving cached rates", "error", err, "date", e.table.Date)
+			return e.table, nil
+		}
+		return nil, err
+	}
+	e.table, e.fetched = table, time.Now()
+	return table, nil
+}
+
+// fetch downloads and parses the ECB feed.
+func (e *ECBRates) fetch() (*RateTable, error) {
+	resp, err := e.client.Get(e.url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch ECB rates: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching ECB rates returned status: %d", resp.StatusCode)
+	}
+	var envelope struct {
+		Cube struct {
+			Cube struct {
+				Time  string `xml:"time,attr"`
+				Rates []struct {
+					Currency string `xml:"currency,attr"`
+					Rate     string `xml:"rate,attr"`
+				} `xml:"Cube"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("invalid ECB rates: %w", err)
+	}
+	rates := make(map[string]string, len(envelope.Cube.Cube.Rates))
+	for _, rate := range envelope.Cube.Cube.Rates {
+		rates[rate.Currency] = rate.Rate
+	}
+	table, err := newRateTable("EUR", envelope.Cube.Cube.Time, rates)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECB rates: %w", err)
+	}
+	return table, nil
+}
+
+// newRateTable parses decimal rates into a table. The base currency is always included
+// with a rate of one.
+func newRateTable(base, date string, rates map[string]string) (*RateTable, error) {
+	base = strings.ToUpper(base)
+	if !currencyCodePattern.MatchString(base) {
+		return nil, fmt.Errorf("invalid base currency %q", base)
+	}
+	if len(rates) == 0 {
+		return nil, errors.New("no rates")
+	}
+	table := &RateTable{Base: base, Date: date, Rates: map[string]*big.Rat{base: big.NewRat(1, 1)}}
+	for code, raw := range rates {
+		code = strings.ToUpper(code)
+		if !currencyCodePattern.MatchString(code) {
+			return nil, fmt.Errorf("invalid currency code %q", code)
+		}
+		rate, ok := new(big.Rat).SetString(raw)
+		if !ok || rate.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate for %s: %q", code, raw)
+		}
+		table.Rates[code] = rate
+	}
+	return table, nil
+}
+
+// CurrencyConverter converts amounts between currencies with exact decimal arithmetic and
+// implements Tool.
+type CurrencyConverter struct {
+	logger   *slog.Logger
+	provider RateProvider
+}
+
+// NewCurrencyConverter creates a currency converter using provider.
+func NewCurrencyConverter(logger *slog.Logger, provider RateProvider) *CurrencyConverter {
+	return &CurrencyConverter{logger: logger, provider: provider}
+}
+
+// NewCurrencyConverterFromConfig creates a currency converter from CURRENCY_RATES_PROVIDER
+// (static or ecb), CURRENCY_RATES_FILE, CURRENCY_ECB_URL, and CURRENCY_CACHE_TTL. It
+// fails when no provider is configured, so the registry skips the tool.
+func NewCurrencyConverterFromConfig(logger *slog.Logger, config map[string]string) (*CurrencyConverter, error) {
+	provider := config["CURRENCY_RATES_PROVIDER"]
+	if provider == "" && config["CURRENCY_RATES_FILE"] != "" {
+		provider = "static"
+	}
+	switch provider {
+	case "":
+		return nil, errors.New("CURRENCY_RATES_PROVIDER is not set")
+	case "static":
+		if config["CURRENCY_RATES_FILE"] == "" {
+			return nil, errors.New("CURRENCY_RATES_FILE is required for the static provider")
+		}
+		rates, err := LoadStaticRates(config["CURRENCY_RATES_FILE"])
+		if err != nil {
+			return nil, err
+		}
+		return NewCurrencyConverter(logger, rates), nil
+	case "ecb":
+		var ttl time.Duration
+		if raw := config["CURRENCY_CACHE_TTL"]; raw != "" {
+			seconds, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CURRENCY_CACHE_TTL: %w", err)
+			}
+			ttl = time.Duration(seconds) * time.Second
+		}
+		return NewCurrencyConverter(logger, NewECBRates(logger, config["CURRENCY_ECB_URL"], ttl)), nil
+	default:
+		return nil, fmt.Errorf("unknown CURRENCY_RATES_PROVIDER %q; use static or ecb", provider)
+	}
+}
+
+// HealthCheck reports whether the rate provider can serve rates
+func (c *CurrencyConverter) HealthCheck(ctx context.Context) error {
+	_, err := c.provider.Rates()
+	return err
+}
+
+// Name returns the tool's name
+func (c *CurrencyConverter) Name() string {
+	return "currency_convert"
+}
+
+// Description returns the tool's description
+func (c *CurrencyConverter) Description() string {
+	return "Converts an amount between currencies using " + c.provider.Name() + " exchange rates, with exact decimal results"
+}
+
+// Category returns the tool's category
+func (c *CurrencyConverter) Category() string {
+	return "finance"
+}
+
+// Tags returns the tool's tags
+func (c *CurrencyConverter) Tags() []string {
+	return []string{"currency", "exchange", "finance", "convert"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (c *CurrencyConverter) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"amount": map[string]interface{}{
+				"type":        []string{"string", "number"},
+				"description": "Amount to convert; pass a string such as \"1234.56\" to avoid floating-point rounding",
+			},
+			"from": map[string]interface{}{
+				"type":        "string",
+				"description": "ISO 4217 code of the amount's currency, e.g. USD",
+			},
+			"to": map[string]interface{}{
+				"type":        "string",
+				"description": "ISO 4217 code of the target currency, e.g. EUR",
+			},
+			"decimals": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     0,
+				"maximum":     maxCurrencyDecimals,
+				"description": "Decimal places in the result, rounded half away from zero (default 2)",
+			},
+		},
+		"required": []string{"amount", "from", "to"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (c *CurrencyConverter) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	var raw string
+	switch amount := args["amount"].(type) {
+	case string:
+		raw = strings.TrimSpace(amount)
+	case float64:
+		raw = strconv.FormatFloat(amount, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("missing required argument: amount")
+	}
+	amount, ok := new(big.Rat).SetString(raw)
+	if !ok || strings.ContainsAny(raw, "/eE") {
+		return nil, fmt.Errorf("invalid amount: %s", raw)
+	}
+	from, _ := args["from"].(string)
+	to, _ := args["to"].(string)
+	from, to = strings.ToUpper(strings.TrimSpace(from)), strings.ToUpper(strings.TrimSpace(to))
+	decimals := intArg(args, "decimals", 2)
+	if decimals < 0 || decimals > maxCurrencyDecimals {
+		return nil, fmt.Errorf("decimals must be between 0 and %d", maxCurrencyDecimals)
+	}
+
+	table, err := c.provider.Rates()
+	if err != nil {
+		return nil, err
+	}
+	fromRate, ok := table.Rates[from]
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency: %s (supported: %s)", from, supportedCurrencies(table))
+	}
+	toRate, ok := table.Rates[to]
+	if !ok {
+		return nil, fmt.Errorf("unsupported currency: %s (supported: %s)", to, supportedCurrencies(table))
+	}
+
+	// Rates are quoted per unit of the base currency, so one unit of from buys
+	// toRate/fromRate units of to.
+	rate := new(big.Rat).Quo(toRate, fromRate)
+	result := new(big.Rat).Mul(amount, rate)
+	output := map[string]interface{}{
+		"amount":   amount.FloatString(max(decimals, decimalPlaces(raw))),
+		"from":     from,
+		"to":       to,
+		"result":   result.FloatString(decimals),
+		"rate":     rate.FloatString(8),
+		"provider": c.provider.Name(),
+	}
+	if table.Date != "" {
+		output["date"] = table.Date
+	}
+	return output, nil
+}
+
+// decimalPlaces returns the number of digits after the decimal point in a number.
+func decimalPlaces(number string) int {
+	if dot := strings.IndexByte(number, '.'); dot >= 0 {
+		return len(number) - dot - 1
+	}
+	return 0
+}
+
+// supportedCurrencies lists a table's currencies in order.
+func supportedCurrencies(table *RateTable) string {
+	codes := make([]string, 0, len(table.Rates))
+	for code := range table.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return strings.Join(codes, ", ")
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const ecbTestFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<Cube>
+		<Cube time="2026-10-15">
+			<Cube currency="USD" rate="1.0876"/>
+			<Cube currency="JPY" rate="162.35"/>
+			<Cube currency="GBP" rate="0.8421"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+func TestCurrencyConverter(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("converts with static rates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "rates.json")
+		if err := os.WriteFile(path, []byte(`{"base": "USD", "date": "2026-01-02", "rates": {"EUR": "0.9", "JPY": 150.25}}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		converter, err := NewCurrencyConverterFromConfig(logger, map[string]string{"CURRENCY_RATES_FILE": path})
+		if err != nil {
+			t.Fatalf("NewCurrencyConverterFromConfig failed: %v", err)
+		}
+
+		result, err := converter.Execute(map[string]interface{}{"amount": "0.10", "from": "usd", "to": "EUR", "decimals": float64(4)})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["result"] != "0.0900" || result["rate"] != "0.90000000" || result["date"] != "2026-01-02" || result["provider"] != "static" {
+			t.Errorf("Unexpected result: %v", result)
+		}
+
+		// Cross rates go through the base currency without floating-point error.
+		result, err = converter.Execute(map[string]interface{}{"amount": float64(100), "from": "EUR", "to": "JPY"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["result"] != "16694.44" {
+			t.Errorf("Expected 16694.44 JPY, got %v", result["result"])
+		}
+	})
+
+	t.Run("fetches and caches ECB rates", func(t *testing.T) {
+		var requests atomic.Int32
+		var fail atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+			if fail.Load() {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = w.Write([]byte(ecbTestFeed))
+		}))
+		defer server.Close()
+
+		provider := NewECBRates(logger, server.URL, time.Hour)
+		converter := NewCurrencyConverter(logger, provider)
+		for i := 0; i < 3; i++ {
+			result, err := converter.Execute(map[string]interface{}{"amount": "10", "from": "EUR", "to": "USD"})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			if result["result"] != "10.88" || result["date"] != "2026-10-15" {
+				t.Errorf("Unexpected result: %v", result)
+			}
+		}
+		if n := requests.Load(); n != 1 {
+			t.Errorf("Expected rates to be fetched once, got %d fetches", n)
+		}
+
+		// An expired cache is refreshed, and a failed refresh serves the old rates.
+		fail.Store(true)
+		provider.fetched = time.Now().Add(-2 * time.Hour)
+		if _, err := converter.Execute(map[string]interface{}{"amount": "1", "from": "GBP", "to": "JPY"}); err != nil {
+			t.Errorf("Expected cached rates after a failed refresh, got %v", err)
+		}
+		if n := requests.Load(); n != 2 {
+			t.Errorf("Expected a refresh attempt, got %d fetches", n)
+		}
+	})
+
+	t.Run("rejects invalid input", func(t *testing.T) {
+		table, _ := newRateTable("USD", "", map[string]string{"EUR": "0.9"})
+		converter := NewCurrencyConverter(logger, &StaticRates{table: table})
+		for _, args := range []map[string]interface{}{
+			{"amount": "ten", "from": "USD", "to": "EUR"},
+			{"amount": "1/3", "from": "USD", "to": "EUR"},
+			{"amount": "1", "from": "USD", "to": "XYZ"},
+			{"amount": "1", "from": "USD", "to": "EUR", "decimals": float64(40)},
+			{"from": "USD", "to": "EUR"},
+		} {
+			if _, err := converter.Execute(args); err == nil {
+				t.Errorf("Expected %v to be refused", args)
+			}
+		}
+	})
+
+	t.Run("requires a provider", func(t *testing.T) {
+		if _, err := NewCurrencyConverterFromConfig(logger, map[string]string{}); err == nil {
+			t.Error("Expected no tool without a provider")
+		}
+		if _, err := NewCurrencyConverterFromConfig(logger, map[string]string{"CURRENCY_RATES_PROVIDER": "static"}); err == nil {
+			t.Error("Expected the static provider to require a file")
+		}
+		if _, err := NewCurrencyConverterFromConfig(logger, map[string]string{"CURRENCY_RATES_PROVIDER": "oanda"}); err == nil {
+			t.Error("Expected an unknown provider to be refused")
+		}
+	})
+}
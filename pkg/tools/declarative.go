@@ -0,0 +1,430 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"regexp"
+	"strings"
+	"time"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// Declarative tool types.
+const (
+	DefinitionExec     = "exec"     // Runs a program, without a shell
+	DefinitionHTTP     = "http"     // Calls an HTTP endpoint
+	DefinitionTemplate = "template" // Returns rendered text
+)
+
+// defaultDeclarativeTimeout bounds each call of an exec or http tool.
+const defaultDeclarativeTimeout = 30 * time.Second
+
+// commandWaitDelay bounds how long a killed command's children may hold its output open.
+const commandWaitDelay = time.Second
+
+// maxDeclarativeResponseBytes caps how much of a command's output or an HTTP response a
+// declarative tool returns.
+const maxDeclarativeResponseBytes = 1 << 20
+
+// placeholderPattern matches {{name}} placeholders in definition templates.
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Definition describes a tool built from data rather than Go code. Command, URL, Headers,
+// and Template may contain {{name}} placeholders, which are replaced with the call's
+// arguments.
+type Definition struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"` // Defaults to a string property per placeholder
+	Category    string                 `json:"category,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Timeout     int                    `json:"timeout,omitempty"` // Seconds allowed per exec or http call (default 30)
+
+	RequiresApproval bool `json:"requiresApproval,omitempty"` // Calls wait for an operator to approve them
+
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"` // Describes the results; see OutputSchemaProvider
+
+	Version    string `json:"version,omitempty"`    // Registers the tool as name@version alongside its other versions
+	Deprecated string `json:"deprecated,omitempty"` // Deprecation notice, such as what to use instead
+
+	Command  []string          `json:"command,omitempty"`  // exec: program and arguments
+	URL      string            `json:"url,omitempty"`      // http: request URL; placeholder values are escaped
+	Method   string            `json:"method,omitempty"`   // http: request method (default GET)
+	Headers  map[string]string `json:"headers,omitempty"`  // http: request headers
+	Extract  string            `json:"extract,omitempty"`  // http: JSONPath selecting the returned body, e.g. $.items[0].name
+	Template string            `json:"template,omitempty"` // template: the text returned
+}
+
+// Validate reports whether the definition describes a usable tool.
+func (d Definition) Validate() error {
+	if d.Name == "" {
+		return errors.New("tool name is required")
+	}
+	if d.Description == "" {
+		return errors.New("tool description is required")
+	}
+	if strings.Contains(d.Name, VersionSeparator) || strings.Contains(d.Version, VersionSeparator) {
+		return fmt.Errorf("tool name and version must not contain %q; set the version separately", VersionSeparator)
+	}
+	switch d.Type {
+	case DefinitionExec:
+		if len(d.Command) == 0 || d.Command[0] == "" {
+			return errors.New("exec tools require a command")
+		}
+		if placeholderPattern.MatchString(d.Command[0]) {
+			return errors.New("exec tools must name their program; placeholders are only allowed in its arguments")
+		}
+	case DefinitionHTTP:
+		u, err := url.Parse(placeholderPattern.ReplaceAllString(d.URL, "x"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("http tools require an http(s) url")
+		}
+		if d.Extract != "" {
+			if _, err := parseJSONPath(d.Extract); err != nil {
+				return err
+			}
+		}
+	case DefinitionTemplate:
+		if d.Template == "" {
+			return errors.New("template tools require a template")
+		}
+	default:
+		return fmt.Errorf("unknown tool type %q; use exec, http, or template", d.Type)
+	}
+	return nil
+}
+
+// placeholders returns the names of the placeholders in the definition, in order of
+// first use.
+func (d Definition) placeholders() []string {
+	templates := append([]string{d.URL, d.Template}, d.Command...)
+	for _, value := range d.Headers {
+		templates = append(templates, value)
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, template := range templates {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	return names
+}
+
+// DeclarativeTool is a Tool built from a Definition. It implements ContextTool, so calls
+// stop when they are canceled or reach their time limit.
+type DeclarativeTool struct {
+	definition Definition
+	extract    []jsonPathStep
+	timeout    time.Duration
+	client     *http.Client
+	logger     *slog.Logger
+}
+
+// NewDeclarativeTool validates definition and creates the tool it describes.
+func NewDeclarativeTool(definition Definition, logger *slog.Logger) (*DeclarativeTool, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	timeout := defaultDeclarativeTimeout
+	if definition.Timeout > 0 {
+		timeout = time.Duration(definition.Timeout) * time.Second
+	}
+	if definition.Method == "" {
+		definition.Method = http.MethodGet
+	}
+	definition.Method = strings.ToUpper(definition.Method)
+	var extract []jsonPathStep
+	if definition.Extract != "" {
+		extract, _ = parseJSONPath(definition.Extract)
+	}
+	return &DeclarativeTool{
+		definition: definition,
+		extract:    extract,
+		timeout:    timeout,
+		client:     httpclient.New(definition.Name, httpclient.WithTimeout(timeout)),
+		logger:     logger,
+	}, nil
+}
+
+// Definition returns the definition the tool was built from.
+func (t *DeclarativeTool) Definition() Definition {
+	return t.definition
+}
+
+// Name returns the tool's name, including its version if it has one
+func (t *DeclarativeTool) Name() string {
+	if t.definition.Version != "" {
+		return t.definition.Name + VersionSeparator + t.definition.Version
+	}
+	return t.definition.Name
+}
+
+// Description returns the tool's description
+func (t *DeclarativeTool) Description() string {
+	return t.definition.Description
+}
+
+// Category returns the tool's category
+func (t *DeclarativeTool) Category() string {
+	return t.definition.Category
+}
+
+// Tags returns the tool's tags
+func (t *DeclarativeTool) Tags() []string {
+	return t.definition.Tags
+}
+
+// RequiresApproval reports whether the definition's calls wait for operator approval
+func (t *DeclarativeTool) RequiresApproval() bool {
+	return t.definition.RequiresApproval
+}
+
+// OutputSchema returns the definition's output schema, if any
+func (t *DeclarativeTool) OutputSchema() map[string]interface{} {
+	return t.definition.OutputSchema
+}
+
+// Deprecation returns the definition's deprecation notice
+func (t *DeclarativeTool) Deprecation() string {
+	return t.definition.Deprecated
+}
+
+// InputSchema returns the definition's schema, or one requiring a string for every
+// placeholder when the definition has none.
+func (t *DeclarativeTool) InputSchema() map[string]interface{} {
+	if t.definition.InputSchema != nil {
+		return t.definition.InputSchema
+	}
+	properties := make(map[string]interface{})
+	required := []string{}
+	for _, name := range t.definition.placeholders() {
+		properties[name] = map[string]interface{}{"type": "string"}
+		required = append(required, name)
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *DeclarativeTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the tool, stopping a command or request when ctx is done
+func (t *DeclarativeTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	switch t.definition.Type {
+	case DefinitionExec:
+		return t.executeCommand(ctx, args)
+	case DefinitionHTTP:
+		return t.executeRequest(ctx, args)
+	default:
+		text, err := expandPlaceholders(t.definition.Template, args, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"text": text}, nil
+	}
+}
+
+// executeCommand runs the command directly, so arguments are never interpreted by a
+// shell, and kills it when ctx is done. An argument may only start with "-" after a
+// "--" in the command, so it cannot be taken for an option. Output, CPU time, and memory
+// are held to the call's quota. A non-zero exit status is an error.
+func (t *DeclarativeTool) executeCommand(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	command := make([]string, len(t.definition.Command))
+	optionsEnded := false
+	for i, part := range t.definition.Command {
+		expanded, err := expandPlaceholders(part, args, nil)
+		if err != nil {
+			return nil, err
+		}
+		if !optionsEnded && strings.HasPrefix(expanded, "-") && !strings.HasPrefix(part, "-") {
+			return nil, fmt.Errorf("argument %q must not start with \"-\"", expanded)
+		}
+		optionsEnded = optionsEnded || part == "--"
+		command[i] = expanded
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	var stdout, stderr limitedBuffer
+	stdoutWriter, stderrWriter := OutputWriter(ctx, &stdout), OutputWriter(ctx, &stderr)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Stdout = stdoutWriter
+	cmd.Stderr = stderrWriter
+	cmd.WaitDelay = commandWaitDelay
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	quota, _ := QuotaFromContext(ctx)
+	if err := limitProcess(cmd.Process.Pid, quota); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return nil, fmt.Errorf("failed to limit %s: %w", command[0], err)
+	}
+	err := cmd.Wait()
+
+	for _, writer := range []io.Writer{stdoutWriter, stderrWriter} {
+		if limited, ok := writer.(*LimitedWriter); ok && limited.Exceeded() {
+			return nil, &QuotaError{Resource: QuotaOutput, Limit: limited.limit}
+		}
+	}
+	if state := cmd.ProcessState; state != nil {
+		if quota.CPUTime > 0 && state.UserTime()+state.SystemTime() > quota.CPUTime {
+			return nil, &QuotaError{Resource: QuotaCPUTime, Limit: quota.CPUTime.Milliseconds()}
+		}
+		if quota.MaxMemoryBytes > 0 && maxRSS(state) > quota.MaxMemoryBytes {
+			return nil, &QuotaError{Resource: QuotaMemory, Limit: quota.MaxMemoryBytes}
+		}
+	}
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("%s stopped: %w", command[0], context.Cause(ctx))
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("failed to run %s: %w", command[0], err)
+	}
+	if exitErr != nil {
+		return nil, fmt.Errorf("%s exited with status %d: %s", command[0], exitErr.ExitCode(), strings.TrimSpace(stderr.String()))
+	}
+	return map[string]interface{}{
+		"stdout": stdout.String(),
+		"stderr": stderr.String(),
+	}, nil
+}
+
+// executeRequest calls the endpoint. Methods other than GET, HEAD, and DELETE send the
+// arguments as a JSON body. Error statuses are errors. With Extract set, the returned
+// body is the value it selects from the JSON response.
+func (t *DeclarativeTool) executeRequest(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	target, err := expandPlaceholders(t.definition.URL, args, escapeURLValue)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	switch t.definition.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+	default:
+		encoded, err := json.Marshal(args)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request body: %w", err)
+		}
+		body = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, t.definition.Method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, value := range t.definition.Headers {
+		expanded, err := expandPlaceholders(value, args, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, expanded)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDeclarativeResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(data) > maxDeclarativeResponseBytes {
+		return nil, fmt.Errorf("response exceeds %d bytes", maxDeclarativeResponseBytes)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		if t.extract != nil {
+			return nil, fmt.Errorf("response is not JSON: %w", err)
+		}
+		decoded = string(data)
+	}
+	if t.extract != nil {
+		if decoded, err = evalJSONPath(t.extract, decoded); err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", t.definition.Extract, err)
+		}
+	}
+	return map[string]interface{}{
+		"status": resp.StatusCode,
+		"body":   decoded,
+	}, nil
+}
+
+// expandPlaceholders replaces the {{name}} placeholders in template with the matching
+// arguments, passed through escape when it is set. A missing argument is an error.
+func expandPlaceholders(template string, args map[string]interface{}, escape func(string) string) (string, error) {
+	var missing string
+	expanded := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		value, ok := args[name]
+		if !ok || value == nil {
+			if missing == "" {
+				missing = name
+			}
+			return ""
+		}
+		text, ok := value.(string)
+		if !ok {
+			text = fmt.Sprint(value)
+		}
+		if escape != nil {
+			text = escape(text)
+		}
+		return text
+	})
+	if missing != "" {
+		return "", fmt.Errorf("missing required argument: %s", missing)
+	}
+	return expanded, nil
+}
+
+// escapeURLValue escapes a value for use in either a URL path segment or a query.
+func escapeURLValue(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// limitedBuffer keeps the first maxDeclarativeResponseBytes written to it and discards
+// the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+// Write implements io.Writer, always reporting success so the command is not disturbed.
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxDeclarativeResponseBytes - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,209 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestDeclarativeTool(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("template", func(t *testing.T) {
+		tool, err := NewDeclarativeTool(Definition{
+			Type: DefinitionTemplate, Name: "greet", Description: "Greets", Template: "Hello, {{ name }}!",
+		}, logger)
+		if err != nil {
+			t.Fatalf("NewDeclarativeTool failed: %v", err)
+		}
+		result, err := tool.Execute(map[string]interface{}{"name": "ops"})
+		if err != nil || result["text"] != "Hello, ops!" {
+			t.Errorf("Unexpected result: %v (%v)", result, err)
+		}
+		if _, err := tool.Execute(nil); err == nil {
+			t.Error("Expected an error for a missing argument")
+		}
+		required := tool.InputSchema()["required"].([]string)
+		if len(required) != 1 || required[0] != "name" {
+			t.Errorf("Expected the placeholder to be required, got %v", required)
+		}
+	})
+
+	t.Run("versioned", func(t *testing.T) {
+		tool, err := NewDeclarativeTool(Definition{
+			Type: DefinitionTemplate, Name: "greet", Description: "Greets", Template: "Hi",
+			Version: "v1", Deprecated: "Use greet@v2",
+		}, logger)
+		if err != nil {
+			t.Fatalf("NewDeclarativeTool failed: %v", err)
+		}
+		if tool.Name() != "greet@v1" || tool.Deprecation() != "Use greet@v2" {
+			t.Errorf("Unexpected name or deprecation: %q, %q", tool.Name(), tool.Deprecation())
+		}
+	})
+
+	t.Run("http", func(t *testing.T) {
+		var gotPath, gotHeader string
+		var gotBody map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.EscapedPath() + "?" + r.URL.RawQuery
+			gotHeader = r.Header.Get("X-Team")
+			data, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(data, &gotBody)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}))
+		defer server.Close()
+
+		tool, err := NewDeclarativeTool(Definition{
+			Type: DefinitionHTTP, Name: "lookup", Description: "Looks up",
+			URL: server.URL + "/items/{{id}}?q={{query}}", Method: "post",
+			Headers: map[string]string{"X-Team": "{{team}}"},
+		}, logger)
+		if err != nil {
+			t.Fatalf("NewDeclarativeTool failed: %v", err)
+		}
+		args := map[string]interface{}{"id": "a/b", "query": "x&y z", "team": "ops"}
+		result, err := tool.Execute(args)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if gotPath != "/items/a%2Fb?q=x%26y%20z" {
+			t.Errorf("Expected escaped placeholders, got %s", gotPath)
+		}
+		if gotHeader != "ops" || gotBody["team"] != "ops" {
+			t.Errorf("Unexpected header %q or body %v", gotHeader, gotBody)
+		}
+		if body, _ := result["body"].(map[string]interface{}); body["ok"] != true || result["status"] != http.StatusOK {
+			t.Errorf("Unexpected result: %v", result)
+		}
+	})
+
+	t.Run("http extracts with JSONPath", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"items":[{"name":"a"},{"name":"b"}]}`))
+		}))
+		defer server.Close()
+
+		for path, want := range map[string]interface{}{
+			"$.items[1].name":     "b",
+			"$['items'][-1].name": "b",
+			"$.items[*].name":     []interface{}{"a", "b"},
+		} {
+			tool, err := NewDeclarativeTool(Definition{
+				Type: DefinitionHTTP, Name: "items", Description: "Lists items", URL: server.URL, Extract: path,
+			}, logger)
+			if err != nil {
+				t.Fatalf("NewDeclarativeTool failed: %v", err)
+			}
+			result, err := tool.Execute(nil)
+			if err != nil {
+				t.Fatalf("%s: Execute failed: %v", path, err)
+			}
+			if got, _ := json.Marshal(result["body"]); string(got) != mustJSON(t, want) {
+				t.Errorf("%s: expected %s, got %s", path, mustJSON(t, want), got)
+			}
+		}
+
+		tool, _ := NewDeclarativeTool(Definition{
+			Type: DefinitionHTTP, Name: "items", Description: "Lists items", URL: server.URL, Extract: "$.missing",
+		}, logger)
+		if _, err := tool.Execute(nil); err == nil {
+			t.Error("Expected an error for a missing key")
+		}
+	})
+
+	t.Run("exec", func(t *testing.T) {
+		if _, err := exec.LookPath("echo"); err != nil {
+			t.Skip("echo is not available")
+		}
+		tool, err := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "say", Description: "Echoes", Command: []string{"echo", "{{text}}"},
+		}, logger)
+		if err != nil {
+			t.Fatalf("NewDeclarativeTool failed: %v", err)
+		}
+		result, err := tool.Execute(map[string]interface{}{"text": "hi; rm -rf /"})
+		if err != nil || result["stdout"] != "hi; rm -rf /\n" {
+			t.Errorf("Expected the argument to reach echo unchanged, got %v (%v)", result, err)
+		}
+		if _, err := tool.Execute(map[string]interface{}{"text": "-e"}); err == nil {
+			t.Error("Expected an argument that looks like an option to be rejected")
+		}
+	})
+
+	t.Run("exec arguments after -- may start with a dash", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("sh is not available")
+		}
+		tool, err := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "say", Description: "Prints", Command: []string{"sh", "-c", `printf %s "$2"`, "sh", "--", "{{text}}"},
+		}, logger)
+		if err != nil {
+			t.Fatalf("NewDeclarativeTool failed: %v", err)
+		}
+		if result, err := tool.Execute(map[string]interface{}{"text": "-n"}); err != nil || result["stdout"] != "-n" {
+			t.Errorf("Expected the argument to be passed after --, got %v (%v)", result, err)
+		}
+	})
+
+	t.Run("exec honors cancellation and the output quota", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("sh is not available")
+		}
+		sleeper, _ := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "sleep", Description: "Sleeps", Command: []string{"sh", "-c", "sleep 10"},
+		}, logger)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		if _, err := sleeper.ExecuteContext(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the command to stop with its context, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("Expected the command to be killed, took %v", elapsed)
+		}
+
+		talker, _ := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "talk", Description: "Talks", Command: []string{"sh", "-c", "yes | head -c 100000"},
+		}, logger)
+		quotaCtx := WithQuota(context.Background(), Quota{MaxOutputBytes: 1000})
+		if _, err := talker.ExecuteContext(quotaCtx, nil); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Expected the output quota to stop the command, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		for name, definition := range map[string]Definition{
+			"no name":      {Type: DefinitionTemplate, Description: "d", Template: "t"},
+			"bad type":     {Type: "grpc", Name: "n", Description: "d"},
+			"no command":   {Type: DefinitionExec, Name: "n", Description: "d"},
+			"open program": {Type: DefinitionExec, Name: "n", Description: "d", Command: []string{"{{program}}"}},
+			"bad url":      {Type: DefinitionHTTP, Name: "n", Description: "d", URL: "ftp://host"},
+			"no template":  {Type: DefinitionTemplate, Name: "n", Description: "d"},
+			"bad extract":  {Type: DefinitionHTTP, Name: "n", Description: "d", URL: "https://host", Extract: "items"},
+			"version name": {Type: DefinitionTemplate, Name: "n@v1", Description: "d", Template: "t"},
+		} {
+			if _, err := NewDeclarativeTool(definition, logger); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+// mustJSON encodes value as JSON.
+func mustJSON(t *testing.T, value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to encode %v: %v", value, err)
+	}
+	return string(data)
+}
@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Default dir_hash limits.
+const (
+	defaultDirHashMaxFiles       = 10000
+	defaultDirHashMaxBytes int64 = 1 << 30 // 1 GiB
+)
+
+// dirHashAlgorithms lists the digest algorithms dir_hash supports.
+var dirHashAlgorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// DirHasher computes deterministic content hashes of a directory tree inside a sandbox and
+// implements Tool.
+type DirHasher struct {
+	logger     *slog.Logger
+	sandboxDir string
+	maxFiles   int
+	maxBytes   int64
+}
+
+// NewDirHasher creates a directory hasher confined to sandboxDir. Trees with more than
+// maxFiles files or maxBytes bytes are refused.
+func NewDirHasher(logger *slog.Logger, sandboxDir string, maxFiles int, maxBytes int64) *DirHasher {
+	if maxFiles <= 0 {
+		maxFiles = defaultDirHashMaxFiles
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultDirHashMaxBytes
+	}
+	return &DirHasher{
+		logger:     logger,
+		sandboxDir: sandboxDir,
+		maxFiles:   maxFiles,
+		maxBytes:   maxBytes,
+	}
+}
+
+// NewDirHasherFromConfig creates a directory hasher from DIR_HASH_SANDBOX_DIR,
+// DIR_HASH_MAX_FILES, and DIR_HASH_MAX_BYTES. It fails when no sandbox is configured, so
+// the registry skips the tool.
+func NewDirHasherFromConfig(logger *slog.Logger, config map[string]string) (*DirHasher, error) {
+	sandboxDir := config["DIR_HASH_SANDBOX_DIR"]
+	if sandboxDir == "" {
+		return nil, errors.New("DIR_HASH_SANDBOX_DIR is not set")
+	}
+	var maxFiles int
+	if raw := config["DIR_HASH_MAX_FILES"]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DIR_HASH_MAX_FILES: %w", err)
+		}
+		maxFiles = parsed
+	}
+	var maxBytes int64
+	if raw := config["DIR_HASH_MAX_BYTES"]; raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DIR_HASH_MAX_BYTES: %w", err)
+		}
+		maxBytes = parsed
+	}
+	return NewDirHasher(logger, sandboxDir, maxFiles, maxBytes), nil
+}
+
+// Name returns the tool's name
+func (d *DirHasher) Name() string {
+	return "dir_hash"
+}
+
+// Description returns the tool's description
+func (d *DirHasher) Description() string {
+	return "Computes deterministic per-file hashes and a merkle root hash of a sandboxed directory tree"
+}
+
+// Category returns the tool's category
+func (d *DirHasher) Category() string {
+	return "security"
+}
+
+// Tags returns the tool's tags
+func (d *DirHasher) Tags() []string {
+	return []string{"hash", "merkle", "checksum", "directory", "verification"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (d *DirHasher) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"path": map[string]interface{}{
+				"type":        "string",
+				"description": "Directory relative to the sandbox directory (default: the sandbox itself)",
+			},
+			"algorithm": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"sha256", "sha512"},
+				"description": "Digest algorithm (default sha256)",
+			},
+			"ignore": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Glob patterns to skip. Patterns without a slash match names at any depth, e.g. *.log or node_modules; patterns with one match paths from the directory, e.g. build/*.o; a trailing slash matches only directories",
+			},
+			"include_files": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return every file's hash and every directory's merkle hash, not only the root (default true)",
+			},
+		},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (d *DirHasher) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	dir, _ := args["path"].(string)
+	algorithm, _ := args["algorithm"].(string)
+	includeFiles := true
+	if value, ok := args["include_files"].(bool); ok {
+		includeFiles = value
+	}
+	if algorithm == "" {
+		algorithm = "sha256"
+	}
+	newHash, ok := dirHashAlgorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported algorithm: %s", algorithm)
+	}
+	var ignore []string
+	if raw, ok := args["ignore"].([]interface{}); ok {
+		for _, value := range raw {
+			pattern, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("ignore patterns must be strings")
+			}
+			if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+				return nil, fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+			}
+			ignore = append(ignore, pattern)
+		}
+	}
+
+	dir = path.Clean("/" + strings.ReplaceAll(dir, "\\", "/"))[1:]
+	if dir == "" {
+		dir = "."
+	}
+	root, err := os.OpenRoot(d.sandboxDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sandbox directory: %w", err)
+	}
+	defer root.Close()
+	fsys, err := fs.Sub(root.FS(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %s: %w", dir, err)
+	}
+	if info, err := fs.Stat(fsys, "."); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", dir, err)
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	walk := &dirHashWalk{
+		hasher:      d,
+		fsys:        fsys,
+		newHash:     newHash,
+		ignore:      ignore,
+		files:       []map[string]interface{}{},
+		directories: map[string]string{},
+	}
+	rootHash, err := walk.hashDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	d.logger.Info("Hashed directory", "path", dir, "files", walk.fileCount, "bytes", walk.totalBytes)
+	result := map[string]interface{}{
+		"path":        dir,
+		"algorithm":   algorithm,
+		"root":        rootHash,
+		"file_count":  walk.fileCount,
+		"total_bytes": walk.totalBytes,
+		"skipped":     walk.skipped,
+	}
+	if includeFiles {
+		result["files"] = walk.files
+		result["directories"] = walk.directories
+	}
+	return result, nil
+}
+
+// dirHashWalk holds the state of one dir_hash call.
+type dirHashWalk struct {
+	hasher      *DirHasher
+	fsys        fs.FS
+	newHash     func() hash.Hash
+	ignore      []string
+	files       []map[string]interface{}
+	directories map[string]string
+	fileCount   int
+	totalBytes  int64
+	skipped     int // Symlinks and special files, which are not hashed
+}
+
+// hashDir returns the merkle hash of a directory: the hash of one line per entry, in name
+// order, giving the entry's kind, hash, and name. Only names and contents contribute, so
+// the hash does not change with timestamps, permissions, or ownership.
+func (w *dirHashWalk) hashDir(dir string) (string, error) {
+	entries, err := fs.ReadDir(w.fsys, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	tree := w.newHash()
+	for _, entry := range entries {
+		name := path.Join(dir, entry.Name())
+		if w.ignored(name, entry.IsDir()) {
+			continue
+		}
+		switch {
+		case entry.IsDir():
+			sum, err := w.hashDir(name)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(tree, "tree %s %s\n", sum, entry.Name())
+		case entry.Type().IsRegular():
+			sum, err := w.hashFile(name)
+			if err != nil {
+				return "", err
+			}
+			fmt.Fprintf(tree, "file %s %s\n", sum, entry.Name())
+		default:
+			w.skipped++
+		}
+	}
+	sum := hex.EncodeToString(tree.Sum(nil))
+	w.directories[dir] = sum
+	return sum, nil
+}
+
+// hashFile returns the hash of a file's contents and records it.
+func (w *dirHashWalk) hashFile(name string) (string, error) {
+	w.fileCount++
+	if w.fileCount > w.hasher.maxFiles {
+		return "", fmt.Errorf("directory has more than %d files", w.hasher.maxFiles)
+	}
+	file, err := w.fsys.Open(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", name, err)
+	}
+	defer file.Close()
+
+	h := w.newHash()
+	remaining := w.hasher.maxBytes - w.totalBytes
+	size, err := io.Copy(h, io.LimitReader(file, remaining+1))
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if size > remaining {
+		return "", fmt.Errorf("directory exceeds the maximum size of %d bytes", w.hasher.maxBytes)
+	}
+	w.totalBytes += size
+	sum := hex.EncodeToString(h.Sum(nil))
+	w.files = append(w.files, map[string]interface{}{"path": name, "hash": sum, "size": size})
+	return sum, nil
+}
+
+// ignored reports whether an ignore pattern matches a path relative to the hashed
+// directory.
+func (w *dirHashWalk) ignored(name string, isDir bool) bool {
+	for _, pattern := range w.ignore {
+		if strings.HasSuffix(pattern, "/") {
+			if !isDir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		if matched, _ := path.Match(strings.TrimPrefix(pattern, "/"), target); matched {
+			return true
+		}
+	}
+	return false
+}
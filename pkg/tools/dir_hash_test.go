@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTree creates files, keyed by slash-separated path, under dir.
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		full := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDirHasher(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	tree := map[string]string{
+		"src/main.go":         "package main\n",
+		"src/util/util.go":    "package util\n",
+		"README.md":           "# readme\n",
+		"build/out.log":       "noise",
+		"node_modules/x/y.js": "noise",
+	}
+
+	t.Run("hashes files and directories", func(t *testing.T) {
+		sandbox := t.TempDir()
+		writeTree(t, sandbox, tree)
+		hasher := NewDirHasher(logger, sandbox, 0, 0)
+
+		result, err := hasher.Execute(map[string]interface{}{"path": "src"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["file_count"] != 2 || result["total_bytes"] != int64(26) {
+			t.Errorf("Unexpected counts: %v", result)
+		}
+		files := result["files"].([]map[string]interface{})
+		sum := sha256.Sum256([]byte("package main\n"))
+		if files[0]["path"] != "main.go" || files[0]["hash"] != hex.EncodeToString(sum[:]) {
+			t.Errorf("Unexpected file entry: %v", files[0])
+		}
+		directories := result["directories"].(map[string]string)
+		if directories["."] != result["root"] || directories["util"] == "" {
+			t.Errorf("Unexpected directory hashes: %v", directories)
+		}
+	})
+
+	t.Run("is deterministic and ignores metadata", func(t *testing.T) {
+		first, second := t.TempDir(), t.TempDir()
+		writeTree(t, first, tree)
+		writeTree(t, second, tree)
+		past := time.Now().Add(-48 * time.Hour)
+		if err := os.Chtimes(filepath.Join(second, "README.md"), past, past); err != nil {
+			t.Fatal(err)
+		}
+		a, _ := NewDirHasher(logger, first, 0, 0).Execute(map[string]interface{}{})
+		b, _ := NewDirHasher(logger, second, 0, 0).Execute(map[string]interface{}{})
+		if a["root"] != b["root"] {
+			t.Errorf("Expected identical trees to hash equally: %v != %v", a["root"], b["root"])
+		}
+
+		writeTree(t, second, map[string]string{"src/util/util.go": "package util // changed\n"})
+		c, _ := NewDirHasher(logger, second, 0, 0).Execute(map[string]interface{}{})
+		if a["root"] == c["root"] {
+			t.Error("Expected a changed file to change the root hash")
+		}
+		if a["directories"].(map[string]string)["build"] != c["directories"].(map[string]string)["build"] {
+			t.Error("Expected unchanged subtrees to keep their hashes")
+		}
+	})
+
+	t.Run("applies ignore patterns", func(t *testing.T) {
+		sandbox := t.TempDir()
+		writeTree(t, sandbox, tree)
+		hasher := NewDirHasher(logger, sandbox, 0, 0)
+		result, err := hasher.Execute(map[string]interface{}{
+			"ignore":        []interface{}{"node_modules/", "*.log"},
+			"include_files": true,
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		for _, file := range result["files"].([]map[string]interface{}) {
+			if file["path"] == "build/out.log" || file["path"] == "node_modules/x/y.js" {
+				t.Errorf("Expected %s to be ignored", file["path"])
+			}
+		}
+		if result["file_count"] != 3 {
+			t.Errorf("Expected 3 files, got %v", result["file_count"])
+		}
+	})
+
+	t.Run("stays inside the sandbox and within limits", func(t *testing.T) {
+		sandbox := t.TempDir()
+		writeTree(t, sandbox, tree)
+		outside := t.TempDir()
+		if err := os.Symlink(outside, filepath.Join(sandbox, "escape")); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := NewDirHasher(logger, sandbox, 0, 0).Execute(map[string]interface{}{"path": "../../"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["path"] != "." || result["skipped"] != 1 {
+			t.Errorf("Expected the path to be clamped and the symlink skipped: %v", result)
+		}
+		if _, err := NewDirHasher(logger, sandbox, 0, 0).Execute(map[string]interface{}{"path": "escape"}); err == nil {
+			t.Error("Expected a symlink out of the sandbox to be refused")
+		}
+		if _, err := NewDirHasher(logger, sandbox, 2, 0).Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected the file limit to be enforced")
+		}
+		if _, err := NewDirHasher(logger, sandbox, 0, 10).Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected the size limit to be enforced")
+		}
+		if _, err := NewDirHasherFromConfig(logger, map[string]string{}); err == nil {
+			t.Error("Expected no tool without a sandbox")
+		}
+	})
+}
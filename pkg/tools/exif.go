@@ -0,0 +1,243 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+)
+
+// maxExifEntries bounds how many entries one IFD may hold, so corrupt data cannot make the
+// parser loop for long.
+const maxExifEntries = 512
+
+// exifTags names the tags readExif reports from the main IFD.
+var exifTags = map[uint16]string{
+	0x010F: "Make",
+	0x0110: "Model",
+	0x0112: "Orientation",
+	0x011A: "XResolution",
+	0x011B: "YResolution",
+	0x0131: "Software",
+	0x0132: "DateTime",
+	0x013B: "Artist",
+	0x8298: "Copyright",
+}
+
+// exifSubTags names the tags readExif reports from the EXIF sub-IFD.
+var exifSubTags = map[uint16]string{
+	0x829A: "ExposureTime",
+	0x829D: "FNumber",
+	0x8827: "ISOSpeedRatings",
+	0x9003: "DateTimeOriginal",
+	0x920A: "FocalLength",
+	0xA002: "PixelXDimension",
+	0xA003: "PixelYDimension",
+	0xA434: "LensModel",
+}
+
+// EXIF pointer tags.
+const (
+	exifIFDPointer = 0x8769
+	gpsIFDPointer  = 0x8825
+)
+
+// readExif returns the common EXIF tags of a JPEG or TIFF image, or nil when it has none.
+// GPS coordinates are reported as decimal GPSLatitude and GPSLongitude.
+func readExif(data []byte) map[string]interface{} {
+	tiff := data
+	if len(data) > 2 && data[0] == 0xFF && data[1] == 0xD8 {
+		if tiff = jpegExif(data); tiff == nil {
+			return nil
+		}
+	}
+	if len(tiff) < 8 {
+		return nil
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	r := exifReader{data: tiff, order: order}
+	tags := make(map[string]interface{})
+	ifd0 := r.readIFD(order.Uint32(tiff[4:]))
+	for tag, name := range exifTags {
+		if value := r.value(ifd0[tag]); value != nil {
+			tags[name] = value
+		}
+	}
+	if pointer, ok := r.value(ifd0[exifIFDPointer]).(int64); ok {
+		sub := r.readIFD(uint32(pointer))
+		for tag, name := range exifSubTags {
+			if value := r.value(sub[tag]); value != nil {
+				tags[name] = value
+			}
+		}
+	}
+	if pointer, ok := r.value(ifd0[gpsIFDPointer]).(int64); ok {
+		gps := r.readIFD(uint32(pointer))
+		if lat, ok := r.coordinate(gps[2], gps[1], "S"); ok {
+			tags["GPSLatitude"] = lat
+		}
+		if lon, ok := r.coordinate(gps[4], gps[3], "W"); ok {
+			tags["GPSLongitude"] = lon
+		}
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// jpegExif returns the TIFF data of a JPEG's APP1 EXIF segment.
+func jpegExif(data []byte) []byte {
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			i += 2
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			// Image data follows; metadata segments always precede it.
+			return nil
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return nil
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return segment[6:]
+		}
+		i = end
+	}
+	return nil
+}
+
+// exifEntry is one undecoded IFD entry.
+type exifEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// exifReader decodes IFDs from TIFF data.
+type exifReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// exifTypeSizes gives the size in bytes of each TIFF field type.
+var exifTypeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+// readIFD returns the entries of the IFD at offset, keyed by tag. Entries that point
+// outside the data are dropped.
+func (r exifReader) readIFD(offset uint32) map[uint16]exifEntry {
+	entries := make(map[uint16]exifEntry)
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return entries
+	}
+	count := int(r.order.Uint16(r.data[offset:]))
+	if count > maxExifEntries {
+		return entries
+	}
+	for i := 0; i < count; i++ {
+		start := uint64(offset) + 2 + uint64(i)*12
+		if start+12 > uint64(len(r.data)) {
+			break
+		}
+		raw := r.data[start : start+12]
+		typ := r.order.Uint16(raw[2:])
+		size, ok := exifTypeSizes[typ]
+		if !ok {
+			continue
+		}
+		n := r.order.Uint32(raw[4:])
+		length := uint64(size) * uint64(n)
+		value := raw[8:12]
+		if length > 4 {
+			at := uint64(r.order.Uint32(raw[8:]))
+			if at+length > uint64(len(r.data)) {
+				continue
+			}
+			value = r.data[at : at+length]
+		}
+		entries[r.order.Uint16(raw)] = exifEntry{typ: typ, count: n, value: value[:min(length, uint64(len(value)))]}
+	}
+	return entries
+}
+
+// value decodes an entry holding text or a single number. Other entries yield nil.
+func (r exifReader) value(e exifEntry) interface{} {
+	switch {
+	case e.typ == 2:
+		text := strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+		if text == "" {
+			return nil
+		}
+		return text
+	case e.count != 1:
+		return nil
+	case e.typ == 1 || e.typ == 7:
+		return int64(e.value[0])
+	case e.typ == 3:
+		return int64(r.order.Uint16(e.value))
+	case e.typ == 4:
+		return int64(r.order.Uint32(e.value))
+	case e.typ == 9:
+		return int64(int32(r.order.Uint32(e.value)))
+	case e.typ == 5 || e.typ == 10:
+		rational, ok := r.rational(e, 0)
+		if !ok {
+			return nil
+		}
+		return rational
+	}
+	return nil
+}
+
+// rational decodes the i-th rational of an entry.
+func (r exifReader) rational(e exifEntry, i int) (float64, bool) {
+	if (e.typ != 5 && e.typ != 10) || uint32(i) >= e.count {
+		return 0, false
+	}
+	num, den := r.order.Uint32(e.value[i*8:]), r.order.Uint32(e.value[i*8+4:])
+	if den == 0 {
+		return 0, false
+	}
+	if e.typ == 10 {
+		return float64(int32(num)) / float64(int32(den)), true
+	}
+	return float64(num) / float64(den), true
+}
+
+// coordinate converts a GPS degrees, minutes, seconds entry to decimal degrees, negative
+// when ref is the given negative hemisphere.
+func (r exifReader) coordinate(e, ref exifEntry, negative string) (float64, bool) {
+	if e.count != 3 {
+		return 0, false
+	}
+	var parts [3]float64
+	for i := range parts {
+		part, ok := r.rational(e, i)
+		if !ok {
+			return 0, false
+		}
+		parts[i] = part
+	}
+	degrees := parts[0] + parts[1]/60 + parts[2]/3600
+	if ref, _ := r.value(ref).(string); ref == negative {
+		degrees = -degrees
+	}
+	return math.Round(degrees*1e6) / 1e6, true
+}
@@ -0,0 +1,504 @@
+package tools
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// defaultExtractMaxBytes caps how much HTML extract_content reads.
+const defaultExtractMaxBytes int64 = 5 << 20 // 5 MiB
+
+// maxExtractLinks caps how many links extract_content returns.
+const maxExtractLinks = 200
+
+// extractSkipped lists elements whose content is never part of a page's text.
+var extractSkipped = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Nav: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Button: true,
+}
+
+// blankLines matches runs of blank lines left after removing markup.
+var blankLines = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+
+// ContentExtractor turns HTML into clean text or Markdown and implements Tool.
+type ContentExtractor struct {
+	logger       *slog.Logger
+	allowedHosts []string
+	maxBytes     int64
+	client       *http.Client
+}
+
+// NewContentExtractor creates a content extractor. An empty allowedHosts list disables
+// fetching URLs, leaving only inline HTML.
+func NewContentExtractor(logger *slog.Logger, allowedHosts []string, maxBytes int64) *ContentExtractor {
+	if maxBytes <= 0 {
+		maxBytes = defaultExtractMaxBytes
+	}
+	e := &ContentExtractor{
+		logger:       logger,
+		allowedHosts: allowedHosts,
+		maxBytes:     maxBytes,
+	}
+	e.client = httpclient.New("extract_content", httpclient.WithAllowedHost(e.hostAllowed))
+	return e
+}
+
+// NewContentExtractorFromConfig creates a content extractor from EXTRACT_ALLOWED_HOSTS
+// and EXTRACT_MAX_BYTES.
+func NewContentExtractorFromConfig(logger *slog.Logger, config map[string]string) (*ContentExtractor, error) {
+	var allowedHosts []string
+	for _, host := range strings.Split(config["EXTRACT_ALLOWED_HOSTS"], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			allowedHosts = append(allowedHosts, strings.ToLower(host))
+		}
+	}
+	var maxBytes int64
+	if raw := config["EXTRACT_MAX_BYTES"]; raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EXTRACT_MAX_BYTES: %w", err)
+		}
+		maxBytes = parsed
+	}
+	return NewContentExtractor(logger, allowedHosts, maxBytes), nil
+}
+
+// Name returns the tool's name
+func (e *ContentExtractor) Name() string {
+	return "extract_content"
+}
+
+// Description returns the tool's description
+func (e *ContentExtractor) Description() string {
+	return "Extracts the readable text of an HTML page as plain text or Markdown, with its title and links"
+}
+
+// Category returns the tool's category
+func (e *ContentExtractor) Category() string {
+	return "text"
+}
+
+// Tags returns the tool's tags
+func (e *ContentExtractor) Tags() []string {
+	return []string{"html", "markdown", "scrape", "text"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (e *ContentExtractor) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"html": map[string]interface{}{
+				"type":        "string",
+				"description": "HTML to extract from",
+			},
+			"url": map[string]interface{}{
+				"type":        "string",
+				"description": "HTTP(S) URL on an allow-listed host to fetch instead of html",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"markdown", "text"},
+				"description": "Output format (default markdown)",
+			},
+		},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test; the HTML is inline, so nothing is fetched
+func (e *ContentExtractor) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"html": "<html><head><title>Self-test</title></head><body><p>Hello</p></body></html>"}
+}
+
+// Execute runs the tool with the given arguments
+func (e *ContentExtractor) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	rawHTML, _ := args["html"].(string)
+	rawURL, _ := args["url"].(string)
+	format, _ := args["format"].(string)
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "text" {
+		return nil, fmt.Errorf("invalid format: %s", format)
+	}
+	if (rawHTML == "") == (rawURL == "") {
+		return nil, fmt.Errorf("exactly one of html or url is required")
+	}
+
+	var source io.Reader = strings.NewReader(rawHTML)
+	var base *url.URL
+	if rawURL != "" {
+		body, finalURL, err := e.fetch(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		source, base = body, finalURL
+	} else if int64(len(rawHTML)) > e.maxBytes {
+		return nil, fmt.Errorf("html exceeds %d bytes", e.maxBytes)
+	}
+
+	doc, err := html.Parse(io.LimitReader(source, e.maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+
+	page := &extraction{markdown: format == "markdown", base: base}
+	page.readMetadata(doc)
+	page.walk(contentRoot(doc))
+	content := strings.TrimSpace(blankLines.ReplaceAllString(page.out.String(), "\n\n"))
+
+	result := map[string]interface{}{
+		"format":  format,
+		"title":   page.title,
+		"content": content,
+		"links":   page.links,
+	}
+	if page.description != "" {
+		result["description"] = page.description
+	}
+	if page.lang != "" {
+		result["lang"] = page.lang
+	}
+	if rawURL != "" {
+		result["url"] = base.String()
+	}
+	return result, nil
+}
+
+// fetch starts a GET request to an allow-listed http(s) URL and returns the body and the
+// URL it was served from after redirects.
+func (e *ContentExtractor) fetch(rawURL string) (io.ReadCloser, *url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, nil, fmt.Errorf("unsupported url scheme: %s", u.Scheme)
+	}
+	if !e.hostAllowed(u.Hostname()) {
+		return nil, nil, fmt.Errorf("host not allowed: %s", u.Hostname())
+	}
+
+	resp, err := e.client.Get(u.String())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", rawURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("fetching %s returned status: %d", rawURL, resp.StatusCode)
+	}
+	return resp.Body, resp.Request.URL, nil
+}
+
+// hostAllowed reports whether host is on the URL allow-list.
+func (e *ContentExtractor) hostAllowed(host string) bool {
+	host = strings.ToLower(host)
+	for _, allowed := range e.allowedHosts {
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// contentRoot returns the page's <main> or single <article> when it has one, since
+// that holds the content without the surrounding chrome, or else the whole document.
+func contentRoot(doc *html.Node) *html.Node {
+	var main *html.Node
+	var articles []*html.Node
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Main:
+				if main == nil {
+					main = n
+				}
+			case atom.Article:
+				articles = append(articles, n)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	switch {
+	case main != nil:
+		return main
+	case len(articles) == 1:
+		return articles[0]
+	default:
+		return doc
+	}
+}
+
+// extraction accumulates the output of one page.
+type extraction struct {
+	markdown    bool
+	base        *url.URL
+	out         strings.Builder
+	title       string
+	description string
+	lang        string
+	links       []map[string]string
+	listDepth   int
+	inPre       bool
+}
+
+// readMetadata records the page's title, description, and language.
+func (p *extraction) readMetadata(n *html.Node) {
+	if n.Type == html.ElementNode {
+		switch n.DataAtom {
+		case atom.Html:
+			p.lang = attr(n, "lang")
+		case atom.Title:
+			if p.title == "" {
+				p.title = collapseSpace(textContent(n))
+			}
+		case atom.Meta:
+			if strings.EqualFold(attr(n, "name"), "description") {
+				p.description = strings.TrimSpace(attr(n, "content"))
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.readMetadata(c)
+	}
+}
+
+// walk writes the readable content under n.
+func (p *extraction) walk(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		if p.inPre {
+			p.out.WriteString(n.Data)
+		} else if text := collapseSpace(n.Data); text != "" {
+			if strings.HasPrefix(n.Data, " ") || strings.HasPrefix(n.Data, "\n") {
+				p.space()
+			}
+			p.out.WriteString(text)
+			if strings.HasSuffix(n.Data, " ") || strings.HasSuffix(n.Data, "\n") {
+				p.out.WriteString(" ")
+			}
+		}
+		return
+	case html.ElementNode:
+		if extractSkipped[n.DataAtom] {
+			return
+		}
+	case html.DocumentNode:
+	default:
+		return
+	}
+
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		p.block()
+		if p.markdown {
+			p.out.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		}
+		p.children(n)
+		p.block()
+	case atom.P, atom.Div, atom.Section, atom.Article, atom.Main, atom.Header, atom.Table, atom.Figure:
+		p.block()
+		p.children(n)
+		p.block()
+	case atom.Tr:
+		p.line()
+		p.children(n)
+	case atom.Td, atom.Th:
+		if n.PrevSibling != nil {
+			p.out.WriteString(" | ")
+		}
+		p.children(n)
+	case atom.Br:
+		p.line()
+	case atom.Hr:
+		p.block()
+		if p.markdown {
+			p.out.WriteString("---")
+		}
+		p.block()
+	case atom.Ul, atom.Ol:
+		p.listDepth++
+		p.block()
+		p.children(n)
+		p.listDepth--
+		p.block()
+	case atom.Li:
+		p.line()
+		p.out.WriteString(strings.Repeat("  ", max(p.listDepth-1, 0)))
+		if n.Parent != nil && n.Parent.DataAtom == atom.Ol {
+			p.out.WriteString(strconv.Itoa(listIndex(n)) + ". ")
+		} else {
+			p.out.WriteString("- ")
+		}
+		p.children(n)
+	case atom.Blockquote:
+		p.block()
+		if p.markdown {
+			p.out.WriteString("> ")
+		}
+		p.children(n)
+		p.block()
+	case atom.Pre:
+		p.block()
+		p.inPre = true
+		if p.markdown {
+			p.out.WriteString("```\n")
+		}
+		p.out.WriteString(strings.Trim(textContent(n), "\n"))
+		if p.markdown {
+			p.out.WriteString("\n```")
+		}
+		p.inPre = false
+		p.block()
+	case atom.Code:
+		p.wrap(n, "`")
+	case atom.Strong, atom.B:
+		p.wrap(n, "**")
+	case atom.Em, atom.I:
+		p.wrap(n, "_")
+	case atom.A:
+		p.link(n)
+	case atom.Img:
+		if alt := strings.TrimSpace(attr(n, "alt")); alt != "" {
+			if p.markdown {
+				p.out.WriteString("![" + alt + "](" + p.resolve(attr(n, "src")) + ")")
+			} else {
+				p.out.WriteString(alt)
+			}
+		}
+	default:
+		p.children(n)
+	}
+}
+
+// children walks n's children.
+func (p *extraction) children(n *html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c)
+	}
+}
+
+// wrap writes n's children between Markdown markers.
+func (p *extraction) wrap(n *html.Node, marker string) {
+	if !p.markdown {
+		p.children(n)
+		return
+	}
+	text := collapseSpace(textContent(n))
+	if text == "" {
+		return
+	}
+	p.out.WriteString(marker + text + marker)
+}
+
+// link writes a link and records it.
+func (p *extraction) link(n *html.Node) {
+	text := collapseSpace(textContent(n))
+	href := attr(n, "href")
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+		p.children(n)
+		return
+	}
+	href = p.resolve(href)
+	if len(p.links) < maxExtractLinks {
+		p.links = append(p.links, map[string]string{"text": text, "href": href})
+	}
+	if p.markdown && text != "" {
+		p.out.WriteString("[" + text + "](" + href + ")")
+	} else {
+		p.out.WriteString(text)
+	}
+}
+
+// resolve makes ref absolute against the page URL, when the page was fetched.
+func (p *extraction) resolve(ref string) string {
+	if p.base == nil {
+		return ref
+	}
+	u, err := p.base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+// block starts a new paragraph.
+func (p *extraction) block() {
+	p.out.WriteString("\n\n")
+}
+
+// line starts a new line.
+func (p *extraction) line() {
+	p.out.WriteString("\n")
+}
+
+// space separates inline text from what precedes it.
+func (p *extraction) space() {
+	s := p.out.String()
+	if s != "" && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+		p.out.WriteString(" ")
+	}
+}
+
+// listIndex returns the 1-based position of a list item among its siblings.
+func listIndex(n *html.Node) int {
+	index := 1
+	for c := n.PrevSibling; c != nil; c = c.PrevSibling {
+		if c.Type == html.ElementNode && c.DataAtom == atom.Li {
+			index++
+		}
+	}
+	return index
+}
+
+// attr returns the named attribute of n.
+func attr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// textContent returns the text under n, skipping scripts and styles.
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var collect func(*html.Node)
+	collect = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Script || n.DataAtom == atom.Style) {
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			collect(c)
+		}
+	}
+	collect(n)
+	return b.String()
+}
+
+// collapseSpace replaces runs of whitespace with single spaces and trims the ends.
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+const extractTestPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>Release notes</title>
+  <meta name="description" content="What changed in 2.0">
+  <style>body { color: red }</style>
+</head>
+<body>
+  <nav><a href="/">Home</a> <a href="/docs">Docs</a></nav>
+  <main>
+    <h1>Release notes</h1>
+    <p>Version 2.0 adds <a href="/plugins">plugins</a> and <strong>faster</strong> startup.</p>
+    <script>track("view")</script>
+    <ul><li>First change</li><li>Second change</li></ul>
+    <pre>make build
+make test</pre>
+  </main>
+  <footer>Copyright</footer>
+</body>
+</html>`
+
+func TestContentExtractor(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("converts inline html to markdown", func(t *testing.T) {
+		extractor := NewContentExtractor(logger, nil, 0)
+		result, err := extractor.Execute(map[string]interface{}{"html": extractTestPage})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["title"] != "Release notes" || result["description"] != "What changed in 2.0" || result["lang"] != "en" {
+			t.Errorf("Unexpected metadata: %v", result)
+		}
+		want := "# Release notes\n\nVersion 2.0 adds [plugins](/plugins) and **faster** startup.\n\n- First change\n- Second change\n\n```\nmake build\nmake test\n```"
+		if result["content"] != want {
+			t.Errorf("Unexpected content:\n%s\nwant:\n%s", result["content"], want)
+		}
+		links := result["links"].([]map[string]string)
+		if len(links) != 1 || links[0]["href"] != "/plugins" {
+			t.Errorf("Expected only the main content's link, got %v", links)
+		}
+	})
+
+	t.Run("converts inline html to text", func(t *testing.T) {
+		extractor := NewContentExtractor(logger, nil, 0)
+		result, err := extractor.Execute(map[string]interface{}{"html": extractTestPage, "format": "text"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		content := result["content"].(string)
+		if !strings.Contains(content, "Version 2.0 adds plugins and faster startup.") {
+			t.Errorf("Unexpected text content: %q", content)
+		}
+		for _, noise := range []string{"track(", "color: red", "Copyright", "Docs", "**", "#"} {
+			if strings.Contains(content, noise) {
+				t.Errorf("Expected %q to be removed from %q", noise, content)
+			}
+		}
+	})
+
+	t.Run("fetches allow-listed urls and resolves links", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(extractTestPage))
+		}))
+		defer server.Close()
+		u, _ := url.Parse(server.URL)
+
+		extractor, err := NewContentExtractorFromConfig(logger, map[string]string{"EXTRACT_ALLOWED_HOSTS": u.Hostname()})
+		if err != nil {
+			t.Fatalf("NewContentExtractorFromConfig failed: %v", err)
+		}
+		result, err := extractor.Execute(map[string]interface{}{"url": server.URL + "/releases"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		links := result["links"].([]map[string]string)
+		if len(links) != 1 || links[0]["href"] != server.URL+"/plugins" {
+			t.Errorf("Expected a resolved link, got %v", links)
+		}
+	})
+
+	t.Run("rejects disallowed sources", func(t *testing.T) {
+		extractor := NewContentExtractor(logger, []string{"example.com"}, 16)
+		for _, args := range []map[string]interface{}{
+			{"url": "https://evil.example.org/"},
+			{"url": "file:///etc/passwd"},
+			{"html": extractTestPage},
+			{"html": "<p>hi</p>", "url": "https://example.com/"},
+			{},
+			{"html": "<p>hi</p>", "format": "pdf"},
+		} {
+			if _, err := extractor.Execute(args); err == nil {
+				t.Errorf("Expected %v to be refused", args)
+			}
+		}
+	})
+}
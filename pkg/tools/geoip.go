@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// GeoIPRecord holds the location and network ownership data for an IP address.
+type GeoIPRecord struct {
+	CountryCode    string
+	CountryName    string
+	ASN            uint
+	ASOrganization string
+}
+
+// GeoIPDatabase looks up GeoIP records. The default implementation reads MaxMind-format
+// (.mmdb) files; other providers can be plugged in through NewGeoIP.
+type GeoIPDatabase interface {
+	// Lookup returns the record for ip and whether the database had any data for it.
+	Lookup(ip net.IP) (GeoIPRecord, bool, error)
+	Close() error
+}
+
+// maxMindDatabase is a GeoIPDatabase backed by a country (or city) database and an
+// optional ASN database in MaxMind format.
+type maxMindDatabase struct {
+	country *maxminddb.Reader
+	asn     *maxminddb.Reader
+}
+
+// OpenMaxMindDatabase opens MaxMind-format databases. Either path may be empty, but not both.
+func OpenMaxMindDatabase(countryPath, asnPath string) (GeoIPDatabase, error) {
+	if countryPath == "" && asnPath == "" {
+		return nil, fmt.Errorf("no GeoIP database path configured")
+	}
+
+	db := &maxMindDatabase{}
+	if countryPath != "" {
+		reader, err := maxminddb.Open(countryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP database %s: %w", countryPath, err)
+		}
+		db.country = reader
+	}
+	if asnPath != "" {
+		reader, err := maxminddb.Open(asnPath)
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to open GeoIP ASN database %s: %w", asnPath, err)
+		}
+		db.asn = reader
+	}
+	return db, nil
+}
+
+// Lookup implements GeoIPDatabase.
+func (db *maxMindDatabase) Lookup(ip net.IP) (GeoIPRecord, bool, error) {
+	var record GeoIPRecord
+	found := false
+
+	if db.country != nil {
+		var country struct {
+			Country struct {
+				ISOCode string            `maxminddb:"iso_code"`
+				Names   map[string]string `maxminddb:"names"`
+			} `maxminddb:"country"`
+		}
+		_, ok, err := db.country.LookupNetwork(ip, &country)
+		if err != nil {
+			return GeoIPRecord{}, false, fmt.Errorf("country lookup failed: %w", err)
+		}
+		if ok {
+			found = true
+			record.CountryCode = country.Country.ISOCode
+			record.CountryName = country.Country.Names["en"]
+		}
+	}
+
+	if db.asn != nil {
+		var asn struct {
+			Number       uint   `maxminddb:"autonomous_system_number"`
+			Organization string `maxminddb:"autonomous_system_organization"`
+		}
+		_, ok, err := db.asn.LookupNetwork(ip, &asn)
+		if err != nil {
+			return GeoIPRecord{}, false, fmt.Errorf("ASN lookup failed: %w", err)
+		}
+		if ok {
+			found = true
+			record.ASN = asn.Number
+			record.ASOrganization = asn.Organization
+		}
+	}
+
+	return record, found, nil
+}
+
+// Close implements GeoIPDatabase.
+func (db *maxMindDatabase) Close() error {
+	var errs []error
+	if db.country != nil {
+		errs = append(errs, db.country.Close())
+	}
+	if db.asn != nil {
+		errs = append(errs, db.asn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// GeoIP looks up country and ASN data for IP addresses and implements Tool
+type GeoIP struct {
+	logger *slog.Logger
+	db     GeoIPDatabase
+}
+
+// NewGeoIP creates a new GeoIP tool backed by the given database
+func NewGeoIP(logger *slog.Logger, db GeoIPDatabase) *GeoIP {
+	return &GeoIP{
+		logger: logger,
+		db:     db,
+	}
+}
+
+// NewGeoIPFromConfig creates a GeoIP tool from GEOIP_DB_PATH and GEOIP_ASN_DB_PATH. It
+// fails when neither database is configured, so the registry skips the tool.
+func NewGeoIPFromConfig(logger *slog.Logger, config map[string]string) (*GeoIP, error) {
+	db, err := OpenMaxMindDatabase(config["GEOIP_DB_PATH"], config["GEOIP_ASN_DB_PATH"])
+	if err != nil {
+		return nil, err
+	}
+	return NewGeoIP(logger, db), nil
+}
+
+// Name returns the tool's name
+func (g *GeoIP) Name() string {
+	return "geoip"
+}
+
+// Description returns the tool's description
+func (g *GeoIP) Description() string {
+	return "Looks up the country and autonomous system (ASN) for an IP address"
+}
+
+// Category returns the tool's category
+func (g *GeoIP) Category() string {
+	return "network"
+}
+
+// Tags returns the tool's tags
+func (g *GeoIP) Tags() []string {
+	return []string{"ip", "geolocation", "asn"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (g *GeoIP) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"ip": map[string]interface{}{
+				"type":        "string",
+				"description": "IPv4 or IPv6 address to look up",
+			},
+		},
+		"required": []string{"ip"},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test; lookups use the local database
+func (g *GeoIP) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"ip": "8.8.8.8"}
+}
+
+// Execute runs the tool with the given arguments
+func (g *GeoIP) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	rawIP, _ := args["ip"].(string)
+	if rawIP == "" {
+		return nil, fmt.Errorf("missing required argument: ip")
+	}
+	ip := net.ParseIP(rawIP)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address: %s", rawIP)
+	}
+
+	record, found, err := g.db.Lookup(ip)
+	if err != nil {
+		g.logger.Error("GeoIP lookup failed", "ip", rawIP, "error", err)
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"ip":    ip.String(),
+		"found": found,
+	}
+	if record.CountryCode != "" {
+		result["country_code"] = record.CountryCode
+		result["country_name"] = record.CountryName
+	}
+	if record.ASN != 0 {
+		result["asn"] = record.ASN
+		result["as_organization"] = record.ASOrganization
+	}
+	return result, nil
+}
@@ -0,0 +1,102 @@
+package tools
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeGeoIPDatabase is an in-memory GeoIPDatabase keyed by IP string.
+type fakeGeoIPDatabase struct {
+	records map[string]GeoIPRecord
+	err     error
+}
+
+func (f *fakeGeoIPDatabase) Lookup(ip net.IP) (GeoIPRecord, bool, error) {
+	if f.err != nil {
+		return GeoIPRecord{}, false, f.err
+	}
+	record, ok := f.records[ip.String()]
+	return record, ok, nil
+}
+
+func (f *fakeGeoIPDatabase) Close() error { return nil }
+
+func TestGeoIP_Execute(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	db := &fakeGeoIPDatabase{records: map[string]GeoIPRecord{
+		"8.8.8.8": {CountryCode: "US", CountryName: "United States", ASN: 15169, ASOrganization: "GOOGLE"},
+	}}
+	tool := NewGeoIP(logger, db)
+
+	t.Run("known address", func(t *testing.T) {
+		result, err := tool.Execute(map[string]interface{}{"ip": "8.8.8.8"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["found"] != true || result["country_code"] != "US" || result["asn"] != uint(15169) {
+			t.Errorf("Unexpected result: %v", result)
+		}
+	})
+
+	t.Run("unknown address", func(t *testing.T) {
+		result, err := tool.Execute(map[string]interface{}{"ip": "2001:db8::1"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["found"] != false {
+			t.Errorf("Expected not found, got %v", result)
+		}
+		if _, ok := result["country_code"]; ok {
+			t.Error("Expected no country data for unknown address")
+		}
+	})
+
+	t.Run("invalid address", func(t *testing.T) {
+		if _, err := tool.Execute(map[string]interface{}{"ip": "not-an-ip"}); err == nil {
+			t.Error("Expected error for invalid IP")
+		}
+		if _, err := tool.Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected error for missing IP")
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		failing := NewGeoIP(logger, &fakeGeoIPDatabase{err: fmt.Errorf("corrupt database")})
+		if _, err := failing.Execute(map[string]interface{}{"ip": "8.8.8.8"}); err == nil {
+			t.Error("Expected database error to be returned")
+		}
+	})
+}
+
+func TestNewGeoIPFromConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	if _, err := NewGeoIPFromConfig(logger, map[string]string{}); err == nil {
+		t.Error("Expected error when no database is configured")
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.mmdb")
+	if _, err := NewGeoIPFromConfig(logger, map[string]string{"GEOIP_DB_PATH": missing}); err == nil {
+		t.Error("Expected error for missing database file")
+	}
+}
+
+func TestToolRegistry_SkipsGeoIPWithoutDatabase(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	_ = os.Unsetenv("GEOIP_DB_PATH")
+	_ = os.Unsetenv("GEOIP_ASN_DB_PATH")
+
+	created, err := NewToolRegistry().CreateAllAvailable(logger)
+	if err != nil {
+		t.Fatalf("CreateAllAvailable failed: %v", err)
+	}
+	for _, tool := range created {
+		if tool.Name() == "geoip" {
+			t.Error("Expected geoip to be skipped without a configured database")
+		}
+	}
+}
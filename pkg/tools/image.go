@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	"golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+// Default image_ops limits.
+const (
+	defaultImageMaxBytes  int64 = 10 << 20 // 10 MiB
+	defaultImageMaxPixels       = 25_000_000
+)
+
+// imageFormats lists the formats image_ops can write.
+var imageFormats = []string{"png", "jpeg", "gif", "bmp", "tiff"}
+
+// ImageOps reports image metadata and resizes or converts images, and implements Tool.
+// Images are passed and returned as base64.
+type ImageOps struct {
+	logger    *slog.Logger
+	maxBytes  int64
+	maxPixels int
+}
+
+// NewImageOps creates an image tool. Images larger than maxBytes, or with more than
+// maxPixels pixels before or after resizing, are refused so one call cannot exhaust the
+// server's memory.
+func NewImageOps(logger *slog.Logger, maxBytes int64, maxPixels int) *ImageOps {
+	if maxBytes <= 0 {
+		maxBytes = defaultImageMaxBytes
+	}
+	if maxPixels <= 0 {
+		maxPixels = defaultImageMaxPixels
+	}
+	return &ImageOps{logger: logger, maxBytes: maxBytes, maxPixels: maxPixels}
+}
+
+// NewImageOpsFromConfig creates an image tool from IMAGE_MAX_BYTES and IMAGE_MAX_PIXELS.
+func NewImageOpsFromConfig(logger *slog.Logger, config map[string]string) (*ImageOps, error) {
+	var maxBytes int64
+	if raw := config["IMAGE_MAX_BYTES"]; raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IMAGE_MAX_BYTES: %w", err)
+		}
+		maxBytes = parsed
+	}
+	var maxPixels int
+	if raw := config["IMAGE_MAX_PIXELS"]; raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IMAGE_MAX_PIXELS: %w", err)
+		}
+		maxPixels = parsed
+	}
+	return NewImageOps(logger, maxBytes, maxPixels), nil
+}
+
+// Name returns the tool's name
+func (o *ImageOps) Name() string {
+	return "image_ops"
+}
+
+// Description returns the tool's description
+func (o *ImageOps) Description() string {
+	return "Reports an image's format, dimensions, and EXIF metadata, or resizes or converts it"
+}
+
+// Category returns the tool's category
+func (o *ImageOps) Category() string {
+	return "media"
+}
+
+// Tags returns the tool's tags
+func (o *ImageOps) Tags() []string {
+	return []string{"image", "exif", "resize", "convert"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (o *ImageOps) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"image": map[string]interface{}{
+				"type":        "string",
+				"description": "Base64-encoded image, optionally as a data URL (png, jpeg, gif, bmp, tiff, or webp)",
+			},
+			"operation": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"info", "resize", "convert"},
+				"description": "info reports metadata, resize scales the image, convert changes its format (default info)",
+			},
+			"width": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Target width for resize; the aspect ratio is kept when height is omitted",
+			},
+			"height": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "Target height for resize; the aspect ratio is kept when width is omitted",
+			},
+			"format": map[string]interface{}{
+				"type":        "string",
+				"enum":        imageFormats,
+				"description": "Output format (default: the input's format, or png for webp)",
+			},
+			"quality": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"maximum":     100,
+				"description": "JPEG quality (default 85)",
+			},
+		},
+		"required": []string{"image"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (o *ImageOps) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	encoded, _ := args["image"].(string)
+	operation, _ := args["operation"].(string)
+	format, _ := args["format"].(string)
+	if encoded == "" {
+		return nil, fmt.Errorf("missing required argument: image")
+	}
+	if operation == "" {
+		operation = "info"
+	}
+
+	// Strip a data URL prefix such as "data:image/png;base64,".
+	if strings.HasPrefix(encoded, "data:") {
+		if comma := strings.IndexByte(encoded, ','); comma >= 0 {
+			encoded = encoded[comma+1:]
+		}
+	}
+	if int64(base64.StdEncoding.DecodedLen(len(encoded))) > o.maxBytes+2 {
+		return nil, fmt.Errorf("image exceeds %d bytes", o.maxBytes)
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("image is not valid base64: %w", err)
+	}
+	if int64(len(data)) > o.maxBytes {
+		return nil, fmt.Errorf("image exceeds %d bytes", o.maxBytes)
+	}
+
+	// Decoding only the header lets oversized images be refused before their pixels are
+	// allocated.
+	config, sourceFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported or invalid image: %w", err)
+	}
+	if pixels := int64(config.Width) * int64(config.Height); pixels > int64(o.maxPixels) {
+		return nil, fmt.Errorf("image has %d pixels, more than the limit of %d", pixels, o.maxPixels)
+	}
+
+	switch operation {
+	case "info":
+		result := map[string]interface{}{
+			"format": sourceFormat,
+			"width":  config.Width,
+			"height": config.Height,
+			"bytes":  len(data),
+		}
+		if exif := readExif(data); exif != nil {
+			result["exif"] = exif
+		}
+		return result, nil
+	case "resize", "convert":
+	default:
+		return nil, fmt.Errorf("invalid operation: %s", operation)
+	}
+
+	if format == "" {
+		if operation == "convert" {
+			return nil, fmt.Errorf("missing required argument: format")
+		}
+		format = sourceFormat
+		if format == "webp" {
+			format = "png"
+		}
+	}
+	if format == "jpg" {
+		format = "jpeg"
+	}
+	validFormat := false
+	for _, f := range imageFormats {
+		validFormat = validFormat || f == format
+	}
+	if !validFormat {
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	var width, height int
+	if operation == "resize" {
+		if width, height, err = o.targetSize(args, config); err != nil {
+			return nil, err
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	if operation == "resize" {
+		scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+		img = scaled
+	}
+
+	var out bytes.Buffer
+	if err := encodeImage(&out, img, format, args); err != nil {
+		return nil, err
+	}
+	bounds := img.Bounds()
+	return map[string]interface{}{
+		"format": format,
+		"width":  bounds.Dx(),
+		"height": bounds.Dy(),
+		"bytes":  out.Len(),
+		"image":  base64.StdEncoding.EncodeToString(out.Bytes()),
+	}, nil
+}
+
+// targetSize returns the resize dimensions, filling in a missing one from the source's
+// aspect ratio.
+func (o *ImageOps) targetSize(args map[string]interface{}, source image.Config) (int, int, error) {
+	width := intArg(args, "width", 0)
+	height := intArg(args, "height", 0)
+	if width < 0 || height < 0 {
+		return 0, 0, fmt.Errorf("width and height must be positive")
+	}
+	switch {
+	case width == 0 && height == 0:
+		return 0, 0, fmt.Errorf("resize requires width or height")
+	case height == 0:
+		height = max(1, int(float64(source.Height)*float64(width)/float64(source.Width)+0.5))
+	case width == 0:
+		width = max(1, int(float64(source.Width)*float64(height)/float64(source.Height)+0.5))
+	}
+	if int64(width)*int64(height) > int64(o.maxPixels) {
+		return 0, 0, fmt.Errorf("resized image would have %d pixels, more than the limit of %d", int64(width)*int64(height), o.maxPixels)
+	}
+	return width, height, nil
+}
+
+// encodeImage writes img in the given format. JPEG has no transparency, so transparent
+// areas are flattened onto white.
+func encodeImage(out *bytes.Buffer, img image.Image, format string, args map[string]interface{}) error {
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(out, img)
+	case "jpeg":
+		quality := intArg(args, "quality", 85)
+		if quality < 1 || quality > 100 {
+			return fmt.Errorf("quality must be between 1 and 100")
+		}
+		flat := image.NewRGBA(img.Bounds())
+		draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Bounds(), img, img.Bounds().Min, draw.Over)
+		err = jpeg.Encode(out, flat, &jpeg.Options{Quality: quality})
+	case "gif":
+		err = gif.Encode(out, img, nil)
+	case "bmp":
+		err = bmp.Encode(out, img)
+	case "tiff":
+		err = tiff.Encode(out, img, &tiff.Options{Compression: tiff.Deflate})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", format, err)
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+// testImage returns a width by height image encoded with encode.
+func testImage(t *testing.T, width, height int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode test image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// exifSegment returns a JPEG APP1 segment holding a little-endian TIFF header with Make,
+// Orientation, and a GPS latitude of 51 degrees 30 minutes south.
+func exifSegment() []byte {
+	var tiff bytes.Buffer
+	le := binary.LittleEndian
+	tiff.WriteString("II*\x00")
+	_ = binary.Write(&tiff, le, uint32(8))
+
+	// IFD0 at 8: three entries, then the next-IFD offset.
+	const ifd0Size = 2 + 3*12 + 4
+	makeAt := uint32(8 + ifd0Size)
+	gpsAt := makeAt + 8
+	_ = binary.Write(&tiff, le, uint16(3))
+	_ = binary.Write(&tiff, le, []uint16{0x010F, 2})
+	_ = binary.Write(&tiff, le, []uint32{5, makeAt})
+	_ = binary.Write(&tiff, le, []uint16{0x0112, 3})
+	_ = binary.Write(&tiff, le, []uint32{1, 6})
+	_ = binary.Write(&tiff, le, []uint16{gpsIFDPointer, 4})
+	_ = binary.Write(&tiff, le, []uint32{1, gpsAt})
+	_ = binary.Write(&tiff, le, uint32(0))
+	tiff.WriteString("Acme\x00\x00\x00\x00")
+
+	// GPS IFD: latitude reference and latitude.
+	latAt := gpsAt + 2 + 2*12 + 4
+	_ = binary.Write(&tiff, le, uint16(2))
+	_ = binary.Write(&tiff, le, []uint16{1, 2})
+	_ = binary.Write(&tiff, le, []uint32{2, 'S'})
+	_ = binary.Write(&tiff, le, []uint16{2, 5})
+	_ = binary.Write(&tiff, le, []uint32{3, latAt})
+	_ = binary.Write(&tiff, le, uint32(0))
+	_ = binary.Write(&tiff, le, []uint32{51, 1, 30, 1, 0, 1})
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func TestImageOps(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	encodePNG := func(buf *bytes.Buffer, img image.Image) error { return png.Encode(buf, img) }
+	encodeJPEG := func(buf *bytes.Buffer, img image.Image) error { return jpeg.Encode(buf, img, nil) }
+	pngData := testImage(t, 40, 20, encodePNG)
+
+	t.Run("reports dimensions", func(t *testing.T) {
+		ops := NewImageOps(logger, 0, 0)
+		result, err := ops.Execute(map[string]interface{}{"image": "data:image/png;base64," + base64.StdEncoding.EncodeToString(pngData)})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["format"] != "png" || result["width"] != 40 || result["height"] != 20 || result["bytes"] != len(pngData) {
+			t.Errorf("Unexpected info: %v", result)
+		}
+		if _, ok := result["exif"]; ok {
+			t.Errorf("Expected no EXIF for a PNG, got %v", result["exif"])
+		}
+	})
+
+	t.Run("reports exif", func(t *testing.T) {
+		jpegData := testImage(t, 8, 8, encodeJPEG)
+		withExif := append(append([]byte{0xFF, 0xD8}, exifSegment()...), jpegData[2:]...)
+		ops := NewImageOps(logger, 0, 0)
+		result, err := ops.Execute(map[string]interface{}{"image": base64.StdEncoding.EncodeToString(withExif)})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		exif, _ := result["exif"].(map[string]interface{})
+		if exif["Make"] != "Acme" || exif["Orientation"] != int64(6) || exif["GPSLatitude"] != -51.5 {
+			t.Errorf("Unexpected EXIF: %v", exif)
+		}
+	})
+
+	t.Run("resizes keeping the aspect ratio", func(t *testing.T) {
+		ops := NewImageOps(logger, 0, 0)
+		result, err := ops.Execute(map[string]interface{}{
+			"image":     base64.StdEncoding.EncodeToString(pngData),
+			"operation": "resize",
+			"width":     float64(10),
+			"format":    "jpeg",
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["width"] != 10 || result["height"] != 5 || result["format"] != "jpeg" {
+			t.Errorf("Unexpected result: %v", result)
+		}
+		data, _ := base64.StdEncoding.DecodeString(result["image"].(string))
+		config, format, err := image.DecodeConfig(bytes.NewReader(data))
+		if err != nil || format != "jpeg" || config.Width != 10 || config.Height != 5 {
+			t.Errorf("Output is not a 10x5 JPEG: %v %s %v", config, format, err)
+		}
+	})
+
+	t.Run("converts formats", func(t *testing.T) {
+		ops := NewImageOps(logger, 0, 0)
+		for _, format := range []string{"gif", "bmp", "tiff"} {
+			result, err := ops.Execute(map[string]interface{}{
+				"image":     base64.StdEncoding.EncodeToString(pngData),
+				"operation": "convert",
+				"format":    format,
+			})
+			if err != nil {
+				t.Fatalf("Converting to %s failed: %v", format, err)
+			}
+			data, _ := base64.StdEncoding.DecodeString(result["image"].(string))
+			if _, decoded, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || decoded != format {
+				t.Errorf("Expected %s output, got %s: %v", format, decoded, err)
+			}
+		}
+	})
+
+	t.Run("enforces limits", func(t *testing.T) {
+		encoded := base64.StdEncoding.EncodeToString(pngData)
+		if _, err := NewImageOps(logger, 16, 0).Execute(map[string]interface{}{"image": encoded}); err == nil {
+			t.Error("Expected an oversized image to be refused")
+		}
+		if _, err := NewImageOps(logger, 0, 100).Execute(map[string]interface{}{"image": encoded}); err == nil {
+			t.Error("Expected an image with too many pixels to be refused")
+		}
+		if _, err := NewImageOps(logger, 0, 1000).Execute(map[string]interface{}{"image": encoded, "operation": "resize", "width": float64(400)}); err == nil {
+			t.Error("Expected a resize past the pixel limit to be refused")
+		}
+		if _, err := NewImageOps(logger, 0, 0).Execute(map[string]interface{}{"image": "bm90IGFuIGltYWdl"}); err == nil {
+			t.Error("Expected invalid image data to be refused")
+		}
+		if _, err := NewImageOps(logger, 0, 0).Execute(map[string]interface{}{"image": encoded, "operation": "convert", "format": "webp"}); err == nil {
+			t.Error("Expected an unsupported output format to be refused")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is one step of a parsed JSONPath: an object key, an array index, or a
+// wildcard over every array element.
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// parseJSONPath parses the JSONPath subset used to extract values from responses:
+// $ followed by .key, ['key'], [n] (negative n counts from the end), and [*].
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(path), "$")
+	if !ok {
+		return nil, fmt.Errorf("invalid JSONPath %q: must start with $", path)
+	}
+	var steps []jsonPathStep
+	for rest != "" {
+		switch {
+		case rest[0] == '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSONPath %q: empty key", path)
+			}
+			steps = append(steps, jsonPathStep{key: key})
+			rest = rest[end+1:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSONPath %q: unclosed [", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				steps = append(steps, jsonPathStep{wildcard: true})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+			default:
+				index, err := strconv.Atoi(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid JSONPath %q: bad index %q", path, inner)
+				}
+				steps = append(steps, jsonPathStep{index: index, isIndex: true})
+			}
+		default:
+			return nil, fmt.Errorf("invalid JSONPath %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// evalJSONPath returns the value at steps in a decoded JSON document. A wildcard yields
+// an array of the values found under each element.
+func evalJSONPath(steps []jsonPathStep, value interface{}) (interface{}, error) {
+	for i, step := range steps {
+		switch {
+		case step.wildcard:
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[*] applied to a non-array")
+			}
+			results := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				result, err := evalJSONPath(steps[i+1:], item)
+				if err != nil {
+					return nil, err
+				}
+				results = append(results, result)
+			}
+			return results, nil
+		case step.isIndex:
+			items, ok := value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("[%d] applied to a non-array", step.index)
+			}
+			index := step.index
+			if index < 0 {
+				index += len(items)
+			}
+			if index < 0 || index >= len(items) {
+				return nil, fmt.Errorf("index %d out of range", step.index)
+			}
+			value = items[index]
+		default:
+			object, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("key %q applied to a non-object", step.key)
+			}
+			if value, ok = object[step.key]; !ok {
+				return nil, fmt.Errorf("key %q not found", step.key)
+			}
+		}
+	}
+	return value, nil
+}
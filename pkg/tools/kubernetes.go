@@ -0,0 +1,335 @@
+package tools
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v2"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// serviceAccountDir holds the credentials Kubernetes mounts into every pod.
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// kubeRequestTimeout bounds each call to the Kubernetes API.
+const kubeRequestTimeout = 30 * time.Second
+
+// KubeClient makes read-only requests to the Kubernetes API. It speaks the REST API
+// directly, so the server does not depend on client-go. The price is that only static
+// credentials are understood: bearer tokens, token files, and client certificates.
+// Kubeconfigs relying on anything else, such as exec credential plugins, auth providers
+// like OIDC, basic auth, or impersonation, are rejected when loaded rather than sending
+// unauthenticated requests.
+type KubeClient struct {
+	server    string
+	token     string
+	tokenFile string // Re-read on every request, since projected tokens rotate
+	namespace string // Namespace used when a tool call names none
+	client    *http.Client
+}
+
+// kubeconfig is the subset of a kubeconfig file needed to reach a cluster.
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// kubeconfigUserFields are the user fields LoadKubeconfig understands. Any other field
+// changes how requests must authenticate, so a user setting one is rejected.
+var kubeconfigUserFields = map[string]bool{
+	"token":                   true,
+	"tokenFile":               true,
+	"client-certificate":      true,
+	"client-certificate-data": true,
+	"client-key":              true,
+	"client-key-data":         true,
+	"extensions":              true,
+}
+
+// unsupportedUserField returns the first field of a kubeconfig user that
+// kubeconfigUserFields does not list, in sorted order, or "" when there is none.
+func unsupportedUserField(user map[string]interface{}) string {
+	var unsupported []string
+	for field := range user {
+		if !kubeconfigUserFields[field] {
+			unsupported = append(unsupported, field)
+		}
+	}
+	if len(unsupported) == 0 {
+		return ""
+	}
+	sort.Strings(unsupported)
+	return unsupported[0]
+}
+
+// LoadKubeconfig creates a client for the current context of the kubeconfig file at
+// path. Tokens and client certificates are supported. A context whose user is missing or
+// authenticates any other way, such as with an exec credential plugin or an auth-provider,
+// is an error.
+func LoadKubeconfig(path string) (*KubeClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	var rawUsers struct {
+		Users []struct {
+			Name string                 `yaml:"name"`
+			User map[string]interface{} `yaml:"user"`
+		} `yaml:"users"`
+	}
+	if err := yaml.Unmarshal(data, &rawUsers); err != nil {
+		return nil, fmt.Errorf("invalid kubeconfig: %w", err)
+	}
+	// Relative paths in a kubeconfig are relative to the file itself.
+	resolve := func(file string) string {
+		if file == "" || filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(filepath.Dir(path), file)
+	}
+
+	var clusterName, userName, namespace string
+	found := false
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext {
+			clusterName, userName, namespace = c.Context.Cluster, c.Context.User, c.Context.Namespace
+			found = true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("kubeconfig context %q not found", config.CurrentContext)
+	}
+
+	client := &KubeClient{namespace: namespace}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	found = false
+	for _, c := range config.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		client.server = c.Cluster.Server
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		ca, err := kubeconfigData(c.Cluster.CertificateAuthorityData, resolve(c.Cluster.CertificateAuthority))
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", clusterName, err)
+		}
+		if ca != nil {
+			if tlsConfig.RootCAs, err = certPool(ca); err != nil {
+				return nil, fmt.Errorf("cluster %s: %w", clusterName, err)
+			}
+		}
+	}
+	if !found || client.server == "" {
+		return nil, fmt.Errorf("kubeconfig cluster %q not found", clusterName)
+	}
+
+	if userName != "" {
+		found = false
+		for _, u := range rawUsers.Users {
+			if u.Name != userName {
+				continue
+			}
+			found = true
+			switch field := unsupportedUserField(u.User); field {
+			case "":
+			case "exec":
+				return nil, fmt.Errorf("user %s: exec credential plugins are not supported; use a token or client certificate", userName)
+			case "auth-provider":
+				return nil, fmt.Errorf("user %s: auth providers such as OIDC are not supported; use a token or client certificate", userName)
+			default:
+				return nil, fmt.Errorf("user %s: %s is not supported; use a token or client certificate", userName, field)
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("kubeconfig user %q not found", userName)
+		}
+	}
+	for _, u := range config.Users {
+		if u.Name != userName {
+			continue
+		}
+		client.token, client.tokenFile = u.User.Token, resolve(u.User.TokenFile)
+		cert, err := kubeconfigData(u.User.ClientCertificateData, resolve(u.User.ClientCertificate))
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", userName, err)
+		}
+		key, err := kubeconfigData(u.User.ClientKeyData, resolve(u.User.ClientKey))
+		if err != nil {
+			return nil, fmt.Errorf("user %s: %w", userName, err)
+		}
+		if cert != nil || key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("user %s: invalid client certificate: %w", userName, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	client.client = httpclient.New("kubernetes", httpclient.WithTimeout(kubeRequestTimeout), httpclient.WithTLSConfig(tlsConfig))
+	return client, nil
+}
+
+// LoadInClusterConfig creates a client from the service account Kubernetes mounts into
+// the pod, using host and port from KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT.
+func LoadInClusterConfig(host, port string) (*KubeClient, error) {
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster")
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account CA: %w", err)
+	}
+	pool, err := certPool(ca)
+	if err != nil {
+		return nil, err
+	}
+	namespace, _ := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+	return &KubeClient{
+		server:    "https://" + strings.TrimSuffix(strings.TrimPrefix(host, "["), "]") + ":" + port,
+		tokenFile: filepath.Join(serviceAccountDir, "token"),
+		namespace: strings.TrimSpace(string(namespace)),
+		client: httpclient.New("kubernetes",
+			httpclient.WithTimeout(kubeRequestTimeout),
+			httpclient.WithTLSConfig(&tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}),
+			// The API server is reached on the cluster network, never through a proxy
+			httpclient.WithoutProxy(),
+		),
+	}, nil
+}
+
+// kubeconfigData returns inline base64 data, or else the contents of file, or nil when
+// neither is set.
+func kubeconfigData(data, file string) ([]byte, error) {
+	if data != "" {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 data: %w", err)
+		}
+		return decoded, nil
+	}
+	if file == "" {
+		return nil, nil
+	}
+	return os.ReadFile(file)
+}
+
+// certPool returns a pool holding the PEM certificates in ca.
+func certPool(ca []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("no valid certificates in certificate authority")
+	}
+	return pool, nil
+}
+
+// Namespace returns the namespace used when a tool call names none.
+func (c *KubeClient) Namespace() string {
+	if c.namespace == "" {
+		return "default"
+	}
+	return c.namespace
+}
+
+// get fetches path from the API server and returns the response body, at most maxBytes
+// long. API errors are returned with the server's message.
+func (c *KubeClient) get(ctx context.Context, path string, query url.Values, maxBytes int64) ([]byte, error) {
+	target := strings.TrimSuffix(c.server, "/") + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	token := c.token
+	if c.tokenFile != "" {
+		data, err := os.ReadFile(c.tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("kubernetes API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubernetes API response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var status struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &status) == nil && status.Message != "" {
+			return nil, fmt.Errorf("kubernetes API returned %d: %s", resp.StatusCode, status.Message)
+		}
+		return nil, fmt.Errorf("kubernetes API returned %d", resp.StatusCode)
+	}
+	return body, nil
+}
+
+// getJSON fetches path and decodes the JSON response into out.
+func (c *KubeClient) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	body, err := c.get(ctx, path, query, 32<<20)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid kubernetes API response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package tools
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeKubeAPI serves canned Kubernetes API responses, requiring the bearer token "t0k3n".
+func fakeKubeAPI(t *testing.T) *httptest.Server {
+	responses := map[string]string{
+		"/api/v1/namespaces/apps/pods": `{"items":[{"metadata":{"name":"web-1"},"spec":{"nodeName":"node-a"},
+			"status":{"phase":"Running","containerStatuses":[{"ready":true,"restartCount":2},{"ready":false,"restartCount":1}]}}]}`,
+		"/api/v1/namespaces/apps/pods/web-1/log": "line 1\nline 2\n",
+		"/version":                               `{"major":"1","minor":"30"}`,
+		"/apis/apps/v1/namespaces/apps/deployments/web": `{"kind":"Deployment","metadata":{"name":"web","managedFields":[{}],
+			"annotations":{"kubectl.kubernetes.io/last-applied-configuration":"{}","team":"ops"}},"spec":{"replicas":2}}`,
+		"/api/v1/namespaces/apps/events": `{"items":[
+			{"involvedObject":{"kind":"Pod","name":"web-1"},"type":"Warning","reason":"BackOff","lastTimestamp":"2026-01-01T00:00:01Z"},
+			{"involvedObject":{"kind":"Pod","name":"web-1"},"type":"Normal","reason":"Pulled","lastTimestamp":"2026-01-01T00:00:02Z"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t0k3n" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Unauthorized"}`))
+			return
+		}
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+		if r.URL.Path == "/api/v1/namespaces/apps/pods/web-1/log" && r.URL.Query().Get("tailLines") != "10" {
+			t.Errorf("Expected tailLines=10, got %q", r.URL.RawQuery)
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// writeKubeconfig writes a kubeconfig for server and returns its path.
+func writeKubeconfig(t *testing.T, server, user string) string {
+	path := filepath.Join(t.TempDir(), "config")
+	config := `apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: test
+  cluster:
+    server: ` + server + `
+contexts:
+- name: test
+  context:
+    cluster: test
+    user: test
+    namespace: apps
+users:
+- name: test
+  user:
+` + user
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestKubernetesTools(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	server := fakeKubeAPI(t)
+	config := map[string]string{
+		"K8S_TOOLS":      "true",
+		"K8S_KUBECONFIG": writeKubeconfig(t, server.URL, "    token: t0k3n\n"),
+	}
+
+	t.Run("disabled without K8S_TOOLS", func(t *testing.T) {
+		if _, err := NewKubePodsFromConfig(logger, map[string]string{"K8S_KUBECONFIG": config["K8S_KUBECONFIG"]}); err == nil {
+			t.Error("Expected an error without K8S_TOOLS")
+		}
+	})
+
+	t.Run("rejects credentials it cannot use", func(t *testing.T) {
+		for name, tc := range map[string]struct{ user, want string }{
+			"exec plugin":    {"    exec:\n      command: aws\n", "exec credential plugins"},
+			"oidc provider":  {"    auth-provider:\n      name: oidc\n", "auth providers such as OIDC"},
+			"basic auth":     {"    username: admin\n    password: secret\n", "password is not supported"},
+			"impersonation":  {"    token: t0k3n\n    as: admin\n", "as is not supported"},
+			"no credentials": {"", ""},
+		} {
+			_, err := LoadKubeconfig(writeKubeconfig(t, server.URL, tc.user))
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("%s: expected the kubeconfig to load, got %v", name, err)
+				}
+				continue
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("%s: expected an error mentioning %q, got %v", name, tc.want, err)
+			}
+		}
+	})
+
+	t.Run("rejects a missing user and several kubeconfigs", func(t *testing.T) {
+		path := writeKubeconfig(t, server.URL, "    token: t0k3n\n")
+		data, _ := os.ReadFile(path)
+		if err := os.WriteFile(path, []byte(strings.Replace(string(data), "- name: test\n  user:", "- name: other\n  user:", 1)), 0o600); err != nil {
+			t.Fatalf("Failed to write kubeconfig: %v", err)
+		}
+		if _, err := LoadKubeconfig(path); err == nil || !strings.Contains(err.Error(), `user "test" not found`) {
+			t.Errorf("Expected a missing user error, got %v", err)
+		}
+		if _, err := NewKubePodsFromConfig(logger, map[string]string{
+			"K8S_TOOLS": "true", "KUBECONFIG": config["K8S_KUBECONFIG"] + string(filepath.ListSeparator) + path,
+		}); err == nil || !strings.Contains(err.Error(), "several files") {
+			t.Errorf("Expected a list of kubeconfigs to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("health check reaches the API server", func(t *testing.T) {
+		tool, _ := NewKubePodsFromConfig(logger, config)
+		if err := tool.HealthCheck(context.Background()); err != nil {
+			t.Errorf("Expected a passing health check, got %v", err)
+		}
+		unauthorized, _ := NewKubePodsFromConfig(logger, map[string]string{
+			"K8S_TOOLS":      "true",
+			"K8S_KUBECONFIG": writeKubeconfig(t, server.URL, "    token: wrong\n"),
+		})
+		if err := unauthorized.HealthCheck(context.Background()); err == nil {
+			t.Error("Expected a failing health check with a bad token")
+		}
+	})
+
+	t.Run("get pods", func(t *testing.T) {
+		tool, err := NewKubePodsFromConfig(logger, config)
+		if err != nil {
+			t.Fatalf("NewKubePodsFromConfig failed: %v", err)
+		}
+		result, err := tool.Execute(nil)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		pods := result["pods"].([]map[string]interface{})
+		if len(pods) != 1 || pods[0]["ready"] != "1/2" || pods[0]["restarts"] != 3 || pods[0]["node"] != "node-a" {
+			t.Errorf("Unexpected pods: %v", pods)
+		}
+	})
+
+	t.Run("pod logs", func(t *testing.T) {
+		tool, _ := NewKubeLogsFromConfig(logger, config)
+		result, err := tool.Execute(map[string]interface{}{"pod": "web-1", "tailLines": float64(10)})
+		if err != nil || result["logs"] != "line 1\nline 2\n" {
+			t.Errorf("Unexpected result: %v (%v)", result, err)
+		}
+		if _, err := tool.Execute(map[string]interface{}{"pod": "web-1", "tailLines": float64(maxKubeTailLines + 1)}); err == nil {
+			t.Error("Expected an error for too many lines")
+		}
+	})
+
+	t.Run("describe strips bulky metadata", func(t *testing.T) {
+		tool, _ := NewKubeDescribeFromConfig(logger, config)
+		result, err := tool.Execute(map[string]interface{}{"kind": "Deployment", "name": "web"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		metadata := result["object"].(map[string]interface{})["metadata"].(map[string]interface{})
+		annotations := metadata["annotations"].(map[string]interface{})
+		if _, ok := metadata["managedFields"]; ok || len(annotations) != 1 {
+			t.Errorf("Expected managed fields and the last applied configuration to be removed: %v", metadata)
+		}
+		if _, err := tool.Execute(map[string]interface{}{"kind": "secret", "name": "db"}); err == nil {
+			t.Error("Expected secrets to be refused")
+		}
+	})
+
+	t.Run("events are newest first", func(t *testing.T) {
+		tool, _ := NewKubeEventsFromConfig(logger, config)
+		result, err := tool.Execute(map[string]interface{}{"kind": "pod", "name": "web-1"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		events := result["events"].([]map[string]interface{})
+		if len(events) != 2 || events[0]["reason"] != "Pulled" || events[0]["object"] != "Pod/web-1" {
+			t.Errorf("Unexpected events: %v", events)
+		}
+	})
+
+	t.Run("namespace allow-list", func(t *testing.T) {
+		restricted := map[string]string{"K8S_TOOLS": "true", "K8S_KUBECONFIG": config["K8S_KUBECONFIG"], "K8S_NAMESPACES": "apps"}
+		pods, _ := NewKubePodsFromConfig(logger, restricted)
+		if _, err := pods.Execute(map[string]interface{}{"namespace": "kube-system"}); err == nil {
+			t.Error("Expected kube-system to be refused")
+		}
+		describe, _ := NewKubeDescribeFromConfig(logger, restricted)
+		if _, err := describe.Execute(map[string]interface{}{"kind": "node", "name": "node-a"}); err == nil {
+			t.Error("Expected cluster-scoped kinds to be refused")
+		}
+	})
+
+	t.Run("API errors carry the server's message", func(t *testing.T) {
+		path := writeKubeconfig(t, server.URL, "    token: wrong\n")
+		tool, _ := NewKubePodsFromConfig(logger, map[string]string{"K8S_TOOLS": "true", "K8S_KUBECONFIG": path})
+		if _, err := tool.Execute(nil); err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+			t.Errorf("Expected an unauthorized error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,542 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Limits on the log output k8s_pod_logs returns.
+const (
+	defaultKubeTailLines = 100
+	maxKubeTailLines     = 5000
+	maxKubeLogBytes      = 1 << 20
+	maxKubeHealthBytes   = 64 << 10
+)
+
+// kubeResource locates a kind in the Kubernetes API.
+type kubeResource struct {
+	kind       string // As it appears in events' involvedObject.kind
+	apiPath    string
+	resource   string
+	namespaced bool
+}
+
+// kubeResources lists the kinds k8s_describe accepts. Secrets and ConfigMaps are left out
+// so the tools never return credentials.
+var kubeResources = map[string]kubeResource{
+	"pod":                   {"Pod", "/api/v1", "pods", true},
+	"service":               {"Service", "/api/v1", "services", true},
+	"persistentvolumeclaim": {"PersistentVolumeClaim", "/api/v1", "persistentvolumeclaims", true},
+	"node":                  {"Node", "/api/v1", "nodes", false},
+	"namespace":             {"Namespace", "/api/v1", "namespaces", false},
+	"deployment":            {"Deployment", "/apis/apps/v1", "deployments", true},
+	"statefulset":           {"StatefulSet", "/apis/apps/v1", "statefulsets", true},
+	"daemonset":             {"DaemonSet", "/apis/apps/v1", "daemonsets", true},
+	"replicaset":            {"ReplicaSet", "/apis/apps/v1", "replicasets", true},
+	"job":                   {"Job", "/apis/batch/v1", "jobs", true},
+	"cronjob":               {"CronJob", "/apis/batch/v1", "cronjobs", true},
+	"ingress":               {"Ingress", "/apis/networking.k8s.io/v1", "ingresses", true},
+}
+
+// kubeTool holds what every k8s_* tool shares.
+type kubeTool struct {
+	client     *KubeClient
+	namespaces map[string]bool // Namespaces the tools may read; nil allows any
+	logger     *slog.Logger
+}
+
+// newKubeToolFromConfig creates the shared state of the k8s_* tools. The tools are off
+// unless K8S_TOOLS is true. The cluster is reached with the kubeconfig named by
+// K8S_KUBECONFIG or KUBECONFIG, else the pod's service account, else ~/.kube/config.
+// Kubeconfigs are not merged, so a KUBECONFIG listing several files is an error.
+// K8S_NAMESPACES optionally limits the namespaces the tools may read.
+func newKubeToolFromConfig(logger *slog.Logger, config map[string]string) (kubeTool, error) {
+	if enabled, _ := strconv.ParseBool(config["K8S_TOOLS"]); !enabled {
+		return kubeTool{}, errors.New("K8S_TOOLS is not enabled")
+	}
+
+	var client *KubeClient
+	var err error
+	path := config["K8S_KUBECONFIG"]
+	if path == "" {
+		path = config["KUBECONFIG"]
+	}
+	switch {
+	case strings.Contains(path, string(filepath.ListSeparator)):
+		return kubeTool{}, fmt.Errorf("kubeconfig %q lists several files, which are not merged; set K8S_KUBECONFIG to one file", path)
+	case path != "":
+		client, err = LoadKubeconfig(path)
+	case config["KUBERNETES_SERVICE_HOST"] != "":
+		client, err = LoadInClusterConfig(config["KUBERNETES_SERVICE_HOST"], config["KUBERNETES_SERVICE_PORT"])
+	default:
+		home, homeErr := os.UserHomeDir()
+		if homeErr != nil {
+			return kubeTool{}, fmt.Errorf("no Kubernetes configuration found: %w", homeErr)
+		}
+		client, err = LoadKubeconfig(filepath.Join(home, ".kube", "config"))
+	}
+	if err != nil {
+		return kubeTool{}, err
+	}
+
+	tool := kubeTool{client: client, logger: logger}
+	for _, namespace := range strings.Split(config["K8S_NAMESPACES"], ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			if tool.namespaces == nil {
+				tool.namespaces = make(map[string]bool)
+			}
+			tool.namespaces[namespace] = true
+		}
+	}
+	return tool, nil
+}
+
+// namespace returns the namespace named in args, or the client's default, refusing
+// namespaces outside K8S_NAMESPACES.
+func (t kubeTool) namespace(args map[string]interface{}) (string, error) {
+	namespace, _ := args["namespace"].(string)
+	if namespace == "" {
+		namespace = t.client.Namespace()
+	}
+	if t.namespaces != nil && !t.namespaces[namespace] {
+		return "", fmt.Errorf("namespace %s is not allowed", namespace)
+	}
+	return namespace, nil
+}
+
+// Category returns the tool's category
+func (t kubeTool) Category() string {
+	return "kubernetes"
+}
+
+// HealthCheck reports whether the API server is reachable with the tool's credentials
+func (t kubeTool) HealthCheck(ctx context.Context) error {
+	_, err := t.client.get(ctx, "/version", nil, maxKubeHealthBytes)
+	return err
+}
+
+// kubeProperty describes a string argument in an input schema.
+func kubeProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// intArg reads a numeric argument, which arrives as a float64 from JSON.
+func intArg(args map[string]interface{}, name string, defaultValue int) int {
+	if value, ok := args[name].(float64); ok {
+		return int(value)
+	}
+	return defaultValue
+}
+
+// KubePods lists pods with their status and implements Tool.
+type KubePods struct{ kubeTool }
+
+// NewKubePodsFromConfig creates k8s_get_pods; see newKubeToolFromConfig.
+func NewKubePodsFromConfig(logger *slog.Logger, config map[string]string) (*KubePods, error) {
+	tool, err := newKubeToolFromConfig(logger, config)
+	return &KubePods{tool}, err
+}
+
+// Name returns the tool's name
+func (t *KubePods) Name() string {
+	return "k8s_get_pods"
+}
+
+// Description returns the tool's description
+func (t *KubePods) Description() string {
+	return "Lists Kubernetes pods in a namespace with their phase, readiness, restarts, and node"
+}
+
+// Tags returns the tool's tags
+func (t *KubePods) Tags() []string {
+	return []string{"kubernetes", "pods", "read-only"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *KubePods) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"namespace":     kubeProperty("Namespace to list; defaults to the configured namespace"),
+			"labelSelector": kubeProperty("Label selector, e.g. app=web"),
+		},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test: a read-only list of the configured namespace
+func (t *KubePods) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{}
+}
+
+// Execute runs the tool with the given arguments
+func (t *KubePods) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	namespace, err := t.namespace(args)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if selector, _ := args["labelSelector"].(string); selector != "" {
+		query.Set("labelSelector", selector)
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				NodeName string `json:"nodeName"`
+			} `json:"spec"`
+			Status struct {
+				Phase             string `json:"phase"`
+				PodIP             string `json:"podIP"`
+				StartTime         string `json:"startTime"`
+				ContainerStatuses []struct {
+					Ready        bool `json:"ready"`
+					RestartCount int  `json:"restartCount"`
+				} `json:"containerStatuses"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kubeRequestTimeout)
+	defer cancel()
+	if err := t.client.getJSON(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods", query, &list); err != nil {
+		return nil, err
+	}
+
+	pods := make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		ready, restarts := 0, 0
+		for _, status := range item.Status.ContainerStatuses {
+			if status.Ready {
+				ready++
+			}
+			restarts += status.RestartCount
+		}
+		pods = append(pods, map[string]interface{}{
+			"name":      item.Metadata.Name,
+			"phase":     item.Status.Phase,
+			"ready":     fmt.Sprintf("%d/%d", ready, len(item.Status.ContainerStatuses)),
+			"restarts":  restarts,
+			"node":      item.Spec.NodeName,
+			"podIP":     item.Status.PodIP,
+			"startTime": item.Status.StartTime,
+		})
+	}
+	return map[string]interface{}{"namespace": namespace, "pods": pods}, nil
+}
+
+// KubeLogs returns the tail of a pod's logs and implements Tool.
+type KubeLogs struct{ kubeTool }
+
+// NewKubeLogsFromConfig creates k8s_pod_logs; see newKubeToolFromConfig.
+func NewKubeLogsFromConfig(logger *slog.Logger, config map[string]string) (*KubeLogs, error) {
+	tool, err := newKubeToolFromConfig(logger, config)
+	return &KubeLogs{tool}, err
+}
+
+// Name returns the tool's name
+func (t *KubeLogs) Name() string {
+	return "k8s_pod_logs"
+}
+
+// Description returns the tool's description
+func (t *KubeLogs) Description() string {
+	return "Returns the most recent log lines of a Kubernetes pod's container"
+}
+
+// Tags returns the tool's tags
+func (t *KubeLogs) Tags() []string {
+	return []string{"kubernetes", "logs", "read-only"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *KubeLogs) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"pod":       kubeProperty("Pod name"),
+			"namespace": kubeProperty("Pod namespace; defaults to the configured namespace"),
+			"container": kubeProperty("Container name; required for pods with several containers"),
+			"tailLines": map[string]interface{}{
+				"type":        "integer",
+				"description": fmt.Sprintf("Lines to return from the end of the log (default %d, at most %d)", defaultKubeTailLines, maxKubeTailLines),
+			},
+			"previous": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Return the logs of the previous, terminated container",
+			},
+		},
+		"required": []string{"pod"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *KubeLogs) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	pod, _ := args["pod"].(string)
+	if pod == "" {
+		return nil, fmt.Errorf("missing required argument: pod")
+	}
+	namespace, err := t.namespace(args)
+	if err != nil {
+		return nil, err
+	}
+	tailLines := intArg(args, "tailLines", defaultKubeTailLines)
+	if tailLines <= 0 || tailLines > maxKubeTailLines {
+		return nil, fmt.Errorf("tailLines must be between 1 and %d", maxKubeTailLines)
+	}
+
+	query := url.Values{
+		"tailLines":  {strconv.Itoa(tailLines)},
+		"limitBytes": {strconv.Itoa(maxKubeLogBytes)},
+	}
+	container, _ := args["container"].(string)
+	if container != "" {
+		query.Set("container", container)
+	}
+	if previous, _ := args["previous"].(bool); previous {
+		query.Set("previous", "true")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), kubeRequestTimeout)
+	defer cancel()
+	logs, err := t.client.get(ctx, "/api/v1/namespaces/"+url.PathEscape(namespace)+"/pods/"+url.PathEscape(pod)+"/log", query, maxKubeLogBytes)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"namespace": namespace,
+		"pod":       pod,
+		"container": container,
+		"logs":      string(logs),
+	}, nil
+}
+
+// KubeDescribe returns an object and its recent events and implements Tool.
+type KubeDescribe struct{ kubeTool }
+
+// NewKubeDescribeFromConfig creates k8s_describe; see newKubeToolFromConfig.
+func NewKubeDescribeFromConfig(logger *slog.Logger, config map[string]string) (*KubeDescribe, error) {
+	tool, err := newKubeToolFromConfig(logger, config)
+	return &KubeDescribe{tool}, err
+}
+
+// Name returns the tool's name
+func (t *KubeDescribe) Name() string {
+	return "k8s_describe"
+}
+
+// Description returns the tool's description
+func (t *KubeDescribe) Description() string {
+	return "Returns a Kubernetes object's spec and status along with its recent events"
+}
+
+// Tags returns the tool's tags
+func (t *KubeDescribe) Tags() []string {
+	return []string{"kubernetes", "describe", "read-only"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *KubeDescribe) InputSchema() map[string]interface{} {
+	kinds := make([]string, 0, len(kubeResources))
+	for kind := range kubeResources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"kind":      map[string]interface{}{"type": "string", "enum": kinds, "description": "Object kind"},
+			"name":      kubeProperty("Object name"),
+			"namespace": kubeProperty("Object namespace; defaults to the configured namespace"),
+		},
+		"required": []string{"kind", "name"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *KubeDescribe) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	kind, _ := args["kind"].(string)
+	name, _ := args["name"].(string)
+	resource, ok := kubeResources[strings.ToLower(kind)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("missing required argument: name")
+	}
+
+	path := resource.apiPath
+	namespace := ""
+	if resource.namespaced {
+		var err error
+		if namespace, err = t.namespace(args); err != nil {
+			return nil, err
+		}
+		path += "/namespaces/" + url.PathEscape(namespace)
+	} else if t.namespaces != nil {
+		return nil, fmt.Errorf("%s is cluster-scoped and K8S_NAMESPACES is set", resource.kind)
+	}
+	path += "/" + resource.resource + "/" + url.PathEscape(name)
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubeRequestTimeout)
+	defer cancel()
+	var object map[string]interface{}
+	if err := t.client.getJSON(ctx, path, nil, &object); err != nil {
+		return nil, err
+	}
+	// Managed fields and the last applied configuration are bulky and repeat the spec.
+	if metadata, ok := object["metadata"].(map[string]interface{}); ok {
+		delete(metadata, "managedFields")
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			delete(annotations, "kubectl.kubernetes.io/last-applied-configuration")
+		}
+	}
+
+	events, err := t.client.events(ctx, namespace, resource.kind, name, 20)
+	if err != nil {
+		t.logger.Warn("Failed to list events", "kind", resource.kind, "name", name, "error", err)
+	}
+	return map[string]interface{}{"object": object, "events": events}, nil
+}
+
+// KubeEvents lists recent events and implements Tool.
+type KubeEvents struct{ kubeTool }
+
+// NewKubeEventsFromConfig creates k8s_events; see newKubeToolFromConfig.
+func NewKubeEventsFromConfig(logger *slog.Logger, config map[string]string) (*KubeEvents, error) {
+	tool, err := newKubeToolFromConfig(logger, config)
+	return &KubeEvents{tool}, err
+}
+
+// Name returns the tool's name
+func (t *KubeEvents) Name() string {
+	return "k8s_events"
+}
+
+// Description returns the tool's description
+func (t *KubeEvents) Description() string {
+	return "Lists recent Kubernetes events in a namespace, newest first, optionally for one object"
+}
+
+// Tags returns the tool's tags
+func (t *KubeEvents) Tags() []string {
+	return []string{"kubernetes", "events", "read-only"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (t *KubeEvents) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"namespace": kubeProperty("Namespace; defaults to the configured namespace"),
+			"kind":      kubeProperty("Only events for objects of this kind, e.g. Pod"),
+			"name":      kubeProperty("Only events for objects with this name"),
+			"limit": map[string]interface{}{
+				"type":        "integer",
+				"description": "Events to return (default 50)",
+			},
+		},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (t *KubeEvents) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	namespace, err := t.namespace(args)
+	if err != nil {
+		return nil, err
+	}
+	kind, _ := args["kind"].(string)
+	if resource, ok := kubeResources[strings.ToLower(kind)]; ok {
+		kind = resource.kind
+	}
+	name, _ := args["name"].(string)
+	limit := intArg(args, "limit", 50)
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit must be positive")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), kubeRequestTimeout)
+	defer cancel()
+	events, err := t.client.events(ctx, namespace, kind, name, limit)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"namespace": namespace, "events": events}, nil
+}
+
+// events returns up to limit events in namespace, newest first, for objects matching
+// kind and name when they are set. An empty namespace lists events in every namespace.
+func (c *KubeClient) events(ctx context.Context, namespace, kind, name string, limit int) ([]map[string]interface{}, error) {
+	var selectors []string
+	if kind != "" {
+		selectors = append(selectors, "involvedObject.kind="+kind)
+	}
+	if name != "" {
+		selectors = append(selectors, "involvedObject.name="+name)
+	}
+	query := url.Values{}
+	if len(selectors) > 0 {
+		query.Set("fieldSelector", strings.Join(selectors, ","))
+	}
+	path := "/api/v1/events"
+	if namespace != "" {
+		path = "/api/v1/namespaces/" + url.PathEscape(namespace) + "/events"
+	}
+
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				CreationTimestamp string `json:"creationTimestamp"`
+			} `json:"metadata"`
+			InvolvedObject struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"involvedObject"`
+			Type          string `json:"type"`
+			Reason        string `json:"reason"`
+			Message       string `json:"message"`
+			Count         int    `json:"count"`
+			LastTimestamp string `json:"lastTimestamp"`
+			EventTime     string `json:"eventTime"`
+		} `json:"items"`
+	}
+	if err := c.getJSON(ctx, path, query, &list); err != nil {
+		return nil, err
+	}
+
+	events := make([]map[string]interface{}, 0, len(list.Items))
+	for _, item := range list.Items {
+		// Events report their time in whichever field the emitting component filled in.
+		lastSeen := item.LastTimestamp
+		if lastSeen == "" {
+			lastSeen = item.EventTime
+		}
+		if lastSeen == "" {
+			lastSeen = item.Metadata.CreationTimestamp
+		}
+		events = append(events, map[string]interface{}{
+			"type":     item.Type,
+			"reason":   item.Reason,
+			"object":   item.InvolvedObject.Kind + "/" + item.InvolvedObject.Name,
+			"message":  item.Message,
+			"count":    item.Count,
+			"lastSeen": lastSeen,
+		})
+	}
+	// RFC 3339 timestamps in UTC sort chronologically as strings.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i]["lastSeen"].(string) > events[j]["lastSeen"].(string)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// LazyTool stands in for a tool whose construction is slow, such as one that connects to
+// a database, so that building it does not delay server startup. It describes itself with
+// an unconfigured prototype of the tool and builds the real tool on first use or when
+// warmed up with Build. A failed build is retried on the next use.
+type LazyTool struct {
+	prototype Tool
+	build     func() (Tool, error)
+	logger    *slog.Logger
+
+	mu      sync.Mutex
+	tool    Tool
+	err     error // Error of the last failed build
+	tried   bool
+	buildMu sync.Mutex // Held while building so concurrent first calls share one build
+}
+
+// NewLazyTool returns a LazyTool described by prototype that calls build when the tool is
+// first needed. The prototype's Name, Description, InputSchema, Category, and Tags must
+// not depend on its configuration.
+func NewLazyTool(prototype Tool, build func() (Tool, error), logger *slog.Logger) *LazyTool {
+	return &LazyTool{prototype: prototype, build: build, logger: logger}
+}
+
+// Build returns the real tool, building it if it has not been built yet.
+func (l *LazyTool) Build() (Tool, error) {
+	l.buildMu.Lock()
+	defer l.buildMu.Unlock()
+	l.mu.Lock()
+	tool := l.tool
+	l.mu.Unlock()
+	if tool != nil {
+		return tool, nil
+	}
+
+	tool, err := l.build()
+	if err == nil && tool.Name() != l.prototype.Name() {
+		err = fmt.Errorf("built tool is named %s, not %s", tool.Name(), l.prototype.Name())
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tried = true
+	if err != nil {
+		l.err = err
+		l.logger.Warn("Failed to build tool", "tool", l.prototype.Name(), "error", err)
+		return nil, err
+	}
+	l.tool, l.err = tool, nil
+	l.logger.Info("Built tool", "tool", tool.Name())
+	return tool, nil
+}
+
+// Built reports whether the real tool has been built.
+func (l *LazyTool) Built() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tool != nil
+}
+
+// Name returns the tool's name
+func (l *LazyTool) Name() string {
+	return l.prototype.Name()
+}
+
+// Description returns the tool's description
+func (l *LazyTool) Description() string {
+	return l.prototype.Description()
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (l *LazyTool) InputSchema() map[string]interface{} {
+	if provider, ok := l.prototype.(SchemaProvider); ok {
+		return provider.InputSchema()
+	}
+	return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+}
+
+// Category returns the tool's category
+func (l *LazyTool) Category() string {
+	if provider, ok := l.prototype.(MetadataProvider); ok {
+		return provider.Category()
+	}
+	return ""
+}
+
+// Tags returns the tool's tags
+func (l *LazyTool) Tags() []string {
+	if provider, ok := l.prototype.(MetadataProvider); ok {
+		return provider.Tags()
+	}
+	return nil
+}
+
+// RequiresApproval reports whether the prototype's calls wait for operator approval
+func (l *LazyTool) RequiresApproval() bool {
+	requirer, ok := l.prototype.(ApprovalRequirer)
+	return ok && requirer.RequiresApproval()
+}
+
+// OutputSchema returns the prototype's output schema, or nil if it has none
+func (l *LazyTool) OutputSchema() map[string]interface{} {
+	if provider, ok := l.prototype.(OutputSchemaProvider); ok {
+		return provider.OutputSchema()
+	}
+	return nil
+}
+
+// Deprecation returns the prototype's deprecation notice, if any
+func (l *LazyTool) Deprecation() string {
+	if provider, ok := l.prototype.(DeprecationProvider); ok {
+		return provider.Deprecation()
+	}
+	return ""
+}
+
+// SelfTestArgs returns the prototype's self-test arguments, or nil if it has none
+func (l *LazyTool) SelfTestArgs() map[string]interface{} {
+	if tester, ok := l.prototype.(SelfTester); ok {
+		return tester.SelfTestArgs()
+	}
+	return nil
+}
+
+// Execute runs the tool with the given arguments
+func (l *LazyTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return l.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext builds the tool if needed and runs it
+func (l *LazyTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	tool, err := l.Build()
+	if err != nil {
+		return nil, fmt.Errorf("tool %s is not available: %w", l.Name(), err)
+	}
+	if contextTool, ok := tool.(ContextTool); ok {
+		return contextTool.ExecuteContext(ctx, args)
+	}
+	return tool.Execute(args)
+}
+
+// HealthCheck reports the last build failure, retrying the build, and otherwise runs the
+// built tool's own check. A tool that has not been built yet is healthy, so health checks
+// do not build tools that were left for first use.
+func (l *LazyTool) HealthCheck(ctx context.Context) error {
+	l.mu.Lock()
+	tool, tried := l.tool, l.tried
+	l.mu.Unlock()
+	if tool == nil {
+		if !tried {
+			return nil
+		}
+		var err error
+		if tool, err = l.Build(); err != nil {
+			return err
+		}
+	}
+	if checker, ok := tool.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLazyTool(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("describes itself without building", func(t *testing.T) {
+		lazy := NewLazyTool(&GeoIP{}, func() (Tool, error) {
+			t.Fatal("builder should not be called")
+			return nil, nil
+		}, logger)
+		if lazy.Name() != "geoip" || lazy.Category() != "network" || lazy.InputSchema()["type"] != "object" {
+			t.Errorf("Expected the prototype's metadata, got %s %s %v", lazy.Name(), lazy.Category(), lazy.InputSchema())
+		}
+		if lazy.SelfTestArgs()["ip"] == nil {
+			t.Errorf("Expected the prototype's self-test arguments, got %v", lazy.SelfTestArgs())
+		}
+		if lazy.Built() {
+			t.Error("Expected the tool to be unbuilt")
+		}
+		if err := lazy.HealthCheck(context.Background()); err != nil {
+			t.Errorf("Expected an unbuilt tool to be healthy, got %v", err)
+		}
+	})
+
+	t.Run("builds once on first use", func(t *testing.T) {
+		var builds atomic.Int32
+		lazy := NewLazyTool(&MockTool{name: "mock"}, func() (Tool, error) {
+			builds.Add(1)
+			return &MockTool{name: "mock"}, nil
+		}, logger)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := lazy.Execute(nil); err != nil {
+					t.Errorf("Execute failed: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		if builds.Load() != 1 {
+			t.Errorf("Expected 1 build, got %d", builds.Load())
+		}
+		if !lazy.Built() {
+			t.Error("Expected the tool to be built")
+		}
+	})
+
+	t.Run("failed build is retried and reported by the health check", func(t *testing.T) {
+		fail := true
+		lazy := NewLazyTool(&MockTool{name: "mock"}, func() (Tool, error) {
+			if fail {
+				return nil, errors.New("database unreachable")
+			}
+			return &MockTool{name: "mock"}, nil
+		}, logger)
+
+		if _, err := lazy.Execute(nil); err == nil {
+			t.Fatal("Expected the failed build to fail the call")
+		}
+		if err := lazy.HealthCheck(context.Background()); err == nil {
+			t.Error("Expected the health check to report the failed build")
+		}
+		fail = false
+		if err := lazy.HealthCheck(context.Background()); err != nil {
+			t.Errorf("Expected the retried build to pass, got %v", err)
+		}
+		if !lazy.Built() {
+			t.Error("Expected the tool to be built")
+		}
+	})
+
+	t.Run("rejects a built tool with another name", func(t *testing.T) {
+		lazy := NewLazyTool(&MockTool{name: "mock"}, func() (Tool, error) {
+			return &MockTool{name: "other"}, nil
+		}, logger)
+		if _, err := lazy.Build(); err == nil {
+			t.Error("Expected a name mismatch error")
+		}
+	})
+}
+
+func TestToolRegistry_SetLazy(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	registry := &ToolRegistry{builders: map[string]ToolBuilder{}, prototypes: map[string]Tool{}}
+	var built atomic.Int32
+	registry.RegisterDeferrable("slow", &MockTool{name: "slow"}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		built.Add(1)
+		return &MockTool{name: "slow"}, nil
+	})
+	registry.Register("fast", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return &MockTool{name: "fast"}, nil
+	})
+
+	eager := registry.SetLazy([]string{"slow", "fast"})
+	if len(eager) != 1 || eager[0] != "fast" {
+		t.Errorf("Expected [fast] to stay eager, got %v", eager)
+	}
+
+	created, err := registry.CreateAllAvailable(logger)
+	if err != nil {
+		t.Fatalf("CreateAllAvailable failed: %v", err)
+	}
+	if len(created) != 2 {
+		t.Fatalf("Expected 2 tools, got %d", len(created))
+	}
+	if built.Load() != 0 {
+		t.Error("Expected the lazy tool not to be built at creation")
+	}
+	for _, tool := range created {
+		if _, lazy := tool.(*LazyTool); lazy != (tool.Name() == "slow") {
+			t.Errorf("Unexpected laziness for %s", tool.Name())
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"mcp-tools-server/pkg/egress"
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// TestMain lets tools reach the loopback test servers, which the default egress policy
+// denies.
+func TestMain(m *testing.M) {
+	policy, err := egress.New(egress.Config{AllowLocal: true})
+	if err == nil {
+		err = httpclient.Configure(httpclient.Config{Policy: policy})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "configuring the HTTP client: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"mcp-tools-server/pkg/httpclient"
+)
+
+// maxNotifyMessageLength caps the message an agent may send, in characters.
+const maxNotifyMessageLength = 4000
+
+// notifySeverities lists the accepted severities.
+var notifySeverities = []string{"info", "warning", "critical"}
+
+// notifyPlaceholders lists the values a channel template may use.
+var notifyPlaceholders = map[string]bool{"message": true, "title": true, "severity": true, "channel": true}
+
+// NotifyChannel is a webhook the notify tool may post to.
+type NotifyChannel struct {
+	URL      string `json:"url"`
+	Type     string `json:"type"`               // slack, discord, teams, or generic
+	Template string `json:"template,omitempty"` // Message text with {{message}}, {{title}}, {{severity}}, and {{channel}} placeholders
+}
+
+// Notify posts messages to operator-configured chat webhooks and implements Tool. Agents
+// can only reach the configured channels, by name, never an arbitrary URL.
+type Notify struct {
+	logger   *slog.Logger
+	channels map[string]NotifyChannel
+	client   *http.Client
+}
+
+// NewNotify creates a notify tool for the given channels, keyed by name.
+func NewNotify(logger *slog.Logger, channels map[string]NotifyChannel) (*Notify, error) {
+	if len(channels) == 0 {
+		return nil, errors.New("no notification channels configured")
+	}
+	for name, channel := range channels {
+		u, err := url.Parse(channel.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("channel %s: url must be http(s)", name)
+		}
+		switch channel.Type {
+		case "slack", "discord", "teams", "generic":
+		case "":
+			channel.Type = "generic"
+		default:
+			return nil, fmt.Errorf("channel %s: unknown type %q; use slack, discord, teams, or generic", name, channel.Type)
+		}
+		if channel.Template == "" {
+			channel.Template = "{{message}}"
+		}
+		for _, match := range placeholderPattern.FindAllStringSubmatch(channel.Template, -1) {
+			if !notifyPlaceholders[match[1]] {
+				return nil, fmt.Errorf("channel %s: unknown template placeholder %s", name, match[0])
+			}
+		}
+		channels[name] = channel
+	}
+	return &Notify{
+		logger:   logger,
+		channels: channels,
+		client:   httpclient.New("notify", httpclient.WithTimeout(10*time.Second)),
+	}, nil
+}
+
+// NewNotifyFromConfig creates a notify tool from NOTIFY_CHANNELS, a JSON object of
+// channels keyed by name. It fails when no channels are configured, so the registry
+// skips the tool.
+func NewNotifyFromConfig(logger *slog.Logger, config map[string]string) (*Notify, error) {
+	raw := config["NOTIFY_CHANNELS"]
+	if raw == "" {
+		return nil, errors.New("NOTIFY_CHANNELS is not set")
+	}
+	var channels map[string]NotifyChannel
+	if err := json.Unmarshal([]byte(raw), &channels); err != nil {
+		return nil, fmt.Errorf("invalid NOTIFY_CHANNELS: %w", err)
+	}
+	return NewNotify(logger, channels)
+}
+
+// Name returns the tool's name
+func (n *Notify) Name() string {
+	return "notify"
+}
+
+// Description returns the tool's description
+func (n *Notify) Description() string {
+	return "Posts a message to a configured Slack, Discord, or Teams channel to alert a human"
+}
+
+// Category returns the tool's category
+func (n *Notify) Category() string {
+	return "communication"
+}
+
+// Tags returns the tool's tags
+func (n *Notify) Tags() []string {
+	return []string{"notify", "slack", "discord", "teams", "alert"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (n *Notify) InputSchema() map[string]interface{} {
+	names := make([]string, 0, len(n.channels))
+	for name := range n.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"channel": map[string]interface{}{
+				"type":        "string",
+				"enum":        names,
+				"description": "Channel to post to",
+			},
+			"message": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("Message text, at most %d characters", maxNotifyMessageLength),
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional short title",
+			},
+			"severity": map[string]interface{}{
+				"type":        "string",
+				"enum":        notifySeverities,
+				"description": "Message severity (default info)",
+			},
+		},
+		"required": []string{"channel", "message"},
+	}
+}
+
+// Execute runs the tool with the given arguments
+func (n *Notify) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	name, _ := args["channel"].(string)
+	message, _ := args["message"].(string)
+	title, _ := args["title"].(string)
+	severity, _ := args["severity"].(string)
+
+	channel, ok := n.channels[name]
+	if !ok {
+		return nil, fmt.Errorf("channel not allowed: %s", name)
+	}
+	if message == "" {
+		return nil, fmt.Errorf("missing required argument: message")
+	}
+	if len([]rune(message)) > maxNotifyMessageLength {
+		return nil, fmt.Errorf("message exceeds %d characters", maxNotifyMessageLength)
+	}
+	if severity == "" {
+		severity = "info"
+	}
+	validSeverity := false
+	for _, s := range notifySeverities {
+		validSeverity = validSeverity || s == severity
+	}
+	if !validSeverity {
+		return nil, fmt.Errorf("invalid severity: %s", severity)
+	}
+
+	values := map[string]interface{}{"message": message, "title": title, "severity": severity, "channel": name}
+	text, err := expandPlaceholders(channel.Template, values, nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(notifyPayload(channel.Type, text, values))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	resp, err := n.client.Post(channel.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		// The URL is a credential for most chat services, so it is left out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("failed to post to %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("posting to %s returned status: %d", name, resp.StatusCode)
+	}
+	n.logger.Info("Notification sent", "channel", name, "severity", severity)
+	return map[string]interface{}{"channel": name, "delivered": true}, nil
+}
+
+// notifyPayload builds the JSON body each webhook type expects.
+func notifyPayload(channelType, text string, values map[string]interface{}) interface{} {
+	switch channelType {
+	case "slack":
+		return map[string]string{"text": text}
+	case "discord":
+		return map[string]string{"content": text}
+	case "teams":
+		payload := map[string]string{"text": text}
+		if title, _ := values["title"].(string); title != "" {
+			payload["title"] = title
+		}
+		return payload
+	default:
+		return map[string]interface{}{
+			"channel":  values["channel"],
+			"severity": values["severity"],
+			"title":    values["title"],
+			"text":     text,
+		}
+	}
+}
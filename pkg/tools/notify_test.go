@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNotify(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	received := make(chan map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload
+	}))
+	defer server.Close()
+
+	t.Run("formats payloads per channel type", func(t *testing.T) {
+		notify, err := NewNotifyFromConfig(logger, map[string]string{
+			"NOTIFY_CHANNELS": `{
+				"ops": {"url": "` + server.URL + `", "type": "slack", "template": "[{{severity}}] {{title}}: {{message}}"},
+				"dev": {"url": "` + server.URL + `", "type": "discord"}
+			}`,
+		})
+		if err != nil {
+			t.Fatalf("NewNotifyFromConfig failed: %v", err)
+		}
+
+		if _, err := notify.Execute(map[string]interface{}{"channel": "ops", "message": "disk full", "title": "db-1", "severity": "critical"}); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if payload := <-received; payload["text"] != "[critical] db-1: disk full" {
+			t.Errorf("Unexpected Slack payload: %v", payload)
+		}
+
+		if _, err := notify.Execute(map[string]interface{}{"channel": "dev", "message": "deployed"}); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if payload := <-received; payload["content"] != "deployed" {
+			t.Errorf("Unexpected Discord payload: %v", payload)
+		}
+	})
+
+	t.Run("only configured channels are reachable", func(t *testing.T) {
+		notify, _ := NewNotify(logger, map[string]NotifyChannel{"ops": {URL: server.URL, Type: "slack"}})
+		if _, err := notify.Execute(map[string]interface{}{"channel": "ceo", "message": "hi"}); err == nil {
+			t.Error("Expected an unconfigured channel to be refused")
+		}
+		if _, err := notify.Execute(map[string]interface{}{"channel": "ops", "message": strings.Repeat("x", maxNotifyMessageLength+1)}); err == nil {
+			t.Error("Expected an oversized message to be refused")
+		}
+		if _, err := notify.Execute(map[string]interface{}{"channel": "ops", "message": "hi", "severity": "panic"}); err == nil {
+			t.Error("Expected an unknown severity to be refused")
+		}
+	})
+
+	t.Run("rejects invalid channels", func(t *testing.T) {
+		for name, channel := range map[string]NotifyChannel{
+			"bad url":         {URL: "file:///etc/passwd", Type: "slack"},
+			"bad type":        {URL: server.URL, Type: "pager"},
+			"bad placeholder": {URL: server.URL, Type: "slack", Template: "{{secret}}"},
+		} {
+			if _, err := NewNotify(logger, map[string]NotifyChannel{"c": channel}); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+		if _, err := NewNotifyFromConfig(logger, map[string]string{}); err == nil {
+			t.Error("Expected an error without NOTIFY_CHANNELS")
+		}
+	})
+}
@@ -0,0 +1,175 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidResult is returned for a result that does not satisfy its tool's output schema.
+var ErrInvalidResult = errors.New("invalid result")
+
+// ValidateResult checks a tool result against a JSON Schema. Unlike ValidateArgs, it
+// converts nothing: values must have the types the schema names once encoded as JSON, so
+// a string holding a number is not a number. It checks type, including "integer", enum,
+// required, properties, additionalProperties, and items. The result's "_meta" is not
+// checked. Violations are errors wrapping ErrInvalidResult.
+func ValidateResult(schema, result map[string]interface{}) error {
+	var decodedSchema map[string]interface{}
+	if err := jsonRoundTrip(schema, &decodedSchema); err != nil {
+		return fmt.Errorf("invalid output schema: %w", err)
+	}
+	var decoded map[string]interface{}
+	if err := jsonRoundTrip(result, &decoded); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidResult, err)
+	}
+	delete(decoded, "_meta")
+	return validateValue(decodedSchema, decoded, "result")
+}
+
+// jsonRoundTrip encodes value as JSON and decodes it into out, so it holds only the types
+// encoding/json decodes to.
+func jsonRoundTrip(value, out interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// validateValue checks a decoded JSON value against a schema.
+func validateValue(schema map[string]interface{}, value interface{}, path string) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s %s", ErrInvalidResult, path, fmt.Sprintf(format, args...))
+	}
+
+	var types []string
+	switch t := schema["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) > 0 {
+		matched := false
+		for _, typ := range types {
+			if jsonTypeMatches(typ, value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return invalid("must be %s, got %s", strings.Join(types, " or "), jsonTypeName(value))
+		}
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, allowed := range enum {
+			if reflect.DeepEqual(allowed, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return invalid("is not one of the allowed values")
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		for _, name := range requiredProperties(schema) {
+			if _, ok := v[name]; !ok {
+				return invalid("is missing %s", name)
+			}
+		}
+		// Sorted so the first error reported does not depend on map order
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if property, ok := properties[name].(map[string]interface{}); ok {
+				if err := validateValue(property, v[name], path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					return invalid("has unexpected property %s", name)
+				}
+			case map[string]interface{}:
+				if err := validateValue(additional, v[name], path+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				if err := validateValue(items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// jsonTypeMatches reports whether a decoded JSON value has a JSON Schema type.
+func jsonTypeMatches(typ string, value interface{}) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "array":
+		_, ok := value.([]interface{})
+		return ok
+	case "object":
+		_, ok := value.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// jsonTypeName names the JSON type of a decoded value for error messages.
+func jsonTypeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
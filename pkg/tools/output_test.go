@@ -0,0 +1,61 @@
+package tools
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateResult(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"status": map[string]interface{}{"type": "string", "enum": []interface{}{"ok", "degraded"}},
+			"count":  map[string]interface{}{"type": "integer"},
+			"items":  map[string]interface{}{"type": []interface{}{"array", "null"}, "items": map[string]interface{}{"type": "number"}},
+		},
+		"required":             []string{"status", "count"},
+		"additionalProperties": false,
+	}
+
+	t.Run("accepts matching results", func(t *testing.T) {
+		for _, result := range []map[string]interface{}{
+			{"status": "ok", "count": 3},
+			{"status": "degraded", "count": int64(0), "items": []float64{1.5, 2}},
+			{"status": "ok", "count": 1.0, "items": nil},
+			{"status": "ok", "count": 1, "_meta": map[string]interface{}{"tool": "x"}},
+		} {
+			if err := ValidateResult(schema, result); err != nil {
+				t.Errorf("Expected %v to be valid, got %v", result, err)
+			}
+		}
+	})
+
+	t.Run("rejects mismatched results", func(t *testing.T) {
+		for _, tc := range []struct {
+			result   map[string]interface{}
+			expected string
+		}{
+			{map[string]interface{}{"count": 1}, "invalid result: result is missing status"},
+			{map[string]interface{}{"status": "ok", "count": "1"}, "invalid result: result.count must be integer, got string"},
+			{map[string]interface{}{"status": "ok", "count": 1.5}, "invalid result: result.count must be integer, got number"},
+			{map[string]interface{}{"status": "broken", "count": 1}, "invalid result: result.status is not one of the allowed values"},
+			{map[string]interface{}{"status": "ok", "count": 1, "items": []interface{}{"a"}}, "invalid result: result.items[0] must be number, got string"},
+			{map[string]interface{}{"status": "ok", "count": 1, "extra": true}, "invalid result: result has unexpected property extra"},
+		} {
+			err := ValidateResult(schema, tc.result)
+			if !errors.Is(err, ErrInvalidResult) || err.Error() != tc.expected {
+				t.Errorf("Expected %q for %v, got %v", tc.expected, tc.result, err)
+			}
+		}
+	})
+
+	t.Run("checks additional properties against a schema", func(t *testing.T) {
+		counts := map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "integer"}}
+		if err := ValidateResult(counts, map[string]interface{}{"a": 1, "b": 2}); err != nil {
+			t.Errorf("Expected integer counts to be valid, got %v", err)
+		}
+		if err := ValidateResult(counts, map[string]interface{}{"a": "one"}); !errors.Is(err, ErrInvalidResult) {
+			t.Errorf("Expected a string count to be invalid, got %v", err)
+		}
+	})
+}
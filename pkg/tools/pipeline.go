@@ -0,0 +1,303 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// pipelineRefPattern matches {{$...}} references embedded in longer strings.
+var pipelineRefPattern = regexp.MustCompile(`\{\{\s*(\$[^}]*?)\s*\}\}`)
+
+// PipelineStep is one tool call in a pipeline. String values in Args may reference the
+// pipeline's input and earlier steps' results with JSONPath: a value that is exactly a
+// path, such as "$.steps.fetch.body.items[0]", is replaced by the value it selects, and
+// {{$.input.name}} inside a longer string is replaced by the value as text.
+type PipelineStep struct {
+	ID   string                 `json:"id,omitempty"` // Name later steps use under $.steps (default: the tool name)
+	Tool string                 `json:"tool"`
+	Args map[string]interface{} `json:"args,omitempty"`
+}
+
+// PipelineDefinition describes a composite tool that runs other tools in sequence.
+type PipelineDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"` // Defaults to an object accepting any arguments
+	Category    string                 `json:"category,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Steps       []PipelineStep         `json:"steps"`
+	Output      interface{}            `json:"output,omitempty"` // Result template using the same references (default: the last step's result)
+}
+
+// Validate reports whether the definition describes a usable pipeline: every reference
+// must parse and may only name steps that run before it.
+func (d PipelineDefinition) Validate() error {
+	if d.Name == "" {
+		return errors.New("pipeline name is required")
+	}
+	if d.Description == "" {
+		return errors.New("pipeline description is required")
+	}
+	if len(d.Steps) == 0 {
+		return errors.New("pipeline requires at least one step")
+	}
+	seen := make(map[string]bool)
+	for i, step := range d.Steps {
+		if step.Tool == "" {
+			return fmt.Errorf("step %d: tool is required", i+1)
+		}
+		if step.Tool == d.Name {
+			return fmt.Errorf("step %d: a pipeline cannot call itself", i+1)
+		}
+		if err := checkPipelineRefs(step.Args, seen); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		id := step.id()
+		if seen[id] {
+			return fmt.Errorf("step %d: duplicate step id %q", i+1, id)
+		}
+		seen[id] = true
+	}
+	if err := checkPipelineRefs(d.Output, seen); err != nil {
+		return fmt.Errorf("output: %w", err)
+	}
+	return nil
+}
+
+// id returns the name the step's result is stored under.
+func (s PipelineStep) id() string {
+	if s.ID != "" {
+		return s.ID
+	}
+	return s.Tool
+}
+
+// ToolExecutor runs a tool by name. Pipelines call their steps through it with the
+// pipeline call's context, so steps get the same middleware as direct calls and run as
+// the same caller: tenant, policy identity, session, and cancellation carry over.
+type ToolExecutor func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error)
+
+// Pipeline is a Tool built from a PipelineDefinition. It implements ContextTool.
+type Pipeline struct {
+	definition PipelineDefinition
+	execute    ToolExecutor
+	logger     *slog.Logger
+}
+
+// NewPipeline validates definition and creates the pipeline it describes.
+func NewPipeline(definition PipelineDefinition, execute ToolExecutor, logger *slog.Logger) (*Pipeline, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+	return &Pipeline{definition: definition, execute: execute, logger: logger}, nil
+}
+
+// Definition returns the definition the pipeline was built from.
+func (p *Pipeline) Definition() PipelineDefinition {
+	return p.definition
+}
+
+// Name returns the tool's name
+func (p *Pipeline) Name() string {
+	return p.definition.Name
+}
+
+// Description returns the tool's description
+func (p *Pipeline) Description() string {
+	return p.definition.Description
+}
+
+// Category returns the tool's category
+func (p *Pipeline) Category() string {
+	return p.definition.Category
+}
+
+// Tags returns the tool's tags
+func (p *Pipeline) Tags() []string {
+	return p.definition.Tags
+}
+
+// InputSchema returns the definition's schema, or an open object schema when it has none.
+func (p *Pipeline) InputSchema() map[string]interface{} {
+	if p.definition.InputSchema != nil {
+		return p.definition.InputSchema
+	}
+	return map[string]interface{}{"type": "object"}
+}
+
+// Execute runs the steps in order, stopping at the first failure
+func (p *Pipeline) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return p.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the steps in order with ctx, stopping at the first failure or once
+// ctx is done
+func (p *Pipeline) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	input, err := normalizeJSON(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid arguments: %w", err)
+	}
+	steps := make(map[string]interface{}, len(p.definition.Steps))
+	scope := map[string]interface{}{"input": input, "steps": steps}
+
+	var last interface{}
+	for i, step := range p.definition.Steps {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, step.id(), err)
+		}
+		resolved, err := resolvePipelineValue(step.Args, scope)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s): %w", i+1, step.id(), err)
+		}
+		stepArgs, _ := resolved.(map[string]interface{})
+		if stepArgs == nil {
+			stepArgs = map[string]interface{}{}
+		}
+		result, err := p.execute(ctx, step.Tool, stepArgs)
+		if err != nil {
+			return nil, fmt.Errorf("step %d (%s) failed: %w", i+1, step.id(), err)
+		}
+		if last, err = normalizeJSON(result); err != nil {
+			return nil, fmt.Errorf("step %d (%s): invalid result: %w", i+1, step.id(), err)
+		}
+		steps[step.id()] = last
+		p.logger.Debug("Pipeline step completed", "pipeline", p.definition.Name, "step", step.id(), "tool", step.Tool)
+	}
+
+	output := last
+	if p.definition.Output != nil {
+		if output, err = resolvePipelineValue(p.definition.Output, scope); err != nil {
+			return nil, fmt.Errorf("output: %w", err)
+		}
+	}
+	if result, ok := output.(map[string]interface{}); ok {
+		return result, nil
+	}
+	return map[string]interface{}{"result": output}, nil
+}
+
+// isPipelinePath reports whether a string is a whole JSONPath reference.
+func isPipelinePath(s string) bool {
+	return s == "$" || strings.HasPrefix(s, "$.") || strings.HasPrefix(s, "$[")
+}
+
+// checkPipelineRefs parses every reference in value and checks that $.steps references
+// name a step in defined.
+func checkPipelineRefs(value interface{}, defined map[string]bool) error {
+	check := func(path string) error {
+		steps, err := parseJSONPath(path)
+		if err != nil {
+			return err
+		}
+		if len(steps) == 0 || steps[0].isIndex || steps[0].wildcard || (steps[0].key != "input" && steps[0].key != "steps") {
+			return fmt.Errorf("reference %s must start with $.input or $.steps", path)
+		}
+		if steps[0].key == "steps" && (len(steps) < 2 || !defined[steps[1].key]) {
+			return fmt.Errorf("reference %s does not name an earlier step", path)
+		}
+		return nil
+	}
+	switch v := value.(type) {
+	case string:
+		if isPipelinePath(v) {
+			return check(v)
+		}
+		for _, match := range pipelineRefPattern.FindAllStringSubmatch(v, -1) {
+			if err := check(match[1]); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := checkPipelineRefs(item, defined); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkPipelineRefs(item, defined); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolvePipelineValue replaces the references in value with what they select in scope.
+func resolvePipelineValue(value interface{}, scope map[string]interface{}) (interface{}, error) {
+	lookup := func(path string) (interface{}, error) {
+		steps, err := parseJSONPath(path)
+		if err != nil {
+			return nil, err
+		}
+		result, err := evalJSONPath(steps, scope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return result, nil
+	}
+	switch v := value.(type) {
+	case string:
+		if isPipelinePath(v) {
+			return lookup(v)
+		}
+		var lookupErr error
+		text := pipelineRefPattern.ReplaceAllStringFunc(v, func(ref string) string {
+			result, err := lookup(pipelineRefPattern.FindStringSubmatch(ref)[1])
+			if err != nil {
+				lookupErr = err
+				return ""
+			}
+			if s, ok := result.(string); ok {
+				return s
+			}
+			encoded, _ := json.Marshal(result)
+			return string(encoded)
+		})
+		return text, lookupErr
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			value, err := resolvePipelineValue(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = value
+		}
+		return resolved, nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			value, err := resolvePipelineValue(item, scope)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = value
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// normalizeJSON converts a value to the form encoding/json decodes into, so typed Go
+// results such as []string can be navigated with JSONPath.
+func normalizeJSON(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	return decoded, nil
+}
@@ -0,0 +1,156 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	// The executor stands in for the tool service: search returns a list, and upper and
+	// echo transform their arguments.
+	type callerKey struct{}
+	var calls []map[string]interface{}
+	var callers []interface{}
+	execute := func(ctx context.Context, name string, args map[string]interface{}) (map[string]interface{}, error) {
+		calls = append(calls, args)
+		callers = append(callers, ctx.Value(callerKey{}))
+		switch name {
+		case "search":
+			return map[string]interface{}{"items": []map[string]string{
+				{"title": "first", "url": "https://example.com/1"},
+				{"title": "second", "url": "https://example.com/2"},
+			}}, nil
+		case "upper":
+			text, _ := args["text"].(string)
+			return map[string]interface{}{"text": strings.ToUpper(text)}, nil
+		case "echo":
+			return args, nil
+		default:
+			return nil, errors.New("tool not found: " + name)
+		}
+	}
+
+	t.Run("maps outputs to inputs", func(t *testing.T) {
+		calls = nil
+		pipeline, err := NewPipeline(PipelineDefinition{
+			Name:        "top_result",
+			Description: "Searches and shouts the top result",
+			Steps: []PipelineStep{
+				{Tool: "search", Args: map[string]interface{}{"query": "$.input.query", "limit": 2}},
+				{ID: "shout", Tool: "upper", Args: map[string]interface{}{"text": "top: {{$.steps.search.items[0].title}}"}},
+			},
+			Output: map[string]interface{}{
+				"headline": "$.steps.shout.text",
+				"urls":     "$.steps.search.items[*].url",
+			},
+		}, execute, logger)
+		if err != nil {
+			t.Fatalf("NewPipeline failed: %v", err)
+		}
+
+		result, err := pipeline.Execute(map[string]interface{}{"query": "golang"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if calls[0]["query"] != "golang" || calls[0]["limit"] != 2 {
+			t.Errorf("Unexpected search arguments: %v", calls[0])
+		}
+		if result["headline"] != "TOP: FIRST" {
+			t.Errorf("Unexpected headline: %v", result["headline"])
+		}
+		urls, _ := result["urls"].([]interface{})
+		if len(urls) != 2 || urls[1] != "https://example.com/2" {
+			t.Errorf("Unexpected urls: %v", result["urls"])
+		}
+	})
+
+	t.Run("returns the last result by default and keeps types", func(t *testing.T) {
+		pipeline, _ := NewPipeline(PipelineDefinition{
+			Name:        "passthrough",
+			Description: "Echoes typed values",
+			Steps:       []PipelineStep{{Tool: "echo", Args: map[string]interface{}{"n": "$.input.n", "tags": []interface{}{"$.input.tag", "fixed"}}}},
+		}, execute, logger)
+		result, err := pipeline.Execute(map[string]interface{}{"n": 3.0, "tag": "x"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if result["n"] != 3.0 {
+			t.Errorf("Expected the number to pass through, got %v", result["n"])
+		}
+		if tags, _ := result["tags"].([]interface{}); len(tags) != 2 || tags[0] != "x" {
+			t.Errorf("Unexpected tags: %v", result["tags"])
+		}
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		calls = nil
+		pipeline, _ := NewPipeline(PipelineDefinition{
+			Name:        "broken",
+			Description: "Calls a missing tool",
+			Steps:       []PipelineStep{{Tool: "missing"}, {Tool: "echo"}},
+		}, execute, logger)
+		if _, err := pipeline.Execute(nil); err == nil || !strings.Contains(err.Error(), "step 1 (missing)") {
+			t.Errorf("Expected the failing step to be named, got %v", err)
+		}
+		if len(calls) != 1 {
+			t.Errorf("Expected later steps to be skipped, got %d calls", len(calls))
+		}
+
+		pipeline, _ = NewPipeline(PipelineDefinition{
+			Name:        "missing_input",
+			Description: "References a missing argument",
+			Steps:       []PipelineStep{{Tool: "echo", Args: map[string]interface{}{"x": "$.input.absent"}}},
+		}, execute, logger)
+		if _, err := pipeline.Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected a missing input to fail")
+		}
+	})
+
+	t.Run("runs steps with the caller's context", func(t *testing.T) {
+		calls, callers = nil, nil
+		pipeline, _ := NewPipeline(PipelineDefinition{
+			Name:        "twice",
+			Description: "Echoes twice",
+			Steps:       []PipelineStep{{ID: "first", Tool: "echo"}, {ID: "second", Tool: "echo"}},
+		}, execute, logger)
+		ctx := context.WithValue(context.Background(), callerKey{}, "team-a")
+		if _, err := pipeline.ExecuteContext(ctx, nil); err != nil {
+			t.Fatalf("ExecuteContext failed: %v", err)
+		}
+		if len(callers) != 2 || callers[0] != "team-a" || callers[1] != "team-a" {
+			t.Errorf("Expected every step to see the caller, got %v", callers)
+		}
+
+		calls = nil
+		canceled, cancel := context.WithCancel(ctx)
+		cancel()
+		if _, err := pipeline.ExecuteContext(canceled, nil); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected a canceled pipeline to stop, got %v", err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("Expected no steps to run after cancellation, got %d", len(calls))
+		}
+	})
+
+	t.Run("validates definitions", func(t *testing.T) {
+		for _, definition := range []PipelineDefinition{
+			{Description: "No name", Steps: []PipelineStep{{Tool: "echo"}}},
+			{Name: "p", Description: "No steps"},
+			{Name: "p", Description: "Calls itself", Steps: []PipelineStep{{Tool: "p"}}},
+			{Name: "p", Description: "Duplicate ids", Steps: []PipelineStep{{Tool: "echo"}, {Tool: "echo"}}},
+			{Name: "p", Description: "Forward reference", Steps: []PipelineStep{{Tool: "echo", Args: map[string]interface{}{"x": "$.steps.later.y"}}, {ID: "later", Tool: "echo"}}},
+			{Name: "p", Description: "Unknown root", Steps: []PipelineStep{{Tool: "echo", Args: map[string]interface{}{"x": "{{$.env.HOME}}"}}}},
+			{Name: "p", Description: "Bad path", Steps: []PipelineStep{{Tool: "echo", Args: map[string]interface{}{"x": "$.input[abc]"}}}},
+		} {
+			if _, err := NewPipeline(definition, execute, logger); err == nil {
+				t.Errorf("Expected %q to be invalid", definition.Description)
+			}
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ErrQuotaExceeded matches every QuotaError with errors.Is.
+var ErrQuotaExceeded = errors.New("resource quota exceeded")
+
+// QuotaResource names a resource limited by a Quota.
+type QuotaResource string
+
+// Resources limited by a Quota.
+const (
+	QuotaTimeLimit QuotaResource = "time_limit"
+	QuotaCPUTime   QuotaResource = "cpu_time"
+	QuotaMemory    QuotaResource = "memory_bytes"
+	QuotaOutput    QuotaResource = "output_bytes"
+)
+
+// Quota limits the resources one tool execution may use. Zero fields are unlimited.
+// CPUTime and MaxMemoryBytes only apply to processes a tool starts, such as exec tools'.
+type Quota struct {
+	TimeLimit      time.Duration // Elapsed time the execution may take
+	CPUTime        time.Duration // User and system CPU time a started process may use
+	MaxMemoryBytes int64         // Address space a started process may map
+	MaxOutputBytes int64         // Largest encoded result or output stream
+}
+
+// QuotaError reports an execution that went over its quota.
+type QuotaError struct {
+	Resource QuotaResource
+	Limit    int64 // Milliseconds for QuotaTimeLimit and QuotaCPUTime, bytes otherwise
+}
+
+// Error returns the error's message
+func (e *QuotaError) Error() string {
+	switch e.Resource {
+	case QuotaTimeLimit:
+		return fmt.Sprintf("%s: time limit of %s reached", ErrQuotaExceeded, time.Duration(e.Limit)*time.Millisecond)
+	case QuotaCPUTime:
+		return fmt.Sprintf("%s: CPU time limit of %s reached", ErrQuotaExceeded, time.Duration(e.Limit)*time.Millisecond)
+	}
+	return fmt.Sprintf("%s: %s limit of %d reached", ErrQuotaExceeded, e.Resource, e.Limit)
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+type quotaKey struct{}
+
+// WithQuota returns a context carrying the quota of a tool execution.
+func WithQuota(ctx context.Context, quota Quota) context.Context {
+	return context.WithValue(ctx, quotaKey{}, quota)
+}
+
+// QuotaFromContext returns the quota of the execution running with ctx. Tools that start
+// processes or runtimes use it to apply the same limits there, for example with
+// OutputWriter. The time limit is also the deadline of ctx.
+func QuotaFromContext(ctx context.Context) (Quota, bool) {
+	quota, ok := ctx.Value(quotaKey{}).(Quota)
+	return quota, ok
+}
+
+// LimitedWriter passes at most a fixed number of bytes to an underlying writer. Writes past
+// the limit write what still fits and fail with a QuotaError, so a tool capturing a
+// process's or runtime's output cannot buffer more than its quota allows.
+type LimitedWriter struct {
+	w        io.Writer
+	limit    int64
+	written  int64
+	exceeded bool
+}
+
+// NewLimitedWriter returns a LimitedWriter passing up to limit bytes to w.
+func NewLimitedWriter(w io.Writer, limit int64) *LimitedWriter {
+	return &LimitedWriter{w: w, limit: limit}
+}
+
+// OutputWriter wraps w in a LimitedWriter when ctx's quota limits output, and otherwise
+// returns w.
+func OutputWriter(ctx context.Context, w io.Writer) io.Writer {
+	if quota, ok := QuotaFromContext(ctx); ok && quota.MaxOutputBytes > 0 {
+		return NewLimitedWriter(w, quota.MaxOutputBytes)
+	}
+	return w
+}
+
+// Write implements io.Writer.
+func (l *LimitedWriter) Write(p []byte) (int, error) {
+	if remaining := l.limit - l.written; int64(len(p)) > remaining {
+		n, err := l.w.Write(p[:max(remaining, 0)])
+		l.written += int64(n)
+		if err != nil {
+			return n, err
+		}
+		l.exceeded = true
+		return n, &QuotaError{Resource: QuotaOutput, Limit: l.limit}
+	}
+	n, err := l.w.Write(p)
+	l.written += int64(n)
+	return n, err
+}
+
+// Written returns the number of bytes passed to the underlying writer.
+func (l *LimitedWriter) Written() int64 {
+	return l.written
+}
+
+// Exceeded reports whether a write went over the limit. Callers that cannot see the
+// write error, such as os/exec copying a process's output, check it instead.
+func (l *LimitedWriter) Exceeded() bool {
+	return l.exceeded
+}
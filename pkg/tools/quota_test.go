@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimitedWriter(t *testing.T) {
+	t.Run("writes within the limit", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewLimitedWriter(&buf, 5)
+		if n, err := w.Write([]byte("hello")); n != 5 || err != nil {
+			t.Fatalf("Expected 5 bytes written, got %d, %v", n, err)
+		}
+		if w.Written() != 5 {
+			t.Errorf("Expected Written 5, got %d", w.Written())
+		}
+	})
+
+	t.Run("writes past the limit fail with a quota error", func(t *testing.T) {
+		var buf bytes.Buffer
+		w := NewLimitedWriter(&buf, 4)
+		n, err := w.Write([]byte("hello"))
+		var quotaErr *QuotaError
+		if n != 4 || !errors.As(err, &quotaErr) || quotaErr.Resource != QuotaOutput || quotaErr.Limit != 4 {
+			t.Fatalf("Expected 4 bytes and an output quota error, got %d, %v", n, err)
+		}
+		if buf.String() != "hell" {
+			t.Errorf("Expected the bytes that fit to be written, got %q", buf.String())
+		}
+		if _, err := w.Write([]byte("!")); !errors.Is(err, ErrQuotaExceeded) {
+			t.Errorf("Expected later writes to fail, got %v", err)
+		}
+	})
+}
+
+func TestOutputWriter(t *testing.T) {
+	var buf bytes.Buffer
+	t.Run("limits output to the context's quota", func(t *testing.T) {
+		ctx := WithQuota(context.Background(), Quota{MaxOutputBytes: 2})
+		if _, ok := OutputWriter(ctx, &buf).(*LimitedWriter); !ok {
+			t.Error("Expected a LimitedWriter")
+		}
+	})
+
+	t.Run("returns the writer without an output quota", func(t *testing.T) {
+		ctx := WithQuota(context.Background(), Quota{TimeLimit: time.Second})
+		if OutputWriter(ctx, &buf) != &buf {
+			t.Error("Expected the writer unchanged")
+		}
+	})
+}
+
+func TestQuotaError(t *testing.T) {
+	err := &QuotaError{Resource: QuotaTimeLimit, Limit: 1500}
+	if got := err.Error(); got != "resource quota exceeded: time limit of 1.5s reached" {
+		t.Errorf("Unexpected message %q", got)
+	}
+	err = &QuotaError{Resource: QuotaCPUTime, Limit: 250}
+	if got := err.Error(); got != "resource quota exceeded: CPU time limit of 250ms reached" {
+		t.Errorf("Unexpected message %q", got)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package tools
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// limitProcess applies the quota's CPU time and memory limits to a started process with
+// prlimit. RLIMIT_CPU counts whole seconds, so the soft limit is the budget rounded up,
+// at which the kernel sends SIGXCPU, and the hard limit a second later kills the process.
+// RLIMIT_AS caps the address space, so allocations past MaxMemoryBytes fail. Both are
+// inherited by processes it starts afterwards.
+func limitProcess(pid int, quota Quota) error {
+	if quota.CPUTime > 0 {
+		seconds := uint64((quota.CPUTime + time.Second - 1) / time.Second)
+		if err := prlimit(pid, unix.RLIMIT_CPU, seconds, seconds+1); err != nil {
+			return err
+		}
+	}
+	if quota.MaxMemoryBytes > 0 {
+		limit := uint64(quota.MaxMemoryBytes)
+		if err := prlimit(pid, unix.RLIMIT_AS, limit, limit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prlimit sets a resource limit of pid. A process that already exited has nothing left
+// to limit.
+func prlimit(pid, resource int, soft, hard uint64) error {
+	err := unix.Prlimit(pid, resource, &unix.Rlimit{Cur: soft, Max: hard}, nil)
+	if errors.Is(err, unix.ESRCH) {
+		return nil
+	}
+	return err
+}
+
+// maxRSS returns the peak resident memory in bytes of an exited process and the
+// descendants it waited for. Linux reports it in kilobytes.
+func maxRSS(state *os.ProcessState) int64 {
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok {
+		return usage.Maxrss * 1024
+	}
+	return 0
+}
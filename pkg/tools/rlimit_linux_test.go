@@ -0,0 +1,49 @@
+//go:build linux
+
+package tools
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecQuotaLimits(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	t.Run("applies rlimits to the process", func(t *testing.T) {
+		tool, _ := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "limits", Description: "Prints limits",
+			Command: []string{"sh", "-c", "sleep 0.5; ulimit -t; ulimit -v"},
+		}, logger)
+		ctx := WithQuota(context.Background(), Quota{CPUTime: 1500 * time.Millisecond, MaxMemoryBytes: 256 << 20})
+		result, err := tool.ExecuteContext(ctx, nil)
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		if got := strings.Fields(result["stdout"].(string)); len(got) != 2 || got[0] != "2" || got[1] != "262144" {
+			t.Errorf("Expected a 2s CPU limit and a 262144 KiB memory limit, got %q", got)
+		}
+	})
+
+	t.Run("stops a process over its CPU time", func(t *testing.T) {
+		tool, _ := NewDeclarativeTool(Definition{
+			Type: DefinitionExec, Name: "spin", Description: "Spins", Command: []string{"sh", "-c", "while :; do :; done"},
+		}, logger)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_, err := tool.ExecuteContext(WithQuota(ctx, Quota{CPUTime: 200 * time.Millisecond}), nil)
+		var quotaErr *QuotaError
+		if !errors.As(err, &quotaErr) || quotaErr.Resource != QuotaCPUTime || quotaErr.Limit != 200 {
+			t.Errorf("Expected a CPU time quota error, got %v", err)
+		}
+	})
+}
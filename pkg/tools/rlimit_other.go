@@ -0,0 +1,16 @@
+//go:build !linux
+
+package tools
+
+import "os"
+
+// limitProcess does nothing where prlimit is unavailable. CPU time is still checked once
+// the process exits, but memory is not limited.
+func limitProcess(pid int, quota Quota) error {
+	return nil
+}
+
+// maxRSS returns 0, since peak memory is not reported in the same unit everywhere.
+func maxRSS(state *os.ProcessState) int64 {
+	return 0
+}
@@ -0,0 +1,39 @@
+package tools
+
+import "context"
+
+// StreamEmitter receives a tool's incremental output while it runs, for callers that
+// stream it to their client. It may be called from any goroutine.
+type StreamEmitter interface {
+	// Progress reports that progress units of work out of total are done; total is 0
+	// when it is not known
+	Progress(progress, total float64, message string)
+	// Partial delivers part of the result ahead of the final one
+	Partial(result map[string]interface{})
+}
+
+type streamKey struct{}
+
+// WithStreamEmitter returns a context whose tool execution sends its incremental output
+// to emitter.
+func WithStreamEmitter(ctx context.Context, emitter StreamEmitter) context.Context {
+	return context.WithValue(ctx, streamKey{}, emitter)
+}
+
+// ReportProgress reports how far the execution running with ctx has got. Streaming-capable
+// tools, which are ContextTools, call it as they work; it does nothing when the caller is
+// not streaming, so tools need not check.
+func ReportProgress(ctx context.Context, progress, total float64, message string) {
+	if emitter, ok := ctx.Value(streamKey{}).(StreamEmitter); ok {
+		emitter.Progress(progress, total, message)
+	}
+}
+
+// EmitPartial sends part of the result of the execution running with ctx to a streaming
+// caller, such as a batch of rows before the rest are ready. The final result is still
+// returned from ExecuteContext as usual. It does nothing when the caller is not streaming.
+func EmitPartial(ctx context.Context, result map[string]interface{}) {
+	if emitter, ok := ctx.Value(streamKey{}).(StreamEmitter); ok {
+		emitter.Partial(result)
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"testing"
+)
+
+// recordingEmitter records what a tool emits.
+type recordingEmitter struct {
+	progress []float64
+	partials []map[string]interface{}
+}
+
+func (e *recordingEmitter) Progress(progress, total float64, message string) {
+	e.progress = append(e.progress, progress)
+}
+
+func (e *recordingEmitter) Partial(result map[string]interface{}) {
+	e.partials = append(e.partials, result)
+}
+
+func TestStreamEmitter(t *testing.T) {
+	t.Run("delivers to the context's emitter", func(t *testing.T) {
+		emitter := &recordingEmitter{}
+		ctx := WithStreamEmitter(context.Background(), emitter)
+		ReportProgress(ctx, 1, 3, "working")
+		EmitPartial(ctx, map[string]interface{}{"row": 1})
+		if len(emitter.progress) != 1 || emitter.progress[0] != 1 || len(emitter.partials) != 1 {
+			t.Errorf("Expected one progress report and one partial result, got %v and %v", emitter.progress, emitter.partials)
+		}
+	})
+
+	t.Run("does nothing without an emitter", func(t *testing.T) {
+		ReportProgress(context.Background(), 1, 0, "")
+		EmitPartial(context.Background(), map[string]interface{}{"row": 1})
+	})
+}
@@ -0,0 +1,364 @@
+package tools
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenizerProfile approximates how a tokenizer splits text. Real tokenizers ship large
+// vocabularies; these averages are close enough for sizing chunks without them.
+type tokenizerProfile struct {
+	wholeWord          int     // Longest Latin-script word usually encoded as one token
+	charsPerToken      float64 // Letters and digits per extra token in longer words
+	tokensPerCJK       float64 // Tokens per Chinese, Japanese, or Korean character
+	otherCharsPerToken float64 // Letters per token in other scripts, such as Cyrillic or Arabic
+}
+
+// tokenizerProfiles lists the tokenizers text_stats estimates for.
+var tokenizerProfiles = map[string]tokenizerProfile{
+	"cl100k_base": {wholeWord: 8, charsPerToken: 4.0, tokensPerCJK: 1.1, otherCharsPerToken: 2.5}, // GPT-4, GPT-3.5
+	"o200k_base":  {wholeWord: 9, charsPerToken: 4.4, tokensPerCJK: 0.8, otherCharsPerToken: 3.5}, // GPT-4o and later
+	"claude":      {wholeWord: 7, charsPerToken: 3.8, tokensPerCJK: 1.2, otherCharsPerToken: 2.5},
+	"llama":       {wholeWord: 6, charsPerToken: 3.5, tokensPerCJK: 1.5, otherCharsPerToken: 2.0}, // SentencePiece vocabularies
+}
+
+// languageStopwords holds frequent words that identify Latin-script languages.
+var languageStopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "was", "with", "as", "on", "are", "this", "be", "by", "not", "you", "have"},
+	"es": {"el", "la", "de", "que", "y", "en", "los", "se", "del", "las", "por", "un", "una", "con", "para", "es", "no", "su", "al", "lo"},
+	"fr": {"le", "la", "les", "de", "et", "des", "est", "un", "une", "du", "en", "que", "qui", "dans", "pour", "pas", "sur", "au", "avec", "il"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "zu", "den", "von", "mit", "sich", "des", "auf", "ein", "eine", "ich", "dem", "es", "im", "auch"},
+	"it": {"il", "di", "che", "e", "la", "per", "un", "non", "in", "sono", "una", "del", "della", "le", "con", "gli", "si", "è", "da", "anche"},
+	"pt": {"o", "de", "que", "e", "do", "da", "em", "um", "para", "não", "uma", "os", "no", "se", "na", "por", "com", "mais", "as", "dos"},
+	"nl": {"de", "het", "en", "van", "een", "is", "dat", "niet", "ik", "zijn", "op", "te", "met", "voor", "die", "er", "maar", "ook", "aan", "wordt"},
+}
+
+// languageNames maps the ISO 639-1 codes text_stats reports to English names.
+var languageNames = map[string]string{
+	"en": "English", "es": "Spanish", "fr": "French", "de": "German", "it": "Italian", "pt": "Portuguese",
+	"nl": "Dutch", "zh": "Chinese", "ja": "Japanese", "ko": "Korean", "ru": "Russian", "ar": "Arabic",
+	"he": "Hebrew", "el": "Greek", "hi": "Hindi", "th": "Thai", "und": "Undetermined",
+}
+
+// scriptLanguages maps scripts used by one main language to that language.
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	script   string
+	language string
+}{
+	{unicode.Hangul, "Hangul", "ko"},
+	{unicode.Cyrillic, "Cyrillic", "ru"},
+	{unicode.Arabic, "Arabic", "ar"},
+	{unicode.Hebrew, "Hebrew", "he"},
+	{unicode.Greek, "Greek", "el"},
+	{unicode.Devanagari, "Devanagari", "hi"},
+	{unicode.Thai, "Thai", "th"},
+}
+
+// TextStats counts characters, words, and lines, estimates LLM token counts, and detects
+// the language of a text, and implements Tool.
+type TextStats struct {
+	logger *slog.Logger
+}
+
+// NewTextStats creates a text statistics tool.
+func NewTextStats(logger *slog.Logger) *TextStats {
+	return &TextStats{logger: logger}
+}
+
+// Name returns the tool's name
+func (s *TextStats) Name() string {
+	return "text_stats"
+}
+
+// Description returns the tool's description
+func (s *TextStats) Description() string {
+	return "Counts characters, words, and lines, estimates LLM token counts, and detects the language of a text"
+}
+
+// Category returns the tool's category
+func (s *TextStats) Category() string {
+	return "text"
+}
+
+// Tags returns the tool's tags
+func (s *TextStats) Tags() []string {
+	return []string{"text", "tokens", "language", "count"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (s *TextStats) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"text": map[string]interface{}{
+				"type":        "string",
+				"description": "Text to analyze",
+			},
+			"chunk_tokens": map[string]interface{}{
+				"type":        "integer",
+				"minimum":     1,
+				"description": "When set, also report how many chunks of this many tokens the text needs per tokenizer",
+			},
+		},
+		"required": []string{"text"},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test
+func (s *TextStats) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"text": "The quick brown fox jumps over the lazy dog."}
+}
+
+// Execute runs the tool with the given arguments
+func (s *TextStats) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	text, ok := args["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing required argument: text")
+	}
+	chunkTokens := intArg(args, "chunk_tokens", 0)
+	if chunkTokens < 0 {
+		return nil, fmt.Errorf("chunk_tokens must be positive")
+	}
+
+	counts := countText(text)
+	tokens := make(map[string]int, len(tokenizerProfiles))
+	for name, profile := range tokenizerProfiles {
+		tokens[name] = counts.estimateTokens(profile)
+	}
+
+	result := map[string]interface{}{
+		"characters": utf8.RuneCountInString(text),
+		"bytes":      len(text),
+		"words":      counts.words,
+		"lines":      countLines(text),
+		"sentences":  counts.sentences,
+		"paragraphs": countParagraphs(text),
+		"tokens":     tokens,
+		"language":   detectLanguage(counts),
+	}
+	if counts.words > 0 {
+		result["average_word_length"] = math.Round(float64(counts.wordRunes)/float64(counts.words)*100) / 100
+	}
+	if chunkTokens > 0 {
+		chunks := make(map[string]int, len(tokens))
+		for name, n := range tokens {
+			chunks[name] = (n + chunkTokens - 1) / chunkTokens
+		}
+		result["chunks"] = chunks
+	}
+	return result, nil
+}
+
+// textCounts holds what one pass over a text finds.
+type textCounts struct {
+	words       int
+	wordRunes   int
+	sentences   int
+	latinWords  []string // Lowercased Latin-script words, for language detection
+	latinRunes  int
+	cjk         int
+	kana        int
+	other       int
+	punctuation int
+	scripts     map[string]int
+}
+
+// countText scans text once. Each Chinese or Japanese character counts as a word, since
+// those languages do not separate words with spaces.
+func countText(text string) textCounts {
+	counts := textCounts{scripts: make(map[string]int)}
+	var word []rune
+	flush := func() {
+		if len(word) == 0 {
+			return
+		}
+		counts.words++
+		counts.wordRunes += len(word)
+		counts.latinRunes += len(word)
+		counts.latinWords = append(counts.latinWords, strings.ToLower(string(word)))
+		word = word[:0]
+	}
+
+	inSentence := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+			flush()
+			counts.words++
+			counts.wordRunes++
+			counts.cjk++
+			if !unicode.Is(unicode.Han, r) {
+				counts.kana++
+			}
+			inSentence = true
+		case unicode.Is(unicode.Latin, r) || unicode.IsDigit(r) || (len(word) > 0 && (r == '\'' || r == '’')):
+			word = append(word, r)
+			inSentence = true
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			// Letters in other scripts form words too, but are weighted separately.
+			flush()
+			counts.other++
+			counts.wordRunes++
+			for _, s := range scriptLanguages {
+				if unicode.Is(s.table, r) {
+					counts.scripts[s.script]++
+				}
+			}
+			if unicode.Is(unicode.Hangul, r) {
+				counts.cjk++
+				counts.other--
+			}
+			inSentence = true
+		default:
+			flush()
+			if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+				counts.punctuation++
+			}
+			if strings.ContainsRune(".!?。！？", r) && inSentence {
+				counts.sentences++
+				inSentence = false
+			}
+		}
+	}
+	flush()
+	if inSentence {
+		counts.sentences++
+	}
+	// Words in other scripts are counted by their space-separated runs.
+	counts.words += countOtherWords(text)
+	return counts
+}
+
+// countOtherWords counts space-separated words written in neither Latin nor CJK scripts.
+func countOtherWords(text string) int {
+	n := 0
+	for _, field := range strings.Fields(text) {
+		for _, r := range field {
+			if unicode.IsLetter(r) && !unicode.Is(unicode.Latin, r) && !unicode.Is(unicode.Han, r) &&
+				!unicode.Is(unicode.Hiragana, r) && !unicode.Is(unicode.Katakana, r) {
+				n++
+				break
+			}
+		}
+	}
+	return n
+}
+
+// estimateTokens applies a tokenizer profile. Common words are single tokens, so a Latin
+// word costs one token plus one per charsPerToken letters past wholeWord; punctuation
+// usually costs a token each.
+func (c textCounts) estimateTokens(profile tokenizerProfile) int {
+	tokens := 0.0
+	for _, word := range c.latinWords {
+		tokens++
+		if extra := utf8.RuneCountInString(word) - profile.wholeWord; extra > 0 {
+			tokens += math.Ceil(float64(extra) / profile.charsPerToken)
+		}
+	}
+	tokens += float64(c.cjk) * profile.tokensPerCJK
+	tokens += float64(c.other) / profile.otherCharsPerToken
+	tokens += float64(c.punctuation)
+	return int(math.Ceil(tokens))
+}
+
+// detectLanguage guesses the language from the dominant script, and for Latin-script text
+// from the share of words that are frequent words of each language.
+func detectLanguage(c textCounts) map[string]interface{} {
+	detected := func(code, script string, confidence float64) map[string]interface{} {
+		return map[string]interface{}{
+			"code":       code,
+			"name":       languageNames[code],
+			"script":     script,
+			"confidence": math.Round(confidence*100) / 100,
+		}
+	}
+	letters := c.latinRunes + c.cjk + c.other
+	if letters == 0 {
+		return detected("und", "", 0)
+	}
+
+	if c.cjk*2 > letters {
+		switch {
+		case c.scripts["Hangul"] > 0 && c.scripts["Hangul"]*2 > c.cjk:
+			return detected("ko", "Hangul", float64(c.scripts["Hangul"])/float64(letters))
+		case c.kana > 0:
+			return detected("ja", "Han", float64(c.cjk)/float64(letters))
+		default:
+			return detected("zh", "Han", float64(c.cjk)/float64(letters))
+		}
+	}
+	if c.other*2 > letters {
+		best, bestCount := "", 0
+		for _, s := range scriptLanguages {
+			if c.scripts[s.script] > bestCount {
+				best, bestCount = s.script, c.scripts[s.script]
+			}
+		}
+		for _, s := range scriptLanguages {
+			if s.script == best {
+				return detected(s.language, s.script, float64(bestCount)/float64(letters))
+			}
+		}
+		return detected("und", "", 0)
+	}
+
+	words := make(map[string]int)
+	for _, word := range c.latinWords {
+		words[word]++
+	}
+	scores := make(map[string]int, len(languageStopwords))
+	codes := make([]string, 0, len(languageStopwords))
+	for code, stopwords := range languageStopwords {
+		for _, stopword := range stopwords {
+			scores[code] += words[stopword]
+		}
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		if scores[codes[i]] != scores[codes[j]] {
+			return scores[codes[i]] > scores[codes[j]]
+		}
+		return codes[i] < codes[j]
+	})
+	best, runnerUp := scores[codes[0]], scores[codes[1]]
+	if best == 0 {
+		return detected("und", "Latin", 0)
+	}
+	// Confidence grows with how much of the text the stopwords cover and with the
+	// margin over the next language.
+	coverage := math.Min(1, float64(best)/float64(len(c.latinWords))*3)
+	margin := float64(best-runnerUp) / float64(best)
+	return detected(codes[0], "Latin", coverage*(0.5+margin/2))
+}
+
+// countLines counts lines; a final line without a newline counts too.
+func countLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	lines := strings.Count(text, "\n")
+	if !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	return lines
+}
+
+// countParagraphs counts runs of non-blank lines.
+func countParagraphs(text string) int {
+	paragraphs := 0
+	inParagraph := false
+	for _, line := range strings.Split(text, "\n") {
+		blank := strings.TrimSpace(line) == ""
+		if !blank && !inParagraph {
+			paragraphs++
+		}
+		inParagraph = !blank
+	}
+	return paragraphs
+}
@@ -0,0 +1,92 @@
+package tools
+
+import (
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func TestTextStats(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	stats := NewTextStats(logger)
+
+	t.Run("counts text", func(t *testing.T) {
+		result, err := stats.Execute(map[string]interface{}{
+			"text": "The quick brown fox jumps over the lazy dog. It was not amused!\n\nThat's all.\n",
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		expected := map[string]interface{}{
+			"characters": 77,
+			"bytes":      77,
+			"words":      15,
+			"lines":      3,
+			"sentences":  3,
+			"paragraphs": 2,
+		}
+		for key, want := range expected {
+			if result[key] != want {
+				t.Errorf("Expected %s %v, got %v", key, want, result[key])
+			}
+		}
+	})
+
+	t.Run("estimates tokens", func(t *testing.T) {
+		result, err := stats.Execute(map[string]interface{}{
+			"text":         "Tokenization estimates should land near the real count.",
+			"chunk_tokens": float64(5),
+		})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		tokens := result["tokens"].(map[string]int)
+		// cl100k_base encodes this sentence as 10 tokens.
+		if n := tokens["cl100k_base"]; n < 8 || n > 13 {
+			t.Errorf("Expected about 10 cl100k_base tokens, got %d", n)
+		}
+		for name := range tokenizerProfiles {
+			if tokens[name] == 0 {
+				t.Errorf("Missing estimate for %s", name)
+			}
+		}
+		chunks := result["chunks"].(map[string]int)
+		if chunks["cl100k_base"] != (tokens["cl100k_base"]+4)/5 {
+			t.Errorf("Unexpected chunk count: %v", chunks)
+		}
+	})
+
+	t.Run("detects languages", func(t *testing.T) {
+		tests := map[string]string{
+			"en": "The weather is nice today and the children are playing in the park with their dog.",
+			"es": "El perro de la casa es muy grande y le gusta jugar en el jardín con los niños.",
+			"fr": "Le chat est sur la table et il regarde les oiseaux dans le jardin avec attention.",
+			"de": "Der Hund ist nicht im Haus, und die Katze schläft auf dem Sofa mit den Kindern.",
+			"ru": "Сегодня хорошая погода, и дети играют в парке.",
+			"zh": "今天天气很好，孩子们在公园里玩。",
+			"ja": "今日は天気がいいので、子供たちは公園で遊んでいます。",
+			"ko": "오늘은 날씨가 좋아서 아이들이 공원에서 놀고 있어요.",
+		}
+		for code, text := range tests {
+			result, err := stats.Execute(map[string]interface{}{"text": text})
+			if err != nil {
+				t.Fatalf("Execute failed: %v", err)
+			}
+			language := result["language"].(map[string]interface{})
+			if language["code"] != code {
+				t.Errorf("Expected %s for %q, got %v", code, text, language)
+			}
+		}
+
+		result, _ := stats.Execute(map[string]interface{}{"text": "12345 !!!"})
+		if language := result["language"].(map[string]interface{}); language["code"] != "und" {
+			t.Errorf("Expected und for text without words, got %v", language)
+		}
+	})
+
+	t.Run("requires text", func(t *testing.T) {
+		if _, err := stats.Execute(map[string]interface{}{}); err == nil {
+			t.Error("Expected missing text to be refused")
+		}
+	})
+}
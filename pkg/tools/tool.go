@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"os"
@@ -13,18 +14,80 @@ type Tool interface {
 	Execute(args map[string]interface{}) (map[string]interface{}, error)
 }
 
+// SchemaProvider is an optional interface for tools that accept arguments. The returned
+// JSON Schema is advertised to clients as the tool's inputSchema.
+type SchemaProvider interface {
+	InputSchema() map[string]interface{}
+}
+
+// OutputSchemaProvider is an optional interface for tools whose results have a stable
+// shape. The returned JSON Schema describes the result object; it is advertised to
+// clients as the tool's outputSchema, and results are checked against it. A nil schema
+// means the tool has none.
+type OutputSchemaProvider interface {
+	OutputSchema() map[string]interface{}
+}
+
+// MetadataProvider is an optional interface for tools that describe how they should be
+// organized. Clients with many tools can group them by category and filter them by tag.
+type MetadataProvider interface {
+	Category() string
+	Tags() []string
+}
+
+// HealthChecker is an optional interface for tools that depend on an external service.
+// The server runs the check periodically and on demand, hides the tool from tool lists
+// while it fails, and only re-enables a quarantined tool once it passes. The check should
+// return when ctx is done.
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// ContextTool is an optional interface for tools that can stop early. The server calls
+// ExecuteContext instead of Execute, with a context that is canceled when the call's
+// time limit is reached and that carries the call's Quota.
+type ContextTool interface {
+	ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error)
+}
+
+// SelfTester is an optional interface for tools that can be smoke tested. SelfTestArgs
+// returns sample arguments that are safe to run against a live deployment: no side
+// effects, no writes, and little cost. The tool passes its self-test when a call with
+// them succeeds. Nil arguments mean the tool has no self-test.
+type SelfTester interface {
+	SelfTestArgs() map[string]interface{}
+}
+
+// ApprovalRequirer is an optional interface for sensitive tools, such as ones that change
+// external systems. Calls to a tool whose RequiresApproval returns true wait for an
+// operator to approve them before they run.
+type ApprovalRequirer interface {
+	RequiresApproval() bool
+}
+
+// DeprecationProvider is an optional interface for tools, usually old versions, that
+// clients should stop using. A non-empty Deprecation is a notice, such as what to use
+// instead, that is listed with the tool and returned with its results.
+type DeprecationProvider interface {
+	Deprecation() string
+}
+
 // ToolBuilder is a function that creates a tool with given dependencies
 type ToolBuilder func(logger *slog.Logger, config map[string]string) (Tool, error)
 
 // ToolRegistry manages tool creation and discovery
 type ToolRegistry struct {
-	builders map[string]ToolBuilder
+	builders   map[string]ToolBuilder
+	prototypes map[string]Tool // Unconfigured tools describing the builders that can be deferred
+	lazy       map[string]bool // Tools built on first use rather than at creation
 }
 
 // NewToolRegistry creates a new tool registry
 func NewToolRegistry() *ToolRegistry {
 	registry := &ToolRegistry{
-		builders: make(map[string]ToolBuilder),
+		builders:   make(map[string]ToolBuilder),
+		prototypes: make(map[string]Tool),
+		lazy:       make(map[string]bool),
 	}
 
 	// Auto-register all known tools
@@ -38,6 +101,61 @@ func (tr *ToolRegistry) registerBuiltinTools() {
 	tr.Register("uuid_gen", func(logger *slog.Logger, config map[string]string) (Tool, error) {
 		return NewUUIDGen(logger), nil
 	})
+
+	// Register checksum verifier (requires a sandbox directory or allowed hosts)
+	tr.Register("verify_checksum", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewChecksumVerifierFromConfig(logger, config)
+	})
+
+	// Register directory hasher (requires a sandbox directory)
+	tr.Register("dir_hash", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewDirHasherFromConfig(logger, config)
+	})
+
+	// Register GeoIP lookup (requires a MaxMind-format database)
+	tr.RegisterDeferrable("geoip", &GeoIP{}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewGeoIPFromConfig(logger, config)
+	})
+
+	// Register the notification tool (requires configured channels)
+	tr.Register("notify", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewNotifyFromConfig(logger, config)
+	})
+
+	// Register the HTML content extractor (URL sources require allowed hosts)
+	tr.Register("extract_content", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewContentExtractorFromConfig(logger, config)
+	})
+
+	// Register the image tool (no config needed)
+	tr.Register("image_ops", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewImageOpsFromConfig(logger, config)
+	})
+
+	// Register the text statistics tool (no config needed)
+	tr.Register("text_stats", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewTextStats(logger), nil
+	})
+
+	// Register the currency converter (requires a rates provider)
+	tr.Register("currency_convert", func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewCurrencyConverterFromConfig(logger, config)
+	})
+
+	// Register the read-only Kubernetes tools (require K8S_TOOLS and cluster access)
+	// Both they and GeoIP can be deferred, since connecting or loading a database can be slow
+	tr.RegisterDeferrable("k8s_get_pods", &KubePods{}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewKubePodsFromConfig(logger, config)
+	})
+	tr.RegisterDeferrable("k8s_pod_logs", &KubeLogs{}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewKubeLogsFromConfig(logger, config)
+	})
+	tr.RegisterDeferrable("k8s_describe", &KubeDescribe{}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewKubeDescribeFromConfig(logger, config)
+	})
+	tr.RegisterDeferrable("k8s_events", &KubeEvents{}, func(logger *slog.Logger, config map[string]string) (Tool, error) {
+		return NewKubeEventsFromConfig(logger, config)
+	})
 }
 
 // Register adds a tool builder to the registry
@@ -45,6 +163,29 @@ func (tr *ToolRegistry) Register(name string, builder ToolBuilder) {
 	tr.builders[name] = builder
 }
 
+// RegisterDeferrable adds a tool builder whose construction can be deferred to first use.
+// The prototype is an unconfigured tool whose Name, Description, InputSchema, Category,
+// and Tags describe the tool until it is built.
+func (tr *ToolRegistry) RegisterDeferrable(name string, prototype Tool, builder ToolBuilder) {
+	tr.builders[name] = builder
+	tr.prototypes[name] = prototype
+}
+
+// SetLazy marks tools to be built on first use, or by warming them up, instead of by
+// CreateAllAvailable. Names of tools that cannot be deferred are returned and stay eager.
+func (tr *ToolRegistry) SetLazy(names []string) []string {
+	var eager []string
+	tr.lazy = make(map[string]bool)
+	for _, name := range names {
+		if _, ok := tr.prototypes[name]; !ok {
+			eager = append(eager, name)
+			continue
+		}
+		tr.lazy[name] = true
+	}
+	return eager
+}
+
 // CreateAllAvailable creates all tools that have their dependencies satisfied
 func (tr *ToolRegistry) CreateAllAvailable(logger *slog.Logger) ([]Tool, error) {
 	// Get all environment variables as config
@@ -54,6 +195,13 @@ func (tr *ToolRegistry) CreateAllAvailable(logger *slog.Logger) ([]Tool, error)
 	var errors []error
 
 	for name, builder := range tr.builders {
+		if tr.lazy[name] {
+			build := func() (Tool, error) { return builder(logger, config) }
+			tools = append(tools, NewLazyTool(tr.prototypes[name], build, logger))
+			logger.Info("Deferred tool construction", "tool", name)
+			continue
+		}
+
 		tool, err := builder(logger, config)
 		if err != nil {
 			logger.Warn("Skipping tool", "tool", name, "reason", err.Error())
@@ -0,0 +1,159 @@
+// Package tooltest provides a configurable fake tool and an in-memory server running it on
+// every transport, so MCP clients can be tested against this server without a deployment.
+package tooltest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Response is a canned outcome of a FakeTool call.
+type Response struct {
+	Result map[string]interface{} // Returned when Err is nil
+	Err    error                  // Returned instead of a result
+	Delay  time.Duration          // Time the call takes before returning
+}
+
+// FakeTool is a tool whose responses are set by the test. Calls take the queued responses
+// in order, then the default response. A FakeTool is safe for concurrent use.
+type FakeTool struct {
+	name        string
+	description string
+	schema      map[string]interface{}
+
+	mu       sync.Mutex
+	fallback Response
+	queue    []Response
+	handler  func(args map[string]interface{}) (map[string]interface{}, error)
+	calls    []map[string]interface{}
+}
+
+// NewFakeTool creates a FakeTool that returns an empty result.
+func NewFakeTool(name string) *FakeTool {
+	return &FakeTool{
+		name:        name,
+		description: "Fake tool for testing",
+		fallback:    Response{Result: map[string]interface{}{}},
+	}
+}
+
+// WithDescription sets the tool's description.
+func (f *FakeTool) WithDescription(description string) *FakeTool {
+	f.description = description
+	return f
+}
+
+// WithInputSchema sets the JSON Schema advertised for the tool's arguments.
+func (f *FakeTool) WithInputSchema(schema map[string]interface{}) *FakeTool {
+	f.schema = schema
+	return f
+}
+
+// Returns sets the result of calls once the queued responses are used up.
+func (f *FakeTool) Returns(result map[string]interface{}) *FakeTool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = Response{Result: result, Delay: f.fallback.Delay}
+	return f
+}
+
+// Fails makes calls fail with err once the queued responses are used up.
+func (f *FakeTool) Fails(err error) *FakeTool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback = Response{Err: err, Delay: f.fallback.Delay}
+	return f
+}
+
+// Delays makes calls take d once the queued responses are used up.
+func (f *FakeTool) Delays(d time.Duration) *FakeTool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fallback.Delay = d
+	return f
+}
+
+// Queue adds responses that the next calls return, one each, before the default.
+func (f *FakeTool) Queue(responses ...Response) *FakeTool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queue = append(f.queue, responses...)
+	return f
+}
+
+// HandleFunc makes calls run handler once the queued responses are used up, after the
+// default delay. It overrides Returns and Fails.
+func (f *FakeTool) HandleFunc(handler func(args map[string]interface{}) (map[string]interface{}, error)) *FakeTool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handler = handler
+	return f
+}
+
+// Name returns the tool's name
+func (f *FakeTool) Name() string {
+	return f.name
+}
+
+// Description returns the tool's description
+func (f *FakeTool) Description() string {
+	return f.description
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (f *FakeTool) InputSchema() map[string]interface{} {
+	if f.schema == nil {
+		return map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return f.schema
+}
+
+// Execute runs the tool with the given arguments
+func (f *FakeTool) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return f.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext records the call and returns the next response, returning early with the
+// context's error if ctx is done during the delay.
+func (f *FakeTool) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	f.mu.Lock()
+	f.calls = append(f.calls, args)
+	response, handler := f.fallback, f.handler
+	if len(f.queue) > 0 {
+		response, handler = f.queue[0], nil
+		f.queue = f.queue[1:]
+	}
+	f.mu.Unlock()
+
+	if response.Delay > 0 {
+		timer := time.NewTimer(response.Delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if handler != nil {
+		return handler(args)
+	}
+	if response.Err != nil {
+		return nil, response.Err
+	}
+	return response.Result, nil
+}
+
+// Calls returns the arguments of every call so far, in order.
+func (f *FakeTool) Calls() []map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]map[string]interface{}(nil), f.calls...)
+}
+
+// Reset forgets recorded calls and queued responses.
+func (f *FakeTool) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls, f.queue = nil, nil
+}
@@ -0,0 +1,86 @@
+package tooltest
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-tools-server/internal/server"
+	"mcp-tools-server/pkg/tools"
+)
+
+// Server runs the given tools behind every transport of this server on loopback
+// listeners. It is closed when the test that created it ends.
+type Server struct {
+	ToolService *server.ToolService
+
+	URL           string // Base URL of the REST API, e.g. URL + "/api/v1/tools/{name}"
+	StreamableURL string // Streamable HTTP MCP endpoint
+	WebSocketURL  string // WebSocket MCP endpoint
+
+	tb     testing.TB
+	logger *slog.Logger
+}
+
+// NewServer starts a Server offering only the given tools. Server logs at warning level
+// and above go to the test log.
+func NewServer(tb testing.TB, fakes ...tools.Tool) *Server {
+	tb.Helper()
+	logger := slog.New(slog.NewTextHandler(testWriter{tb}, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	toolService := server.NewToolServiceWithTools(fakes, logger)
+
+	httpServer := httptest.NewServer(server.NewHTTPServer(toolService, server.WithLogger(logger)).Handler())
+	tb.Cleanup(httpServer.Close)
+	streamableServer := httptest.NewServer(server.NewStreamableHTTPServer(toolService, server.WithLogger(logger)).Handler())
+	tb.Cleanup(streamableServer.Close)
+	webSocketServer := httptest.NewServer(server.NewWebSocketServer(toolService, server.WithLogger(logger)).Handler())
+	tb.Cleanup(webSocketServer.Close)
+
+	return &Server{
+		ToolService:   toolService,
+		URL:           httpServer.URL,
+		StreamableURL: streamableServer.URL + "/mcp",
+		WebSocketURL:  "ws" + strings.TrimPrefix(webSocketServer.URL, "http") + "/ws",
+		tb:            tb,
+		logger:        logger,
+	}
+}
+
+// Stdio starts a stdio MCP session and returns the pipes standing in for the server's
+// stdin and stdout. The session starts with the client's initialize request, as over real
+// stdio, and ends when stdin is closed or the test ends.
+func (s *Server) Stdio() (stdin io.WriteCloser, stdout io.Reader) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	mcpServer := server.NewMCPServer(s.ToolService, s.logger)
+	mcpServer.SetHeartbeat(0)
+	mcpServer.SetIO(inReader, outWriter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = mcpServer.Start(ctx)
+		outWriter.Close()
+	}()
+	s.tb.Cleanup(func() {
+		cancel()
+		inWriter.Close()
+		outReader.Close()
+		<-done
+	})
+	return inWriter, outReader
+}
+
+// testWriter writes server logs to the test log.
+type testWriter struct {
+	tb testing.TB
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.tb.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
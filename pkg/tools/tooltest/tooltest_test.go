@@ -0,0 +1,118 @@
+package tooltest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"mcp-tools-server/pkg/client"
+
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+)
+
+func TestFakeTool(t *testing.T) {
+	t.Run("queued responses come before the default", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		fake := NewFakeTool("fake").Returns(map[string]interface{}{"n": 0}).Queue(
+			Response{Result: map[string]interface{}{"n": 1}},
+			Response{Err: errBoom},
+		)
+		if result, err := fake.Execute(nil); err != nil || result["n"] != 1 {
+			t.Errorf("Expected the first queued result, got %v (%v)", result, err)
+		}
+		if _, err := fake.Execute(nil); !errors.Is(err, errBoom) {
+			t.Errorf("Expected the queued error, got %v", err)
+		}
+		if result, err := fake.Execute(map[string]interface{}{"x": "y"}); err != nil || result["n"] != 0 {
+			t.Errorf("Expected the default result, got %v (%v)", result, err)
+		}
+		if calls := fake.Calls(); len(calls) != 3 || calls[2]["x"] != "y" {
+			t.Errorf("Expected 3 recorded calls, got %v", calls)
+		}
+	})
+
+	t.Run("delays respect the context", func(t *testing.T) {
+		fake := NewFakeTool("slow").Delays(time.Minute)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		if _, err := fake.ExecuteContext(ctx, nil); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected the context's error, got %v", err)
+		}
+	})
+
+	t.Run("handler computes results", func(t *testing.T) {
+		fake := NewFakeTool("echo").HandleFunc(func(args map[string]interface{}) (map[string]interface{}, error) {
+			return args, nil
+		})
+		if result, err := fake.Execute(map[string]interface{}{"a": "b"}); err != nil || result["a"] != "b" {
+			t.Errorf("Expected the arguments echoed, got %v (%v)", result, err)
+		}
+	})
+}
+
+func TestServer(t *testing.T) {
+	fake := NewFakeTool("fake").Returns(map[string]interface{}{"answer": 42.0})
+	srv := NewServer(t, fake)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("REST API", func(t *testing.T) {
+		result, err := client.New(srv.URL, nil).CallTool(ctx, "fake", nil)
+		if err != nil || result["answer"] != 42.0 {
+			t.Errorf("Expected the canned result, got %v (%v)", result, err)
+		}
+	})
+
+	t.Run("Streamable HTTP", func(t *testing.T) {
+		mcpClient := client.NewMCPClient(srv.StreamableURL, nil)
+		tools, err := mcpClient.ListTools(ctx)
+		if err != nil || len(tools) != 1 || tools[0].Name != "fake" {
+			t.Fatalf("Expected only the fake tool, got %v (%v)", tools, err)
+		}
+		if _, err := mcpClient.CallTool(ctx, "fake", nil); err != nil {
+			t.Errorf("CallTool failed: %v", err)
+		}
+	})
+
+	t.Run("WebSocket", func(t *testing.T) {
+		conn, _, err := websocket.Dial(ctx, srv.WebSocketURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to dial: %v", err)
+		}
+		defer conn.Close(websocket.StatusNormalClosure, "")
+		if err := wsjson.Write(ctx, conn, map[string]interface{}{"jsonrpc": "2.0", "id": 1, "method": "tools/call", "params": map[string]interface{}{"name": "fake"}}); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+		var response map[string]interface{}
+		if err := wsjson.Read(ctx, conn, &response); err != nil || response["result"] == nil {
+			t.Errorf("Expected a result, got %v (%v)", response, err)
+		}
+	})
+
+	t.Run("stdio", func(t *testing.T) {
+		stdin, stdout := srv.Stdio()
+		lines := bufio.NewScanner(stdout)
+		for id, method := range []string{"initialize", "tools/list"} {
+			fmt.Fprintf(stdin, `{"jsonrpc":"2.0","id":%d,"method":%q}`+"\n", id, method)
+			if !lines.Scan() {
+				t.Fatalf("Expected a response to %s: %v", method, lines.Err())
+			}
+			var response map[string]interface{}
+			if err := json.Unmarshal(lines.Bytes(), &response); err != nil || response["result"] == nil {
+				t.Errorf("Expected a result for %s, got %s", method, lines.Bytes())
+			}
+		}
+	})
+
+	t.Run("failures map to errors", func(t *testing.T) {
+		fake.Queue(Response{Err: errors.New("unavailable")})
+		if _, err := client.NewMCPClient(srv.StreamableURL, nil).CallTool(ctx, "fake", nil); err == nil {
+			t.Error("Expected the queued failure")
+		}
+	})
+}
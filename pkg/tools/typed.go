@@ -0,0 +1,242 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Typed returns a Tool that calls fn with its arguments decoded into an I and returns
+// fn's O as its result. The input schema is derived from I, which should be a struct:
+//
+//   - Each exported field is a property named by its json tag, or by the field name.
+//     Fields tagged json:"-" are left out.
+//   - Fields are required unless their json tag has omitempty or they are pointers.
+//   - A description tag describes the property, and an enum tag lists its allowed
+//     values, separated by commas.
+//   - Strings, bools, integers, floats, time.Time (a date-time string), slices, maps,
+//     and nested structs map to the matching JSON Schema types.
+//
+// The result is O encoded as a JSON object; an O that is not an object, such as a string,
+// is returned as {"result": value}. The tool's output schema is derived from O the same
+// way, except that every field without omitempty is required, and pointers, slices, and
+// maps may be null. Tools returning map[string]interface{} have no output schema.
+func Typed[I any, O any](name, description string, fn func(ctx context.Context, input I) (O, error)) Tool {
+	return &typedTool[I, O]{
+		name:         name,
+		description:  description,
+		schema:       structSchema(reflect.TypeFor[I]()),
+		outputSchema: resultSchema(reflect.TypeFor[O]()),
+		fn:           fn,
+	}
+}
+
+// typedTool is the Tool Typed returns.
+type typedTool[I any, O any] struct {
+	name         string
+	description  string
+	schema       map[string]interface{}
+	outputSchema map[string]interface{}
+	fn           func(ctx context.Context, input I) (O, error)
+}
+
+// Name returns the tool's name
+func (t *typedTool[I, O]) Name() string {
+	return t.name
+}
+
+// Description returns the tool's description
+func (t *typedTool[I, O]) Description() string {
+	return t.description
+}
+
+// InputSchema returns the schema derived from the input type
+func (t *typedTool[I, O]) InputSchema() map[string]interface{} {
+	return t.schema
+}
+
+// OutputSchema returns the schema derived from the output type
+func (t *typedTool[I, O]) OutputSchema() map[string]interface{} {
+	return t.outputSchema
+}
+
+// Execute calls the tool without a deadline
+func (t *typedTool[I, O]) Execute(args map[string]interface{}) (map[string]interface{}, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext decodes the arguments, calls the tool's function, and encodes its output
+func (t *typedTool[I, O]) ExecuteContext(ctx context.Context, args map[string]interface{}) (map[string]interface{}, error) {
+	var input I
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+	if err := json.Unmarshal(encoded, &input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArguments, err)
+	}
+
+	output, err := t.fn(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if result, ok := any(output).(map[string]interface{}); ok {
+		return result, nil
+	}
+	encoded, err = json.Marshal(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode result: %w", err)
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		var value interface{}
+		if err := json.Unmarshal(encoded, &value); err != nil {
+			return nil, fmt.Errorf("failed to encode result: %w", err)
+		}
+		return map[string]interface{}{"result": value}, nil
+	}
+	return result, nil
+}
+
+// timeType is the reflect.Type of time.Time, which is encoded as a date-time string.
+var timeType = reflect.TypeFor[time.Time]()
+
+// structSchema returns the JSON Schema of an object decoded into a value of type t.
+func structSchema(t reflect.Type) map[string]interface{} {
+	return typeSchema(t, map[reflect.Type]bool{}, false)
+}
+
+// resultSchema returns the JSON Schema of the results a Typed tool returning t produces,
+// or nil when they can hold anything.
+func resultSchema(t reflect.Type) map[string]interface{} {
+	base := t
+	for base.Kind() == reflect.Pointer {
+		base = base.Elem()
+	}
+	switch {
+	case base.Kind() == reflect.Interface:
+		return nil
+	case base.Kind() == reflect.Map && base.Key().Kind() == reflect.String:
+		if base.Elem().Kind() == reflect.Interface {
+			return nil
+		}
+		return typeSchema(base, map[reflect.Type]bool{}, true)
+	case base.Kind() == reflect.Struct && base != timeType:
+		return typeSchema(base, map[reflect.Type]bool{}, true)
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"result": typeSchema(t, map[reflect.Type]bool{}, true)},
+		"required":   []string{"result"},
+	}
+}
+
+// typeSchema returns the JSON Schema of values of type t. seen holds the struct types
+// being described, so recursive types end in an unconstrained schema. output describes
+// values as encoding/json encodes them rather than what it decodes, so nil pointers,
+// slices, and maps may be null.
+func typeSchema(t reflect.Type, seen map[reflect.Type]bool, output bool) map[string]interface{} {
+	schema := nonNullSchema(t, seen, output)
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		if typ, ok := schema["type"].(string); ok && output {
+			schema["type"] = []interface{}{typ, "null"}
+		}
+	}
+	return schema
+}
+
+// nonNullSchema returns the JSON Schema of the values of type t that are not null.
+func nonNullSchema(t reflect.Type, seen map[reflect.Type]bool, output bool) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json encodes byte slices as base64 strings
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem(), seen, output)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem(), seen, output)}
+	case reflect.Struct:
+		if seen[t] {
+			return map[string]interface{}{}
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		properties := make(map[string]interface{})
+		required := []string{}
+		addFields(t, properties, &required, seen, output)
+		schema := map[string]interface{}{"type": "object", "properties": properties}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema
+	}
+	return map[string]interface{}{}
+}
+
+// addFields adds the properties of a struct's fields, including those of embedded
+// structs, as encoding/json would decode them, or encode them for output.
+func addFields(t reflect.Type, properties map[string]interface{}, required *[]string, seen map[reflect.Type]bool, output bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				addFields(embedded, properties, required, seen, output)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := typeSchema(field.Type, seen, output)
+		if description := field.Tag.Get("description"); description != "" {
+			property["description"] = description
+		}
+		if enum := field.Tag.Get("enum"); enum != "" {
+			values := []interface{}{}
+			for _, value := range strings.Split(enum, ",") {
+				values = append(values, strings.TrimSpace(value))
+			}
+			property["enum"] = values
+		}
+		properties[name] = property
+		if !strings.Contains(options, "omitempty") && (output || field.Type.Kind() != reflect.Pointer) {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type typedFilter struct {
+	Max int `json:"max"`
+}
+
+type typedInput struct {
+	Name    string            `json:"name" description:"Report name"`
+	Format  string            `json:"format,omitempty" enum:"csv, json"`
+	Count   int               `json:"count,omitempty"`
+	Ratio   float64           `json:"ratio,omitempty"`
+	Since   *time.Time        `json:"since"`
+	Tags    []string          `json:"tags,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Filter  typedFilter       `json:"filter"`
+	Verbose bool
+	Ignored string `json:"-"`
+}
+
+type typedOutput struct {
+	Summary string `json:"summary"`
+	Total   int    `json:"total"`
+}
+
+func TestTyped(t *testing.T) {
+	t.Run("derives the input schema", func(t *testing.T) {
+		tool := Typed("report", "Builds a report", func(ctx context.Context, input typedInput) (typedOutput, error) {
+			return typedOutput{}, nil
+		})
+		if tool.Name() != "report" || tool.Description() != "Builds a report" {
+			t.Fatalf("unexpected name or description: %q, %q", tool.Name(), tool.Description())
+		}
+		schema := tool.(SchemaProvider).InputSchema()
+		properties := schema["properties"].(map[string]interface{})
+
+		expected := map[string]interface{}{
+			"name":    map[string]interface{}{"type": "string", "description": "Report name"},
+			"format":  map[string]interface{}{"type": "string", "enum": []interface{}{"csv", "json"}},
+			"count":   map[string]interface{}{"type": "integer"},
+			"ratio":   map[string]interface{}{"type": "number"},
+			"since":   map[string]interface{}{"type": "string", "format": "date-time"},
+			"tags":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"labels":  map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "string"}},
+			"Verbose": map[string]interface{}{"type": "boolean"},
+			"filter": map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"max": map[string]interface{}{"type": "integer"}},
+				"required":   []string{"max"},
+			},
+		}
+		if !reflect.DeepEqual(properties, expected) {
+			t.Errorf("unexpected properties: %v", properties)
+		}
+		if required := schema["required"]; !reflect.DeepEqual(required, []string{"name", "filter", "Verbose"}) {
+			t.Errorf("unexpected required properties: %v", required)
+		}
+	})
+
+	t.Run("decodes arguments and encodes the output", func(t *testing.T) {
+		var received typedInput
+		tool := Typed("report", "", func(ctx context.Context, input typedInput) (typedOutput, error) {
+			received = input
+			return typedOutput{Summary: input.Name, Total: input.Count}, nil
+		})
+		args := map[string]interface{}{
+			"name":   "daily",
+			"count":  float64(3),
+			"since":  "2025-01-02T15:04:05Z",
+			"filter": map[string]interface{}{"max": float64(5)},
+		}
+		result, err := tool.Execute(args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(result, map[string]interface{}{"summary": "daily", "total": float64(3)}) {
+			t.Errorf("unexpected result: %v", result)
+		}
+		if received.Since == nil || !received.Since.Equal(time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)) {
+			t.Errorf("unexpected since: %v", received.Since)
+		}
+		if received.Filter.Max != 5 {
+			t.Errorf("expected filter max 5, got %d", received.Filter.Max)
+		}
+
+		// The schema is applied the same way the server applies it
+		validated, err := ValidateArgs(tool.(SchemaProvider).InputSchema(), map[string]interface{}{"name": "daily"})
+		if !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("expected missing filter to be invalid, got %v, %v", validated, err)
+		}
+	})
+
+	t.Run("wraps values that are not objects", func(t *testing.T) {
+		tool := Typed("echo", "", func(ctx context.Context, input struct {
+			Text string `json:"text"`
+		}) (string, error) {
+			return input.Text, nil
+		})
+		result, err := tool.(ContextTool).ExecuteContext(context.Background(), map[string]interface{}{"text": "hi"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result["result"] != "hi" {
+			t.Errorf("unexpected result: %v", result)
+		}
+	})
+
+	t.Run("passes maps through", func(t *testing.T) {
+		tool := Typed("map", "", func(ctx context.Context, input struct{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"ok": true}, nil
+		})
+		result, err := tool.Execute(nil)
+		if err != nil || result["ok"] != true {
+			t.Errorf("unexpected result: %v, %v", result, err)
+		}
+	})
+
+	t.Run("derives the output schema", func(t *testing.T) {
+		tool := Typed("report", "", func(ctx context.Context, input typedInput) (typedOutput, error) {
+			return typedOutput{Summary: input.Name, Total: 2}, nil
+		})
+		schema := tool.(OutputSchemaProvider).OutputSchema()
+		expected := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"summary": map[string]interface{}{"type": "string"},
+				"total":   map[string]interface{}{"type": "integer"},
+			},
+			"required": []string{"summary", "total"},
+		}
+		if !reflect.DeepEqual(schema, expected) {
+			t.Errorf("unexpected output schema: %v", schema)
+		}
+		result, err := tool.Execute(map[string]interface{}{"name": "q3", "filter": map[string]interface{}{}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := ValidateResult(schema, result); err != nil {
+			t.Errorf("expected the result to match its schema: %v", err)
+		}
+
+		wrapped := Typed("list", "", func(ctx context.Context, input struct{}) ([]string, error) {
+			return nil, nil
+		}).(OutputSchemaProvider).OutputSchema()
+		items := wrapped["properties"].(map[string]interface{})["result"].(map[string]interface{})
+		if !reflect.DeepEqual(items["type"], []interface{}{"array", "null"}) {
+			t.Errorf("expected a nullable array result, got %v", wrapped)
+		}
+		if schema := Typed("map", "", func(ctx context.Context, input struct{}) (map[string]interface{}, error) {
+			return nil, nil
+		}).(OutputSchemaProvider).OutputSchema(); schema != nil {
+			t.Errorf("expected no output schema for maps, got %v", schema)
+		}
+	})
+
+	t.Run("rejects arguments that do not decode", func(t *testing.T) {
+		tool := Typed("report", "", func(ctx context.Context, input typedInput) (typedOutput, error) {
+			return typedOutput{}, nil
+		})
+		_, err := tool.Execute(map[string]interface{}{"count": "three"})
+		if !errors.Is(err, ErrInvalidArguments) {
+			t.Errorf("expected ErrInvalidArguments, got %v", err)
+		}
+	})
+
+	t.Run("returns the function's error", func(t *testing.T) {
+		failure := errors.New("boom")
+		tool := Typed("fail", "", func(ctx context.Context, input struct{}) (typedOutput, error) {
+			return typedOutput{}, failure
+		})
+		if _, err := tool.Execute(nil); !errors.Is(err, failure) {
+			t.Errorf("expected the function's error, got %v", err)
+		}
+	})
+}
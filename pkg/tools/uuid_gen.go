@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"fmt"
 	"log/slog"
 
 	"github.com/google/uuid"
@@ -31,6 +32,16 @@ func (g *UUIDGen) GenerateUUID() (string, error) {
 	return u.String(), nil
 }
 
+// GenerateUUIDv7 generates a time-ordered UUID v7 string
+func (g *UUIDGen) GenerateUUIDv7() (string, error) {
+	u, err := uuid.NewV7()
+	if err != nil {
+		g.logger.Error("Failed to generate UUID v7", "error", err)
+		return "", err
+	}
+	return u.String(), nil
+}
+
 // Name returns the tool's name
 func (g *UUIDGen) Name() string {
 	return "generate_uuid"
@@ -41,9 +52,49 @@ func (g *UUIDGen) Description() string {
 	return "Generates a random UUID v4 string"
 }
 
+// Category returns the tool's category
+func (g *UUIDGen) Category() string {
+	return "generators"
+}
+
+// Tags returns the tool's tags
+func (g *UUIDGen) Tags() []string {
+	return []string{"uuid", "identifier"}
+}
+
+// InputSchema returns the JSON Schema for the tool's arguments
+func (g *UUIDGen) InputSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"version": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"v4", "v7"},
+				"description": "UUID version: random (v4) or time-ordered (v7); defaults to v4",
+			},
+		},
+	}
+}
+
+// SelfTestArgs returns safe arguments for a self-test
+func (g *UUIDGen) SelfTestArgs() map[string]interface{} {
+	return map[string]interface{}{"version": "v4"}
+}
+
 // Execute runs the tool with the given arguments
 func (g *UUIDGen) Execute(args map[string]interface{}) (map[string]interface{}, error) {
-	uuid, err := g.GenerateUUID()
+	version, _ := args["version"].(string)
+
+	var uuid string
+	var err error
+	switch version {
+	case "", "v4":
+		uuid, err = g.GenerateUUID()
+	case "v7":
+		uuid, err = g.GenerateUUIDv7()
+	default:
+		return nil, fmt.Errorf("unsupported UUID version: %s", version)
+	}
 	if err != nil {
 		g.logger.Error("Failed to generate UUID", "error", err)
 		return map[string]interface{}{"error": err.Error()}, err
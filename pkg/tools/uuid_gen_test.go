@@ -385,3 +385,36 @@ func TestUUIDGen_ErrorHandling(t *testing.T) {
 		}
 	})
 }
+
+func TestUUIDGen_Versions(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	gen := NewUUIDGen(logger)
+
+	t.Run("v7 produces a version 7 UUID", func(t *testing.T) {
+		result, err := gen.Execute(map[string]interface{}{"version": "v7"})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		id := result["uuid"].(string)
+		if id[14] != '7' {
+			t.Errorf("Expected version nibble 7, got %c in %s", id[14], id)
+		}
+	})
+
+	t.Run("v4 is the default", func(t *testing.T) {
+		result, err := gen.Execute(map[string]interface{}{})
+		if err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		id := result["uuid"].(string)
+		if id[14] != '4' {
+			t.Errorf("Expected version nibble 4, got %c in %s", id[14], id)
+		}
+	})
+
+	t.Run("unsupported version fails", func(t *testing.T) {
+		if _, err := gen.Execute(map[string]interface{}{"version": "v1"}); err == nil {
+			t.Error("Expected error for unsupported version")
+		}
+	})
+}
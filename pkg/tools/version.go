@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionSeparator separates a tool's name from its version in the names of versioned
+// tools, such as convert@v2. Every version of a tool is registered under its own name,
+// and calls to the bare name run the default version.
+const VersionSeparator = "@"
+
+// SplitVersion splits a versioned tool name into the tool's name and version. The version
+// is empty for names without one.
+func SplitVersion(name string) (string, string) {
+	base, version, _ := strings.Cut(name, VersionSeparator)
+	return base, version
+}
+
+// CompareVersions compares two tool versions, returning -1, 0, or +1 as a is older than,
+// the same as, or newer than b. Versions are dot-separated numbers with an optional "v"
+// prefix, such as v2 or 1.10.0, compared number by number; other parts compare as text.
+func CompareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart string
+		if i < len(aParts) {
+			aPart = aParts[i]
+		}
+		if i < len(bParts) {
+			bPart = bParts[i]
+		}
+		aNum, aErr := strconv.Atoi(aPart)
+		bNum, bErr := strconv.Atoi(bPart)
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return compareInts(aNum, bNum)
+			}
+		case aPart == "" && bErr == nil:
+			if bNum != 0 {
+				return -1
+			}
+		case bPart == "" && aErr == nil:
+			if aNum != 0 {
+				return 1
+			}
+		default:
+			if c := strings.Compare(aPart, bPart); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
+
+// compareInts compares two integers like strings.Compare.
+func compareInts(a, b int) int {
+	if a < b {
+		return -1
+	}
+	return 1
+}
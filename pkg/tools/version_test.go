@@ -0,0 +1,39 @@
+package tools
+
+import "testing"
+
+func TestSplitVersion(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"convert@v2":    {"convert", "v2"},
+		"convert":       {"convert", ""},
+		"convert@1.2.0": {"convert", "1.2.0"},
+	} {
+		base, version := SplitVersion(name)
+		if base != want[0] || version != want[1] {
+			t.Errorf("SplitVersion(%q) = %q, %q; want %q, %q", name, base, version, want[0], want[1])
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v2", -1},
+		{"v2", "v10", -1},
+		{"v2", "v2", 0},
+		{"v2", "2.0", 0},
+		{"1.10.0", "1.9.3", 1},
+		{"v1.1", "v1", 1},
+		{"v2-beta", "v2-alpha", 1},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := CompareVersions(tt.b, tt.a); got != -tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
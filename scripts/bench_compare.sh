@@ -0,0 +1,78 @@
+#!/bin/bash
+#
+# Compares two `go test -bench -benchmem` outputs and fails when a benchmark got slower,
+# or allocates more, by more than the threshold. Runs of the same benchmark (-count) are
+# averaged. benchstat, when installed, also prints its statistical comparison.
+#
+# Usage: scripts/bench_compare.sh BASELINE CURRENT [THRESHOLD_PERCENT]
+
+set -euo pipefail
+
+if [ $# -lt 2 ]; then
+    echo "Usage: $0 BASELINE CURRENT [THRESHOLD_PERCENT]" >&2
+    exit 2
+fi
+
+baseline=$1
+current=$2
+threshold=${3:-10}
+
+if command -v benchstat > /dev/null; then
+    benchstat "$baseline" "$current" || true
+    echo
+fi
+
+awk -v threshold="$threshold" '
+FNR == 1 { file++; pkg = "" }
+/^pkg: / { pkg = $2; next }
+/^Benchmark/ {
+    name = $1
+    sub(/-[0-9]+$/, "", name)
+    key = pkg "." name
+    for (i = 3; i < NF; i += 2) {
+        sum[file, key, $(i + 1)] += $i
+        runs[file, key, $(i + 1)]++
+    }
+    if (file == 2) {
+        seen[key] = 1
+    }
+}
+END {
+    units[1] = "ns/op"; units[2] = "B/op"; units[3] = "allocs/op"
+    printf "%-70s %-10s %14s %14s %9s\n", "benchmark", "metric", "baseline", "current", "delta"
+    regressions = 0
+    for (key in seen) {
+        for (u = 1; u <= 3; u++) {
+            unit = units[u]
+            if (!runs[2, key, unit]) {
+                continue
+            }
+            now = sum[2, key, unit] / runs[2, key, unit]
+            if (!runs[1, key, unit]) {
+                printf "%-70s %-10s %14s %14.0f %9s\n", key, unit, "-", now, "new" | "sort"
+                continue
+            }
+            base = sum[1, key, unit] / runs[1, key, unit]
+            if (base > 0) {
+                delta = sprintf("%+.1f%%", (now - base) / base * 100)
+                regressed = (now - base) / base * 100 > threshold
+            } else {
+                delta = now > 0 ? "+inf" : "+0.0%"
+                regressed = now > 0
+            }
+            mark = ""
+            if (regressed) {
+                mark = "  REGRESSED"
+                regressions++
+            }
+            printf "%-70s %-10s %14.0f %14.0f %9s%s\n", key, unit, base, now, delta, mark | "sort"
+        }
+    }
+    close("sort")
+    if (regressions > 0) {
+        printf "\n%d metric(s) regressed by more than %s%%\n", regressions, threshold
+        exit 1
+    }
+    printf "\nNo regressions beyond %s%%\n", threshold
+}
+' "$baseline" "$current"